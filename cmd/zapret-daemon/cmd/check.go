@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the strategy without applying it",
+	Long: `Parse the configured strategy and build the firewall rules and nfqws
+processes starting it would use, without touching the firewall or spawning
+nfqws. Missing list files, invalid ports and nfqws arguments are reported.
+
+Exits with a non-zero status if any rule would fail.`,
+	Args: cobra.NoArgs,
+	RunE: runCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if !cfg.StrategyRunner.Enabled {
+		fmt.Println("⚠ strategy runner is not enabled, checking its config anyway")
+	}
+
+	// Problems are reported below; the log would only repeat them
+	runner, err := strategyrunner.NewRunner(&cfg.StrategyRunner, slog.New(slog.DiscardHandler))
+	if err != nil {
+		return fmt.Errorf("invalid strategy config: %w", err)
+	}
+
+	plan, err := runner.Plan()
+	if err != nil {
+		return fmt.Errorf("check failed: %w", err)
+	}
+
+	for _, msg := range plan.Warnings {
+		fmt.Printf("⚠ %s\n", msg)
+	}
+	for _, msg := range plan.Errors {
+		fmt.Printf("✗ %s\n", msg)
+	}
+	if !plan.Valid() {
+		return fmt.Errorf("%s: %d problems found", plan.StrategyFile, len(plan.Errors))
+	}
+
+	fmt.Printf("✓ %s is valid: %d rules, %d firewall rules, %d processes\n",
+		plan.StrategyFile, len(plan.Rules), len(plan.Firewall), len(plan.Processes))
+
+	fmt.Println("\nStarting the strategy would perform:")
+	for _, op := range plan.Operations {
+		fmt.Printf("  + %-8s %s\n", op.Kind, op.Description)
+	}
+	return nil
+}
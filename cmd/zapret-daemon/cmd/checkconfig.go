@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/schema"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkConfigMigrate bool
+	checkConfigWrite   bool
+)
+
+var checkConfigCmd = &cobra.Command{
+	Use:   "check-config",
+	Short: "Check the config files for errors and outdated keys",
+	Long: `Load and validate the daemon config and the strategy runner config,
+reporting renamed keys with the syntax replacing them.
+
+With --migrate the config files are printed with all renamed keys updated;
+--write rewrites them in place instead, keeping the original as <file>.bak.
+Retired keys have no replacement and must be removed by hand.`,
+	Args: cobra.NoArgs,
+	RunE: runCheckConfig,
+}
+
+func init() {
+	rootCmd.AddCommand(checkConfigCmd)
+	checkConfigCmd.Flags().BoolVar(&checkConfigMigrate, "migrate", false, "print the config files with renamed keys updated")
+	checkConfigCmd.Flags().BoolVar(&checkConfigWrite, "write", false, "with --migrate, rewrite the files in place (backup in <file>.bak)")
+}
+
+func runCheckConfig(cmd *cobra.Command, args []string) error {
+	if checkConfigWrite && !checkConfigMigrate {
+		return errors.New("--write needs --migrate")
+	}
+
+	path := GetConfigPath()
+	if checkConfigMigrate {
+		if err := migrateConfigFile(path, config.Migrations); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	printDeprecations(path, cfg.Deprecations)
+	printNormalizations(path, cfg.Normalizations)
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	fmt.Printf("✓ %s is valid\n", path)
+
+	strategyPath := cfg.StrategyRunner.ConfigPath
+	if _, err := os.Stat(strategyPath); err != nil {
+		if !cfg.StrategyRunner.Enabled {
+			return nil
+		}
+		return fmt.Errorf("strategy runner config: %w", err)
+	}
+	if checkConfigMigrate {
+		if err := migrateConfigFile(strategyPath, strategyrunner.Migrations); err != nil {
+			return err
+		}
+	}
+
+	strategyCfg, err := strategyrunner.LoadStrategyConfig(strategyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load strategy runner config: %w", err)
+	}
+	printDeprecations(strategyPath, strategyCfg.Deprecations)
+	printNormalizations(strategyPath, strategyCfg.Normalizations)
+	if err := strategyCfg.Validate(); err != nil {
+		return fmt.Errorf("invalid strategy runner config: %w", err)
+	}
+	fmt.Printf("✓ %s is valid\n", strategyPath)
+
+	return nil
+}
+
+// printDeprecations prints the deprecation warnings of the config at path.
+func printDeprecations(path string, warnings []string) {
+	for _, w := range warnings {
+		fmt.Printf("⚠ %s: %s\n", path, w)
+	}
+	if len(warnings) > 0 && !checkConfigMigrate {
+		fmt.Println("  run `zapret-daemon check-config --migrate --write` to update the file")
+	}
+}
+
+// printNormalizations prints the values of the config at path that were read
+// after folding look-alike characters.
+func printNormalizations(path string, warnings []string) {
+	for _, w := range warnings {
+		fmt.Printf("⚠ %s: %s (non-ASCII characters normalized)\n", path, w)
+	}
+}
+
+// migrateConfigFile applies migrations to the config file at path. The
+// migrated file is printed, or written in place with --write after saving
+// the original to path.bak.
+func migrateConfigFile(path string, migrations []schema.Migration) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to access %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	migrated, err := schema.Migrate(data, migrations)
+	if err != nil {
+		return fmt.Errorf("%s can't be migrated automatically:\n%w", path, err)
+	}
+	if !migrated.Changed {
+		fmt.Printf("✓ %s has no outdated keys\n", path)
+		return nil
+	}
+
+	if !checkConfigWrite {
+		fmt.Printf("# %s migrated (--write to save):\n%s\n", path, migrated.Data)
+		return nil
+	}
+
+	backup := path + ".bak"
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write backup %s: %w", backup, err)
+	}
+	if err := os.WriteFile(path, migrated.Data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("✓ %s migrated (%d keys), original saved to %s\n", path, len(migrated.Warnings), backup)
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/spf13/cobra"
+)
+
+var cleanupForce bool
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove nfqws processes and firewall rules left by a crashed daemon",
+	Long: `Kill the nfqws processes recorded in the process state file and remove the
+firewall objects recorded in the firewall state file, as well as the table
+and chain with the configured names. The daemon does this itself on start;
+run it to recover by hand after the daemon was killed and won't be started
+again soon.
+
+Refuses to run while a daemon answers on the control socket, whose processes
+would be killed too, unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: runCleanup,
+}
+
+func init() {
+	rootCmd.AddCommand(cleanupCmd)
+	cleanupCmd.Flags().BoolVar(&cleanupForce, "force", false, "clean up even if a daemon is running")
+}
+
+func runCleanup(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if !cleanupForce && daemonRunning(cfg.Server.SocketPath) {
+		return fmt.Errorf("a daemon is listening on %s, stop it first or use --force", cfg.Server.SocketPath)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	runner, err := strategyrunner.NewRunner(&cfg.StrategyRunner, logger)
+	if err != nil {
+		return fmt.Errorf("invalid strategy config: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := runner.Cleanup(ctx)
+	if result != nil {
+		for _, p := range result.Processes {
+			if p.Killed {
+				fmt.Printf("✓ killed nfqws pid %d (queue %d)\n", p.PID, p.QueueNum)
+			} else if p.Skipped != "" {
+				fmt.Printf("  nfqws pid %d (queue %d): %s\n", p.PID, p.QueueNum, p.Skipped)
+			}
+		}
+		if len(result.Processes) == 0 {
+			fmt.Println("✓ no nfqws processes recorded by a previous run")
+		}
+		if result.Firewall != "" {
+			fmt.Printf("✓ removed firewall rules (%s)\n", result.Firewall)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("cleanup failed: %w", err)
+	}
+	return nil
+}
+
+// daemonRunning reports whether a daemon accepts connections on socketPath.
+func daemonRunning(socketPath string) bool {
+	if socketPath == "" {
+		return false
+	}
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
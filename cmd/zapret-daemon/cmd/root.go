@@ -15,6 +15,8 @@ var rootCmd = &cobra.Command{
 	Long: `Zapret daemon is a background service that manages zapret operations.
 It provides a control interface via Unix socket or network connection.`,
 	SilenceUsage: true,
+	// main prints the error
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
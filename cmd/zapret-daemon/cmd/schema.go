@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/schema"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	strategySchema bool
+)
+
+var printSchemaCmd = &cobra.Command{
+	Use:   "print-schema",
+	Short: "Print the JSON schema of the configuration file",
+	Long: `Print the JSON schema of the daemon configuration file (or the strategy
+runner configuration with --strategy) for use by editors.`,
+	RunE: runPrintSchema,
+}
+
+func init() {
+	rootCmd.AddCommand(printSchemaCmd)
+	printSchemaCmd.Flags().BoolVar(&strategySchema, "strategy", false, "print the strategy runner config schema")
+}
+
+func runPrintSchema(cmd *cobra.Command, args []string) error {
+	s := schema.Generate(config.Config{}, "zapret-daemon configuration")
+	if strategySchema {
+		s = schema.Generate(strategyrunner.Config{}, "zapret-ng strategy runner configuration")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"net"
@@ -12,20 +11,28 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/spf13/cobra"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/daemonserver"
+	"github.com/spf13/cobra"
 )
 
+var serveForegroundStrict bool
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the zapret daemon service",
-	Long:  `Start the zapret daemon service and listen for control commands.`,
-	RunE:  runServe,
+	Long: `Start the zapret daemon service and listen for control commands.
+
+The daemon stays in the foreground and only writes logs, to logging.output.
+SIGINT and SIGTERM shut it down, SIGHUP reloads the strategy config and
+SIGUSR2 reopens the log file after rotation.`,
+	RunE: runServe,
 }
 
 func init() {
 	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().BoolVar(&serveForegroundStrict, "foreground-strict", false,
+		"fail instead of warning when the socket permissions can't be set, and exit nonzero when shutdown fails (for runit, s6 and other supervisors)")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -40,11 +47,29 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize logger
-	logger := daemonserver.InitLogger(cfg.Logging.Level, cfg.Logging.Format)
+	logOut, err := daemonserver.OpenLogOutput(cfg.Logging.Output)
+	if err != nil {
+		return err
+	}
+	defer logOut.Close()
+	logger, flushLogs := daemonserver.InitLogger(cfg.Logging, logOut)
+	defer flushLogs()
 	logger.Info("starting zapret daemon",
 		slog.String("socket_path", cfg.Server.SocketPath),
 		slog.String("network_address", cfg.Server.NetworkAddress),
 	)
+	for _, w := range cfg.Deprecations {
+		logger.Warn("deprecated config key, run zapret-daemon check-config --migrate --write to update the file",
+			slog.String("path", GetConfigPath()),
+			slog.String("detail", w),
+		)
+	}
+	for _, w := range cfg.Normalizations {
+		logger.Warn("normalized config value with non-ASCII characters",
+			slog.String("path", GetConfigPath()),
+			slog.String("detail", w),
+		)
+	}
 
 	// Create Twirp server with config
 	twirpServer, daemonSrv, err := daemonserver.NewTwirpServer(logger, cfg)
@@ -52,12 +77,15 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create twirp server: %w", err)
 	}
 
-	// Create HTTP server
+	// Create HTTP server. Write deadlines are managed per request so that
+	// long-running methods like Restart are not cut off mid-response.
+	// ConnContext lets the auth check tell unix socket clients from network ones.
+	drainer := daemonserver.NewDrainHandler(daemonserver.NewHTTPHandler(twirpServer, daemonSrv, &cfg.Server))
 	httpServer := &http.Server{
-		Handler:      twirpServer,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Handler:     drainer,
+		ConnContext: daemonserver.ConnContext,
+		ReadTimeout: cfg.Server.ReadTimeout,
+		IdleTimeout: 60 * time.Second,
 	}
 
 	// Setup listeners
@@ -84,6 +112,10 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 		// Set socket permissions
 		if err := os.Chmod(cfg.Server.SocketPath, cfg.Server.SocketPermissions); err != nil {
+			if serveForegroundStrict {
+				unixListener.Close()
+				return fmt.Errorf("failed to set socket permissions: %w", err)
+			}
 			logger.Warn("failed to set socket permissions",
 				slog.String("path", cfg.Server.SocketPath),
 				slog.String("error", err.Error()),
@@ -101,7 +133,16 @@ func runServe(cmd *cobra.Command, args []string) error {
 		}
 		listeners = append(listeners, tcpListener)
 
-		logger.Info("listening on network", slog.String("address", cfg.Server.NetworkAddress))
+		logger.Info("listening on network",
+			slog.String("address", cfg.Server.NetworkAddress),
+			slog.Bool("auth", cfg.Server.AuthToken != ""),
+		)
+		if cfg.Server.WebUI {
+			logger.Info("web status page enabled", slog.String("url", "http://"+cfg.Server.NetworkAddress+"/"))
+		}
+		if cfg.Server.MetricsEnabled {
+			logger.Info("metrics endpoint enabled", slog.String("url", "http://"+cfg.Server.NetworkAddress+"/metrics"))
+		}
 	}
 
 	// Start serving on all listeners
@@ -114,50 +155,54 @@ func runServe(cmd *cobra.Command, args []string) error {
 		}(listener)
 	}
 
-	// Wait for interrupt signal
+	// Wait for a shutdown signal, handling reload and log reopen signals meanwhile
+	signals := []os.Signal{os.Interrupt, syscall.SIGTERM}
+	if sigReload != nil {
+		signals = append(signals, sigReload, sigReopenLog)
+	}
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	select {
-	case err := <-errChan:
-		// Server error occurred - cleanup before returning
-		logger.Error("server error occurred, cleaning up", slog.String("error", err.Error()))
-		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cleanupCancel()
-		if cleanupErr := daemonSrv.Shutdown(cleanupCtx); cleanupErr != nil {
-			logger.Error("cleanup error", slog.String("error", cleanupErr.Error()))
+	signal.Notify(sigChan, signals...)
+
+	var serveErr error
+wait:
+	for {
+		select {
+		case serveErr = <-errChan:
+			logger.Error("server error occurred, cleaning up", slog.String("error", serveErr.Error()))
+			break wait
+		case sig := <-sigChan:
+			switch sig {
+			case sigReload:
+				logger.Info("received reload signal", slog.String("signal", sig.String()))
+				go daemonSrv.Reload("signal")
+			case sigReopenLog:
+				if err := logOut.Reopen(); err != nil {
+					logger.Error("failed to reopen log file", slog.String("error", err.Error()))
+				} else {
+					logger.Info("reopened log file", slog.String("signal", sig.String()))
+				}
+			default:
+				logger.Info("received shutdown signal", slog.String("signal", sig.String()))
+				break wait
+			}
 		}
-		return err
-	case sig := <-sigChan:
-		logger.Info("received shutdown signal", slog.String("signal", sig.String()))
 	}
 
-	// Graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	logger.Info("shutting down gracefully...", slog.Duration("budget", cfg.Server.ShutdownTimeout))
 
-	logger.Info("shutting down gracefully...")
-
-	// First, shutdown the daemon server to cleanup resources (firewall rules, processes)
-	if err := daemonSrv.Shutdown(shutdownCtx); err != nil {
-		logger.Error("daemon shutdown error", slog.String("error", err.Error()))
-		// Continue with HTTP server shutdown even if daemon shutdown fails
+	// Each phase gets a share of shutdown_timeout. If stopping the runner runs
+	// out of time, processes are killed and firewall rules removed best-effort.
+	err = daemonserver.RunShutdown(cfg.Server.ShutdownTimeout,
+		daemonSrv.ShutdownPhases(drainer, httpServer, cfg.Server.SocketPath),
+		daemonSrv.ForceShutdown, logger)
+	if err != nil {
+		logger.Error("shutdown finished with errors", slog.String("error", err.Error()))
 	}
-
-	// Then shutdown HTTP server
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		logger.Error("http server shutdown error", slog.String("error", err.Error()))
-		return err
+	if serveErr != nil {
+		return serveErr
 	}
-
-	// Cleanup unix socket
-	if cfg.Server.SocketPath != "" {
-		if err := os.RemoveAll(cfg.Server.SocketPath); err != nil {
-			logger.Warn("failed to remove socket file",
-				slog.String("path", cfg.Server.SocketPath),
-				slog.String("error", err.Error()),
-			)
-		}
+	if err != nil && serveForegroundStrict {
+		return fmt.Errorf("shutdown failed: %w", err)
 	}
 
 	logger.Info("daemon stopped")
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals reloading the strategy config and reopening the log file.
+var (
+	sigReload    os.Signal = syscall.SIGHUP
+	sigReopenLog os.Signal = syscall.SIGUSR2
+)
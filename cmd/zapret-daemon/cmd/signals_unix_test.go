@@ -0,0 +1,243 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// daemonArgsEnv makes the test binary run the daemon with these arguments
+// instead of the tests.
+const daemonArgsEnv = "ZAPRET_TEST_DAEMON_ARGS"
+
+func TestMain(m *testing.M) {
+	if args := os.Getenv(daemonArgsEnv); args != "" {
+		rootCmd.SetArgs(strings.Fields(args))
+		if err := Execute(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testDaemon is a zapret-daemon serve process run from the test binary.
+type testDaemon struct {
+	cmd     *exec.Cmd
+	socket  string
+	logFile string
+	stdout  bytes.Buffer
+	stderr  bytes.Buffer
+	done    chan struct{}
+	err     error
+}
+
+// startDaemon runs serve with a config logging to output, where "file" stands
+// for a log file in a temporary directory, and waits until it listens.
+func startDaemon(t *testing.T, output string) *testDaemon {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, t.TempDir() may exceed that
+	dir, err := os.MkdirTemp("", "zapret-daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	d := &testDaemon{
+		socket:  filepath.Join(dir, "daemon.sock"),
+		logFile: filepath.Join(dir, "daemon.log"),
+		done:    make(chan struct{}),
+	}
+	if output == "file" {
+		output = d.logFile
+	}
+	config := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`server:
+  socket_path: %s
+  shutdown_timeout: 5s
+logging:
+  level: info
+  format: text
+  dedup_window: 0s
+  output: %s
+strategy_runner:
+  enabled: false
+`, d.socket, output)
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d.cmd = exec.Command(os.Args[0], "-test.run=^$")
+	d.cmd.Env = append(os.Environ(), daemonArgsEnv+"=serve --config "+config)
+	d.cmd.Stdout = &d.stdout
+	d.cmd.Stderr = &d.stderr
+	if err := d.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		d.err = d.cmd.Wait()
+		close(d.done)
+	}()
+	t.Cleanup(func() {
+		d.cmd.Process.Kill()
+		<-d.done
+	})
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(d.socket); err == nil {
+			return d
+		}
+		select {
+		case <-d.done:
+			t.Fatalf("daemon exited before listening: %v\nstderr: %s", d.err, d.stderr.String())
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("daemon did not create its socket")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// signal sends sig to the daemon.
+func (d *testDaemon) signal(t *testing.T, sig syscall.Signal) {
+	t.Helper()
+	if err := d.cmd.Process.Signal(sig); err != nil {
+		t.Fatalf("signal %v: %v", sig, err)
+	}
+}
+
+// wait waits for the daemon to exit and returns its exit code.
+func (d *testDaemon) wait(t *testing.T) int {
+	t.Helper()
+	select {
+	case <-d.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("daemon did not exit")
+	}
+	var exitErr *exec.ExitError
+	if errors.As(d.err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if d.err != nil {
+		t.Fatal(d.err)
+	}
+	return 0
+}
+
+// waitLog waits until the log file contains want.
+func waitLog(t *testing.T, path, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s does not contain %q:\n%s", path, want, data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSignalShutdown(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGTERM, syscall.SIGINT} {
+		t.Run(sig.String(), func(t *testing.T) {
+			d := startDaemon(t, "file")
+			d.signal(t, sig)
+			if code := d.wait(t); code != 0 {
+				t.Errorf("exit code = %d, want 0\nstderr: %s", code, d.stderr.String())
+			}
+			waitLog(t, d.logFile, "daemon stopped")
+			if _, err := os.Stat(d.socket); !os.IsNotExist(err) {
+				t.Errorf("socket left after shutdown: %v", err)
+			}
+			if d.stdout.Len() > 0 {
+				t.Errorf("daemon wrote to stdout: %s", d.stdout.String())
+			}
+		})
+	}
+}
+
+func TestSignalReload(t *testing.T) {
+	d := startDaemon(t, "file")
+	d.signal(t, syscall.SIGHUP)
+	waitLog(t, d.logFile, "received reload signal")
+	waitLog(t, d.logFile, `nothing to reload" trigger=signal`)
+
+	// The daemon keeps serving after a reload
+	select {
+	case <-d.done:
+		t.Fatalf("daemon exited on SIGHUP: %v", d.err)
+	default:
+	}
+	d.signal(t, syscall.SIGTERM)
+	if code := d.wait(t); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+}
+
+func TestSignalReopenLog(t *testing.T) {
+	d := startDaemon(t, "file")
+	waitLog(t, d.logFile, "listening on unix socket")
+
+	// Rotate the way logrotate does without copytruncate
+	rotated := d.logFile + ".1"
+	if err := os.Rename(d.logFile, rotated); err != nil {
+		t.Fatal(err)
+	}
+	d.signal(t, syscall.SIGUSR2)
+	waitLog(t, d.logFile, "reopened log file")
+
+	d.signal(t, syscall.SIGTERM)
+	if code := d.wait(t); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	waitLog(t, d.logFile, "daemon stopped")
+	if data, _ := os.ReadFile(rotated); strings.Contains(string(data), "daemon stopped") {
+		t.Errorf("logs written to the rotated file after SIGUSR2:\n%s", data)
+	}
+}
+
+func TestLogOutputStderr(t *testing.T) {
+	d := startDaemon(t, "stderr")
+	d.signal(t, syscall.SIGTERM)
+	if code := d.wait(t); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if d.stdout.Len() > 0 {
+		t.Errorf("daemon wrote to stdout: %s", d.stdout.String())
+	}
+	if !strings.Contains(d.stderr.String(), "daemon stopped") {
+		t.Errorf("stderr = %q, want the logs", d.stderr.String())
+	}
+}
+
+func TestServeFatalErrorExitsNonzero(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), daemonArgsEnv+"=serve --config "+filepath.Join(t.TempDir(), "missing.yaml"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() == 0 {
+		t.Fatalf("serve with a missing config: %v, want a nonzero exit", err)
+	}
+	if !strings.HasPrefix(stderr.String(), "Error: ") || stdout.Len() > 0 {
+		t.Errorf("stdout = %q, stderr = %q, want the error on stderr only", stdout.String(), stderr.String())
+	}
+}
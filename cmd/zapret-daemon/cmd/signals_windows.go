@@ -0,0 +1,12 @@
+//go:build windows
+
+package cmd
+
+import "os"
+
+// Signals reloading the strategy config and reopening the log file; Windows
+// has neither.
+var (
+	sigReload    os.Signal
+	sigReopenLog os.Signal
+)
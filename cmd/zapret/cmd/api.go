@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/cmd/zapret/output"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+)
+
+// formatResponses are the responses --format templates of each command are evaluated against.
+var formatResponses = map[string]any{
+	"status":  &daemon.StatusResponse{},
+	"rules":   &daemon.ListRulesResponse{},
+	"inspect": &daemon.SnapshotResponse{},
+}
+
+var apiCmd = &cobra.Command{
+	Use:   "api [command]",
+	Short: "List the fields available to --format templates",
+	Long: `List the response fields a command's --format template can use.
+Fields ending in [] are lists; use them with {{range}}, e.g.
+
+  zapret rules --format '{{range .Rules}}{{.QueueNum}} {{.Ports}}{{"\n"}}{{end}}'`,
+	Args:        cobra.MaximumNArgs(1),
+	ValidArgs:   []string{"status", "rules", "inspect"},
+	RunE:        runAPI,
+	Annotations: map[string]string{annotationLocal: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+}
+
+func runAPI(cmd *cobra.Command, args []string) error {
+	names := make([]string, 0, len(formatResponses))
+	for name := range formatResponses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(args) == 1 {
+		if _, ok := formatResponses[args[0]]; !ok {
+			return fmt.Errorf("unknown command %q (available: %s)", args[0], strings.Join(names, ", "))
+		}
+		names = []string{args[0]}
+	}
+
+	for i, name := range names {
+		if i > 0 {
+			fmt.Println()
+		}
+		fields, err := output.Fields(formatResponses[name])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("zapret %s --format:\n", name)
+		for _, f := range fields {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+	return nil
+}
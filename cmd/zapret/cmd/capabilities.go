@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var capabilitiesJSON bool
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Show what this installation can do",
+	Long: `Print the compiled-in firewall backends and features, kernel capabilities,
+daemon privileges, the nfqws binary and its optional options, and which
+optional subsystems are enabled.`,
+	Args: cobra.NoArgs,
+	RunE: runCapabilities,
+}
+
+func init() {
+	rootCmd.AddCommand(capabilitiesCmd)
+	capabilitiesCmd.Flags().BoolVar(&capabilitiesJSON, "json", false, "print the capabilities as JSON")
+}
+
+// getCapabilities fetches the capabilities from the daemon.
+func getCapabilities() (*daemon.CapabilitiesResponse, error) {
+	client, err := GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetCapabilities(ctx, &daemon.CapabilitiesRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return nil, fmt.Errorf("get capabilities failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return nil, fmt.Errorf("get capabilities failed: %w", err)
+	}
+	return resp, nil
+}
+
+func runCapabilities(cmd *cobra.Command, args []string) error {
+	resp, err := getCapabilities()
+	if err != nil {
+		return err
+	}
+
+	if capabilitiesJSON {
+		data, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			return fmt.Errorf("failed to encode capabilities: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printCapabilities(os.Stdout, resp)
+	return nil
+}
+
+// printCapabilities prints the capabilities grouped by section.
+func printCapabilities(out io.Writer, resp *daemon.CapabilitiesResponse) {
+	fmt.Fprintf(out, "Platform: %s\n", resp.Platform)
+
+	fmt.Fprintln(out, "\nCompiled in:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, f := range resp.Features {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", f.Kind, f.Name, f.Detail)
+	}
+	w.Flush()
+
+	if len(resp.KernelCapabilities) > 0 {
+		fmt.Fprintln(out, "\nKernel:")
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, c := range resp.KernelCapabilities {
+			fmt.Fprintf(w, "  %s %s\t%s\t%s\n", capabilityMark(c.State), c.Name, c.State, c.Detail)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out, "\nPrivileges:")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, p := range resp.Privileges {
+		fmt.Fprintf(w, "  %s %s\t%s\n", checkMark(p.Ok), p.Name, p.Detail)
+	}
+	w.Flush()
+
+	if n := resp.Nfqws; n != nil {
+		fmt.Fprintln(out, "\nnfqws:")
+		fmt.Fprintf(out, "  binary:  %s\n", n.Binary)
+		kind := n.Kind
+		if n.External {
+			kind += " (supervised externally)"
+		}
+		fmt.Fprintf(out, "  kind:    %s\n", kind)
+		if n.Error != "" {
+			fmt.Fprintf(out, "  error:   %s\n", n.Error)
+		} else {
+			fmt.Fprintf(out, "  options: %d\n", n.OptionCount)
+		}
+		if len(n.Supported) > 0 {
+			fmt.Fprintf(out, "  has:     %s\n", strings.Join(n.Supported, ", "))
+		}
+		for _, opt := range n.Unsupported {
+			fmt.Fprintf(out, "  lacks:   %s\n", opt)
+		}
+	}
+
+	fmt.Fprintln(out, "\nSubsystems:")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, sub := range resp.Subsystems {
+		state := "disabled"
+		if sub.Enabled {
+			state = "enabled"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", sub.Name, state, sub.Detail)
+	}
+	w.Flush()
+}
+
+// capabilityMark returns the status mark of a kernel capability state.
+func capabilityMark(state string) string {
+	switch state {
+	case "available":
+		return "✓"
+	case "missing":
+		return "✗"
+	default:
+		return "?"
+	}
+}
+
+// checkMark returns the status mark of a check.
+func checkMark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
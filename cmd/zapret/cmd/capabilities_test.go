@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/daemonserver"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// TestCapabilitiesListEveryFeature checks that every registered feature
+// reaches GetCapabilities and the printed output, so registering a feature
+// is all it takes to report it.
+func TestCapabilitiesListEveryFeature(t *testing.T) {
+	server, err := daemonserver.NewServer(slog.New(slog.DiscardHandler), &config.Config{})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	resp, err := server.GetCapabilities(t.Context(), &daemon.CapabilitiesRequest{})
+	if err != nil {
+		t.Fatalf("GetCapabilities() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	printCapabilities(&buf, resp)
+	out := buf.String()
+
+	compiled := features.Compiled()
+	if len(compiled) == 0 {
+		t.Fatal("no features registered")
+	}
+	if len(resp.Features) != len(compiled) {
+		t.Errorf("GetCapabilities() has %d features, want %d", len(resp.Features), len(compiled))
+	}
+	for _, f := range compiled {
+		listed := slices.ContainsFunc(strings.Split(out, "\n"), func(line string) bool {
+			fields := strings.Fields(line)
+			return len(fields) > 2 && fields[0] == f.Kind && fields[1] == f.Name && strings.HasSuffix(line, f.Detail)
+		})
+		if !listed {
+			t.Errorf("output lacks %s %s (%s):\n%s", f.Kind, f.Name, f.Detail, out)
+		}
+	}
+	for _, p := range resp.Privileges {
+		if !strings.Contains(out, p.Name) {
+			t.Errorf("output lacks privilege %s", p.Name)
+		}
+	}
+	for _, sub := range resp.Subsystems {
+		if !strings.Contains(out, sub.Name) {
+			t.Errorf("output lacks subsystem %s", sub.Name)
+		}
+	}
+}
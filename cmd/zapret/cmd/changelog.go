@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Show the changelog of applied configurations",
+	Long: `Inspect the append-only changelog the daemon writes after every successful
+apply: strategy hash, applied rules, firewall backend and the difference to the
+previous apply. Entries are hash-chained, so edited or removed entries are reported.`,
+}
+
+var changelogShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print changelog entries",
+	Long: `Print changelog entries, oldest first.
+
+--since takes an RFC3339 time (2024-05-01T00:00:00Z) or a duration back from
+now (24h).`,
+	Args: cobra.NoArgs,
+	RunE: runChangelogShow,
+}
+
+var (
+	changelogSince string
+	changelogLimit int
+	changelogJSON  bool
+)
+
+// changelogPageSize is the number of entries requested per RPC call.
+const changelogPageSize = 100
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+	changelogCmd.AddCommand(changelogShowCmd)
+	changelogShowCmd.Flags().StringVar(&changelogSince, "since", "", "only show entries at or after this time or duration ago")
+	changelogShowCmd.Flags().IntVar(&changelogLimit, "limit", 0, "maximum number of entries to show (0 for all)")
+	changelogShowCmd.Flags().BoolVar(&changelogJSON, "json", false, "print entries as JSON lines")
+}
+
+// parseSince parses an RFC3339 time or a duration back from now.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use an RFC3339 time or a duration like 24h", value)
+	}
+	return time.Now().Add(-d), nil
+}
+
+func runChangelogShow(cmd *cobra.Command, args []string) error {
+	since, err := parseSince(changelogSince)
+	if err != nil {
+		return err
+	}
+	var sinceStr string
+	if !since.IsZero() {
+		sinceStr = since.UTC().Format(time.RFC3339)
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var entries []*daemon.ChangelogEntry
+	var chainError string
+	var afterSeq uint64
+	for {
+		resp, err := client.GetChangelog(ctx, &daemon.ChangelogRequest{
+			Since:    sinceStr,
+			AfterSeq: afterSeq,
+			Limit:    changelogPageSize,
+		})
+		if err != nil {
+			if twerr, ok := err.(twirp.Error); ok {
+				return fmt.Errorf("get changelog failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+			}
+			return fmt.Errorf("get changelog failed: %w", err)
+		}
+		entries = append(entries, resp.Entries...)
+		chainError = resp.ChainError
+		if resp.NextSeq == 0 || (changelogLimit > 0 && len(entries) >= changelogLimit) {
+			break
+		}
+		afterSeq = resp.NextSeq
+	}
+	if changelogLimit > 0 && len(entries) > changelogLimit {
+		entries = entries[:changelogLimit]
+	}
+
+	if changelogJSON {
+		for _, e := range entries {
+			data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(e)
+			if err != nil {
+				return fmt.Errorf("failed to encode entry: %w", err)
+			}
+			fmt.Fprintln(os.Stdout, string(data))
+		}
+	} else {
+		printChangelog(entries)
+	}
+
+	if chainError != "" {
+		fmt.Fprintf(os.Stderr, "✗ changelog hash chain is broken: %s\n", chainError)
+		return fmt.Errorf("changelog failed verification")
+	}
+	if !changelogJSON {
+		fmt.Println("✓ hash chain verified")
+	}
+	return nil
+}
+
+// printChangelog prints changelog entries with their differences.
+func printChangelog(entries []*daemon.ChangelogEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No changelog entries")
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Printf("#%d  %s  %s  %d rules  +%d -%d\n", e.Seq, e.Time, e.FirewallBackend, len(e.Rules), len(e.Added), len(e.Removed))
+		strategy := shortHash(e.StrategyHash)
+		if e.StrategyChanged {
+			strategy += " (changed)"
+		}
+		fmt.Printf("    strategy: %s %s\n", e.StrategyFile, strategy)
+		if e.PreviousBackend != "" {
+			fmt.Printf("    backend:  %s -> %s\n", e.PreviousBackend, e.FirewallBackend)
+		}
+		for _, r := range e.Added {
+			fmt.Printf("    + %s %s queue %d args %s\n", r.Protocol, r.Ports, r.Queue, r.ArgsHash)
+		}
+		for _, r := range e.Removed {
+			fmt.Printf("    - %s %s queue %d args %s\n", r.Protocol, r.Ports, r.Queue, r.ArgsHash)
+		}
+	}
+}
+
+// shortHash abbreviates a hex hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
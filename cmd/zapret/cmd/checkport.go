@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var checkPortAllRules bool
+
+var checkPortCmd = &cobra.Command{
+	Use:   "checkport <tcp|udp> <port>",
+	Short: "Show which rule queues a port",
+	Long: `Evaluate the applied rules against a protocol and port and show which rule
+queues its packets, tracing each covering rule's port spec from the strategy
+file through %GameFilter% substitution and alias resolution to the spec the
+firewall rule was generated from. Rules are listed in firewall order; the
+first installed rule covering the port takes its packets.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCheckPort,
+}
+
+func init() {
+	rootCmd.AddCommand(checkPortCmd)
+	checkPortCmd.Flags().BoolVar(&checkPortAllRules, "all-rules", false, "also show rules of the protocol not covering the port")
+}
+
+func runCheckPort(cmd *cobra.Command, args []string) error {
+	port, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid port %q", args[1])
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.CheckPort(ctx, &daemon.CheckPortRequest{Protocol: args[0], Port: int32(port)})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("checkport failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("checkport failed: %w", err)
+	}
+
+	if resp.Queued {
+		fmt.Printf("✓ %s %d is queued to queue %d\n", args[0], port, resp.QueueNum)
+	} else {
+		fmt.Printf("✗ %s %d is not queued by any rule\n", args[0], port)
+	}
+	if resp.ExternalFirewall {
+		fmt.Println("⚠ firewall management is external: this is what the strategy asks for, not what is installed")
+	}
+
+	for _, coverage := range resp.Rules {
+		if !coverage.Covered && !checkPortAllRules {
+			continue
+		}
+		printPortCoverage(coverage)
+	}
+	return nil
+}
+
+// printPortCoverage prints a rule's coverage with the trace of its port spec.
+func printPortCoverage(c *daemon.PortCoverage) {
+	mark := "✗"
+	switch {
+	case c.Queued:
+		mark = "✓"
+	case c.Covered:
+		mark = "⚠"
+	}
+	label := ""
+	if c.Rule.Label != "" {
+		label = ", " + c.Rule.Label
+	}
+	fmt.Printf("\n%s queue %d  (line %d%s)\n", mark, c.Rule.QueueNum, c.Rule.SourceLine, label)
+
+	for _, stage := range c.Stages {
+		var result string
+		switch {
+		case !stage.Resolved:
+			result = "-"
+		case stage.Covered:
+			result = "✓ " + stage.Range
+		default:
+			result = "✗"
+		}
+		fmt.Printf("    %-36s %-32s %s\n", stage.Name, truncate(stage.Spec, 32), result)
+	}
+	if c.Reason != "" {
+		fmt.Printf("    %s\n", c.Reason)
+	}
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// defaultConfigPath is read for contexts when --config is not given.
+const defaultConfigPath = "/etc/zapret-ng/config.yaml"
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage the daemons the CLI talks to",
+	Long: `List and select named daemon contexts from client.contexts in the config.
+
+The selected context is used unless --context, --address or --socket is given.
+--all runs a read-only command against every context at once.`,
+	Annotations: map[string]string{annotationLocal: "true"},
+}
+
+var contextListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List the configured contexts",
+	Args:        cobra.NoArgs,
+	RunE:        runContextList,
+	Annotations: map[string]string{annotationLocal: "true"},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Select the default context",
+	Long: `Store the default context for this user. Use "-" to clear the selection and
+fall back to client.current_context or the server settings.`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runContextUse,
+	Annotations: map[string]string{annotationLocal: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+}
+
+// loadClientConfig loads the config for client settings: the --config file,
+// or the default config when it exists.
+func loadClientConfig() (*config.Config, error) {
+	path := cfgFile
+	if path == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			path = defaultConfigPath
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	for name, ctx := range cfg.Client.Contexts {
+		if err := ctx.Validate(); err != nil {
+			return nil, fmt.Errorf("context %q: %w", name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// contextNames returns the configured context names, sorted.
+func contextNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Client.Contexts))
+	for name := range cfg.Client.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectionPath is the file storing the context chosen by `zapret context use`.
+func selectionPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "zapret-ng", "context"), nil
+}
+
+// storedContext returns the context chosen by `zapret context use`, "" if none.
+func storedContext() string {
+	path, err := selectionPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// currentContext returns the context selected by --context, `zapret context
+// use` or client.current_context, in that order ("" for none).
+func currentContext(cfg *config.Config) string {
+	if contextName != "" {
+		return contextName
+	}
+	if name := storedContext(); name != "" {
+		if _, ok := cfg.Client.Contexts[name]; ok {
+			return name
+		}
+	}
+	return cfg.Client.CurrentContext
+}
+
+// selectedContext returns the context to connect to, "" when none is
+// selected and the server settings of the config apply.
+func selectedContext() (string, config.ContextConfig, error) {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		if contextName != "" {
+			return "", config.ContextConfig{}, err
+		}
+		// Without --context the server settings are used, which report the error
+		return "", config.ContextConfig{}, nil
+	}
+	name := currentContext(cfg)
+	if name == "" {
+		return "", config.ContextConfig{}, nil
+	}
+	ctx, ok := cfg.Client.Contexts[name]
+	if !ok {
+		return "", config.ContextConfig{}, fmt.Errorf("unknown context %q (see `zapret context list`)", name)
+	}
+	return name, ctx, nil
+}
+
+// contextOptions returns the client options for a context. token overrides
+// the token of the context when set.
+func contextOptions(ctx config.ContextConfig, token string) (client.Options, error) {
+	if ctx.Address == "" {
+		return client.Options{Socket: ctx.Socket}, nil
+	}
+	if token == "" {
+		token = ctx.Token
+	}
+	opts := client.Options{Address: ctx.Address, Token: token}
+	if !ctx.TLS {
+		return opts, nil
+	}
+
+	tlsConfig, err := client.LoadTLSConfig(ctx.CAFile, ctx.InsecureSkipVerify)
+	if err != nil {
+		return client.Options{}, err
+	}
+	opts.TLS = tlsConfig
+	return opts, nil
+}
+
+// describeContext returns the connection target of a context.
+func describeContext(ctx config.ContextConfig) string {
+	switch {
+	case ctx.Address == "":
+		return "unix:" + ctx.Socket
+	case ctx.TLS:
+		return "https://" + ctx.Address
+	default:
+		return "http://" + ctx.Address
+	}
+}
+
+func runContextList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	names := contextNames(cfg)
+	if len(names) == 0 {
+		fmt.Println("No contexts configured (client.contexts)")
+		return nil
+	}
+
+	current := currentContext(cfg)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tTARGET\tAUTH")
+	for _, name := range names {
+		ctx := cfg.Client.Contexts[name]
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+		auth := "-"
+		if ctx.Token != "" && ctx.Address != "" {
+			auth = "token"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, name, describeContext(ctx), auth)
+	}
+	return w.Flush()
+}
+
+func runContextUse(cmd *cobra.Command, args []string) error {
+	path, err := selectionPath()
+	if err != nil {
+		return fmt.Errorf("cannot locate the user config directory: %w", err)
+	}
+
+	if args[0] == "-" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear context selection: %w", err)
+		}
+		fmt.Println("✓ context selection cleared")
+		return nil
+	}
+
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Client.Contexts[args[0]]; !ok {
+		return fmt.Errorf("unknown context %q (see `zapret context list`)", args[0])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to store context selection: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(args[0]+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to store context selection: %w", err)
+	}
+	fmt.Printf("✓ using context %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var diagCmd = &cobra.Command{
+	Use:   "diag",
+	Short: "Diagnose common problems with the bypass setup",
+	Long: `Report problems detected by the daemon that commonly make strategies fail,
+such as NIC offloads (GRO/GSO/TSO) that defeat desync, and how to fix them.
+The full capability summary is printed by 'zapret capabilities'.`,
+	RunE: runDiag,
+}
+
+func init() {
+	rootCmd.AddCommand(diagCmd)
+}
+
+func runDiag(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetStatus(ctx, &daemon.StatusRequest{})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("get status failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("get status failed: %w", err)
+	}
+
+	problems := 0
+
+	if resp.Running {
+		fmt.Println("✓ strategy runner is running")
+	} else {
+		fmt.Println("✗ strategy runner is not running")
+		problems++
+	}
+
+	if resp.PendingRules > 0 {
+		fmt.Printf("⚠ %d rules are pending, waiting for hostlist files (see `zapret rules`)\n", resp.PendingRules)
+		problems++
+	}
+
+	if len(resp.UnboundQueues) > 0 {
+		fmt.Printf("⚠ queues %s have no nfqws bound; check your external nfqws supervisor\n", joinInts(resp.UnboundQueues))
+		problems++
+	}
+
+	for _, c := range resp.Conflicts {
+		fmt.Printf("⚠ %s %s competes with zapret-ng for NFQUEUE and firewall rules\n", c.Kind, c.Name)
+		fmt.Printf("    fix: %s\n", c.Remedy)
+		problems++
+	}
+
+	problems += printKernelCapabilities(resp.KernelCapabilities)
+	if caps, err := getCapabilities(); err != nil {
+		fmt.Printf("⚠ %v\n", err)
+		problems++
+	} else {
+		problems += printCapabilityProblems(caps)
+	}
+	problems += printOffloadFindings(resp.Offload)
+
+	if problems == 0 {
+		fmt.Println("\nNo problems found")
+	}
+	return nil
+}
+
+// printKernelCapabilities prints the probed kernel capabilities and returns
+// the number of missing ones.
+func printKernelCapabilities(caps []*daemon.KernelCapability) int {
+	problems := 0
+	for _, c := range caps {
+		switch c.State {
+		case "available":
+			fmt.Printf("✓ kernel: %s available (%s)\n", c.Name, c.Detail)
+		case "missing":
+			fmt.Printf("✗ kernel: %s missing, rules using it fail or run without it (compat_mode)\n", c.Name)
+			fmt.Printf("    %s\n", c.Detail)
+			fmt.Printf("    fix: modprobe %s\n", c.Module)
+			problems++
+		default:
+			fmt.Printf("? kernel: %s unknown (%s)\n", c.Name, c.Detail)
+		}
+	}
+	return problems
+}
+
+// printCapabilityProblems prints missing privileges and nfqws problems from
+// the capabilities summary and returns their number.
+func printCapabilityProblems(caps *daemon.CapabilitiesResponse) int {
+	problems := 0
+	for _, p := range caps.Privileges {
+		if p.Ok {
+			fmt.Printf("✓ privilege: %s\n", p.Name)
+			continue
+		}
+		fmt.Printf("✗ privilege: %s (%s)\n", p.Name, p.Detail)
+		problems++
+	}
+	if n := caps.Nfqws; n != nil && !n.External {
+		switch n.Kind {
+		case "nfqws":
+			fmt.Printf("✓ nfqws: %s (%d options)\n", n.Binary, n.OptionCount)
+		case "unavailable":
+			fmt.Printf("✗ nfqws: %s can't be run: %s\n", n.Binary, n.Error)
+			problems++
+		default:
+			fmt.Printf("⚠ nfqws: %s doesn't look like nfqws\n", n.Binary)
+			problems++
+		}
+	}
+	return problems
+}
+
+// printOffloadFindings prints offload check results and returns the number of problems.
+func printOffloadFindings(findings []*daemon.OffloadFinding) int {
+	problems := 0
+	for _, f := range findings {
+		switch {
+		case f.Error != "" && len(f.Features) == 0:
+			fmt.Printf("⚠ %s: failed to check offloads: %s\n", f.Interface, f.Error)
+			problems++
+		case f.Fixed:
+			fmt.Printf("✓ %s: disabled %s (restored on stop)\n", f.Interface, strings.Join(f.Features, ", "))
+		default:
+			fmt.Printf("⚠ %s: %s enabled, nfqws may see coalesced packets and fail to desync\n",
+				f.Interface, strings.Join(f.Features, ", "))
+			if f.Error != "" {
+				fmt.Printf("    auto fix failed: %s\n", f.Error)
+			}
+			fmt.Printf("    fix: %s\n", f.FixCommand)
+			problems++
+		}
+	}
+	return problems
+}
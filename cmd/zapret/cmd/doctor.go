@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var doctorJSON bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the setup and suggest fixes",
+	Long: `Run the daemon's diagnostic checks: privileges, the nfqws binary, the
+configured interface, kernel capabilities, hostlist files, kernel queue
+bindings, the firewall table, conflicting zapret services, NIC offloads and,
+with dns_probe.enabled, the DNS answers for the probe domains.
+Each finding is reported as pass, warn or fail with a suggested fix.
+
+Exits with a non-zero status if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "print the findings as JSON")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Checks probe the kernel, nfqws and the strategy, so allow more than usual
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := client.RunDiagnostics(ctx, &daemon.DiagnosticsRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("diagnostics failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("diagnostics failed: %w", err)
+	}
+
+	if doctorJSON {
+		data, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			return fmt.Errorf("failed to encode diagnostics: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printDoctor(os.Stdout, resp.Checks)
+	}
+
+	if failed := countChecks(resp.Checks)["fail"]; failed > 0 {
+		// The findings say what is wrong; usage would only bury them
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	return nil
+}
+
+// countChecks counts the findings per state.
+func countChecks(checks []*daemon.DiagnosticCheck) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.State]++
+	}
+	return counts
+}
+
+// doctorMark returns the mark printed for a finding state.
+func doctorMark(state string) string {
+	switch state {
+	case "pass":
+		return "✓"
+	case "warn":
+		return "⚠"
+	case "fail":
+		return "✗"
+	default:
+		return "-"
+	}
+}
+
+// printDoctor prints the findings grouped by check, then a summary.
+func printDoctor(w io.Writer, checks []*daemon.DiagnosticCheck) {
+	last := ""
+	for _, c := range checks {
+		if c.Name != last {
+			if last != "" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s:\n", c.Name)
+			last = c.Name
+		}
+		fmt.Fprintf(w, "  %s %s\n", doctorMark(c.State), c.Message)
+		if c.Suggestion != "" && c.State != "pass" {
+			fmt.Fprintf(w, "    fix: %s\n", c.Suggestion)
+		}
+	}
+
+	counts := countChecks(checks)
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+		counts["pass"], counts["warn"], counts["fail"], counts["skip"])
+}
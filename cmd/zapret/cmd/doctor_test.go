@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+func TestPrintDoctorGolden(t *testing.T) {
+	checks := []*daemon.DiagnosticCheck{
+		{Name: "privileges", State: "pass", Message: "running with the required privileges"},
+		{Name: "interface", State: "fail", Message: "interface eth1 not found", Suggestion: "set interface to one of: eth0, wlan0"},
+		{Name: "hostlists", State: "warn", Message: "line 2: /etc/zapret/list.txt is empty, the rule matches no hosts", Suggestion: "add domains to the list"},
+		{Name: "hostlists", State: "fail", Message: "line 3: /etc/zapret/other.txt is missing, the rule stays pending", Suggestion: "create the file"},
+		// A passing finding's suggestion is not printed
+		{Name: "queues", State: "pass", Message: "2 queues bound", Suggestion: "ignored"},
+		{Name: "offload", State: "skip", Message: "no specific interface configured"},
+	}
+
+	var buf bytes.Buffer
+	printDoctor(&buf, checks)
+	got := buf.Bytes()
+
+	golden := filepath.Join("testdata", "doctor.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -run TestPrintDoctorGolden -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("doctor output differs from %s (run with -update if the change is intended)\n got: %s\nwant: %s",
+			golden, got, want)
+	}
+}
+
+func TestCountChecks(t *testing.T) {
+	counts := countChecks([]*daemon.DiagnosticCheck{
+		{State: "pass"}, {State: "fail"}, {State: "pass"}, {State: "warn"},
+	})
+	if counts["pass"] != 2 || counts["warn"] != 1 || counts["fail"] != 1 || counts["skip"] != 0 {
+		t.Errorf("countChecks() = %v, want 2 passed, 1 warning, 1 failed", counts)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <domain>",
+	Short: "Show which rules handle a domain",
+	Long: `Evaluate the applied rules against a domain in nfqws evaluation order and show
+which rule would desync its traffic, or why none does.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Loading large hostlists for the first time may take a while
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := client.ExplainDomain(ctx, &daemon.ExplainDomainRequest{Domain: args[0]})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("explain failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("explain failed: %w", err)
+	}
+
+	var matched, missed []*daemon.DomainRuleMatch
+	for _, m := range resp.Matches {
+		if m.Matched {
+			matched = append(matched, m)
+		} else {
+			missed = append(missed, m)
+		}
+	}
+
+	if len(matched) == 0 {
+		fmt.Printf("No rule matches %s\n", args[0])
+	} else {
+		fmt.Printf("Rules matching %s (first match handles the traffic):\n", args[0])
+		for _, m := range matched {
+			printDomainMatch(m)
+		}
+	}
+
+	if len(missed) > 0 {
+		fmt.Println("\nNear misses:")
+		for _, m := range missed {
+			printDomainMatch(m)
+		}
+	}
+
+	return nil
+}
+
+// printDomainMatch prints a single rule evaluation result.
+func printDomainMatch(m *daemon.DomainRuleMatch) {
+	fmt.Printf("  queue %d  %s %s  (line %d)\n", m.Rule.QueueNum, m.Rule.Protocol, m.Rule.Ports, m.Rule.SourceLine)
+	fmt.Printf("    %s\n", m.Reason)
+	fmt.Printf("    args: %s\n", truncate(m.Rule.Args, 100))
+}
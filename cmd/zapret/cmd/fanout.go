@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Command annotations controlling --all.
+const (
+	// annotationMutating marks commands changing daemon state; --all needs --yes
+	annotationMutating = "zapret.mutating"
+
+	// annotationLocal marks commands not talking to a daemon; --all is rejected
+	annotationLocal = "zapret.local"
+)
+
+var (
+	fanOutAll     bool
+	fanOutYes     bool
+	fanOutTimeout time.Duration
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&fanOutAll, "all", false, "run the command against every context concurrently")
+	rootCmd.PersistentFlags().BoolVar(&fanOutYes, "yes", false, "confirm running a mutating command with --all")
+	rootCmd.PersistentFlags().DurationVar(&fanOutTimeout, "all-timeout", 30*time.Second, "time limit per context with --all")
+}
+
+// fanOutResult is the outcome of a command on one context.
+type fanOutResult struct {
+	Context string
+	Stdout  []byte
+	Err     error
+}
+
+// wrapFanOut makes every runnable command below cmd honour --all.
+func wrapFanOut(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(c *cobra.Command, args []string) error {
+			if !fanOutAll {
+				return run(c, args)
+			}
+			return runFanOut(c)
+		}
+	}
+	for _, child := range cmd.Commands() {
+		wrapFanOut(child)
+	}
+}
+
+// runFanOut runs the invoked command once per context, each in a child
+// process selecting the context with --context, and prints the results.
+func runFanOut(cmd *cobra.Command) error {
+	if cmd.Annotations[annotationLocal] != "" {
+		return fmt.Errorf("%s does not talk to a daemon and can't be used with --all", cmd.CommandPath())
+	}
+	if contextName != "" || networkAddress != "" || socketPath != "" {
+		return fmt.Errorf("--all can't be combined with --context, --address or --socket")
+	}
+	if cmd.Annotations[annotationMutating] != "" && !fanOutYes {
+		return fmt.Errorf("%s changes daemon state; pass --yes to run it on every context", cmd.CommandPath())
+	}
+
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	names := contextNames(cfg)
+	if len(names) == 0 {
+		return fmt.Errorf("no contexts configured (client.contexts)")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the zapret binary: %w", err)
+	}
+
+	results := fanOut(exe, fanOutArgs(os.Args[1:]), names, fanOutTimeout)
+	printFanOut(results)
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		// Failures were reported per context; usage would only bury them
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d contexts failed", failed, len(results))
+	}
+	return nil
+}
+
+// fanOutArgs strips the --all flags from the command line of the parent.
+func fanOutArgs(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(out, args[i:]...)
+		case arg == "--all" || strings.HasPrefix(arg, "--all="):
+		case arg == "--all-timeout":
+			i++
+		case strings.HasPrefix(arg, "--all-timeout="):
+		default:
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+// fanOut runs exe with args and --context for every context concurrently.
+// A failing or hanging context never holds up the others past timeout.
+func fanOut(exe string, args, names []string, timeout time.Duration) []fanOutResult {
+	results := make([]fanOutResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = runContext(exe, args, name, timeout)
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// runContext runs the command against one context.
+func runContext(exe string, args []string, name string, timeout time.Duration) fanOutResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, exe, append(append([]string(nil), args...), "--context", name)...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	err := c.Run()
+	res := fanOutResult{Context: name, Stdout: stdout.Bytes()}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.Err = fmt.Errorf("timed out after %s", timeout)
+	case err != nil:
+		if msg := lastLine(stderr.String()); msg != "" {
+			res.Err = errors.New(strings.TrimPrefix(msg, "Error: "))
+		} else {
+			res.Err = err
+		}
+	}
+	return res
+}
+
+// lastLine returns the last non-empty line of s.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// printFanOut prints a merged JSON object keyed by context when every
+// successful context printed JSON, otherwise a section per context.
+func printFanOut(results []fanOutResult) {
+	if merged, ok := mergeJSON(results); ok {
+		fmt.Println(string(merged))
+		return
+	}
+
+	for i, res := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("== %s ==\n", res.Context)
+		if out := bytes.TrimRight(res.Stdout, "\n"); len(out) > 0 {
+			fmt.Println(string(out))
+		}
+		if res.Err != nil {
+			fmt.Printf("✗ %s: %v\n", res.Context, res.Err)
+		}
+	}
+}
+
+// mergeJSON merges JSON outputs into an object keyed by context. Failed
+// contexts map to {"error": ...}. ok is false if any successful output isn't
+// a single JSON value.
+func mergeJSON(results []fanOutResult) ([]byte, bool) {
+	merged := make(map[string]json.RawMessage, len(results))
+	succeeded := 0
+	for _, res := range results {
+		if res.Err != nil {
+			data, _ := json.Marshal(map[string]string{"error": res.Err.Error()})
+			merged[res.Context] = data
+			continue
+		}
+		out := bytes.TrimSpace(res.Stdout)
+		if len(out) == 0 || !json.Valid(out) {
+			return nil, false
+		}
+		merged[res.Context] = out
+		succeeded++
+	}
+	if succeeded == 0 {
+		return nil, false
+	}
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
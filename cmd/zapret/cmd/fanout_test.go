@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// fanOutChildEnv makes the test binary run the CLI instead of the tests, so
+// fanOut can start it as the zapret binary for each context.
+const fanOutChildEnv = "ZAPRET_TEST_FANOUT_CHILD"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fanOutChildEnv) != "" {
+		if err := Execute(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeDaemon answers GetStatus after delay with the strategy file or err.
+type fakeDaemon struct {
+	daemon.ZapretDaemon
+	strategyFile string
+	err          error
+	delay        <-chan struct{}
+}
+
+func (d *fakeDaemon) GetStatus(ctx context.Context, req *daemon.StatusRequest) (*daemon.StatusResponse, error) {
+	if d.delay != nil {
+		<-d.delay
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &daemon.StatusResponse{Running: true, StrategyFile: d.strategyFile}, nil
+}
+
+// startFakeDaemon serves d over Twirp and returns its address.
+func startFakeDaemon(t *testing.T, d *fakeDaemon) string {
+	t.Helper()
+	ts := httptest.NewServer(daemon.NewZapretDaemonServer(d))
+	t.Cleanup(ts.Close)
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func TestFanOut(t *testing.T) {
+	// The slow daemon answers only after the test, once its client is gone
+	release := make(chan struct{})
+	contexts := map[string]*fakeDaemon{
+		"fast":    {strategyFile: "/etc/zapret-ng/fast.bat"},
+		"failing": {err: twirp.NewError(twirp.Unavailable, "strategy runner is stopped")},
+		"slow":    {strategyFile: "/etc/zapret-ng/slow.bat", delay: release},
+	}
+	var config strings.Builder
+	config.WriteString("client:\n  contexts:\n")
+	for name, d := range contexts {
+		fmt.Fprintf(&config, "    %s:\n      address: %s\n", name, startFakeDaemon(t, d))
+	}
+	t.Cleanup(func() { close(release) })
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfgPath, []byte(config.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(fanOutChildEnv, "1")
+	args := fanOutArgs([]string{"status", "--all", "--all-timeout", "2s", "--config", cfgPath, "--format", `{"file":"{{.StrategyFile}}"}`})
+	const timeout = 2 * time.Second
+
+	start := time.Now()
+	results := fanOut(exe, args, []string{"failing", "fast", "slow"}, timeout)
+	if elapsed := time.Since(start); elapsed > timeout+3*time.Second {
+		t.Errorf("fanOut() took %s, want the slow context cut off after %s", elapsed, timeout)
+	}
+
+	// Each context gets its own result, in the order of the names
+	var names []string
+	for _, res := range results {
+		names = append(names, res.Context)
+	}
+	if !slices.Equal(names, []string{"failing", "fast", "slow"}) {
+		t.Fatalf("results for %v, want failing, fast, slow", names)
+	}
+	if err := results[0].Err; err == nil || !strings.Contains(err.Error(), "strategy runner is stopped") {
+		t.Errorf("failing context error = %v, want the daemon's error", err)
+	}
+	if results[1].Err != nil || strings.TrimSpace(string(results[1].Stdout)) != `{"file":"/etc/zapret-ng/fast.bat"}` {
+		t.Errorf("fast context = %q, %v, want its status", results[1].Stdout, results[1].Err)
+	}
+	if err := results[2].Err; err == nil || err.Error() != "timed out after 2s" {
+		t.Errorf("slow context error = %v, want a timeout", err)
+	}
+
+	// The successful JSON outputs merge with the errors keyed by context
+	merged, ok := mergeJSON(results)
+	if !ok {
+		t.Fatal("mergeJSON() failed on JSON outputs")
+	}
+	var got map[string]map[string]string
+	if err := json.Unmarshal(merged, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		"failing": {"error": results[0].Err.Error()},
+		"fast":    {"file": "/etc/zapret-ng/fast.bat"},
+		"slow":    {"error": "timed out after 2s"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("merged output = %v, want %v", got, want)
+	}
+}
+
+func TestFanOutArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"status", "--all"}, []string{"status"}},
+		{[]string{"--all=true", "rules", "--json"}, []string{"rules", "--json"}},
+		{[]string{"status", "--all", "--all-timeout", "5s", "--yes"}, []string{"status", "--yes"}},
+		{[]string{"status", "--all-timeout=5s", "--all"}, []string{"status"}},
+		{[]string{"check", "--all", "--", "--all"}, []string{"check", "--", "--all"}},
+	}
+
+	for _, tt := range tests {
+		if got := fanOutArgs(tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("fanOutArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestMergeJSONPlainOutput(t *testing.T) {
+	results := []fanOutResult{
+		{Context: "a", Stdout: []byte(`{"ok":true}`)},
+		{Context: "b", Stdout: []byte("Status: running\n")},
+	}
+	if _, ok := mergeJSON(results); ok {
+		t.Error("mergeJSON() merged output that isn't JSON")
+	}
+	if _, ok := mergeJSON([]fanOutResult{{Context: "a", Err: errors.New("down")}}); ok {
+		t.Error("mergeJSON() merged without a successful context")
+	}
+}
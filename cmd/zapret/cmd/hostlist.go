@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var hostlistCmd = &cobra.Command{
+	Use:   "hostlist",
+	Short: "Manage downloaded hostlists",
+	Long:  `Inspect hostlists the daemon keeps up to date from URLs.`,
+}
+
+var hostlistStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show hostlist update state",
+	Long:  `Show the URL, last success, last error, size and entry count of each downloaded hostlist.`,
+	RunE:  runHostlistStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(hostlistCmd)
+	hostlistCmd.AddCommand(hostlistStatusCmd)
+}
+
+func runHostlistStatus(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetHostlistStatus(ctx, &daemon.HostlistStatusRequest{})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("get hostlist status failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("get hostlist status failed: %w", err)
+	}
+
+	if len(resp.Sources) == 0 {
+		fmt.Println("No hostlist sources configured")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tURL\tLAST SUCCESS\tSIZE\tENTRIES\tLAST ERROR")
+	for _, src := range resp.Sources {
+		lastSuccess := orDash(src.LastSuccess)
+		lastError := "-"
+		if src.LastError != "" {
+			lastError = src.LastError
+			if src.NextAttempt != "" {
+				lastError = fmt.Sprintf("%s (retry %s)", lastError, src.NextAttempt)
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			src.Path, src.Url, lastSuccess, src.Size, src.Entries, truncate(lastError, 80))
+	}
+	return w.Flush()
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
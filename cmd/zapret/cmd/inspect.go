@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/cmd/zapret/output"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [queue]",
+	Short: "Show per-queue traffic and desync statistics",
+	Long: `Show packet counters and desync statistics for every queue, or details of a single queue.
+
+Desync statistics require process.collect_stats: true in the strategy config.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInspect,
+}
+
+var inspectFormat string
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+	inspectCmd.Flags().StringVar(&inspectFormat, "format", "", "print the snapshot with a Go template, e.g. '{{range .Processes}}{{.Pid}}\n{{end}}' (fields: zapret api inspect)")
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	queue := -1
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid queue number: %s", args[0])
+		}
+		queue = n
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetSnapshot(ctx, &daemon.SnapshotRequest{
+		Fields: []string{"rules", "counters", "processes"},
+	})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("inspect failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("inspect failed: %w", err)
+	}
+
+	if inspectFormat != "" {
+		return output.Template(os.Stdout, inspectFormat, resp)
+	}
+
+	processes := make(map[int32]*daemon.ProcessInfo, len(resp.Processes))
+	for _, proc := range resp.Processes {
+		processes[proc.QueueNum] = proc
+	}
+
+	if queue >= 0 {
+		for _, rule := range resp.Rules {
+			if int(rule.QueueNum) == queue {
+				printQueueDetails(rule, processes[rule.QueueNum], resp.CountersError)
+				return nil
+			}
+		}
+		return fmt.Errorf("queue %d not found", queue)
+	}
+
+	if len(resp.Rules) == 0 {
+		fmt.Println("No rules applied")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUEUE\tPROTO\tPORTS\tPID\tPACKETS\tDESYNC\tHOSTLIST\tAUTOLIST\tERRORS")
+	for _, rule := range resp.Rules {
+		pid, desync, hits, added, errs := "-", "-", "-", "-", "-"
+		if proc := processes[rule.QueueNum]; proc != nil {
+			pid = strconv.Itoa(int(proc.Pid))
+			if proc.Degraded && proc.LastExit == "oom" {
+				pid += " (out of memory)"
+			} else if proc.Degraded {
+				pid += " (crashed)"
+			} else if proc.Suspended {
+				pid += " (suspended)"
+			}
+			if s := proc.Stats; s != nil {
+				desync = strconv.FormatUint(s.DesyncApplied, 10)
+				hits = strconv.FormatUint(s.HostlistHits, 10)
+				added = strconv.FormatUint(s.AutohostlistAdditions, 10)
+				errs = strconv.FormatUint(s.ParseErrors, 10)
+			}
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			rule.QueueNum, rule.Protocol, truncate(rule.Ports, 20), pid, rule.Packets, desync, hits, added, errs)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if resp.CountersError != "" {
+		fmt.Printf("\nPacket counters unavailable: %s\n", resp.CountersError)
+	}
+
+	return nil
+}
+
+// printQueueDetails prints everything known about a single queue.
+func printQueueDetails(rule *daemon.RuleInfo, proc *daemon.ProcessInfo, countersErr string) {
+	fmt.Printf("Queue:              %d\n", rule.QueueNum)
+	fmt.Printf("Rule:               %s %s (line %d)\n", rule.Protocol, rule.Ports, rule.SourceLine)
+	fmt.Printf("Arguments:          %s\n", rule.Args)
+	if countersErr != "" {
+		fmt.Printf("Traffic:            unavailable (%s)\n", countersErr)
+	} else {
+		fmt.Printf("Traffic:            %d packets, %d bytes\n", rule.Packets, rule.Bytes)
+	}
+
+	if proc == nil {
+		fmt.Printf("Process:            ❌ not running\n")
+		return
+	}
+	switch {
+	case proc.Degraded:
+		fmt.Printf("Process:            ❌ pid %d crashed, not restarted after %d restarts\n", proc.Pid, proc.Restarts)
+	case proc.Suspended:
+		fmt.Printf("Process:            pid %d, started %s, ⏸ suspended\n", proc.Pid, proc.StartedAt)
+	default:
+		fmt.Printf("Process:            pid %d, started %s\n", proc.Pid, proc.StartedAt)
+	}
+	if proc.Restarts > 0 && !proc.Degraded {
+		fmt.Printf("Restarts:           %d after crashes\n", proc.Restarts)
+	}
+	if limit := proc.MemoryLimitBytes; limit > 0 {
+		if limit%(1<<20) == 0 {
+			fmt.Printf("Memory Limit:       %d MiB\n", limit>>20)
+		} else {
+			fmt.Printf("Memory Limit:       %d KiB\n", limit>>10)
+		}
+	}
+	if proc.OomKills > 0 {
+		fmt.Printf("OOM Kills:          %d (last exit: %s); reduce the rule's hostlists or raise its memory_limit\n", proc.OomKills, proc.LastExit)
+	}
+
+	if proc.Stats == nil {
+		fmt.Printf("Desync Stats:       not collected (set process.collect_stats: true)\n")
+		return
+	}
+	fmt.Printf("Desync Applied:     %d\n", proc.Stats.DesyncApplied)
+	fmt.Printf("Hostlist Hits:      %d\n", proc.Stats.HostlistHits)
+	fmt.Printf("Autohostlist Adds:  %d\n", proc.Stats.AutohostlistAdditions)
+	fmt.Printf("Parse Errors:       %d\n", proc.Stats.ParseErrors)
+}
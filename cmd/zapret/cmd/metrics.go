@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/metrics"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var (
+	metricsTextfile  string
+	metricsTimestamp bool
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print daemon metrics in Prometheus text format",
+	Long: `Fetch a state snapshot from the daemon and print it as Prometheus metrics.
+
+With --textfile the metrics are written atomically to a file for the
+node_exporter textfile collector, e.g. from a cron job:
+
+  zapret metrics --textfile /var/lib/node_exporter/zapret.prom`,
+	RunE: runMetrics,
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.Flags().StringVar(&metricsTextfile, "textfile", "", "write metrics atomically to this file instead of stdout")
+	metricsCmd.Flags().BoolVar(&metricsTimestamp, "timestamp", false, "add sample timestamps (not recommended for the textfile collector)")
+}
+
+func runMetrics(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snap, err := client.GetSnapshot(ctx, &daemon.SnapshotRequest{Fields: metrics.SnapshotFields})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("get snapshot failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("get snapshot failed: %w", err)
+	}
+
+	var opts metrics.Options
+	if metricsTimestamp {
+		opts.Timestamp = time.Now()
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf, snap, opts); err != nil {
+		return fmt.Errorf("failed to render metrics: %w", err)
+	}
+
+	if metricsTextfile == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	return writeTextfile(metricsTextfile, buf.Bytes())
+}
+
+// writeTextfile writes data to a temporary file next to path and renames it into
+// place, so the textfile collector never reads a partially written file.
+func writeTextfile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	return nil
+}
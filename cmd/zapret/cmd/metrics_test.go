@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "zapret.prom")
+
+	for _, data := range []string{"zapret_up 1\n", "zapret_up 0\n"} {
+		if err := writeTextfile(path, []byte(data)); err != nil {
+			t.Fatalf("writeTextfile() error = %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("textfile = %q, want %q", got, data)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Errorf("textfile mode = %v, want 0644", perm)
+	}
+
+	// The collector only reads *.prom, but temp files must not pile up either
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the textfile", len(entries))
+	}
+}
+
+func TestWriteTextfileMissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "zapret.prom")
+	if err := writeTextfile(path, []byte("zapret_up 1\n")); err == nil {
+		t.Error("writeTextfile() into a missing directory succeeded, want error")
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var payloadsCmd = &cobra.Command{
+	Use:   "payloads",
+	Short: "Inspect fake payload files",
+	Long:  `Inspect the fake payload files (--dpi-desync-fake-tls=<file> etc.) referenced by the applied strategy.`,
+}
+
+var payloadsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List referenced payload files",
+	Long:  `Show each referenced payload file with its size, SHA-256, the rules using it and any problem found with its format.`,
+	RunE:  runPayloadsList,
+}
+
+func init() {
+	rootCmd.AddCommand(payloadsCmd)
+	payloadsCmd.AddCommand(payloadsListCmd)
+}
+
+func runPayloadsList(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.ListPayloads(ctx, &daemon.ListPayloadsRequest{})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("list payloads failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("list payloads failed: %w", err)
+	}
+
+	if len(resp.Payloads) == 0 {
+		fmt.Println("No payload files referenced")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tKIND\tSIZE\tSHA256\tLINES\tSTATE")
+	for _, p := range resp.Payloads {
+		lines := make([]string, len(p.SourceLines))
+		for i, line := range p.SourceLines {
+			lines[i] = strconv.Itoa(int(line))
+		}
+
+		state := "ok"
+		if p.Problem != "" {
+			state = "invalid"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			p.Path, p.Kind, p.Size, orDash(p.Sha256), strings.Join(lines, ","), state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range resp.Payloads {
+		if p.Problem != "" {
+			fmt.Printf("✗ %s\n", p.Problem)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List the nfqws processes",
+	Long: `List the nfqws processes started by the daemon, one per queue, with their
+state, uptime, restarts and arguments.
+
+A process that exited stays listed while it waits for its restart, or as
+degraded once it is no longer restarted, so the queue that lost its nfqws
+shows. The rule of a queue is shown by 'zapret rules'.`,
+	Args: cobra.NoArgs,
+	RunE: runPs,
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+}
+
+func runPs(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetStatus(ctx, &daemon.StatusRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("get status failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("get status failed: %w", err)
+	}
+
+	if len(resp.Processes) == 0 {
+		switch {
+		case resp.ProcessManagement == "external":
+			fmt.Println("No processes: nfqws is supervised externally (see `zapret queues`)")
+		case !resp.Running:
+			fmt.Println("No processes: the strategy runner is not running")
+		default:
+			fmt.Println("No processes")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUEUE\tPID\tSTATE\tUPTIME\tRESTARTS\tARGS")
+	for _, p := range resp.Processes {
+		uptime := "-"
+		if p.Running {
+			uptime = formatUptime(time.Duration(p.UptimeSeconds) * time.Second)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%s\n",
+			p.QueueNum, p.Pid, processState(p), uptime, p.Restarts, truncate(p.Args, 60))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range resp.Processes {
+		if !p.Running {
+			fmt.Printf("⚠ queue %d has no running nfqws, its traffic is not handled (see `zapret queues`)\n", p.QueueNum)
+		}
+	}
+	return nil
+}
+
+// processState describes the state of a process for the table.
+func processState(p *daemon.ProcessInfo) string {
+	switch {
+	case p.Degraded && p.LastExit == "oom":
+		return "degraded (oom)"
+	case p.Degraded:
+		return "degraded"
+	case !p.Running && p.LastExit == "oom":
+		return "restarting (oom)"
+	case !p.Running:
+		return "restarting"
+	case p.Suspended:
+		return "suspended"
+	default:
+		return "running"
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var queuesCmd = &cobra.Command{
+	Use:   "queues",
+	Short: "Show kernel-side NFQUEUE health",
+	Long: `Show the NFQUEUE queues as the kernel sees them (/proc/net/netfilter/nfnetlink_queue)
+next to the rules feeding them: whether a consumer is bound, its pid, the number of
+packets waiting for a verdict, the copy mode and the drop counters.
+
+Flags queues with an installed rule but no bound consumer, and queues whose drop
+counters grew since the previous query. The kernel doesn't expose the maximum
+queue length, so it isn't shown.`,
+	RunE: runQueues,
+}
+
+func init() {
+	rootCmd.AddCommand(queuesCmd)
+}
+
+func runQueues(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.GetKernelQueues(ctx, &daemon.KernelQueuesRequest{})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("get kernel queues failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("get kernel queues failed: %w", err)
+	}
+
+	if len(resp.Queues) == 0 {
+		fmt.Println("No queues in use")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUEUE\tLABEL\tLINE\tBOUND\tPID\tLEN\tCOPY\tDROPPED\tUSER DROPPED")
+	for _, q := range resp.Queues {
+		line := "-"
+		if q.SourceLine > 0 {
+			line = strconv.Itoa(int(q.SourceLine))
+		}
+		if q.Unknown {
+			fmt.Fprintf(w, "%d\t%s\t%s\t?\t-\t?\t-\t?\t?\n", q.Queue, orDash(q.Label), line)
+			continue
+		}
+		bound := "no"
+		if q.Bound {
+			bound = "yes"
+		}
+		pid := "-"
+		if q.Pid > 0 {
+			pid = strconv.Itoa(int(q.Pid))
+		}
+		copyMode := "-"
+		if q.Bound {
+			copyMode = fmt.Sprintf("%s/%d", q.CopyMode, q.CopyRange)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%d\t%d\n",
+			q.Queue, orDash(q.Label), line, bound, pid, q.Length, copyMode, q.Dropped, q.UserDropped)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, q := range resp.Queues {
+		if q.Unknown {
+			fmt.Println("⚠ kernel queue state unavailable: nfnetlink_queue is not loaded or /proc/net/netfilter is hidden (see `zapret doctor`)")
+			break
+		}
+	}
+	for _, q := range resp.Queues {
+		for _, problem := range q.Problems {
+			fmt.Printf("⚠ queue %d: %s\n", q.Queue, problem)
+		}
+	}
+	return nil
+}
@@ -3,27 +3,62 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/cmd/zapret/output"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
 	"github.com/spf13/cobra"
 	"github.com/twitchtv/twirp"
-	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
 )
 
 var (
-	forceRestart bool
+	forceRestart   bool
+	restartTimeout time.Duration
+	onlyLabel      string
+	onlyProto      string
+	onlyPort       string
+	onlyRules      []int32
+	restartCanary  bool
+	restartWait    bool
 )
 
 var restartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "Restart the zapret daemon",
-	Long:  `Send a restart command to the zapret daemon service.`,
-	RunE:  runRestart,
+	Long: `Send a restart command to the zapret daemon service.
+
+The --only-* flags apply just the matching rules, e.g. to test one
+strategy line. Selectors combine: a rule must match all of them. The filter
+stays active across reloads until the next restart without --only-* flags.
+Labels and rule numbers are listed by 'zapret rules'.
+
+Only the rules that changed are replaced: processes and firewall rules of
+new rules are added before the obsolete ones are removed, so unchanged rules
+keep running. When settings other than the strategy changed, hooks are
+configured or --force is given, everything is stopped and started again.
+
+With --canary the new strategy must pass the canary probes (canary.domains
+in the strategy config) or the previous one is restored.
+
+By default restart waits for the reload to finish. On a terminal the
+reload phases are shown as they happen; piped output only gets the final
+message. With --wait=false it returns once the daemon started the reload,
+which 'zapret status' then follows.`,
+	RunE:        runRestart,
+	Annotations: map[string]string{annotationMutating: "true"},
 }
 
 func init() {
 	rootCmd.AddCommand(restartCmd)
-	restartCmd.Flags().BoolVarP(&forceRestart, "force", "f", false, "force restart even if daemon is busy")
+	restartCmd.Flags().BoolVarP(&forceRestart, "force", "f", false, "stop everything and start again instead of replacing only changed rules")
+	restartCmd.Flags().DurationVar(&restartTimeout, "timeout", 5*time.Minute, "how long to wait for the restart to complete")
+	restartCmd.Flags().StringVar(&onlyLabel, "only-label", "", "apply only rules whose label matches this glob (case-insensitive)")
+	restartCmd.Flags().StringVar(&onlyProto, "only-proto", "", "apply only rules of this protocol (tcp or udp)")
+	restartCmd.Flags().StringVar(&onlyPort, "only-port", "", "apply only rules whose ports contain this port (number or alias like https)")
+	restartCmd.Flags().BoolVar(&restartCanary, "canary", false, "verify the new strategy with the canary probes and roll back if they fail")
+	restartCmd.Flags().BoolVar(&restartWait, "wait", true, "wait for the restart to finish, showing its progress on a terminal")
+	restartCmd.Flags().Int32SliceVar(&onlyRules, "only-rules", nil, "apply only the rules with these numbers (as shown by zapret rules)")
 }
 
 func runRestart(cmd *cobra.Command, args []string) error {
@@ -32,14 +67,25 @@ func runRestart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), restartTimeout)
 	defer cancel()
 
 	req := &daemon.RestartRequest{
-		Force: forceRestart,
+		Force:     forceRestart,
+		OnlyLabel: onlyLabel,
+		OnlyProto: onlyProto,
+		OnlyPort:  onlyPort,
+		OnlyRules: onlyRules,
+		Canary:    restartCanary,
+		Detach:    !restartWait,
 	}
 
+	stopProgress := func() {}
+	if restartWait {
+		stopProgress = output.StartProgress(ctx, client, os.Stdout)
+	}
 	resp, err := client.Restart(ctx, req)
+	stopProgress()
 	if err != nil {
 		// Handle Twirp errors with more context
 		if twerr, ok := err.(twirp.Error); ok {
@@ -49,7 +95,12 @@ func runRestart(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Println("✓", resp.Message)
-	fmt.Printf("Restarted at: %s\n", resp.RestartedAt)
+	if resp.RestartedAt != "" {
+		fmt.Printf("Restarted at: %s\n", resp.RestartedAt)
+	}
+	if onlyLabel != "" || onlyProto != "" || onlyPort != "" || len(onlyRules) > 0 {
+		fmt.Println("⚠ Only the selected rules are applied; run `zapret restart` without --only-* flags to apply all.")
+	}
 
 	return nil
 }
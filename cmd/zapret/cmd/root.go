@@ -2,17 +2,18 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
-	"github.com/spf13/cobra"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
-	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/pkg/client"
+	"github.com/spf13/cobra"
 )
 
 var (
 	cfgFile        string
 	socketPath     string
 	networkAddress string
+	authToken      string
+	contextName    string
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -24,6 +25,7 @@ var rootCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
+	wrapFanOut(rootCmd)
 	return rootCmd.Execute()
 }
 
@@ -31,50 +33,58 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file path")
 	rootCmd.PersistentFlags().StringVarP(&socketPath, "socket", "s", "", "unix socket path (overrides config)")
 	rootCmd.PersistentFlags().StringVarP(&networkAddress, "address", "a", "", "network address (overrides config and socket)")
+	rootCmd.PersistentFlags().StringVar(&authToken, "token", "", "auth token for the network address (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "named daemon context from client.contexts")
 }
 
 // GetClient creates a Twirp client for the daemon service.
-func GetClient() (daemon.ZapretDaemon, error) {
-	var httpClient *http.Client
-	var baseURL string
+func GetClient() (*client.Client, error) {
+	opts, err := clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(opts)
+}
 
-	// Priority: network address flag > socket flag > config file
+// clientOptions resolves the daemon to connect to.
+// Priority: network address flag > socket flag > context > config file.
+func clientOptions() (client.Options, error) {
 	if networkAddress != "" {
-		// Use network address
-		baseURL = fmt.Sprintf("http://%s", networkAddress)
-		httpClient = &http.Client{}
-	} else if socketPath != "" {
-		// Use socket path from flag
-		httpClient = NewUnixSocketClient(socketPath)
-		baseURL = "http://unix"
-	} else {
-		// Load from config
-		cfg, err := config.Load(cfgFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load config: %w", err)
-		}
+		return client.Options{Address: networkAddress, Token: authToken}, nil
+	}
+	if socketPath != "" {
+		return client.Options{Socket: socketPath}, nil
+	}
 
-		// Prefer network address from config, fallback to socket
-		if cfg.Server.NetworkAddress != "" {
-			baseURL = fmt.Sprintf("http://%s", cfg.Server.NetworkAddress)
-			httpClient = &http.Client{}
-		} else if cfg.Server.SocketPath != "" {
-			httpClient = NewUnixSocketClient(cfg.Server.SocketPath)
-			baseURL = "http://unix"
-		} else {
-			return nil, fmt.Errorf("no connection method configured")
+	name, ctx, err := selectedContext()
+	if err != nil {
+		return client.Options{}, err
+	}
+	if name != "" {
+		opts, err := contextOptions(ctx, authToken)
+		if err != nil {
+			return client.Options{}, fmt.Errorf("context %q: %w", name, err)
 		}
+		return opts, nil
 	}
 
-	client := daemon.NewZapretDaemonProtobufClient(baseURL, httpClient)
-	return client, nil
-}
+	// Load from config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return client.Options{}, fmt.Errorf("failed to load config: %w", err)
+	}
 
-// NewUnixSocketClient creates an HTTP client that connects via Unix socket.
-func NewUnixSocketClient(socketPath string) *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			DialContext: UnixDialer(socketPath),
-		},
+	// Prefer network address from config, fallback to socket
+	switch {
+	case cfg.Server.NetworkAddress != "":
+		token := cfg.Server.AuthToken
+		if authToken != "" {
+			token = authToken
+		}
+		return client.Options{Address: cfg.Server.NetworkAddress, Token: token}, nil
+	case cfg.Server.SocketPath != "":
+		return client.Options{Socket: cfg.Server.SocketPath}, nil
+	default:
+		return client.Options{}, fmt.Errorf("no connection method configured")
 	}
 }
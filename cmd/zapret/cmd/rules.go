@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/cmd/zapret/output"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var (
+	wideRules   bool
+	renderRules bool
+	rulesFormat string
+
+	exportOverrides bool
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "List applied strategy rules",
+	Long: `List the rules currently applied by the strategy runner.
+
+--export-overrides prints an overrides skeleton with an entry selecting each
+rule by its label, or by protocol, ports and line when the label isn't
+unique. Entries are enabled with no extra_args, ready to edit and copy into
+the strategy config.`,
+	RunE: runRules,
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.Flags().BoolVarP(&wideRules, "wide", "w", false, "show full nfqws arguments")
+	rulesCmd.Flags().BoolVar(&renderRules, "render", false, "show the firewall commands installing each rule")
+	rulesCmd.Flags().StringVar(&rulesFormat, "format", "", "print the rules with a Go template, e.g. '{{range .Rules}}{{.QueueNum}} {{.Ports}}\n{{end}}' (fields: zapret api rules)")
+	rulesCmd.Flags().BoolVar(&exportOverrides, "export-overrides", false, "print an overrides skeleton for the rules as YAML")
+}
+
+func runRules(cmd *cobra.Command, args []string) error {
+	if exportOverrides && (rulesFormat != "" || renderRules) {
+		return fmt.Errorf("--export-overrides can't be used with --format or --render")
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.ListRules(ctx, &daemon.ListRulesRequest{Render: renderRules})
+	if err != nil {
+		// Handle Twirp errors with more context
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("list rules failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("list rules failed: %w", err)
+	}
+
+	if rulesFormat != "" {
+		return output.Template(os.Stdout, rulesFormat, resp)
+	}
+
+	if exportOverrides {
+		if len(resp.Rules) == 0 {
+			return fmt.Errorf("no rules applied")
+		}
+		rules := make([]strategyrunner.ParsedRule, 0, len(resp.Rules))
+		for _, rule := range resp.Rules {
+			rules = append(rules, strategyrunner.ParsedRule{
+				Protocol:      rule.Protocol,
+				Ports:         rule.Ports,
+				PortsResolved: rule.PortsResolved,
+				NFQWSArgs:     rule.Args,
+				QueueNum:      int(rule.QueueNum),
+				SourceLine:    int(rule.SourceLine),
+				RateLimit:     int(rule.RateLimit),
+				Notrack:       rule.Notrack,
+				Label:         rule.Label,
+			})
+		}
+		fmt.Print(strategyrunner.ExportOverrides(rules))
+		return nil
+	}
+
+	if len(resp.Rules) == 0 {
+		fmt.Println("No rules applied")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tQUEUE\tNUM\tLABEL\tPROTO\tPORTS\tLINE\tLIMIT\tCT\tSTATE\tARGS")
+	for i, rule := range resp.Rules {
+		limit := "-"
+		if rule.RateLimit > 0 {
+			limit = fmt.Sprintf("%d/s", rule.RateLimit)
+		}
+
+		conntrack := "on"
+		if rule.Notrack {
+			conntrack = "off"
+		}
+
+		state := "active"
+		switch {
+		case rule.FilteredOut:
+			state = "filtered"
+		case rule.CompatError != "":
+			state = "failed"
+		case len(rule.MissingFiles) > 0:
+			state = "pending"
+		case rule.ScheduledOff:
+			state = "inactive (scheduled)"
+		case len(rule.StrippedArgs) > 0:
+			state = "degraded"
+		}
+
+		num := "new"
+		if rule.QueuePreserved {
+			num = "kept"
+		}
+
+		ruleArgs := rule.Args
+		if !wideRules {
+			ruleArgs = truncate(ruleArgs, 60)
+		}
+
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			i+1, rule.QueueNum, num, rule.Label, rule.Protocol, rulePorts(rule), rule.SourceLine, limit, conntrack, state, ruleArgs)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Explain why rules are failed, degraded or waiting
+	for _, rule := range resp.Rules {
+		if rule.CompatError != "" {
+			fmt.Printf("queue %d failed: %s\n", rule.QueueNum, rule.CompatError)
+		}
+		if len(rule.StrippedArgs) > 0 {
+			fmt.Printf("queue %d has degraded args: removed %s\n", rule.QueueNum, strings.Join(rule.StrippedArgs, ", "))
+		}
+		if len(rule.ActiveHours) > 0 {
+			active := "active"
+			if rule.ScheduledOff {
+				active = "inactive"
+			}
+			fmt.Printf("queue %d is scheduled (%s): %s until %s\n",
+				rule.QueueNum, strings.Join(rule.ActiveHours, ", "), active, orDash(rule.NextTransition))
+		}
+		for _, issue := range rule.PayloadIssues {
+			fmt.Printf("queue %d has an invalid payload: %s\n", rule.QueueNum, issue)
+		}
+		for _, path := range rule.MissingFiles {
+			fmt.Printf("queue %d is pending: %s does not exist yet\n", rule.QueueNum, path)
+		}
+	}
+
+	if renderRules {
+		for _, rule := range resp.Rules {
+			fmt.Printf("\n# queue %d (line %d)\n", rule.QueueNum, rule.SourceLine)
+			for _, cmd := range rule.FirewallCommands {
+				fmt.Println(cmd)
+			}
+		}
+	}
+
+	return nil
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// rulePorts returns the port spec of rule with the aliases it was written
+// with, e.g. "443 (https)".
+func rulePorts(rule *daemon.RuleInfo) string {
+	if len(rule.PortAliases) == 0 {
+		return rule.Ports
+	}
+	return fmt.Sprintf("%s (%s)", rule.Ports, strings.Join(rule.PortAliases, ", "))
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// Exit codes of `zapret status --short`. They are part of the CLI contract.
+const (
+	ShortExitHealthy     = 0
+	ShortExitDegraded    = 1
+	ShortExitStopped     = 2
+	ShortExitUnreachable = 3
+)
+
+// shortTimeout bounds `zapret status --short`, which runs in shell prompts.
+const shortTimeout = 3 * time.Second
+
+// ExitError makes the CLI exit with Code without printing an error.
+type ExitError struct {
+	Code int
+}
+
+// Error implements error.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// runStatusShort prints the one-line status and returns the exit code.
+func runStatusShort() int {
+	client, err := GetClient()
+	if err != nil {
+		fmt.Println(formatShortStatus(nil, time.Now()))
+		return ShortExitUnreachable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shortTimeout)
+	defer cancel()
+
+	// Counters need a round trip to the kernel, leave them out
+	snap, err := client.Snapshot(ctx, "status", "health")
+	if err != nil {
+		snap = nil
+	}
+
+	fmt.Println(formatShortStatus(snap, time.Now()))
+	return shortExitCode(snap)
+}
+
+// formatShortStatus renders the one-line status, e.g.
+// "zapret: running 12/12 queues, nft, up 3d". A nil snapshot means the daemon
+// is unreachable. The format is stable; scripts should still prefer --json.
+func formatShortStatus(snap *daemon.SnapshotResponse, now time.Time) string {
+	if snap == nil {
+		return "zapret: unreachable"
+	}
+
+	status := snap.Status
+	switch snap.Health {
+	case "stopped", "":
+		return "zapret: stopped"
+	case "reloading":
+		return fmt.Sprintf("zapret: reloading (%s)", status.GetPhase())
+	}
+
+	state := "running"
+	if snap.Health == "degraded" {
+		state = "degraded"
+	}
+
+	served := strconv.Itoa(int(status.GetActiveProcesses()))
+	if status.GetKernelQueuesUnavailable() {
+		served = "?"
+	}
+	parts := []string{
+		fmt.Sprintf("zapret: %s %s/%d queues", state, served, status.GetActiveQueues()),
+		shortBackend(status),
+	}
+	if started, err := time.Parse(time.RFC3339, status.GetStartTime()); err == nil {
+		parts = append(parts, "up "+shortDuration(now.Sub(started)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// shortExitCode returns the exit code for the snapshot (nil if unreachable).
+func shortExitCode(snap *daemon.SnapshotResponse) int {
+	switch {
+	case snap == nil:
+		return ShortExitUnreachable
+	case snap.Health == "healthy":
+		return ShortExitHealthy
+	case snap.Health == "stopped" || snap.Health == "":
+		return ShortExitStopped
+	default:
+		// degraded, or a reload in progress
+		return ShortExitDegraded
+	}
+}
+
+// shortBackend abbreviates the firewall backend.
+func shortBackend(status *daemon.StatusResponse) string {
+	if status.GetFirewallManagement() == "external" {
+		return "external fw"
+	}
+	// Drop the fallback mode note, e.g. "nftables (no set support, expanded rules)"
+	backend, _, _ := strings.Cut(status.GetFirewallBackend(), " (")
+	if backend == "nftables" {
+		return "nft"
+	}
+	return backend
+}
+
+// shortDuration formats d with its largest unit only, e.g. "3d" or "15m".
+func shortDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// testNow is the time the short status tests are rendered at.
+var testNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+func TestStatusShortGolden(t *testing.T) {
+	running := func(health string, status *daemon.StatusResponse) *daemon.SnapshotResponse {
+		status.Running = true
+		status.ActiveQueues = 12
+		if status.ActiveProcesses == 0 {
+			status.ActiveProcesses = 12
+		}
+		if status.FirewallBackend == "" {
+			status.FirewallBackend = "nftables"
+		}
+		return &daemon.SnapshotResponse{Health: health, Status: status}
+	}
+
+	tests := []struct {
+		name string
+		snap *daemon.SnapshotResponse
+	}{
+		{name: "unreachable"},
+		{name: "stopped", snap: &daemon.SnapshotResponse{Health: "stopped", Status: &daemon.StatusResponse{}}},
+		{name: "no health", snap: &daemon.SnapshotResponse{}},
+		{name: "reloading", snap: &daemon.SnapshotResponse{Health: "reloading", Status: &daemon.StatusResponse{Phase: "firewall"}}},
+		{name: "healthy", snap: running("healthy", &daemon.StatusResponse{StartTime: "2026-10-12T09:00:00Z"})},
+		{name: "healthy no start time", snap: running("healthy", &daemon.StatusResponse{})},
+		{name: "degraded", snap: running("degraded", &daemon.StatusResponse{
+			StartTime:       "2026-10-15T11:45:00Z",
+			ActiveProcesses: 11,
+		})},
+		{name: "kernel queues unavailable", snap: running("healthy", &daemon.StatusResponse{
+			StartTime:               "2026-10-15T11:59:30Z",
+			KernelQueuesUnavailable: true,
+		})},
+		{name: "nft fallback", snap: running("healthy", &daemon.StatusResponse{
+			StartTime:       "2026-10-15T07:00:00Z",
+			FirewallBackend: "nftables (no set support, expanded rules)",
+		})},
+		{name: "iptables", snap: running("healthy", &daemon.StatusResponse{
+			StartTime:       "2026-10-15T07:00:00Z",
+			FirewallBackend: "iptables",
+		})},
+		{name: "external firewall", snap: running("healthy", &daemon.StatusResponse{
+			StartTime:          "2026-10-15T07:00:00Z",
+			FirewallManagement: "external",
+		})},
+	}
+
+	var buf bytes.Buffer
+	for _, tt := range tests {
+		fmt.Fprintf(&buf, "%s: %s (exit %d)\n", tt.name, formatShortStatus(tt.snap, testNow), shortExitCode(tt.snap))
+	}
+	got := buf.Bytes()
+
+	golden := filepath.Join("testdata", "status-short.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -run TestStatusShortGolden -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("status --short differs from %s (run with -update if the change is intended)\n got: %s\nwant: %s",
+			golden, got, want)
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: -time.Second, want: "0s"},
+		{d: 0, want: "0s"},
+		{d: 59 * time.Second, want: "59s"},
+		{d: time.Minute, want: "1m"},
+		{d: 59*time.Minute + 59*time.Second, want: "59m"},
+		{d: time.Hour, want: "1h"},
+		{d: 23 * time.Hour, want: "23h"},
+		{d: 24 * time.Hour, want: "1d"},
+		{d: 400 * time.Hour, want: "16d"},
+	}
+
+	for _, tt := range tests {
+		if got := shortDuration(tt.d); got != tt.want {
+			t.Errorf("shortDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var (
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the strategy runner stopped by zapret stop",
+	Long: `Start the nfqws processes and apply the firewall rules again after
+'zapret stop'. Does nothing if the strategy runner is already running.`,
+	Args:        cobra.NoArgs,
+	RunE:        runStart,
+	Annotations: map[string]string{annotationMutating: "true"},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the strategy runner, keeping the daemon running",
+	Long: `Stop the nfqws processes and remove the firewall rules, e.g. to check
+whether a site works without zapret. The daemon keeps running and answering
+the CLI; 'zapret start' brings the strategy runner back. Does nothing if it
+is already stopped.`,
+	Args:        cobra.NoArgs,
+	RunE:        runStop,
+	Annotations: map[string]string{annotationMutating: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	startCmd.Flags().DurationVar(&startTimeout, "timeout", 5*time.Minute, "how long to wait for the start to complete")
+	stopCmd.Flags().DurationVar(&stopTimeout, "timeout", time.Minute, "how long to wait for the stop to complete")
+}
+
+func runStart(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	resp, err := client.Start(ctx, &daemon.StartRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("start failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("start failed: %w", err)
+	}
+
+	fmt.Println("✓", resp.Message)
+	return nil
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+
+	resp, err := client.Stop(ctx, &daemon.StopRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("stop failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("stop failed: %w", err)
+	}
+
+	fmt.Println("✓", resp.Message)
+	fmt.Println("Run `zapret start` to start it again.")
+	return nil
+}
@@ -3,25 +3,56 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/cmd/zapret/output"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
 	"github.com/spf13/cobra"
 	"github.com/twitchtv/twirp"
-	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	statusJSON   bool
+	statusFormat string
+	statusShort  bool
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Get strategy runner status",
-	Long:  `Get the current status of the strategy runner.`,
-	RunE:  runStatus,
+	Long: `Get the current status of the strategy runner.
+
+--short prints a single line for shell prompts and MOTD, e.g.
+"zapret: running 12/12 queues, nft, up 3d", and exits with
+0 when healthy, 1 when degraded or reloading, 2 when stopped and
+3 when the daemon is unreachable.`,
+	RunE: runStatus,
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "print a full state snapshot as JSON")
+	statusCmd.Flags().BoolVar(&statusShort, "short", false, "print a one-line status; the exit code tells the health")
+	statusCmd.Flags().StringVar(&statusFormat, "format", "", "print the status with a Go template, e.g. '{{.ActiveProcesses}}' (fields: zapret api status)")
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	if statusShort {
+		if statusJSON || statusFormat != "" {
+			return fmt.Errorf("--short can't be used with --json or --format")
+		}
+		if code := runStatusShort(); code != ShortExitHealthy {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &ExitError{Code: code}
+		}
+		return nil
+	}
+
 	client, err := GetClient()
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
@@ -30,6 +61,13 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if statusJSON && statusFormat != "" {
+		return fmt.Errorf("--json and --format can't be used together")
+	}
+	if statusJSON {
+		return printSnapshotJSON(ctx, client)
+	}
+
 	resp, err := client.GetStatus(ctx, &daemon.StatusRequest{})
 	if err != nil {
 		// Handle Twirp errors with more context
@@ -39,11 +77,18 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get status failed: %w", err)
 	}
 
+	if statusFormat != "" {
+		return output.Template(os.Stdout, statusFormat, resp)
+	}
+
 	// Print status
 	runningStr := "❌ not running"
 	if resp.Running {
 		runningStr = "✓ running"
 	}
+	if resp.Phase != "" && resp.Phase != "running" && resp.Phase != "stopped" {
+		runningStr = fmt.Sprintf("⟳ %s (restart in progress)", resp.Phase)
+	}
 
 	fmt.Printf("Status:             %s\n", runningStr)
 
@@ -59,13 +104,122 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Strategy File:      %s\n", resp.StrategyFile)
+	if resp.ConfigVersion != "" {
+		fmt.Printf("Config Version:     %s\n", shortHash(resp.ConfigVersion))
+	}
+	if resp.ApplyPending {
+		fmt.Printf("⟳ Apply Pending:    config changes wait for the watch quiet period (touch .zapret-apply to apply now)\n")
+	}
+	switch resp.StrategySource {
+	case "embedded-fallback":
+		fmt.Printf("⚠ Strategy Source:  embedded-fallback (strategy file missing, only a minimal built-in strategy is applied)\n")
+	case "canary-rollback":
+		fmt.Printf("⚠ Strategy Source:  canary-rollback (the new strategy failed the canary check, the previous one is applied)\n")
+	}
+	if resp.RuleFilter != "" {
+		fmt.Printf("⚠ Rule Filter:      %s (%d rules filtered out, `zapret restart` applies all)\n",
+			resp.RuleFilter, resp.FilteredRules)
+	}
 	fmt.Printf("Active Queues:      %d\n", resp.ActiveQueues)
-	fmt.Printf("Active Processes:   %d\n", resp.ActiveProcesses)
-	fmt.Printf("Firewall Backend:   %s\n", resp.FirewallBackend)
+	if resp.ProcessManagement == "external" {
+		if resp.KernelQueuesUnavailable {
+			fmt.Printf("Active Processes:   unknown, kernel queue state unavailable (see `zapret doctor`)\n")
+		} else {
+			fmt.Printf("Active Processes:   %d bound queues (process management is external)\n", resp.ActiveProcesses)
+		}
+		if len(resp.UnboundQueues) > 0 {
+			fmt.Printf("Unbound Queues:     %s (no nfqws consumer, degraded)\n", joinInts(resp.UnboundQueues))
+		}
+	} else {
+		fmt.Printf("Active Processes:   %d\n", resp.ActiveProcesses)
+	}
+	if resp.SuspendedUntil != "" {
+		fmt.Printf("⚠ Suspended:        queues %s until %s (`zapret resume-processes`)\n", joinInts(resp.SuspendedQueues), resp.SuspendedUntil)
+	} else if len(resp.SuspendedQueues) > 0 {
+		fmt.Printf("⚠ Suspended:        queues %s stopped by SIGSTOP (`zapret resume-processes`)\n", joinInts(resp.SuspendedQueues))
+	}
+	if len(resp.DegradedQueues) > 0 {
+		fmt.Printf("✗ Crashed:          queues %s, nfqws no longer restarted (see the daemon log, `zapret restart` retries)\n", joinInts(resp.DegradedQueues))
+	}
+	if resp.QueueMapFile != "" && resp.Running {
+		fmt.Printf("Queue Map:          %s\n", resp.QueueMapFile)
+	}
+	if resp.WatchFallback {
+		fmt.Printf("⚠ Config Polling:   every %s (file watcher unavailable)\n", resp.ConfigPollInterval)
+	} else if resp.ConfigPollInterval != "" {
+		fmt.Printf("Config Polling:     every %s\n", resp.ConfigPollInterval)
+	}
+	if resp.EmptyRuleset {
+		fmt.Printf("⚠ Rules:            none applied (filters and compatibility checks left no rule)\n")
+	}
+	if resp.ScheduledOffRules > 0 {
+		fmt.Printf("Scheduled Off:      %d rules outside their active hours (see `zapret rules`)\n", resp.ScheduledOffRules)
+	}
+	if resp.PendingRules > 0 {
+		fmt.Printf("Pending Rules:      %d (waiting for hostlist files, see `zapret rules`)\n", resp.PendingRules)
+	}
+	if resp.FirewallManagement == "external" {
+		fmt.Printf("Firewall Backend:   external (queue rules are managed by the user)\n")
+	} else {
+		fmt.Printf("Firewall Backend:   %s\n", resp.FirewallBackend)
+	}
+	if resp.FirewallMaxOpMs > 0 {
+		fmt.Printf("Firewall Latency:   last %.1fms, max %.1fms\n", resp.FirewallLastOpMs, resp.FirewallMaxOpMs)
+	}
+	if resp.NetlinkReconnects > 0 {
+		fmt.Printf("Netlink Reconnects: %d\n", resp.NetlinkReconnects)
+	}
+	if resp.ZombiesReaped > 0 {
+		fmt.Printf("⚠ Zombies Reaped:   %d (a child process was never waited for, please report this bug)\n", resp.ZombiesReaped)
+	}
+	if resp.HostlistIndexBytes > 0 {
+		fmt.Printf("Hostlist Index:     %.1f KiB\n", float64(resp.HostlistIndexBytes)/1024)
+	}
+	for _, c := range resp.KernelCapabilities {
+		if c.State == "missing" {
+			fmt.Printf("⚠ Kernel:           %s missing (modprobe %s, see `zapret diag`)\n", c.Name, c.Module)
+		}
+	}
+	for _, c := range resp.Conflicts {
+		fmt.Printf("⚠ Conflict:         %s %s competes for NFQUEUE (fix: %s)\n", c.Kind, c.Name, c.Remedy)
+	}
+	for _, f := range resp.Offload {
+		if len(f.Features) > 0 && !f.Fixed {
+			fmt.Printf("Offload Warning:    %s has %s enabled (see `zapret diag`)\n", f.Interface, strings.Join(f.Features, ", "))
+		}
+	}
 
 	return nil
 }
 
+// printSnapshotJSON prints the daemon state snapshot as JSON.
+func printSnapshotJSON(ctx context.Context, client daemon.ZapretDaemon) error {
+	resp, err := client.GetSnapshot(ctx, &daemon.SnapshotRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("get snapshot failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("get snapshot failed: %w", err)
+	}
+
+	data, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true, EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	fmt.Println(string(data))
+
+	return nil
+}
+
+// joinInts formats queue numbers as a comma-separated list.
+func joinInts(values []int32) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // formatUptime formats a duration into a human-readable uptime string.
 func formatUptime(d time.Duration) string {
 	days := int(d.Hours() / 24)
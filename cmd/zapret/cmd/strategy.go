@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/registry"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var (
+	registryURL      string
+	listPresets      bool
+	activateStrategy bool
+	strategyChecksum string
+)
+
+var strategyCmd = &cobra.Command{
+	Use:   "strategy",
+	Short: "Manage strategy presets",
+	Long:  `Manage strategy presets installed on the daemon host.`,
+}
+
+var strategyFetchCmd = &cobra.Command{
+	Use:   "fetch <name|url>",
+	Short: "Download and install a strategy preset",
+	Long: `Download a strategy preset from the community registry (or a direct URL),
+verify its checksums and install it on the daemon host.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if listPresets {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE:        runStrategyFetch,
+	Annotations: map[string]string{annotationMutating: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(strategyCmd)
+	strategyCmd.AddCommand(strategyFetchCmd)
+	strategyFetchCmd.Flags().StringVar(&registryURL, "registry", "", "registry index URL (overrides config)")
+	strategyFetchCmd.Flags().BoolVar(&listPresets, "list", false, "list available presets")
+	strategyFetchCmd.Flags().BoolVar(&activateStrategy, "activate", false, "switch to the preset after installing it")
+	strategyFetchCmd.Flags().StringVar(&strategyChecksum, "sha256", "", "expected sha256 checksum when fetching a URL")
+}
+
+func runStrategyFetch(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	reg := registry.NewClient(&http.Client{Timeout: time.Minute})
+
+	var preset *registry.Preset
+	if len(args) == 1 && registry.IsURL(args[0]) {
+		preset = &registry.Preset{
+			Name:   registry.NameFromURL(args[0]),
+			URL:    args[0],
+			SHA256: strategyChecksum,
+		}
+	} else {
+		indexURL, err := getRegistryURL()
+		if err != nil {
+			return err
+		}
+
+		index, err := reg.FetchIndex(ctx, indexURL)
+		if err != nil {
+			return err
+		}
+
+		if listPresets {
+			printPresets(index)
+			return nil
+		}
+
+		preset, err = index.Find(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Downloading %s...\n", preset.Name)
+	strategy, err := reg.Download(ctx, preset.URL, preset.SHA256)
+	if err != nil {
+		return err
+	}
+
+	lists := make(map[string][]byte, len(preset.Lists))
+	for _, list := range preset.Lists {
+		data, err := reg.Download(ctx, list.URL, list.SHA256)
+		if err != nil {
+			return err
+		}
+		lists[list.Name] = data
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := client.InstallStrategy(ctx, &daemon.InstallStrategyRequest{
+		Name:     preset.Name,
+		Strategy: strategy,
+		Lists:    lists,
+		Activate: activateStrategy,
+	})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("install failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("install failed: %w", err)
+	}
+
+	fmt.Println("✓", resp.Message)
+	for _, path := range resp.InstalledPaths {
+		fmt.Printf("  %s\n", path)
+	}
+
+	return nil
+}
+
+// getRegistryURL returns the registry index URL from the flag or config.
+func getRegistryURL() (string, error) {
+	if registryURL != "" {
+		return registryURL, nil
+	}
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return cfg.Client.RegistryURL, nil
+}
+
+// printPresets prints the presets available in the registry index.
+func printPresets(index *registry.Index) {
+	if len(index.Strategies) == 0 {
+		fmt.Println("No presets available")
+		return
+	}
+
+	for _, preset := range index.Strategies {
+		fmt.Printf("%-24s %s\n", preset.Name, preset.Description)
+	}
+}
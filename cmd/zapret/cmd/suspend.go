@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var suspendTimeout time.Duration
+
+var suspendCmd = &cobra.Command{
+	Use:   "suspend",
+	Short: "Stop the nfqws processes for a while",
+	Long: `Stop all nfqws processes with SIGSTOP, e.g. while a backup needs the CPU.
+
+Firewall rules stay in place: packets queue up for nfqws and bypass desync
+once the kernel queue is full. The processes are resumed by
+'zapret resume-processes' or automatically after the timeout
+(process.suspend_timeout, 30m by default).`,
+	Args:        cobra.NoArgs,
+	RunE:        runSuspend,
+	Annotations: map[string]string{annotationMutating: "true"},
+}
+
+var resumeProcessesCmd = &cobra.Command{
+	Use:         "resume-processes",
+	Short:       "Continue nfqws processes stopped by zapret suspend",
+	Args:        cobra.NoArgs,
+	RunE:        runResumeProcesses,
+	Annotations: map[string]string{annotationMutating: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(suspendCmd)
+	rootCmd.AddCommand(resumeProcessesCmd)
+	suspendCmd.Flags().DurationVar(&suspendTimeout, "timeout", 0, "resume automatically after this long (default process.suspend_timeout)")
+}
+
+func runSuspend(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := &daemon.SuspendProcessesRequest{}
+	if suspendTimeout > 0 {
+		req.Timeout = suspendTimeout.String()
+	}
+
+	resp, err := client.SuspendProcesses(ctx, req)
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("suspend failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("suspend failed: %w", err)
+	}
+
+	fmt.Printf("✓ %d nfqws processes suspended\n", resp.Processes)
+	fmt.Printf("Resume at: %s (or run `zapret resume-processes`)\n", resp.ResumeAt)
+	return nil
+}
+
+func runResumeProcesses(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.ResumeProcesses(ctx, &daemon.ResumeProcessesRequest{})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("resume failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("resume failed: %w", err)
+	}
+
+	fmt.Printf("✓ %d nfqws processes resumed\n", resp.Processes)
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test <domain>",
+	Short: "Check why a domain may not work: DNS answers and rule coverage",
+	Long: `Resolve the domain with the system resolver and with the DNS-over-HTTPS
+server of dns_probe and compare the answers, then show which rule desyncs
+its traffic.
+
+Poisoned DNS answers (a block page or 0.0.0.0) can't be fixed by any
+strategy: the connection never reaches the real server. Answers that differ
+from DoH may also come from a CDN answering by location.
+
+Exits with a non-zero status if the DNS answers are bogus.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTest,
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Loading large hostlists for the first time may take a while
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dns, err := client.ProbeDNS(ctx, &daemon.ProbeDNSRequest{Domain: args[0]})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("dns probe failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("dns probe failed: %w", err)
+	}
+
+	fmt.Printf("DNS %s:\n", dns.Domain)
+	fmt.Printf("  %s %s\n", doctorMark(dns.State), dns.Detail)
+	printAddrs("system", dns.SystemAddrs, dns.SystemError)
+	printAddrs("DoH", dns.DohAddrs, dns.DohError)
+	if dns.Suggestion != "" {
+		fmt.Printf("    fix: %s\n", dns.Suggestion)
+	}
+
+	fmt.Println("\nRules:")
+	explain, err := client.ExplainDomain(ctx, &daemon.ExplainDomainRequest{Domain: args[0]})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			fmt.Printf("  ⚠ explain failed: %s (code: %s)\n", twerr.Msg(), twerr.Code())
+		} else {
+			fmt.Printf("  ⚠ explain failed: %v\n", err)
+		}
+	} else {
+		matched := false
+		for _, m := range explain.Matches {
+			if m.Matched {
+				printDomainMatch(m)
+				matched = true
+			}
+		}
+		if !matched {
+			fmt.Printf("  ✗ no rule desyncs %s, add it to a hostlist (details: zapret explain %s)\n", dns.Domain, dns.Domain)
+		}
+	}
+
+	if dns.State == "fail" {
+		// The findings say what is wrong; usage would only bury them
+		cmd.SilenceUsage = true
+		return fmt.Errorf("DNS answers for %s are bogus", dns.Domain)
+	}
+	return nil
+}
+
+// printAddrs prints the answers of a resolver, or why it failed.
+func printAddrs(resolver string, addrs []string, errMsg string) {
+	switch {
+	case errMsg != "":
+		fmt.Printf("    %s: %s\n", resolver, errMsg)
+	case len(addrs) == 0:
+		fmt.Printf("    %s: no addresses\n", resolver)
+	default:
+		fmt.Printf("    %s: %s\n", resolver, strings.Join(addrs, ", "))
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/spf13/cobra"
+	"github.com/twitchtv/twirp"
+)
+
+var (
+	validateAgainstDaemon bool
+	validateSimulate      bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <strategy.bat>",
+	Short: "Check a strategy file before applying it",
+	Long: `Parse a strategy file and report its rules.
+
+With --against-daemon the daemon checks the strategy with its live config,
+overrides and queue allocations. Adding --simulate prints the operations a
+real apply would perform, without changing anything.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().BoolVar(&validateAgainstDaemon, "against-daemon", false, "validate with the daemon's runtime state")
+	validateCmd.Flags().BoolVar(&validateSimulate, "simulate", false, "print the operations applying the strategy would perform (requires --against-daemon)")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if validateSimulate && !validateAgainstDaemon {
+		return errors.New("--simulate requires --against-daemon")
+	}
+	if !validateAgainstDaemon {
+		return validateLocal(args[0])
+	}
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read strategy file: %w", err)
+	}
+
+	client, err := GetClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := client.ValidateStrategy(ctx, &daemon.ValidateStrategyRequest{
+		Strategy:        content,
+		ApplySimulation: validateSimulate,
+	})
+	if err != nil {
+		if twerr, ok := err.(twirp.Error); ok {
+			return fmt.Errorf("validate failed: %s (code: %s)", twerr.Msg(), twerr.Code())
+		}
+		return fmt.Errorf("validate failed: %w", err)
+	}
+
+	for _, msg := range resp.Warnings {
+		fmt.Printf("⚠ %s\n", msg)
+	}
+	if !resp.Valid {
+		for _, msg := range resp.Errors {
+			fmt.Printf("✗ %s\n", msg)
+		}
+		return errors.New("strategy is invalid")
+	}
+
+	fmt.Printf("✓ %s is valid: %d rules\n", args[0], len(resp.Rules))
+	if validateSimulate {
+		printPlan(resp.Operations)
+	}
+	return nil
+}
+
+// validateLocal parses the strategy file without contacting the daemon.
+func validateLocal(path string) error {
+	parser := strategyrunner.NewParser("/usr/bin", strategyrunner.DefaultListsPath, "1024-65535", true,
+		slog.New(slog.DiscardHandler))
+
+	strategy, err := parser.Parse(path)
+	if err != nil {
+		return err
+	}
+	if len(strategy.Rules) == 0 {
+		return errors.New("strategy contains no rules")
+	}
+
+	failed := 0
+	for _, rule := range strategy.Rules {
+		for _, c := range rule.PathConversions {
+			if c.Error != "" {
+				fmt.Printf("✗ line %d: cannot convert Windows path %s=%s: %s\n", rule.SourceLine, c.Option, c.Original, c.Error)
+			} else {
+				fmt.Printf("⚠ line %d: converted Windows path %s=%s to %s\n", rule.SourceLine, c.Option, c.Original, c.Converted)
+			}
+		}
+		if rule.CompatError != "" {
+			failed++
+		}
+	}
+
+	fmt.Printf("✓ %s parsed: %d rules\n", path, len(strategy.Rules))
+	for _, rule := range strategy.Rules {
+		fmt.Printf("  line %-4d %s %s\n", rule.SourceLine, rule.Protocol, rule.Ports)
+	}
+	fmt.Println("\nQueue numbers, overrides and file checks depend on the daemon; use --against-daemon to include them.")
+	if failed > 0 {
+		return fmt.Errorf("%d rules would fail", failed)
+	}
+	return nil
+}
+
+// printPlan prints the operations of a simulated apply.
+func printPlan(ops []*daemon.PlannedOperation) {
+	fmt.Println("\nApplying this strategy would perform:")
+
+	counts := make(map[string]int)
+	for _, op := range ops {
+		fmt.Printf("  + %-8s %s\n", op.Kind, op.Description)
+		counts[op.Kind]++
+	}
+
+	fmt.Printf("\nPlan: %d firewall, %d process and %d hook operations.\n",
+		counts[strategyrunner.OpFirewall], counts[strategyrunner.OpProcess], counts[strategyrunner.OpHook])
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// progressInterval is how often the daemon phase is polled during a long call.
+const progressInterval = 500 * time.Millisecond
+
+// progressWidth bounds the progress line so it never wraps on a typical
+// terminal; the oldest steps are dropped first.
+const progressWidth = 78
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// phaseStep describes a runner phase for the progress line, with the rule
+// counts of the phases that have them.
+func phaseStep(status *daemon.StatusResponse) string {
+	done, total := status.GetPhaseDone(), status.GetPhaseTotal()
+	switch phase := status.GetPhase(); phase {
+	case "applying_firewall":
+		if total > 0 {
+			return fmt.Sprintf("firewall (%d/%d rules)", done, total)
+		}
+		return "firewall"
+	case "starting_processes":
+		if total > 0 {
+			return fmt.Sprintf("starting processes (%d/%d)", done, total)
+		}
+		return "starting processes"
+	default:
+		return phase
+	}
+}
+
+// progressLine is a single redrawn line listing the phases a call went
+// through, e.g. "⠹ parsing… firewall (8/12 rules)… starting processes (5/12)…".
+type progressLine struct {
+	out   io.Writer
+	steps []string
+	phase string
+	frame int
+}
+
+// update records the phase in status: a new phase adds a step, progress in
+// the same phase replaces its step. Idle phases are not shown.
+func (p *progressLine) update(status *daemon.StatusResponse) {
+	phase := status.GetPhase()
+	if phase == "" || phase == "running" || phase == "stopped" {
+		return
+	}
+	step := phaseStep(status)
+	if phase == p.phase && len(p.steps) > 0 {
+		p.steps[len(p.steps)-1] = step
+		return
+	}
+	p.phase = phase
+	p.steps = append(p.steps, step)
+}
+
+// render returns the line for the next spinner frame.
+func (p *progressLine) render() string {
+	spinner := spinnerFrames[p.frame%len(spinnerFrames)]
+	p.frame++
+
+	steps := p.steps
+	if len(steps) == 0 {
+		steps = []string{"waiting for the daemon"}
+	}
+	line := spinner + " " + strings.Join(steps, "… ") + "…"
+	for len(steps) > 1 && len([]rune(line)) > progressWidth {
+		steps = steps[1:]
+		line = spinner + " … " + strings.Join(steps, "… ") + "…"
+	}
+	return line
+}
+
+// draw redraws the line in place.
+func (p *progressLine) draw() {
+	fmt.Fprint(p.out, "\r\033[K"+p.render())
+}
+
+// clear erases the line so the final message starts at column 0.
+func (p *progressLine) clear() {
+	fmt.Fprint(p.out, "\r\033[K")
+}
+
+// StatusClient is the part of the daemon client the progress line polls.
+type StatusClient interface {
+	GetStatus(ctx context.Context, req *daemon.StatusRequest) (*daemon.StatusResponse, error)
+}
+
+// StartProgress polls the daemon phase every progressInterval and shows it
+// on out until the returned stop is called. Output that isn't a terminal is
+// left untouched, so scripts and logs see only the final message.
+func StartProgress(ctx context.Context, client StatusClient, out *os.File) (stop func()) {
+	if !isTerminal(out) {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	line := &progressLine{out: out}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		line.draw()
+		for {
+			select {
+			case <-ctx.Done():
+				line.clear()
+				return
+			case <-ticker.C:
+			}
+
+			// A failed poll keeps the last known phase; the call itself reports errors
+			pollCtx, cancelPoll := context.WithTimeout(ctx, progressInterval)
+			status, err := client.GetStatus(pollCtx, &daemon.StatusRequest{})
+			cancelPoll()
+			if err == nil {
+				line.update(status)
+			}
+			if ctx.Err() == nil {
+				line.draw()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
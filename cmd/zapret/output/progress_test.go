@@ -0,0 +1,79 @@
+package output
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// fakeStatus counts the status polls.
+type fakeStatus struct {
+	polls atomic.Int32
+}
+
+func (f *fakeStatus) GetStatus(ctx context.Context, req *daemon.StatusRequest) (*daemon.StatusResponse, error) {
+	f.polls.Add(1)
+	return &daemon.StatusResponse{Phase: "parsing"}, nil
+}
+
+func TestStartProgressNotTerminal(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	client := &fakeStatus{}
+	stop := StartProgress(t.Context(), client, out)
+	stop()
+
+	// On a terminal the line is drawn right away, before the first poll
+	info, err := out.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("output size = %d, want nothing written to a file", info.Size())
+	}
+	if n := client.polls.Load(); n != 0 {
+		t.Errorf("status polled %d times for a file", n)
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	line := &progressLine{}
+	if got := line.render(); got != spinnerFrames[0]+" waiting for the daemon…" {
+		t.Errorf("render() before any phase = %q", got)
+	}
+
+	for _, status := range []*daemon.StatusResponse{
+		{Phase: "running"},
+		{Phase: "parsing"},
+		{Phase: "applying_firewall", PhaseDone: 3, PhaseTotal: 12},
+		{Phase: "applying_firewall", PhaseDone: 8, PhaseTotal: 12},
+		{Phase: "starting_processes", PhaseDone: 5, PhaseTotal: 12},
+	} {
+		line.update(status)
+	}
+	want := spinnerFrames[1] + " parsing… firewall (8/12 rules)… starting processes (5/12)…"
+	if got := line.render(); got != want {
+		t.Errorf("render() = %q, want %q", got, want)
+	}
+
+	// The oldest steps are dropped to fit the width
+	for i := range 10 {
+		line.update(&daemon.StatusResponse{Phase: strings.Repeat("x", i+5)})
+	}
+	got := line.render()
+	if n := len([]rune(got)); n > progressWidth {
+		t.Errorf("render() is %d runes, want at most %d: %q", n, progressWidth, got)
+	}
+	if !strings.Contains(got, " … ") || !strings.HasSuffix(got, strings.Repeat("x", 14)+"…") {
+		t.Errorf("render() = %q, want the latest steps after an ellipsis", got)
+	}
+}
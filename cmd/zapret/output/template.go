@@ -0,0 +1,152 @@
+// Package output renders CLI responses: user supplied templates, so scripts
+// can pick single fields without jq, and the progress line of long calls.
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// unknownFieldRegex matches the text/template error for a missing struct field.
+var unknownFieldRegex = regexp.MustCompile(`can't evaluate field (\w+) in type (\S+)`)
+
+// Template renders data with the Go text/template format and a trailing newline.
+// Unknown fields are reported with the fields available at that point.
+func Template(w io.Writer, format string, data any) error {
+	tmpl, err := template.New("format").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid --format template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return explain(err, data)
+	}
+
+	_, err = fmt.Fprintln(w, strings.TrimSuffix(out.String(), "\n"))
+	return err
+}
+
+// explain turns an unknown field error into a message listing the available fields.
+func explain(err error, data any) error {
+	m := unknownFieldRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return fmt.Errorf("--format failed: %w", err)
+	}
+
+	t := findType(reflect.TypeOf(data), m[2], map[reflect.Type]bool{})
+	if t == nil {
+		return fmt.Errorf("--format failed: unknown field %q", m[1])
+	}
+	return fmt.Errorf("--format failed: unknown field %q of %s, available fields: %s",
+		m[1], t.Name(), strings.Join(fieldNames(t), ", "))
+}
+
+// findType returns the struct type named name reachable from t.
+func findType(t reflect.Type, name string, seen map[reflect.Type]bool) reflect.Type {
+	if t == nil || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	if t.String() == name {
+		return deref(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return findType(t.Elem(), name, seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				if found := findType(f.Type, name, seen); found != nil {
+					return found
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deref returns the type t points to, if it is a pointer.
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldNames returns the sorted exported field names of a struct type.
+func fieldNames(t reflect.Type) []string {
+	t = deref(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fields describes the fields available to templates of data, one per line,
+// e.g. ".Rules[].QueueNum int32". Nested structs are expanded.
+func Fields(data any) ([]string, error) {
+	t := reflect.TypeOf(data)
+	if t == nil || deref(t).Kind() != reflect.Struct {
+		return nil, errors.New("fields are only available for structs")
+	}
+
+	var lines []string
+	describe(deref(t), "", map[reflect.Type]bool{}, &lines)
+	return lines, nil
+}
+
+// describe appends the fields of struct type t under prefix to lines.
+func describe(t reflect.Type, prefix string, seen map[reflect.Type]bool, lines *[]string) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		path := prefix + "." + f.Name
+		ft := f.Type
+		for {
+			switch ft.Kind() {
+			case reflect.Pointer:
+				ft = ft.Elem()
+				continue
+			case reflect.Slice, reflect.Array:
+				if ft.Elem().Kind() != reflect.Uint8 {
+					path += "[]"
+					ft = ft.Elem()
+					continue
+				}
+			}
+			break
+		}
+
+		if ft.Kind() == reflect.Struct {
+			describe(ft, path, seen, lines)
+			continue
+		}
+		*lines = append(*lines, fmt.Sprintf("%s %s", path, ft))
+	}
+}
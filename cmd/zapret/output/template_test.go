@@ -0,0 +1,85 @@
+package output
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+func TestTemplate(t *testing.T) {
+	snapshot := &daemon.SnapshotResponse{
+		Status: &daemon.StatusResponse{Running: true, ActiveProcesses: 2},
+		Rules: []*daemon.RuleInfo{
+			{QueueNum: 200, Protocol: "tcp", Ports: "443"},
+			{QueueNum: 201, Protocol: "udp", Ports: "50000-50100"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "nested field", format: "{{.Status.ActiveProcesses}}", want: "2\n"},
+		{name: "range over rules", format: `{{range .Rules}}{{.QueueNum}} {{.Protocol}} {{.Ports}}{{"\n"}}{{end}}`, want: "200 tcp 443\n201 udp 50000-50100\n"},
+		{name: "single trailing newline", format: "{{len .Rules}}\n", want: "2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Template(&out, tt.format, snapshot); err != nil {
+				t.Fatalf("Template() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Template() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	snapshot := &daemon.SnapshotResponse{Rules: []*daemon.RuleInfo{{QueueNum: 200}}}
+
+	tests := []struct {
+		name   string
+		format string
+		want   []string
+	}{
+		{name: "syntax", format: "{{.Rules", want: []string{"invalid --format template"}},
+		{name: "unknown field", format: "{{.Queues}}", want: []string{`unknown field "Queues" of SnapshotResponse`, "Rules", "TakenAt"}},
+		{name: "unknown field in range", format: "{{range .Rules}}{{.Queue}}{{end}}", want: []string{`unknown field "Queue" of RuleInfo`, "QueueNum", "SourceLine"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Template(&strings.Builder{}, tt.format, snapshot)
+			if err == nil {
+				t.Fatal("Template() succeeded, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Template() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	fields, err := Fields(&daemon.ListRulesResponse{})
+	if err != nil {
+		t.Fatalf("Fields() error = %v", err)
+	}
+	for _, want := range []string{".Rules[].QueueNum int32", ".Rules[].Ports string"} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("Fields() = %q, want it to contain %q", fields, want)
+		}
+	}
+
+	if _, err := Fields(42); err == nil {
+		t.Error("Fields() of a non-struct succeeded")
+	}
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/schema"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
@@ -12,8 +16,25 @@ type Config struct {
 	Server         ServerConfig         `yaml:"server"`
 	Logging        LoggingConfig        `yaml:"logging"`
 	StrategyRunner StrategyRunnerConfig `yaml:"strategy_runner"`
+	Client         ClientConfig         `yaml:"client"`
+
+	// Lenient disables the unknown key check when loading the config file.
+	Lenient bool `yaml:"lenient"`
+
+	// Deprecations are warnings about legacy keys migrated while loading.
+	Deprecations []string `yaml:"-"`
+
+	// Normalizations are warnings about values read only after folding
+	// look-alike characters, e.g. "5 сек".
+	Normalizations []string `yaml:"-"`
 }
 
+// Migrations lists renamed and retired keys of the daemon config, oldest
+// first. Renaming an option adds an entry so existing configs keep loading
+// with a deprecation warning; `zapret-daemon check-config --migrate --write`
+// rewrites them.
+var Migrations []schema.Migration
+
 // ServerConfig contains server-related configuration.
 type ServerConfig struct {
 	// SocketPath is the path to Unix domain socket.
@@ -26,6 +47,32 @@ type ServerConfig struct {
 
 	// SocketPermissions is the file permissions for Unix socket (octal).
 	SocketPermissions os.FileMode `yaml:"socket_permissions" env:"ZAPRET_SOCKET_PERMISSIONS" env-default:"0660"`
+
+	// ReadTimeout is the maximum duration for reading a request.
+	ReadTimeout time.Duration `yaml:"read_timeout" env:"ZAPRET_READ_TIMEOUT" env-default:"15s"`
+
+	// WriteTimeout is the deadline for regular RPC methods to produce a response.
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"ZAPRET_WRITE_TIMEOUT" env-default:"15s"`
+
+	// LongRequestTimeout is the deadline for long-running RPC methods such as Restart.
+	LongRequestTimeout time.Duration `yaml:"long_request_timeout" env:"ZAPRET_LONG_REQUEST_TIMEOUT" env-default:"5m"`
+
+	// ShutdownTimeout is the total time budget for a graceful shutdown: draining
+	// requests, stopping the strategy runner and closing listeners. Keep it
+	// below systemd's TimeoutStopSec.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"ZAPRET_SHUTDOWN_TIMEOUT" env-default:"30s"`
+
+	// AuthToken is the bearer token required for requests on the network listener.
+	// If empty, network requests are not authenticated. Unix socket access is
+	// controlled by SocketPermissions.
+	AuthToken string `yaml:"auth_token" env:"ZAPRET_AUTH_TOKEN"`
+
+	// WebUI enables the read-only status page at / on the listeners.
+	WebUI bool `yaml:"web_ui" env:"ZAPRET_WEB_UI" env-default:"false"`
+
+	// MetricsEnabled serves Prometheus metrics at /metrics on the listeners.
+	// The auth token applies as for RPC calls.
+	MetricsEnabled bool `yaml:"metrics_enabled" env:"ZAPRET_METRICS_ENABLED" env-default:"false"`
 }
 
 // LoggingConfig contains logging-related configuration.
@@ -35,6 +82,18 @@ type LoggingConfig struct {
 
 	// Format is the log format (json, text).
 	Format string `yaml:"format" env:"ZAPRET_LOG_FORMAT" env-default:"text"`
+
+	// DedupWindow collapses identical records logged within this period into
+	// one line with a repeated count (0 disables).
+	DedupWindow time.Duration `yaml:"dedup_window" env:"ZAPRET_LOG_DEDUP_WINDOW" env-default:"10s"`
+
+	// DedupKeys are the attributes that tell records with the same message apart
+	// (empty compares all attributes).
+	DedupKeys []string `yaml:"dedup_keys" env:"ZAPRET_LOG_DEDUP_KEYS"`
+
+	// Output is where logs are written: stdout, stderr or an absolute file
+	// path. A file is reopened on SIGUSR2 for log rotation.
+	Output string `yaml:"output" env:"ZAPRET_LOG_OUTPUT" env-default:"stdout"`
 }
 
 // StrategyRunnerConfig contains strategy runner configuration.
@@ -52,6 +111,51 @@ type StrategyRunnerConfig struct {
 	NFQWSBinary string `yaml:"nfqws_binary" env:"ZAPRET_SR_NFQWS_BINARY" env-default:"/usr/bin/nfqws"`
 }
 
+// ClientConfig contains CLI client configuration.
+type ClientConfig struct {
+	// RegistryURL is the URL of the JSON index listing strategy presets.
+	RegistryURL string `yaml:"registry_url" env:"ZAPRET_REGISTRY_URL" env-default:"https://raw.githubusercontent.com/Sergeydigl3/zapret-discord-youtube-ng/refs/heads/master/strategies/index.json"`
+
+	// Contexts are named daemons the CLI targets with --context or --all.
+	Contexts map[string]ContextConfig `yaml:"contexts"`
+
+	// CurrentContext is the context used when neither --context nor
+	// `zapret context use` selects one ("" connects with the server settings).
+	CurrentContext string `yaml:"current_context" env:"ZAPRET_CONTEXT"`
+}
+
+// ContextConfig describes how the CLI connects to one daemon.
+type ContextConfig struct {
+	// Address is the network address of the daemon (host:port).
+	Address string `yaml:"address"`
+
+	// Socket is the Unix socket path of the daemon, used when Address is empty.
+	Socket string `yaml:"socket"`
+
+	// Token is the bearer token sent to Address.
+	Token string `yaml:"token"`
+
+	// TLS connects to Address over HTTPS, e.g. through a TLS terminating proxy.
+	TLS bool `yaml:"tls"`
+
+	// CAFile is a PEM bundle of CAs trusted for TLS ("" uses the system roots).
+	CAFile string `yaml:"ca_file"`
+
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// Validate validates the context.
+func (c *ContextConfig) Validate() error {
+	if c.Address == "" && c.Socket == "" {
+		return fmt.Errorf("address or socket must be set")
+	}
+	if c.Address == "" && c.TLS {
+		return fmt.Errorf("tls needs an address")
+	}
+	return nil
+}
+
 // Load loads configuration from file and environment variables.
 // Environment variables take precedence over config file values.
 func Load(configPath string) (*Config, error) {
@@ -65,9 +169,28 @@ func Load(configPath string) (*Config, error) {
 			}
 			return nil, fmt.Errorf("failed to access config file: %w", err)
 		}
-		if err := cleanenv.ReadConfig(configPath, cfg); err != nil {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
+		migrated, err := schema.Migrate(data, Migrations)
+		if err != nil {
+			return nil, fmt.Errorf("outdated keys in %s:\n%w", configPath, err)
+		}
+		normalized, normalizations, err := schema.NormalizeDurations(migrated.Data, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := cleanenv.ParseYAML(bytes.NewReader(normalized), cfg); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		cfg.Deprecations = migrated.Warnings
+		cfg.Normalizations = normalizations
+		if !cfg.Lenient {
+			if err := schema.CheckKnownFields(migrated.Data, cfg); err != nil {
+				return nil, fmt.Errorf("invalid keys in %s (set lenient: true to ignore):\n%w", configPath, err)
+			}
+		}
 	}
 
 	// Read environment variables (they override file values)
@@ -84,6 +207,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("at least one of socket_path or network_address must be configured")
 	}
 
+	if c.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown_timeout must be positive")
+	}
+
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLevels[c.Logging.Level] {
 		return fmt.Errorf("invalid log level: %s (must be one of: debug, info, warn, error)", c.Logging.Level)
@@ -94,5 +221,22 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format: %s (must be one of: json, text)", c.Logging.Format)
 	}
 
+	if c.Logging.DedupWindow < 0 {
+		return fmt.Errorf("invalid log dedup_window: must not be negative")
+	}
+
+	if out := c.Logging.Output; out != "stdout" && out != "stderr" && !filepath.IsAbs(out) {
+		return fmt.Errorf("invalid log output: %s (must be stdout, stderr or an absolute file path)", out)
+	}
+
+	for name, ctx := range c.Client.Contexts {
+		if err := ctx.Validate(); err != nil {
+			return fmt.Errorf("client context %q: %w", name, err)
+		}
+	}
+	if _, ok := c.Client.Contexts[c.Client.CurrentContext]; c.Client.CurrentContext != "" && !ok {
+		return fmt.Errorf("client current_context %q is not defined in contexts", c.Client.CurrentContext)
+	}
+
 	return nil
 }
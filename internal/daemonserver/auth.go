@@ -0,0 +1,55 @@
+package daemonserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// localConnKey marks requests received over the unix socket.
+type localConnKey struct{}
+
+// ConnContext records whether a connection came in over the unix socket.
+// It is meant to be used as http.Server.ConnContext.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	if c.LocalAddr().Network() == "unix" {
+		return context.WithValue(ctx, localConnKey{}, true)
+	}
+	return ctx
+}
+
+// isLocalConn reports whether the request was received over the unix socket.
+func isLocalConn(ctx context.Context) bool {
+	local, _ := ctx.Value(localConnKey{}).(bool)
+	return local
+}
+
+// WithAuth requires a bearer token on requests received over the network.
+// Requests over the unix socket are already guarded by the socket permissions.
+// An empty token disables authentication.
+func WithAuth(handler http.Handler, token string) http.Handler {
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLocalConn(r.Context()) || validToken(r, token) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="zapret"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// validToken checks the Authorization header against the expected token.
+func validToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
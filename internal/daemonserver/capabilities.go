@@ -0,0 +1,66 @@
+package daemonserver
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// GetCapabilities implements the GetCapabilities RPC method. It works without
+// the strategy runner, which only adds the kernel and nfqws sections.
+func (s *Server) GetCapabilities(ctx context.Context, req *daemon.CapabilitiesRequest) (*daemon.CapabilitiesResponse, error) {
+	resp := &daemon.CapabilitiesResponse{
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	for _, f := range features.Compiled() {
+		resp.Features = append(resp.Features, &daemon.CompiledFeature{
+			Kind:   f.Kind,
+			Name:   f.Name,
+			Detail: f.Detail,
+		})
+	}
+	for _, p := range features.Privileges() {
+		resp.Privileges = append(resp.Privileges, &daemon.PrivilegeCheck{
+			Name:   p.Name,
+			Ok:     p.OK,
+			Detail: p.Detail,
+		})
+	}
+
+	srv := s.config.Server
+	subsystems := []features.Subsystem{
+		{Name: "strategy_runner", Enabled: s.strategyRunner != nil, Detail: s.config.StrategyRunner.ConfigPath},
+		{Name: "config_watcher", Enabled: s.strategyRunner != nil && s.config.StrategyRunner.Watch},
+		{Name: "network_listener", Enabled: srv.NetworkAddress != "", Detail: srv.NetworkAddress},
+		{Name: "auth", Enabled: srv.AuthToken != "", Detail: "bearer token on the network listener"},
+		{Name: "web_ui", Enabled: srv.WebUI, Detail: "read-only status page at /"},
+		{Name: "metrics", Enabled: true, Detail: "RPC snapshot, `zapret metrics` renders Prometheus text"},
+		{Name: "metrics_endpoint", Enabled: srv.MetricsEnabled, Detail: "Prometheus metrics at /metrics"},
+	}
+
+	if s.strategyRunner != nil {
+		caps := s.strategyRunner.Capabilities()
+		resp.KernelCapabilities = kernelCapabilities(caps.Kernel)
+		resp.Nfqws = &daemon.NfqwsInfo{
+			Binary:      caps.NFQWS.Binary,
+			Kind:        caps.NFQWS.Kind,
+			OptionCount: int32(caps.NFQWS.Options),
+			Supported:   caps.NFQWS.Supported,
+			Unsupported: caps.NFQWS.Unsupported,
+			Error:       caps.NFQWS.Error,
+			External:    caps.ExternalProcesses,
+		}
+		subsystems = append(subsystems, caps.Subsystems...)
+	}
+
+	for _, sub := range subsystems {
+		resp.Subsystems = append(resp.Subsystems, &daemon.Subsystem{
+			Name:    sub.Name,
+			Enabled: sub.Enabled,
+			Detail:  sub.Detail,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,114 @@
+package daemonserver
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// newChangelogClient serves a changelog with n entries over Twirp.
+func newChangelogClient(t *testing.T, n int) daemon.ZapretDaemon {
+	t.Helper()
+	changelog := strategyrunner.NewChangelog(strategyrunner.ChangelogConfig{
+		File:     filepath.Join(t.TempDir(), "changelog.jsonl"),
+		MaxSize:  1 << 20,
+		MaxFiles: 1,
+	})
+	for i := range n {
+		rules := []strategyrunner.ParsedRule{{Protocol: "tcp", Ports: "443", QueueNum: 200 + i%2}}
+		if _, err := changelog.Append("strategy.bat", []byte(fmt.Sprint(i)), "nftables", rules); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Server{
+		logger:        slog.New(slog.DiscardHandler),
+		rpcMetrics:    newRPCMetrics(),
+		readChangelog: changelog.Read,
+	}
+	srv := httptest.NewServer(daemon.NewZapretDaemonServer(s))
+	t.Cleanup(srv.Close)
+	return daemon.NewZapretDaemonProtobufClient(srv.URL, srv.Client())
+}
+
+func TestGetChangelogPagination(t *testing.T) {
+	client := newChangelogClient(t, 5)
+
+	var (
+		seqs     []uint64
+		afterSeq uint64
+		pages    int
+	)
+	for {
+		resp, err := client.GetChangelog(t.Context(), &daemon.ChangelogRequest{AfterSeq: afterSeq, Limit: 2})
+		if err != nil {
+			t.Fatalf("GetChangelog() error = %v", err)
+		}
+		if resp.ChainError != "" {
+			t.Fatalf("ChainError = %s", resp.ChainError)
+		}
+		pages++
+		for _, e := range resp.Entries {
+			seqs = append(seqs, e.Seq)
+			if e.Hash == "" || len(e.Rules) != 1 {
+				t.Errorf("entry %d = %v, want a hash and one rule", e.Seq, e)
+			}
+		}
+		if resp.NextSeq == 0 {
+			break
+		}
+		afterSeq = resp.NextSeq
+	}
+
+	if pages != 3 {
+		t.Errorf("read %d pages, want 3", pages)
+	}
+	if fmt.Sprint(seqs) != "[1 2 3 4 5]" {
+		t.Errorf("entries = %v, want [1 2 3 4 5]", seqs)
+	}
+}
+
+func TestGetChangelogSince(t *testing.T) {
+	client := newChangelogClient(t, 3)
+
+	since := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	resp, err := client.GetChangelog(t.Context(), &daemon.ChangelogRequest{Since: since})
+	if err != nil {
+		t.Fatalf("GetChangelog() error = %v", err)
+	}
+	if len(resp.Entries) != 0 || resp.NextSeq != 0 {
+		t.Errorf("GetChangelog() since the future = %d entries, next %d, want none", len(resp.Entries), resp.NextSeq)
+	}
+}
+
+func TestGetChangelogErrors(t *testing.T) {
+	client := newChangelogClient(t, 1)
+
+	tests := []struct {
+		name string
+		req  *daemon.ChangelogRequest
+		want twirp.ErrorCode
+	}{
+		{name: "bad since", req: &daemon.ChangelogRequest{Since: "yesterday"}, want: twirp.InvalidArgument},
+		{name: "negative limit", req: &daemon.ChangelogRequest{Limit: -1}, want: twirp.InvalidArgument},
+	}
+	for _, tt := range tests {
+		_, err := client.GetChangelog(t.Context(), tt.req)
+		if terr, ok := err.(twirp.Error); !ok || terr.Code() != tt.want {
+			t.Errorf("%s: GetChangelog() error = %v, want %s", tt.name, err, tt.want)
+		}
+	}
+
+	disabled := &Server{logger: slog.New(slog.DiscardHandler)}
+	_, err := disabled.GetChangelog(t.Context(), &daemon.ChangelogRequest{})
+	if terr, ok := err.(twirp.Error); !ok || terr.Code() != twirp.FailedPrecondition {
+		t.Errorf("GetChangelog() without a runner error = %v, want %s", err, twirp.FailedPrecondition)
+	}
+}
@@ -0,0 +1,40 @@
+package daemonserver
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+)
+
+// longRunningMethods lists RPC methods that may legitimately exceed the regular write timeout.
+var longRunningMethods = map[string]bool{
+	"Restart":         true,
+	"InstallStrategy": true,
+	"Start":           true,
+	"Stop":            true,
+}
+
+// WithDeadlines wraps handler with per-request deadlines.
+// Regular methods get writeTimeout, long-running methods get longTimeout.
+// The deadline is applied both to the request context and the connection write deadline,
+// so the HTTP server itself should not set a server-wide WriteTimeout.
+func WithDeadlines(handler http.Handler, writeTimeout, longTimeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := writeTimeout
+		if longRunningMethods[path.Base(r.URL.Path)] {
+			timeout = longTimeout
+		}
+
+		if timeout > 0 {
+			// Leave some headroom to write the response after the handler gives up
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + time.Second))
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
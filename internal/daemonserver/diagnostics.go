@@ -0,0 +1,73 @@
+package daemonserver
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// RunDiagnostics implements the RunDiagnostics RPC method. Without the
+// strategy runner there is nothing to check but that it is disabled.
+func (s *Server) RunDiagnostics(ctx context.Context, req *daemon.DiagnosticsRequest) (*daemon.DiagnosticsResponse, error) {
+	if s.strategyRunner == nil {
+		return &daemon.DiagnosticsResponse{Checks: []*daemon.DiagnosticCheck{{
+			Name:       "strategy_runner",
+			State:      strategyrunner.CheckFail,
+			Message:    "strategy runner is not enabled",
+			Suggestion: "set strategy_runner.enabled: true in the daemon config and restart it",
+		}}}, nil
+	}
+
+	resp := &daemon.DiagnosticsResponse{}
+	for _, res := range s.strategyRunner.RunDiagnostics() {
+		resp.Checks = append(resp.Checks, &daemon.DiagnosticCheck{
+			Name:       res.Check,
+			State:      res.State,
+			Message:    res.Message,
+			Suggestion: res.Suggestion,
+		})
+	}
+	return resp, nil
+}
+
+// ProbeDNS implements the ProbeDNS RPC method. It works whether or not the
+// strategy runner is running.
+func (s *Server) ProbeDNS(ctx context.Context, req *daemon.ProbeDNSRequest) (*daemon.ProbeDNSResponse, error) {
+	if req.Domain == "" {
+		return nil, twirp.RequiredArgumentError("domain")
+	}
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	result, err := s.strategyRunner.ProbeDNS(ctx, req.Domain)
+	if err != nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	}
+
+	check := strategyrunner.DNSCheckResult(result)
+	return &daemon.ProbeDNSResponse{
+		Domain:      result.Domain,
+		Verdict:     result.Verdict,
+		Detail:      result.Detail,
+		SystemAddrs: addrStrings(result.System),
+		SystemError: result.SystemError,
+		DohAddrs:    addrStrings(result.DoH),
+		DohError:    result.DoHError,
+		BogusAddrs:  addrStrings(result.Bogus),
+		State:       check.State,
+		Suggestion:  check.Suggestion,
+	}, nil
+}
+
+// addrStrings formats addresses for a response.
+func addrStrings(addrs []netip.Addr) []string {
+	out := make([]string, len(addrs))
+	for i, addr := range addrs {
+		out[i] = addr.String()
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package daemonserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// Start implements the Start RPC method.
+// Like a restart, the start runs detached from the request, so a dropped
+// connection never aborts it half-way.
+func (s *Server) Start(ctx context.Context, req *daemon.StartRequest) (*daemon.StartResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	s.logger.Info("start requested")
+
+	err := s.strategyRunner.Start(context.WithoutCancel(ctx))
+	if errors.Is(err, strategyrunner.ErrAlreadyRunning) {
+		return &daemon.StartResponse{
+			Message: "strategy runner is already running",
+			Running: true,
+		}, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to start strategy runner", slog.Any("error", err))
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &daemon.StartResponse{
+		Message: "strategy runner started",
+		Running: s.strategyRunner.GetStatus().Running,
+	}, nil
+}
+
+// Stop implements the Stop RPC method.
+// The daemon keeps serving requests; the runner is started again by Start.
+func (s *Server) Stop(ctx context.Context, req *daemon.StopRequest) (*daemon.StopResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	s.logger.Info("stop requested")
+
+	err := s.strategyRunner.Stop(context.WithoutCancel(ctx))
+	if errors.Is(err, strategyrunner.ErrNotRunning) {
+		return &daemon.StopResponse{
+			Message: "strategy runner is already stopped",
+			Running: false,
+		}, nil
+	}
+	if err != nil {
+		// The runner is stopped even when cleanup partly failed
+		s.logger.Error("errors while stopping strategy runner", slog.Any("error", err))
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &daemon.StopResponse{
+		Message: "strategy runner stopped",
+		Running: s.strategyRunner.GetStatus().Running,
+	}, nil
+}
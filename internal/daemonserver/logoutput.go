@@ -0,0 +1,80 @@
+package daemonserver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// LogOutput is the destination of the daemon logs configured by
+// logging.output. A file output can be reopened after it was rotated.
+type LogOutput struct {
+	mu   sync.Mutex
+	path string // empty for stdout and stderr
+	w    io.Writer
+	file *os.File
+}
+
+// OpenLogOutput opens output, which is "stdout", "stderr" or a file path the
+// logs are appended to.
+func OpenLogOutput(output string) (*LogOutput, error) {
+	switch output {
+	case "", "stdout":
+		return &LogOutput{w: os.Stdout}, nil
+	case "stderr":
+		return &LogOutput{w: os.Stderr}, nil
+	}
+
+	file, err := openLogFile(output)
+	if err != nil {
+		return nil, err
+	}
+	return &LogOutput{path: output, w: file, file: file}, nil
+}
+
+func openLogFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
+
+// Write writes p to the current output.
+func (o *LogOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.w.Write(p)
+}
+
+// Reopen opens the log file again, so logs go to a new file after the old
+// one was moved away by logrotate or a similar tool. The old file is kept
+// if the new one can't be opened. Stdout and stderr aren't reopened.
+func (o *LogOutput) Reopen() error {
+	if o.path == "" {
+		return nil
+	}
+
+	file, err := openLogFile(o.path)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	old := o.file
+	o.w, o.file = file, file
+	o.mu.Unlock()
+
+	return old.Close()
+}
+
+// Close closes the log file. Stdout and stderr are left open.
+func (o *LogOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Close()
+}
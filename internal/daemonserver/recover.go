@@ -0,0 +1,100 @@
+package daemonserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/twitchtv/twirp"
+)
+
+// requestIDKey holds the ID of the request being served.
+type requestIDKey struct{}
+
+// requestIDHeader carries a client supplied request ID and echoes the one used.
+const requestIDHeader = "X-Request-Id"
+
+// lastRequestID numbers requests that come without an ID.
+var lastRequestID atomic.Uint64
+
+// requestID returns the ID of the request being served, "" outside a request.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// recordingWriter remembers whether the response has been started.
+type recordingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection, for per-request deadlines.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithRecovery assigns every request an ID and turns a panic in handler into
+// a Twirp internal error, so a bug in one handler can't take down the daemon
+// and the firewall state it manages. The panic is logged with its stack trace
+// and counted in metrics.
+func WithRecovery(handler http.Handler, logger *slog.Logger, metrics *rpcMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = strconv.FormatUint(lastRequestID.Add(1), 10)
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+		rw := &recordingWriter{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// Deliberate abort of the response, not a bug
+				panic(p)
+			}
+
+			method := path.Base(r.URL.Path)
+			metrics.recordPanic(method)
+			logger.Error("panic while serving request",
+				slog.String("request_id", id),
+				slog.String("method", method),
+				slog.String("panic", fmt.Sprint(p)),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			// Twirp answers panics in handlers itself before re-raising them
+			if !rw.wroteHeader {
+				_ = twirp.WriteError(rw, twirp.InternalError("internal server error"))
+			}
+		}()
+
+		handler.ServeHTTP(rw, r)
+	})
+}
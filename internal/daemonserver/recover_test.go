@@ -0,0 +1,128 @@
+package daemonserver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// logBuffer collects log output written by server goroutines.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// panickingDaemon panics in the first GetStatus call the way a handler bug
+// would and serves every later call. Other methods are not implemented.
+type panickingDaemon struct {
+	daemon.ZapretDaemon
+	calls atomic.Int32
+}
+
+func (d *panickingDaemon) GetStatus(ctx context.Context, req *daemon.StatusRequest) (*daemon.StatusResponse, error) {
+	if d.calls.Add(1) == 1 {
+		var states map[string]int
+		states["running"] = 1
+	}
+	return &daemon.StatusResponse{Running: true}, nil
+}
+
+func TestRecoveryKeepsServing(t *testing.T) {
+	var log logBuffer
+	logger := slog.New(slog.NewTextHandler(&log, nil))
+	metrics := newRPCMetrics()
+	twirpServer := daemon.NewZapretDaemonServer(&panickingDaemon{}, twirp.WithServerHooks(serverHooks(logger, metrics)))
+
+	srv := httptest.NewServer(WithRecovery(twirpServer, logger, metrics))
+	defer srv.Close()
+	client := daemon.NewZapretDaemonProtobufClient(srv.URL, srv.Client())
+
+	ctx, err := twirp.WithHTTPRequestHeaders(t.Context(), http.Header{requestIDHeader: {"test-42"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetStatus(ctx, &daemon.StatusRequest{})
+	if terr, ok := err.(twirp.Error); !ok || terr.Code() != twirp.Internal {
+		t.Fatalf("GetStatus() of the panicking handler error = %v, want a twirp internal error", err)
+	}
+
+	for i := range 3 {
+		resp, err := client.GetStatus(t.Context(), &daemon.StatusRequest{})
+		if err != nil || !resp.Running {
+			t.Fatalf("GetStatus() #%d after the panic = %v, %v, want served", i+2, resp, err)
+		}
+	}
+
+	out := log.String()
+	for _, want := range []string{"level=ERROR", `msg="panic while serving request"`, "request_id=test-42", "method=GetStatus", "assignment to entry in nil map", "stack="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("panic log misses %q:\n%s", want, out)
+		}
+	}
+
+	var snap daemon.SnapshotResponse
+	metrics.fill(&snap)
+	if snap.RpcPanics != 1 {
+		t.Errorf("RpcPanics = %d, want 1", snap.RpcPanics)
+	}
+	if len(snap.RpcMethods) != 1 || snap.RpcMethods[0].Method != "GetStatus" || snap.RpcMethods[0].Panics != 1 {
+		t.Errorf("RpcMethods = %v, want GetStatus with 1 panic", snap.RpcMethods)
+	}
+}
+
+func TestRecoveryWritesError(t *testing.T) {
+	metrics := newRPCMetrics()
+	handler := WithRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestID(r.Context()) == "" {
+			t.Error("request has no ID")
+		}
+		panic("boom")
+	}), slog.New(slog.DiscardHandler), metrics)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/twirp/zapret.daemon.ZapretDaemon/ListRules", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"internal"`) {
+		t.Errorf("body = %s, want a twirp internal error", rec.Body)
+	}
+	if rec.Header().Get(requestIDHeader) == "" {
+		t.Errorf("response has no %s header", requestIDHeader)
+	}
+}
+
+func TestRecoveryRepanicsAbort(t *testing.T) {
+	handler := WithRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), slog.New(slog.DiscardHandler), newRPCMetrics())
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler passed on", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+}
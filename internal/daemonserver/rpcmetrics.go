@@ -0,0 +1,143 @@
+package daemonserver
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// rpcLatencyBuckets are the upper bounds in seconds of the RPC latency histogram.
+var rpcLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// requestStartKey holds when a request was routed to its method.
+type requestStartKey struct{}
+
+// methodStats are the counters of one RPC method.
+type methodStats struct {
+	requests uint64
+	errors   uint64
+	panics   uint64
+	buckets  []uint64 // per bucket of rpcLatencyBuckets, not cumulative
+	sum      float64
+}
+
+// rpcMetrics counts requests, errors, panics and latencies per RPC method.
+type rpcMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*methodStats
+	panics  uint64
+}
+
+// newRPCMetrics creates empty RPC metrics.
+func newRPCMetrics() *rpcMetrics {
+	return &rpcMetrics{methods: make(map[string]*methodStats)}
+}
+
+// method returns the stats of method, creating them. Caller must hold m.mu.
+func (m *rpcMetrics) method(name string) *methodStats {
+	stats, ok := m.methods[name]
+	if !ok {
+		stats = &methodStats{buckets: make([]uint64, len(rpcLatencyBuckets))}
+		m.methods[name] = stats
+	}
+	return stats
+}
+
+// observe records a served request.
+func (m *rpcMetrics) observe(name string, duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.method(name)
+	stats.requests++
+	if failed {
+		stats.errors++
+	}
+	seconds := duration.Seconds()
+	stats.sum += seconds
+	if i := sort.SearchFloat64s(rpcLatencyBuckets, seconds); i < len(rpcLatencyBuckets) {
+		stats.buckets[i]++
+	}
+}
+
+// recordPanic records a recovered panic of a request for method.
+func (m *rpcMetrics) recordPanic(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.panics++
+	m.method(name).panics++
+}
+
+// fill adds the RPC metrics to a snapshot response.
+func (m *rpcMetrics) fill(resp *daemon.SnapshotResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resp.RpcPanics = m.panics
+	for name, stats := range m.methods {
+		info := &daemon.RpcMethodStats{
+			Method:             name,
+			Requests:           stats.requests,
+			Errors:             stats.errors,
+			Panics:             stats.panics,
+			BucketBounds:       rpcLatencyBuckets,
+			DurationSumSeconds: stats.sum,
+		}
+		var cumulative uint64
+		for _, n := range stats.buckets {
+			cumulative += n
+			info.BucketCounts = append(info.BucketCounts, cumulative)
+		}
+		resp.RpcMethods = append(resp.RpcMethods, info)
+	}
+	sort.Slice(resp.RpcMethods, func(i, j int) bool { return resp.RpcMethods[i].Method < resp.RpcMethods[j].Method })
+}
+
+// serverHooks returns the Twirp hooks logging requests and recording their
+// latency in metrics.
+func serverHooks(logger *slog.Logger, metrics *rpcMetrics) *twirp.ServerHooks {
+	return &twirp.ServerHooks{
+		RequestReceived: func(ctx context.Context) (context.Context, error) {
+			logger.Debug("request received", slog.String("request_id", requestID(ctx)))
+			return ctx, nil
+		},
+		RequestRouted: func(ctx context.Context) (context.Context, error) {
+			method, _ := twirp.MethodName(ctx)
+			logger.Debug("request routed",
+				slog.String("request_id", requestID(ctx)),
+				slog.String("method", method),
+			)
+			return context.WithValue(ctx, requestStartKey{}, time.Now()), nil
+		},
+		ResponsePrepared: func(ctx context.Context) context.Context {
+			logger.Debug("response prepared", slog.String("request_id", requestID(ctx)))
+			return ctx
+		},
+		ResponseSent: func(ctx context.Context) {
+			start, ok := ctx.Value(requestStartKey{}).(time.Time)
+			if !ok {
+				// Not routed to a method
+				return
+			}
+			method, _ := twirp.MethodName(ctx)
+			status, _ := twirp.StatusCode(ctx)
+			metrics.observe(method, time.Since(start), status != "200")
+		},
+		Error: func(ctx context.Context, err twirp.Error) context.Context {
+			method, _ := twirp.MethodName(ctx)
+			logger.Error("twirp error",
+				slog.String("request_id", requestID(ctx)),
+				slog.String("method", method),
+				slog.String("code", string(err.Code())),
+				slog.String("msg", err.Msg()),
+			)
+			return ctx
+		},
+	}
+}
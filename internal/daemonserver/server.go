@@ -2,15 +2,18 @@ package daemonserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"os"
+	"sync"
 	"time"
 
-	"github.com/twitchtv/twirp"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/logdedup"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
 )
 
 // Server implements the ZapretDaemon service.
@@ -19,6 +22,14 @@ type Server struct {
 	startTime      time.Time
 	restartCount   int
 	strategyRunner *strategyrunner.Runner
+	mu             sync.Mutex
+	restart        *restartFlight
+	restartRunner  func(ctx context.Context, filter *strategyrunner.RuleFilter, canary, force bool) error
+	readChangelog  func(since time.Time, afterSeq uint64, limit int) (*strategyrunner.ChangelogPage, error)
+	stopRunner     func(ctx context.Context) error
+	forceStop      func()
+	rpcMetrics     *rpcMetrics
+	config         *config.Config
 }
 
 // NewServer creates a new daemon server instance.
@@ -33,49 +44,165 @@ func NewServer(logger *slog.Logger, cfg *config.Config) (*Server, error) {
 		}
 	}
 
-	return &Server{
+	s := &Server{
 		logger:         logger,
 		startTime:      time.Now(),
 		strategyRunner: runner,
-	}, nil
+		rpcMetrics:     newRPCMetrics(),
+		config:         cfg,
+	}
+	s.restartRunner = s.restartStrategyRunner
+	if runner != nil {
+		s.readChangelog = runner.Changelog
+		s.stopRunner = runner.Stop
+		s.forceStop = runner.ForceStop
+	}
+	return s, nil
+}
+
+// restartStrategyRunner reloads the strategy runner, if it is enabled.
+func (s *Server) restartStrategyRunner(ctx context.Context, filter *strategyrunner.RuleFilter, canary, force bool) error {
+	if s.strategyRunner == nil {
+		return nil
+	}
+	if canary {
+		return s.strategyRunner.RestartVerified(ctx, filter, force)
+	}
+	return s.strategyRunner.RestartFiltered(ctx, filter, force)
 }
 
 // Restart implements the Restart RPC method.
+// The restart itself runs detached from the request: if the client gives up waiting,
+// the reload still completes and concurrent callers join the one already in progress.
 func (s *Server) Restart(ctx context.Context, req *daemon.RestartRequest) (*daemon.RestartResponse, error) {
-	s.logger.Info("restart requested",
-		slog.Bool("force", req.Force),
-		slog.Int("restart_count", s.restartCount),
-	)
-
 	// Validate request
 	if req == nil {
 		return nil, twirp.RequiredArgumentError("request")
 	}
 
-	// If strategy runner is enabled, restart it
-	if s.strategyRunner != nil {
-		if err := s.strategyRunner.Restart(ctx); err != nil {
-			s.logger.Error("failed to restart strategy runner", slog.Any("error", err))
-			return nil, twirp.InternalErrorWith(err)
-		}
+	filter := &strategyrunner.RuleFilter{
+		Label:    req.OnlyLabel,
+		Protocol: req.OnlyProto,
+		Port:     req.OnlyPort,
+	}
+	for _, index := range req.OnlyRules {
+		filter.Indices = append(filter.Indices, int(index))
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, twirp.InvalidArgumentError("filter", err.Error())
+	}
+	if !filter.IsZero() && s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
 	}
 
-	// Perform restart tracking
-	restartedAt := time.Now()
-	s.restartCount++
-	s.startTime = restartedAt
-
-	s.logger.Info("strategy runner restarted successfully",
-		slog.Time("restarted_at", restartedAt),
-		slog.Int("total_restarts", s.restartCount),
+	s.logger.Info("restart requested",
+		slog.Bool("force", req.Force),
+		slog.Bool("canary", req.Canary),
+		slog.Bool("detach", req.Detach),
+		slog.String("filter", filter.String()),
+		slog.Int("restart_count", s.GetRestartCount()),
 	)
 
+	flight, err := s.beginRestart(ctx, filter, req.Canary, req.Force)
+	if err != nil {
+		return nil, err
+	}
+	if req.Detach {
+		return &daemon.RestartResponse{Message: "strategy runner restart started, follow it with `zapret status`"}, nil
+	}
+
+	select {
+	case <-flight.done:
+	case <-ctx.Done():
+		s.logger.Warn("client stopped waiting for restart, continuing in background")
+		return nil, twirp.NewError(twirp.DeadlineExceeded, "restart still in progress")
+	}
+
+	if errors.Is(flight.err, strategyrunner.ErrRunnerStopped) {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is stopped, start it with `zapret start`")
+	}
+	if errors.Is(flight.err, strategyrunner.ErrFilterNoMatch) || errors.Is(flight.err, strategyrunner.ErrCanaryFailed) {
+		return nil, twirp.NewError(twirp.FailedPrecondition, flight.err.Error())
+	}
+	if flight.err != nil {
+		return nil, twirp.InternalErrorWith(flight.err)
+	}
+
 	return &daemon.RestartResponse{
-		Message:     fmt.Sprintf("strategy runner restarted successfully (restart #%d)", s.restartCount),
-		RestartedAt: restartedAt.Format(time.RFC3339),
+		Message:     fmt.Sprintf("strategy runner restarted successfully (restart #%d)", flight.count),
+		RestartedAt: flight.restartedAt.Format(time.RFC3339),
 	}, nil
 }
 
+// restartFlight tracks a restart shared by all callers that arrive while it runs.
+type restartFlight struct {
+	done        chan struct{}
+	err         error
+	restartedAt time.Time
+	count       int
+	filter      string
+	canary      bool
+	force       bool
+}
+
+// beginRestart returns the in-progress restart or starts a new one. Callers
+// only share a restart applying the same rule filter, canary and force setting.
+func (s *Server) beginRestart(ctx context.Context, filter *strategyrunner.RuleFilter, canary, force bool) (*restartFlight, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.restart != nil {
+		if s.restart.filter != filter.String() {
+			return nil, twirp.NewError(twirp.Unavailable, "a restart with a different rule filter is in progress")
+		}
+		if s.restart.canary != canary {
+			return nil, twirp.NewError(twirp.Unavailable, "a restart with a different canary setting is in progress")
+		}
+		if s.restart.force != force {
+			return nil, twirp.NewError(twirp.Unavailable, "a restart with a different force setting is in progress")
+		}
+		s.logger.Info("restart already in progress, waiting for it")
+		return s.restart, nil
+	}
+
+	flight := &restartFlight{done: make(chan struct{}), filter: filter.String(), canary: canary, force: force}
+	s.restart = flight
+
+	// Detach from the request so a dropped connection never aborts a half-done reload
+	restartCtx := context.WithoutCancel(ctx)
+
+	go func() {
+		defer close(flight.done)
+
+		err := s.restartRunner(restartCtx, filter, canary, force)
+		if err != nil {
+			s.logger.Error("failed to restart strategy runner", slog.Any("error", err))
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.restart = nil
+		flight.err = err
+		if err != nil {
+			return
+		}
+
+		// Perform restart tracking
+		flight.restartedAt = time.Now()
+		s.restartCount++
+		s.startTime = flight.restartedAt
+		flight.count = s.restartCount
+
+		s.logger.Info("strategy runner restarted successfully",
+			slog.Time("restarted_at", flight.restartedAt),
+			slog.Int("total_restarts", s.restartCount),
+		)
+	}()
+
+	return flight, nil
+}
+
 // GetStatus implements the GetStatus RPC method.
 func (s *Server) GetStatus(ctx context.Context, req *daemon.StatusRequest) (*daemon.StatusResponse, error) {
 	if s.strategyRunner == nil {
@@ -84,30 +211,425 @@ func (s *Server) GetStatus(ctx context.Context, req *daemon.StatusRequest) (*dae
 		}, nil
 	}
 
-	status := s.strategyRunner.GetStatus()
+	return statusResponse(s.strategyRunner.GetStatus()), nil
+}
 
+// statusResponse converts a runner status to its RPC representation.
+func statusResponse(status *strategyrunner.Status) *daemon.StatusResponse {
 	var startTimeStr string
 	if !status.StartTime.IsZero() {
 		startTimeStr = status.StartTime.Format(time.RFC3339)
 	}
 
+	var pollInterval string
+	if status.PollInterval > 0 {
+		pollInterval = status.PollInterval.String()
+	}
+
+	var suspendedUntil string
+	if !status.SuspendedUntil.IsZero() {
+		suspendedUntil = status.SuspendedUntil.Format(time.RFC3339)
+	}
+
 	return &daemon.StatusResponse{
-		Running:         status.Running,
-		StrategyFile:    status.StrategyFile,
-		ActiveQueues:    int32(status.ActiveQueues),
-		ActiveProcesses: int32(status.ActiveProcesses),
-		FirewallBackend: status.FirewallBackend,
-		StartTime:       startTimeStr,
+		Running:            status.Running,
+		Phase:              status.Phase,
+		PhaseDone:          int32(status.PhaseDone),
+		PhaseTotal:         int32(status.PhaseTotal),
+		StrategyFile:       status.StrategyFile,
+		StrategySource:     status.StrategySource,
+		ActiveQueues:       int32(status.ActiveQueues),
+		ActiveProcesses:    int32(status.ActiveProcesses),
+		FirewallBackend:    status.FirewallBackend,
+		StartTime:          startTimeStr,
+		FirewallLastOpMs:   durationMs(status.FirewallLastOp),
+		FirewallMaxOpMs:    durationMs(status.FirewallMaxOp),
+		HostlistIndexBytes: status.HostlistMemory,
+		PendingRules:       int32(status.PendingRules),
+		Offload:            offloadFindings(status.Offload),
+		ProcessManagement:  status.ProcessManagement,
+		FirewallManagement: status.FirewallManagement,
+		UnboundQueues:      int32s(status.UnboundQueues),
+		QueueMapFile:       status.QueueMapFile,
+		RuleFilter:         status.RuleFilter,
+		FilteredRules:      int32(status.FilteredRules),
+		ScheduledOffRules:  int32(status.ScheduledOffRules),
+		ConfigPollInterval: pollInterval,
+		WatchFallback:      status.WatchFallback,
+		EmptyRuleset:       status.EmptyRuleset,
+		Conflicts:          conflicts(status.Conflicts),
+		KernelCapabilities: kernelCapabilities(status.KernelCapabilities),
+		NetlinkReconnects:  status.NetlinkReconnects,
+		SuspendedUntil:     suspendedUntil,
+		SuspendedQueues:    int32s(status.SuspendedQueues),
+		DegradedQueues:     int32s(status.DegradedQueues),
+
+		KernelQueuesUnavailable: status.KernelQueuesUnavailable,
+		ConfigVersion:           status.ConfigVersion,
+		ApplyPending:            status.ApplyPending,
+		Processes:               processInfos(status.Processes),
+		ZombiesReaped:           status.ZombiesReaped,
+		FirewallRules:           int32(status.FirewallRules),
+		ProcessRestarts:         status.ProcessRestarts,
+		ConfigReloads:           status.ConfigReloads,
+		ParseErrors:             status.ParseErrors,
+	}
+}
+
+// int32s converts ints to int32s.
+func int32s(values []int) []int32 {
+	var out []int32
+	for _, v := range values {
+		out = append(out, int32(v))
+	}
+	return out
+}
+
+// offloadFindings converts offload findings to their RPC representation.
+func offloadFindings(findings []strategyrunner.OffloadFinding) []*daemon.OffloadFinding {
+	var out []*daemon.OffloadFinding
+	for _, f := range findings {
+		out = append(out, &daemon.OffloadFinding{
+			Interface:  f.Interface,
+			Features:   f.Features,
+			FixCommand: f.FixCommand,
+			Fixed:      f.Fixed,
+			Error:      f.Err,
+		})
+	}
+	return out
+}
+
+// conflicts converts conflicts to their RPC representation.
+func conflicts(found []strategyrunner.Conflict) []*daemon.Conflict {
+	var out []*daemon.Conflict
+	for _, c := range found {
+		out = append(out, &daemon.Conflict{
+			Kind:   c.Kind,
+			Name:   c.Name,
+			Remedy: c.Remedy,
+		})
+	}
+	return out
+}
+
+// kernelCapabilities converts kernel capabilities to their RPC representation.
+func kernelCapabilities(caps []strategyrunner.KernelCapability) []*daemon.KernelCapability {
+	var out []*daemon.KernelCapability
+	for _, c := range caps {
+		out = append(out, &daemon.KernelCapability{
+			Name:   c.Name,
+			State:  c.State,
+			Module: c.Module,
+			Detail: c.Detail,
+		})
+	}
+	return out
+}
+
+// ListRules implements the ListRules RPC method.
+func (s *Server) ListRules(ctx context.Context, req *daemon.ListRulesRequest) (*daemon.ListRulesResponse, error) {
+	if s.strategyRunner == nil {
+		return &daemon.ListRulesResponse{}, nil
+	}
+
+	rules := s.strategyRunner.Rules()
+	resp := &daemon.ListRulesResponse{
+		Rules: make([]*daemon.RuleInfo, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		info := ruleInfo(rule)
+		if req.Render {
+			cmds, err := s.strategyRunner.RenderRule(rule)
+			if err != nil {
+				return nil, twirp.NewError(twirp.FailedPrecondition, "cannot render rules: "+err.Error())
+			}
+			info.FirewallCommands = cmds
+		}
+		resp.Rules = append(resp.Rules, info)
+	}
+
+	return resp, nil
+}
+
+// ExplainDomain implements the ExplainDomain RPC method.
+func (s *Server) ExplainDomain(ctx context.Context, req *daemon.ExplainDomainRequest) (*daemon.ExplainDomainResponse, error) {
+	if req.Domain == "" {
+		return nil, twirp.RequiredArgumentError("domain")
+	}
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	matches, err := s.strategyRunner.ExplainDomain(req.Domain)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	resp := &daemon.ExplainDomainResponse{}
+	for _, match := range matches {
+		resp.Matches = append(resp.Matches, &daemon.DomainRuleMatch{
+			Rule:    ruleInfo(match.Rule),
+			Matched: match.Matched,
+			Reason:  match.Reason,
+		})
+	}
+
+	return resp, nil
+}
+
+// CheckPort implements the CheckPort RPC method.
+func (s *Server) CheckPort(ctx context.Context, req *daemon.CheckPortRequest) (*daemon.CheckPortResponse, error) {
+	if req.Protocol == "" {
+		return nil, twirp.RequiredArgumentError("protocol")
+	}
+	if req.Port == 0 {
+		return nil, twirp.RequiredArgumentError("port")
+	}
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	check, err := s.strategyRunner.CheckPort(req.Protocol, int(req.Port))
+	if err != nil {
+		return nil, twirp.InvalidArgumentError("port", err.Error())
+	}
+
+	resp := &daemon.CheckPortResponse{ExternalFirewall: check.ExternalFirewall}
+	if check.Rule != nil {
+		resp.Queued = true
+		resp.QueueNum = int32(check.Rule.QueueNum)
+	}
+	for _, coverage := range check.Rules {
+		info := &daemon.PortCoverage{
+			Rule:    ruleInfo(coverage.Rule),
+			Covered: coverage.Covered,
+			Queued:  coverage.Queued,
+			Reason:  coverage.Reason,
+		}
+		for _, stage := range coverage.Stages {
+			ps := &daemon.PortStage{
+				Name:     stage.Name,
+				Spec:     stage.Spec,
+				Resolved: stage.Resolved,
+				Covered:  stage.Covered,
+			}
+			if stage.Covered {
+				ps.Range = stage.Range.String()
+			}
+			info.Stages = append(info.Stages, ps)
+		}
+		resp.Rules = append(resp.Rules, info)
+	}
+
+	return resp, nil
+}
+
+// GetHostlistStatus implements the GetHostlistStatus RPC method.
+func (s *Server) GetHostlistStatus(ctx context.Context, req *daemon.HostlistStatusRequest) (*daemon.HostlistStatusResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	resp := &daemon.HostlistStatusResponse{}
+	for _, st := range s.strategyRunner.HostlistStatus() {
+		resp.Sources = append(resp.Sources, &daemon.HostlistSource{
+			Url:         st.URL,
+			Path:        st.Path,
+			LastSuccess: formatTime(st.LastSuccess),
+			LastError:   st.LastError,
+			Size:        st.Size,
+			Entries:     int32(st.Entries),
+			Failures:    int32(st.Failures),
+			NextAttempt: formatTime(st.NextAttempt),
+		})
+	}
+
+	return resp, nil
+}
+
+// ListPayloads implements the ListPayloads RPC method.
+func (s *Server) ListPayloads(ctx context.Context, req *daemon.ListPayloadsRequest) (*daemon.ListPayloadsResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	resp := &daemon.ListPayloadsResponse{}
+	for _, p := range s.strategyRunner.Payloads() {
+		payload := &daemon.Payload{
+			Path:    p.Path,
+			Kind:    p.Kind,
+			Size:    p.Size,
+			Sha256:  p.SHA256,
+			Problem: p.Problem,
+		}
+		for _, line := range p.SourceLines {
+			payload.SourceLines = append(payload.SourceLines, int32(line))
+		}
+		for _, queue := range p.Queues {
+			payload.Queues = append(payload.Queues, int32(queue))
+		}
+		resp.Payloads = append(resp.Payloads, payload)
+	}
+
+	return resp, nil
+}
+
+// GetKernelQueues implements the GetKernelQueues RPC method.
+func (s *Server) GetKernelQueues(ctx context.Context, req *daemon.KernelQueuesRequest) (*daemon.KernelQueuesResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	queues, err := s.strategyRunner.KernelQueues()
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	resp := &daemon.KernelQueuesResponse{}
+	for _, q := range queues {
+		resp.Queues = append(resp.Queues, &daemon.KernelQueue{
+			Queue:        int32(q.Queue),
+			Label:        q.Label,
+			SourceLine:   int32(q.SourceLine),
+			Installed:    q.Installed,
+			Bound:        q.Bound,
+			PeerPortid:   q.PortID,
+			Pid:          int32(q.PID),
+			Length:       int32(q.Length),
+			CopyMode:     q.CopyMode,
+			CopyRange:    int32(q.CopyRange),
+			Dropped:      q.Dropped,
+			UserDropped:  q.UserDropped,
+			DropsGrowing: q.DropsGrowing,
+			Problems:     q.Problems,
+			Unknown:      q.Unknown,
+		})
+	}
+
+	return resp, nil
+}
+
+// formatTime formats t as RFC3339, or returns "" for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ruleInfo converts a parsed rule to its RPC representation.
+func ruleInfo(rule strategyrunner.ParsedRule) *daemon.RuleInfo {
+	return &daemon.RuleInfo{
+		QueueNum:       int32(rule.QueueNum),
+		Protocol:       rule.Protocol,
+		Ports:          rule.Ports,
+		Args:           rule.NFQWSArgs,
+		SourceLine:     int32(rule.SourceLine),
+		RateLimit:      int32(rule.RateLimit),
+		MissingFiles:   rule.MissingFiles,
+		QueuePreserved: rule.QueuePreserved,
+		StrippedArgs:   rule.StrippedArgs,
+		CompatError:    rule.CompatError,
+		Label:          rule.Label,
+		FilteredOut:    rule.FilteredOut,
+		Notrack:        rule.Notrack,
+		PayloadIssues:  rule.PayloadIssues,
+		ActiveHours:    rule.Schedule.Strings(),
+		ScheduledOff:   rule.ScheduledOff,
+		NextTransition: formatTime(rule.NextTransition),
+		PortAliases:    rule.PortAliases,
+		PortsResolved:  rule.PortsResolved,
+	}
+}
+
+// InstallStrategy implements the InstallStrategy RPC method.
+func (s *Server) InstallStrategy(ctx context.Context, req *daemon.InstallStrategyRequest) (*daemon.InstallStrategyResponse, error) {
+	if req.Name == "" {
+		return nil, twirp.RequiredArgumentError("name")
+	}
+	if len(req.Strategy) == 0 {
+		return nil, twirp.RequiredArgumentError("strategy")
+	}
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	s.logger.Info("strategy install requested",
+		slog.String("name", req.Name),
+		slog.Bool("activate", req.Activate),
+	)
+
+	paths, err := s.strategyRunner.InstallStrategy(ctx, &strategyrunner.InstallRequest{
+		Name:     req.Name,
+		Strategy: req.Strategy,
+		Lists:    req.Lists,
+		Activate: req.Activate,
+	})
+	if err != nil {
+		s.logger.Error("failed to install strategy", slog.Any("error", err))
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	message := fmt.Sprintf("strategy %s installed", req.Name)
+	if req.Activate {
+		message = fmt.Sprintf("strategy %s installed and activated", req.Name)
+	}
+
+	return &daemon.InstallStrategyResponse{
+		Message:        message,
+		InstalledPaths: paths,
 	}, nil
 }
 
+// ValidateStrategy implements the ValidateStrategy RPC method.
+func (s *Server) ValidateStrategy(ctx context.Context, req *daemon.ValidateStrategyRequest) (*daemon.ValidateStrategyResponse, error) {
+	if len(req.Strategy) == 0 {
+		return nil, twirp.RequiredArgumentError("strategy")
+	}
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	sim, err := s.strategyRunner.ValidateStrategy(req.Strategy, req.ApplySimulation)
+	if err != nil {
+		// An invalid strategy is a result, not a failure of the call
+		return &daemon.ValidateStrategyResponse{Errors: []string{err.Error()}}, nil
+	}
+
+	resp := &daemon.ValidateStrategyResponse{
+		Valid:    true,
+		Warnings: sim.Warnings,
+		Rules:    make([]*daemon.RuleInfo, 0, len(sim.Rules)),
+	}
+	for _, rule := range sim.Rules {
+		resp.Rules = append(resp.Rules, ruleInfo(rule))
+	}
+	for _, op := range sim.Operations {
+		resp.Operations = append(resp.Operations, &daemon.PlannedOperation{
+			Kind:        op.Kind,
+			Description: op.Description,
+		})
+	}
+	return resp, nil
+}
+
+// durationMs converts a duration to fractional milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // GetStartTime returns when the server was started.
 func (s *Server) GetStartTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.startTime
 }
 
 // GetRestartCount returns the number of times the server has been restarted.
 func (s *Server) GetRestartCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.restartCount
 }
 
@@ -115,8 +637,8 @@ func (s *Server) GetRestartCount() int {
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("shutting down daemon server")
 
-	if s.strategyRunner != nil {
-		if err := s.strategyRunner.Stop(ctx); err != nil {
+	if s.stopRunner != nil {
+		if err := s.stopRunner(ctx); err != nil && !errors.Is(err, strategyrunner.ErrNotRunning) {
 			s.logger.Error("failed to stop strategy runner during shutdown", slog.Any("error", err))
 			return err
 		}
@@ -126,6 +648,24 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Reload requests a reload of the strategy config, as sent by SIGHUP. It is
+// merged with other triggers like a changed watched file.
+func (s *Server) Reload(reason string) {
+	if s.strategyRunner == nil {
+		s.logger.Info("strategy runner is not enabled, nothing to reload", slog.String("trigger", reason))
+		return
+	}
+	s.strategyRunner.TriggerReload(reason)
+}
+
+// ForceShutdown kills nfqws processes and removes firewall rules without
+// waiting, for use when a graceful Shutdown exceeds its budget.
+func (s *Server) ForceShutdown() {
+	if s.forceStop != nil {
+		s.forceStop()
+	}
+}
+
 // NewTwirpServer creates a new Twirp HTTP handler for the daemon service.
 // It returns both the Twirp server and the underlying Server instance for cleanup.
 func NewTwirpServer(logger *slog.Logger, cfg *config.Config) (daemon.TwirpServer, *Server, error) {
@@ -142,39 +682,18 @@ func NewTwirpServer(logger *slog.Logger, cfg *config.Config) (daemon.TwirpServer
 		}
 	}
 
-	// Create Twirp server with hooks for logging
-	hooks := &twirp.ServerHooks{
-		RequestReceived: func(ctx context.Context) (context.Context, error) {
-			logger.Debug("request received")
-			return ctx, nil
-		},
-		RequestRouted: func(ctx context.Context) (context.Context, error) {
-			method, _ := twirp.MethodName(ctx)
-			logger.Debug("request routed", slog.String("method", method))
-			return ctx, nil
-		},
-		ResponsePrepared: func(ctx context.Context) context.Context {
-			logger.Debug("response prepared")
-			return ctx
-		},
-		Error: func(ctx context.Context, err twirp.Error) context.Context {
-			method, _ := twirp.MethodName(ctx)
-			logger.Error("twirp error",
-				slog.String("method", method),
-				slog.String("code", string(err.Code())),
-				slog.String("msg", err.Msg()),
-			)
-			return ctx
-		},
-	}
+	// Create Twirp server with hooks for logging and metrics
+	hooks := serverHooks(logger, server.rpcMetrics)
 
 	return daemon.NewZapretDaemonServer(server, twirp.WithServerHooks(hooks)), server, nil
 }
 
-// InitLogger initializes a structured logger with the specified level and format.
-func InitLogger(level, format string) *slog.Logger {
+// InitLogger initializes a structured logger writing to w from the logging
+// configuration. The returned flush function emits pending repeated-record
+// counts and must be called before exit.
+func InitLogger(cfg config.LoggingConfig, w io.Writer) (*slog.Logger, func()) {
 	var logLevel slog.Level
-	switch level {
+	switch cfg.Level {
 	case "debug":
 		logLevel = slog.LevelDebug
 	case "info":
@@ -192,11 +711,80 @@ func InitLogger(level, format string) *slog.Logger {
 	}
 
 	var handler slog.Handler
-	if format == "json" {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(w, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	if cfg.DedupWindow <= 0 {
+		return slog.New(handler), func() {}
+	}
+
+	var keys []string
+	if len(cfg.DedupKeys) > 0 {
+		keys = cfg.DedupKeys
+	}
+	dedup := logdedup.New(handler, logdedup.Options{Window: cfg.DedupWindow, Keys: keys})
+	return slog.New(dedup), func() { dedup.Flush() }
+}
+
+// GetChangelog implements the GetChangelog RPC method.
+func (s *Server) GetChangelog(ctx context.Context, req *daemon.ChangelogRequest) (*daemon.ChangelogResponse, error) {
+	if s.readChangelog == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	var since time.Time
+	if req.Since != "" {
+		t, err := time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			return nil, twirp.InvalidArgumentError("since", "must be an RFC3339 time")
+		}
+		since = t
+	}
+	if req.Limit < 0 {
+		return nil, twirp.InvalidArgumentError("limit", "must not be negative")
+	}
+
+	page, err := s.readChangelog(since, req.AfterSeq, int(req.Limit))
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	resp := &daemon.ChangelogResponse{
+		NextSeq:    page.NextSeq,
+		ChainError: page.ChainError,
 	}
+	for _, e := range page.Entries {
+		resp.Entries = append(resp.Entries, &daemon.ChangelogEntry{
+			Seq:             e.Seq,
+			Time:            e.Time.Format(time.RFC3339),
+			StrategyFile:    e.StrategyFile,
+			StrategyHash:    e.StrategyHash,
+			FirewallBackend: e.FirewallBackend,
+			Rules:           changelogRules(e.Rules),
+			Added:           changelogRules(e.Diff.Added),
+			Removed:         changelogRules(e.Diff.Removed),
+			StrategyChanged: e.Diff.StrategyChanged,
+			PreviousBackend: e.Diff.PreviousBackend,
+			PrevHash:        e.PrevHash,
+			Hash:            e.Hash,
+		})
+	}
+	return resp, nil
+}
 
-	return slog.New(handler)
+// changelogRules converts changelog rules to their RPC representation.
+func changelogRules(rules []strategyrunner.ChangelogRule) []*daemon.ChangelogRule {
+	var out []*daemon.ChangelogRule
+	for _, r := range rules {
+		out = append(out, &daemon.ChangelogRule{
+			Protocol: r.Protocol,
+			Ports:    r.Ports,
+			Queue:    int32(r.Queue),
+			ArgsHash: r.ArgsHash,
+		})
+	}
+	return out
 }
@@ -0,0 +1,317 @@
+package daemonserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// newTestServer returns a server whose restarts block until release is closed.
+func newTestServer(release <-chan struct{}, calls *atomic.Int32) *Server {
+	s := &Server{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		startTime:  time.Now(),
+		rpcMetrics: newRPCMetrics(),
+	}
+	s.restartRunner = func(ctx context.Context, filter *strategyrunner.RuleFilter, canary, force bool) error {
+		calls.Add(1)
+		<-release
+		return ctx.Err()
+	}
+	return s
+}
+
+func TestRestartOutlivesClient(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	s := newTestServer(release, &calls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := s.Restart(ctx, &daemon.RestartRequest{})
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.DeadlineExceeded {
+		t.Fatalf("Restart() error = %v, want %s", err, twirp.DeadlineExceeded)
+	}
+
+	s.mu.Lock()
+	flight := s.restart
+	s.mu.Unlock()
+	if flight == nil {
+		t.Fatal("restart was abandoned with the client")
+	}
+
+	close(release)
+	<-flight.done
+	if flight.err != nil {
+		t.Fatalf("detached restart failed: %v", flight.err)
+	}
+	if got := s.GetRestartCount(); got != 1 {
+		t.Errorf("restart count = %d, want 1", got)
+	}
+}
+
+func TestRestartDetach(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	s := newTestServer(release, &calls)
+
+	resp, err := s.Restart(t.Context(), &daemon.RestartRequest{Detach: true})
+	if err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if resp.RestartedAt != "" {
+		t.Errorf("RestartedAt = %q before the restart finished", resp.RestartedAt)
+	}
+
+	s.mu.Lock()
+	flight := s.restart
+	s.mu.Unlock()
+	if flight == nil {
+		t.Fatal("detached restart is not running")
+	}
+	close(release)
+	<-flight.done
+	if got := s.GetRestartCount(); got != 1 {
+		t.Errorf("restart count = %d, want 1", got)
+	}
+}
+
+func TestRestartConcurrentCallersShareFlight(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	s := newTestServer(release, &calls)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Restart(context.Background(), &daemon.RestartRequest{Force: true})
+			errs <- err
+		}()
+	}
+
+	// Let every caller join before the restart finishes
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Restart() error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("runner restarted %d times, want 1", got)
+	}
+}
+
+func TestRestartRejectsMismatchedFlight(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var calls atomic.Int32
+	s := newTestServer(release, &calls)
+
+	if _, err := s.beginRestart(context.Background(), &strategyrunner.RuleFilter{}, false, false); err != nil {
+		t.Fatalf("beginRestart() error = %v", err)
+	}
+
+	_, err := s.beginRestart(context.Background(), &strategyrunner.RuleFilter{}, false, true)
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.Unavailable {
+		t.Errorf("beginRestart() with different force error = %v, want %s", err, twirp.Unavailable)
+	}
+}
+
+func TestWithDeadlines(t *testing.T) {
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/twirp/zapret.daemon.ZapretDaemon/GetStatus", time.Second},
+		{"/twirp/zapret.daemon.ZapretDaemon/Restart", time.Minute},
+		{"/twirp/zapret.daemon.ZapretDaemon/InstallStrategy", time.Minute},
+	}
+
+	for _, tt := range tests {
+		var got time.Duration
+		handler := WithDeadlines(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok := r.Context().Deadline()
+			if !ok {
+				t.Errorf("%s: no deadline set", tt.path)
+				return
+			}
+			got = time.Until(deadline)
+		}), time.Second, time.Minute)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, tt.path, nil))
+		if got > tt.want || got < tt.want-time.Second/2 {
+			t.Errorf("%s: deadline in %v, want about %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// shutdownEvents records the order of what a shutdown sequence does.
+type shutdownEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *shutdownEvents) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *shutdownEvents) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.events)
+}
+
+// newShutdownTest returns a server with a fake runner whose stop runs
+// stop, serving RPCs that block until release is closed through a drainer.
+func newShutdownTest(t *testing.T, events *shutdownEvents, release <-chan struct{}, stop func(ctx context.Context) error) (*Server, *DrainHandler, *httptest.Server, string) {
+	t.Helper()
+	drainer := NewDrainHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		events.add("rpc finished")
+	}))
+	s := &Server{logger: slog.New(slog.DiscardHandler)}
+	s.stopRunner = func(ctx context.Context) error {
+		// RPCs are refused by now
+		rec := httptest.NewRecorder()
+		drainer.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/twirp/zapret.daemon.ZapretDaemon/GetStatus", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			events.add("rpc accepted")
+		}
+		events.add("stop runner")
+		return stop(ctx)
+	}
+	s.forceStop = func() { events.add("force stop") }
+
+	ts := httptest.NewServer(drainer)
+	t.Cleanup(ts.Close)
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return s, drainer, ts, socket
+}
+
+func TestShutdownPhaseOrder(t *testing.T) {
+	var events shutdownEvents
+	release := make(chan struct{})
+	s, drainer, ts, socket := newShutdownTest(t, &events, release, func(ctx context.Context) error {
+		return nil
+	})
+
+	// An RPC in flight when the shutdown begins
+	rpcDone := make(chan error, 1)
+	go func() {
+		resp, err := ts.Client().Post(ts.URL, "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		rpcDone <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		drainer.mu.Lock()
+		inflight := drainer.inflight
+		drainer.mu.Unlock()
+		if inflight > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("request never reached the handler")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	err := RunShutdown(5*time.Second, s.ShutdownPhases(drainer, ts.Config, socket), s.ForceShutdown, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("RunShutdown() error = %v", err)
+	}
+	if err := <-rpcDone; err != nil {
+		t.Errorf("in-flight RPC failed: %v", err)
+	}
+
+	// RPCs drain before the runner stops; nothing is forced
+	if got, want := events.get(), []string{"rpc finished", "stop runner"}; !slices.Equal(got, want) {
+		t.Errorf("shutdown events = %v, want %v", got, want)
+	}
+	if _, err := ts.Client().Get(ts.URL); err == nil {
+		t.Error("listener still accepting after the shutdown")
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket left after the shutdown: %v", err)
+	}
+}
+
+func TestShutdownForced(t *testing.T) {
+	var events shutdownEvents
+	release := make(chan struct{})
+	close(release)
+
+	// The runner stop hangs, ignoring its deadline, until forced
+	forced := make(chan struct{})
+	s, drainer, ts, socket := newShutdownTest(t, &events, release, func(ctx context.Context) error {
+		<-forced
+		return nil
+	})
+	s.forceStop = func() {
+		events.add("force stop")
+		close(forced)
+	}
+
+	start := time.Now()
+	err := RunShutdown(200*time.Millisecond, s.ShutdownPhases(drainer, ts.Config, socket), s.ForceShutdown, slog.New(slog.DiscardHandler))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunShutdown() error = %v, want the runner stop timed out", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("forced shutdown took %s", elapsed)
+	}
+
+	if got, want := events.get(), []string{"stop runner", "force stop"}; !slices.Equal(got, want) {
+		t.Errorf("shutdown events = %v, want %v", got, want)
+	}
+	// Closing the listeners is skipped, removing files is not
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Errorf("listener closed after the forced cleanup: %v", err)
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("request after the shutdown got status %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket left after the forced shutdown: %v", err)
+	}
+}
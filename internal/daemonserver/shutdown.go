@@ -0,0 +1,213 @@
+package daemonserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DrainHandler rejects new requests once draining has begun and tracks the
+// requests still in flight.
+type DrainHandler struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	draining bool
+	inflight int
+	idle     chan struct{} // closed when draining and no requests are in flight
+}
+
+// NewDrainHandler wraps next with request draining.
+func NewDrainHandler(next http.Handler) *DrainHandler {
+	return &DrainHandler{next: next, idle: make(chan struct{})}
+}
+
+// ServeHTTP implements http.Handler.
+func (d *DrainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		w.Header().Set("Connection", "close")
+		http.Error(w, "daemon is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	d.inflight++
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		d.inflight--
+		if d.draining && d.inflight == 0 {
+			close(d.idle)
+		}
+		d.mu.Unlock()
+	}()
+
+	d.next.ServeHTTP(w, r)
+}
+
+// Drain stops accepting new requests.
+func (d *DrainHandler) Drain() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return
+	}
+	d.draining = true
+	if d.inflight == 0 {
+		close(d.idle)
+	}
+}
+
+// Wait blocks until all in-flight requests have finished or ctx is done.
+// Drain must be called first.
+func (d *DrainHandler) Wait(ctx context.Context) error {
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownPhase is a step of the shutdown sequence.
+type ShutdownPhase struct {
+	// Name identifies the phase in logs
+	Name string
+
+	// Share is the fraction of the total shutdown budget the phase may use
+	Share float64
+
+	// Critical phases trigger the forced cleanup when they exceed their budget
+	Critical bool
+
+	// Always phases still run after the budget is exhausted
+	Always bool
+
+	// Run performs the phase; ctx expires when the phase budget is used up
+	Run func(ctx context.Context) error
+}
+
+// RunShutdown runs phases in order within total. Each phase gets its share
+// of the total, capped by what is left. When the total is exhausted or a
+// critical phase runs out of time, force is called and only the remaining
+// Always phases run.
+func RunShutdown(total time.Duration, phases []ShutdownPhase, force func(), logger *slog.Logger) error {
+	start := time.Now()
+	deadline := start.Add(total)
+	forced := false
+
+	var errs []error
+	for _, phase := range phases {
+		remaining := time.Until(deadline)
+		if !forced && remaining <= 0 {
+			logger.Error("shutdown budget exhausted, forcing cleanup", slog.Duration("budget", total))
+			force()
+			forced = true
+		}
+		if forced && !phase.Always {
+			logger.Warn("skipping shutdown phase", slog.String("phase", phase.Name))
+			continue
+		}
+
+		budget := time.Duration(float64(total) * phase.Share)
+		if !forced {
+			budget = min(budget, remaining)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), budget)
+		phaseStart := time.Now()
+
+		// Phases that ignore ctx are abandoned when their budget runs out
+		done := make(chan error, 1)
+		go func() { done <- phase.Run(ctx) }()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = fmt.Errorf("%s: %w", phase.Name, ctx.Err())
+		}
+		timedOut := ctx.Err() != nil
+		cancel()
+
+		attrs := []any{
+			slog.String("phase", phase.Name),
+			slog.Duration("duration", time.Since(phaseStart)),
+			slog.Duration("budget", budget),
+		}
+		if err != nil {
+			logger.Warn("shutdown phase failed", append(attrs, slog.Any("error", err))...)
+			errs = append(errs, err)
+		} else {
+			logger.Info("shutdown phase finished", attrs...)
+		}
+
+		if phase.Critical && timedOut && !forced {
+			logger.Error("shutdown phase exceeded its budget, forcing cleanup", slog.String("phase", phase.Name))
+			force()
+			forced = true
+		}
+	}
+
+	logger.Info("shutdown sequence finished",
+		slog.Duration("duration", time.Since(start)),
+		slog.Bool("forced", forced),
+	)
+	return errors.Join(errs...)
+}
+
+// ShutdownPhases returns the shutdown sequence of the daemon: new RPCs are
+// rejected and in-flight ones drained before the strategy runner stops, so
+// no request sees it half stopped. Stopping the runner is critical; when it
+// runs out of time ForceShutdown kills nfqws and removes the firewall rules.
+func (s *Server) ShutdownPhases(drainer *DrainHandler, httpServer *http.Server, socketPath string) []ShutdownPhase {
+	return []ShutdownPhase{
+		{
+			Name:  "stop_accepting",
+			Share: 0.05,
+			Run: func(ctx context.Context) error {
+				drainer.Drain()
+				return nil
+			},
+		},
+		{
+			Name:  "drain_requests",
+			Share: 0.2,
+			Run:   drainer.Wait,
+		},
+		{
+			Name:     "stop_runner",
+			Share:    0.6,
+			Critical: true,
+			Run:      s.Shutdown,
+		},
+		{
+			Name:  "close_listeners",
+			Share: 0.1,
+			Run: func(ctx context.Context) error {
+				if err := httpServer.Shutdown(ctx); err != nil {
+					httpServer.Close()
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			Name:   "remove_files",
+			Share:  0.1,
+			Always: true,
+			Run: func(ctx context.Context) error {
+				if socketPath == "" {
+					return nil
+				}
+				return os.RemoveAll(socketPath)
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package daemonserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// snapshotFieldNames maps field mask entries to snapshot parts.
+var snapshotFieldNames = map[string]strategyrunner.SnapshotFields{
+	"status":    strategyrunner.SnapshotStatus,
+	"rules":     strategyrunner.SnapshotRules,
+	"counters":  strategyrunner.SnapshotCounters,
+	"processes": strategyrunner.SnapshotProcesses,
+	"health":    strategyrunner.SnapshotHealth,
+	"reloads":   strategyrunner.SnapshotReloads,
+	"events":    strategyrunner.SnapshotEvents,
+}
+
+// GetSnapshot implements the GetSnapshot RPC method.
+func (s *Server) GetSnapshot(ctx context.Context, req *daemon.SnapshotRequest) (*daemon.SnapshotResponse, error) {
+	fields := strategyrunner.SnapshotAll
+	withRPC := true
+	if len(req.Fields) > 0 {
+		fields = 0
+		withRPC = false
+		for _, name := range req.Fields {
+			// RPC metrics are kept by the server, not the runner
+			if name == "rpc" {
+				withRPC = true
+				continue
+			}
+			field, ok := snapshotFieldNames[name]
+			if !ok {
+				return nil, twirp.InvalidArgumentError("fields", "unknown field "+name)
+			}
+			fields |= field
+		}
+	}
+
+	if s.strategyRunner == nil {
+		resp := &daemon.SnapshotResponse{
+			TakenAt: time.Now().Format(time.RFC3339),
+			Status:  &daemon.StatusResponse{Running: false},
+			Health:  strategyrunner.HealthStopped,
+		}
+		if withRPC {
+			s.rpcMetrics.fill(resp)
+		}
+		return resp, nil
+	}
+
+	snap := s.strategyRunner.Snapshot(ctx, fields, req.EventsSince)
+
+	resp := &daemon.SnapshotResponse{
+		TakenAt:      snap.TakenAt.Format(time.RFC3339),
+		Health:       snap.Health,
+		HealthReason: snap.HealthReason,
+		EventCursor:  snap.EventCursor,
+	}
+	if snap.Status != nil {
+		resp.Status = statusResponse(snap.Status)
+	}
+	for _, rule := range snap.Rules {
+		info := ruleInfo(rule)
+		if c, ok := snap.Counters[rule.QueueNum]; ok {
+			info.Packets = c.Packets
+			info.Bytes = c.Bytes
+		}
+		resp.Rules = append(resp.Rules, info)
+	}
+	if snap.CountersErr != nil {
+		resp.CountersError = snap.CountersErr.Error()
+	}
+	resp.Processes = processInfos(snap.Processes)
+	for _, reload := range snap.Reloads {
+		resp.Reloads = append(resp.Reloads, &daemon.ReloadInfo{
+			Time:       reload.Time.Format(time.RFC3339),
+			Triggers:   reload.Triggers,
+			DurationMs: durationMs(reload.Duration),
+			Error:      reload.Err,
+		})
+	}
+	for _, event := range snap.Events {
+		resp.Events = append(resp.Events, &daemon.EventInfo{
+			Seq:     event.Seq,
+			Time:    event.Time.Format(time.RFC3339),
+			Kind:    event.Kind,
+			Message: event.Message,
+		})
+	}
+	if withRPC {
+		s.rpcMetrics.fill(resp)
+	}
+
+	return resp, nil
+}
+
+// processInfos converts tracked processes to their RPC representation.
+func processInfos(procs []strategyrunner.ProcessInfo) []*daemon.ProcessInfo {
+	var out []*daemon.ProcessInfo
+	for _, proc := range procs {
+		info := &daemon.ProcessInfo{
+			Pid:       int32(proc.PID),
+			QueueNum:  int32(proc.QueueNum),
+			StartedAt: proc.StartedAt.Format(time.RFC3339),
+			Suspended: proc.Suspended,
+			Restarts:  int32(proc.Restarts),
+			Degraded:  proc.Degraded,
+			Running:   proc.Running,
+			Args:      strings.Join(proc.Args, " "),
+
+			LastExit:         proc.LastExit,
+			OomKills:         int32(proc.OOMKills),
+			MemoryLimitBytes: proc.MemoryLimit,
+		}
+		if proc.Running {
+			info.UptimeSeconds = int64(time.Since(proc.StartedAt).Seconds())
+		}
+		if proc.Stats != nil {
+			info.Stats = &daemon.QueueStats{
+				DesyncApplied:         proc.Stats.DesyncApplied,
+				HostlistHits:          proc.Stats.HostlistHits,
+				AutohostlistAdditions: proc.Stats.AutoHostlistAdditions,
+				ParseErrors:           proc.Stats.ParseErrors,
+			}
+		}
+		out = append(out, info)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package daemonserver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// SuspendProcesses implements the SuspendProcesses RPC method.
+func (s *Server) SuspendProcesses(ctx context.Context, req *daemon.SuspendProcessesRequest) (*daemon.SuspendProcessesResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	var timeout time.Duration
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 {
+			return nil, twirp.InvalidArgumentError("timeout", "must be a positive duration like 45m")
+		}
+		timeout = d
+	}
+
+	s.logger.Info("process suspend requested", slog.String("timeout", req.Timeout))
+
+	n, until, err := s.strategyRunner.SuspendProcesses(timeout)
+	if err != nil && n == 0 {
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		s.logger.Warn("some nfqws processes were not suspended", slog.Any("error", err))
+	}
+
+	return &daemon.SuspendProcessesResponse{
+		Processes: int32(n),
+		ResumeAt:  until.Format(time.RFC3339),
+	}, nil
+}
+
+// ResumeProcesses implements the ResumeProcesses RPC method.
+func (s *Server) ResumeProcesses(ctx context.Context, req *daemon.ResumeProcessesRequest) (*daemon.ResumeProcessesResponse, error) {
+	if s.strategyRunner == nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled")
+	}
+
+	s.logger.Info("process resume requested")
+
+	n, err := s.strategyRunner.ResumeProcesses()
+	if err != nil && n == 0 {
+		return nil, twirp.NewError(twirp.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		s.logger.Warn("some nfqws processes were not resumed", slog.Any("error", err))
+	}
+
+	return &daemon.ResumeProcessesResponse{Processes: int32(n)}, nil
+}
@@ -0,0 +1,71 @@
+package daemonserver
+
+import (
+	"bytes"
+	_ "embed"
+	"log/slog"
+	"net/http"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/metrics"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// indexHTML is the read-only status page. It polls GetSnapshot through the
+// Twirp JSON API and has no external assets.
+//
+//go:embed web/index.html
+var indexHTML []byte
+
+// NewHTTPHandler routes Twirp requests and, if enabled, the web status page
+// and the metrics endpoint. The page itself is public; the RPC calls it makes
+// and /metrics are subject to the auth token.
+func NewHTTPHandler(twirpHandler http.Handler, server *Server, cfg *config.ServerConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(daemon.ZapretDaemonPathPrefix,
+		WithRecovery(WithAuth(WithDeadlines(twirpHandler, cfg.WriteTimeout, cfg.LongRequestTimeout), cfg.AuthToken),
+			server.logger, server.rpcMetrics))
+
+	if cfg.WebUI {
+		mux.HandleFunc("GET /{$}", serveIndex)
+	}
+	if cfg.MetricsEnabled {
+		mux.Handle("GET /metrics",
+			WithRecovery(WithAuth(http.HandlerFunc(server.serveMetrics), cfg.AuthToken), server.logger, server.rpcMetrics))
+	}
+
+	return mux
+}
+
+// serveIndex serves the embedded status page.
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Security-Policy",
+		"default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'")
+	_, _ = w.Write(indexHTML)
+}
+
+// serveMetrics renders a snapshot in the Prometheus text format, the same
+// output as `zapret metrics`, for scraping.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	snap, err := s.GetSnapshot(r.Context(), &daemon.SnapshotRequest{Fields: metrics.SnapshotFields})
+	if err != nil {
+		s.logger.Error("failed to collect metrics", slog.Any("error", err))
+		http.Error(w, "failed to collect metrics", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf, snap, metrics.Options{}); err != nil {
+		s.logger.Error("failed to render metrics", slog.Any("error", err))
+		http.Error(w, "failed to render metrics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(buf.Bytes())
+}
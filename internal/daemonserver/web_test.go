@@ -0,0 +1,145 @@
+package daemonserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// snapshotEndpoint is the RPC the status page polls.
+const snapshotEndpoint = daemon.ZapretDaemonPathPrefix + "GetSnapshot"
+
+// newWebServer serves the HTTP handler of a daemon without a strategy runner,
+// requiring token on the network.
+func newWebServer(t *testing.T, webUI bool, token string) *httptest.Server {
+	t.Helper()
+	server, err := NewServer(slog.New(slog.DiscardHandler), &config.Config{})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	ts := httptest.NewServer(NewHTTPHandler(daemon.NewZapretDaemonServer(server), server, &config.ServerConfig{
+		AuthToken:          token,
+		WebUI:              webUI,
+		WriteTimeout:       15 * time.Second,
+		LongRequestTimeout: time.Minute,
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestWebIndex(t *testing.T) {
+	ts := newWebServer(t, true, "secret")
+
+	// The page itself is public, the token is asked for by its script
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET / status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want html", got)
+	}
+	if csp := resp.Header.Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'none'") {
+		t.Errorf("Content-Security-Policy = %q, want external assets denied", csp)
+	}
+	if !bytes.Equal(body, indexHTML) {
+		t.Error("GET / did not serve the embedded page")
+	}
+	if !strings.Contains(string(body), snapshotEndpoint) {
+		t.Errorf("page does not poll %s", snapshotEndpoint)
+	}
+
+	// Only the root is the page
+	resp, err = http.Get(ts.URL + "/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /index.html status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestWebIndexDisabled(t *testing.T) {
+	ts := newWebServer(t, false, "")
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET / status = %d with the web UI disabled, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestWebSnapshotAuth(t *testing.T) {
+	ts := newWebServer(t, true, "secret")
+
+	tests := []struct {
+		name string
+		auth string
+		want int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", auth: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "not a bearer token", auth: "secret", want: http.StatusUnauthorized},
+		{name: "token", auth: "Bearer secret", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+snapshotEndpoint, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized {
+				if got := resp.Header.Get("WWW-Authenticate"); got == "" {
+					t.Error("401 without WWW-Authenticate")
+				}
+				return
+			}
+
+			// The page reads these fields; a disabled runner reports it is stopped
+			var snap map[string]json.RawMessage
+			if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+				t.Fatalf("decoding the snapshot: %v", err)
+			}
+			for _, key := range []string{"taken_at", "status", "health"} {
+				if _, ok := snap[key]; !ok {
+					t.Errorf("snapshot has no %q: %v", key, snap)
+				}
+			}
+			var health string
+			json.Unmarshal(snap["health"], &health)
+			if health != strategyrunner.HealthStopped {
+				t.Errorf("health = %q, want %q", health, strategyrunner.HealthStopped)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+// Package dnsprobe tells DNS poisoning from DPI blocking: it resolves a
+// domain with the system resolver and with a DNS-over-HTTPS server and
+// compares the answers. Desync strategies can't help when the resolver
+// already answers with a block page or a bogus address.
+package dnsprobe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Probe verdicts.
+const (
+	// VerdictOK means the system resolver agrees with the DoH server
+	VerdictOK = "ok"
+
+	// VerdictMismatch means the answers share no address, or only the DoH
+	// server resolves the domain. CDNs answering by location cause this too
+	VerdictMismatch = "mismatch"
+
+	// VerdictBogus means the system resolver answered with an address in a
+	// bogus range: unroutable, loopback or a configured ISP block page
+	VerdictBogus = "bogus"
+
+	// VerdictError means the answers couldn't be compared
+	VerdictError = "error"
+)
+
+// DefaultTimeout bounds each resolver of a probe when Config.Timeout is 0.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultBogusRanges are answers blocking resolvers give, which no public
+// domain resolves to.
+var DefaultBogusRanges = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+}
+
+// Resolver is the part of *net.Resolver used for the system lookup.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// HTTPClient is the part of *http.Client used for DoH queries.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config contains probe settings.
+type Config struct {
+	// DoHURL is the RFC 8484 endpoint, e.g. https://cloudflare-dns.com/dns-query
+	DoHURL string
+
+	// Bootstrap is the address ("ip" or "ip:port") connected to for DoHURL
+	// instead of resolving its host, so the DoH query doesn't depend on the
+	// resolver being checked ("" resolves the host)
+	Bootstrap string
+
+	// Timeout bounds each resolver (DefaultTimeout if 0)
+	Timeout time.Duration
+
+	// BogusRanges are checked in addition to DefaultBogusRanges, e.g. the
+	// addresses of ISP block pages
+	BogusRanges []netip.Prefix
+
+	// Resolver is the system resolver (net.DefaultResolver if nil)
+	Resolver Resolver
+
+	// Client performs DoH queries (a client dialing Bootstrap if nil)
+	Client HTTPClient
+}
+
+// Result is the outcome of probing a domain.
+type Result struct {
+	Domain string
+
+	// System are the addresses from the system resolver
+	System      []netip.Addr
+	SystemError string
+
+	// DoH are the addresses from the DoH server
+	DoH      []netip.Addr
+	DoHError string
+
+	// Bogus are the system answers in a bogus range
+	Bogus []netip.Addr
+
+	// Verdict is one of the Verdict constants
+	Verdict string
+
+	// Detail explains the verdict
+	Detail string
+}
+
+// Prober compares the system resolver with a DoH server.
+type Prober struct {
+	endpoint *url.URL
+	timeout  time.Duration
+	bogus    []netip.Prefix
+	resolver Resolver
+	client   HTTPClient
+}
+
+// New creates a prober from cfg.
+func New(cfg Config) (*Prober, error) {
+	endpoint, err := url.Parse(cfg.DoHURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DoH URL: %w", err)
+	}
+	if endpoint.Scheme != "https" && endpoint.Scheme != "http" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid DoH URL %q: must be an https:// URL", cfg.DoHURL)
+	}
+
+	p := &Prober{
+		endpoint: endpoint,
+		timeout:  cfg.Timeout,
+		bogus:    append(slices.Clone(DefaultBogusRanges), cfg.BogusRanges...),
+		resolver: cfg.Resolver,
+		client:   cfg.Client,
+	}
+	if p.timeout <= 0 {
+		p.timeout = DefaultTimeout
+	}
+	if p.resolver == nil {
+		p.resolver = net.DefaultResolver
+	}
+	if p.client == nil {
+		client, err := NewClient(cfg.Bootstrap)
+		if err != nil {
+			return nil, err
+		}
+		p.client = client
+	}
+	return p, nil
+}
+
+// NewClient returns an HTTP client connecting to bootstrap for every
+// request. TLS still verifies the certificate against the URL host.
+func NewClient(bootstrap string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if bootstrap != "" {
+		host, port, err := splitBootstrap(bootstrap)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, addrPort, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			if port != "" {
+				addrPort = port
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(host, addrPort))
+		}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// splitBootstrap splits "ip" or "ip:port" (IPv6 as "[ip]:port").
+func splitBootstrap(bootstrap string) (host, port string, err error) {
+	if addr, err := netip.ParseAddr(bootstrap); err == nil {
+		return addr.String(), "", nil
+	}
+	addrPort, err := netip.ParseAddrPort(bootstrap)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid bootstrap address %q: must be an IP or ip:port", bootstrap)
+	}
+	return addrPort.Addr().String(), fmt.Sprint(addrPort.Port()), nil
+}
+
+// Probe resolves domain with both resolvers at once and compares the answers.
+func (p *Prober) Probe(ctx context.Context, domain string) *Result {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	result := &Result{Domain: domain}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ctx, cancel := context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+		addrs, err := p.resolver.LookupNetIP(ctx, "ip", domain)
+		if err != nil {
+			result.SystemError = lookupError(err)
+			return
+		}
+		result.System = normalize(addrs)
+	})
+	wg.Go(func() {
+		ctx, cancel := context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+		addrs, err := p.lookupDoH(ctx, domain)
+		if err != nil {
+			result.DoHError = err.Error()
+			return
+		}
+		result.DoH = addrs
+	})
+	wg.Wait()
+
+	for _, addr := range result.System {
+		if p.isBogus(addr) {
+			result.Bogus = append(result.Bogus, addr)
+		}
+	}
+
+	result.Verdict, result.Detail = verdict(result)
+	return result
+}
+
+// verdict compares the answers of result.
+func verdict(result *Result) (string, string) {
+	switch {
+	case len(result.Bogus) > 0:
+		return VerdictBogus, fmt.Sprintf("system resolver answers %s, a blocking or unroutable address; desync can't fix this",
+			joinAddrs(result.Bogus))
+	case result.SystemError != "" && result.DoHError == "" && len(result.DoH) > 0:
+		return VerdictMismatch, fmt.Sprintf("system resolver fails (%s) but DoH resolves to %s",
+			result.SystemError, joinAddrs(result.DoH))
+	case result.SystemError != "":
+		return VerdictError, "system resolver fails: " + result.SystemError
+	case result.DoHError != "":
+		return VerdictError, "DoH query fails, answers can't be compared: " + result.DoHError
+	case len(result.DoH) == 0:
+		return VerdictMismatch, fmt.Sprintf("system resolver answers %s but DoH has no address",
+			joinAddrs(result.System))
+	case !overlap(result.System, result.DoH):
+		return VerdictMismatch, fmt.Sprintf("system resolver answers %s, DoH answers %s; "+
+			"a CDN answering by location does this too", joinAddrs(result.System), joinAddrs(result.DoH))
+	default:
+		return VerdictOK, fmt.Sprintf("system resolver agrees with DoH (%s)", joinAddrs(result.System))
+	}
+}
+
+// isBogus reports whether addr is in a bogus range.
+func (p *Prober) isBogus(addr netip.Addr) bool {
+	for _, prefix := range p.bogus {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupDoH returns the A and AAAA addresses of domain from the DoH server.
+func (p *Prober) lookupDoH(ctx context.Context, domain string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	var errs []error
+	for _, qtype := range []uint16{typeA, typeAAAA} {
+		found, err := p.queryDoH(ctx, domain, qtype)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		addrs = append(addrs, found...)
+	}
+	if len(addrs) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return normalize(addrs), nil
+}
+
+// lookupError shortens resolver errors to their cause.
+func lookupError(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound {
+			return "no such host"
+		}
+		if dnsErr.IsTimeout {
+			return "timeout"
+		}
+		return dnsErr.Err
+	}
+	return err.Error()
+}
+
+// normalize unmaps IPv4-mapped addresses, sorts and dedupes addrs.
+func normalize(addrs []netip.Addr) []netip.Addr {
+	out := make([]netip.Addr, 0, len(addrs))
+	for _, addr := range addrs {
+		out = append(out, addr.Unmap())
+	}
+	slices.SortFunc(out, func(a, b netip.Addr) int { return a.Compare(b) })
+	return slices.Compact(out)
+}
+
+// overlap reports whether a and b share an address.
+func overlap(a, b []netip.Addr) bool {
+	for _, addr := range a {
+		if slices.Contains(b, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinAddrs formats addrs as a comma separated list.
+func joinAddrs(addrs []netip.Addr) string {
+	parts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		parts[i] = addr.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,373 @@
+package dnsprobe
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers system lookups from a map, failing unknown names.
+type fakeResolver struct {
+	addrs map[string][]netip.Addr
+	err   error
+	block bool // wait for the context to end
+}
+
+func (r *fakeResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	if r.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	addrs, ok := r.addrs[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+// dohServer is an RFC 8484 server answering from a map of names to
+// addresses. Every answer comes after a CNAME record, with the name
+// compressed, like public resolvers answer for CDN hosted domains.
+type dohServer struct {
+	addrs  map[string][]netip.Addr
+	status int           // answer with this HTTP status instead
+	delay  time.Duration // wait before answering
+}
+
+func (d *dohServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if d.delay > 0 {
+		select {
+		case <-time.After(d.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if d.status != 0 {
+		w.WriteHeader(d.status)
+		return
+	}
+	query, err := base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+	if err != nil || len(query) < 12 || r.Header.Get("Accept") != dohMediaType {
+		http.Error(w, "bad query", http.StatusBadRequest)
+		return
+	}
+
+	// Decode the question
+	var labels []string
+	off := 12
+	for query[off] != 0 {
+		n := int(query[off])
+		labels = append(labels, string(query[off+1:off+1+n]))
+		off += 1 + n
+	}
+	question := query[12 : off+5]
+	qtype := binary.BigEndian.Uint16(query[off+1:])
+	name := strings.Join(labels, ".")
+
+	addrs, known := d.addrs[name]
+	var answers [][]byte
+	for _, addr := range addrs {
+		if (qtype == typeA) == addr.Is4() {
+			answers = append(answers, addr.AsSlice())
+		}
+	}
+
+	msg := make([]byte, 12)
+	flags := uint16(0x8000 | flagRD)
+	if !known {
+		flags |= rcodeNXDomain
+	}
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	if len(answers) > 0 {
+		binary.BigEndian.PutUint16(msg[6:], uint16(len(answers)+1))
+	}
+	msg = append(msg, question...)
+	if len(answers) > 0 {
+		// name CNAME cdn.<name>, pointing back at the question name
+		target := []byte{3, 'c', 'd', 'n', 0xc0, 12}
+		msg = append(msg, 0xc0, 12, 0, 5, 0, 1, 0, 0, 0, 60, 0, byte(len(target)))
+		msg = append(msg, target...)
+		for _, rdata := range answers {
+			msg = append(msg, 0xc0, 12)
+			msg = binary.BigEndian.AppendUint16(msg, qtype)
+			msg = append(msg, 0, 1, 0, 0, 0, 60, 0, byte(len(rdata)))
+			msg = append(msg, rdata...)
+		}
+	}
+	w.Header().Set("Content-Type", dohMediaType)
+	w.Write(msg)
+}
+
+// newProber returns a prober using resolver and a DoH server run by doh.
+func newProber(t *testing.T, resolver Resolver, doh http.Handler, bogus ...netip.Prefix) *Prober {
+	t.Helper()
+	srv := httptest.NewServer(doh)
+	t.Cleanup(srv.Close)
+	p, err := New(Config{
+		DoHURL:      srv.URL + "/dns-query",
+		Timeout:     time.Second,
+		BogusRanges: bogus,
+		Resolver:    resolver,
+		Client:      srv.Client(),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return p
+}
+
+func addrs(s ...string) []netip.Addr {
+	out := make([]netip.Addr, len(s))
+	for i, a := range s {
+		out[i] = netip.MustParseAddr(a)
+	}
+	return out
+}
+
+func TestProbeVerdicts(t *testing.T) {
+	blockPage := netip.MustParsePrefix("198.51.100.0/24")
+	doh := &dohServer{addrs: map[string][]netip.Addr{
+		"discord.com": addrs("162.159.128.233", "162.159.135.232", "2606:4700::6810:e8e9"),
+		"youtube.com": addrs("142.250.74.78"),
+		"nothing.com": nil,
+	}}
+
+	tests := []struct {
+		name     string
+		domain   string
+		system   map[string][]netip.Addr
+		err      error
+		want     string
+		wantText string
+	}{
+		{
+			name:     "agree",
+			domain:   "discord.com",
+			system:   map[string][]netip.Addr{"discord.com": addrs("::ffff:162.159.128.233", "2606:4700::6810:e8e9")},
+			want:     VerdictOK,
+			wantText: "agrees with DoH (162.159.128.233, 2606:4700::6810:e8e9)",
+		},
+		{
+			name:     "domain written loosely",
+			domain:   " YouTube.com. ",
+			system:   map[string][]netip.Addr{"youtube.com": addrs("142.250.74.78")},
+			want:     VerdictOK,
+			wantText: "agrees",
+		},
+		{
+			name:     "different addresses",
+			domain:   "youtube.com",
+			system:   map[string][]netip.Addr{"youtube.com": addrs("203.0.113.7")},
+			want:     VerdictMismatch,
+			wantText: "system resolver answers 203.0.113.7, DoH answers 142.250.74.78",
+		},
+		{
+			name:     "loopback answer",
+			domain:   "youtube.com",
+			system:   map[string][]netip.Addr{"youtube.com": addrs("127.0.0.1")},
+			want:     VerdictBogus,
+			wantText: "answers 127.0.0.1",
+		},
+		{
+			name:     "zero answer",
+			domain:   "discord.com",
+			system:   map[string][]netip.Addr{"discord.com": addrs("0.0.0.0")},
+			want:     VerdictBogus,
+			wantText: "answers 0.0.0.0",
+		},
+		{
+			name:     "isp block page",
+			domain:   "discord.com",
+			system:   map[string][]netip.Addr{"discord.com": addrs("198.51.100.10")},
+			want:     VerdictBogus,
+			wantText: "blocking or unroutable",
+		},
+		{
+			name:     "only doh resolves",
+			domain:   "discord.com",
+			system:   map[string][]netip.Addr{},
+			want:     VerdictMismatch,
+			wantText: "system resolver fails (no such host) but DoH resolves",
+		},
+		{
+			name:     "doh has no address",
+			domain:   "nothing.com",
+			system:   map[string][]netip.Addr{"nothing.com": addrs("203.0.113.7")},
+			want:     VerdictMismatch,
+			wantText: "DoH has no address",
+		},
+		{
+			name:     "unknown everywhere",
+			domain:   "missing.example",
+			system:   map[string][]netip.Addr{},
+			want:     VerdictError,
+			wantText: "system resolver fails: no such host",
+		},
+		{
+			name:     "system resolver down",
+			domain:   "discord.com",
+			err:      &net.DNSError{Err: "server misbehaving", Name: "discord.com"},
+			want:     VerdictMismatch,
+			wantText: "server misbehaving",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProber(t, &fakeResolver{addrs: tt.system, err: tt.err}, doh, blockPage)
+			result := p.Probe(t.Context(), tt.domain)
+			if result.Verdict != tt.want || !strings.Contains(result.Detail, tt.wantText) {
+				t.Errorf("Probe() = %s: %s, want %s containing %q", result.Verdict, result.Detail, tt.want, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestProbeDoHFailure(t *testing.T) {
+	system := &fakeResolver{addrs: map[string][]netip.Addr{"discord.com": addrs("162.159.128.233")}}
+
+	tests := []struct {
+		name     string
+		doh      http.Handler
+		wantText string
+	}{
+		{name: "server error", doh: &dohServer{status: http.StatusBadGateway}, wantText: "502"},
+		{
+			name: "not a dns message",
+			doh: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte("<html>blocked</html>"))
+			}),
+			wantText: `"text/html"`,
+		},
+		{
+			name: "truncated answer",
+			doh: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", dohMediaType)
+				w.Write([]byte{0, 0, 0x81, 0x80, 0, 1, 0, 1})
+			}),
+			wantText: "truncated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := newProber(t, system, tt.doh).Probe(t.Context(), "discord.com")
+			if result.Verdict != VerdictError || !strings.Contains(result.DoHError, tt.wantText) {
+				t.Errorf("Probe() = %s (DoH error %q), want an error containing %q", result.Verdict, result.DoHError, tt.wantText)
+			}
+			if len(result.System) != 1 {
+				t.Errorf("system answers = %v, want them kept", result.System)
+			}
+		})
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	srv := httptest.NewServer(&dohServer{delay: time.Minute})
+	t.Cleanup(srv.Close)
+	p, err := New(Config{
+		DoHURL:   srv.URL,
+		Timeout:  50 * time.Millisecond,
+		Resolver: &fakeResolver{block: true},
+		Client:   srv.Client(),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	start := time.Now()
+	result := p.Probe(t.Context(), "discord.com")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Probe() took %v with a 50ms timeout", elapsed)
+	}
+	if result.Verdict != VerdictError || result.SystemError == "" || result.DoHError == "" {
+		t.Errorf("Probe() = %+v, want both resolvers timed out", result)
+	}
+}
+
+func TestBootstrapBypassesResolver(t *testing.T) {
+	srv := httptest.NewServer(&dohServer{addrs: map[string][]netip.Addr{"discord.com": addrs("162.159.128.233")}})
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// The DoH host doesn't resolve; only the bootstrap address reaches it
+	p, err := New(Config{
+		DoHURL:    "http://doh.invalid:" + port + "/dns-query",
+		Bootstrap: "127.0.0.1",
+		Resolver:  &fakeResolver{addrs: map[string][]netip.Addr{"discord.com": addrs("162.159.128.233")}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if result := p.Probe(t.Context(), "discord.com"); result.Verdict != VerdictOK {
+		t.Errorf("Probe() = %s: %s (DoH error %q), want ok", result.Verdict, result.Detail, result.DoHError)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "no scheme", cfg: Config{DoHURL: "cloudflare-dns.com/dns-query"}},
+		{name: "other scheme", cfg: Config{DoHURL: "tls://1.1.1.1"}},
+		{name: "bad bootstrap", cfg: Config{DoHURL: "https://cloudflare-dns.com/dns-query", Bootstrap: "one.one.one.one"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := New(tt.cfg); err == nil {
+			t.Errorf("New() with %s succeeded, want error", tt.name)
+		}
+	}
+}
+
+func TestSplitBootstrap(t *testing.T) {
+	tests := []struct {
+		in, host, port string
+	}{
+		{in: "1.1.1.1", host: "1.1.1.1"},
+		{in: "1.1.1.1:8443", host: "1.1.1.1", port: "8443"},
+		{in: "2606:4700:4700::1111", host: "2606:4700:4700::1111"},
+		{in: "[2606:4700:4700::1111]:443", host: "2606:4700:4700::1111", port: "443"},
+	}
+
+	for _, tt := range tests {
+		host, port, err := splitBootstrap(tt.in)
+		if err != nil || host != tt.host || port != tt.port {
+			t.Errorf("splitBootstrap(%q) = %q, %q, %v, want %q, %q", tt.in, host, port, err, tt.host, tt.port)
+		}
+	}
+}
+
+func TestBuildQueryInvalidDomain(t *testing.T) {
+	for _, domain := range []string{"", "a..b", strings.Repeat("a", 64) + ".com", strings.Repeat("abcdefgh.", 32) + "com"} {
+		if _, err := buildQuery(domain, typeA); err == nil {
+			t.Errorf("buildQuery(%q) succeeded, want error", domain)
+		}
+	}
+}
+
+func FuzzParseResponse(f *testing.F) {
+	query, _ := buildQuery("discord.com", typeA)
+	f.Add(query)
+	f.Add([]byte{0, 0, 0x81, 0x80, 0, 1, 0, 1, 0, 0, 0, 0, 0xc0})
+	f.Add([]byte{0, 0, 0x81, 0x83, 0, 0, 0, 0, 0, 0, 0, 0})
+
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		// Responses from the network must never panic the daemon
+		parseResponse(msg, typeA)
+		parseResponse(msg, typeAAAA)
+	})
+}
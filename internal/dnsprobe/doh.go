@@ -0,0 +1,175 @@
+package dnsprobe
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DNS record types and the header bits used by queries.
+const (
+	typeA    uint16 = 1
+	typeAAAA uint16 = 28
+	classIN  uint16 = 1
+
+	flagRD = 1 << 8
+
+	rcodeNXDomain = 3
+)
+
+// maxDoHResponse bounds a DoH response.
+const maxDoHResponse = 64 << 10
+
+// dohMediaType is the content type of RFC 8484 messages.
+const dohMediaType = "application/dns-message"
+
+// queryDoH asks the DoH server for the qtype records of domain with an RFC
+// 8484 GET request.
+func (p *Prober) queryDoH(ctx context.Context, domain string, qtype uint16) ([]netip.Addr, error) {
+	query, err := buildQuery(domain, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *p.endpoint
+	values := u.Query()
+	values.Set("dns", base64.RawURLEncoding.EncodeToString(query))
+	u.RawQuery = values.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", dohMediaType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("DoH request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH server returned %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, dohMediaType) {
+		return nil, fmt.Errorf("DoH server returned %q instead of %s", ct, dohMediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DoH response: %w", err)
+	}
+	return parseResponse(data, qtype)
+}
+
+// buildQuery encodes a recursive query for the qtype records of domain. The
+// ID is 0 as RFC 8484 recommends, for HTTP caching.
+func buildQuery(domain string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, 12, 12+len(domain)+6)
+	binary.BigEndian.PutUint16(msg[2:], flagRD)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 {
+			return nil, fmt.Errorf("invalid domain %q", domain)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	if len(msg)-12 > 255 {
+		return nil, fmt.Errorf("domain %q is too long", domain)
+	}
+
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	return msg, nil
+}
+
+// errTruncatedMessage reports a DNS message ending inside a record.
+var errTruncatedMessage = errors.New("truncated DNS response")
+
+// parseResponse returns the qtype addresses in the answer section of msg.
+// CNAME chains are followed by the server, so the addresses of the final
+// name are among the answers.
+func parseResponse(msg []byte, qtype uint16) ([]netip.Addr, error) {
+	if len(msg) < 12 {
+		return nil, errTruncatedMessage
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return nil, errors.New("DoH server returned a query instead of a response")
+	}
+	switch rcode := flags & 0xf; rcode {
+	case 0:
+	case rcodeNXDomain:
+		return nil, errors.New("no such host")
+	default:
+		return nil, fmt.Errorf("DoH server answered with rcode %d", rcode)
+	}
+
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+	off := 12
+
+	for range qdcount {
+		var err error
+		if off, err = skipName(msg, off); err != nil {
+			return nil, err
+		}
+		off += 4
+	}
+
+	var addrs []netip.Addr
+	for range ancount {
+		var err error
+		if off, err = skipName(msg, off); err != nil {
+			return nil, err
+		}
+		if off+10 > len(msg) {
+			return nil, errTruncatedMessage
+		}
+		rtype := binary.BigEndian.Uint16(msg[off:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, errTruncatedMessage
+		}
+		if rtype == qtype {
+			if addr, ok := netip.AddrFromSlice(msg[off : off+rdlen]); ok {
+				addrs = append(addrs, addr)
+			}
+		}
+		off += rdlen
+	}
+	return addrs, nil
+}
+
+// skipName returns the offset after the name starting at off. A compression
+// pointer ends the name.
+func skipName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, errTruncatedMessage
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1, nil
+		case n&0xc0 == 0xc0:
+			if off+2 > len(msg) {
+				return 0, errTruncatedMessage
+			}
+			return off + 2, nil
+		case n&0xc0 != 0:
+			return 0, errors.New("invalid label in DNS response")
+		}
+		off += 1 + n
+	}
+}
@@ -0,0 +1,104 @@
+// Package ethtool inspects and changes NIC offload features that interfere
+// with nfqws desync. Offloads such as GRO coalesce packets into superpackets
+// that nfqws cannot split or fake correctly.
+package ethtool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned on platforms without ethtool.
+var ErrUnsupported = errors.New("ethtool is not supported on this platform")
+
+// Legacy ethtool commands (linux/ethtool.h).
+const (
+	cmdGetTSO = 0x0000001e
+	cmdSetTSO = 0x0000001f
+	cmdGetGSO = 0x00000023
+	cmdSetGSO = 0x00000024
+	cmdGetGRO = 0x0000002b
+	cmdSetGRO = 0x0000002c
+)
+
+// Device performs ethtool value requests on network interfaces.
+// It is the syscall layer of the package and can be replaced in tests.
+type Device interface {
+	// Get returns the value of a get command for iface
+	Get(iface string, cmd uint32) (uint32, error)
+
+	// Set sets the value of a set command for iface
+	Set(iface string, cmd uint32, value uint32) error
+}
+
+// Feature is an offload feature that defeats desync when enabled.
+type Feature struct {
+	// Name is the feature name as used by ethtool -K
+	Name string
+
+	get uint32
+	set uint32
+}
+
+// Features are the offloads checked by Check.
+var Features = []Feature{
+	{Name: "gro", get: cmdGetGRO, set: cmdSetGRO},
+	{Name: "gso", get: cmdGetGSO, set: cmdSetGSO},
+	{Name: "tso", get: cmdGetTSO, set: cmdSetTSO},
+}
+
+// Check returns the features enabled on iface.
+func Check(dev Device, iface string) ([]Feature, error) {
+	var enabled []Feature
+	for _, f := range Features {
+		v, err := dev.Get(iface, f.get)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s on %s: %w", f.Name, iface, err)
+		}
+		if v != 0 {
+			enabled = append(enabled, f)
+		}
+	}
+	return enabled, nil
+}
+
+// SetEnabled enables or disables features on iface. It stops at the first
+// failure and returns the features changed so far along with the error.
+func SetEnabled(dev Device, iface string, features []Feature, enabled bool) ([]Feature, error) {
+	var value uint32
+	if enabled {
+		value = 1
+	}
+
+	var changed []Feature
+	for _, f := range features {
+		if err := dev.Set(iface, f.set, value); err != nil {
+			return changed, fmt.Errorf("failed to set %s on %s: %w", f.Name, iface, err)
+		}
+		changed = append(changed, f)
+	}
+	return changed, nil
+}
+
+// Names returns the names of features.
+func Names(features []Feature) []string {
+	names := make([]string, len(features))
+	for i, f := range features {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// FixCommand returns the ethtool command disabling features on iface.
+func FixCommand(iface string, features []Feature) string {
+	var b strings.Builder
+	b.WriteString("ethtool -K ")
+	b.WriteString(iface)
+	for _, f := range features {
+		b.WriteString(" ")
+		b.WriteString(f.Name)
+		b.WriteString(" off")
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package ethtool
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+)
+
+func init() {
+	features.Register(features.KindFeature, "offload", "NIC offload checks and fixes via ethtool ioctls")
+}
+
+// siocEthtool is the SIOCETHTOOL ioctl request.
+const siocEthtool = 0x8946
+
+// ethtoolValue is struct ethtool_value.
+type ethtoolValue struct {
+	cmd  uint32
+	data uint32
+}
+
+// ifreq is struct ifreq with ifr_data set.
+type ifreq struct {
+	name [syscall.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [24 - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// System is the Device backed by the SIOCETHTOOL ioctl.
+type System struct{}
+
+// Get implements Device.
+func (System) Get(iface string, cmd uint32) (uint32, error) {
+	v := ethtoolValue{cmd: cmd}
+	if err := ioctl(iface, &v); err != nil {
+		return 0, err
+	}
+	return v.data, nil
+}
+
+// Set implements Device.
+func (System) Set(iface string, cmd uint32, value uint32) error {
+	return ioctl(iface, &ethtoolValue{cmd: cmd, data: value})
+}
+
+// ioctl issues an ethtool request for iface.
+func ioctl(iface string, v *ethtoolValue) error {
+	if len(iface) >= syscall.IFNAMSIZ {
+		return fmt.Errorf("interface name %q too long", iface)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	req := ifreq{data: unsafe.Pointer(v)}
+	copy(req.name[:], iface)
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return errno
+	}
+	return nil
+}
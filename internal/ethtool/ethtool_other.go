@@ -0,0 +1,17 @@
+//go:build !linux
+
+package ethtool
+
+// System is the Device backed by the platform. Offload control is only
+// implemented on Linux.
+type System struct{}
+
+// Get implements Device.
+func (System) Get(iface string, cmd uint32) (uint32, error) {
+	return 0, ErrUnsupported
+}
+
+// Set implements Device.
+func (System) Set(iface string, cmd uint32, value uint32) error {
+	return ErrUnsupported
+}
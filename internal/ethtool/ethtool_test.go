@@ -0,0 +1,105 @@
+package ethtool
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeDevice keeps feature values in memory, keyed by interface and get command.
+type fakeDevice struct {
+	values  map[string]map[uint32]uint32
+	getErr  error
+	failSet uint32 // set command that fails, 0 for none
+}
+
+func (d *fakeDevice) Get(iface string, cmd uint32) (uint32, error) {
+	if d.getErr != nil {
+		return 0, d.getErr
+	}
+	return d.values[iface][cmd], nil
+}
+
+func (d *fakeDevice) Set(iface string, cmd uint32, value uint32) error {
+	if cmd == d.failSet {
+		return errors.New("operation not permitted")
+	}
+	for _, f := range Features {
+		if f.set == cmd {
+			d.values[iface][f.get] = value
+			return nil
+		}
+	}
+	return errors.New("unknown command")
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{values: map[string]map[uint32]uint32{
+		"eth0": {cmdGetGRO: 1, cmdGetGSO: 0, cmdGetTSO: 1},
+		"wg0":  {},
+	}}
+}
+
+func TestCheck(t *testing.T) {
+	dev := newFakeDevice()
+
+	got, err := Check(dev, "eth0")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if want := []string{"gro", "tso"}; !reflect.DeepEqual(Names(got), want) {
+		t.Errorf("Check(eth0) = %v, want %v", Names(got), want)
+	}
+
+	if got, err := Check(dev, "wg0"); err != nil || len(got) != 0 {
+		t.Errorf("Check(wg0) = %v, %v, want none", Names(got), err)
+	}
+
+	dev.getErr = errors.New("no such device")
+	if _, err := Check(dev, "eth1"); err == nil {
+		t.Error("Check() with a failing device succeeded, want error")
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	dev := newFakeDevice()
+	enabled, _ := Check(dev, "eth0")
+
+	changed, err := SetEnabled(dev, "eth0", enabled, false)
+	if err != nil {
+		t.Fatalf("SetEnabled() error = %v", err)
+	}
+	if !reflect.DeepEqual(changed, enabled) {
+		t.Errorf("SetEnabled() changed %v, want %v", Names(changed), Names(enabled))
+	}
+	if left, _ := Check(dev, "eth0"); len(left) != 0 {
+		t.Errorf("features still enabled after SetEnabled(false): %v", Names(left))
+	}
+
+	if _, err := SetEnabled(dev, "eth0", enabled, true); err != nil {
+		t.Fatalf("SetEnabled(true) error = %v", err)
+	}
+	if restored, _ := Check(dev, "eth0"); !reflect.DeepEqual(restored, enabled) {
+		t.Errorf("features after restore = %v, want %v", Names(restored), Names(enabled))
+	}
+}
+
+func TestSetEnabledPartialFailure(t *testing.T) {
+	dev := newFakeDevice()
+	dev.failSet = cmdSetTSO
+
+	changed, err := SetEnabled(dev, "eth0", Features, false)
+	if err == nil {
+		t.Fatal("SetEnabled() succeeded, want error for tso")
+	}
+	// The caller restores what was changed, so the list must stop at the failure
+	if want := []string{"gro", "gso"}; !reflect.DeepEqual(Names(changed), want) {
+		t.Errorf("SetEnabled() changed %v, want %v", Names(changed), want)
+	}
+}
+
+func TestFixCommand(t *testing.T) {
+	if got, want := FixCommand("eth0", Features[:2]), "ethtool -K eth0 gro off gso off"; got != want {
+		t.Errorf("FixCommand() = %q, want %q", got, want)
+	}
+}
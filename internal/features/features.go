@@ -0,0 +1,66 @@
+// Package features records what this build of zapret-ng can do: the
+// compiled-in backends and optional features, registered by the packages
+// implementing them, and the privileges the daemon runs with.
+package features
+
+import (
+	"sort"
+	"sync"
+)
+
+// Kinds of compiled-in features.
+const (
+	// KindFirewall is a firewall backend
+	KindFirewall = "firewall"
+
+	// KindFeature is an optional feature depending on the platform
+	KindFeature = "feature"
+)
+
+// Feature is a compiled-in backend or feature.
+type Feature struct {
+	// Kind is "firewall" or "feature"
+	Kind string
+
+	// Name identifies the feature, e.g. "nftables"
+	Name string
+
+	// Detail describes what the feature provides
+	Detail string
+}
+
+var (
+	mu       sync.Mutex
+	compiled []Feature
+)
+
+// Register records a compiled-in feature. Packages call it from init in the
+// files built for the platforms they support.
+func Register(kind, name, detail string) {
+	mu.Lock()
+	defer mu.Unlock()
+	compiled = append(compiled, Feature{Kind: kind, Name: name, Detail: detail})
+}
+
+// Compiled returns the registered features sorted by kind and name.
+func Compiled() []Feature {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := make([]Feature, len(compiled))
+	copy(out, compiled)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Subsystem is an optional part of the daemon that is enabled by config.
+type Subsystem struct {
+	Name    string
+	Enabled bool
+	Detail  string
+}
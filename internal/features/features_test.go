@@ -0,0 +1,55 @@
+package features
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompiledSorted(t *testing.T) {
+	Register(KindFeature, "test-b", "second")
+	Register(KindFirewall, "test-fw", "backend")
+	Register(KindFeature, "test-a", "first")
+
+	got := Compiled()
+	sorted := slices.IsSortedFunc(got, func(a, b Feature) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if !sorted {
+		t.Errorf("Compiled() = %v, want features sorted by kind and name", got)
+	}
+	for _, name := range []string{"test-a", "test-b", "test-fw"} {
+		if !slices.ContainsFunc(got, func(f Feature) bool { return f.Name == name }) {
+			t.Errorf("Compiled() = %v, want registered feature %s", got, name)
+		}
+	}
+
+	// Callers get a copy
+	got[0].Name = "changed"
+	if Compiled()[0].Name == "changed" {
+		t.Error("Compiled() returned the registry itself")
+	}
+}
+
+func TestPrivileges(t *testing.T) {
+	checks := Privileges()
+	names := make([]string, len(checks))
+	for i, c := range checks {
+		names[i] = c.Name
+	}
+	if want := []string{"root", "CAP_NET_ADMIN", "CAP_NET_RAW"}; !slices.Equal(names, want) {
+		t.Fatalf("Privileges() checks %v, want %v", names, want)
+	}
+	if root := checks[0]; root.OK != (os.Geteuid() == 0) {
+		t.Errorf("root check = %+v with effective uid %d", root, os.Geteuid())
+	}
+	for _, c := range checks[1:] {
+		if c.Detail == "" {
+			t.Errorf("%s check has no detail", c.Name)
+		}
+	}
+}
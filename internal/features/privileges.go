@@ -0,0 +1,79 @@
+package features
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Linux capabilities the daemon needs, as bit numbers of CapEff.
+var requiredCaps = []struct {
+	Name string
+	Bit  uint
+	Use  string
+}{
+	{Name: "CAP_NET_ADMIN", Bit: 12, Use: "firewall rules and NFQUEUE binding"},
+	{Name: "CAP_NET_RAW", Bit: 13, Use: "sending desync packets from nfqws"},
+}
+
+// PrivilegeCheck is the result of checking one privilege.
+type PrivilegeCheck struct {
+	// Name is the privilege, e.g. "root" or "CAP_NET_ADMIN"
+	Name string
+
+	// OK reports that the daemon has the privilege
+	OK bool
+
+	// Detail explains the result
+	Detail string
+}
+
+// Privileges checks the privileges of the current process. Capabilities are
+// read from /proc/self/status; without it only the user is checked.
+func Privileges() []PrivilegeCheck {
+	euid := os.Geteuid()
+	checks := []PrivilegeCheck{{
+		Name:   "root",
+		OK:     euid == 0,
+		Detail: fmt.Sprintf("effective uid %d", euid),
+	}}
+
+	effective, err := effectiveCaps()
+	for _, c := range requiredCaps {
+		check := PrivilegeCheck{Name: c.Name}
+		switch {
+		case err != nil:
+			check.OK = euid == 0
+			check.Detail = "cannot read capabilities: " + err.Error()
+		case effective&(1<<c.Bit) != 0:
+			check.OK = true
+			check.Detail = "effective, needed for " + c.Use
+		default:
+			check.Detail = "missing, needed for " + c.Use
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// effectiveCaps returns the effective capability set of the process.
+func effectiveCaps() (uint64, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "CapEff:"); ok {
+			return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no CapEff in /proc/self/status")
+}
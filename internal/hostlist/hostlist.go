@@ -0,0 +1,173 @@
+// Package hostlist loads nfqws hostlist files and matches domains against them.
+package hostlist
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// List is a loaded hostlist file.
+// Entries match the domain itself and all of its subdomains, unless prefixed
+// with "^" which restricts the entry to an exact match (nfqws semantics).
+type List struct {
+	// Path is the file the list was loaded from
+	Path string
+
+	// ModTime is the modification time of the file when it was loaded
+	ModTime time.Time
+
+	// Entries is the number of domains in the list
+	Entries int
+
+	domains map[string]bool // domain -> exact match only
+	bytes   int64
+}
+
+// Load reads a hostlist file.
+func Load(path string) (*List, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hostlist: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat hostlist: %w", err)
+	}
+
+	list := &List{
+		Path:    path,
+		ModTime: info.ModTime(),
+		domains: make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		exact := strings.HasPrefix(line, "^")
+		domain := Normalize(strings.TrimPrefix(line, "^"))
+		if domain == "" {
+			continue
+		}
+
+		if _, ok := list.domains[domain]; !ok {
+			// Rough estimate: key bytes plus map entry overhead
+			list.bytes += int64(len(domain)) + 48
+		}
+		list.domains[domain] = exact
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hostlist %s: %w", path, err)
+	}
+
+	list.Entries = len(list.domains)
+	return list, nil
+}
+
+// NewInline creates a list from domains given directly on the command line
+// (e.g. --hostlist-domains).
+func NewInline(domains []string) *List {
+	list := &List{
+		Path:    "(inline)",
+		domains: make(map[string]bool, len(domains)),
+	}
+	for _, d := range domains {
+		exact := strings.HasPrefix(d, "^")
+		if domain := Normalize(strings.TrimPrefix(d, "^")); domain != "" {
+			list.domains[domain] = exact
+		}
+	}
+	list.Entries = len(list.domains)
+	return list
+}
+
+// Match reports whether domain is covered by the list and returns the matching entry.
+func (l *List) Match(domain string) (string, bool) {
+	domain = Normalize(domain)
+	for suffix := domain; suffix != ""; {
+		if exact, ok := l.domains[suffix]; ok && (!exact || suffix == domain) {
+			return suffix, true
+		}
+
+		dot := strings.IndexByte(suffix, '.')
+		if dot < 0 {
+			break
+		}
+		suffix = suffix[dot+1:]
+	}
+	return "", false
+}
+
+// MemoryBytes returns an estimate of the memory used by the list.
+func (l *List) MemoryBytes() int64 {
+	return l.bytes
+}
+
+// Normalize lowercases a domain and strips surrounding dots and whitespace.
+func Normalize(domain string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// Index lazily loads and caches hostlists by path.
+// Lists are reloaded when their modification time changes.
+type Index struct {
+	mu    sync.Mutex
+	lists map[string]*List
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{lists: make(map[string]*List)}
+}
+
+// Get returns the list for path, loading it on first use or when it changed on disk.
+func (i *Index) Get(path string) (*List, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(i.lists, path)
+		return nil, fmt.Errorf("failed to stat hostlist: %w", err)
+	}
+
+	if list, ok := i.lists[path]; ok && list.ModTime.Equal(info.ModTime()) {
+		return list, nil
+	}
+
+	list, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	i.lists[path] = list
+	return list, nil
+}
+
+// Reset drops all cached lists.
+func (i *Index) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lists = make(map[string]*List)
+}
+
+// MemoryBytes returns an estimate of the memory used by all loaded lists.
+func (i *Index) MemoryBytes() int64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var total int64
+	for _, list := range i.lists {
+		total += list.MemoryBytes()
+	}
+	return total
+}
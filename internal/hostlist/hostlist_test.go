@@ -0,0 +1,110 @@
+package hostlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeList writes a hostlist file into a temporary directory.
+func writeList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeList(t, "# comment\n\nDiscord.com.\n  youtube.com  \n^exact.example\ndiscord.com\n.\n")
+
+	list, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if list.Entries != 3 {
+		t.Errorf("Entries = %d, want 3", list.Entries)
+	}
+	if list.Path != path {
+		t.Errorf("Path = %q, want %q", list.Path, path)
+	}
+	if list.MemoryBytes() <= 0 {
+		t.Errorf("MemoryBytes() = %d, want > 0", list.MemoryBytes())
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Load() of a missing file succeeded, want error")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	list := NewInline([]string{"discord.com", "^exact.example", "GoogleVideo.com."})
+
+	tests := []struct {
+		domain    string
+		wantEntry string
+		wantOK    bool
+	}{
+		{domain: "discord.com", wantEntry: "discord.com", wantOK: true},
+		{domain: "cdn.discord.com", wantEntry: "discord.com", wantOK: true},
+		{domain: "CDN.Discord.COM.", wantEntry: "discord.com", wantOK: true},
+		{domain: "notdiscord.com", wantOK: false},
+		{domain: "com", wantOK: false},
+		{domain: "exact.example", wantEntry: "exact.example", wantOK: true},
+		{domain: "sub.exact.example", wantOK: false},
+		{domain: "rr1.googlevideo.com", wantEntry: "googlevideo.com", wantOK: true},
+		{domain: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		entry, ok := list.Match(tt.domain)
+		if entry != tt.wantEntry || ok != tt.wantOK {
+			t.Errorf("Match(%q) = %q, %v, want %q, %v", tt.domain, entry, ok, tt.wantEntry, tt.wantOK)
+		}
+	}
+}
+
+func TestIndexReloadsChangedFiles(t *testing.T) {
+	path := writeList(t, "discord.com\n")
+	index := NewIndex()
+
+	first, err := index.Get(path)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if again, _ := index.Get(path); again != first {
+		t.Error("Get() of an unchanged file reloaded it")
+	}
+
+	if err := os.WriteFile(path, []byte("discord.com\nyoutube.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the change is visible on filesystems with coarse timestamps
+	later := first.ModTime.Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := index.Get(path)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if changed.Entries != 2 {
+		t.Errorf("Entries after change = %d, want 2", changed.Entries)
+	}
+	if index.MemoryBytes() != changed.MemoryBytes() {
+		t.Errorf("Index.MemoryBytes() = %d, want %d", index.MemoryBytes(), changed.MemoryBytes())
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := index.Get(path); err == nil {
+		t.Error("Get() of a removed file succeeded, want error")
+	}
+	if index.MemoryBytes() != 0 {
+		t.Errorf("Index.MemoryBytes() after removal = %d, want 0", index.MemoryBytes())
+	}
+}
@@ -0,0 +1,421 @@
+package hostlist
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+const (
+	// maxListSize bounds a downloaded hostlist
+	maxListSize = 64 << 20
+
+	// backoffBase is the delay after the first failure of a source
+	backoffBase = time.Minute
+
+	// backoffMax caps the delay between attempts of a failing source
+	backoffMax = 24 * time.Hour
+)
+
+// HTTPClient is the part of *http.Client used by the updater.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Source is a hostlist file kept up to date from a URL.
+type Source struct {
+	// URL is where the list is downloaded from
+	URL string `yaml:"url"`
+
+	// Path is the file the list is written to
+	Path string `yaml:"file"`
+
+	// SHA256 pins the expected content checksum (hex) if set
+	SHA256 string `yaml:"sha256"`
+
+	// MinSize rejects downloads smaller than this many bytes
+	MinSize int64 `yaml:"min_size"`
+}
+
+// SourceState is the persisted update state of a source.
+type SourceState struct {
+	URL          string    `json:"url"`
+	Path         string    `json:"path"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	LastAttempt  time.Time `json:"last_attempt"`
+	LastSuccess  time.Time `json:"last_success"`
+	LastError    string    `json:"last_error,omitempty"`
+	Failures     int       `json:"failures"`
+	NextAttempt  time.Time `json:"next_attempt"`
+	Size         int64     `json:"size"`
+	Entries      int       `json:"entries"`
+}
+
+// UpdaterConfig contains updater settings.
+type UpdaterConfig struct {
+	// Client performs HTTP requests (http.DefaultClient if nil)
+	Client HTTPClient
+
+	// StatePath is where update state is persisted across restarts ("" to keep it in memory)
+	StatePath string
+
+	// Concurrency caps parallel downloads
+	Concurrency int
+
+	// Logger receives update progress
+	Logger *slog.Logger
+
+	// OnEvent is called for notable results ("hostlist_updated", "hostlist_failed")
+	OnEvent func(kind, message string)
+}
+
+// Updater downloads hostlists with conditional requests, validation and
+// per-source exponential backoff.
+type Updater struct {
+	cfg   UpdaterConfig
+	mu    sync.Mutex
+	state map[string]*SourceState // keyed by URL
+}
+
+// NewUpdater creates an updater and loads persisted state.
+func NewUpdater(cfg UpdaterConfig) *Updater {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 2
+	}
+	if cfg.OnEvent == nil {
+		cfg.OnEvent = func(string, string) {}
+	}
+
+	u := &Updater{cfg: cfg, state: make(map[string]*SourceState)}
+	if err := u.loadState(); err != nil {
+		cfg.Logger.Warn("failed to load hostlist update state", slog.Any("error", err))
+	}
+	return u
+}
+
+// Update refreshes all sources whose backoff has expired and that were not
+// successfully checked within minAge. It returns the paths of files that were replaced.
+func (u *Updater) Update(ctx context.Context, sources []Source, minAge time.Duration) []string {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		changed []string
+	)
+	sem := make(chan struct{}, u.cfg.Concurrency)
+
+	for _, src := range sources {
+		st := u.sourceState(src)
+		// Persisted times carry no monotonic reading. A backoff ending further
+		// ahead than the longest backoff, or a success in the future, was
+		// recorded before the wall clock jumped back (e.g. a router booting
+		// without an RTC) and would stall updates for the jumped duration.
+		wait := time.Until(st.NextAttempt)
+		if wait > 0 && wait <= backoffMax {
+			u.cfg.Logger.Debug("hostlist in backoff, skipping",
+				slog.String("url", src.URL),
+				slog.Time("next_attempt", st.NextAttempt),
+			)
+			continue
+		}
+		if age := time.Since(st.LastSuccess); st.Failures == 0 && age >= 0 && age < minAge {
+			continue
+		}
+
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if u.updateSource(ctx, src) {
+				mu.Lock()
+				changed = append(changed, src.Path)
+				mu.Unlock()
+			}
+		}(src)
+	}
+	wg.Wait()
+
+	if err := u.saveState(); err != nil {
+		u.cfg.Logger.Warn("failed to save hostlist update state", slog.Any("error", err))
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// ResetBackoff lets all sources in backoff be retried by the next Update,
+// keeping their failure counts. It is used after a wall clock jump made the
+// recorded backoff deadlines meaningless. It returns the number of sources reset.
+func (u *Updater) ResetBackoff() int {
+	u.mu.Lock()
+	n := 0
+	for _, st := range u.state {
+		if !st.NextAttempt.IsZero() {
+			st.NextAttempt = time.Time{}
+			n++
+		}
+	}
+	u.mu.Unlock()
+
+	if n > 0 {
+		if err := u.saveState(); err != nil {
+			u.cfg.Logger.Warn("failed to save hostlist update state", slog.Any("error", err))
+		}
+	}
+	return n
+}
+
+// Status returns the state of all known sources ordered by path.
+func (u *Updater) Status() []SourceState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	states := make([]SourceState, 0, len(u.state))
+	for _, st := range u.state {
+		states = append(states, *st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Path < states[j].Path })
+	return states
+}
+
+// sourceState returns the state of src, creating it on first use.
+func (u *Updater) sourceState(src Source) SourceState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	st, ok := u.state[src.URL]
+	if !ok {
+		st = &SourceState{URL: src.URL}
+		u.state[src.URL] = st
+	}
+	st.Path = src.Path
+	return *st
+}
+
+// updateSource downloads a single source and reports whether the file was replaced.
+func (u *Updater) updateSource(ctx context.Context, src Source) bool {
+	st := u.sourceState(src)
+	logger := u.cfg.Logger.With(slog.String("url", src.URL), slog.String("path", src.Path))
+
+	result, err := u.fetch(ctx, src, st)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	cur := u.state[src.URL]
+	cur.LastAttempt = time.Now()
+
+	if err != nil {
+		cur.Failures++
+		cur.LastError = err.Error()
+		cur.NextAttempt = cur.LastAttempt.Add(backoff(cur.Failures))
+		logger.Warn("hostlist update failed, keeping previous file",
+			slog.Int("failures", cur.Failures),
+			slog.Time("next_attempt", cur.NextAttempt),
+			slog.Any("error", err),
+		)
+		u.cfg.OnEvent("hostlist_failed", fmt.Sprintf("%s: %v", src.Path, err))
+		return false
+	}
+
+	cur.Failures = 0
+	cur.LastError = ""
+	cur.NextAttempt = time.Time{}
+	cur.LastSuccess = cur.LastAttempt
+	if result == nil {
+		logger.Debug("hostlist not modified")
+		return false
+	}
+
+	cur.ETag = result.etag
+	cur.LastModified = result.lastModified
+	cur.Size = result.size
+	cur.Entries = result.entries
+
+	logger.Info("hostlist updated", slog.Int64("size", result.size), slog.Int("entries", result.entries))
+	u.cfg.OnEvent("hostlist_updated", fmt.Sprintf("%s: %d entries", src.Path, result.entries))
+	return true
+}
+
+// fetchResult describes a replaced file.
+type fetchResult struct {
+	etag         string
+	lastModified string
+	size         int64
+	entries      int
+}
+
+// fetch downloads src and replaces its file if the content is valid.
+// It returns nil without error when the server reports the list unchanged.
+func (u *Updater) fetch(ctx context.Context, src Source, st SourceState) (*fetchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	// Only send validators if the file they describe is still there
+	if _, err := os.Stat(src.Path); err == nil {
+		if st.ETag != "" {
+			req.Header.Set("If-None-Match", st.ETag)
+		}
+		if st.LastModified != "" {
+			req.Header.Set("If-Modified-Since", st.LastModified)
+		}
+	}
+
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxListSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if len(data) > maxListSize {
+		return nil, fmt.Errorf("list exceeds maximum size of %d bytes", maxListSize)
+	}
+
+	if int64(len(data)) < src.MinSize {
+		return nil, fmt.Errorf("list is %d bytes, below min_size %d", len(data), src.MinSize)
+	}
+	if src.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, src.SHA256) {
+			return nil, fmt.Errorf("checksum mismatch: expected %s, got %s", src.SHA256, actual)
+		}
+	}
+
+	// Servers without conditional request support resend unchanged lists
+	if current, err := os.ReadFile(src.Path); err == nil && bytes.Equal(current, data) {
+		return nil, nil
+	}
+
+	entries, err := replaceList(src.Path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fetchResult{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		size:         int64(len(data)),
+		entries:      entries,
+	}, nil
+}
+
+// replaceList validates data as a hostlist and atomically replaces path with it.
+// The previous file is left untouched if validation fails.
+func replaceList(path string, data []byte) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create lists directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	list, err := Load(tmpName)
+	if err != nil {
+		return 0, err
+	}
+	if list.Entries == 0 {
+		return 0, fmt.Errorf("downloaded list has no entries")
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return 0, fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return list.Entries, nil
+}
+
+// backoff returns the delay before the next attempt after n consecutive failures.
+func backoff(n int) time.Duration {
+	d := backoffBase
+	for i := 1; i < n && d < backoffMax; i++ {
+		d *= 2
+	}
+	return min(d, backoffMax)
+}
+
+// loadState reads persisted state.
+func (u *Updater) loadState() error {
+	if u.cfg.StatePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(u.cfg.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var states []*SourceState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", u.cfg.StatePath, err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, st := range states {
+		u.state[st.URL] = st
+	}
+	return nil
+}
+
+// saveState persists state atomically.
+func (u *Updater) saveState() error {
+	if u.cfg.StatePath == "" {
+		return nil
+	}
+	return statepaths.WriteJSON(u.cfg.StatePath, u.Status())
+}
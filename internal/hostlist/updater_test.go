@@ -0,0 +1,417 @@
+package hostlist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testList = "discord.com\nyoutube.com\n"
+
+// fakeResponse is a canned reply of fakeClient.
+type fakeResponse struct {
+	status int
+	body   string
+	header http.Header
+	err    error
+}
+
+// fakeClient serves canned responses by URL and records the requests.
+type fakeClient struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	requests  []*http.Request
+
+	// gate, if set, blocks every request until it is closed
+	gate     chan struct{}
+	inFlight int
+	maxSeen  int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{responses: make(map[string]fakeResponse)}
+}
+
+func (c *fakeClient) set(url string, resp fakeResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[url] = resp
+}
+
+func (c *fakeClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.inFlight++
+	c.maxSeen = max(c.maxSeen, c.inFlight)
+	resp, ok := c.responses[req.URL.String()]
+	gate := c.gate
+	c.mu.Unlock()
+
+	if gate != nil {
+		<-gate
+	}
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	if !ok {
+		resp = fakeResponse{status: http.StatusNotFound}
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	header := resp.header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:     http.StatusText(resp.status),
+		StatusCode: resp.status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(resp.body)),
+	}, nil
+}
+
+// lastRequest returns the most recent request.
+func (c *fakeClient) lastRequest() *http.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) == 0 {
+		return nil
+	}
+	return c.requests[len(c.requests)-1]
+}
+
+// requestCount returns the number of requests made so far.
+func (c *fakeClient) requestCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.requests)
+}
+
+// event is a recorded OnEvent call.
+type event struct {
+	kind    string
+	message string
+}
+
+// testUpdater creates an updater using client, recording its events.
+func testUpdater(client HTTPClient, statePath string) (*Updater, *[]event) {
+	var (
+		mu     sync.Mutex
+		events []event
+	)
+	u := NewUpdater(UpdaterConfig{
+		Client:    client,
+		StatePath: statePath,
+		Logger:    slog.New(slog.DiscardHandler),
+		OnEvent: func(kind, message string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event{kind, message})
+		},
+	})
+	return u, &events
+}
+
+func TestUpdaterDownloadsAndRevalidates(t *testing.T) {
+	client := newFakeClient()
+	path := filepath.Join(t.TempDir(), "lists", "discord.txt")
+	src := Source{URL: "https://example.com/discord.txt", Path: path}
+	client.set(src.URL, fakeResponse{
+		status: http.StatusOK,
+		body:   testList,
+		header: http.Header{"Etag": {`"v1"`}, "Last-Modified": {"Mon, 12 Oct 2026 09:00:00 GMT"}},
+	})
+	u, events := testUpdater(client, "")
+
+	changed := u.Update(t.Context(), []Source{src}, 0)
+	if len(changed) != 1 || changed[0] != path {
+		t.Fatalf("Update() = %v, want [%s]", changed, path)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != testList {
+		t.Fatalf("list file = %q, %v, want %q", data, err, testList)
+	}
+	if len(*events) != 1 || (*events)[0].kind != "hostlist_updated" {
+		t.Errorf("events = %v, want one hostlist_updated", *events)
+	}
+
+	status := u.Status()
+	if len(status) != 1 {
+		t.Fatalf("Status() has %d sources, want 1", len(status))
+	}
+	st := status[0]
+	if st.ETag != `"v1"` || st.Entries != 2 || st.Size != int64(len(testList)) || st.LastSuccess.IsZero() {
+		t.Errorf("Status() = %+v, want etag \"v1\", 2 entries, size %d and a success time", st, len(testList))
+	}
+
+	// The next check is conditional and the server reports no change
+	client.set(src.URL, fakeResponse{status: http.StatusNotModified})
+	if changed := u.Update(t.Context(), []Source{src}, 0); len(changed) != 0 {
+		t.Errorf("Update() of an unchanged list = %v, want none", changed)
+	}
+	req := client.lastRequest()
+	if got := req.Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `"v1"`)
+	}
+	if got := req.Header.Get("If-Modified-Since"); got != "Mon, 12 Oct 2026 09:00:00 GMT" {
+		t.Errorf("If-Modified-Since = %q, want the stored Last-Modified", got)
+	}
+
+	// Servers ignoring validators resend the same content
+	client.set(src.URL, fakeResponse{status: http.StatusOK, body: testList})
+	if changed := u.Update(t.Context(), []Source{src}, 0); len(changed) != 0 {
+		t.Errorf("Update() with identical content = %v, want none", changed)
+	}
+}
+
+func TestUpdaterSkipsValidatorsForMissingFile(t *testing.T) {
+	client := newFakeClient()
+	path := filepath.Join(t.TempDir(), "discord.txt")
+	src := Source{URL: "https://example.com/discord.txt", Path: path}
+	client.set(src.URL, fakeResponse{status: http.StatusOK, body: testList, header: http.Header{"Etag": {`"v1"`}}})
+	u, _ := testUpdater(client, "")
+
+	u.Update(t.Context(), []Source{src}, 0)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed := u.Update(t.Context(), []Source{src}, 0); len(changed) != 1 {
+		t.Errorf("Update() after the file was removed = %v, want it rewritten", changed)
+	}
+	if got := client.lastRequest().Header.Get("If-None-Match"); got != "" {
+		t.Errorf("If-None-Match = %q for a missing file, want none", got)
+	}
+}
+
+func TestUpdaterRejectsInvalidDownloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     Source
+		resp    fakeResponse
+		wantErr string
+	}{
+		{
+			name:    "server error",
+			resp:    fakeResponse{status: http.StatusInternalServerError},
+			wantErr: "unexpected status",
+		},
+		{
+			name:    "transport error",
+			resp:    fakeResponse{err: errors.New("connection refused")},
+			wantErr: "connection refused",
+		},
+		{
+			name:    "checksum mismatch",
+			src:     Source{SHA256: strings.Repeat("0", 64)},
+			resp:    fakeResponse{status: http.StatusOK, body: testList},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "below min size",
+			src:     Source{MinSize: 1024},
+			resp:    fakeResponse{status: http.StatusOK, body: testList + "x.com\n"},
+			wantErr: "below min_size",
+		},
+		{
+			name:    "no entries",
+			resp:    fakeResponse{status: http.StatusOK, body: "# nothing here\n"},
+			wantErr: "no entries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const previous = "old.example\n"
+			path := filepath.Join(t.TempDir(), "discord.txt")
+			if err := os.WriteFile(path, []byte(previous), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			src := tt.src
+			src.URL = "https://example.com/discord.txt"
+			src.Path = path
+			client := newFakeClient()
+			client.set(src.URL, tt.resp)
+			u, events := testUpdater(client, "")
+
+			before := time.Now()
+			if changed := u.Update(t.Context(), []Source{src}, 0); len(changed) != 0 {
+				t.Errorf("Update() = %v, want no replaced files", changed)
+			}
+
+			if data, _ := os.ReadFile(path); string(data) != previous {
+				t.Errorf("list file = %q, want the previous content kept", data)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+				t.Errorf("lists directory has %d entries, want no temp files left", len(entries))
+			}
+			if len(*events) != 1 || (*events)[0].kind != "hostlist_failed" {
+				t.Errorf("events = %v, want one hostlist_failed", *events)
+			}
+
+			st := u.Status()[0]
+			if !strings.Contains(st.LastError, tt.wantErr) {
+				t.Errorf("LastError = %q, want it to contain %q", st.LastError, tt.wantErr)
+			}
+			if st.Failures != 1 {
+				t.Errorf("Failures = %d, want 1", st.Failures)
+			}
+			if wait := st.NextAttempt.Sub(before); wait < backoffBase || wait > backoffBase+time.Minute {
+				t.Errorf("NextAttempt is %v ahead, want about %v", wait, backoffBase)
+			}
+		})
+	}
+}
+
+func TestUpdaterAcceptsPinnedChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte(testList))
+	src := Source{
+		URL:     "https://example.com/list.txt",
+		Path:    filepath.Join(t.TempDir(), "list.txt"),
+		SHA256:  strings.ToUpper(hex.EncodeToString(sum[:])),
+		MinSize: int64(len(testList)),
+	}
+	client := newFakeClient()
+	client.set(src.URL, fakeResponse{status: http.StatusOK, body: testList})
+	u, _ := testUpdater(client, "")
+
+	if changed := u.Update(t.Context(), []Source{src}, 0); len(changed) != 1 {
+		t.Errorf("Update() = %v, want the list replaced", changed)
+	}
+}
+
+func TestUpdaterBackoffPersists(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "hostlist-state.json")
+	src := Source{URL: "https://mirror.example/list.txt", Path: filepath.Join(dir, "list.txt")}
+
+	client := newFakeClient()
+	client.set(src.URL, fakeResponse{status: http.StatusServiceUnavailable})
+	u, _ := testUpdater(client, statePath)
+	u.Update(t.Context(), []Source{src}, 0)
+	if n := client.requestCount(); n != 1 {
+		t.Fatalf("requests = %d, want 1", n)
+	}
+
+	// A restarted daemon must not hammer the dead mirror
+	restarted, _ := testUpdater(client, statePath)
+	restarted.Update(t.Context(), []Source{src}, 0)
+	if n := client.requestCount(); n != 1 {
+		t.Errorf("requests after restart = %d, want the source still in backoff", n)
+	}
+	if st := restarted.Status(); len(st) != 1 || st[0].Failures != 1 {
+		t.Errorf("restored Status() = %+v, want one source with 1 failure", st)
+	}
+
+	if n := restarted.ResetBackoff(); n != 1 {
+		t.Errorf("ResetBackoff() = %d, want 1", n)
+	}
+	client.set(src.URL, fakeResponse{status: http.StatusOK, body: testList})
+	if changed := restarted.Update(t.Context(), []Source{src}, 0); len(changed) != 1 {
+		t.Errorf("Update() after ResetBackoff = %v, want the list replaced", changed)
+	}
+	if st := restarted.Status()[0]; st.Failures != 0 || st.LastError != "" || !st.NextAttempt.IsZero() {
+		t.Errorf("Status() after success = %+v, want failures cleared", st)
+	}
+}
+
+func TestUpdaterIgnoresBackoffFromClockJump(t *testing.T) {
+	dir := t.TempDir()
+	src := Source{URL: "https://example.com/list.txt", Path: filepath.Join(dir, "list.txt")}
+	client := newFakeClient()
+	client.set(src.URL, fakeResponse{status: http.StatusOK, body: testList})
+	u, _ := testUpdater(client, "")
+
+	// Recorded before the clock jumped back by a year
+	u.state[src.URL] = &SourceState{
+		URL:         src.URL,
+		Failures:    3,
+		NextAttempt: time.Now().Add(365 * 24 * time.Hour),
+		LastSuccess: time.Now().Add(300 * 24 * time.Hour),
+	}
+
+	if changed := u.Update(t.Context(), []Source{src}, time.Hour); len(changed) != 1 {
+		t.Errorf("Update() = %v, want the stale backoff ignored", changed)
+	}
+}
+
+func TestUpdaterMinAge(t *testing.T) {
+	src := Source{URL: "https://example.com/list.txt", Path: filepath.Join(t.TempDir(), "list.txt")}
+	client := newFakeClient()
+	client.set(src.URL, fakeResponse{status: http.StatusOK, body: testList})
+	u, _ := testUpdater(client, "")
+
+	u.Update(t.Context(), []Source{src}, time.Hour)
+	u.Update(t.Context(), []Source{src}, time.Hour)
+	if n := client.requestCount(); n != 1 {
+		t.Errorf("requests = %d, want the recently checked list skipped", n)
+	}
+}
+
+func TestUpdaterConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	client := newFakeClient()
+	client.gate = make(chan struct{})
+
+	var sources []Source
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		src := Source{URL: "https://example.com/" + name, Path: filepath.Join(dir, name+".txt")}
+		client.set(src.URL, fakeResponse{status: http.StatusOK, body: name + ".example\n"})
+		sources = append(sources, src)
+	}
+	u, _ := testUpdater(client, "")
+
+	done := make(chan []string)
+	go func() { done <- u.Update(context.Background(), sources, 0) }()
+
+	// Let the workers pile up on the gate before releasing them
+	deadline := time.Now().Add(5 * time.Second)
+	for client.requestCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(client.gate)
+
+	changed := <-done
+	if len(changed) != len(sources) {
+		t.Errorf("Update() replaced %d files, want %d", len(changed), len(sources))
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.maxSeen != 2 {
+		t.Errorf("max concurrent downloads = %d, want the default of 2", client.maxSeen)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Minute},
+		{failures: 2, want: 2 * time.Minute},
+		{failures: 5, want: 16 * time.Minute},
+		{failures: 11, want: 1024 * time.Minute},
+		{failures: 12, want: backoffMax},
+		{failures: 1000, want: backoffMax},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package logdedup provides a slog handler that collapses bursts of identical
+// log records, so a crash-looping queue or a persistent watcher error doesn't
+// flood the log and wear out flash storage.
+package logdedup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RepeatedKey is the attribute carrying the number of suppressed duplicates.
+const RepeatedKey = "repeated"
+
+// Options configure a Handler.
+type Options struct {
+	// Window is how long duplicates of a record are suppressed after it is logged
+	Window time.Duration
+
+	// Keys are the attributes that distinguish records with the same level and
+	// message. Nil compares all attributes.
+	Keys []string
+}
+
+// pending is the most recently logged record and its suppressed duplicates.
+type pending struct {
+	key     string
+	next    slog.Handler
+	record  slog.Record
+	count   int
+	expires time.Time
+	timer   *time.Timer
+}
+
+// state is shared by a handler and all handlers derived from it.
+type state struct {
+	opts Options
+	keys map[string]bool
+
+	mu   sync.Mutex
+	last *pending
+}
+
+// Handler passes the first of a run of identical records through immediately
+// and counts the duplicates that follow within the window. When the window
+// closes or a different record is logged, the last duplicate is emitted once
+// with a repeated=N attribute. Debug records are never deduplicated.
+type Handler struct {
+	next   slog.Handler
+	state  *state
+	attrs  []string // "group.key=value" of attributes added with WithAttrs
+	groups string   // group prefix for record attributes
+}
+
+// New wraps next in a deduplicating handler.
+func New(next slog.Handler, opts Options) *Handler {
+	s := &state{opts: opts}
+	if opts.Keys != nil {
+		s.keys = make(map[string]bool, len(opts.Keys))
+		for _, k := range opts.Keys {
+			s.keys[k] = true
+		}
+	}
+	return &Handler{next: next, state: s}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelInfo || h.state.opts.Window <= 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	key := h.key(r)
+	now := time.Now()
+
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last := s.last; last != nil && last.key == key && now.Before(last.expires) {
+		last.record = r.Clone()
+		last.next = h.next
+		last.count++
+		if last.timer == nil {
+			last.timer = time.AfterFunc(last.expires.Sub(now), func() { s.expire(last) })
+		}
+		return nil
+	}
+
+	// A different record ends the run of the previous one
+	flushErr := s.flushLocked()
+	s.last = &pending{key: key, next: h.next, expires: now.Add(s.opts.Window)}
+
+	if err := h.next.Handle(ctx, r); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append([]string(nil), h.attrs...)
+	for _, a := range attrs {
+		clone.attrs = h.state.appendAttr(clone.attrs, h.groups, a)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.groups = h.groups + name + "."
+	return &clone
+}
+
+// Flush emits the pending aggregate, if any. Call it on shutdown so the
+// final count is not lost.
+func (h *Handler) Flush() error {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	err := h.state.flushLocked()
+	h.state.last = nil
+	return err
+}
+
+// key returns the identity of r: level, message and the compared attributes.
+func (h *Handler) key(r slog.Record) string {
+	parts := append([]string{r.Level.String(), r.Message}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		parts = h.state.appendAttr(parts, h.groups, a)
+		return true
+	})
+	return strings.Join(parts, "\x00")
+}
+
+// appendAttr appends the compared parts of a to parts.
+func (s *state) appendAttr(parts []string, prefix string, a slog.Attr) []string {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			parts = s.appendAttr(parts, prefix+a.Key+".", ga)
+		}
+		return parts
+	}
+	if s.keys != nil && !s.keys[a.Key] {
+		return parts
+	}
+	return append(parts, fmt.Sprintf("%s%s=%v", prefix, a.Key, a.Value.Any()))
+}
+
+// expire flushes last when its window closes, unless it was already replaced.
+func (s *state) expire(last *pending) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last == last {
+		s.flushLocked()
+		s.last = nil
+	}
+}
+
+// flushLocked emits the aggregate of the pending run. Caller must hold s.mu.
+func (s *state) flushLocked() error {
+	last := s.last
+	if last == nil || last.count == 0 {
+		return nil
+	}
+	if last.timer != nil {
+		last.timer.Stop()
+	}
+
+	r := last.record.Clone()
+	r.AddAttrs(slog.Int(RepeatedKey, last.count))
+	last.count = 0
+	return last.next.Handle(context.Background(), r)
+}
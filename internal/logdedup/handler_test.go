@@ -0,0 +1,177 @@
+package logdedup
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the window timer goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines returns the logged lines.
+func (b *syncBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimSuffix(b.buf.String(), "\n"), "\n")
+}
+
+// newTestLogger returns a logger deduplicating into a text handler without
+// timestamps, along with its handler and output.
+func newTestLogger(opts Options) (*slog.Logger, *Handler, *syncBuffer) {
+	out := &syncBuffer{}
+	text := slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	h := New(text, opts)
+	return slog.New(h), h, out
+}
+
+// checkLines fails the test unless out holds exactly want.
+func checkLines(t *testing.T, out *syncBuffer, want ...string) {
+	t.Helper()
+	got := out.lines()
+	if len(want) == 0 {
+		want = []string{""}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHandlerCollapsesRuns(t *testing.T) {
+	logger, _, out := newTestLogger(Options{Window: time.Hour})
+
+	for range 3 {
+		logger.Warn("queue crashed", "queue", 200)
+	}
+	logger.Warn("queue crashed", "queue", 201)
+	logger.Info("reloaded")
+
+	checkLines(t, out,
+		`level=WARN msg="queue crashed" queue=200`,
+		`level=WARN msg="queue crashed" queue=200 repeated=2`,
+		`level=WARN msg="queue crashed" queue=201`,
+		`level=INFO msg=reloaded`,
+	)
+}
+
+func TestHandlerFlush(t *testing.T) {
+	logger, h, out := newTestLogger(Options{Window: time.Hour})
+
+	logger.Error("watch failed", "error", "permission denied")
+	logger.Error("watch failed", "error", "permission denied")
+	if err := h.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	// Nothing is pending any more
+	if err := h.Flush(); err != nil {
+		t.Fatalf("second Flush() error = %v", err)
+	}
+	// The run ended with the flush, so the same record is logged again
+	logger.Error("watch failed", "error", "permission denied")
+
+	checkLines(t, out,
+		`level=ERROR msg="watch failed" error="permission denied"`,
+		`level=ERROR msg="watch failed" error="permission denied" repeated=1`,
+		`level=ERROR msg="watch failed" error="permission denied"`,
+	)
+}
+
+func TestHandlerWindowExpires(t *testing.T) {
+	logger, _, out := newTestLogger(Options{Window: 20 * time.Millisecond})
+
+	logger.Warn("slow firewall operation")
+	logger.Warn("slow firewall operation")
+	logger.Warn("slow firewall operation")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(out.lines()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	checkLines(t, out,
+		`level=WARN msg="slow firewall operation"`,
+		`level=WARN msg="slow firewall operation" repeated=2`,
+	)
+
+	// After the window the next record starts a new run
+	logger.Warn("slow firewall operation")
+	if n := len(out.lines()); n != 3 {
+		t.Errorf("logged %d lines after the window closed, want 3", n)
+	}
+}
+
+func TestHandlerDebugExempt(t *testing.T) {
+	logger, _, out := newTestLogger(Options{Window: time.Hour})
+
+	logger.Debug("polling")
+	logger.Debug("polling")
+
+	checkLines(t, out,
+		`level=DEBUG msg=polling`,
+		`level=DEBUG msg=polling`,
+	)
+}
+
+func TestHandlerZeroWindow(t *testing.T) {
+	logger, _, out := newTestLogger(Options{})
+
+	logger.Info("tick")
+	logger.Info("tick")
+
+	checkLines(t, out, `level=INFO msg=tick`, `level=INFO msg=tick`)
+}
+
+func TestHandlerKeySubset(t *testing.T) {
+	logger, h, out := newTestLogger(Options{Window: time.Hour, Keys: []string{"queue"}})
+
+	// pid is not compared, so restarts of the same queue collapse and the
+	// aggregate keeps the attributes of the last duplicate
+	logger.Warn("queue crashed", "queue", 200, "pid", 10)
+	logger.Warn("queue crashed", "queue", 200, "pid", 11)
+	logger.Warn("queue crashed", "queue", 200, "pid", 12)
+	logger.Warn("queue crashed", "queue", 201, "pid", 13)
+	h.Flush()
+
+	checkLines(t, out,
+		`level=WARN msg="queue crashed" queue=200 pid=10`,
+		`level=WARN msg="queue crashed" queue=200 pid=12 repeated=2`,
+		`level=WARN msg="queue crashed" queue=201 pid=13`,
+	)
+}
+
+func TestHandlerPreservesAttrsAndGroups(t *testing.T) {
+	logger, h, out := newTestLogger(Options{Window: time.Hour})
+
+	watcher := logger.With("component", "watcher").WithGroup("file")
+	watcher.Warn("stat failed", "path", "/etc/zapret/strategy.bat")
+	watcher.Warn("stat failed", "path", "/etc/zapret/strategy.bat")
+	// Same message and attribute under another group is a different record
+	logger.Warn("stat failed", "path", "/etc/zapret/strategy.bat")
+	h.Flush()
+
+	// The count is a record attribute, so it lands in the open group
+	checkLines(t, out,
+		`level=WARN msg="stat failed" component=watcher file.path=/etc/zapret/strategy.bat`,
+		`level=WARN msg="stat failed" component=watcher file.path=/etc/zapret/strategy.bat file.repeated=1`,
+		`level=WARN msg="stat failed" path=/etc/zapret/strategy.bat`,
+	)
+}
@@ -0,0 +1,267 @@
+// Package metrics renders daemon state in the Prometheus text exposition format.
+// The CLI textfile mode and the daemon share these definitions so both sources
+// expose identical metric names and labels.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// SnapshotFields are the snapshot parts needed to render all metrics.
+var SnapshotFields = []string{"status", "rules", "counters", "processes", "health", "reloads", "rpc"}
+
+// healthStates are the possible values of the state label of zapret_health.
+var healthStates = []string{"healthy", "degraded", "reloading", "stopped"}
+
+// Options control rendering.
+type Options struct {
+	// Timestamp is appended to every sample if set. The textfile collector
+	// convention is to omit timestamps, so leave it zero unless needed.
+	Timestamp time.Time
+}
+
+// Label is a metric label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// writer emits metric families, remembering the first write error.
+type writer struct {
+	w   *bufio.Writer
+	ts  string
+	err error
+}
+
+// family writes the HELP and TYPE header of a metric family.
+func (w *writer) family(name, typ, help string) {
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a single sample.
+func (w *writer) sample(name string, value float64, labels ...Label) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(l.Name)
+			b.WriteString(`="`)
+			b.WriteString(escapeLabel(l.Value))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	if w.ts != "" {
+		b.WriteByte(' ')
+		b.WriteString(w.ts)
+	}
+	b.WriteByte('\n')
+	w.printf("%s", b.String())
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
+
+// escapeLabel escapes a label value for the text format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// boolValue converts b to a gauge value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Write renders snap as Prometheus metrics.
+func Write(out io.Writer, snap *daemon.SnapshotResponse, opts Options) error {
+	w := &writer{w: bufio.NewWriter(out)}
+	if !opts.Timestamp.IsZero() {
+		w.ts = strconv.FormatInt(opts.Timestamp.UnixMilli(), 10)
+	}
+
+	status := snap.GetStatus()
+
+	w.family("zapret_up", "gauge", "Whether the strategy runner is running.")
+	w.sample("zapret_up", boolValue(status.GetRunning()))
+
+	w.family("zapret_health", "gauge", "Strategy runner health state.")
+	for _, state := range healthStates {
+		w.sample("zapret_health", boolValue(snap.Health == state), Label{"state", state})
+	}
+
+	if start, err := time.Parse(time.RFC3339, status.GetStartTime()); err == nil {
+		w.family("zapret_start_time_seconds", "gauge", "Unix time the strategy runner was started.")
+		w.sample("zapret_start_time_seconds", float64(start.Unix()))
+	}
+
+	w.family("zapret_rules", "gauge", "Number of strategy rules.")
+	w.sample("zapret_rules", float64(len(snap.Rules)))
+
+	w.family("zapret_rules_pending", "gauge", "Number of rules waiting for their hostlist files.")
+	w.sample("zapret_rules_pending", float64(status.GetPendingRules()))
+
+	w.family("zapret_processes", "gauge", "Number of running nfqws processes.")
+	w.sample("zapret_processes", float64(status.GetActiveProcesses()))
+
+	w.family("zapret_queues", "gauge", "Number of queues of the applied rules.")
+	w.sample("zapret_queues", float64(status.GetActiveQueues()))
+
+	w.family("zapret_firewall_rules", "gauge", "Number of queue rules installed in the firewall.")
+	w.sample("zapret_firewall_rules", float64(status.GetFirewallRules()))
+
+	w.family("zapret_process_restarts_total", "counter", "Restarts of crashed nfqws processes.")
+	w.sample("zapret_process_restarts_total", float64(status.GetProcessRestarts()))
+
+	w.family("zapret_config_reloads_total", "counter", "Configuration reloads, failed ones included.")
+	w.sample("zapret_config_reloads_total", float64(status.GetConfigReloads()))
+
+	w.family("zapret_strategy_parse_errors_total", "counter", "Strategy files that failed to parse on start or reload.")
+	w.sample("zapret_strategy_parse_errors_total", float64(status.GetParseErrors()))
+
+	w.family("zapret_firewall_op_last_seconds", "gauge", "Duration of the most recent firewall operation.")
+	w.sample("zapret_firewall_op_last_seconds", status.GetFirewallLastOpMs()/1000)
+
+	w.family("zapret_firewall_op_max_seconds", "gauge", "Longest firewall operation observed.")
+	w.sample("zapret_firewall_op_max_seconds", status.GetFirewallMaxOpMs()/1000)
+
+	w.family("zapret_hostlist_index_bytes", "gauge", "Estimated memory used by loaded hostlists.")
+	w.sample("zapret_hostlist_index_bytes", float64(status.GetHostlistIndexBytes()))
+
+	w.family("zapret_netlink_reconnects_total", "counter", "Firewall operations retried after a netlink buffer overrun.")
+	w.sample("zapret_netlink_reconnects_total", float64(status.GetNetlinkReconnects()))
+
+	w.family("zapret_zombies_reaped_total", "counter", "Exited child processes nobody waited for, reaped by the periodic check.")
+	w.sample("zapret_zombies_reaped_total", float64(status.GetZombiesReaped()))
+
+	w.family("zapret_processes_suspended", "gauge", "Number of nfqws processes stopped by zapret suspend or SIGSTOP.")
+	w.sample("zapret_processes_suspended", float64(len(status.GetSuspendedQueues())))
+
+	if len(snap.Reloads) > 0 {
+		last := snap.Reloads[0]
+		w.family("zapret_last_reload_success", "gauge", "Whether the most recent reload succeeded.")
+		w.sample("zapret_last_reload_success", boolValue(last.Error == ""))
+
+		if t, err := time.Parse(time.RFC3339, last.Time); err == nil {
+			w.family("zapret_last_reload_timestamp_seconds", "gauge", "Unix time of the most recent reload.")
+			w.sample("zapret_last_reload_timestamp_seconds", float64(t.Unix()))
+		}
+	}
+
+	writeQueues(w, snap)
+	writeRPC(w, snap)
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// writeQueues writes the per-queue metric families.
+func writeQueues(w *writer, snap *daemon.SnapshotResponse) {
+	if len(snap.Rules) == 0 {
+		return
+	}
+
+	labels := func(rule *daemon.RuleInfo) []Label {
+		return []Label{
+			{"queue", strconv.Itoa(int(rule.QueueNum))},
+			{"protocol", rule.Protocol},
+			{"ports", rule.Ports},
+		}
+	}
+
+	if snap.CountersError == "" {
+		w.family("zapret_queue_packets_total", "counter", "Packets sent to the queue.")
+		for _, rule := range snap.Rules {
+			w.sample("zapret_queue_packets_total", float64(rule.Packets), labels(rule)...)
+		}
+
+		w.family("zapret_queue_bytes_total", "counter", "Bytes sent to the queue.")
+		for _, rule := range snap.Rules {
+			w.sample("zapret_queue_bytes_total", float64(rule.Bytes), labels(rule)...)
+		}
+	}
+
+	stats := make(map[int32]*daemon.QueueStats)
+	for _, proc := range snap.Processes {
+		if proc.Stats != nil {
+			stats[proc.QueueNum] = proc.Stats
+		}
+	}
+	if len(stats) == 0 {
+		return
+	}
+
+	families := []struct {
+		name  string
+		help  string
+		value func(*daemon.QueueStats) uint64
+	}{
+		{"zapret_queue_desync_applied_total", "Desync actions applied by nfqws.", (*daemon.QueueStats).GetDesyncApplied},
+		{"zapret_queue_hostlist_hits_total", "Hostlist checks that matched.", (*daemon.QueueStats).GetHostlistHits},
+		{"zapret_queue_autohostlist_additions_total", "Domains added to the auto hostlist.", (*daemon.QueueStats).GetAutohostlistAdditions},
+		{"zapret_queue_parse_errors_total", "Packets nfqws failed to parse.", (*daemon.QueueStats).GetParseErrors},
+	}
+	for _, f := range families {
+		w.family(f.name, "counter", f.help)
+		for _, rule := range snap.Rules {
+			if s, ok := stats[rule.QueueNum]; ok {
+				w.sample(f.name, float64(f.value(s)), labels(rule)...)
+			}
+		}
+	}
+}
+
+// writeRPC writes the per-method RPC metric families.
+func writeRPC(w *writer, snap *daemon.SnapshotResponse) {
+	w.family("zapret_rpc_panics_total", "counter", "Panics recovered while serving RPC requests.")
+	w.sample("zapret_rpc_panics_total", float64(snap.RpcPanics))
+
+	if len(snap.RpcMethods) == 0 {
+		return
+	}
+
+	w.family("zapret_rpc_requests_total", "counter", "RPC requests served.")
+	for _, m := range snap.RpcMethods {
+		w.sample("zapret_rpc_requests_total", float64(m.Requests), Label{"method", m.Method})
+	}
+
+	w.family("zapret_rpc_errors_total", "counter", "RPC requests answered with an error.")
+	for _, m := range snap.RpcMethods {
+		w.sample("zapret_rpc_errors_total", float64(m.Errors), Label{"method", m.Method})
+	}
+
+	w.family("zapret_rpc_duration_seconds", "histogram", "Time spent serving RPC requests.")
+	for _, m := range snap.RpcMethods {
+		method := Label{"method", m.Method}
+		for i, bound := range m.BucketBounds {
+			if i < len(m.BucketCounts) {
+				le := Label{"le", strconv.FormatFloat(bound, 'g', -1, 64)}
+				w.sample("zapret_rpc_duration_seconds_bucket", float64(m.BucketCounts[i]), method, le)
+			}
+		}
+		w.sample("zapret_rpc_duration_seconds_bucket", float64(m.Requests), method, Label{"le", "+Inf"})
+		w.sample("zapret_rpc_duration_seconds_sum", m.DurationSumSeconds, method)
+		w.sample("zapret_rpc_duration_seconds_count", float64(m.Requests), method)
+	}
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// testSnapshot returns a snapshot of a running daemon with two rules, queue
+// statistics for one of them, a failed last reload and RPC statistics.
+func testSnapshot() *daemon.SnapshotResponse {
+	return &daemon.SnapshotResponse{
+		Health: "degraded",
+		Status: &daemon.StatusResponse{
+			Running:            true,
+			ActiveQueues:       2,
+			ActiveProcesses:    1,
+			StartTime:          "2026-10-01T12:00:00Z",
+			FirewallLastOpMs:   12.5,
+			FirewallMaxOpMs:    250,
+			HostlistIndexBytes: 4096,
+			PendingRules:       1,
+			NetlinkReconnects:  3,
+			ZombiesReaped:      1,
+			SuspendedQueues:    []int32{201},
+		},
+		Rules: []*daemon.RuleInfo{
+			{QueueNum: 200, Protocol: "tcp", Ports: "80,443", Packets: 1500, Bytes: 900000},
+			{QueueNum: 201, Protocol: "udp", Ports: "443", Packets: 20, Bytes: 24000},
+		},
+		Processes: []*daemon.ProcessInfo{
+			{QueueNum: 200, Stats: &daemon.QueueStats{DesyncApplied: 120, HostlistHits: 80, AutohostlistAdditions: 2, ParseErrors: 1}},
+			{QueueNum: 201},
+		},
+		Reloads: []*daemon.ReloadInfo{
+			{Time: "2026-10-02T08:30:00Z", Triggers: []string{"strategy"}, Error: "parse strategy: line 3: unknown option"},
+			{Time: "2026-10-01T12:00:00Z", Triggers: []string{"config"}},
+		},
+		RpcPanics: 1,
+		RpcMethods: []*daemon.RpcMethodStats{
+			{
+				Method:             "Status",
+				Requests:           10,
+				Errors:             1,
+				BucketBounds:       []float64{0.005, 0.05, 0.5},
+				BucketCounts:       []uint64{7, 9, 10},
+				DurationSumSeconds: 0.125,
+			},
+		},
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	countersErr := testSnapshot()
+	countersErr.CountersError = "nft: permission denied"
+
+	tests := []struct {
+		name string
+		snap *daemon.SnapshotResponse
+		opts Options
+	}{
+		{name: "running", snap: testSnapshot()},
+		{name: "timestamp", snap: testSnapshot(), opts: Options{Timestamp: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC)}},
+		{name: "counters-error", snap: countersErr},
+		{name: "stopped", snap: &daemon.SnapshotResponse{Health: "stopped"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.snap, tt.opts); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			got := buf.Bytes()
+
+			golden := filepath.Join("testdata", tt.name+".golden.prom")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -run TestWriteGolden -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Write() differs from %s (run with -update if the change is intended)\n got: %s\nwant: %s",
+					golden, got, want)
+			}
+		})
+	}
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteError(t *testing.T) {
+	if err := Write(failWriter{}, testSnapshot(), Options{}); err == nil {
+		t.Error("Write() to a failing writer succeeded, want error")
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "80,443", want: "80,443"},
+		{in: `C:\lists`, want: `C:\\lists`},
+		{in: `say "hi"`, want: `say \"hi\"`},
+		{in: "a\nb", want: `a\nb`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLabel(tt.in); got != tt.want {
+			t.Errorf("escapeLabel(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
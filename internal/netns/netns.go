@@ -0,0 +1,21 @@
+// Package netns runs work inside a named network namespace, as created by
+// `ip netns add`. Commands started inside inherit the namespace, so firewall
+// tools and nfqws operate entirely within it while the caller stays in the
+// host namespace.
+package netns
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// Dir is where `ip netns` bind-mounts named namespaces.
+const Dir = "/run/netns"
+
+// ErrUnsupported is returned on platforms without network namespaces.
+var ErrUnsupported = errors.New("network namespaces are not supported on this platform")
+
+// Path returns the path of the named namespace.
+func Path(name string) string {
+	return filepath.Join(Dir, name)
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package netns
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+	"golang.org/x/sys/unix"
+)
+
+func init() {
+	features.Register(features.KindFeature, "netns", "running rules and nfqws in a network namespace")
+}
+
+// Check returns an error if the named namespace doesn't exist.
+func Check(name string) error {
+	if _, err := os.Stat(Path(name)); err != nil {
+		return fmt.Errorf("network namespace %q not found: %w", name, err)
+	}
+	return nil
+}
+
+// Do runs fn with the calling OS thread in the named namespace. An empty name
+// runs fn directly. fn runs on a dedicated goroutine locked to its thread;
+// the thread is never unlocked, so the runtime discards it afterwards instead
+// of reusing it in the wrong namespace.
+func Do(name string, fn func() error) error {
+	if name == "" {
+		return fn()
+	}
+
+	ns, err := os.Open(Path(name))
+	if err != nil {
+		return fmt.Errorf("failed to open network namespace %q: %w", name, err)
+	}
+	defer ns.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+
+		if err := unix.Setns(int(ns.Fd()), unix.CLONE_NEWNET); err != nil {
+			errc <- fmt.Errorf("failed to enter network namespace %q: %w", name, err)
+			return
+		}
+		errc <- fn()
+	}()
+	return <-errc
+}
@@ -0,0 +1,99 @@
+//go:build linux
+
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// scratchNamespace creates a named namespace removed when the test ends. It
+// skips the test unless running as root with ip(8) available.
+func scratchNamespace(t *testing.T) string {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces needs root")
+	}
+	if _, err := exec.LookPath("ip"); err != nil {
+		t.Skip("ip(8) not found")
+	}
+	name := fmt.Sprintf("zapret-test-%d", os.Getpid())
+	if out, err := exec.Command("ip", "netns", "add", name).CombinedOutput(); err != nil {
+		t.Skipf("ip netns add: %v: %s", err, out)
+	}
+	t.Cleanup(func() { exec.Command("ip", "netns", "delete", name).Run() })
+	return name
+}
+
+// currentNamespace returns the network namespace of the calling thread.
+func currentNamespace(t *testing.T) string {
+	t.Helper()
+	ns, err := os.Readlink("/proc/thread-self/ns/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ns
+}
+
+func TestDoWithoutName(t *testing.T) {
+	called := false
+	if err := Do("", func() error { called = true; return nil }); err != nil || !called {
+		t.Errorf("Do(\"\") = %v, called %v, want fn run in place", err, called)
+	}
+}
+
+func TestDoMissingNamespace(t *testing.T) {
+	err := Do("zapret-missing", func() error {
+		t.Error("fn ran without its namespace")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "zapret-missing") {
+		t.Errorf("Do() error = %v, want an error naming the namespace", err)
+	}
+	if err := Check("zapret-missing"); err == nil {
+		t.Error("Check() of a missing namespace succeeded")
+	}
+}
+
+func TestDoEntersNamespace(t *testing.T) {
+	name := scratchNamespace(t)
+	if err := Check(name); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	host := currentNamespace(t)
+	var inside string
+	var links []byte
+	err := Do(name, func() error {
+		// fn runs on another goroutine, where t.Fatal must not be called
+		var err error
+		if inside, err = os.Readlink("/proc/thread-self/ns/net"); err != nil {
+			return err
+		}
+		// Commands started here inherit the namespace
+		links, err = exec.Command("ip", "-o", "link").Output()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if inside == host {
+		t.Errorf("fn ran in the host namespace %s", host)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(links)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "lo:") {
+		t.Errorf("links inside the namespace = %q, want only lo", links)
+	}
+	if got := currentNamespace(t); got != host {
+		t.Errorf("caller moved to namespace %s", got)
+	}
+
+	// Errors of fn are returned as is
+	errFn := errors.New("fn failed")
+	if err := Do(name, func() error { return errFn }); !errors.Is(err, errFn) {
+		t.Errorf("Do() error = %v, want %v", err, errFn)
+	}
+}
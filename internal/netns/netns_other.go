@@ -0,0 +1,16 @@
+//go:build !linux
+
+package netns
+
+// Check returns ErrUnsupported: named namespaces only exist on Linux.
+func Check(name string) error {
+	return ErrUnsupported
+}
+
+// Do runs fn directly for an empty name and fails otherwise.
+func Do(name string, fn func() error) error {
+	if name == "" {
+		return fn()
+	}
+	return ErrUnsupported
+}
@@ -0,0 +1,191 @@
+// Package ports parses port specs like "80,443,50000-50100" into normalized
+// sets, subtracts excluded ports from them and traces whether a port is
+// covered through the transformations a spec goes through before it becomes
+// a firewall rule.
+package ports
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range is an inclusive port range; a single port has First == Last.
+type Range struct {
+	First int
+	Last  int
+}
+
+// String formats the range as "443" or "1024-65535".
+func (r Range) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// Contains reports whether port lies in the range.
+func (r Range) Contains(port int) bool {
+	return port >= r.First && port <= r.Last
+}
+
+// Set is a normalized port set: sorted, non-overlapping ranges with adjacent
+// ranges merged.
+type Set []Range
+
+// Parse parses a comma-separated list of ports and port ranges into a set.
+func Parse(spec string) (Set, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("no ports specified")
+	}
+
+	var ranges []Range
+	for _, part := range strings.Split(spec, ",") {
+		if part == "" {
+			return nil, fmt.Errorf("empty port in list")
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := ParsePort(lo)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = ParsePort(hi); err != nil {
+				return nil, err
+			}
+			if first > last {
+				return nil, fmt.Errorf("invalid port range %s: start is greater than end", part)
+			}
+		}
+		ranges = append(ranges, Range{First: first, Last: last})
+	}
+
+	return normalize(ranges), nil
+}
+
+// ParsePort parses a single port number in the range 1-65535.
+func ParsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q: must be a number between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+// normalize sorts ranges and merges overlapping and adjacent ones.
+func normalize(ranges []Range) Set {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].First < ranges[j].First })
+
+	set := make(Set, 0, len(ranges))
+	for _, r := range ranges {
+		if n := len(set); n > 0 && r.First <= set[n-1].Last+1 {
+			set[n-1].Last = max(set[n-1].Last, r.Last)
+			continue
+		}
+		set = append(set, r)
+	}
+	return set
+}
+
+// Find returns the range of the set containing port.
+func (s Set) Find(port int) (Range, bool) {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Last >= port })
+	if i < len(s) && s[i].Contains(port) {
+		return s[i], true
+	}
+	return Range{}, false
+}
+
+// Contains reports whether the set contains port.
+func (s Set) Contains(port int) bool {
+	_, ok := s.Find(port)
+	return ok
+}
+
+// String formats the set as a port spec.
+func (s Set) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Subtract returns the ports of s not in other.
+func (s Set) Subtract(other Set) Set {
+	var result Set
+	j := 0
+	for _, r := range s {
+		first := r.First
+		// Skip the ranges of other ending before r
+		for j < len(other) && other[j].Last < first {
+			j++
+		}
+		for k := j; k < len(other) && other[k].First <= r.Last; k++ {
+			if other[k].First > first {
+				result = append(result, Range{First: first, Last: other[k].First - 1})
+			}
+			first = other[k].Last + 1
+		}
+		if first <= r.Last {
+			result = append(result, Range{First: first, Last: r.Last})
+		}
+	}
+	return result
+}
+
+// Exclude returns spec without the ports of excluded as a normalized spec,
+// "" when nothing is left. An empty excluded returns spec unchanged.
+func Exclude(spec, excluded string) (string, error) {
+	if excluded == "" {
+		return spec, nil
+	}
+	set, err := Parse(spec)
+	if err != nil {
+		return "", err
+	}
+	remove, err := Parse(excluded)
+	if err != nil {
+		return "", fmt.Errorf("excluded ports: %w", err)
+	}
+	return set.Subtract(remove).String(), nil
+}
+
+// Stage is a port spec at one step of its transformations, e.g. as written
+// in the strategy file or after variables were substituted.
+type Stage struct {
+	// Name describes the transformation that produced Spec
+	Name string
+
+	// Spec is the port spec after the transformation
+	Spec string
+
+	// Resolved reports that Spec is numeric; a spec still holding variables
+	// or aliases can't be evaluated
+	Resolved bool
+
+	// Covered reports that the resolved spec contains the port
+	Covered bool
+
+	// Range is the range of the spec containing the port
+	Range Range
+}
+
+// Trace evaluates port against the spec of each stage in order and returns
+// the stages with Resolved, Covered and Range filled in. Covered of the last
+// stage is the coverage of the final spec.
+func Trace(port int, stages []Stage) []Stage {
+	traced := make([]Stage, len(stages))
+	for i, stage := range stages {
+		stage.Resolved, stage.Covered, stage.Range = false, false, Range{}
+		if set, err := Parse(stage.Spec); err == nil {
+			stage.Resolved = true
+			stage.Range, stage.Covered = set.Find(port)
+		}
+		traced[i] = stage
+	}
+	return traced
+}
@@ -0,0 +1,168 @@
+package ports
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{spec: "443", want: "443"},
+		{spec: "443,80", want: "80,443"},
+		{spec: "50000-50100,50050-50200", want: "50000-50200"},
+		{spec: "1-10,11-20,22", want: "1-20,22"},
+		{spec: "443,443", want: "443"},
+		{spec: "", wantErr: "no ports specified"},
+		{spec: "80,,443", wantErr: "empty port in list"},
+		{spec: "0", wantErr: `invalid port "0"`},
+		{spec: "65536", wantErr: `invalid port "65536"`},
+		{spec: "https", wantErr: `invalid port "https"`},
+		{spec: "200-100", wantErr: "start is greater than end"},
+	}
+
+	for _, tt := range tests {
+		set, err := Parse(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := set.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		spec     string
+		excluded string
+		want     string
+	}{
+		{spec: "443", excluded: "", want: "443"},
+		{spec: "1024-65535", excluded: "50000-50100", want: "1024-49999,50101-65535"},
+		{spec: "1024-65535", excluded: "1024,65535", want: "1025-65534"},
+		{spec: "80,443,50000-50100", excluded: "443,50050", want: "80,50000-50049,50051-50100"},
+		{spec: "50000-50100", excluded: "1-49999,50101-65535", want: "50000-50100"},
+		{spec: "50000-50100", excluded: "40000-60000", want: ""},
+		{spec: "80,443", excluded: "443,80", want: ""},
+		{spec: "100-200,300-400", excluded: "150-350", want: "100-149,351-400"},
+	}
+
+	for _, tt := range tests {
+		got, err := Exclude(tt.spec, tt.excluded)
+		if err != nil || got != tt.want {
+			t.Errorf("Exclude(%q, %q) = %q, %v, want %q", tt.spec, tt.excluded, got, err, tt.want)
+		}
+	}
+
+	if _, err := Exclude("443", "x"); err == nil || !strings.Contains(err.Error(), "excluded ports") {
+		t.Errorf("Exclude() of an invalid exclusion error = %v, want it named", err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	stages := Trace(50012, []Stage{
+		{Name: "as written", Spec: "%GameFilter%"},
+		{Name: "%GameFilter% expanded", Spec: "1024-65535"},
+		{Name: "exclusions", Spec: "1024-49999,50010-65535"},
+		{Name: "never-queue", Spec: "1024-49999,50010-50011,50013-65535"},
+	})
+
+	want := []struct {
+		resolved bool
+		covered  bool
+		rng      string
+	}{
+		{resolved: false},
+		{resolved: true, covered: true, rng: "1024-65535"},
+		{resolved: true, covered: true, rng: "50010-65535"},
+		{resolved: true, covered: false},
+	}
+	for i, w := range want {
+		got := stages[i]
+		if got.Resolved != w.resolved || got.Covered != w.covered || got.Covered && got.Range.String() != w.rng {
+			t.Errorf("stage %d (%s) = resolved %v, covered %v, range %s, want %v, %v, %s",
+				i, got.Name, got.Resolved, got.Covered, got.Range, w.resolved, w.covered, w.rng)
+		}
+	}
+}
+
+// portBits returns the ports of spec as a bitmap, with port 0 unused.
+func portBits(t *testing.T, spec string) []bool {
+	t.Helper()
+	bits := make([]bool, 65536)
+	set, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", spec, err)
+	}
+	for _, r := range set {
+		for port := r.First; port <= r.Last; port++ {
+			bits[port] = true
+		}
+	}
+	return bits
+}
+
+// specFrom builds a valid, unnormalized port spec from the bytes of data,
+// four per port or range.
+func specFrom(data []byte) string {
+	var parts []string
+	for i := 0; i+3 < len(data); i += 4 {
+		first := 1 + (int(data[i])<<8|int(data[i+1]))%65535
+		last := first + int(data[i+2])<<4 + int(data[i+3])%16
+		if data[i+2]%3 == 0 || last > 65535 {
+			last = first
+		}
+		parts = append(parts, Range{First: first, Last: last}.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func FuzzExclude(f *testing.F) {
+	f.Add([]byte{0, 80, 0, 0, 1, 187, 0, 0}, []byte{1, 187, 0, 0})
+	f.Add([]byte{4, 0, 255, 15}, []byte{195, 80, 6, 4})
+	f.Add([]byte{255, 255, 255, 255}, []byte{0, 0, 1, 1, 255, 254, 0, 0})
+
+	f.Fuzz(func(t *testing.T, specData, excludedData []byte) {
+		spec, excluded := specFrom(specData), specFrom(excludedData)
+		if spec == "" {
+			return
+		}
+
+		got, err := Exclude(spec, excluded)
+		if err != nil {
+			t.Fatalf("Exclude(%q, %q) error = %v", spec, excluded, err)
+		}
+
+		// The result is normalized unless nothing was excluded, and holds
+		// exactly the ports of spec not excluded
+		in := portBits(t, spec)
+		out := make([]bool, 65536)
+		if got != "" {
+			set, err := Parse(got)
+			if err != nil || excluded != "" && set.String() != got {
+				t.Fatalf("Exclude(%q, %q) = %q, not a normalized spec: %v", spec, excluded, got, err)
+			}
+			out = portBits(t, got)
+		}
+		removed := make([]bool, 65536)
+		if excluded != "" {
+			removed = portBits(t, excluded)
+		}
+		for port := 1; port <= 65535; port++ {
+			if want := in[port] && !removed[port]; out[port] != want {
+				t.Fatalf("Exclude(%q, %q) = %q: port %d covered %v, want %v", spec, excluded, got, port, out[port], want)
+			}
+		}
+	})
+}
@@ -0,0 +1,135 @@
+// Package registry fetches community strategy presets from a JSON index.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// maxDownloadSize limits the size of a single downloaded file.
+const maxDownloadSize = 16 << 20
+
+// Index is the registry index listing available presets.
+type Index struct {
+	Strategies []Preset `json:"strategies"`
+}
+
+// Preset is a named strategy preset.
+type Preset struct {
+	// Name is the unique preset name
+	Name string `json:"name"`
+
+	// Description is a short human-readable description
+	Description string `json:"description"`
+
+	// URL is the location of the strategy .bat file
+	URL string `json:"url"`
+
+	// SHA256 is the expected hex-encoded checksum of the strategy file
+	SHA256 string `json:"sha256"`
+
+	// Lists are hostlist files referenced by the strategy
+	Lists []File `json:"lists"`
+}
+
+// File is a downloadable file with a checksum.
+type File struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// Client downloads registry data.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new registry client.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{httpClient: httpClient}
+}
+
+// FetchIndex downloads and decodes the registry index.
+func (c *Client) FetchIndex(ctx context.Context, url string) (*Index, error) {
+	data, err := c.Download(ctx, url, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registry index: %w", err)
+	}
+
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode registry index: %w", err)
+	}
+
+	return &index, nil
+}
+
+// Find returns the preset with the given name.
+func (idx *Index) Find(name string) (*Preset, error) {
+	for i := range idx.Strategies {
+		if idx.Strategies[i].Name == name {
+			return &idx.Strategies[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown strategy preset: %s", name)
+}
+
+// Download fetches url and verifies its sha256 checksum when one is given.
+func (c *Client) Download(ctx context.Context, url, checksum string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %w", url, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("file %s exceeds maximum size of %d bytes", url, maxDownloadSize)
+	}
+
+	if checksum != "" {
+		sum := sha256.Sum256(data)
+		actual := hex.EncodeToString(sum[:])
+		if !strings.EqualFold(actual, checksum) {
+			return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", url, checksum, actual)
+		}
+	}
+
+	return data, nil
+}
+
+// IsURL reports whether s looks like an http(s) URL rather than a preset name.
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// NameFromURL derives a preset name from the last path element of a URL.
+func NameFromURL(url string) string {
+	name := path.Base(url)
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
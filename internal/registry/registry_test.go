@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// checksum returns the hex sha256 of s.
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRegistry serves files by path from an httptest server.
+func newRegistry(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchIndex(t *testing.T) {
+	srv := newRegistry(t, map[string]string{
+		"/index.json": `{"strategies": [
+			{"name": "general", "description": "Default", "url": "https://example.com/general.bat", "sha256": "ab",
+			 "lists": [{"name": "list-general.txt", "url": "https://example.com/list-general.txt"}]},
+			{"name": "alt", "url": "https://example.com/alt.bat"}
+		]}`,
+		"/broken.json": `{"strategies": [`,
+	})
+	c := NewClient(srv.Client())
+
+	index, err := c.FetchIndex(t.Context(), srv.URL+"/index.json")
+	if err != nil {
+		t.Fatalf("FetchIndex() error = %v", err)
+	}
+	preset, err := index.Find("general")
+	if err != nil {
+		t.Fatalf("Find(general) error = %v", err)
+	}
+	if preset.Description != "Default" || len(preset.Lists) != 1 || preset.Lists[0].Name != "list-general.txt" {
+		t.Errorf("Find(general) = %+v", preset)
+	}
+	if _, err := index.Find("missing"); err == nil || !strings.Contains(err.Error(), "unknown strategy preset: missing") {
+		t.Errorf("Find(missing) error = %v, want an unknown preset error", err)
+	}
+
+	if _, err := c.FetchIndex(t.Context(), srv.URL+"/broken.json"); err == nil || !strings.Contains(err.Error(), "decode") {
+		t.Errorf("FetchIndex() of a broken index error = %v, want a decode error", err)
+	}
+	if _, err := c.FetchIndex(t.Context(), srv.URL+"/missing.json"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("FetchIndex() of a missing index error = %v, want the status", err)
+	}
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	const strategy = "--filter-tcp=443 --dpi-desync=fake\n"
+	srv := newRegistry(t, map[string]string{"/general.bat": strategy})
+	c := NewClient(srv.Client())
+	url := srv.URL + "/general.bat"
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{name: "no checksum", checksum: ""},
+		{name: "matching", checksum: checksum(strategy)},
+		{name: "matching upper case", checksum: strings.ToUpper(checksum(strategy))},
+		{name: "mismatch", checksum: checksum("something else"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := c.Download(t.Context(), url, tt.checksum)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+					t.Errorf("Download() error = %v, want a checksum mismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if string(data) != strategy {
+				t.Errorf("Download() = %q, want %q", data, strategy)
+			}
+		})
+	}
+}
+
+func TestDownloadTooLarge(t *testing.T) {
+	srv := newRegistry(t, map[string]string{"/huge.bat": strings.Repeat("x", maxDownloadSize+1)})
+	if _, err := NewClient(srv.Client()).Download(t.Context(), srv.URL+"/huge.bat", ""); err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
+		t.Errorf("Download() error = %v, want a size error", err)
+	}
+}
+
+func TestNameFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/strategies/general.bat", want: "general"},
+		{url: "https://example.com/general%20alt.bat?raw=1", want: "general%20alt"},
+		{url: "https://example.com/discord.bat#top", want: "discord"},
+		{url: "https://example.com/preset", want: "preset"},
+	}
+
+	for _, tt := range tests {
+		if got := NameFromURL(tt.url); got != tt.want {
+			t.Errorf("NameFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	for s, want := range map[string]bool{
+		"general":                   false,
+		"https://example.com/a.bat": true,
+		"http://example.com/a.bat":  true,
+		"ftp://example.com/a.bat":   false,
+	} {
+		if got := IsURL(s); got != want {
+			t.Errorf("IsURL(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
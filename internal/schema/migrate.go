@@ -0,0 +1,211 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migration maps a renamed or retired config key to its replacement.
+type Migration struct {
+	// Key is the dotted path of the legacy key, e.g. "firewall.table"
+	Key string
+
+	// NewKey is the dotted path of the key replacing it from the document
+	// root; "" retires Key without replacement
+	NewKey string
+
+	// Convert turns the legacy value into the new one (nil keeps the value)
+	Convert func(value *yaml.Node) (*yaml.Node, error)
+
+	// Reason explains the change, e.g. what replaces a retired key
+	Reason string
+}
+
+// Migrated is a config document with migrations applied.
+type Migrated struct {
+	// Data is the migrated document, the input itself if nothing changed
+	Data []byte
+
+	// Changed reports that a migration was applied
+	Changed bool
+
+	// Warnings describe each applied migration with the new syntax
+	Warnings []string
+}
+
+// Migrate applies migrations to the YAML document data in order. Each legacy
+// key found is moved to its new key, and a warning shows the exact syntax
+// replacing it. Retired keys and keys set under both names are errors; all
+// are reported together.
+func Migrate(data []byte, migrations []Migration) (*Migrated, error) {
+	result := &Migrated{Data: data}
+
+	// Syntax errors are left to the decoder reading the config
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return result, nil
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return result, nil
+	}
+	root := doc.Content[0]
+
+	var errs []error
+	for _, m := range migrations {
+		parent, index := lookup(root, m.Key)
+		if parent == nil {
+			continue
+		}
+		key, value := parent.Content[index], parent.Content[index+1]
+
+		if m.NewKey == "" {
+			errs = append(errs, fmt.Errorf("%q at line %d was removed: %s", m.Key, key.Line, m.Reason))
+			continue
+		}
+		if p, _ := lookup(root, m.NewKey); p != nil {
+			errs = append(errs, fmt.Errorf("%q at line %d is set along with its replacement %q; remove it", m.Key, key.Line, m.NewKey))
+			continue
+		}
+
+		if m.Convert != nil {
+			converted, err := m.Convert(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%q at line %d can't be migrated to %q: %w", m.Key, key.Line, m.NewKey, err))
+				continue
+			}
+			// Comments on the value, e.g. after it on the line, stay with it
+			if converted != value && converted.HeadComment == "" && converted.LineComment == "" && converted.FootComment == "" {
+				converted.HeadComment, converted.LineComment, converted.FootComment = value.HeadComment, value.LineComment, value.FootComment
+			}
+			value = converted
+		}
+
+		// A key renamed within its mapping keeps its place and comments
+		oldParent, _ := splitKey(m.Key)
+		newParent, name := splitKey(m.NewKey)
+		if oldParent == newParent {
+			renamed := scalar(name)
+			renamed.HeadComment, renamed.LineComment, renamed.FootComment = key.HeadComment, key.LineComment, key.FootComment
+			parent.Content[index], parent.Content[index+1] = renamed, value
+		} else {
+			parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+			insert(root, m.NewKey, value)
+		}
+		result.Changed = true
+
+		warning := fmt.Sprintf("%q at line %d is deprecated, replace it with:\n%s", m.Key, key.Line, indent(render(m.NewKey, value), "    "))
+		if m.Reason != "" {
+			warning += "\n  (" + m.Reason + ")"
+		}
+		result.Warnings = append(result.Warnings, warning)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if result.Changed {
+		out, err := encode(&doc)
+		if err != nil {
+			return nil, err
+		}
+		result.Data = out
+	}
+	return result, nil
+}
+
+// lookup finds the mapping holding the key at the dotted path and the index
+// of the key in its content. It returns nil if the key isn't set.
+func lookup(root *yaml.Node, path string) (*yaml.Node, int) {
+	node := root
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		if node.Kind != yaml.MappingNode {
+			return nil, 0
+		}
+		found := -1
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part {
+				found = j
+				break
+			}
+		}
+		if found < 0 {
+			return nil, 0
+		}
+		if i == len(parts)-1 {
+			return node, found
+		}
+		node = node.Content[found+1]
+	}
+	return nil, 0
+}
+
+// splitKey splits a dotted path into the path of its mapping and the key.
+func splitKey(path string) (string, string) {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// insert sets the key at the dotted path to value, creating the mappings
+// leading to it.
+func insert(root *yaml.Node, path string, value *yaml.Node) {
+	node := root
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part && node.Content[j+1].Kind == yaml.MappingNode {
+				next = node.Content[j+1]
+				break
+			}
+		}
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, scalar(part), next)
+		}
+		node = next
+	}
+	node.Content = append(node.Content, scalar(parts[len(parts)-1]), value)
+}
+
+// scalar returns a plain string scalar node.
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// render returns the YAML setting the dotted path to value.
+func render(path string, value *yaml.Node) string {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	insert(root, path, value)
+	out, err := encode(root)
+	if err != nil {
+		return fmt.Sprintf("%s: %s", path, value.Value)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// encode marshals node with the two-space indentation of the example configs.
+func encode(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// indent prefixes every line of s.
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
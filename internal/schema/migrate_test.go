@@ -0,0 +1,183 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// testMigrations model the history of the fixtures in testdata/migrate: v1
+// had a numeric socket_permissions and the table key, v2 a single
+// interface, v3 is current.
+var testMigrations = []Migration{
+	{
+		Key:     "server.socket_permissions",
+		NewKey:  "server.socket_mode",
+		Convert: octalString,
+		Reason:  "the mode is a string so YAML can't read it as decimal",
+	},
+	{
+		Key:    "strategy_runner.firewall.table",
+		NewKey: "strategy_runner.firewall.table_name",
+	},
+	{
+		Key:     "strategy_runner.interface",
+		NewKey:  "strategy_runner.interfaces",
+		Convert: stringList,
+		Reason:  "rules can apply to several interfaces",
+	},
+	{
+		Key:    "strategy_runner.legacy_nfqws",
+		Reason: "nfqws is always run by the daemon",
+	},
+}
+
+// octalString converts a file mode written as a number to a quoted string.
+func octalString(value *yaml.Node) (*yaml.Node, error) {
+	if value.Kind != yaml.ScalarNode || strings.Trim(value.Value, "01234567") != "" {
+		return nil, fmt.Errorf("%q is not an octal mode", value.Value)
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Value, Style: yaml.DoubleQuotedStyle}, nil
+}
+
+// stringList converts a comma separated scalar to a list.
+func stringList(value *yaml.Node) (*yaml.Node, error) {
+	if value.Kind != yaml.ScalarNode {
+		return nil, errors.New("not a single value")
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, item := range strings.Split(value.Value, ",") {
+		list.Content = append(list.Content, scalar(strings.TrimSpace(item)))
+	}
+	return list, nil
+}
+
+func TestMigrateFixtures(t *testing.T) {
+	tests := []struct {
+		version  string
+		warnings int
+	}{
+		{version: "v1", warnings: 3},
+		{version: "v2", warnings: 2},
+		{version: "v3", warnings: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "migrate", tt.version+".yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			migrated, err := Migrate(data, testMigrations)
+			if err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+			if migrated.Changed != (tt.warnings > 0) || len(migrated.Warnings) != tt.warnings {
+				t.Fatalf("Migrate() changed = %v with %d warnings, want %d warnings", migrated.Changed, len(migrated.Warnings), tt.warnings)
+			}
+			if !migrated.Changed {
+				// A current config is left exactly as it is
+				if !bytes.Equal(migrated.Data, data) {
+					t.Errorf("Migrate() rewrote a current config:\n%s", migrated.Data)
+				}
+				return
+			}
+
+			var got bytes.Buffer
+			got.Write(migrated.Data)
+			for _, warning := range migrated.Warnings {
+				fmt.Fprintf(&got, "--- warning\n%s\n", warning)
+			}
+			golden := filepath.Join("testdata", "migrate", tt.version+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run go test -run TestMigrateFixtures -update to create it)", err)
+				}
+				if !bytes.Equal(got.Bytes(), want) {
+					t.Errorf("migrated %s differs from %s (run with -update if the change is intended)\n got: %s\nwant: %s",
+						tt.version, golden, got.Bytes(), want)
+				}
+			}
+
+			// Migrating the result again changes nothing
+			again, err := Migrate(migrated.Data, testMigrations)
+			if err != nil {
+				t.Fatalf("Migrate() of the migrated config error = %v", err)
+			}
+			if again.Changed || len(again.Warnings) != 0 || !bytes.Equal(again.Data, migrated.Data) {
+				t.Errorf("Migrate() of the migrated config changed it again:\n%s", again.Data)
+			}
+		})
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "retired key",
+			data: "strategy_runner:\n  legacy_nfqws: true\n",
+			want: []string{`"strategy_runner.legacy_nfqws" at line 2 was removed: nfqws is always run by the daemon`},
+		},
+		{
+			name: "set with its replacement",
+			data: "strategy_runner:\n  firewall:\n    table: a\n    table_name: b\n",
+			want: []string{`"strategy_runner.firewall.table" at line 3 is set along with its replacement "strategy_runner.firewall.table_name"`},
+		},
+		{
+			name: "converter failure",
+			data: "server:\n  socket_permissions: rw-rw----\n",
+			want: []string{`"server.socket_permissions" at line 2 can't be migrated to "server.socket_mode": "rw-rw----" is not an octal mode`},
+		},
+		{
+			name: "all reported together",
+			data: "server:\n  socket_permissions: 0999\nstrategy_runner:\n  legacy_nfqws: true\n  interface: [eth0]\n",
+			want: []string{
+				`"server.socket_permissions" at line 2`,
+				`"strategy_runner.legacy_nfqws" at line 4 was removed`,
+				`"strategy_runner.interface" at line 5 can't be migrated`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, err := Migrate([]byte(tt.data), testMigrations)
+			if err == nil {
+				t.Fatalf("Migrate() = %q, want an error", migrated.Data)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Migrate() error = %v\nwant it to contain %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateLeavesInvalidYAML(t *testing.T) {
+	// Syntax errors and documents that aren't mappings are the decoder's
+	for _, data := range []string{"server: [unclosed\n", "- a\n- b\n", ""} {
+		migrated, err := Migrate([]byte(data), testMigrations)
+		if err != nil || migrated.Changed || string(migrated.Data) != data {
+			t.Errorf("Migrate(%q) = %q, %v, want it unchanged", data, migrated.Data, err)
+		}
+	}
+}
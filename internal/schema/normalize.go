@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/textnorm"
+	"gopkg.in/yaml.v3"
+)
+
+// NormalizeDurations rewrites the duration values of v in the YAML document
+// data that only parse once look-alike characters are folded, e.g. "5 сек"
+// or "30 s" with a no-break space. It returns the document, the input itself
+// if nothing changed, and a warning naming each rewritten value.
+func NormalizeDurations(data []byte, v any) ([]byte, []string, error) {
+	// Syntax errors are left to the decoder reading the config
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+
+	var warnings []string
+	normalize(doc.Content[0], reflect.TypeOf(v), "", &warnings)
+	if len(warnings) == 0 {
+		return data, nil, nil
+	}
+
+	out, err := encode(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, warnings, nil
+}
+
+// normalize walks node recursively against type t, rewriting durations.
+func normalize(node *yaml.Node, t reflect.Type, path string, warnings *[]string) {
+	t = indirect(t)
+
+	switch {
+	case t == durationType:
+		if node.Kind != yaml.ScalarNode {
+			return
+		}
+		if _, err := time.ParseDuration(node.Value); err == nil {
+			return
+		}
+		folded, changed := textnorm.Duration(node.Value)
+		if _, err := time.ParseDuration(folded); !changed || err != nil {
+			return
+		}
+		*warnings = append(*warnings, fmt.Sprintf("%q at line %d: read %q as %q", path, node.Line, node.Value, folded))
+		node.Value, node.Tag, node.Style = folded, "!!str", 0
+
+	case t.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		known := make(map[string]reflect.Type)
+		for _, f := range fields(t) {
+			known[f.name] = f.typ
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if ft, ok := known[node.Content[i].Value]; ok {
+				normalize(node.Content[i+1], ft, joinPath(path, node.Content[i].Value), warnings)
+			}
+		}
+
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			normalize(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), warnings)
+		}
+
+	case t.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			normalize(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), warnings)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package schema validates YAML configuration keys against Go config structs
+// and generates JSON schemas for editor completion.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	fileModeType = reflect.TypeOf(os.FileMode(0))
+)
+
+// field describes a YAML key of a struct.
+type field struct {
+	name string
+	typ  reflect.Type
+	tag  reflect.StructTag
+}
+
+// fields returns the YAML keys of a struct type, flattening inline structs.
+func fields(t reflect.Type) []field {
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if opts == "inline" {
+			result = append(result, fields(indirect(f.Type))...)
+			continue
+		}
+		if name == "" {
+			// Fields without a yaml tag are runtime-only
+			continue
+		}
+
+		result = append(result, field{name: name, typ: f.Type, tag: f.Tag})
+	}
+	return result
+}
+
+// indirect dereferences pointer types.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// CheckKnownFields reports YAML keys in data that don't correspond to fields of v.
+// Every unknown key is listed with its location and the closest valid key.
+func CheckKnownFields(data []byte, v any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	var errs []error
+	check(doc.Content[0], reflect.TypeOf(v), "", &errs)
+	return errors.Join(errs...)
+}
+
+// check walks node recursively against type t.
+func check(node *yaml.Node, t reflect.Type, path string, errs *[]error) {
+	t = indirect(t)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode || t == durationType {
+			return
+		}
+
+		known := make(map[string]reflect.Type)
+		var names []string
+		for _, f := range fields(t) {
+			known[f.name] = f.typ
+			names = append(names, f.name)
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+
+			ft, ok := known[key.Value]
+			if !ok {
+				err := fmt.Sprintf("unknown key %q at line %d, column %d", keyPath, key.Line, key.Column)
+				if suggestion := closest(key.Value, names); suggestion != "" {
+					err += fmt.Sprintf(" (did you mean %q?)", joinPath(path, suggestion))
+				}
+				*errs = append(*errs, errors.New(err))
+				continue
+			}
+
+			check(value, ft, keyPath, errs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			check(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			check(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), errs)
+		}
+	}
+}
+
+// joinPath joins a parent key path and a child key.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closest returns the candidate with the smallest edit distance to s,
+// or "" if none is reasonably close.
+func closest(s string, candidates []string) string {
+	best := ""
+	bestDist := len(s)/2 + 2
+	for _, c := range candidates {
+		if d := levenshtein(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// Generate builds a JSON schema (draft 2020-12) describing the YAML form of v.
+func Generate(v any, title string) map[string]any {
+	s := typeSchema(reflect.TypeOf(v), "")
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["title"] = title
+	return s
+}
+
+// typeSchema builds the schema for a single type.
+func typeSchema(t reflect.Type, tag reflect.StructTag) map[string]any {
+	t = indirect(t)
+	s := map[string]any{}
+
+	switch {
+	case t == durationType:
+		s["type"] = "string"
+		s["pattern"] = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	case t == fileModeType:
+		s["type"] = "integer"
+	default:
+		switch t.Kind() {
+		case reflect.Struct:
+			props := map[string]any{}
+			for _, f := range fields(t) {
+				props[f.name] = typeSchema(f.typ, f.tag)
+			}
+			s["type"] = "object"
+			s["properties"] = props
+			s["additionalProperties"] = false
+		case reflect.Slice, reflect.Array:
+			s["type"] = "array"
+			s["items"] = typeSchema(t.Elem(), "")
+		case reflect.Map:
+			s["type"] = "object"
+			s["additionalProperties"] = typeSchema(t.Elem(), "")
+		case reflect.Bool:
+			s["type"] = "boolean"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s["type"] = "integer"
+		case reflect.Float32, reflect.Float64:
+			s["type"] = "number"
+		default:
+			s["type"] = "string"
+		}
+	}
+
+	if def, ok := tag.Lookup("env-default"); ok {
+		s["default"] = defaultValue(s["type"], def)
+	}
+	if env, ok := tag.Lookup("env"); ok {
+		s["description"] = fmt.Sprintf("Environment variable: %s", env)
+	}
+
+	return s
+}
+
+// defaultValue converts an env-default tag value to the JSON type of the schema.
+func defaultValue(typ any, def string) any {
+	switch typ {
+	case "boolean":
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case "integer":
+		if n, err := strconv.ParseInt(def, 0, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
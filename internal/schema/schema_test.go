@@ -0,0 +1,220 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type testInline struct {
+	Shared string `yaml:"shared"`
+}
+
+type testRule struct {
+	Name  string `yaml:"name"`
+	Ports string `yaml:"ports"`
+}
+
+type testConfig struct {
+	Common     testInline          `yaml:",inline"`
+	GameFilter bool                `yaml:"gamefilter" env:"ZAPRET_GAMEFILTER" env-default:"true"`
+	Timeout    time.Duration       `yaml:"timeout" env-default:"5s"`
+	Workers    int                 `yaml:"workers" env-default:"4"`
+	Rules      []testRule          `yaml:"rules"`
+	Labels     map[string]testRule `yaml:"labels"`
+	Runtime    string
+}
+
+func TestCheckKnownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "known keys",
+			data: "shared: x\ngamefilter: false\ntimeout: 5s\nrules:\n  - name: a\n    ports: \"443\"\nlabels:\n  web:\n    name: b\n",
+		},
+		{
+			name: "typo with suggestion",
+			data: "gamefiler: false\n",
+			want: []string{`unknown key "gamefiler" at line 1, column 1 (did you mean "gamefilter"?)`},
+		},
+		{
+			name: "nothing close",
+			data: "completely_unrelated: 1\n",
+			want: []string{`unknown key "completely_unrelated" at line 1, column 1`},
+		},
+		{
+			name: "inside slice",
+			data: "rules:\n  - name: a\n    prots: \"443\"\n",
+			want: []string{`unknown key "rules[0].prots" at line 3, column 5 (did you mean "rules[0].ports"?)`},
+		},
+		{
+			name: "inside map",
+			data: "labels:\n  web:\n    nmae: b\n",
+			want: []string{`unknown key "labels.web.nmae" at line 3, column 5 (did you mean "labels.web.name"?)`},
+		},
+		{
+			name: "untagged fields are not keys",
+			data: "Runtime: x\n",
+			want: []string{`unknown key "Runtime"`},
+		},
+		{
+			name: "every unknown key is reported",
+			data: "a_key: 1\nworkerz: 2\n",
+			want: []string{`"a_key"`, `"workerz" at line 2`},
+		},
+		{
+			name: "empty document",
+			data: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckKnownFields([]byte(tt.data), testConfig{})
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("CheckKnownFields() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("CheckKnownFields() succeeded, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"gamefilter", "interface", "ports"}
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"gamefiler", "gamefilter"},
+		{"interfcae", "interface"},
+		{"port", "ports"},
+		{"xyz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := closest(tt.s, candidates); got != tt.want {
+			t.Errorf("closest(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"ports", "prots", 2},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(testConfig{}, "Test config")
+
+	if s["$schema"] != "https://json-schema.org/draft/2020-12/schema" || s["title"] != "Test config" {
+		t.Errorf("header = %v, %v", s["$schema"], s["title"])
+	}
+	if s["additionalProperties"] != false {
+		t.Errorf("additionalProperties = %v, want false", s["additionalProperties"])
+	}
+
+	props := s["properties"].(map[string]any)
+	if _, ok := props["shared"]; !ok {
+		t.Error("inline field missing from properties")
+	}
+	if _, ok := props["Runtime"]; ok {
+		t.Error("untagged field in properties")
+	}
+
+	gamefilter := props["gamefilter"].(map[string]any)
+	if gamefilter["type"] != "boolean" || gamefilter["default"] != true {
+		t.Errorf("gamefilter = %v, want a boolean defaulting to true", gamefilter)
+	}
+	if gamefilter["description"] != "Environment variable: ZAPRET_GAMEFILTER" {
+		t.Errorf("gamefilter description = %v", gamefilter["description"])
+	}
+
+	timeout := props["timeout"].(map[string]any)
+	if timeout["type"] != "string" || timeout["default"] != "5s" || timeout["pattern"] == nil {
+		t.Errorf("timeout = %v, want a duration string defaulting to 5s", timeout)
+	}
+
+	if workers := props["workers"].(map[string]any); workers["default"] != int64(4) {
+		t.Errorf("workers default = %#v, want 4", workers["default"])
+	}
+
+	rules := props["rules"].(map[string]any)
+	items := rules["items"].(map[string]any)
+	if rules["type"] != "array" || items["type"] != "object" {
+		t.Errorf("rules = %v, want an array of objects", rules)
+	}
+
+	labels := props["labels"].(map[string]any)
+	if labels["type"] != "object" || labels["additionalProperties"].(map[string]any)["type"] != "object" {
+		t.Errorf("labels = %v, want a map of objects", labels)
+	}
+}
+
+func TestNormalizeDurations(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     time.Duration
+		warnings int
+	}{
+		{name: "ascii", data: "timeout: 30s\n", want: 30 * time.Second},
+		{name: "russian unit", data: "timeout: 30 сек\n", want: 30 * time.Second, warnings: 1},
+		{name: "no-break space", data: "timeout: \"30\u00a0s\"\n", want: 30 * time.Second, warnings: 1},
+		{name: "decimal comma", data: "timeout: \"１,５ ч\"\n", want: 90 * time.Minute, warnings: 1},
+		// Values that don't parse either way are left for the decoder to report
+		{name: "unknown unit", data: "timeout: 5 дней\n", warnings: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, warnings, err := NormalizeDurations([]byte(tt.data), testConfig{})
+			if err != nil {
+				t.Fatalf("NormalizeDurations() error = %v", err)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("NormalizeDurations() warnings = %q, want %d", warnings, tt.warnings)
+			}
+			if tt.warnings == 0 {
+				if string(out) != tt.data {
+					t.Errorf("NormalizeDurations() = %q, want the input unchanged", out)
+				}
+				return
+			}
+			var cfg testConfig
+			if err := yaml.Unmarshal(out, &cfg); err != nil {
+				t.Fatalf("normalized document %q doesn't decode: %v", out, err)
+			}
+			if cfg.Timeout != tt.want {
+				t.Errorf("timeout = %v, want %v", cfg.Timeout, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package statepaths places runtime state files in a volatile and a persistent
+// directory and writes them atomically. When the persistent directory is not
+// writable (e.g. a read-only root filesystem on OpenWrt) persistent state falls
+// back to the volatile directory instead of failing.
+package statepaths
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Kind tells how long a state file needs to live.
+type Kind int
+
+const (
+	// Volatile state only needs to survive daemon restarts, not reboots
+	Volatile Kind = iota
+
+	// Persistent state should survive reboots
+	Persistent
+)
+
+// String returns the kind name.
+func (k Kind) String() string {
+	if k == Persistent {
+		return "persistent"
+	}
+	return "volatile"
+}
+
+// Layout is the pair of state directories and whether each can be written.
+type Layout struct {
+	VolatileDir        string
+	PersistentDir      string
+	VolatileWritable   bool
+	PersistentWritable bool
+}
+
+// Probe checks which of the state directories can be written, creating them if needed.
+func Probe(volatileDir, persistentDir string) *Layout {
+	return &Layout{
+		VolatileDir:        volatileDir,
+		PersistentDir:      persistentDir,
+		VolatileWritable:   Writable(volatileDir),
+		PersistentWritable: Writable(persistentDir),
+	}
+}
+
+// Resolve returns the path of a state file and the kind of storage it ends up
+// in. Absolute names are used as is. Relative names are placed in the
+// directory of kind; persistent files fall back to the volatile directory when
+// the persistent one is not writable. It returns "" when name is empty or no
+// writable directory is left, which disables the file.
+func (l *Layout) Resolve(kind Kind, name string) (string, Kind) {
+	if name == "" || filepath.IsAbs(name) {
+		return name, kind
+	}
+	if kind == Persistent && l.PersistentWritable {
+		return filepath.Join(l.PersistentDir, name), Persistent
+	}
+	if l.VolatileWritable {
+		return filepath.Join(l.VolatileDir, name), Volatile
+	}
+	return "", kind
+}
+
+// Writable reports whether files can be created in dir, creating it if needed.
+func Writable(dir string) bool {
+	if dir == "" {
+		return false
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false
+	}
+	f, err := os.CreateTemp(dir, ".probe*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// WriteAtomic replaces path with data, so readers never see a partial file.
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
+
+// WriteJSON atomically replaces path with v encoded as indented JSON.
+func WriteJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return WriteAtomic(path, data, 0644)
+}
@@ -0,0 +1,169 @@
+package statepaths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readOnlyDir returns a directory path that cannot be created or written,
+// like state directories on a read-only root filesystem. Permission bits
+// don't stop root, so it then places the path below a regular file instead.
+func readOnlyDir(t *testing.T) string {
+	t.Helper()
+	parent := t.TempDir()
+	if err := os.Chmod(parent, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(parent, 0755) })
+
+	dir := filepath.Join(parent, "state")
+	if f, err := os.CreateTemp(parent, "probe"); err == nil {
+		// Running as root
+		f.Close()
+		os.Remove(f.Name())
+
+		file := filepath.Join(t.TempDir(), "rootfs")
+		if err := os.WriteFile(file, nil, 0444); err != nil {
+			t.Fatal(err)
+		}
+		dir = filepath.Join(file, "state")
+	}
+	return dir
+}
+
+func TestWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run", "zapret")
+	if !Writable(dir) {
+		t.Errorf("Writable(%s) = false, want true", dir)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Writable() left %d files behind", len(entries))
+	}
+
+	if ro := readOnlyDir(t); Writable(ro) {
+		t.Errorf("Writable(%s) = true for a read-only directory", ro)
+	}
+	if Writable("") {
+		t.Error(`Writable("") = true, want false`)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	volatile := filepath.Join(t.TempDir(), "run")
+	persistent := filepath.Join(t.TempDir(), "state")
+	readOnly := readOnlyDir(t)
+
+	tests := []struct {
+		name     string
+		layout   *Layout
+		kind     Kind
+		file     string
+		wantPath string
+		wantKind Kind
+	}{
+		{
+			name:     "persistent",
+			layout:   Probe(volatile, persistent),
+			kind:     Persistent,
+			file:     "queues.json",
+			wantPath: filepath.Join(persistent, "queues.json"),
+			wantKind: Persistent,
+		},
+		{
+			name:     "volatile",
+			layout:   Probe(volatile, persistent),
+			kind:     Volatile,
+			file:     "firewall.json",
+			wantPath: filepath.Join(volatile, "firewall.json"),
+			wantKind: Volatile,
+		},
+		{
+			name:     "read-only persistent falls back to volatile",
+			layout:   Probe(volatile, readOnly),
+			kind:     Persistent,
+			file:     "queues.json",
+			wantPath: filepath.Join(volatile, "queues.json"),
+			wantKind: Volatile,
+		},
+		{
+			name:     "nothing writable disables the file",
+			layout:   Probe(readOnly, readOnly),
+			kind:     Persistent,
+			file:     "queues.json",
+			wantPath: "",
+			wantKind: Persistent,
+		},
+		{
+			name:     "absolute paths are kept",
+			layout:   Probe(readOnly, readOnly),
+			kind:     Persistent,
+			file:     "/var/lib/zapret/queues.json",
+			wantPath: "/var/lib/zapret/queues.json",
+			wantKind: Persistent,
+		},
+		{
+			name:     "empty name stays disabled",
+			layout:   Probe(volatile, persistent),
+			kind:     Volatile,
+			wantPath: "",
+			wantKind: Volatile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, kind := tt.layout.Resolve(tt.kind, tt.file)
+			if path != tt.wantPath || kind != tt.wantKind {
+				t.Errorf("Resolve(%v, %q) = %q, %v, want %q, %v", tt.kind, tt.file, path, kind, tt.wantPath, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "state.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := WriteAtomic(path, []byte(data), 0600); err != nil {
+			t.Fatalf("WriteAtomic() error = %v", err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != data {
+			t.Errorf("file = %q, want %q", got, data)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want no temp files left", len(entries))
+	}
+
+	if err := WriteAtomic(filepath.Join(readOnlyDir(t), "state.json"), []byte("x"), 0644); err == nil {
+		t.Error("WriteAtomic() into a read-only directory succeeded, want error")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := WriteJSON(path, map[string]int{"queue": 200}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if got, want := readFile(t, path), "{\n  \"queue\": 200\n}"; got != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+
+	if err := WriteJSON(path, func() {}); err == nil {
+		t.Error("WriteJSON() of a func succeeded, want error")
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
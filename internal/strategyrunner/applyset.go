@@ -0,0 +1,200 @@
+package strategyrunner
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApplyMarker is the file in the config directory whose creation applies
+// pending changes at once, skipping the quiet period. It is removed when
+// handled.
+const ApplyMarker = ".zapret-apply"
+
+// applySetDigest returns the combined digest of the files feeding a reload:
+// the strategy config and the strategy file. A missing or unreadable file
+// contributes an empty digest, so its appearance changes the result too.
+func applySetDigest(configPath, strategyPath string) string {
+	var parts []string
+	for _, member := range []struct{ name, path string }{
+		{WatchTargetConfig, configPath},
+		{WatchTargetStrategy, strategyPath},
+	} {
+		digest := ""
+		if member.path != "" {
+			if data, err := os.ReadFile(member.path); err == nil {
+				digest = digestBytes(data)
+			}
+		}
+		parts = append(parts, member.name+"="+digest)
+	}
+	return digestBytes([]byte(strings.Join(parts, "\n")))
+}
+
+// applySet defers reloads until the files of the apply set stopped changing,
+// so an edit touching several of them (config and strategy file, e.g. by a
+// deploy tool) is applied once, as a whole.
+type applySet struct {
+	mu      sync.Mutex
+	trigger func(reason string)
+	logger  *slog.Logger
+	clock   clock
+
+	// configPath and strategyPath are the members of the set
+	configPath   string
+	strategyPath string
+
+	// quiet is how long the set must stay unchanged before it is applied
+	quiet time.Duration
+
+	timer   clockTimer
+	last    string
+	reasons []string
+}
+
+// newApplySet creates an apply set calling trigger once per stable change.
+func newApplySet(trigger func(reason string), logger *slog.Logger) *applySet {
+	return &applySet{trigger: trigger, logger: logger, clock: systemClock{}}
+}
+
+// configure sets the members of the set and the quiet period of following changes.
+func (s *applySet) configure(configPath, strategyPath string, quiet time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configPath, s.strategyPath, s.quiet = configPath, strategyPath, quiet
+}
+
+// digest returns the digest of the set as it is on disk now. Caller must hold s.mu.
+func (s *applySet) digest() string {
+	return applySetDigest(s.configPath, s.strategyPath)
+}
+
+// changed records a change of a member and (re)starts the quiet period.
+// Without a quiet period the change is applied at once.
+func (s *applySet) changed(reason string) {
+	s.mu.Lock()
+	if s.quiet <= 0 {
+		s.mu.Unlock()
+		s.trigger(reason)
+		return
+	}
+	defer s.mu.Unlock()
+
+	s.reasons = append(s.reasons, reason)
+	s.last = s.digest()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = s.clock.AfterFunc(s.quiet, s.check)
+}
+
+// check applies the pending changes if the set is unchanged since the last
+// change, or waits another quiet period.
+func (s *applySet) check() {
+	s.mu.Lock()
+	if s.timer == nil {
+		// Flushed or cancelled meanwhile
+		s.mu.Unlock()
+		return
+	}
+	if digest := s.digest(); digest != s.last {
+		s.logger.Debug("watched files still changing, waiting for them to settle", slog.Duration("quiet_period", s.quiet))
+		s.last = digest
+		s.timer.Reset(s.quiet)
+		s.mu.Unlock()
+		return
+	}
+	reasons := s.take()
+	s.mu.Unlock()
+
+	for _, reason := range reasons {
+		s.trigger(reason)
+	}
+}
+
+// flush applies the pending changes at once, with reason added.
+func (s *applySet) flush(reason string) {
+	s.mu.Lock()
+	reasons := append(s.take(), reason)
+	s.mu.Unlock()
+
+	for _, reason := range reasons {
+		s.trigger(reason)
+	}
+}
+
+// cancel drops the pending changes.
+func (s *applySet) cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.take()
+}
+
+// pending reports whether changes wait for the quiet period to pass.
+func (s *applySet) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timer != nil
+}
+
+// take stops the timer and returns the pending reasons. Caller must hold s.mu.
+func (s *applySet) take() []string {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	reasons := s.reasons
+	s.reasons = nil
+	return reasons
+}
+
+// applyTarget returns the watch target of the apply marker: the directory of
+// the config file, or of the strategy file without one.
+func (r *Runner) applyTarget() WatchTarget {
+	dir := filepath.Dir(r.config.StrategyFile)
+	if r.config.ConfigPath != "" {
+		dir = filepath.Dir(r.config.ConfigPath)
+	}
+	return WatchTarget{
+		Name:        WatchTargetApply,
+		Path:        dir,
+		Dir:         true,
+		WatchPolicy: WatchPolicy{Policy: PolicyReload},
+	}
+}
+
+// clearApplyMarker removes an apply marker left from before the start. The
+// start applied the files as they are, and a marker that already exists
+// would hide the next one from the watcher. Caller must hold r.mu.
+func (r *Runner) clearApplyMarker() {
+	marker := filepath.Join(r.applyTarget().Path, ApplyMarker)
+	err := os.Remove(marker)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		r.logger.Warn("failed to remove stale apply marker", slog.String("path", marker), slog.Any("error", err))
+		return
+	}
+	r.logger.Info("removed apply marker left from before the start", slog.String("path", marker))
+}
+
+// onApplyMarker applies pending changes at once when the apply marker was
+// created, and removes it.
+func (r *Runner) onApplyMarker(target WatchTarget) {
+	marker := filepath.Join(target.Path, ApplyMarker)
+	if _, err := os.Stat(marker); err != nil {
+		// Our own removal, or a marker gone before it was handled
+		return
+	}
+	if err := os.Remove(marker); err != nil && !errors.Is(err, os.ErrNotExist) {
+		r.logger.Warn("failed to remove apply marker", slog.String("path", marker), slog.Any("error", err))
+	}
+
+	r.logger.Info("apply marker found, applying changes now", slog.String("path", marker))
+	r.applySet.flush("watcher:" + WatchTargetApply)
+}
@@ -0,0 +1,151 @@
+package strategyrunner
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestApplySet returns an apply set over two files in a temporary
+// directory with a quiet period of quiet, on a fake clock.
+func newTestApplySet(t *testing.T, quiet time.Duration) (*applySet, *fakeClock, string, func() []string) {
+	t.Helper()
+	dir := t.TempDir()
+	configPath, strategyPath := filepath.Join(dir, "strategy.yaml"), filepath.Join(dir, "strategy.bat")
+	for _, path := range []string{configPath, strategyPath} {
+		if err := os.WriteFile(path, []byte("v1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var triggered []string
+	s := newApplySet(func(reason string) {
+		mu.Lock()
+		defer mu.Unlock()
+		triggered = append(triggered, reason)
+	}, slog.New(slog.DiscardHandler))
+	clk := newFakeClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	s.clock = clk
+	s.configure(configPath, strategyPath, quiet)
+
+	return s, clk, strategyPath, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(triggered)
+	}
+}
+
+func TestApplySetQuietPeriod(t *testing.T) {
+	s, clk, strategyPath, triggered := newTestApplySet(t, 2*time.Second)
+
+	s.changed("watcher:config")
+	if !s.pending() {
+		t.Error("pending() = false during the quiet period")
+	}
+	clk.Advance(time.Second)
+	s.changed("watcher:strategy")
+
+	// A change without an event restarts the quiet period at the check
+	clk.Advance(time.Second)
+	if err := os.WriteFile(strategyPath, []byte("v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(time.Second)
+	if got := triggered(); len(got) != 0 {
+		t.Fatalf("applied %v while the files were still changing", got)
+	}
+	clk.Advance(time.Second)
+	if got := triggered(); len(got) != 0 {
+		t.Fatalf("applied %v before the files were stable for the quiet period", got)
+	}
+
+	// Stable for a whole quiet period, the changes are applied once
+	clk.Advance(time.Second)
+	if got, want := triggered(), []string{"watcher:config", "watcher:strategy"}; !slices.Equal(got, want) {
+		t.Errorf("triggered %v, want %v", got, want)
+	}
+	if s.pending() {
+		t.Error("pending() = true after the changes were applied")
+	}
+	clk.Advance(time.Minute)
+	if got := triggered(); len(got) != 2 {
+		t.Errorf("triggered %v, want nothing more", got)
+	}
+}
+
+func TestApplySetFlushCancel(t *testing.T) {
+	s, clk, _, triggered := newTestApplySet(t, 2*time.Second)
+
+	// The apply marker flushes pending changes at once
+	s.changed("watcher:config")
+	s.flush("watcher:apply")
+	if got, want := triggered(), []string{"watcher:config", "watcher:apply"}; !slices.Equal(got, want) {
+		t.Errorf("triggered %v, want %v", got, want)
+	}
+	clk.Advance(time.Minute)
+	if got := triggered(); len(got) != 2 {
+		t.Errorf("triggered %v after the flush, want nothing more", got)
+	}
+
+	// Stop drops them
+	s.changed("watcher:strategy")
+	s.cancel()
+	clk.Advance(time.Minute)
+	if got := triggered(); len(got) != 2 {
+		t.Errorf("triggered %v after cancel, want nothing more", got)
+	}
+	if s.pending() {
+		t.Error("pending() = true after cancel")
+	}
+}
+
+func TestApplySetNoQuietPeriod(t *testing.T) {
+	s, _, _, triggered := newTestApplySet(t, 0)
+	s.changed("watcher:config")
+	if got := triggered(); !slices.Equal(got, []string{"watcher:config"}) {
+		t.Errorf("triggered %v, want the change applied at once", got)
+	}
+}
+
+func TestRunnerApplyMarker(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	marker := filepath.Join(filepath.Dir(tr.config), ApplyMarker)
+	// Triggers after the start are applied at once
+	tr.Runner.config.ReloadCooldown = 0
+
+	// A marker left from before the start is removed by it
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if _, err := os.Stat(marker); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("stale apply marker left after Start: %v", err)
+	}
+	rules := tr.fw.queues()
+
+	// A marker applying a broken strategy is removed although the reload fails
+	tr.writeStrategy(t, "rem no rules\n")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr.onApplyMarker(tr.applyTarget())
+	if _, err := os.Stat(marker); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("apply marker left after a failed reload: %v", err)
+	}
+	events, _ := tr.events.Since(0)
+	if !slices.ContainsFunc(events, func(e Event) bool { return e.Kind == "reload_failed" }) {
+		t.Errorf("events = %+v, want the reload failed", events)
+	}
+	if got := tr.fw.queues(); !slices.Equal(got, rules) {
+		t.Errorf("firewall rules for queues %v after the failed reload, want %v", got, rules)
+	}
+	tr.checkConsistent(t)
+}
@@ -0,0 +1,136 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// joinNFQWSArgs joins args into an argument string, quoting arguments with
+// spaces so parseNFQWSArgs splits it the same way.
+func joinNFQWSArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.Contains(arg, " ") {
+			name, value, ok := strings.Cut(arg, "=")
+			if ok {
+				arg = name + `="` + value + `"`
+			} else {
+				arg = `"` + arg + `"`
+			}
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// optionValues returns all values of an nfqws option in args.
+// Both "--opt=value" and "--opt value" forms are recognized.
+func optionValues(args []string, name string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			values = append(values, value)
+			continue
+		}
+		if arg == name && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			values = append(values, args[i+1])
+			i++
+		}
+	}
+	return values
+}
+
+// replaceOptionValues returns args with every value of an nfqws option
+// replaced by fn. Both "--opt=value" and "--opt value" forms are recognized.
+func replaceOptionValues(args []string, name string, fn func(string) string) []string {
+	replaced := make([]string, len(args))
+	copy(replaced, args)
+	for i := 0; i < len(replaced); i++ {
+		arg := replaced[i]
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			replaced[i] = name + "=" + fn(value)
+			continue
+		}
+		if arg == name && i+1 < len(replaced) && !strings.HasPrefix(replaced[i+1], "--") {
+			replaced[i+1] = fn(replaced[i+1])
+			i++
+		}
+	}
+	return replaced
+}
+
+// controlledOptions are the nfqws options the process manager sets itself.
+// The value reports whether the option takes a value.
+var controlledOptions = map[string]bool{
+	"--qnum":    true,
+	"--daemon":  false,
+	"--pidfile": true,
+}
+
+// explicitQueue returns the queue number set by the --qnum options among
+// the controlled options removed from a rule. The rule is pinned to it, so
+// the queue nfqws binds is the one the firewall targets. Repeating --qnum
+// with another number is an error.
+func explicitQueue(removed []string) (queue int, ok bool, err error) {
+	for _, opt := range removed {
+		name, value, hasValue := strings.Cut(opt, "=")
+		if !hasValue {
+			name, value, hasValue = strings.Cut(opt, " ")
+		}
+		if name != "--qnum" {
+			continue
+		}
+		if !hasValue {
+			return 0, false, fmt.Errorf("--qnum needs a queue number")
+		}
+		q, err := strconv.Atoi(value)
+		if err != nil || q < 0 || q > 65535 {
+			return 0, false, fmt.Errorf("invalid --qnum %q: want a queue number 0-65535", value)
+		}
+		if ok && q != queue {
+			return 0, false, fmt.Errorf("conflicting --qnum=%d and --qnum=%d", queue, q)
+		}
+		queue, ok = q, true
+	}
+	return queue, ok, nil
+}
+
+// isQnumOption reports whether opt, as removed by stripControlledOptions,
+// is a --qnum option.
+func isQnumOption(opt string) bool {
+	return opt == "--qnum" || strings.HasPrefix(opt, "--qnum=") || strings.HasPrefix(opt, "--qnum ")
+}
+
+// desyncMarkOption is the nfqws option setting the mark of the packets it
+// sends, set by the process manager when desync_fwmark is configured.
+const desyncMarkOption = "--dpi-desync-fwmark"
+
+// stripControlledOptions removes the options set by the process manager from
+// args, so nfqws never sees them twice. Both "--opt=value" and "--opt value"
+// forms are recognized. It returns the remaining arguments and the removed ones.
+func stripControlledOptions(args []string) ([]string, []string) {
+	return stripOptions(args, controlledOptions)
+}
+
+// stripOptions removes options from args. The value of options reports
+// whether the option takes a value.
+func stripOptions(args []string, options map[string]bool) ([]string, []string) {
+	var kept, removed []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		takesValue, ok := options[name]
+		if !ok {
+			kept = append(kept, arg)
+			continue
+		}
+		if takesValue && !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			arg += " " + args[i+1]
+			i++
+		}
+		removed = append(removed, arg)
+	}
+	return kept, removed
+}
@@ -0,0 +1,247 @@
+package strategyrunner
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/hostlist"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// StrategySourceRollback is the previously applied strategy, restored after
+// a new one failed the canary check.
+const StrategySourceRollback = "canary-rollback"
+
+// canaryRetryInterval is the pause between probes of a domain that failed,
+// giving freshly started nfqws processes time to bind their queues.
+const canaryRetryInterval = 2 * time.Second
+
+// Canary errors.
+var (
+	// ErrCanaryFailed is returned by reloads whose new strategy failed the
+	// canary check and was rolled back
+	ErrCanaryFailed = errors.New("new strategy failed the canary check")
+
+	// ErrQuarantined is returned by automatic reloads of a strategy that
+	// failed the canary check before
+	ErrQuarantined = errors.New("strategy is quarantined after a failed canary check")
+)
+
+// CanaryConfig contains settings of the check verifying a new strategy
+// after an unattended reload.
+type CanaryConfig struct {
+	// Enabled verifies the strategy after reloads triggered by watched files;
+	// `zapret restart --canary` verifies manual restarts
+	Enabled bool `yaml:"enabled" env:"ZAPRET_CANARY_ENABLED"`
+
+	// Domains must complete a TLS handshake through the new strategy
+	Domains []string `yaml:"domains" env:"ZAPRET_CANARY_DOMAINS" env-default:"discord.com,www.youtube.com"`
+
+	// Deadline bounds the check; domains still failing then fail it
+	Deadline time.Duration `yaml:"deadline" env:"ZAPRET_CANARY_DEADLINE" env-default:"30s"`
+}
+
+// Validate checks the canary settings.
+func (c *CanaryConfig) Validate() error {
+	if c.Deadline <= 0 {
+		return fmt.Errorf("deadline must be positive")
+	}
+	if c.Enabled && len(c.Domains) == 0 {
+		return fmt.Errorf("domains must not be empty when enabled")
+	}
+	for _, domain := range c.Domains {
+		if hostlist.Normalize(domain) == "" {
+			return fmt.Errorf("invalid domain %q", domain)
+		}
+	}
+	return nil
+}
+
+// reloadOptions controls the canary check of a reload.
+type reloadOptions struct {
+	// canary verifies the new strategy and rolls back if it fails
+	canary bool
+
+	// skipQuarantined refuses strategies that failed the canary before
+	skipQuarantined bool
+
+	// force stops the runner and starts it again instead of changing only
+	// the rules that differ
+	force bool
+}
+
+// appliedStrategy is what a successful start applied, retained so a failed
+// canary can restore it without the strategy file.
+type appliedStrategy struct {
+	config   *Config
+	filter   *RuleFilter
+	strategy []byte
+	hash     string
+}
+
+// strategyHash identifies a strategy by its content.
+func strategyHash(strategy []byte) string {
+	sum := sha256.Sum256(strategy)
+	return hex.EncodeToString(sum[:])
+}
+
+// retainApplied keeps the strategy applied from path for a later rollback.
+// Caller must hold r.mu.
+func (r *Runner) retainApplied(path string) {
+	strategy, err := os.ReadFile(path)
+	if err != nil {
+		r.applied = nil
+		r.logger.Warn("failed to retain the applied strategy, a failed canary can't roll back to it",
+			slog.Any("error", err))
+		return
+	}
+	r.applied = &appliedStrategy{
+		config:   r.config,
+		filter:   r.filter,
+		strategy: strategy,
+		hash:     strategyHash(strategy),
+	}
+}
+
+// checkQuarantine refuses a config whose strategy failed the canary check
+// before, until the strategy file changes.
+func (r *Runner) checkQuarantine(cfg *Config) error {
+	path, _, err := resolveStrategy(cfg)
+	if err != nil {
+		return err
+	}
+	strategy, err := os.ReadFile(path)
+	if err != nil {
+		// The reload reports the missing file itself
+		return nil
+	}
+	hash := strategyHash(strategy)
+
+	r.mu.RLock()
+	since, quarantined := r.quarantine[hash]
+	r.mu.RUnlock()
+	if !quarantined {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (sha256 %s, since %s), change the strategy or apply it with `zapret restart`",
+		ErrQuarantined, path, hash[:12], since.Format(time.RFC3339))
+}
+
+// tlsProbe completes a TLS handshake with domain, whose packets go through
+// the queues like any other traffic.
+func tlsProbe(ctx context.Context, domain string) error {
+	return probeTLS(ctx, net.JoinHostPort(domain, "443"), &tls.Config{ServerName: domain})
+}
+
+// probeTLS completes a TLS handshake with the server at addr.
+func probeTLS(ctx context.Context, addr string, config *tls.Config) error {
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// runCanary probes the domains of cfg until each succeeded once or the
+// deadline passes, and returns the failures.
+func runCanary(ctx context.Context, cfg CanaryConfig, probe func(ctx context.Context, domain string) error) []string {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Deadline)
+	defer cancel()
+
+	var mu sync.Mutex
+	var failures []string
+	var wg sync.WaitGroup
+	for _, domain := range cfg.Domains {
+		domain = hostlist.Normalize(domain)
+		wg.Go(func() {
+			for {
+				err := probe(ctx, domain)
+				if err == nil {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					mu.Lock()
+					failures = append(failures, fmt.Sprintf("%s: %v", domain, err))
+					mu.Unlock()
+					return
+				case <-time.After(canaryRetryInterval):
+				}
+			}
+		})
+	}
+	wg.Wait()
+	return failures
+}
+
+// verifyCanary checks the strategy a reload just applied with the canary
+// settings of the previous, known good config. On failure previous is
+// applied again, and a changed strategy is quarantined: a failure with the
+// same strategy comes from the config or the network.
+func (r *Runner) verifyCanary(ctx context.Context, previous *appliedStrategy) error {
+	r.mu.RLock()
+	applied := r.applied
+	r.mu.RUnlock()
+
+	cfg := previous.config.Canary
+	r.logger.Info("verifying the new strategy",
+		slog.Any("domains", cfg.Domains),
+		slog.Duration("deadline", cfg.Deadline),
+	)
+	failures := runCanary(ctx, cfg, r.canaryProbe)
+	if len(failures) == 0 {
+		r.logger.Info("new strategy passed the canary check")
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("canary check aborted: %w", err)
+	}
+
+	detail := strings.Join(failures, "; ")
+	if applied != nil && applied.hash != previous.hash {
+		r.mu.Lock()
+		r.quarantine[applied.hash] = time.Now()
+		r.mu.Unlock()
+	}
+	r.logger.Error("new strategy failed the canary check, rolling back",
+		slog.Any("failures", failures),
+	)
+	r.events.Add("canary_failed", "rolled back: "+detail)
+
+	if err := r.rollback(ctx, previous); err != nil {
+		return fmt.Errorf("%w (%s), rollback failed: %w", ErrCanaryFailed, detail, err)
+	}
+	return fmt.Errorf("%w, rolled back to the previous strategy: %s", ErrCanaryFailed, detail)
+}
+
+// rollback applies previous again from the retained strategy, written to
+// the volatile state directory since the strategy file holds the failed one.
+func (r *Runner) rollback(ctx context.Context, previous *appliedStrategy) error {
+	dir := filepath.Join(previous.config.State.VolatileDir, "canary")
+	if !statepaths.Writable(dir) {
+		dir = filepath.Join(os.TempDir(), "zapret-canary")
+	}
+	path := filepath.Join(dir, "strategy.bat")
+	if err := statepaths.WriteAtomic(path, previous.strategy, 0644); err != nil {
+		return fmt.Errorf("failed to write the previous strategy: %w", err)
+	}
+
+	r.mu.Lock()
+	r.rollbackPath = path
+	r.mu.Unlock()
+
+	cfg := *previous.config
+	return r.apply(ctx, &cfg, previous.filter, false)
+}
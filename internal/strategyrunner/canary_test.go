@@ -0,0 +1,211 @@
+package strategyrunner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const canarySettings = `canary:
+  domains: [example.com]
+  deadline: 200ms
+`
+
+// ruleArgs returns the args of the rules by label and queue.
+func (tr *testRunner) ruleArgs() map[string]string {
+	args := make(map[string]string)
+	for _, rule := range tr.Rules() {
+		args[fmt.Sprintf("%s@%d", rule.Label, rule.QueueNum)] = rule.NFQWSArgs
+	}
+	return args
+}
+
+// processArgs returns the args of the running processes by queue.
+func (tr *testRunner) processArgs() map[int][]string {
+	args := make(map[int][]string)
+	for _, p := range tr.procManager.Processes() {
+		if p.Running {
+			args[p.QueueNum] = p.Args
+		}
+	}
+	return args
+}
+
+func TestRunnerCanaryRollback(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, canarySettings)
+	var failing atomic.Bool
+	tr.canaryProbe = func(ctx context.Context, domain string) error {
+		if failing.Load() {
+			return errors.New("handshake failed")
+		}
+		return nil
+	}
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	rules, queues, args := tr.ruleArgs(), tr.fw.queues(), tr.processArgs()
+
+	// The new strategy changes a rule and adds one, then fails its check
+	tr.writeStrategy(t, strings.Replace(testStrategy, "--dpi-desync-repeats=2", "--dpi-desync-repeats=3 --new ^\n--filter-tcp=80 --dpi-desync=fake", 1))
+	failing.Store(true)
+	err := tr.RestartVerified(t.Context(), nil, false)
+	if !errors.Is(err, ErrCanaryFailed) {
+		t.Fatalf("RestartVerified() error = %v, want %v", err, ErrCanaryFailed)
+	}
+
+	// The previous rules and processes are back
+	if got := tr.ruleArgs(); !reflect.DeepEqual(got, rules) {
+		t.Errorf("rules after the rollback = %v, want %v", got, rules)
+	}
+	if got := tr.fw.queues(); !slices.Equal(got, queues) {
+		t.Errorf("firewall rules for queues %v after the rollback, want %v", got, queues)
+	}
+	if got := tr.processArgs(); !reflect.DeepEqual(got, args) {
+		t.Errorf("process args after the rollback = %v, want %v", got, args)
+	}
+	tr.checkConsistent(t)
+
+	// and the status reports the rollback
+	if status := tr.GetStatus(); !status.Running || status.StrategySource != StrategySourceRollback {
+		t.Errorf("status running = %v, source = %q, want running %q", status.Running, status.StrategySource, StrategySourceRollback)
+	}
+	events, _ := tr.events.Since(0)
+	if !slices.ContainsFunc(events, func(e Event) bool { return e.Kind == "canary_failed" }) {
+		t.Errorf("events = %+v, want a canary_failed event", events)
+	}
+
+	// An automatic reload refuses the quarantined strategy
+	failing.Store(false)
+	if err := tr.restart(t.Context(), []string{"test"}, nil, reloadOptions{canary: true, skipQuarantined: true}); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("automatic reload error = %v, want %v", err, ErrQuarantined)
+	}
+}
+
+func TestRunnerCanaryPass(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, canarySettings)
+	var probes atomic.Int32
+	tr.canaryProbe = func(ctx context.Context, domain string) error {
+		probes.Add(1)
+		return nil
+	}
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	tr.writeStrategy(t, strings.Replace(testStrategy, "--dpi-desync-repeats=2", "--dpi-desync-repeats=3", 1))
+	if err := tr.RestartVerified(t.Context(), nil, false); err != nil {
+		t.Fatalf("RestartVerified() error = %v", err)
+	}
+	if probes.Load() == 0 {
+		t.Error("canary check never probed")
+	}
+	if status := tr.GetStatus(); status.StrategySource != StrategySourceFile {
+		t.Errorf("StrategySource = %q, want %q", status.StrategySource, StrategySourceFile)
+	}
+	for _, rule := range tr.Rules() {
+		if args := tr.processArgs()[rule.QueueNum]; rule.Protocol == "udp" && !slices.Contains(args, "--dpi-desync-repeats=3") {
+			t.Errorf("udp rule process args = %q, want the new strategy", args)
+		}
+	}
+}
+
+func TestProbeTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	trusted := x509.NewCertPool()
+	trusted.AddCert(server.Certificate())
+
+	// A server accepting connections without ever answering the handshake
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { silent.Close() })
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	closed := httptest.NewTLSServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		addr    string
+		config  *tls.Config
+		wantErr func(error) bool // nil for success
+	}{
+		{
+			name:   "success",
+			addr:   server.Listener.Addr().String(),
+			config: &tls.Config{ServerName: "example.com", RootCAs: trusted},
+		},
+		{
+			name:   "untrusted certificate",
+			addr:   server.Listener.Addr().String(),
+			config: &tls.Config{ServerName: "example.com"},
+			wantErr: func(err error) bool {
+				var certErr *tls.CertificateVerificationError
+				return errors.As(err, &certErr)
+			},
+		},
+		{
+			name:   "wrong name",
+			addr:   server.Listener.Addr().String(),
+			config: &tls.Config{ServerName: "discord.com", RootCAs: trusted},
+			wantErr: func(err error) bool {
+				var certErr *tls.CertificateVerificationError
+				return errors.As(err, &certErr)
+			},
+		},
+		{
+			name:    "refused",
+			addr:    closed.Listener.Addr().String(),
+			config:  &tls.Config{ServerName: "example.com", RootCAs: trusted},
+			wantErr: func(err error) bool { var opErr *net.OpError; return errors.As(err, &opErr) },
+		},
+		{
+			name:    "timeout",
+			addr:    silent.Addr().String(),
+			config:  &tls.Config{ServerName: "example.com", RootCAs: trusted},
+			wantErr: func(err error) bool { return errors.Is(err, context.DeadlineExceeded) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+			defer cancel()
+			err := probeTLS(ctx, tt.addr, tt.config)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("probeTLS() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !tt.wantErr(err) {
+				t.Errorf("probeTLS() error = %v", err)
+			}
+		})
+	}
+}
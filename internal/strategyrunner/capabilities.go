@@ -0,0 +1,282 @@
+package strategyrunner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// Kernel capabilities the firewall rules depend on.
+const (
+	CapNFQueue   = "nfqueue"
+	CapConntrack = "conntrack"
+	CapNotrack   = "notrack"
+	CapLimit     = "limit"
+)
+
+// States of a kernel capability.
+const (
+	CapAvailable = "available"
+	CapMissing   = "missing"
+	CapUnknown   = "unknown"
+)
+
+// capabilityProbeTable is the scratch table of nft probes. Probes run with
+// nft --check, so the kernel validates the ruleset without committing it and
+// nothing is ever left behind.
+const capabilityProbeTable = "inet zapret_probe"
+
+// errProbeUnavailable is returned by CheckNft when nft can't be run, which
+// leaves the capability unknown rather than missing.
+var errProbeUnavailable = errors.New("nft is not available")
+
+// capabilityModules are the modules providing each capability, per backend.
+var capabilityModules = map[string]map[string][]string{
+	"nftables": {
+		CapNFQueue:   {"nfnetlink_queue", "nft_queue"},
+		CapConntrack: {"nf_conntrack"},
+		CapNotrack:   {"nft_ct"},
+		CapLimit:     {"nft_limit"},
+	},
+	"iptables": {
+		CapNFQueue:   {"nfnetlink_queue", "xt_NFQUEUE"},
+		CapConntrack: {"nf_conntrack"},
+		CapNotrack:   {"iptable_raw", "xt_CT"},
+		CapLimit:     {"xt_limit"},
+	},
+}
+
+// capabilityProbeRules are nft rules exercising each capability.
+var capabilityProbeRules = map[string]string{
+	CapNFQueue:   "queue num 0 bypass",
+	CapConntrack: "ct state established accept",
+	CapNotrack:   "notrack",
+	CapLimit:     "limit rate 1/second accept",
+}
+
+// KernelCapability is the probed state of a kernel feature.
+type KernelCapability struct {
+	// Name is the capability ("nfqueue", "conntrack", "notrack", "limit")
+	Name string
+
+	// State is "available", "missing" or "unknown"
+	State string
+
+	// Module is the kernel module to load when the capability is missing
+	Module string
+
+	// Detail explains how the state was determined
+	Detail string
+}
+
+// capabilityProber exposes the system interfaces the capability probe reads.
+type capabilityProber interface {
+	// Exists reports whether path exists
+	Exists(path string) bool
+
+	// Modules returns the loaded kernel modules
+	Modules() (map[string]bool, error)
+
+	// CheckNft validates an nft ruleset against the kernel without applying
+	// it, in the network namespace ("" for the host)
+	CheckNft(namespace, ruleset string) error
+}
+
+// systemProber probes the running kernel.
+type systemProber struct{}
+
+// Exists implements capabilityProber.
+func (systemProber) Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Modules implements capabilityProber by reading /proc/modules.
+func (systemProber) Modules() (map[string]bool, error) {
+	f, err := os.Open("/proc/modules")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	modules := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name, _, ok := strings.Cut(scanner.Text(), " "); ok {
+			modules[name] = true
+		}
+	}
+	return modules, scanner.Err()
+}
+
+// CheckNft implements capabilityProber using nft --check.
+func (systemProber) CheckNft(namespace, ruleset string) error {
+	if _, err := exec.LookPath("nft"); err != nil {
+		return errProbeUnavailable
+	}
+	return netns.Do(namespace, func() error {
+		cmd := exec.Command("nft", "--check", "-f", "-")
+		cmd.Stdin = strings.NewReader(ruleset)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	})
+}
+
+// probeRuleset returns the scratch ruleset checking an nft rule. Notrack is
+// only valid in a chain running before conntrack.
+func probeRuleset(capability, rule string) string {
+	chain := "type filter hook output priority 0;"
+	if capability == CapNotrack {
+		chain = "type filter hook output priority raw;"
+	}
+	return fmt.Sprintf("add table %[1]s\nadd chain %[1]s probe { %[2]s }\nadd rule %[1]s probe %[3]s\n",
+		capabilityProbeTable, chain, rule)
+}
+
+// probeCapabilities determines the kernel capabilities for the firewall
+// backend. A loaded module or a kernel interface proves a capability; for
+// nftables the kernel is asked to validate a rule using it, which also
+// covers built-in and autoloaded modules.
+func probeCapabilities(p capabilityProber, backend, namespace string) []KernelCapability {
+	modules, modErr := p.Modules()
+	candidates := capabilityModules[backend]
+	if candidates == nil {
+		candidates = capabilityModules["nftables"]
+	}
+
+	var caps []KernelCapability
+	for _, name := range []string{CapNFQueue, CapConntrack, CapNotrack, CapLimit} {
+		c := KernelCapability{Name: name, Module: candidates[name][0]}
+
+		for _, module := range candidates[name] {
+			if modules[module] {
+				c.State, c.Detail = CapAvailable, "module "+module+" loaded"
+				break
+			}
+		}
+		if c.State == "" {
+			switch {
+			case name == CapNFQueue && p.Exists(nfqueuePath):
+				c.State, c.Detail = CapAvailable, nfqueuePath+" present"
+			case name == CapConntrack && (p.Exists("/proc/sys/net/netfilter/nf_conntrack_max") || p.Exists("/proc/net/nf_conntrack")):
+				c.State, c.Detail = CapAvailable, "conntrack sysctls present"
+			}
+		}
+		if c.State == "" && backend == "nftables" {
+			err := p.CheckNft(namespace, probeRuleset(name, capabilityProbeRules[name]))
+			switch {
+			case errors.Is(err, errProbeUnavailable):
+				// Left unknown below
+			case err != nil:
+				c.State, c.Detail = CapMissing, "kernel rejected a probe rule: "+err.Error()
+			default:
+				c.State, c.Detail = CapAvailable, "kernel accepted a probe rule"
+			}
+		}
+		if c.State == "" {
+			// Modules may be built in or autoloaded on first use
+			c.State, c.Detail = CapUnknown, "module not loaded, may be built in or autoloaded"
+			if modErr != nil {
+				c.Detail = "cannot read loaded modules: " + modErr.Error()
+			}
+		}
+		caps = append(caps, c)
+	}
+	return caps
+}
+
+// missingCapabilities returns the capabilities rule needs that are missing.
+func missingCapabilities(rule ParsedRule, caps []KernelCapability) []KernelCapability {
+	state := make(map[string]KernelCapability, len(caps))
+	for _, c := range caps {
+		state[c.Name] = c
+	}
+
+	var needs []string
+	if rule.Notrack {
+		needs = append(needs, CapConntrack, CapNotrack)
+	}
+	if rule.RateLimit > 0 {
+		needs = append(needs, CapLimit)
+	}
+
+	var missing []KernelCapability
+	for _, name := range needs {
+		if c, ok := state[name]; ok && c.State == CapMissing {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// applyCapabilities checks the firewall features of rules against the kernel
+// capabilities. In strict mode a rule needing a missing capability fails; in
+// lenient mode the feature is dropped and the rule runs degraded.
+func applyCapabilities(rules []ParsedRule, caps []KernelCapability, mode string, logger *slog.Logger) {
+	for i := range rules {
+		rule := &rules[i]
+		missing := missingCapabilities(*rule, caps)
+		if len(missing) == 0 || rule.CompatError != "" {
+			continue
+		}
+
+		var names, modules []string
+		for _, c := range missing {
+			names = append(names, c.Name)
+			modules = append(modules, c.Module)
+		}
+
+		if mode != CompatLenient {
+			rule.CompatError = fmt.Sprintf("kernel lacks %s (load with: modprobe %s)",
+				strings.Join(names, ", "), strings.Join(modules, " "))
+			logger.Error("rule needs kernel capabilities that are missing, rule failed",
+				slog.Int("line", rule.SourceLine),
+				slog.Any("missing", names),
+				slog.Any("modules", modules),
+			)
+			continue
+		}
+
+		var dropped []string
+		for _, c := range missing {
+			switch {
+			case (c.Name == CapConntrack || c.Name == CapNotrack) && rule.Notrack:
+				rule.Notrack = false
+				dropped = append(dropped, "notrack")
+			case c.Name == CapLimit && rule.RateLimit > 0:
+				rule.RateLimit = 0
+				dropped = append(dropped, "rate_limit")
+			}
+		}
+		rule.StrippedArgs = append(rule.StrippedArgs, dropped...)
+		logger.Warn("kernel capabilities missing, rule runs without the features needing them",
+			slog.Int("line", rule.SourceLine),
+			slog.Any("missing", names),
+			slog.Any("dropped", dropped),
+		)
+	}
+}
+
+// checkCapabilities probes the kernel for cfg and logs missing capabilities.
+// Firewall features are only checked when the runner installs the rules.
+func (r *Runner) checkCapabilities(cfg *Config) []KernelCapability {
+	caps := probeCapabilities(r.capProber, cfg.Firewall.Backend, cfg.NetworkNamespace)
+	for _, c := range caps {
+		if c.State == CapMissing {
+			r.logger.Warn("kernel capability missing",
+				slog.String("capability", c.Name),
+				slog.String("module", c.Module),
+				slog.String("detail", c.Detail),
+			)
+		}
+	}
+	return caps
+}
@@ -0,0 +1,232 @@
+package strategyrunner
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubProber is a kernel with the paths, loaded modules and nft result given.
+type stubProber struct {
+	paths   map[string]bool
+	modules map[string]bool
+	modErr  error
+	nftErr  error
+
+	// checked are the rulesets passed to CheckNft
+	checked []string
+}
+
+func (p *stubProber) Exists(path string) bool { return p.paths[path] }
+
+func (p *stubProber) Modules() (map[string]bool, error) { return p.modules, p.modErr }
+
+func (p *stubProber) CheckNft(namespace, ruleset string) error {
+	p.checked = append(p.checked, ruleset)
+	return p.nftErr
+}
+
+// capStates returns the state of each capability by name.
+func capStates(caps []KernelCapability) map[string]string {
+	states := make(map[string]string, len(caps))
+	for _, c := range caps {
+		states[c.Name] = c.State
+	}
+	return states
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	all := func(state string) map[string]string {
+		return map[string]string{CapNFQueue: state, CapConntrack: state, CapNotrack: state, CapLimit: state}
+	}
+	tests := []struct {
+		name       string
+		backend    string
+		prober     *stubProber
+		want       map[string]string
+		wantDetail string // a substring of every detail
+		wantChecks int    // the nft probes run
+	}{
+		{
+			name:    "modules loaded",
+			backend: "nftables",
+			prober: &stubProber{modules: map[string]bool{
+				"nfnetlink_queue": true, "nf_conntrack": true, "nft_ct": true, "nft_limit": true,
+			}},
+			want:       all(CapAvailable),
+			wantDetail: "loaded",
+		},
+		{
+			name:    "proc paths present",
+			backend: "iptables",
+			prober: &stubProber{paths: map[string]bool{
+				nfqueuePath: true, "/proc/net/nf_conntrack": true,
+			}},
+			want:       map[string]string{CapNFQueue: CapAvailable, CapConntrack: CapAvailable, CapNotrack: CapUnknown, CapLimit: CapUnknown},
+			wantChecks: 0,
+		},
+		{
+			name:       "nft accepts",
+			backend:    "nftables",
+			prober:     &stubProber{},
+			want:       all(CapAvailable),
+			wantDetail: "kernel accepted",
+			wantChecks: 4,
+		},
+		{
+			name:       "nft rejects",
+			backend:    "nftables",
+			prober:     &stubProber{nftErr: errors.New("Error: Could not process rule: No such file or directory")},
+			want:       all(CapMissing),
+			wantDetail: "kernel rejected a probe rule: Error: Could not process rule",
+			wantChecks: 4,
+		},
+		{
+			name:       "nft unavailable",
+			backend:    "nftables",
+			prober:     &stubProber{nftErr: errProbeUnavailable},
+			want:       all(CapUnknown),
+			wantDetail: "may be built in",
+			wantChecks: 4,
+		},
+		{
+			name:       "modules unreadable",
+			backend:    "iptables",
+			prober:     &stubProber{modErr: errors.New("permission denied")},
+			want:       all(CapUnknown),
+			wantDetail: "cannot read loaded modules: permission denied",
+		},
+		{
+			name:       "iptables never probes nft",
+			backend:    "iptables",
+			prober:     &stubProber{nftErr: errors.New("rejected")},
+			want:       all(CapUnknown),
+			wantChecks: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := probeCapabilities(tt.prober, tt.backend, "")
+			if got := capStates(caps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("probeCapabilities() states = %v, want %v", got, tt.want)
+			}
+			for _, c := range caps {
+				if !strings.Contains(c.Detail, tt.wantDetail) {
+					t.Errorf("%s detail = %q, want %q in it", c.Name, c.Detail, tt.wantDetail)
+				}
+				if want := capabilityModules[tt.backend][c.Name][0]; c.Module != want {
+					t.Errorf("%s module = %q, want %q", c.Name, c.Module, want)
+				}
+			}
+			if got := len(tt.prober.checked); got != tt.wantChecks {
+				t.Errorf("%d nft probes, want %d", got, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestProbeRuleset(t *testing.T) {
+	tests := []struct {
+		capability string
+		want       string
+	}{
+		{
+			capability: CapLimit,
+			want: "add table inet zapret_probe\n" +
+				"add chain inet zapret_probe probe { type filter hook output priority 0; }\n" +
+				"add rule inet zapret_probe probe limit rate 1/second accept\n",
+		},
+		{
+			// Notrack runs before conntrack
+			capability: CapNotrack,
+			want: "add table inet zapret_probe\n" +
+				"add chain inet zapret_probe probe { type filter hook output priority raw; }\n" +
+				"add rule inet zapret_probe probe notrack\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := probeRuleset(tt.capability, capabilityProbeRules[tt.capability]); got != tt.want {
+			t.Errorf("probeRuleset(%s) = %q, want %q", tt.capability, got, tt.want)
+		}
+	}
+}
+
+func TestApplyCapabilities(t *testing.T) {
+	missing := []KernelCapability{
+		{Name: CapNFQueue, State: CapAvailable, Module: "nfnetlink_queue"},
+		{Name: CapConntrack, State: CapAvailable, Module: "nf_conntrack"},
+		{Name: CapNotrack, State: CapMissing, Module: "nft_ct"},
+		{Name: CapLimit, State: CapMissing, Module: "nft_limit"},
+	}
+	unknown := []KernelCapability{
+		{Name: CapNotrack, State: CapUnknown, Module: "nft_ct"},
+		{Name: CapLimit, State: CapUnknown, Module: "nft_limit"},
+	}
+	tests := []struct {
+		name string
+		caps []KernelCapability
+		mode string
+		rule ParsedRule
+		want ParsedRule
+	}{
+		{
+			name: "strict fails",
+			caps: missing,
+			mode: CompatStrict,
+			rule: ParsedRule{Notrack: true, RateLimit: 100},
+			want: ParsedRule{Notrack: true, RateLimit: 100, CompatError: "kernel lacks notrack, limit (load with: modprobe nft_ct nft_limit)"},
+		},
+		{
+			name: "lenient drops notrack",
+			caps: missing,
+			mode: CompatLenient,
+			rule: ParsedRule{Notrack: true},
+			want: ParsedRule{StrippedArgs: []string{"notrack"}},
+		},
+		{
+			name: "lenient drops the rate limit",
+			caps: missing,
+			mode: CompatLenient,
+			rule: ParsedRule{RateLimit: 100, StrippedArgs: []string{"--dup"}},
+			want: ParsedRule{StrippedArgs: []string{"--dup", "rate_limit"}},
+		},
+		{
+			name: "unneeded",
+			caps: missing,
+			mode: CompatStrict,
+			rule: ParsedRule{},
+			want: ParsedRule{},
+		},
+		{
+			name: "already failed",
+			caps: missing,
+			mode: CompatStrict,
+			rule: ParsedRule{Notrack: true, CompatError: "unsupported nfqws options: --dup"},
+			want: ParsedRule{Notrack: true, CompatError: "unsupported nfqws options: --dup"},
+		},
+		{
+			// Unknown capabilities are given the benefit of the doubt
+			name: "unknown",
+			caps: unknown,
+			mode: CompatStrict,
+			rule: ParsedRule{Notrack: true, RateLimit: 100},
+			want: ParsedRule{Notrack: true, RateLimit: 100},
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := []ParsedRule{tt.rule}
+			applyCapabilities(rules, tt.caps, tt.mode, logger)
+			if !reflect.DeepEqual(rules[0], tt.want) {
+				t.Errorf("applyCapabilities() rule = %+v, want %+v", rules[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package strategyrunner
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Page sizes of changelog reads.
+const (
+	changelogDefaultLimit = 50
+	changelogMaxLimit     = 500
+)
+
+// ChangelogConfig contains settings of the applied configuration changelog.
+type ChangelogConfig struct {
+	// File is the JSONL changelog, relative to the state directory ("" disables it)
+	File string `yaml:"file" env:"ZAPRET_CHANGELOG_FILE" env-default:"changelog.jsonl"`
+
+	// MaxSize rotates the file once it would grow beyond this many bytes
+	MaxSize int64 `yaml:"max_size" env:"ZAPRET_CHANGELOG_MAX_SIZE" env-default:"10485760"`
+
+	// MaxFiles is the number of rotated files kept next to the current one
+	MaxFiles int `yaml:"max_files" env:"ZAPRET_CHANGELOG_MAX_FILES" env-default:"5"`
+}
+
+// Validate validates the changelog configuration.
+func (c *ChangelogConfig) Validate() error {
+	if c.File == "" {
+		return nil
+	}
+	if c.MaxSize <= 0 {
+		return fmt.Errorf("max_size must be positive")
+	}
+	if c.MaxFiles < 0 {
+		return fmt.Errorf("max_files must not be negative")
+	}
+	return nil
+}
+
+// ChangelogRule is an applied rule as recorded in the changelog.
+type ChangelogRule struct {
+	Protocol string `json:"protocol"`
+	Ports    string `json:"ports"`
+	Queue    int    `json:"queue"`
+	ArgsHash string `json:"args_hash"`
+}
+
+// String returns a one-line description of the rule.
+func (r ChangelogRule) String() string {
+	return fmt.Sprintf("%s %s queue %d args %s", r.Protocol, r.Ports, r.Queue, r.ArgsHash)
+}
+
+// ChangelogDiff is the difference of an entry to the previous one.
+type ChangelogDiff struct {
+	Added   []ChangelogRule `json:"added,omitempty"`
+	Removed []ChangelogRule `json:"removed,omitempty"`
+
+	// StrategyChanged reports a different strategy content hash
+	StrategyChanged bool `json:"strategy_changed,omitempty"`
+
+	// PreviousBackend is the firewall backend before, set when it changed
+	PreviousBackend string `json:"previous_backend,omitempty"`
+}
+
+// ChangelogEntry records one successful apply of the configuration.
+type ChangelogEntry struct {
+	Seq             uint64          `json:"seq"`
+	Time            time.Time       `json:"time"`
+	StrategyFile    string          `json:"strategy_file"`
+	StrategyHash    string          `json:"strategy_hash"`
+	FirewallBackend string          `json:"firewall_backend"`
+	Rules           []ChangelogRule `json:"rules"`
+	Diff            ChangelogDiff   `json:"diff"`
+
+	// PrevHash is the hash of the previous entry, chaining the entries so
+	// edits and removals are detectable
+	PrevHash string `json:"prev_hash"`
+
+	// Hash is the SHA-256 of the entry encoded with an empty Hash
+	Hash string `json:"hash"`
+}
+
+// computeHash returns the hash of the entry.
+func (e ChangelogEntry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChangelogPage is a page of changelog entries, oldest first.
+type ChangelogPage struct {
+	Entries []ChangelogEntry
+
+	// NextSeq is the cursor of the next page, 0 when there are no more entries
+	NextSeq uint64
+
+	// ChainError describes the first break of the hash chain, "" if intact
+	ChainError string
+}
+
+// Changelog is an append-only JSONL log of applied configurations, rotated by
+// size. Entries continue the sequence and hash chain across rotations and
+// daemon restarts.
+type Changelog struct {
+	cfg ChangelogConfig
+
+	mu     sync.Mutex
+	last   *ChangelogEntry
+	loaded bool
+}
+
+// NewChangelog creates a changelog writing to cfg.File.
+func NewChangelog(cfg ChangelogConfig) *Changelog {
+	return &Changelog{cfg: cfg}
+}
+
+// changelogRules converts the applied rules to their changelog representation.
+func changelogRules(rules []ParsedRule) []ChangelogRule {
+	out := make([]ChangelogRule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.active() {
+			continue
+		}
+		sum := sha256.Sum256([]byte(strings.Join(parseNFQWSArgs(rule.NFQWSArgs), "\x00")))
+		out = append(out, ChangelogRule{
+			Protocol: rule.Protocol,
+			Ports:    rule.Ports,
+			Queue:    rule.QueueNum,
+			ArgsHash: hex.EncodeToString(sum[:8]),
+		})
+	}
+	return out
+}
+
+// diffChangelog returns the difference of entry to prev (nil for the first entry).
+func diffChangelog(prev *ChangelogEntry, entry *ChangelogEntry) ChangelogDiff {
+	if prev == nil {
+		return ChangelogDiff{Added: entry.Rules, StrategyChanged: true}
+	}
+
+	diff := ChangelogDiff{StrategyChanged: prev.StrategyHash != entry.StrategyHash}
+	if prev.FirewallBackend != entry.FirewallBackend {
+		diff.PreviousBackend = prev.FirewallBackend
+	}
+
+	before := make(map[ChangelogRule]bool, len(prev.Rules))
+	for _, rule := range prev.Rules {
+		before[rule] = true
+	}
+	after := make(map[ChangelogRule]bool, len(entry.Rules))
+	for _, rule := range entry.Rules {
+		after[rule] = true
+		if !before[rule] {
+			diff.Added = append(diff.Added, rule)
+		}
+	}
+	for _, rule := range prev.Rules {
+		if !after[rule] {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+	return diff
+}
+
+// Append records an apply of rules. Nothing is written when the changelog is
+// disabled.
+func (c *Changelog) Append(strategyFile string, strategy []byte, backend string, rules []ParsedRule) (*ChangelogEntry, error) {
+	if c.cfg.File == "" {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		last, err := c.lastEntry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read changelog: %w", err)
+		}
+		c.last = last
+		c.loaded = true
+	}
+
+	sum := sha256.Sum256(strategy)
+	entry := &ChangelogEntry{
+		Seq:             1,
+		Time:            time.Now().UTC(),
+		StrategyFile:    strategyFile,
+		StrategyHash:    hex.EncodeToString(sum[:]),
+		FirewallBackend: backend,
+		Rules:           changelogRules(rules),
+	}
+	if c.last != nil {
+		entry.Seq = c.last.Seq + 1
+		entry.PrevHash = c.last.Hash
+	}
+	entry.Diff = diffChangelog(c.last, entry)
+	entry.Hash = entry.computeHash()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode changelog entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if err := c.rotate(int64(len(line))); err != nil {
+		return nil, fmt.Errorf("failed to rotate changelog: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.cfg.File), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create changelog directory: %w", err)
+	}
+	f, err := os.OpenFile(c.cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open changelog: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write changelog: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write changelog: %w", err)
+	}
+
+	c.last = entry
+	return entry, nil
+}
+
+// rotatedPath returns the path of the n-th rotated file (0 is the current one).
+func (c *Changelog) rotatedPath(n int) string {
+	if n == 0 {
+		return c.cfg.File
+	}
+	return fmt.Sprintf("%s.%d", c.cfg.File, n)
+}
+
+// rotate shifts the files when appending size bytes would exceed MaxSize,
+// dropping the oldest beyond MaxFiles.
+func (c *Changelog) rotate(size int64) error {
+	info, err := os.Stat(c.cfg.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+size <= c.cfg.MaxSize {
+		return nil
+	}
+
+	if c.cfg.MaxFiles == 0 {
+		return os.Remove(c.cfg.File)
+	}
+	if err := os.Remove(c.rotatedPath(c.cfg.MaxFiles)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := c.cfg.MaxFiles - 1; n >= 0; n-- {
+		if err := os.Rename(c.rotatedPath(n), c.rotatedPath(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// files returns the existing changelog files, oldest first.
+func (c *Changelog) files() []string {
+	var files []string
+	for n := c.cfg.MaxFiles; n >= 0; n-- {
+		if _, err := os.Stat(c.rotatedPath(n)); err == nil {
+			files = append(files, c.rotatedPath(n))
+		}
+	}
+	return files
+}
+
+// readChangelogFile decodes the entries of a changelog file.
+func readChangelogFile(path string) ([]ChangelogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ChangelogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry ChangelogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// lastEntry returns the newest entry, nil if the changelog is empty.
+func (c *Changelog) lastEntry() (*ChangelogEntry, error) {
+	files := c.files()
+	for i := len(files) - 1; i >= 0; i-- {
+		entries, err := readChangelogFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			return &entries[len(entries)-1], nil
+		}
+	}
+	return nil, nil
+}
+
+// Read returns up to limit entries at or after since with a sequence number
+// above afterSeq, oldest first, and verifies the hash chain of all retained
+// entries. The first retained entry may link to a rotated-out one.
+func (c *Changelog) Read(since time.Time, afterSeq uint64, limit int) (*ChangelogPage, error) {
+	if c.cfg.File == "" {
+		return nil, errors.New("changelog is disabled")
+	}
+	if limit <= 0 {
+		limit = changelogDefaultLimit
+	}
+	limit = min(limit, changelogMaxLimit)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	page := &ChangelogPage{}
+	var prev *ChangelogEntry
+	for _, path := range c.files() {
+		entries, err := readChangelogFile(path)
+		if err != nil {
+			if page.ChainError == "" {
+				page.ChainError = err.Error()
+			}
+		}
+		for i := range entries {
+			entry := entries[i]
+			if page.ChainError == "" {
+				page.ChainError = verifyChangelogEntry(prev, entry)
+			}
+			prev = &entries[i]
+
+			if entry.Seq <= afterSeq || entry.Time.Before(since) {
+				continue
+			}
+			if len(page.Entries) == limit {
+				page.NextSeq = page.Entries[limit-1].Seq
+				continue
+			}
+			page.Entries = append(page.Entries, entry)
+		}
+	}
+	return page, nil
+}
+
+// verifyChangelogEntry checks the hash of entry and its link to prev,
+// returning a description of the problem or "".
+func verifyChangelogEntry(prev *ChangelogEntry, entry ChangelogEntry) string {
+	if entry.computeHash() != entry.Hash {
+		return fmt.Sprintf("entry %d was modified (hash mismatch)", entry.Seq)
+	}
+	if prev == nil {
+		return ""
+	}
+	if entry.Seq != prev.Seq+1 {
+		return fmt.Sprintf("entries %d to %d are missing", prev.Seq+1, entry.Seq-1)
+	}
+	if entry.PrevHash != prev.Hash {
+		return fmt.Sprintf("entry %d doesn't link to entry %d", entry.Seq, prev.Seq)
+	}
+	return ""
+}
+
+// recordApply appends the applied rules to the changelog. A failure is logged,
+// not returned: the configuration is already applied. Caller must hold r.mu.
+func (r *Runner) recordApply(strategyPath string) {
+	if r.config.Changelog.File == "" {
+		return
+	}
+	if r.changelog == nil || r.changelog.cfg != r.config.Changelog {
+		r.changelog = NewChangelog(r.config.Changelog)
+	}
+
+	strategy, err := os.ReadFile(strategyPath)
+	if err != nil {
+		r.logger.Warn("failed to read strategy for changelog", slog.Any("error", err))
+	}
+	entry, err := r.changelog.Append(strategyPath, strategy, r.firewallBackend(), r.rules)
+	if err != nil {
+		r.logger.Warn("failed to record applied configuration in changelog", slog.Any("error", err))
+		return
+	}
+	r.logger.Debug("recorded applied configuration",
+		slog.Uint64("seq", entry.Seq),
+		slog.Int("added", len(entry.Diff.Added)),
+		slog.Int("removed", len(entry.Diff.Removed)),
+	)
+}
+
+// Changelog returns a page of the applied configuration changelog.
+func (r *Runner) Changelog(since time.Time, afterSeq uint64, limit int) (*ChangelogPage, error) {
+	r.mu.RLock()
+	changelog := r.changelog
+	cfg := r.config.Changelog
+	r.mu.RUnlock()
+
+	if changelog == nil {
+		changelog = NewChangelog(cfg)
+	}
+	return changelog.Read(since, afterSeq, limit)
+}
@@ -0,0 +1,263 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	changelogTCP = ParsedRule{Protocol: "tcp", Ports: "443", QueueNum: 200, NFQWSArgs: "--dpi-desync=fake"}
+	changelogUDP = ParsedRule{Protocol: "udp", Ports: "443", QueueNum: 201, NFQWSArgs: "--dpi-desync=fake --dpi-desync-repeats=6"}
+)
+
+// newTestChangelog returns a changelog in a temporary directory.
+func newTestChangelog(t *testing.T, maxSize int64, maxFiles int) *Changelog {
+	t.Helper()
+	return NewChangelog(ChangelogConfig{
+		File:     filepath.Join(t.TempDir(), "state", "changelog.jsonl"),
+		MaxSize:  maxSize,
+		MaxFiles: maxFiles,
+	})
+}
+
+// appendEntries appends n entries alternating between two rule sets.
+func appendEntries(t *testing.T, c *Changelog, n int) {
+	t.Helper()
+	for i := range n {
+		rules := []ParsedRule{changelogTCP}
+		if i%2 == 1 {
+			rules = append(rules, changelogUDP)
+		}
+		if _, err := c.Append("strategy.bat", []byte(fmt.Sprintf("strategy %d", i%2)), "nftables", rules); err != nil {
+			t.Fatalf("Append() #%d error = %v", i+1, err)
+		}
+	}
+}
+
+// readAll pages through the whole changelog with the given page size.
+func readAll(t *testing.T, c *Changelog, since time.Time, limit int) ([]ChangelogEntry, int) {
+	t.Helper()
+	var (
+		entries  []ChangelogEntry
+		afterSeq uint64
+		pages    int
+	)
+	for {
+		page, err := c.Read(since, afterSeq, limit)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if page.ChainError != "" {
+			t.Fatalf("Read() chain error = %s", page.ChainError)
+		}
+		pages++
+		entries = append(entries, page.Entries...)
+		if page.NextSeq == 0 {
+			return entries, pages
+		}
+		afterSeq = page.NextSeq
+	}
+}
+
+func TestChangelogAppend(t *testing.T) {
+	c := newTestChangelog(t, 1<<20, 2)
+
+	first, err := c.Append("strategy.bat", []byte("v1"), "nftables", []ParsedRule{changelogTCP})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if first.Seq != 1 || first.PrevHash != "" || !first.Diff.StrategyChanged || len(first.Diff.Added) != 1 {
+		t.Errorf("first entry = %+v, want seq 1 adding the rule", first)
+	}
+
+	// Inactive rules are not applied, so they are not recorded
+	missing := changelogUDP
+	missing.MissingFiles = []string{"list-general.txt"}
+	second, err := c.Append("strategy.bat", []byte("v1"), "iptables", []ParsedRule{changelogTCP, missing})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if second.Seq != 2 || second.PrevHash != first.Hash {
+		t.Errorf("second entry seq %d prev %s, want 2 linked to %s", second.Seq, second.PrevHash, first.Hash)
+	}
+	if d := second.Diff; d.StrategyChanged || d.PreviousBackend != "nftables" || len(d.Added) != 0 || len(d.Removed) != 0 {
+		t.Errorf("second diff = %+v, want only the backend change", d)
+	}
+
+	changed := changelogTCP
+	changed.NFQWSArgs = "--dpi-desync=split"
+	third, err := c.Append("strategy.bat", []byte("v2"), "iptables", []ParsedRule{changed, changelogUDP})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if d := third.Diff; !d.StrategyChanged || len(d.Added) != 2 || len(d.Removed) != 1 || d.Removed[0].Queue != 200 {
+		t.Errorf("third diff = %+v, want the changed tcp rule replaced and the udp rule added", d)
+	}
+
+	// A restarted daemon continues the sequence and the chain
+	restarted := NewChangelog(c.cfg)
+	fourth, err := restarted.Append("strategy.bat", []byte("v2"), "iptables", []ParsedRule{changed, changelogUDP})
+	if err != nil {
+		t.Fatalf("Append() after restart error = %v", err)
+	}
+	if fourth.Seq != 4 || fourth.PrevHash != third.Hash {
+		t.Errorf("entry after restart seq %d prev %s, want 4 linked to %s", fourth.Seq, fourth.PrevHash, third.Hash)
+	}
+}
+
+func TestChangelogRotation(t *testing.T) {
+	c := newTestChangelog(t, 1500, 2)
+	appendEntries(t, c, 12)
+
+	current, err := os.Stat(c.cfg.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Size() > c.cfg.MaxSize {
+		t.Errorf("current file is %d bytes, want at most max_size %d", current.Size(), c.cfg.MaxSize)
+	}
+	if _, err := os.Stat(c.rotatedPath(2)); err != nil {
+		t.Errorf("rotated file .2 missing: %v", err)
+	}
+	if _, err := os.Stat(c.rotatedPath(3)); !os.IsNotExist(err) {
+		t.Errorf("rotated file .3 exists beyond max_files 2")
+	}
+
+	// Old entries were dropped, the rest is still a verified chain ending at 12
+	entries, _ := readAll(t, c, time.Time{}, 0)
+	if len(entries) == 0 || len(entries) >= 12 {
+		t.Fatalf("read %d entries, want some rotated out", len(entries))
+	}
+	if last := entries[len(entries)-1].Seq; last != 12 {
+		t.Errorf("last entry seq = %d, want 12", last)
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Seq != entries[i-1].Seq+1 {
+			t.Errorf("entries %d and %d are not consecutive", entries[i-1].Seq, entries[i].Seq)
+		}
+	}
+}
+
+func TestChangelogRotationWithoutBackups(t *testing.T) {
+	c := newTestChangelog(t, 1500, 0)
+	appendEntries(t, c, 8)
+
+	if _, err := os.Stat(c.rotatedPath(1)); !os.IsNotExist(err) {
+		t.Errorf("rotated file .1 exists with max_files 0")
+	}
+	entries, _ := readAll(t, c, time.Time{}, 0)
+	if len(entries) == 0 || entries[len(entries)-1].Seq != 8 {
+		t.Errorf("read %d entries, want the newest ending at seq 8", len(entries))
+	}
+}
+
+func TestChangelogPagination(t *testing.T) {
+	c := newTestChangelog(t, 1<<20, 2)
+	appendEntries(t, c, 7)
+
+	tests := []struct {
+		limit     int
+		wantPages int
+	}{
+		{limit: 1, wantPages: 7},
+		{limit: 3, wantPages: 3},
+		{limit: 7, wantPages: 1},
+		{limit: 0, wantPages: 1},
+		{limit: changelogMaxLimit + 1, wantPages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			entries, pages := readAll(t, c, time.Time{}, tt.limit)
+			if pages != tt.wantPages {
+				t.Errorf("read %d pages, want %d", pages, tt.wantPages)
+			}
+			if len(entries) != 7 {
+				t.Fatalf("read %d entries, want 7", len(entries))
+			}
+			for i, e := range entries {
+				if e.Seq != uint64(i+1) {
+					t.Errorf("entry %d has seq %d, want %d", i, e.Seq, i+1)
+				}
+			}
+		})
+	}
+
+	if page, _ := c.Read(time.Now().Add(time.Hour), 0, 0); len(page.Entries) != 0 || page.NextSeq != 0 {
+		t.Errorf("Read() since the future = %d entries, next %d, want none", len(page.Entries), page.NextSeq)
+	}
+	if page, _ := c.Read(time.Time{}, 5, 0); len(page.Entries) != 2 || page.Entries[0].Seq != 6 {
+		t.Errorf("Read() after seq 5 = %+v, want entries 6 and 7", page.Entries)
+	}
+}
+
+func TestChangelogTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+		want   string
+	}{
+		{
+			name: "edited entry",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"nftables"`), []byte(`"iptables"`), 1)
+				return lines
+			},
+			want: "entry 2 was modified",
+		},
+		{
+			name: "removed entry",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+			want: "entries 2 to 2 are missing",
+		},
+		{
+			name: "garbage line",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[2] = []byte("{not json")
+				return lines
+			},
+			want: "line 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestChangelog(t, 1<<20, 2)
+			appendEntries(t, c, 4)
+
+			data, err := os.ReadFile(c.cfg.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")))
+			if err := os.WriteFile(c.cfg.File, append(bytes.Join(lines, []byte("\n")), '\n'), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			page, err := c.Read(time.Time{}, 0, 0)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if !strings.Contains(page.ChainError, tt.want) {
+				t.Errorf("ChainError = %q, want it to contain %q", page.ChainError, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangelogDisabled(t *testing.T) {
+	c := NewChangelog(ChangelogConfig{})
+	if entry, err := c.Append("strategy.bat", nil, "nftables", []ParsedRule{changelogTCP}); entry != nil || err != nil {
+		t.Errorf("Append() on a disabled changelog = %v, %v, want nil, nil", entry, err)
+	}
+	if _, err := c.Read(time.Time{}, 0, 0); err == nil {
+		t.Error("Read() on a disabled changelog succeeded, want error")
+	}
+}
@@ -0,0 +1,157 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+// optionSyntaxRegex matches a well-formed nfqws option name.
+var optionSyntaxRegex = regexp.MustCompile(`^--[a-z0-9][a-z0-9-]*$`)
+
+// Plan is what starting the configured strategy would do, built without
+// touching the firewall or spawning nfqws.
+type Plan struct {
+	// StrategyFile is the strategy file that was checked
+	StrategyFile string
+
+	// Rules are the parsed rules after overrides and compatibility checks
+	Rules []ParsedRule
+
+	// Firewall holds the firewall rules of the active rules
+	Firewall []*firewall.Rule
+
+	// Processes holds the nfqws process configs of the active rules
+	Processes []*ProcessConfig
+
+	// Operations lists what a real start would do, in order
+	Operations []Operation
+
+	// Warnings are problems that don't prevent starting
+	Warnings []string
+
+	// Errors are problems that would break a rule when started
+	Errors []string
+}
+
+// Valid reports whether the plan has no errors.
+func (p *Plan) Valid() bool {
+	return len(p.Errors) == 0
+}
+
+// Plan parses the configured strategy and builds the firewall rules and
+// process configs starting it would use. Problems a running daemon tolerates,
+// such as missing list files that leave a rule pending, are errors here.
+// An error is returned only when the strategy can't be parsed at all.
+func (r *Runner) Plan() (*Plan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	strategyPath, source, err := resolveStrategy(r.config)
+	if err != nil {
+		return nil, err
+	}
+
+	sim, err := r.simulate(strategyPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		StrategyFile: strategyPath,
+		Rules:        sim.Rules,
+		Operations:   sim.Operations,
+	}
+	if source == StrategySourceFallback {
+		plan.Errors = append(plan.Errors, fmt.Sprintf("strategy file %s is missing, the embedded fallback would be applied", r.config.StrategyFile))
+	}
+	for _, line := range sim.EmptyRules {
+		plan.Errors = append(plan.Errors, fmt.Sprintf("line %d: rule has no nfqws arguments", line))
+	}
+
+	var stats *StatsClassifier
+	if r.config.Process.CollectStats {
+		stats = &StatsClassifier{}
+	}
+	for _, rule := range plan.Rules {
+		errorf := func(format string, args ...any) {
+			plan.Errors = append(plan.Errors, fmt.Sprintf("line %d: ", rule.SourceLine)+fmt.Sprintf(format, args...))
+		}
+		warnf := func(format string, args ...any) {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("line %d: ", rule.SourceLine)+fmt.Sprintf(format, args...))
+		}
+
+		if rule.CompatError != "" {
+			errorf("%s", rule.CompatError)
+		}
+		if len(rule.MissingFiles) > 0 {
+			errorf("missing files: %s", strings.Join(rule.MissingFiles, ", "))
+		}
+		if strings.Count(rule.NFQWSArgs, `"`)%2 != 0 {
+			errorf("unbalanced quotes in nfqws arguments")
+		}
+		for _, issue := range argSyntaxIssues(parseNFQWSArgs(rule.NFQWSArgs)) {
+			errorf("%s", issue)
+		}
+		for _, c := range rule.PathConversions {
+			if c.Error == "" {
+				warnf("converted Windows path %s=%s to %s", c.Option, c.Original, c.Converted)
+			}
+		}
+		if len(rule.StrippedArgs) > 0 {
+			warnf("rule would run degraded without %v", rule.StrippedArgs)
+		}
+		for _, issue := range rule.PayloadIssues {
+			warnf("%s", issue)
+		}
+		if !rule.active() {
+			continue
+		}
+		if !r.externalProcesses() {
+			for _, issue := range copyRangeIssues(rule, r.processCopyRange()) {
+				warnf("%s", issue)
+			}
+		}
+
+		if !r.externalFirewall() {
+			fwRule := r.convertToFirewallRule(rule)
+			if _, err := r.fw.Render(fwRule); err != nil && !errors.Is(err, firewall.ErrRenderUnsupported) {
+				errorf("invalid firewall rule: %v", err)
+			}
+			plan.Firewall = append(plan.Firewall, fwRule)
+		}
+		if !r.externalProcesses() {
+			plan.Processes = append(plan.Processes, r.planProcess(rule, stats))
+		}
+	}
+	return plan, nil
+}
+
+// argSyntaxIssues checks that args are nfqws options, each optionally
+// followed by a separate value.
+func argSyntaxIssues(args []string) []string {
+	if len(args) == 0 {
+		return []string{"no nfqws arguments"}
+	}
+
+	var issues []string
+	valueAllowed := false
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			if !valueAllowed {
+				issues = append(issues, fmt.Sprintf("stray argument %q", arg))
+			}
+			valueAllowed = false
+			continue
+		}
+		name, _, hasValue := strings.Cut(arg, "=")
+		if !optionSyntaxRegex.MatchString(name) {
+			issues = append(issues, fmt.Sprintf("malformed option %q", arg))
+		}
+		valueAllowed = !hasValue
+	}
+	return issues
+}
@@ -0,0 +1,161 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ports"
+)
+
+// PortCoverage describes how a rule's port spec covers a port.
+type PortCoverage struct {
+	// Rule is the evaluated rule
+	Rule ParsedRule
+
+	// Stages trace the port spec from the strategy file to the applied spec
+	// the firewall rule was generated from
+	Stages []ports.Stage
+
+	// Covered reports that the applied port spec contains the port
+	Covered bool
+
+	// Queued reports that the rule queues the port: it covers it, is
+	// installed and no earlier rule takes the port first
+	Queued bool
+
+	// Reason explains why a covering rule doesn't queue the port, or the
+	// caveats of the one that does
+	Reason string
+}
+
+// PortCheck is the coverage of a port by the applied rules.
+type PortCheck struct {
+	Protocol string
+	Port     int
+
+	// Rule is the rule queueing the port, nil if none does
+	Rule *ParsedRule
+
+	// Rules lists the rules of the protocol in firewall order
+	Rules []PortCoverage
+
+	// ExternalFirewall reports that the daemon doesn't install the firewall
+	// rules; the coverage is what the strategy asks for
+	ExternalFirewall bool
+}
+
+// CheckPort evaluates the applied rules against a protocol and port and
+// reports which rule queues it, tracing each port spec through %GameFilter%
+// substitution, alias resolution and port exclusions to the spec the
+// firewall rule was generated from. The first installed rule covering the port takes its
+// packets; later ones are shadowed.
+func (r *Runner) CheckPort(protocol string, port int) (*PortCheck, error) {
+	if protocol != "tcp" && protocol != "udp" {
+		return nil, fmt.Errorf("invalid protocol %q: must be tcp or udp", protocol)
+	}
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	check := &PortCheck{
+		Protocol:         protocol,
+		Port:             port,
+		ExternalFirewall: r.externalFirewall(),
+	}
+	for _, rule := range r.rules {
+		if rule.Protocol != protocol {
+			continue
+		}
+
+		stages := ports.Trace(port, r.portStages(rule))
+		coverage := PortCoverage{
+			Rule:    rule,
+			Stages:  stages,
+			Covered: stages[len(stages)-1].Covered,
+		}
+		if coverage.Covered {
+			coverage.Reason = r.portReason(rule, check.Rule)
+			if coverage.Reason == "" && check.Rule == nil {
+				coverage.Queued = true
+				check.Rule = &coverage.Rule
+				var limits []string
+				if rule.RateLimit > 0 {
+					limits = append(limits, fmt.Sprintf("rate limited to %d packets/s, excess packets are not queued", rule.RateLimit))
+				}
+				if limit := r.config.Firewall.ConnbytesLimit; limit > 0 && !rule.Notrack && !check.ExternalFirewall {
+					limits = append(limits, fmt.Sprintf("only the first %d packets of each connection are queued", limit))
+				}
+				coverage.Reason = strings.Join(limits, "; ")
+			}
+		}
+		check.Rules = append(check.Rules, coverage)
+	}
+
+	return check, nil
+}
+
+// portStages returns the port spec of rule as written, after %GameFilter%
+// substitution, with aliases resolved, without the exclude_ports of
+// overrides and without the never_queue ports, skipping steps that changed
+// nothing. The last stage is the applied spec. Caller must hold r.mu.
+func (r *Runner) portStages(rule ParsedRule) []ports.Stage {
+	var stages []ports.Stage
+	add := func(name, spec string) {
+		if spec == "" || len(stages) > 0 && stages[len(stages)-1].Spec == spec {
+			return
+		}
+		stages = append(stages, ports.Stage{Name: name, Spec: spec})
+	}
+
+	add("as written", rule.PortsWritten)
+	if strings.Contains(rule.PortsWritten, "%GameFilter%") {
+		if r.config.GameFilter {
+			add("%GameFilter% expanded to "+r.config.GameFilterPorts, rule.PortsExpanded)
+		} else {
+			add("%GameFilter% removed (gamefilter disabled)", rule.PortsExpanded)
+		}
+	} else {
+		add("variables substituted", rule.PortsExpanded)
+	}
+	if len(rule.PortAliases) > 0 {
+		add("aliases resolved ("+strings.Join(rule.PortAliases, ", ")+")", rule.selectorPorts())
+	}
+	if rule.PortsResolved != "" {
+		never, _, _ := r.parser.portAliases.Resolve(r.config.NeverQueue.Ports(rule.Protocol), rule.Protocol)
+		// The same computation applyExclusions derived rule.Ports with
+		if excluded, _, err := excludePorts(&rule, never); err == nil {
+			add("exclude_ports of overrides removed "+rule.ExcludedPorts, excluded)
+		}
+		add(fmt.Sprintf("never_queue.%s removed %s", rule.Protocol, never), rule.Ports)
+	}
+
+	// The applied spec always closes the trace, even if unchanged
+	applied := ports.Stage{Name: "applied", Spec: rule.Ports}
+	if len(stages) > 0 && stages[len(stages)-1].Spec == rule.Ports {
+		stages[len(stages)-1].Name += ", applied"
+		return stages
+	}
+	return append(stages, applied)
+}
+
+// portReason returns why rule, covering the port, doesn't queue it, or ""
+// if it does. taken is the earlier rule queueing the port, if any. Caller
+// must hold r.mu.
+func (r *Runner) portReason(rule ParsedRule, taken *ParsedRule) string {
+	switch {
+	case rule.FilteredOut:
+		return fmt.Sprintf("filtered out by the rule filter (%s)", r.filter)
+	case rule.CompatError != "":
+		return "rule failed: " + rule.CompatError
+	case len(rule.MissingFiles) > 0:
+		return "pending, missing " + strings.Join(rule.MissingFiles, ", ")
+	case rule.ScheduledOff:
+		return "outside its active hours " + strings.Join(rule.Schedule.Strings(), ", ")
+	case taken != nil:
+		return fmt.Sprintf("shadowed by queue %d (line %d), which comes first", taken.QueueNum, taken.SourceLine)
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package strategyrunner
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// layeredStrategy has a GameFilter rule and a rule using a port alias.
+const layeredStrategy = `--filter-udp=%GameFilter% --dpi-desync=fake
+--filter-udp=quic,50000-50100 --dpi-desync=fake
+--filter-tcp=443 --dpi-desync=fake
+`
+
+// layeredSettings excludes ports from the GameFilter rule with an override
+// and never queues a port of the second rule and DNS.
+const layeredSettings = `gamefilter: true
+gamefilter_ports: "1024-65535"
+overrides:
+  - line: 1
+    exclude_ports: "50000-50100"
+  - protocol: tcp
+    exclude_ports: "https"
+never_queue:
+  udp: "50012,dns"
+`
+
+func TestCheckPortLayered(t *testing.T) {
+	tr := newTestRunner(t, layeredStrategy, layeredSettings)
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The tcp rule excluded all of its ports and was dropped
+	rules := tr.Rules()
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want the two udp rules", len(rules))
+	}
+
+	// The firewall rules get the ports left after the exclusions
+	wantPorts := map[int][]string{
+		rules[0].QueueNum: {"1024-49999", "50101-65535"},
+		rules[1].QueueNum: {"443", "50000-50011", "50013-50100"},
+	}
+	tr.fw.mu.Lock()
+	for queue, want := range wantPorts {
+		if got := tr.fw.rules[queue].Ports; !slices.Equal(got, want) {
+			t.Errorf("firewall rule of queue %d has ports %q, want %q", queue, got, want)
+		}
+	}
+	tr.fw.mu.Unlock()
+
+	type stage struct {
+		name    string
+		spec    string
+		covered bool
+	}
+	tests := []struct {
+		port      int
+		wantQueue int // -1 for no rule
+		wantRule1 []stage
+		wantRule2 []stage
+	}{
+		{
+			port:      50012,
+			wantQueue: -1,
+			wantRule1: []stage{
+				{name: "as written", spec: "%GameFilter%"},
+				{name: "%GameFilter% expanded to 1024-65535", spec: "1024-65535", covered: true},
+				{name: "exclude_ports of overrides removed 50000-50100, applied", spec: "1024-49999,50101-65535"},
+			},
+			wantRule2: []stage{
+				{name: "as written", spec: "quic,50000-50100"},
+				{name: "aliases resolved (quic)", spec: "443,50000-50100", covered: true},
+				{name: "never_queue.udp removed 50012,53, applied", spec: "443,50000-50011,50013-50100"},
+			},
+		},
+		{port: 50050, wantQueue: rules[1].QueueNum},
+		{port: 60000, wantQueue: rules[0].QueueNum},
+		{port: 53, wantQueue: -1},
+	}
+
+	for _, tt := range tests {
+		check, err := tr.CheckPort("udp", tt.port)
+		if err != nil {
+			t.Fatalf("CheckPort(udp, %d) error = %v", tt.port, err)
+		}
+		switch {
+		case tt.wantQueue < 0 && check.Rule != nil:
+			t.Errorf("CheckPort(udp, %d) = queue %d, want no rule", tt.port, check.Rule.QueueNum)
+		case tt.wantQueue >= 0 && (check.Rule == nil || check.Rule.QueueNum != tt.wantQueue):
+			t.Errorf("CheckPort(udp, %d) = %+v, want queue %d", tt.port, check.Rule, tt.wantQueue)
+		}
+		if len(check.Rules) != 2 {
+			t.Fatalf("CheckPort(udp, %d) evaluated %d rules, want 2", tt.port, len(check.Rules))
+		}
+
+		for i, want := range [][]stage{tt.wantRule1, tt.wantRule2} {
+			if want == nil {
+				continue
+			}
+			var got []stage
+			for _, s := range check.Rules[i].Stages {
+				got = append(got, stage{name: s.Name, spec: s.Spec, covered: s.Covered})
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("CheckPort(udp, %d) rule %d stages:\n got %+v\nwant %+v", tt.port, i+1, got, want)
+			}
+		}
+	}
+
+	// Exported overrides select rules by their ports before the exclusions,
+	// the spec overrides match on the next reload
+	for i := range rules {
+		rules[i].Label = ""
+	}
+	if exported := ExportOverrides(rules); !strings.Contains(exported, `ports: "443,50000-50100"`) {
+		t.Errorf("export doesn't select the rule by its ports before the exclusions:\n%s", exported)
+	}
+	checkRoundTrip(t, rules)
+}
@@ -0,0 +1,125 @@
+package strategyrunner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// clockCheckInterval is how often the wall clock is compared with the monotonic clock
+	clockCheckInterval = 10 * time.Second
+
+	// clockJumpThreshold is the smallest wall clock step reported as a jump;
+	// smaller differences are NTP slewing and scheduling latency
+	clockJumpThreshold = 30 * time.Second
+)
+
+// clock is the time source of timers and periodic checks. Tests substitute a
+// fake one to control time.
+type clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) clockTimer
+	NewTicker(d time.Duration) clockTicker
+}
+
+// clockTimer is a timer created by clock.AfterFunc.
+type clockTimer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// clockTicker is a ticker created by clock.NewTicker.
+type clockTicker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// systemClock is the clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) clockTimer { return time.AfterFunc(d, f) }
+
+func (systemClock) NewTicker(d time.Duration) clockTicker { return systemTicker{time.NewTicker(d)} }
+
+// systemTicker adapts time.Ticker to clockTicker.
+type systemTicker struct{ *time.Ticker }
+
+func (t systemTicker) Chan() <-chan time.Time { return t.C }
+
+// clockJumpDetector detects steps of the wall clock, e.g. NTP setting the
+// time on a router that booted without an RTC, by comparing the wall and the
+// monotonic time elapsed between checks.
+type clockJumpDetector struct {
+	now  func() time.Time
+	last time.Time
+}
+
+// newClockJumpDetector creates a detector reading the time from now.
+func newClockJumpDetector(now func() time.Time) *clockJumpDetector {
+	return &clockJumpDetector{now: now, last: now()}
+}
+
+// check returns how far the wall clock stepped since the previous check,
+// positive when it jumped forward, or 0 if it didn't jump.
+func (d *clockJumpDetector) check() time.Duration {
+	now := d.now()
+	monotonic := now.Sub(d.last)
+	wall := now.Round(0).Sub(d.last.Round(0))
+	d.last = now
+
+	jump := wall - monotonic
+	if jump.Abs() < clockJumpThreshold {
+		return 0
+	}
+	return jump
+}
+
+// watchClock checks for wall clock jumps until ctx is cancelled.
+func (r *Runner) watchClock(ctx context.Context, interval time.Duration) {
+	ticker := r.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	detector := newClockJumpDetector(r.clock.Now)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		if jump := detector.check(); jump != 0 {
+			r.onClockJump(jump)
+		}
+	}
+}
+
+// onClockJump corrects state derived from the wall clock after it jumped.
+// Timers and durations use the monotonic clock and are unaffected; what
+// remains are wall times shown to users and persisted backoff deadlines.
+func (r *Runner) onClockJump(jump time.Duration) {
+	r.logger.Warn("system clock jumped, correcting wall clock based state",
+		slog.Duration("jump", jump),
+	)
+	r.events.Add("clock_jump", fmt.Sprintf("wall clock stepped by %s", jump))
+
+	// Shift the reported start time with the clock so the uptime shown to
+	// clients stays right
+	r.mu.Lock()
+	if !r.startTime.IsZero() {
+		r.startTime = r.startTime.Round(0).Add(jump)
+	}
+	r.mu.Unlock()
+
+	// Backoff deadlines were computed with the old clock and could stall
+	// updates for the jumped duration
+	if n := r.updater.ResetBackoff(); n > 0 {
+		r.logger.Info("reset hostlist update backoff after clock jump", slog.Int("sources", n))
+	}
+
+	// Scheduled rules may have crossed a window boundary
+	r.applySchedule(context.Background(), r.clock.Now())
+}
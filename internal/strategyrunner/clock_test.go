@@ -0,0 +1,162 @@
+package strategyrunner
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose time only moves with Advance. Timers run their
+// function on the goroutine calling Advance; tickers deliver to a channel
+// with room for one tick, like time.Ticker.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	tickers []*fakeTicker
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) clockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, when: c.now.Add(d), f: f, active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) clockTicker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, period: d, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the time forward by d, running the timers and ticking the
+// tickers that fall due in order.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	for {
+		var timer *fakeTimer
+		for _, t := range c.timers {
+			if t.active && !t.when.After(target) && (timer == nil || t.when.Before(timer.when)) {
+				timer = t
+			}
+		}
+		var ticker *fakeTicker
+		for _, t := range c.tickers {
+			if !t.stopped && !t.next.After(target) && (ticker == nil || t.next.Before(ticker.next)) {
+				ticker = t
+			}
+		}
+
+		switch {
+		case timer != nil && (ticker == nil || !ticker.next.Before(timer.when)):
+			c.now = timer.when
+			timer.active = false
+			c.mu.Unlock()
+			timer.f()
+			c.mu.Lock()
+		case ticker != nil:
+			c.now = ticker.next
+			ticker.next = ticker.next.Add(ticker.period)
+			select {
+			case ticker.c <- c.now:
+			default:
+			}
+		default:
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+	}
+}
+
+// pendingTimers counts the timers that haven't fired or been stopped.
+func (c *fakeClock) pendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.timers {
+		if t.active {
+			n++
+		}
+	}
+	return n
+}
+
+// activeTickers counts the tickers that haven't been stopped.
+func (c *fakeClock) activeTickers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.tickers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// waitTickers waits until n tickers are active, as goroutines create and
+// stop theirs asynchronously.
+func (c *fakeClock) waitTickers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.activeTickers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d tickers active, want %d", c.activeTickers(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	when   time.Time
+	f      func()
+	active bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.when, t.active = t.clock.now.Add(d), true
+	return active
+}
+
+type fakeTicker struct {
+	clock   *fakeClock
+	period  time.Duration
+	next    time.Time
+	c       chan time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
@@ -0,0 +1,117 @@
+package strategyrunner
+
+import (
+	"sync"
+	"time"
+)
+
+// ReloadCoalescer merges reload triggers that arrive during a hold window
+// into a single reload executed when the window ends. Triggers arriving
+// outside a hold window fire immediately. Triggers arriving while a reload
+// runs are merged into one follow-up reload once it returned.
+type ReloadCoalescer struct {
+	mu        sync.Mutex
+	clock     clock
+	fire      func(reasons []string)
+	holdUntil time.Time
+	timer     clockTimer
+	reasons   []string
+	firing    bool
+}
+
+// NewReloadCoalescer creates a coalescer calling fire with the reasons of all merged triggers.
+func NewReloadCoalescer(fire func(reasons []string)) *ReloadCoalescer {
+	return &ReloadCoalescer{clock: systemClock{}, fire: fire}
+}
+
+// Hold extends the hold window to at least d from now.
+func (c *ReloadCoalescer) Hold(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	until := now.Add(d)
+	if until.After(c.holdUntil) {
+		c.holdUntil = until
+	}
+	if c.timer != nil {
+		c.timer.Reset(c.holdUntil.Sub(now))
+	}
+}
+
+// Trigger requests a reload. Within a hold window the reload is deferred
+// to the end of the window and merged with other triggers.
+func (c *ReloadCoalescer) Trigger(reason string) {
+	c.mu.Lock()
+	c.reasons = append(c.reasons, reason)
+
+	wait := c.holdUntil.Sub(c.clock.Now())
+	if wait > 0 {
+		if c.timer == nil {
+			c.timer = c.clock.AfterFunc(wait, c.flush)
+		}
+		c.mu.Unlock()
+		return
+	}
+
+	c.mu.Unlock()
+	c.flush()
+}
+
+// Pending returns the number of triggers waiting for the hold window to end.
+func (c *ReloadCoalescer) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.reasons)
+}
+
+// Cancel drops any pending reload.
+func (c *ReloadCoalescer) Cancel() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reasons = nil
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+// flush fires the merged reload, or re-arms the timer if the window was
+// extended. While a reload runs, flush leaves the triggers to the follow-up
+// that reload fires when it returns.
+func (c *ReloadCoalescer) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if wait := c.holdUntil.Sub(c.clock.Now()); wait > 0 && c.timer != nil {
+		c.timer.Reset(wait)
+		return
+	}
+	c.timer = nil
+	if c.firing {
+		return
+	}
+
+	c.firing = true
+	for len(c.reasons) > 0 {
+		reasons := c.reasons
+		c.reasons = nil
+		c.mu.Unlock()
+		c.fire(reasons)
+		c.mu.Lock()
+
+		// The reload may have started a hold window for the follow-up
+		if wait := c.holdUntil.Sub(c.clock.Now()); wait > 0 && len(c.reasons) > 0 {
+			if c.timer == nil {
+				c.timer = c.clock.AfterFunc(wait, c.flush)
+			}
+			break
+		}
+	}
+	c.firing = false
+}
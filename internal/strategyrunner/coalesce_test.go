@@ -0,0 +1,162 @@
+package strategyrunner
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordedFires collects the reasons of each reload a coalescer fires.
+type recordedFires struct {
+	mu    sync.Mutex
+	fires [][]string
+}
+
+func (r *recordedFires) fire(reasons []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fires = append(r.fires, reasons)
+}
+
+func (r *recordedFires) get() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.fires...)
+}
+
+// newTestCoalescer returns a coalescer on a fake clock recording its reloads.
+func newTestCoalescer() (*ReloadCoalescer, *fakeClock, *recordedFires) {
+	clk := newFakeClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	fires := &recordedFires{}
+	c := NewReloadCoalescer(fires.fire)
+	c.clock = clk
+	return c, clk, fires
+}
+
+func TestReloadCoalescerBurst(t *testing.T) {
+	c, clk, fires := newTestCoalescer()
+
+	// Outside a hold window a trigger fires at once
+	c.Trigger("manual")
+	if got := fires.get(); !reflect.DeepEqual(got, [][]string{{"manual"}}) {
+		t.Fatalf("fires = %v, want the trigger fired at once", got)
+	}
+
+	// A burst within the window fires once when it ends, extended by Hold
+	c.Hold(5 * time.Second)
+	c.Trigger("config")
+	clk.Advance(time.Second)
+	c.Trigger("strategy")
+	c.Hold(5 * time.Second)
+	c.Trigger("hostlist")
+	if got := c.Pending(); got != 3 {
+		t.Errorf("Pending() = %d, want 3", got)
+	}
+	clk.Advance(4 * time.Second)
+	if got := fires.get(); len(got) != 1 {
+		t.Fatalf("fires = %v before the extended window ended", got)
+	}
+	clk.Advance(time.Second)
+	want := [][]string{{"manual"}, {"config", "strategy", "hostlist"}}
+	if got := fires.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fires = %v, want %v", got, want)
+	}
+	if got := c.Pending(); got != 0 {
+		t.Errorf("Pending() = %d after the reload, want 0", got)
+	}
+}
+
+func TestReloadCoalescerTriggerDuringReload(t *testing.T) {
+	c, clk, fires := newTestCoalescer()
+	entered, release := make(chan struct{}), make(chan struct{})
+	first := true
+	c.fire = func(reasons []string) {
+		fires.fire(reasons)
+		if first {
+			first = false
+			close(entered)
+			<-release
+			// Reloads hold triggers for the cooldown after them
+			c.Hold(2 * time.Second)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Trigger("config")
+	}()
+	<-entered
+
+	// Triggers while the reload runs wait for it
+	c.Trigger("strategy")
+	c.Trigger("hostlist")
+	if got := fires.get(); len(got) != 1 {
+		t.Fatalf("fires = %v while the first reload runs", got)
+	}
+	close(release)
+	<-done
+
+	// and get exactly one follow-up after the cooldown the reload started
+	if got := fires.get(); len(got) != 1 {
+		t.Fatalf("fires = %v before the cooldown ended", got)
+	}
+	clk.Advance(2 * time.Second)
+	want := [][]string{{"config"}, {"strategy", "hostlist"}}
+	if got := fires.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fires = %v, want %v", got, want)
+	}
+	clk.Advance(time.Minute)
+	if got := fires.get(); len(got) != 2 {
+		t.Errorf("fires = %v, want no more reloads", got)
+	}
+}
+
+func TestReloadCoalescerCancel(t *testing.T) {
+	c, clk, fires := newTestCoalescer()
+	c.Hold(5 * time.Second)
+	c.Trigger("config")
+	c.Trigger("strategy")
+
+	// Stop cancels the coalescer: pending triggers and the timer are dropped
+	c.Cancel()
+	if got := c.Pending(); got != 0 {
+		t.Errorf("Pending() = %d after Cancel, want 0", got)
+	}
+	if got := clk.pendingTimers(); got != 0 {
+		t.Errorf("%d timers left after Cancel", got)
+	}
+	clk.Advance(time.Minute)
+	if got := fires.get(); len(got) != 0 {
+		t.Errorf("fires = %v after Cancel, want none", got)
+	}
+}
+
+func TestRunnerTriggerAfterStop(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	// Without a cooldown the trigger reaches the runner at once
+	tr.Runner.config.ReloadCooldown = 0
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	tr.fw.takeOps()
+
+	// A trigger after Stop is dropped without starting the runner again
+	tr.TriggerReload("test")
+	if got := tr.reloads.Pending(); got != 0 {
+		t.Errorf("%d triggers pending after Stop", got)
+	}
+	if status := tr.GetStatus(); status.Running {
+		t.Error("trigger after Stop started the runner")
+	}
+	if ops := tr.fw.takeOps(); len(ops) != 0 {
+		t.Errorf("firewall operations after Stop = %v, want none", ops)
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d processes after a trigger after Stop", got)
+	}
+}
@@ -0,0 +1,62 @@
+package strategyrunner
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// winwsPrefixRegex matches the Windows invocation of winws at the start of a line,
+	// e.g. `start "zapret" /min "%BIN%winws.exe"`, with or without the start command.
+	winwsPrefixRegex = regexp.MustCompile(`(?i)^\s*(?:start(?:\s+(?:"[^"]*"|/\w+))*?\s+)?("[^"]*winws(?:\.exe)?"|[^\s"]*winws(?:\.exe)?)(?:\s+|$)`)
+
+	// winwsFilterRegex matches the WinDivert port filters selecting the traffic winws handles
+	winwsFilterRegex = regexp.MustCompile(`--wf-(tcp|udp)=([0-9,-]+)`)
+
+	// windowsOnlyOptionRegex matches winws options that nfqws does not understand.
+	// WinDivert options all start with --wf-, the rest select networks by Windows profile.
+	windowsOnlyOptionRegex = regexp.MustCompile(`(?:^|\s)(--wf-[a-z0-9-]+|--ssid-filter|--nlm-filter|--nlm-list)(?:=(?:"[^"]*"|\S*))?`)
+)
+
+// stripWinwsPrefix removes a leading winws invocation from a line.
+// It reports whether the line was a winws invocation.
+func stripWinwsPrefix(line string) (string, bool) {
+	loc := winwsPrefixRegex.FindStringIndex(line)
+	if loc == nil {
+		return line, false
+	}
+	return line[loc[1]:], true
+}
+
+// winwsMatches converts the --wf-tcp/--wf-udp filters of a winws line without
+// --filter- rules into matches shaped like the --filter- regex submatches:
+// full match, protocol, ports, remaining arguments.
+func winwsMatches(line string) [][]string {
+	filters := winwsFilterRegex.FindAllStringSubmatch(line, -1)
+	if len(filters) == 0 {
+		return nil
+	}
+
+	args := strings.TrimSpace(winwsFilterRegex.ReplaceAllString(line, ""))
+
+	matches := make([][]string, 0, len(filters))
+	for _, f := range filters {
+		matches = append(matches, []string{f[0], f[1], f[2], args})
+	}
+	return matches
+}
+
+// stripWindowsOnlyOptions removes winws options with no nfqws equivalent from args.
+// It returns the remaining arguments and the names of the removed options.
+func stripWindowsOnlyOptions(args string) (string, []string) {
+	found := windowsOnlyOptionRegex.FindAllStringSubmatch(args, -1)
+	if len(found) == 0 {
+		return args, nil
+	}
+
+	removed := make([]string, 0, len(found))
+	for _, m := range found {
+		removed = append(removed, m[1])
+	}
+	return windowsOnlyOptionRegex.ReplaceAllString(args, ""), removed
+}
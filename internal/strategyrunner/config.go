@@ -1,9 +1,22 @@
 package strategyrunner
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/dnsprobe"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/hostlist"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/schema"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/textnorm"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
@@ -12,6 +25,21 @@ type Config struct {
 	// Interface is the network interface to apply rules to ("eth0", "any", etc.)
 	Interface string `yaml:"interface" env:"ZAPRET_INTERFACE" env-default:"any"`
 
+	// InterfaceV6 overrides Interface for IPv6 traffic (e.g. a tunnel like "he-ipv6")
+	InterfaceV6 string `yaml:"interface_v6" env:"ZAPRET_INTERFACE_V6"`
+
+	// LanInterface restricts firewall.hook "forward" to packets arriving on
+	// this interface, e.g. "br-lan" on a router ("" for all)
+	LanInterface string `yaml:"lan_interface" env:"ZAPRET_LAN_INTERFACE"`
+
+	// NetworkNamespace runs the firewall rules, nfqws and interface checks inside
+	// this named network namespace (see `ip netns`); "" uses the host namespace
+	NetworkNamespace string `yaml:"network_namespace" env:"ZAPRET_NETWORK_NAMESPACE"`
+
+	// ExcludeNetworks are destination prefixes never queued to nfqws: private,
+	// loopback and link-local networks by default; [] queues them too
+	ExcludeNetworks []string `yaml:"exclude_networks" env:"ZAPRET_EXCLUDE_NETWORKS" env-default:"10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,127.0.0.0/8,169.254.0.0/16,::1/128,fc00::/7,fe80::/10"`
+
 	// GameFilter enables filtering of game ports (1024-65535)
 	GameFilter bool `yaml:"gamefilter" env:"ZAPRET_GAMEFILTER" env-default:"true"`
 
@@ -21,9 +49,151 @@ type Config struct {
 	// StrategyFile is the path to the .bat strategy file
 	StrategyFile string `yaml:"strategy_file" env:"ZAPRET_STRATEGY_FILE"`
 
+	// BinPath replaces %BIN% in the strategy file, the directory of the fake
+	// payloads shipped with nfqws
+	BinPath string `yaml:"bin_path" env:"ZAPRET_BIN_PATH" env-default:"/usr/bin"`
+
+	// ListsPath replaces %LISTS% in the strategy file and holds installed and
+	// downloaded hostlists
+	ListsPath string `yaml:"lists_path" env:"ZAPRET_LISTS_PATH" env-default:"/etc/zapret-ng/lists"`
+
+	// FallbackStrategy applies a minimal strategy embedded in the binary while
+	// StrategyFile is missing, reporting the runner as degraded
+	FallbackStrategy bool `yaml:"fallback_strategy" env:"ZAPRET_FALLBACK_STRATEGY" env-default:"false"`
+
+	// Dedupe collapses rules identical in protocol, ports and args into one
+	Dedupe bool `yaml:"dedupe" env:"ZAPRET_DEDUPE"`
+
+	// Timezone evaluates the active_hours of rule overrides, e.g. "Europe/Moscow"
+	// ("" for the system time zone)
+	Timezone string `yaml:"timezone" env:"ZAPRET_TIMEZONE"`
+
+	// PayloadsDir resolves fake payload files given by bare name in the strategy
+	// (e.g. --dpi-desync-fake-tls=tls_clienthello.bin); "" leaves them as written
+	PayloadsDir string `yaml:"payloads_dir" env:"ZAPRET_PAYLOADS_DIR"`
+
+	// StartupSettle merges reload triggers during this period after daemon start into one reload
+	StartupSettle time.Duration `yaml:"startup_settle" env:"ZAPRET_STARTUP_SETTLE"`
+
+	// ReloadCooldown merges reload triggers during this period after each reload into one reload
+	ReloadCooldown time.Duration `yaml:"reload_cooldown" env:"ZAPRET_RELOAD_COOLDOWN" env-default:"3s"`
+
+	// WatchTargets sets the debounce and trigger policy of each watched file
+	WatchTargets WatchConfig `yaml:"watch_targets"`
+
+	// ReloadRetryDelay is the delay before retrying a reload that failed on an
+	// invalid config, in case the file was caught while being written
+	ReloadRetryDelay time.Duration `yaml:"reload_retry_delay" env:"ZAPRET_RELOAD_RETRY_DELAY" env-default:"2s"`
+
+	// ReloadRetryAttempts is how many times such a reload is retried (0 disables)
+	ReloadRetryAttempts int `yaml:"reload_retry_attempts" env:"ZAPRET_RELOAD_RETRY_ATTEMPTS" env-default:"3"`
+
+	// ConfigCheckInterval polls the watched files for content changes at this
+	// interval, for filesystems where fsnotify doesn't fire (NFS, 9p); 0 polls
+	// only when the fsnotify watcher can't be set up
+	ConfigCheckInterval time.Duration `yaml:"config_check_interval" env:"ZAPRET_CONFIG_CHECK_INTERVAL"`
+
+	// WaitForPaths lists paths (e.g. a lists directory on a late mount) to wait for at startup
+	WaitForPaths []string `yaml:"wait_for_paths"`
+
+	// WaitTimeout is how long to wait for WaitForPaths before starting with pending rules
+	WaitTimeout time.Duration `yaml:"wait_timeout" env:"ZAPRET_WAIT_TIMEOUT" env-default:"30s"`
+
+	// QueueDrainTimeout is how long stopping waits for nfqws to answer the
+	// packets still queued after the firewall rules are removed (0 stops nfqws
+	// right away)
+	QueueDrainTimeout time.Duration `yaml:"queue_drain_timeout" env:"ZAPRET_QUEUE_DRAIN_TIMEOUT" env-default:"2s"`
+
+	// PendingRetryInterval is how often rules with missing files are retried
+	PendingRetryInterval time.Duration `yaml:"pending_retry_interval" env:"ZAPRET_PENDING_RETRY_INTERVAL" env-default:"10s"`
+
+	// CheckOffload warns at startup when the interfaces have offloads (GRO/GSO/TSO) enabled
+	CheckOffload bool `yaml:"check_offload" env:"ZAPRET_CHECK_OFFLOAD"`
+
+	// AutoFixOffload disables those offloads at start and restores them on stop
+	AutoFixOffload bool `yaml:"auto_fix_offload" env:"ZAPRET_AUTO_FIX_OFFLOAD"`
+
+	// QueueCopyRange limits how many bytes of each packet the kernel copies to
+	// nfqws (0 copies whole packets). Needs an nfqws build supporting --copy-range
+	QueueCopyRange int `yaml:"queue_copy_range" env:"ZAPRET_QUEUE_COPY_RANGE"`
+
+	// StrictExclusive fails the start when another zapret-family service
+	// (legacy units, /opt/zapret tables, foreign nfqws processes) is found
+	StrictExclusive bool `yaml:"strict_exclusive" env:"ZAPRET_STRICT_EXCLUSIVE"`
+
+	// ProcessManagement is "managed" to run nfqws, or "external" when nfqws is
+	// supervised elsewhere and only needs to be bound to the queues
+	ProcessManagement string `yaml:"process_management" env:"ZAPRET_PROCESS_MANAGEMENT" env-default:"managed"`
+
+	// FirewallManagement is "managed" to install queue rules, or "external"
+	// when the user's own firewall scripts feed the queues
+	FirewallManagement string `yaml:"firewall_management" env:"ZAPRET_FIREWALL_MANAGEMENT" env-default:"managed"`
+
+	// State contains the directories runtime state is kept in
+	State StateConfig `yaml:"state"`
+
+	// QueueMapFile publishes the queue to rule mapping after each (re)load for
+	// external log pipelines; removed on stop. Relative to the volatile state
+	// directory ("" disables).
+	QueueMapFile string `yaml:"queue_map_file" env:"ZAPRET_QUEUE_MAP_FILE" env-default:"queues.json"`
+
+	// CompatMode is "strict" to fail rules using options the installed nfqws
+	// doesn't support, or "lenient" to strip the options that are safe to drop.
+	// Rules needing missing kernel capabilities (notrack, rate_limit) are
+	// failed or run without those features the same way
+	CompatMode string `yaml:"compat_mode" env:"ZAPRET_COMPAT_MODE" env-default:"strict"`
+
+	// EmptyRulesetPolicy decides what happens when filters, overrides and
+	// compatibility checks leave no rule to apply: "error" fails the (re)start
+	// keeping the running rules, "warn" runs with nothing and reports degraded
+	// health, "allow" runs with nothing silently
+	EmptyRulesetPolicy string `yaml:"empty_ruleset_policy" env:"ZAPRET_EMPTY_RULESET_POLICY" env-default:"error"`
+
+	// Queues controls how queue numbers are assigned to rules
+	Queues QueueConfig `yaml:"queues"`
+
+	// Changelog records every successful apply of the configuration
+	Changelog ChangelogConfig `yaml:"changelog"`
+
 	// Firewall contains firewall backend configuration
 	Firewall FirewallConfig `yaml:"firewall"`
 
+	// Process contains nfqws process settings
+	Process ProcessOptions `yaml:"process"`
+
+	// HostlistUpdate keeps hostlist files up to date from URLs
+	HostlistUpdate HostlistUpdateConfig `yaml:"hostlist_update"`
+
+	// DNSProbe compares the system resolver with DNS over HTTPS, telling DNS
+	// poisoning from DPI blocking in `zapret test` and `zapret doctor`
+	DNSProbe DNSProbeConfig `yaml:"dns_probe"`
+
+	// Canary verifies a strategy applied by an automatic reload and rolls
+	// back to the previous one if it breaks connectivity
+	Canary CanaryConfig `yaml:"canary"`
+
+	// Hooks are commands run around firewall changes
+	Hooks HooksConfig `yaml:"hooks"`
+
+	// MaxLineLength is the longest strategy line accepted, in bytes, also
+	// after joining ^ continuations. Generated strategies chain dozens of
+	// filters on one line
+	MaxLineLength int `yaml:"max_line_length" env:"ZAPRET_MAX_LINE_LENGTH" env-default:"1048576"`
+
+	// PortAliases names ports for use in port specs, as "443" or "443/udp" to
+	// restrict the alias to one protocol; extends the built-in http, https,
+	// quic (udp only) and dns
+	PortAliases map[string]string `yaml:"port_aliases"`
+
+	// Overrides customize individual rules parsed from the strategy file
+	Overrides []RuleOverride `yaml:"overrides"`
+
+	// NeverQueue are ports removed from every rule after the overrides
+	NeverQueue NeverQueueConfig `yaml:"never_queue"`
+
+	// Lenient disables the unknown key check when loading the config file
+	Lenient bool `yaml:"lenient"`
+
 	// BinaryPath is the path to nfqws binary (from main config)
 	BinaryPath string
 
@@ -32,23 +202,303 @@ type Config struct {
 
 	// Watch indicates if config file should be watched for changes
 	Watch bool
+
+	// Deprecations are warnings about legacy keys migrated while loading
+	Deprecations []string
+
+	// Normalizations are warnings about values read only after folding
+	// look-alike characters, e.g. "5 сек" or a port spec with a no-break space
+	Normalizations []string
 }
 
 // FirewallConfig contains firewall backend settings.
 type FirewallConfig struct {
-	// Backend is the firewall backend to use ("nftables" or "iptables")
+	// Backend is the firewall backend to use ("nftables", "iptables" or "auto",
+	// which selects nftables and falls back to iptables without nf_tables)
 	Backend string `yaml:"backend" env:"ZAPRET_FIREWALL_BACKEND" env-default:"nftables"`
 
+	// Hook selects the packets queued: "output" for the host's own traffic,
+	// "forward" for traffic routed through it, "postrouting" for both
+	Hook string `yaml:"hook" env:"ZAPRET_FIREWALL_HOOK" env-default:"output"`
+
 	// TableName is the nftables table name (only for nftables backend)
 	TableName string `yaml:"table_name" env:"ZAPRET_FIREWALL_TABLE_NAME" env-default:"inet zapretunix"`
 
 	// ChainName is the chain name to use
 	ChainName string `yaml:"chain_name" env:"ZAPRET_FIREWALL_CHAIN_NAME" env-default:"output"`
+
+	// SlowOpThreshold is the duration after which a firewall operation is logged as slow
+	SlowOpThreshold time.Duration `yaml:"slow_op_threshold" env:"ZAPRET_FIREWALL_SLOW_OP_THRESHOLD" env-default:"500ms"`
+
+	// StateFile records installed firewall objects until they are removed, so a
+	// daemon killed mid-shutdown cleans them up on the next start. Relative to
+	// the volatile state directory ("" disables).
+	StateFile string `yaml:"state_file" env:"ZAPRET_FIREWALL_STATE_FILE" env-default:"firewall-state.json"`
+
+	// MatchMark only queues packets whose mark & MatchMarkMask equals it (e.g. "0x100")
+	MatchMark string `yaml:"match_mark" env:"ZAPRET_FIREWALL_MATCH_MARK"`
+
+	// MatchMarkMask selects the mark bits compared with MatchMark (all bits if empty)
+	MatchMarkMask string `yaml:"match_mark_mask" env:"ZAPRET_FIREWALL_MATCH_MARK_MASK"`
+
+	// MatchMarkNegate queues only packets whose masked mark differs from MatchMark
+	MatchMarkNegate bool `yaml:"match_mark_negate" env:"ZAPRET_FIREWALL_MATCH_MARK_NEGATE"`
+
+	// ExcludeMark skips packets with any of these mark bits set (e.g. "0x40000000")
+	ExcludeMark string `yaml:"exclude_mark" env:"ZAPRET_FIREWALL_EXCLUDE_MARK"`
+
+	// DesyncFwmark is passed to nfqws as --dpi-desync-fwmark and excluded from
+	// queueing like ExcludeMark, so packets nfqws sends are never queued again
+	DesyncFwmark string `yaml:"desync_fwmark" env:"ZAPRET_FIREWALL_DESYNC_FWMARK"`
+
+	// ConnbytesLimit queues only the first packets of each connection, which
+	// is all desync needs (0 queues every packet)
+	ConnbytesLimit int `yaml:"connbytes_limit" env:"ZAPRET_FIREWALL_CONNBYTES_LIMIT"`
+}
+
+// Marks parses the mark options into a firewall mark match.
+func (c *FirewallConfig) Marks() (firewall.MarkMatch, error) {
+	var m firewall.MarkMatch
+
+	if c.MatchMark != "" {
+		value, err := parseMark(c.MatchMark)
+		if err != nil {
+			return m, fmt.Errorf("invalid match_mark: %w", err)
+		}
+		mask := uint32(0xffffffff)
+		if c.MatchMarkMask != "" {
+			if mask, err = parseMark(c.MatchMarkMask); err != nil {
+				return m, fmt.Errorf("invalid match_mark_mask: %w", err)
+			}
+			if mask == 0 {
+				return m, fmt.Errorf("match_mark_mask must not be 0")
+			}
+		}
+		if value&^mask != 0 {
+			return m, fmt.Errorf("match_mark 0x%x has bits outside match_mark_mask 0x%x and never matches", value, mask)
+		}
+		m.Match, m.MatchValue, m.MatchMask, m.Negate = true, value, mask, c.MatchMarkNegate
+	} else if c.MatchMarkMask != "" || c.MatchMarkNegate {
+		return m, fmt.Errorf("match_mark_mask and match_mark_negate require match_mark")
+	}
+
+	if c.ExcludeMark != "" {
+		exclude, err := parseMark(c.ExcludeMark)
+		if err != nil {
+			return m, fmt.Errorf("invalid exclude_mark: %w", err)
+		}
+		m.Exclude = exclude
+	}
+
+	desync, err := c.DesyncMark()
+	if err != nil {
+		return m, err
+	}
+	m.Exclude |= desync
+
+	// A positive match requiring excluded bits would never queue anything
+	if m.Match && !m.Negate && m.MatchValue&m.MatchMask&m.Exclude != 0 {
+		return m, fmt.Errorf("match_mark 0x%x/0x%x requires bits excluded by exclude_mark/desync_fwmark 0x%x, no traffic would be queued",
+			m.MatchValue, m.MatchMask, m.Exclude)
+	}
+
+	return m, nil
+}
+
+// DesyncMark parses desync_fwmark, the mark nfqws sets on the packets it
+// sends (0 if unset).
+func (c *FirewallConfig) DesyncMark() (uint32, error) {
+	if c.DesyncFwmark == "" {
+		return 0, nil
+	}
+	mark, err := parseMark(c.DesyncFwmark)
+	if err != nil {
+		return 0, fmt.Errorf("invalid desync_fwmark: %w", err)
+	}
+	if mark == 0 {
+		return 0, fmt.Errorf("desync_fwmark must not be 0")
+	}
+	return mark, nil
+}
+
+// parseMark parses a 32-bit mark in decimal or 0x-prefixed hex.
+func parseMark(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a 32-bit number", s)
+	}
+	return uint32(v), nil
+}
+
+// HostlistUpdateConfig contains hostlist auto-update settings.
+type HostlistUpdateConfig struct {
+	// Interval is how often lists are refreshed (0 disables updates)
+	Interval time.Duration `yaml:"interval" env:"ZAPRET_HOSTLIST_UPDATE_INTERVAL" env-default:"6h"`
+
+	// Concurrency caps parallel downloads
+	Concurrency int `yaml:"concurrency" env:"ZAPRET_HOSTLIST_UPDATE_CONCURRENCY" env-default:"2"`
+
+	// StateFile persists validators and backoff across daemon restarts.
+	// Relative to the state directory ("" keeps it in memory).
+	StateFile string `yaml:"state_file" env:"ZAPRET_HOSTLIST_STATE_FILE" env-default:"hostlist-state.json"`
+
+	// Sources lists the hostlists to download. Relative file paths are
+	// resolved against the lists directory.
+	Sources []hostlist.Source `yaml:"sources"`
+}
+
+// NeverQueueConfig contains the ports no rule queues, per protocol, e.g. a
+// local game server or VoIP ports whose traffic must never reach nfqws.
+// Specs take port aliases like rule ports.
+type NeverQueueConfig struct {
+	TCP string `yaml:"tcp" env:"ZAPRET_NEVER_QUEUE_TCP"`
+	UDP string `yaml:"udp" env:"ZAPRET_NEVER_QUEUE_UDP"`
+}
+
+// Ports returns the never-queued port spec of protocol ("" for none).
+func (c NeverQueueConfig) Ports(protocol string) string {
+	if protocol == "udp" {
+		return c.UDP
+	}
+	return c.TCP
+}
+
+// DNSProbeConfig contains DNS probe settings.
+type DNSProbeConfig struct {
+	// Enabled adds the probe of Domains to `zapret doctor`; `zapret test`
+	// probes the tested domain regardless
+	Enabled bool `yaml:"enabled" env:"ZAPRET_DNS_PROBE"`
+
+	// DoHURL is the DNS-over-HTTPS (RFC 8484) endpoint compared against
+	DoHURL string `yaml:"doh_url" env:"ZAPRET_DNS_PROBE_DOH_URL" env-default:"https://cloudflare-dns.com/dns-query"`
+
+	// Bootstrap is the address connected to for DoHURL instead of resolving
+	// its host through the resolver being checked ("" resolves it)
+	Bootstrap string `yaml:"bootstrap" env:"ZAPRET_DNS_PROBE_BOOTSTRAP" env-default:"1.1.1.1"`
+
+	// Timeout bounds each resolver of a probe
+	Timeout time.Duration `yaml:"timeout" env:"ZAPRET_DNS_PROBE_TIMEOUT" env-default:"5s"`
+
+	// Domains are probed by `zapret doctor`
+	Domains []string `yaml:"domains" env:"ZAPRET_DNS_PROBE_DOMAINS" env-default:"discord.com,www.youtube.com"`
+
+	// BogusRanges are CIDRs of ISP block pages, flagged like 0.0.0.0 and
+	// 127.0.0.1 when the system resolver answers with them
+	BogusRanges []string `yaml:"bogus_ranges"`
+}
+
+// Validate checks the DNS probe settings.
+func (c *DNSProbeConfig) Validate() error {
+	if _, err := c.proberConfig(); err != nil {
+		return err
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	return nil
+}
+
+// proberConfig converts the settings to the probe configuration.
+func (c *DNSProbeConfig) proberConfig() (dnsprobe.Config, error) {
+	cfg := dnsprobe.Config{DoHURL: c.DoHURL, Bootstrap: c.Bootstrap, Timeout: c.Timeout}
+	for _, cidr := range c.BogusRanges {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return cfg, fmt.Errorf("bogus_ranges: invalid CIDR %q", cidr)
+		}
+		cfg.BogusRanges = append(cfg.BogusRanges, prefix.Masked())
+	}
+	// Checks the URL and bootstrap address
+	if _, err := dnsprobe.New(cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// ProcessOptions contains nfqws process settings.
+type ProcessOptions struct {
+	// CollectStats runs nfqws in the foreground with debug output and counts desync events per queue
+	CollectStats bool `yaml:"collect_stats" env:"ZAPRET_COLLECT_STATS"`
+
+	// StatsPatterns replaces the regular expressions classifying nfqws output, keyed by stat name
+	StatsPatterns map[string]string `yaml:"stats_patterns"`
+
+	// SuspendTimeout is how long `zapret suspend` stops nfqws at most before
+	// the processes are resumed automatically
+	SuspendTimeout time.Duration `yaml:"suspend_timeout" env:"ZAPRET_SUSPEND_TIMEOUT" env-default:"30m"`
+
+	// RestartMaxRetries is how often a crashed nfqws is restarted before its
+	// queue is reported degraded (0 never restarts)
+	RestartMaxRetries int `yaml:"restart_max_retries" env:"ZAPRET_RESTART_MAX_RETRIES" env-default:"5"`
+
+	// RestartBackoffMax caps the exponential backoff between restarts; a
+	// process running this long counts as recovered
+	RestartBackoffMax time.Duration `yaml:"restart_backoff_max" env:"ZAPRET_RESTART_BACKOFF_MAX" env-default:"1m"`
+
+	// StateFile records the PIDs and queues of the started nfqws processes,
+	// so processes left by a daemon that was killed are killed on the next start
+	StateFile string `yaml:"state_file" env:"ZAPRET_PROCESS_STATE_FILE" env-default:"processes.json"`
+
+	// StateFormat is "json" or "text" (one "queue pid start_time" line per process)
+	StateFormat string `yaml:"state_format" env:"ZAPRET_PROCESS_STATE_FORMAT" env-default:"json"`
+
+	// MemoryLimit caps the memory of each nfqws process, e.g. "64M" ("" for
+	// none). The kernel OOM killer takes a process exceeding it. Needs cgroup v2
+	MemoryLimit string `yaml:"memory_limit" env:"ZAPRET_PROCESS_MEMORY_LIMIT"`
+
+	// CgroupDir is the cgroup v2 group the processes with a memory limit run
+	// below, in a group per queue
+	CgroupDir string `yaml:"cgroup_dir" env:"ZAPRET_PROCESS_CGROUP_DIR" env-default:"/sys/fs/cgroup/zapret-ng"`
+}
+
+// Migrations lists renamed and retired keys of the strategy runner config,
+// oldest first. Renaming an option adds an entry so existing configs keep
+// loading with a deprecation warning.
+var Migrations []schema.Migration
+
+// ErrConfigIncomplete reports a config file that is empty or ends abruptly,
+// most likely because it is still being written.
+var ErrConfigIncomplete = errors.New("config file is incomplete (empty or truncated), it may still be being written")
+
+// configError marks a reload failure caused by the new config rather than by
+// applying it.
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string { return e.err.Error() }
+func (e *configError) Unwrap() error { return e.err }
+
+// checkConfigComplete fails with ErrConfigIncomplete if the config file at
+// path exists but is empty. A missing file is left to LoadStrategyConfig.
+func checkConfigComplete(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("%s: %w", path, ErrConfigIncomplete)
+	}
+	return nil
+}
+
+// isTruncatedYAML reports whether a YAML decoding error means the input ended early.
+func isTruncatedYAML(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "unexpected EOF") || strings.Contains(msg, "unexpected end of stream")
 }
 
 // LoadStrategyConfig loads strategy configuration from file and environment variables.
 func LoadStrategyConfig(path string) (*Config, error) {
 	cfg := &Config{
+		Dedupe:       true,
+		WatchTargets: defaultWatchConfig(),
 		Firewall: FirewallConfig{
 			Backend:   "nftables",
 			TableName: "inet zapretunix",
@@ -59,9 +509,31 @@ func LoadStrategyConfig(path string) (*Config, error) {
 	// Check if config file exists
 	if path != "" {
 		if _, err := os.Stat(path); err == nil {
-			if err := cleanenv.ReadConfig(path, cfg); err != nil {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read strategy config file: %w", err)
+			}
+			migrated, err := schema.Migrate(data, Migrations)
+			if err != nil {
+				return nil, fmt.Errorf("outdated keys in %s:\n%w", path, err)
+			}
+			normalized, normalizations, err := schema.NormalizeDurations(migrated.Data, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read strategy config file: %w", err)
+			}
+			if err := cleanenv.ParseYAML(bytes.NewReader(normalized), cfg); err != nil {
+				if isTruncatedYAML(err) {
+					err = fmt.Errorf("%w: %v", ErrConfigIncomplete, err)
+				}
 				return nil, fmt.Errorf("failed to read strategy config file: %w", err)
 			}
+			cfg.Deprecations = migrated.Warnings
+			cfg.Normalizations = normalizations
+			if !cfg.Lenient {
+				if err := schema.CheckKnownFields(migrated.Data, cfg); err != nil {
+					return nil, fmt.Errorf("invalid keys in %s (set lenient: true to ignore):\n%w", path, err)
+				}
+			}
 		} else if !os.IsNotExist(err) {
 			return nil, fmt.Errorf("failed to access strategy config file: %w", err)
 		}
@@ -72,29 +544,266 @@ func LoadStrategyConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read environment variables: %w", err)
 	}
 
+	if ports, changed := textnorm.Spec(cfg.GameFilterPorts); changed {
+		cfg.Normalizations = append(cfg.Normalizations,
+			fmt.Sprintf("\"gamefilter_ports\": read %q as %q", cfg.GameFilterPorts, ports))
+		cfg.GameFilterPorts = ports
+	}
+
 	cfg.ConfigPath = path
 
 	return cfg, nil
 }
 
+// ExcludePrefixes parses ExcludeNetworks.
+func (c *Config) ExcludePrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.ExcludeNetworks))
+	for _, network := range c.ExcludeNetworks {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil {
+			return nil, fmt.Errorf("exclude_networks: invalid network %q: %w", network, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
 // Validate validates the configuration.
 func (c *Config) Validate() error {
 	if c.StrategyFile == "" {
 		return fmt.Errorf("strategy_file must be specified")
 	}
 
-	if _, err := os.Stat(c.StrategyFile); err != nil {
+	if _, err := os.Stat(c.StrategyFile); err != nil && !(c.FallbackStrategy && os.IsNotExist(err)) {
 		return fmt.Errorf("strategy file not found: %s", c.StrategyFile)
 	}
 
-	validBackends := map[string]bool{"nftables": true, "iptables": true}
+	// Rules reading lists from a missing directory would stay pending forever
+	if data, err := os.ReadFile(c.StrategyFile); err == nil && strings.Contains(string(data), "%LISTS%") {
+		if info, err := os.Stat(c.ListsPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("lists_path is not a directory: %s (the strategy uses %%LISTS%%)", c.ListsPath)
+		}
+	}
+
+	if c.ConfigCheckInterval < 0 {
+		return fmt.Errorf("config_check_interval must not be negative")
+	}
+
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+		}
+	}
+
+	if c.PayloadsDir != "" {
+		if info, err := os.Stat(c.PayloadsDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("payloads_dir is not a directory: %s", c.PayloadsDir)
+		}
+	}
+
+	validBackends := map[string]bool{"nftables": true, "iptables": true, firewall.BackendAuto: true}
 	if !validBackends[c.Firewall.Backend] {
-		return fmt.Errorf("invalid firewall backend: %s (must be 'nftables' or 'iptables')", c.Firewall.Backend)
+		return fmt.Errorf("invalid firewall backend: %s (must be 'nftables', 'iptables' or 'auto')", c.Firewall.Backend)
+	}
+
+	// auto may fall back to iptables
+	if c.Firewall.Backend == "iptables" || c.Firewall.Backend == firewall.BackendAuto {
+		if err := firewall.ValidateIptablesChain(c.Firewall.ChainName); err != nil {
+			return fmt.Errorf("firewall: %w", err)
+		}
+	}
+
+	if _, err := c.Firewall.Marks(); err != nil {
+		return fmt.Errorf("firewall: %w", err)
+	}
+
+	if c.Firewall.ConnbytesLimit < 0 {
+		return fmt.Errorf("firewall: connbytes_limit must not be negative, got %d", c.Firewall.ConnbytesLimit)
+	}
+
+	if _, err := c.ExcludePrefixes(); err != nil {
+		return err
+	}
+
+	if err := firewall.ValidateHook(c.Firewall.Hook); err != nil {
+		return fmt.Errorf("firewall: %w", err)
+	}
+
+	// Packets leaving through postrouting no longer know their input interface
+	if c.LanInterface != "" && c.LanInterface != "any" && c.Firewall.Hook != firewall.HookForward {
+		return fmt.Errorf("lan_interface requires firewall.hook: %s", firewall.HookForward)
 	}
 
 	if c.Interface == "" && c.Interface != "any" {
 		return fmt.Errorf("interface must be specified or set to 'any'")
 	}
 
+	if c.NetworkNamespace != "" {
+		if err := netns.Check(c.NetworkNamespace); err != nil {
+			return fmt.Errorf("network_namespace: %w", err)
+		}
+	}
+
+	if err := validateManagement("process_management", c.ProcessManagement); err != nil {
+		return err
+	}
+	if err := validateManagement("firewall_management", c.FirewallManagement); err != nil {
+		return err
+	}
+	if c.ProcessManagement == ManagementExternal && c.FirewallManagement == ManagementExternal {
+		return fmt.Errorf("process_management and firewall_management can't both be external")
+	}
+	if c.ProcessManagement == ManagementExternal && c.Process.CollectStats {
+		return fmt.Errorf("process.collect_stats requires process_management: managed")
+	}
+
+	if c.CompatMode != CompatStrict && c.CompatMode != CompatLenient {
+		return fmt.Errorf("invalid compat_mode: %s (must be '%s' or '%s')", c.CompatMode, CompatStrict, CompatLenient)
+	}
+
+	if err := validateCopyRange(c.QueueCopyRange); err != nil {
+		return err
+	}
+
+	if err := validateEmptyRulesetPolicy(c.EmptyRulesetPolicy); err != nil {
+		return err
+	}
+
+	if c.Process.CollectStats {
+		if _, err := NewStatsClassifier(c.Process.StatsPatterns); err != nil {
+			return fmt.Errorf("process.stats_patterns: %w", err)
+		}
+	}
+
+	if err := c.WatchTargets.Validate(); err != nil {
+		return fmt.Errorf("watch_targets: %w", err)
+	}
+
+	if c.ReloadRetryDelay < 0 || c.ReloadRetryAttempts < 0 {
+		return fmt.Errorf("reload_retry_delay and reload_retry_attempts must not be negative")
+	}
+
+	if c.QueueDrainTimeout < 0 {
+		return fmt.Errorf("queue_drain_timeout must not be negative")
+	}
+
+	if c.PendingRetryInterval <= 0 {
+		return fmt.Errorf("pending_retry_interval must be positive")
+	}
+
+	if c.Process.SuspendTimeout <= 0 {
+		return fmt.Errorf("process.suspend_timeout must be positive")
+	}
+
+	if c.Process.RestartMaxRetries < 0 {
+		return fmt.Errorf("process.restart_max_retries must not be negative")
+	}
+
+	if c.Process.RestartBackoffMax < restartBackoffMin {
+		return fmt.Errorf("process.restart_backoff_max must be at least %s", restartBackoffMin)
+	}
+
+	if c.Process.StateFormat != ProcessStateJSON && c.Process.StateFormat != ProcessStateText {
+		return fmt.Errorf("process.state_format must be %q or %q", ProcessStateJSON, ProcessStateText)
+	}
+
+	if _, err := parseMemoryLimit(c.Process.MemoryLimit); err != nil {
+		return fmt.Errorf("process.memory_limit: %w", err)
+	}
+
+	for i, src := range c.HostlistUpdate.Sources {
+		if src.URL == "" || src.Path == "" {
+			return fmt.Errorf("hostlist_update.sources[%d]: url and file must be specified", i)
+		}
+	}
+
+	if err := c.DNSProbe.Validate(); err != nil {
+		return fmt.Errorf("dns_probe: %w", err)
+	}
+
+	if err := c.Canary.Validate(); err != nil {
+		return fmt.Errorf("canary: %w", err)
+	}
+
+	if err := c.Queues.Validate(); err != nil {
+		return fmt.Errorf("queues: %w", err)
+	}
+
+	if err := c.Changelog.Validate(); err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+
+	if err := c.Hooks.Validate(); err != nil {
+		return fmt.Errorf("hooks: %w", err)
+	}
+
+	if c.MaxLineLength < minStrategyLine {
+		return fmt.Errorf("max_line_length must be at least %d", minStrategyLine)
+	}
+
+	aliases, err := NewPortAliases(c.PortAliases)
+	if err != nil {
+		return fmt.Errorf("port_aliases: %w", err)
+	}
+
+	for i := range c.Overrides {
+		if err := c.Overrides[i].Validate(); err != nil {
+			return fmt.Errorf("overrides[%d]: %w", i, err)
+		}
+		if _, _, err := aliases.Resolve(c.Overrides[i].Ports, c.Overrides[i].Protocol); err != nil {
+			return fmt.Errorf("overrides[%d]: ports: %w", i, err)
+		}
+		if c.Overrides[i].PortsV6 != "" {
+			portsV6, _, err := aliases.Resolve(c.Overrides[i].PortsV6, c.Overrides[i].Protocol)
+			if err == nil {
+				err = validatePortSpec(portsV6)
+			}
+			if err != nil {
+				return fmt.Errorf("overrides[%d]: ports_v6: %w", i, err)
+			}
+		}
+		if (c.Overrides[i].PortsV6 != "" || c.Overrides[i].InterfaceV6 != "") && c.FirewallManagement == ManagementExternal {
+			return fmt.Errorf("overrides[%d]: ports_v6 and interface_v6 require firewall_management: managed", i)
+		}
+		if c.Overrides[i].RateLimit != nil && c.FirewallManagement == ManagementExternal {
+			return fmt.Errorf("overrides[%d]: rate_limit requires firewall_management: managed", i)
+		}
+		if c.Overrides[i].Notrack != nil && *c.Overrides[i].Notrack && c.FirewallManagement == ManagementExternal {
+			return fmt.Errorf("overrides[%d]: notrack requires firewall_management: managed", i)
+		}
+		if len(c.Overrides[i].ActiveHours) > 0 && c.FirewallManagement == ManagementExternal {
+			return fmt.Errorf("overrides[%d]: active_hours requires firewall_management: managed", i)
+		}
+		if c.Overrides[i].ExcludePorts != "" {
+			excluded, _, err := aliases.Resolve(c.Overrides[i].ExcludePorts, c.Overrides[i].Protocol)
+			if err == nil {
+				err = validatePortSpec(excluded)
+			}
+			if err != nil {
+				return fmt.Errorf("overrides[%d]: exclude_ports: %w", i, err)
+			}
+			if c.FirewallManagement == ManagementExternal {
+				return fmt.Errorf("overrides[%d]: exclude_ports requires firewall_management: managed", i)
+			}
+		}
+	}
+
+	for _, protocol := range []string{"tcp", "udp"} {
+		spec := c.NeverQueue.Ports(protocol)
+		if spec == "" {
+			continue
+		}
+		resolved, _, err := aliases.Resolve(spec, protocol)
+		if err == nil {
+			err = validatePortSpec(resolved)
+		}
+		if err != nil {
+			return fmt.Errorf("never_queue.%s: %w", protocol, err)
+		}
+		if c.FirewallManagement == ManagementExternal {
+			return fmt.Errorf("never_queue requires firewall_management: managed")
+		}
+	}
+
 	return nil
 }
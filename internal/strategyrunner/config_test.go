@@ -0,0 +1,178 @@
+package strategyrunner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+// loadTestConfig loads a strategy config made of body and a strategy_file
+// pointing at a one-rule strategy in a temporary directory.
+func loadTestConfig(t *testing.T, body string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	strategy := filepath.Join(dir, "strategy.bat")
+	if err := os.WriteFile(strategy, []byte("--filter-tcp=443 --dpi-desync=fake\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "strategy.yaml")
+	if err := os.WriteFile(path, []byte("strategy_file: "+strategy+"\n"+body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadStrategyConfig(path)
+	if err != nil {
+		t.Fatalf("LoadStrategyConfig: %v", err)
+	}
+	return cfg
+}
+
+func TestValidateOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string // "" for a valid config
+	}{
+		{
+			name: "no overrides",
+		},
+		{
+			name: "IPv6 ports and interface",
+			body: "overrides:\n  - protocol: udp\n    ports: \"443,50000-50100\"\n    ports_v6: \"443\"\n    interface_v6: he-ipv6\n",
+		},
+		{
+			name: "IPv6 ports with an alias",
+			body: "overrides:\n  - protocol: tcp\n    ports_v6: https\n",
+		},
+		{
+			name: "invalid IPv6 ports",
+			body: "overrides:\n  - protocol: udp\n    ports_v6: \"600-500\"\n",
+			want: "overrides[0]: ports_v6",
+		},
+		{
+			name: "unknown alias in IPv6 ports",
+			body: "overrides:\n  - ports_v6: nosuchport\n",
+			want: "overrides[0]: ports_v6",
+		},
+		{
+			name: "IPv6 interface with external firewall",
+			body: "firewall_management: external\noverrides:\n  - interface_v6: he-ipv6\n",
+			want: "interface_v6 require firewall_management: managed",
+		},
+		{
+			name: "excluded ports and never-queued ports with aliases",
+			body: "overrides:\n  - line: 1\n    exclude_ports: \"https,50000-50100\"\nnever_queue:\n  tcp: \"22\"\n  udp: \"dns,123\"\n",
+		},
+		{
+			name: "invalid excluded ports",
+			body: "overrides:\n  - exclude_ports: \"0\"\n",
+			want: "overrides[0]: exclude_ports",
+		},
+		{
+			name: "invalid never-queued ports",
+			body: "never_queue:\n  udp: \"nosuchport\"\n",
+			want: "never_queue.udp",
+		},
+		{
+			name: "never-queued ports with external firewall",
+			body: "firewall_management: external\nnever_queue:\n  tcp: \"22\"\n",
+			want: "never_queue requires firewall_management: managed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadTestConfig(t, tt.body).Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirewallMarks(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  FirewallConfig
+		want    firewall.MarkMatch
+		wantErr string
+	}{
+		{name: "unset"},
+		{
+			name:   "match",
+			config: FirewallConfig{MatchMark: "0x100"},
+			want:   firewall.MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xffffffff},
+		},
+		{
+			name:   "negated match with a mask",
+			config: FirewallConfig{MatchMark: "256", MatchMarkMask: "0xff00", MatchMarkNegate: true},
+			want:   firewall.MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xff00, Negate: true},
+		},
+		{
+			name:   "exclude",
+			config: FirewallConfig{ExcludeMark: "0x40000000"},
+			want:   firewall.MarkMatch{Exclude: 0x40000000},
+		},
+		{
+			name:   "negated match of excluded bits",
+			config: FirewallConfig{MatchMark: "0x1", MatchMarkNegate: true, ExcludeMark: "0x1"},
+			want:   firewall.MarkMatch{Match: true, MatchValue: 0x1, MatchMask: 0xffffffff, Negate: true, Exclude: 0x1},
+		},
+		{
+			name:   "desync mark",
+			config: FirewallConfig{DesyncFwmark: "0x40000000"},
+			want:   firewall.MarkMatch{Exclude: 0x40000000},
+		},
+		{
+			name:   "desync mark added to the excluded bits",
+			config: FirewallConfig{MatchMark: "0x100", ExcludeMark: "0x1", DesyncFwmark: "0x40000000"},
+			want:   firewall.MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xffffffff, Exclude: 0x40000001},
+		},
+		{name: "invalid match", config: FirewallConfig{MatchMark: "vpn"}, wantErr: "invalid match_mark"},
+		{name: "too large", config: FirewallConfig{MatchMark: "0x100000000"}, wantErr: "invalid match_mark"},
+		{name: "zero mask", config: FirewallConfig{MatchMark: "0", MatchMarkMask: "0"}, wantErr: "match_mark_mask must not be 0"},
+		{name: "bits outside the mask", config: FirewallConfig{MatchMark: "0x101", MatchMarkMask: "0xff"}, wantErr: "never matches"},
+		{name: "mask without a match", config: FirewallConfig{MatchMarkMask: "0xff"}, wantErr: "require match_mark"},
+		{name: "negate without a match", config: FirewallConfig{MatchMarkNegate: true}, wantErr: "require match_mark"},
+		{name: "invalid exclude", config: FirewallConfig{ExcludeMark: "-1"}, wantErr: "invalid exclude_mark"},
+		{name: "invalid desync mark", config: FirewallConfig{DesyncFwmark: "fwmark"}, wantErr: "invalid desync_fwmark"},
+		{name: "zero desync mark", config: FirewallConfig{DesyncFwmark: "0x0"}, wantErr: "desync_fwmark must not be 0"},
+		{
+			// Packets nfqws sent would be the only ones queued
+			name:    "match of the desync mark",
+			config:  FirewallConfig{MatchMark: "0x40000000", MatchMarkMask: "0x40000000", DesyncFwmark: "0x40000000"},
+			wantErr: "no traffic would be queued",
+		},
+		{
+			name:    "match of excluded bits",
+			config:  FirewallConfig{MatchMark: "0x3", ExcludeMark: "0x1"},
+			wantErr: "no traffic would be queued",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.Marks()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Marks() error = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Marks() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Marks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// Kinds of conflicting artifacts.
+const (
+	ConflictService = "service"
+	ConflictTable   = "nftables"
+	ConflictProcess = "process"
+)
+
+// conflictLogLimit is how many conflicts the event summary names.
+const conflictLogLimit = 3
+
+// legacyServices are systemd units of other zapret-family installs.
+var legacyServices = []string{"zapret", "zapret-discord-youtube"}
+
+// legacyTables are nftables tables installed by the /opt/zapret scripts.
+var legacyTables = []string{"inet zapret"}
+
+// Conflict is another zapret-family service competing for NFQUEUE and
+// firewall rules.
+type Conflict struct {
+	// Kind is "service", "nftables" or "process"
+	Kind string
+
+	// Name identifies the artifact (unit, table or process)
+	Name string
+
+	// Remedy is a hint on how to resolve the conflict
+	Remedy string
+}
+
+// String returns a one-line description of the conflict.
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s", c.Kind, c.Name)
+}
+
+// conflictProcess is a running process as seen by the conflict check.
+type conflictProcess struct {
+	PID     int
+	Comm    string
+	Cmdline []string
+}
+
+// conflictSystem exposes the system state inspected for conflicts.
+type conflictSystem interface {
+	// ServiceActive reports whether a systemd unit is active
+	ServiceActive(unit string) (bool, error)
+
+	// Tables lists nftables tables as "family name" in the namespace ("" for the host)
+	Tables(namespace string) ([]string, error)
+
+	// Processes lists running processes
+	Processes() ([]conflictProcess, error)
+}
+
+// systemConflicts inspects the running system.
+type systemConflicts struct{}
+
+// ServiceActive implements conflictSystem using systemctl.
+func (systemConflicts) ServiceActive(unit string) (bool, error) {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false, nil
+	}
+	output, err := exec.Command("systemctl", "is-active", unit).Output()
+	state := strings.TrimSpace(string(output))
+	if state == "active" || state == "activating" || state == "reloading" {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return false, err
+	}
+	return false, nil
+}
+
+// Tables implements conflictSystem using the nft CLI.
+func (systemConflicts) Tables(namespace string) ([]string, error) {
+	if _, err := exec.LookPath("nft"); err != nil {
+		return nil, nil
+	}
+	var output []byte
+	err := netns.Do(namespace, func() error {
+		var err error
+		output, err = exec.Command("nft", "list", "tables").Output()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nftables tables: %w", err)
+	}
+
+	var tables []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "table "); ok {
+			tables = append(tables, name)
+		}
+	}
+	return tables, nil
+}
+
+// Processes implements conflictSystem by scanning /proc.
+func (systemConflicts) Processes() ([]conflictProcess, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []conflictProcess
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", e.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		cmdline, _ := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline"))
+		procs = append(procs, conflictProcess{
+			PID:     pid,
+			Comm:    strings.TrimSpace(string(comm)),
+			Cmdline: strings.Split(string(bytes.TrimRight(cmdline, "\x00")), "\x00"),
+		})
+	}
+	return procs, nil
+}
+
+// serviceConflicts returns the legacy systemd units that are active.
+func serviceConflicts(sys conflictSystem) ([]Conflict, error) {
+	var conflicts []Conflict
+	for _, unit := range legacyServices {
+		active, err := sys.ServiceActive(unit)
+		if err != nil {
+			return conflicts, fmt.Errorf("failed to query %s: %w", unit, err)
+		}
+		if active {
+			conflicts = append(conflicts, Conflict{
+				Kind:   ConflictService,
+				Name:   unit + ".service",
+				Remedy: fmt.Sprintf("systemctl disable --now %s", unit),
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// tableConflicts returns legacy nftables tables other than ownTable.
+func tableConflicts(tables []string, ownTable string) []Conflict {
+	var conflicts []Conflict
+	for _, table := range tables {
+		if table == ownTable {
+			continue
+		}
+		for _, legacy := range legacyTables {
+			if table == legacy {
+				conflicts = append(conflicts, Conflict{
+					Kind:   ConflictTable,
+					Name:   table,
+					Remedy: fmt.Sprintf("stop the legacy zapret install (/opt/zapret/init.d/sysv/zapret stop) or nft delete table %s", table),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// processConflicts returns nfqws processes not started by this runner.
+// ownPIDs are the processes the runner tracks; queueDirs is the queue
+// directory root, identifying orphans of an earlier run of zapret-ng.
+func processConflicts(procs []conflictProcess, binary string, ownPIDs map[int]bool, queueDirs string) []Conflict {
+	var conflicts []Conflict
+	for _, p := range procs {
+		if ownPIDs[p.PID] || !isNFQWS(p, binary) {
+			continue
+		}
+
+		conflict := Conflict{
+			Kind:   ConflictProcess,
+			Name:   fmt.Sprintf("nfqws (pid %d)", p.PID),
+			Remedy: fmt.Sprintf("stop the service that started it or kill %d", p.PID),
+		}
+		if queueDirs != "" && strings.Contains(strings.Join(p.Cmdline, " "), queueDirs+string(filepath.Separator)) {
+			conflict.Remedy = fmt.Sprintf("left by a previous zapret-ng run, kill %d", p.PID)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// isNFQWS reports whether p runs nfqws or the configured nfqws binary.
+func isNFQWS(p conflictProcess, binary string) bool {
+	if p.Comm == "nfqws" {
+		return true
+	}
+	if len(p.Cmdline) == 0 || p.Cmdline[0] == "" {
+		return false
+	}
+	return p.Cmdline[0] == binary || filepath.Base(p.Cmdline[0]) == "nfqws"
+}
+
+// detectConflicts looks for other zapret-family services. A failing probe is
+// logged and skipped, so a missing systemctl or nft doesn't block the start.
+// Caller must hold r.mu.
+func (r *Runner) detectConflicts() []Conflict {
+	sys := r.conflictSys
+	var conflicts []Conflict
+
+	found, err := serviceConflicts(sys)
+	if err != nil {
+		r.logger.Debug("service conflict check failed", slog.Any("error", err))
+	}
+	conflicts = append(conflicts, found...)
+
+	tables, err := sys.Tables(r.config.NetworkNamespace)
+	if err != nil {
+		r.logger.Debug("table conflict check failed", slog.Any("error", err))
+	}
+	conflicts = append(conflicts, tableConflicts(tables, r.config.Firewall.TableName)...)
+
+	// Externally managed nfqws processes are expected
+	if !r.externalProcesses() {
+		procs, err := sys.Processes()
+		if err != nil {
+			r.logger.Debug("process conflict check failed", slog.Any("error", err))
+		}
+		own := make(map[int]bool)
+		for _, info := range r.procManager.Processes() {
+			own[info.PID] = true
+		}
+		conflicts = append(conflicts, processConflicts(procs, r.mainCfg.NFQWSBinary, own, queueDirsRoot(r.config))...)
+	}
+	return conflicts
+}
+
+// checkConflicts reports conflicting services, failing the start when
+// strict_exclusive is set. Caller must hold r.mu.
+func (r *Runner) checkConflicts() error {
+	r.conflicts = r.detectConflicts()
+	if len(r.conflicts) == 0 {
+		return nil
+	}
+
+	for _, c := range r.conflicts {
+		r.logger.Warn("another zapret service is competing for NFQUEUE and firewall rules",
+			slog.String("kind", c.Kind),
+			slog.String("name", c.Name),
+			slog.String("remedy", c.Remedy),
+		)
+	}
+
+	names := make([]string, 0, conflictLogLimit)
+	for i, c := range r.conflicts {
+		if i == conflictLogLimit {
+			names = append(names, fmt.Sprintf("and %d more", len(r.conflicts)-i))
+			break
+		}
+		names = append(names, c.String())
+	}
+	summary := strings.Join(names, ", ")
+	r.events.Add("conflict", summary)
+
+	if r.config.StrictExclusive {
+		return fmt.Errorf("conflicting zapret services found (strict_exclusive): %s", summary)
+	}
+	return nil
+}
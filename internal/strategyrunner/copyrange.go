@@ -0,0 +1,136 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// copyRangeOption is the nfqws option setting the number of bytes of each
+// packet the kernel copies to nfqws when it binds its queue. Stock nfqws
+// binds with the whole packet; the option is only passed to builds listing it
+// in --help.
+const copyRangeOption = "--copy-range"
+
+// Copy range limits of queue_copy_range. Below the minimum even the IP and
+// transport headers may not fit.
+const (
+	minCopyRange = 128
+	maxCopyRange = 65535
+)
+
+// Bytes a desync mode needs to see of each packet. Modes splitting or
+// reordering the payload rebuild it from what was copied, so a truncated
+// packet is sent truncated; the others need headers and the first bytes of
+// the payload to recognize the protocol.
+const (
+	fullPacketCopyRange = 1500
+	headerCopyRange     = 256
+)
+
+// fullPayloadModes are desync modes that rewrite the payload of the packet.
+var fullPayloadModes = map[string]bool{
+	"split":         true,
+	"split2":        true,
+	"disorder":      true,
+	"disorder2":     true,
+	"multisplit":    true,
+	"multidisorder": true,
+	"fakedsplit":    true,
+	"fakeddisorder": true,
+	"hostfakesplit": true,
+	"ipfrag1":       true,
+	"ipfrag2":       true,
+	"udplen":        true,
+	"tamper":        true,
+}
+
+// validateCopyRange validates queue_copy_range (0 copies whole packets).
+func validateCopyRange(n int) error {
+	if n != 0 && (n < minCopyRange || n > maxCopyRange) {
+		return fmt.Errorf("queue_copy_range must be 0 (whole packet) or between %d and %d", minCopyRange, maxCopyRange)
+	}
+	return nil
+}
+
+// desyncModes returns the desync modes a rule uses, sorted.
+func desyncModes(rule ParsedRule) []string {
+	seen := make(map[string]bool)
+	for _, value := range optionValues(parseNFQWSArgs(rule.NFQWSArgs), "--dpi-desync") {
+		for _, mode := range strings.Split(value, ",") {
+			if mode = strings.TrimSpace(mode); mode != "" {
+				seen[mode] = true
+			}
+		}
+	}
+
+	modes := make([]string, 0, len(seen))
+	for mode := range seen {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// copyRangeIssues returns warnings for the desync modes of rule that need
+// more bytes than copyRange (0 copies whole packets and never warns).
+func copyRangeIssues(rule ParsedRule, copyRange int) []string {
+	if copyRange == 0 {
+		return nil
+	}
+
+	var issues []string
+	for _, mode := range desyncModes(rule) {
+		need := headerCopyRange
+		if fullPayloadModes[mode] {
+			need = fullPacketCopyRange
+		}
+		if copyRange < need {
+			issues = append(issues, fmt.Sprintf("queue_copy_range %d is smaller than the %d bytes desync mode %s needs; packets reach nfqws truncated",
+				copyRange, need, mode))
+		}
+	}
+	return issues
+}
+
+// processCopyRange returns the copy range to pass to nfqws, 0 when none is
+// configured or the installed nfqws can't set it. Caller must hold r.mu.
+func (r *Runner) processCopyRange() int {
+	if r.config.QueueCopyRange == 0 {
+		return 0
+	}
+	supported, err := r.supportedOptions()
+	if err != nil || !supported[copyRangeOption] {
+		return 0
+	}
+	return r.config.QueueCopyRange
+}
+
+// checkCopyRange logs whether queue_copy_range takes effect and warns about
+// rules whose desync modes need more bytes. Caller must hold r.mu.
+func (r *Runner) checkCopyRange(rules []ParsedRule) {
+	if r.config.QueueCopyRange == 0 || r.externalProcesses() {
+		return
+	}
+	if r.processCopyRange() == 0 {
+		r.logger.Warn("installed nfqws can't set the queue copy range, whole packets are copied",
+			slog.Int("queue_copy_range", r.config.QueueCopyRange),
+			slog.String("option", copyRangeOption),
+		)
+		return
+	}
+
+	for _, rule := range rules {
+		if !rule.active() {
+			continue
+		}
+		for _, issue := range copyRangeIssues(rule, r.config.QueueCopyRange) {
+			r.logger.Warn("queue copy range too small for rule",
+				slog.Int("queue", rule.QueueNum),
+				slog.Int("line", rule.SourceLine),
+				slog.String("problem", issue),
+			)
+		}
+	}
+}
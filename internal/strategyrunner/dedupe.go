@@ -0,0 +1,46 @@
+package strategyrunner
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// ruleKey returns the identity of a rule used for deduplication.
+// Args are normalized through the same tokenizer used to launch nfqws,
+// so whitespace and quoting differences don't produce distinct keys.
+func ruleKey(rule ParsedRule) string {
+	return rule.Protocol + "|" + rule.Ports + "|" + strings.Join(parseNFQWSArgs(rule.NFQWSArgs), "\x00")
+}
+
+// dedupeRules collapses rules that are identical in protocol, ports and
+// normalized args. The first occurrence wins and queue numbers not pinned
+// with --qnum are reassigned sequentially so no gaps are left behind.
+func dedupeRules(rules []ParsedRule, logger *slog.Logger) []ParsedRule {
+	seen := make(map[string]int, len(rules))
+	result := make([]ParsedRule, 0, len(rules))
+
+	for _, rule := range rules {
+		key := ruleKey(rule)
+		if idx, ok := seen[key]; ok {
+			logger.Warn("dropping duplicate strategy rule",
+				slog.String("protocol", rule.Protocol),
+				slog.String("ports", rule.Ports),
+				slog.Int("line", rule.SourceLine),
+				slog.Int("duplicate_of_line", result[idx].SourceLine),
+			)
+			if rule.QueuePinned && !result[idx].QueuePinned {
+				// Keep the queue number the duplicate was pinned to
+				result[idx].QueueNum, result[idx].QueuePinned = rule.QueueNum, true
+			}
+			continue
+		}
+
+		seen[key] = len(result)
+		if !rule.QueuePinned {
+			rule.QueueNum = len(result)
+		}
+		result = append(result, rule)
+	}
+
+	return result
+}
@@ -0,0 +1,435 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ethtool"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// Diagnostic check states.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+// CheckResult is a finding of a diagnostic check.
+type CheckResult struct {
+	// Check is the name of the check that produced the result
+	Check string
+
+	// State is "pass", "warn", "fail" or "skip"
+	State string
+
+	// Message describes the finding
+	Message string
+
+	// Suggestion is a one-line fix ("" when there is nothing to fix)
+	Suggestion string
+}
+
+// DiagnosticCheck is a check run by RunDiagnostics. Checks reuse the probes
+// the runner uses at start and in its status, so they report what the
+// runner sees.
+type DiagnosticCheck struct {
+	// Name identifies the check
+	Name string
+
+	// Run returns the findings of the check. It takes the runner lock itself.
+	Run func(r *Runner) []CheckResult
+}
+
+// DiagnosticChecks are the checks run by RunDiagnostics, in order.
+var DiagnosticChecks = []DiagnosticCheck{
+	{Name: "privileges", Run: checkPrivileges},
+	{Name: "nfqws", Run: checkNFQWSBinary},
+	{Name: "interface", Run: checkInterfaces},
+	{Name: "kernel", Run: checkKernel},
+	{Name: "hostlists", Run: checkHostlistFiles},
+	{Name: "queues", Run: checkQueueBindings},
+	{Name: "memory", Run: checkProcessMemory},
+	{Name: "firewall", Run: checkFirewallTable},
+	{Name: "conflicts", Run: checkConflictingServices},
+	{Name: "offload", Run: checkOffloadFeatures},
+	{Name: "dns", Run: checkDNS},
+}
+
+// RunDiagnostics runs every diagnostic check and returns the findings.
+func (r *Runner) RunDiagnostics() []CheckResult {
+	return runChecks(r, DiagnosticChecks)
+}
+
+// runChecks runs checks in order, naming each result after its check.
+func runChecks(r *Runner, checks []DiagnosticCheck) []CheckResult {
+	var results []CheckResult
+	for _, check := range checks {
+		for _, res := range check.Run(r) {
+			res.Check = check.Name
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// checkPrivileges reports missing root privileges and capabilities.
+func checkPrivileges(r *Runner) []CheckResult {
+	var results []CheckResult
+	for _, p := range features.Privileges() {
+		if p.OK {
+			continue
+		}
+		results = append(results, CheckResult{
+			State:      CheckFail,
+			Message:    fmt.Sprintf("%s: %s", p.Name, p.Detail),
+			Suggestion: "run the daemon as root or grant CAP_NET_ADMIN and CAP_NET_RAW (AmbientCapabilities= in the systemd unit)",
+		})
+	}
+	if len(results) == 0 {
+		results = append(results, CheckResult{State: CheckPass, Message: "running with the required privileges"})
+	}
+	return results
+}
+
+// checkNFQWSBinary reports an nfqws binary that can't be run.
+func checkNFQWSBinary(r *Runner) []CheckResult {
+	caps := r.Capabilities()
+	if caps.ExternalProcesses {
+		return []CheckResult{{State: CheckSkip, Message: "nfqws is supervised externally"}}
+	}
+
+	info := caps.NFQWS
+	switch info.Kind {
+	case NFQWSKindNFQWS:
+		return []CheckResult{{State: CheckPass, Message: fmt.Sprintf("%s supports %d options", info.Binary, info.Options)}}
+	case NFQWSKindUnavailable:
+		return []CheckResult{{
+			State:      CheckFail,
+			Message:    fmt.Sprintf("%s can't be run: %s", info.Binary, info.Error),
+			Suggestion: "install nfqws or point strategy_runner.nfqws_binary at it",
+		}}
+	default:
+		return []CheckResult{{
+			State:      CheckWarn,
+			Message:    fmt.Sprintf("%s doesn't look like nfqws", info.Binary),
+			Suggestion: "point strategy_runner.nfqws_binary at the nfqws binary of zapret",
+		}}
+	}
+}
+
+// checkInterfaces reports configured interfaces that don't exist or are down.
+func checkInterfaces(r *Runner) []CheckResult {
+	r.mu.RLock()
+	ifaces := r.offloadInterfaces()
+	namespace := r.config.NetworkNamespace
+	r.mu.RUnlock()
+
+	if len(ifaces) == 0 {
+		return []CheckResult{{State: CheckPass, Message: "rules apply to all interfaces"}}
+	}
+
+	var results []CheckResult
+	err := netns.Do(namespace, func() error {
+		for _, name := range ifaces {
+			iface, err := net.InterfaceByName(name)
+			switch {
+			case err != nil:
+				results = append(results, CheckResult{
+					State:      CheckFail,
+					Message:    fmt.Sprintf("interface %s not found", name),
+					Suggestion: "set interface to one of: " + strings.Join(interfaceNames(), ", "),
+				})
+			case iface.Flags&net.FlagUp == 0:
+				results = append(results, CheckResult{
+					State:      CheckWarn,
+					Message:    fmt.Sprintf("interface %s is down", name),
+					Suggestion: fmt.Sprintf("ip link set %s up, or set interface to the one carrying traffic", name),
+				})
+			default:
+				results = append(results, CheckResult{State: CheckPass, Message: fmt.Sprintf("interface %s is up", name)})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return []CheckResult{{
+			State:      CheckFail,
+			Message:    fmt.Sprintf("cannot enter network namespace %s: %v", namespace, err),
+			Suggestion: "create the namespace or fix network_namespace",
+		}}
+	}
+	return results
+}
+
+// interfaceNames returns the names of the interfaces other than loopback.
+func interfaceNames() []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			names = append(names, iface.Name)
+		}
+	}
+	return names
+}
+
+// checkKernel reports missing kernel capabilities.
+func checkKernel(r *Runner) []CheckResult {
+	var results []CheckResult
+	for _, c := range r.Capabilities().Kernel {
+		switch c.State {
+		case CapAvailable:
+			results = append(results, CheckResult{State: CheckPass, Message: fmt.Sprintf("%s available", c.Name)})
+		case CapMissing:
+			results = append(results, CheckResult{
+				State:      CheckFail,
+				Message:    fmt.Sprintf("%s missing: %s", c.Name, c.Detail),
+				Suggestion: "modprobe " + c.Module,
+			})
+		default:
+			results = append(results, CheckResult{State: CheckWarn, Message: fmt.Sprintf("%s unknown: %s", c.Name, c.Detail)})
+		}
+	}
+	return results
+}
+
+// diagnosticRules returns the applied rules, or the rules a start would
+// apply while the runner is stopped.
+func (r *Runner) diagnosticRules() ([]ParsedRule, error) {
+	if rules := r.Rules(); len(rules) > 0 {
+		return rules, nil
+	}
+	plan, err := r.Plan()
+	if err != nil {
+		return nil, err
+	}
+	return plan.Rules, nil
+}
+
+// checkHostlistFiles reports list files of the rules that are missing or empty.
+func checkHostlistFiles(r *Runner) []CheckResult {
+	rules, err := r.diagnosticRules()
+	if err != nil {
+		return []CheckResult{{
+			State:      CheckFail,
+			Message:    err.Error(),
+			Suggestion: "fix the strategy file (zapret-daemon check shows every problem)",
+		}}
+	}
+
+	var results []CheckResult
+	seen := make(map[string]bool)
+	files := 0
+	for _, rule := range rules {
+		args := parseNFQWSArgs(rule.NFQWSArgs)
+		for _, opt := range fileOptions {
+			for _, path := range optionValues(args, opt) {
+				if seen[path] {
+					continue
+				}
+				seen[path] = true
+				files++
+
+				info, err := os.Stat(path)
+				switch {
+				case err != nil:
+					results = append(results, CheckResult{
+						State:      CheckFail,
+						Message:    fmt.Sprintf("line %d: %s is missing, the rule stays pending", rule.SourceLine, path),
+						Suggestion: "create the file or fix the path in the strategy",
+					})
+				case info.Size() == 0:
+					results = append(results, CheckResult{
+						State:      CheckWarn,
+						Message:    fmt.Sprintf("line %d: %s is empty, the rule matches no hosts", rule.SourceLine, path),
+						Suggestion: "add domains to the list or configure hostlist_update sources",
+					})
+				}
+			}
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, CheckResult{State: CheckPass, Message: fmt.Sprintf("%d list files present", files)})
+	}
+	return results
+}
+
+// checkQueueBindings reports queues with rules but no nfqws bound.
+func checkQueueBindings(r *Runner) []CheckResult {
+	if !r.GetStatus().Running {
+		return []CheckResult{{State: CheckSkip, Message: "strategy runner is not running"}}
+	}
+
+	queues, err := r.KernelQueues()
+	if err != nil {
+		return []CheckResult{{State: CheckWarn, Message: fmt.Sprintf("cannot read kernel queues: %v", err)}}
+	}
+	if r.queueState.Unavailable() {
+		return []CheckResult{{
+			State:   CheckWarn,
+			Message: fmt.Sprintf("%s is unavailable, queue bindings can't be verified", nfqueuePath),
+			Suggestion: "the nfnetlink_queue module loads when nfqws binds its first queue; if nfqws runs, " +
+				"load it with `modprobe nfnetlink_queue` (add it to /etc/modules-load.d to load at boot). " +
+				"Hardened kernels may hide /proc/net/netfilter; bindings are then never checked",
+		}}
+	}
+
+	var results []CheckResult
+	for _, q := range queues {
+		for _, problem := range q.Problems {
+			res := CheckResult{State: CheckWarn, Message: fmt.Sprintf("queue %d: %s", q.Queue, problem)}
+			if q.Installed && !q.Bound {
+				res.State = CheckFail
+				res.Suggestion = "zapret restart; if it persists check the nfqws log for why it exits"
+			}
+			results = append(results, res)
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, CheckResult{State: CheckPass, Message: fmt.Sprintf("%d queues bound", len(queues))})
+	}
+	return results
+}
+
+// checkProcessMemory reports nfqws processes killed by the OOM killer and
+// memory limits that aren't enforced.
+func checkProcessMemory(r *Runner) []CheckResult {
+	if !r.GetStatus().Running {
+		return []CheckResult{{State: CheckSkip, Message: "strategy runner is not running"}}
+	}
+
+	r.mu.RLock()
+	external := r.externalProcesses()
+	limits := make(map[int]int64, len(r.rules))
+	for _, rule := range r.rules {
+		limits[rule.QueueNum] = r.processMemoryLimit(rule)
+	}
+	r.mu.RUnlock()
+	if external {
+		return []CheckResult{{State: CheckSkip, Message: "process management is external"}}
+	}
+
+	var results []CheckResult
+	for _, proc := range r.procManager.Processes() {
+		if proc.OOMKills > 0 {
+			res := CheckResult{
+				State:      CheckWarn,
+				Message:    fmt.Sprintf("queue %d: nfqws was killed by the OOM killer %d times", proc.QueueNum, proc.OOMKills),
+				Suggestion: oomHint,
+			}
+			if proc.MemoryLimit > 0 {
+				res.Message += fmt.Sprintf(" (memory_limit %s)", formatMemory(proc.MemoryLimit))
+			}
+			if proc.Degraded && proc.LastExit == ExitOOM {
+				res.State = CheckFail
+				res.Message += ", no longer restarted"
+			}
+			results = append(results, res)
+		}
+		if limit := limits[proc.QueueNum]; limit > 0 && proc.MemoryLimit == 0 {
+			results = append(results, CheckResult{
+				State:      CheckWarn,
+				Message:    fmt.Sprintf("queue %d: memory_limit %s is not enforced", proc.QueueNum, formatMemory(limit)),
+				Suggestion: "memory limits need the cgroup v2 memory controller at process.cgroup_dir; see the daemon log for why the cgroup wasn't created",
+			})
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, CheckResult{State: CheckPass, Message: "no nfqws killed by the OOM killer"})
+	}
+	return results
+}
+
+// checkFirewallTable reports a firewall table removed behind the runner's
+// back, e.g. by firewalld flushing the ruleset on reload.
+func checkFirewallTable(r *Runner) []CheckResult {
+	r.mu.RLock()
+	running := r.running
+	external := r.externalFirewall()
+	backend := r.config.Firewall.Backend
+	table := r.config.Firewall.TableName
+	namespace := r.config.NetworkNamespace
+	r.mu.RUnlock()
+
+	switch {
+	case !running:
+		return []CheckResult{{State: CheckSkip, Message: "strategy runner is not running"}}
+	case external:
+		return []CheckResult{{State: CheckSkip, Message: "firewall rules are managed externally"}}
+	case backend != "nftables":
+		return []CheckResult{{State: CheckSkip, Message: fmt.Sprintf("not checked for the %s backend", backend)}}
+	}
+
+	tables, err := r.conflictSys.Tables(namespace)
+	if err != nil {
+		return []CheckResult{{State: CheckWarn, Message: err.Error()}}
+	}
+	if !slices.Contains(tables, table) {
+		return []CheckResult{{
+			State:      CheckFail,
+			Message:    fmt.Sprintf("table %s is gone, traffic is not queued", table),
+			Suggestion: "zapret restart; stop firewalld or other tools from flushing the nftables ruleset",
+		}}
+	}
+	return []CheckResult{{State: CheckPass, Message: fmt.Sprintf("table %s present", table)}}
+}
+
+// checkConflictingServices reports other zapret-family services.
+func checkConflictingServices(r *Runner) []CheckResult {
+	r.mu.RLock()
+	conflicts := r.detectConflicts()
+	r.mu.RUnlock()
+
+	var results []CheckResult
+	for _, c := range conflicts {
+		results = append(results, CheckResult{
+			State:      CheckWarn,
+			Message:    fmt.Sprintf("%s competes for NFQUEUE and firewall rules", c),
+			Suggestion: c.Remedy,
+		})
+	}
+	if len(results) == 0 {
+		results = append(results, CheckResult{State: CheckPass, Message: "no other zapret services found"})
+	}
+	return results
+}
+
+// checkOffloadFeatures reports offloads that defeat desync on the configured
+// interfaces.
+func checkOffloadFeatures(r *Runner) []CheckResult {
+	r.mu.RLock()
+	ifaces := r.offloadInterfaces()
+	dev := r.offloadDevice()
+	r.mu.RUnlock()
+
+	if len(ifaces) == 0 {
+		return []CheckResult{{State: CheckSkip, Message: "no specific interface configured"}}
+	}
+
+	var results []CheckResult
+	for _, iface := range ifaces {
+		found, err := ethtool.Check(dev, iface)
+		switch {
+		case err != nil:
+			results = append(results, CheckResult{State: CheckWarn, Message: fmt.Sprintf("%s: cannot check offloads: %v", iface, err)})
+		case len(found) > 0:
+			results = append(results, CheckResult{
+				State:      CheckWarn,
+				Message:    fmt.Sprintf("%s: %s enabled, nfqws may see coalesced packets", iface, strings.Join(ethtool.Names(found), ", ")),
+				Suggestion: ethtool.FixCommand(iface, found) + " (or set auto_fix_offload)",
+			})
+		default:
+			results = append(results, CheckResult{State: CheckPass, Message: fmt.Sprintf("%s: no offloads defeating desync", iface)})
+		}
+	}
+	return results
+}
@@ -0,0 +1,94 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunChecks(t *testing.T) {
+	var order []string
+	check := func(name string, results ...CheckResult) DiagnosticCheck {
+		return DiagnosticCheck{Name: name, Run: func(r *Runner) []CheckResult {
+			order = append(order, name)
+			return results
+		}}
+	}
+	checks := []DiagnosticCheck{
+		check("first", CheckResult{State: CheckPass, Message: "ok"}),
+		check("silent"),
+		// A check can't claim another check's name
+		check("second",
+			CheckResult{Check: "first", State: CheckWarn, Message: "odd", Suggestion: "look"},
+			CheckResult{State: CheckFail, Message: "broken", Suggestion: "fix"}),
+		check("third", CheckResult{State: CheckSkip, Message: "not running"}),
+	}
+
+	got := runChecks(&Runner{}, checks)
+	if want := []string{"first", "silent", "second", "third"}; !slices.Equal(order, want) {
+		t.Errorf("checks ran in order %v, want %v", order, want)
+	}
+	want := []CheckResult{
+		{Check: "first", State: CheckPass, Message: "ok"},
+		{Check: "second", State: CheckWarn, Message: "odd", Suggestion: "look"},
+		{Check: "second", State: CheckFail, Message: "broken", Suggestion: "fix"},
+		{Check: "third", State: CheckSkip, Message: "not running"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("runChecks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiagnosticChecksRegistry(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, check := range DiagnosticChecks {
+		if check.Name == "" || check.Run == nil {
+			t.Errorf("incomplete check %+v", check)
+		}
+		if seen[check.Name] {
+			t.Errorf("check %s registered twice", check.Name)
+		}
+		seen[check.Name] = true
+	}
+}
+
+func TestCheckHostlistFiles(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	lists := t.TempDir()
+	present, empty, missing := filepath.Join(lists, "present.txt"), filepath.Join(lists, "empty.txt"), filepath.Join(lists, "missing.txt")
+	writeFiles(t, map[string]string{present: "a.example\n", empty: ""})
+	tr.writeStrategy(t, fmt.Sprintf(`--filter-tcp=443 --hostlist=%s --hostlist=%s --dpi-desync=fake --new
+--filter-udp=443 --hostlist=%s --hostlist=%s --dpi-desync=fake
+`, present, empty, missing, present))
+
+	// A stopped runner checks the rules a start would apply
+	got := checkHostlistFiles(tr.Runner)
+	if len(got) != 2 {
+		t.Fatalf("checkHostlistFiles() = %+v, want the empty and the missing list", got)
+	}
+	if got[0].State != CheckWarn || !strings.Contains(got[0].Message, empty) || got[0].Suggestion == "" {
+		t.Errorf("empty list = %+v, want a warning with a fix", got[0])
+	}
+	if got[1].State != CheckFail || !strings.Contains(got[1].Message, missing) || got[1].Suggestion == "" {
+		t.Errorf("missing list = %+v, want a failure with a fix", got[1])
+	}
+
+	if err := os.WriteFile(missing, []byte("b.example\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(empty, []byte("c.example\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got = checkHostlistFiles(tr.Runner)
+	if want := []CheckResult{{State: CheckPass, Message: "3 list files present"}}; !slices.Equal(got, want) {
+		t.Errorf("checkHostlistFiles() = %+v, want %+v", got, want)
+	}
+
+	tr.writeStrategy(t, "rem no rules\n")
+	if got := checkHostlistFiles(tr.Runner); len(got) != 1 || got[0].State != CheckFail {
+		t.Errorf("checkHostlistFiles() = %+v with a broken strategy, want a failure", got)
+	}
+}
@@ -0,0 +1,88 @@
+package strategyrunner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/dnsprobe"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/hostlist"
+)
+
+// ProbeDNS resolves domain with the system resolver and the configured DoH
+// server and compares the answers. It doesn't depend on the runner running.
+func (r *Runner) ProbeDNS(ctx context.Context, domain string) (*dnsprobe.Result, error) {
+	domain = hostlist.Normalize(domain)
+	if domain == "" {
+		return nil, fmt.Errorf("domain must not be empty")
+	}
+
+	r.mu.RLock()
+	probeConfig := r.config.DNSProbe
+	r.mu.RUnlock()
+
+	cfg, err := probeConfig.proberConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns_probe config: %w", err)
+	}
+	prober, err := dnsprobe.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns_probe config: %w", err)
+	}
+	return prober.Probe(ctx, domain), nil
+}
+
+// checkDNS reports test domains whose system resolver answers are poisoned.
+func checkDNS(r *Runner) []CheckResult {
+	r.mu.RLock()
+	probeConfig := r.config.DNSProbe
+	r.mu.RUnlock()
+
+	if !probeConfig.Enabled {
+		return []CheckResult{{State: CheckSkip, Message: "DNS probe disabled (dns_probe.enabled)"}}
+	}
+
+	if len(probeConfig.Domains) == 0 {
+		return []CheckResult{{State: CheckSkip, Message: "no domains to probe (dns_probe.domains)"}}
+	}
+
+	// Probe at once, so the check takes one probe timeout at most
+	results := make([]CheckResult, len(probeConfig.Domains))
+	var wg sync.WaitGroup
+	for i, domain := range probeConfig.Domains {
+		wg.Go(func() {
+			result, err := r.ProbeDNS(context.Background(), domain)
+			if err != nil {
+				results[i] = CheckResult{State: CheckFail, Message: err.Error(), Suggestion: "fix dns_probe in the strategy config"}
+				return
+			}
+			results[i] = DNSCheckResult(result)
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// DNSCheckResult converts a probe result to a diagnostic finding, with the
+// state and suggested fix `zapret doctor` reports for it.
+func DNSCheckResult(result *dnsprobe.Result) CheckResult {
+	res := CheckResult{Message: fmt.Sprintf("%s: %s", result.Domain, result.Detail)}
+	switch result.Verdict {
+	case dnsprobe.VerdictOK:
+		res.State = CheckPass
+	case dnsprobe.VerdictBogus:
+		res.State = CheckFail
+		res.Suggestion = dnsPoisoningSuggestion
+	case dnsprobe.VerdictMismatch:
+		res.State = CheckWarn
+		res.Suggestion = dnsPoisoningSuggestion
+	default:
+		res.State = CheckWarn
+		res.Suggestion = "check network connectivity and dns_probe.doh_url"
+	}
+	return res
+}
+
+// dnsPoisoningSuggestion is the fix suggested for poisoned DNS answers.
+const dnsPoisoningSuggestion = "the resolver's answers are tampered with, which no strategy fixes: " +
+	"use encrypted DNS (DoH/DoT in the browser, DNSOverTLS= in systemd-resolved) or another resolver"
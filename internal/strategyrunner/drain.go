@@ -0,0 +1,151 @@
+package strategyrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// drainPollInterval is how often queue lengths are read while draining.
+const drainPollInterval = 50 * time.Millisecond
+
+// queueBindTimeout bounds waiting for a replaced nfqws to bind its queue.
+const queueBindTimeout = 5 * time.Second
+
+// drainQueues waits until the kernel reports no packets waiting in queues, or
+// until timeout or ctx expires. read returns the kernel queues of a
+// namespace. It returns the lengths of the queues still
+// holding packets, nil once all are drained.
+func drainQueues(ctx context.Context, read func() ([]KernelQueue, error), queues []int, timeout time.Duration) (map[int]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wanted := make(map[int]bool, len(queues))
+	for _, q := range queues {
+		wanted[q] = true
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		kernel, err := read()
+		if err != nil {
+			return nil, err
+		}
+		waiting := make(map[int]int)
+		for _, q := range kernel {
+			if wanted[q.Queue] && q.Length > 0 {
+				waiting[q.Queue] = q.Length
+			}
+		}
+		if len(waiting) == 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return waiting, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// drainAppliedQueues lets nfqws give a verdict to the packets already queued
+// after the firewall stopped feeding the queues, so stopping it doesn't drop
+// them. Caller must hold r.mu.
+func (r *Runner) drainAppliedQueues(ctx context.Context) {
+	var queues []int
+	for _, rule := range r.rules {
+		if rule.active() && !rule.ScheduledOff {
+			queues = append(queues, rule.QueueNum)
+		}
+	}
+	r.drainRuleQueues(ctx, queues)
+}
+
+// drainRuleQueues waits up to queue_drain_timeout for queues to be empty.
+// Caller must hold r.mu.
+func (r *Runner) drainRuleQueues(ctx context.Context, queues []int) {
+	timeout := r.config.QueueDrainTimeout
+	if timeout <= 0 || len(queues) == 0 {
+		return
+	}
+
+	namespace := r.config.NetworkNamespace
+	start := time.Now()
+	waiting, err := drainQueues(ctx, func() ([]KernelQueue, error) { return r.queueState.Queues(namespace) }, queues, timeout)
+	switch {
+	case errors.Is(err, ErrKernelQueuesUnavailable):
+		// Logged once by queueState
+	case err != nil:
+		r.logger.Debug("cannot read queue lengths, not draining", slog.Any("error", err))
+	case len(waiting) > 0:
+		r.logger.Warn("queues still hold packets, stopping nfqws anyway",
+			slog.Any("queues", waiting),
+			slog.Duration("queue_drain_timeout", timeout),
+		)
+	default:
+		r.logger.Debug("queues drained", slog.Duration("took", time.Since(start)))
+	}
+}
+
+// waitQueuesBound waits until the kernel lists queues as bound, or until
+// timeout or ctx expires. read returns the kernel queues of a namespace. It
+// returns the queues still unbound, nil once all are bound.
+func waitQueuesBound(ctx context.Context, read func() ([]KernelQueue, error), queues []int, timeout time.Duration) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		kernel, err := read()
+		if err != nil {
+			return nil, err
+		}
+		bound := make(map[int]bool, len(kernel))
+		for _, q := range kernel {
+			bound[q.Queue] = true
+		}
+		var unbound []int
+		for _, q := range queues {
+			if !bound[q] {
+				unbound = append(unbound, q)
+			}
+		}
+		if len(unbound) == 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return unbound, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// confirmBound waits up to the bind timeout for the nfqws of queue to bind
+// it, so the firewall doesn't feed a queue without a consumer. The old
+// consumer has exited by then, so a listed queue is bound by the new one.
+// Without the kernel queue state nothing can be confirmed and nil is
+// returned. Caller must hold r.mu.
+func (r *Runner) confirmBound(ctx context.Context, queue int) error {
+	namespace := r.config.NetworkNamespace
+	unbound, err := waitQueuesBound(ctx, func() ([]KernelQueue, error) { return r.queueState.Queues(namespace) }, []int{queue}, r.bindTimeout)
+	switch {
+	case errors.Is(err, ErrKernelQueuesUnavailable):
+		// Logged once by queueState
+		return nil
+	case err != nil:
+		r.logger.Debug("cannot read bound queues, not confirming the bind", slog.Any("error", err))
+		return nil
+	case len(unbound) > 0:
+		return fmt.Errorf("nfqws didn't bind queue %d within %s", queue, r.bindTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Empty ruleset policies.
+const (
+	// EmptyRulesetError fails a (re)start that would apply no rules; a reload
+	// keeps the running rules
+	EmptyRulesetError = "error"
+
+	// EmptyRulesetWarn applies nothing but reports the runner as degraded
+	EmptyRulesetWarn = "warn"
+
+	// EmptyRulesetAllow applies nothing and reports the runner as healthy, for
+	// a daemon deliberately kept as a placeholder
+	EmptyRulesetAllow = "allow"
+)
+
+// ErrEmptyRuleset is returned when no rules are left to apply under the
+// error policy.
+var ErrEmptyRuleset = errors.New("no rules left to apply after filters, overrides and compatibility checks")
+
+// emptyRulesetReason is the health reason reported under the warn policy.
+const emptyRulesetReason = "no rules applied (empty_ruleset_policy: warn)"
+
+// validateEmptyRulesetPolicy validates the empty_ruleset_policy option.
+func validateEmptyRulesetPolicy(policy string) error {
+	switch policy {
+	case EmptyRulesetError, EmptyRulesetWarn, EmptyRulesetAllow:
+		return nil
+	}
+	return fmt.Errorf("invalid empty_ruleset_policy: %s (must be '%s', '%s' or '%s')",
+		policy, EmptyRulesetError, EmptyRulesetWarn, EmptyRulesetAllow)
+}
+
+// appliedRules counts the rules that will be applied: selected by the filter
+// and not failed. Pending and scheduled rules count, they are applied later.
+func appliedRules(rules []ParsedRule) int {
+	applied := 0
+	for _, rule := range rules {
+		if !rule.FilteredOut && rule.CompatError == "" {
+			applied++
+		}
+	}
+	return applied
+}
+
+// checkEmptyRuleset fails under the error policy if no rules will be applied.
+func checkEmptyRuleset(rules []ParsedRule, policy string) error {
+	if policy == EmptyRulesetError && appliedRules(rules) == 0 {
+		return fmt.Errorf("%w (%d parsed, set empty_ruleset_policy to warn or allow to start anyway)", ErrEmptyRuleset, len(rules))
+	}
+	return nil
+}
+
+// setEmptyRuleset records whether rules leave nothing to apply, warning about
+// it under the warn policy. Caller must hold r.mu.
+func (r *Runner) setEmptyRuleset(rules []ParsedRule) {
+	r.emptyRuleset = appliedRules(rules) == 0
+	if r.emptyRuleset && r.config.EmptyRulesetPolicy == EmptyRulesetWarn {
+		r.logger.Warn("no rules left to apply, the runner does nothing",
+			slog.Int("parsed", len(rules)),
+			slog.String("empty_ruleset_policy", r.config.EmptyRulesetPolicy),
+		)
+		r.events.Add("empty_ruleset", fmt.Sprintf("no rules applied (%d parsed)", len(rules)))
+	}
+}
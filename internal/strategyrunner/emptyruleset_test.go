@@ -0,0 +1,164 @@
+package strategyrunner
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestValidateEmptyRulesetPolicy(t *testing.T) {
+	tests := []struct {
+		policy  string
+		wantErr bool
+	}{
+		{policy: EmptyRulesetError},
+		{policy: EmptyRulesetWarn},
+		{policy: EmptyRulesetAllow},
+		{policy: "", wantErr: true},
+		{policy: "ignore", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateEmptyRulesetPolicy(tt.policy); (err != nil) != tt.wantErr {
+			t.Errorf("validateEmptyRulesetPolicy(%q) error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckEmptyRuleset(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []ParsedRule
+		empty bool // whether no rule is applied
+	}{
+		{name: "no rules", empty: true},
+		{name: "filtered out", rules: []ParsedRule{{FilteredOut: true}, {FilteredOut: true}}, empty: true},
+		{name: "failed", rules: []ParsedRule{{CompatError: "unsupported nfqws options: --dup"}}, empty: true},
+		{name: "filtered out and failed", rules: []ParsedRule{{FilteredOut: true}, {CompatError: "unsupported"}}, empty: true},
+		{name: "one applied", rules: []ParsedRule{{FilteredOut: true}, {}}},
+		// Pending and scheduled rules are applied later
+		{name: "pending", rules: []ParsedRule{{MissingFiles: []string{"list.txt"}}}},
+		{name: "scheduled off", rules: []ParsedRule{{ScheduledOff: true}}},
+	}
+
+	for _, tt := range tests {
+		for _, policy := range []string{EmptyRulesetError, EmptyRulesetWarn, EmptyRulesetAllow} {
+			err := checkEmptyRuleset(tt.rules, policy)
+			if want := tt.empty && policy == EmptyRulesetError; errors.Is(err, ErrEmptyRuleset) != want {
+				t.Errorf("%s: checkEmptyRuleset(%s) error = %v, want an error %v", tt.name, policy, err, want)
+			}
+		}
+	}
+}
+
+// emptyRulesetCases are the outcomes of a start or reload leaving nothing to
+// apply under each policy.
+var emptyRulesetCases = []struct {
+	policy     string
+	wantErr    bool
+	wantHealth string
+	wantEvent  bool // whether empty_ruleset is reported
+}{
+	{policy: EmptyRulesetError, wantErr: true},
+	{policy: EmptyRulesetWarn, wantHealth: HealthDegraded, wantEvent: true},
+	{policy: EmptyRulesetAllow, wantHealth: HealthHealthy},
+}
+
+// checkEmptyRun fails the test unless tr runs nothing, reported with
+// wantHealth and, if wantEvent, an empty_ruleset event.
+func (tr *testRunner) checkEmptyRun(t *testing.T, wantHealth string, wantEvent bool) {
+	t.Helper()
+	status := tr.GetStatus()
+	if !status.Running || !status.EmptyRuleset || status.ActiveProcesses != 0 || status.FirewallRules != 0 {
+		t.Errorf("status: running %v, empty ruleset %v, %d processes, %d firewall rules, want running nothing",
+			status.Running, status.EmptyRuleset, status.ActiveProcesses, status.FirewallRules)
+	}
+	if got := tr.fw.queues(); len(got) != 0 {
+		t.Errorf("firewall rules on queues %v, want none", got)
+	}
+	snap := tr.Snapshot(t.Context(), SnapshotHealth|SnapshotEvents, 0)
+	if snap.Health != wantHealth {
+		t.Errorf("health = %s (%s), want %s", snap.Health, snap.HealthReason, wantHealth)
+	}
+	if wantHealth == HealthDegraded && snap.HealthReason != emptyRulesetReason {
+		t.Errorf("health reason = %q, want %q", snap.HealthReason, emptyRulesetReason)
+	}
+	if got := slices.ContainsFunc(snap.Events, func(e Event) bool { return e.Kind == "empty_ruleset" }); got != wantEvent {
+		t.Errorf("empty_ruleset event reported %v, want %v", got, wantEvent)
+	}
+}
+
+func TestRunnerEmptyRulesetStart(t *testing.T) {
+	for _, tt := range emptyRulesetCases {
+		t.Run(tt.policy, func(t *testing.T) {
+			// Both rules fail on options the stub nfqws lacks
+			tr := newTestRunner(t, compatStrategy, "compat_mode: strict\nempty_ruleset_policy: "+tt.policy+"\n")
+			err := tr.Start(t.Context())
+			if tt.wantErr {
+				if !errors.Is(err, ErrEmptyRuleset) {
+					t.Fatalf("Start() error = %v, want %v", err, ErrEmptyRuleset)
+				}
+				if status := tr.GetStatus(); status.Running || status.ActiveProcesses != 0 {
+					t.Errorf("status after the failed start: running %v, %d processes", status.Running, status.ActiveProcesses)
+				}
+				if got := tr.fw.queues(); len(got) != 0 {
+					t.Errorf("firewall rules after the failed start: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			tr.checkEmptyRun(t, tt.wantHealth, tt.wantEvent)
+		})
+	}
+}
+
+func TestRunnerEmptyRulesetReload(t *testing.T) {
+	for _, tt := range emptyRulesetCases {
+		for _, full := range []bool{false, true} {
+			name := tt.policy + "/in place"
+			if full {
+				name = tt.policy + "/full"
+			}
+			t.Run(name, func(t *testing.T) {
+				tr := newTestRunner(t, testStrategy, "compat_mode: strict\nempty_ruleset_policy: "+tt.policy+"\n")
+				if err := tr.Start(t.Context()); err != nil {
+					t.Fatalf("Start() error = %v", err)
+				}
+				pids, queues := tr.runningPIDs(), tr.fw.queues()
+				args := tr.ruleArgs()
+
+				// The new strategy has only rules failing in strict mode
+				tr.writeStrategy(t, compatStrategy)
+				err := tr.RestartFiltered(t.Context(), nil, full)
+				if tt.wantErr {
+					if !errors.Is(err, ErrEmptyRuleset) {
+						t.Fatalf("Restart() error = %v, want %v", err, ErrEmptyRuleset)
+					}
+					// The running rules are kept as they were
+					if got := tr.runningPIDs(); !slices.Equal(got, pids) {
+						t.Errorf("running processes %v, want %v kept", got, pids)
+					}
+					if got := tr.fw.queues(); !slices.Equal(got, queues) {
+						t.Errorf("firewall rules on queues %v, want %v kept", got, queues)
+					}
+					if got := tr.ruleArgs(); !maps.Equal(got, args) {
+						t.Errorf("rules %v, want %v kept", got, args)
+					}
+					if snap := tr.Snapshot(t.Context(), SnapshotStatus|SnapshotHealth, 0); snap.Health != HealthHealthy || snap.Status.EmptyRuleset {
+						t.Errorf("health = %s (%s), empty ruleset %v, want the old rules healthy", snap.Health, snap.HealthReason, snap.Status.EmptyRuleset)
+					}
+					tr.checkConsistent(t)
+					return
+				}
+				if err != nil {
+					t.Fatalf("Restart() error = %v", err)
+				}
+				tr.checkEmptyRun(t, tt.wantHealth, tt.wantEvent)
+				tr.checkConsistent(t)
+			})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package strategyrunner
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// eventLogSize is the number of recent events kept in memory
+	eventLogSize = 256
+
+	// reloadHistorySize is the number of recent reloads kept in memory
+	reloadHistorySize = 16
+)
+
+// Event is a notable change in the runner lifecycle.
+type Event struct {
+	// Seq is the event sequence number, increasing from 1
+	Seq uint64
+
+	// Time is when the event happened
+	Time time.Time
+
+	// Kind is the event kind ("started", "stopped", "reload", "reload_failed")
+	Kind string
+
+	// Message describes the event
+	Message string
+}
+
+// EventLog keeps a bounded list of recent events.
+type EventLog struct {
+	mu     sync.Mutex
+	events []Event
+	seq    uint64
+}
+
+// NewEventLog creates an empty event log.
+func NewEventLog() *EventLog {
+	return &EventLog{}
+}
+
+// Add appends an event, dropping the oldest one when the log is full.
+func (l *EventLog) Add(kind, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	l.events = append(l.events, Event{
+		Seq:     l.seq,
+		Time:    time.Now(),
+		Kind:    kind,
+		Message: message,
+	})
+	if len(l.events) > eventLogSize {
+		l.events = l.events[len(l.events)-eventLogSize:]
+	}
+}
+
+// Since returns the events after cursor and the cursor to pass on the next call.
+func (l *EventLog) Since(cursor uint64) ([]Event, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []Event
+	for _, e := range l.events {
+		if e.Seq > cursor {
+			events = append(events, e)
+		}
+	}
+	return events, l.seq
+}
+
+// ReloadRecord describes a single runner reload.
+type ReloadRecord struct {
+	// Time is when the reload finished
+	Time time.Time
+
+	// Triggers lists what requested the reload
+	Triggers []string
+
+	// Duration is how long the reload took
+	Duration time.Duration
+
+	// Err is the reload error, empty on success
+	Err string
+}
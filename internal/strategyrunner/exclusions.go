@@ -0,0 +1,88 @@
+package strategyrunner
+
+import (
+	"log/slog"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ports"
+)
+
+// selectorPorts returns the port spec overrides and exports select the rule
+// by: the spec before any ports were excluded.
+func (r *ParsedRule) selectorPorts() string {
+	if r.PortsResolved != "" {
+		return r.PortsResolved
+	}
+	return r.Ports
+}
+
+// joinPortSpecs joins port specs into one normalized spec, skipping empty ones.
+func joinPortSpecs(specs ...string) string {
+	var joined string
+	for _, spec := range specs {
+		switch {
+		case spec == "":
+		case joined == "":
+			joined = spec
+		default:
+			joined += "," + spec
+		}
+	}
+	if set, err := ports.Parse(joined); err == nil {
+		return set.String()
+	}
+	return joined
+}
+
+// excludePorts returns the port specs of rule after removing the
+// exclude_ports of its overrides, then the never_queue ports of its
+// protocol. The second is the applied spec, "" when no port is left.
+// CheckPort traces the same two steps.
+func excludePorts(rule *ParsedRule, neverQueue string) (excluded, applied string, err error) {
+	if excluded, err = ports.Exclude(rule.selectorPorts(), rule.ExcludedPorts); err != nil || excluded == "" {
+		return excluded, excluded, err
+	}
+	applied, err = ports.Exclude(excluded, neverQueue)
+	return excluded, applied, err
+}
+
+// applyExclusions removes the exclude_ports of overrides and the
+// never_queue ports from the rules and returns the rules with ports left.
+// A rule excluding all of its ports is dropped with a warning.
+func applyExclusions(rules []ParsedRule, neverQueue NeverQueueConfig, aliases PortAliases, logger *slog.Logger) []ParsedRule {
+	never := make(map[string]string, 2)
+	for _, protocol := range []string{"tcp", "udp"} {
+		// Validated when the config was loaded
+		never[protocol], _, _ = aliases.Resolve(neverQueue.Ports(protocol), protocol)
+	}
+
+	result := make([]ParsedRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ExcludedPorts == "" && never[rule.Protocol] == "" {
+			result = append(result, rule)
+			continue
+		}
+
+		_, applied, err := excludePorts(&rule, never[rule.Protocol])
+		switch {
+		case err != nil:
+			logger.Warn("cannot exclude ports from rule, rule keeps its ports",
+				slog.Int("line", rule.SourceLine),
+				slog.String("ports", rule.Ports),
+				slog.String("error", err.Error()),
+			)
+		case applied == "":
+			logger.Warn("rule has no ports left after exclusions, rule dropped",
+				slog.String("protocol", rule.Protocol),
+				slog.String("ports", rule.Ports),
+				slog.Int("line", rule.SourceLine),
+				slog.String("excluded", rule.ExcludedPorts),
+				slog.String("never_queue", never[rule.Protocol]),
+			)
+			continue
+		case applied != rule.Ports:
+			rule.PortsResolved, rule.Ports = rule.Ports, applied
+		}
+		result = append(result, rule)
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/hostlist"
+)
+
+// DomainMatch describes how a rule relates to a domain.
+type DomainMatch struct {
+	// Rule is the evaluated rule
+	Rule ParsedRule
+
+	// Matched indicates the rule would handle the domain
+	Matched bool
+
+	// Reason explains why the rule matched or was skipped
+	Reason string
+}
+
+// ExplainDomain evaluates the applied rules against a domain in nfqws evaluation order.
+// It returns matching rules first-to-last, plus near misses where the domain was excluded.
+func (r *Runner) ExplainDomain(domain string) ([]DomainMatch, error) {
+	domain = hostlist.Normalize(domain)
+	if domain == "" {
+		return nil, fmt.Errorf("domain must not be empty")
+	}
+
+	var result []DomainMatch
+	for _, rule := range r.Rules() {
+		match, err := r.explainRule(rule, domain)
+		if err != nil {
+			return nil, err
+		}
+		if match != nil {
+			result = append(result, *match)
+		}
+	}
+
+	return result, nil
+}
+
+// explainRule checks a single rule's hostlist filters against domain.
+// It returns nil when the rule's include lists simply don't contain the domain.
+func (r *Runner) explainRule(rule ParsedRule, domain string) (*DomainMatch, error) {
+	args := parseNFQWSArgs(rule.NFQWSArgs)
+
+	// Exclusions take precedence over inclusions in nfqws
+	excludes, err := r.ruleLists(args, "--hostlist-exclude", "--hostlist-exclude-domains")
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range excludes {
+		if entry, ok := list.Match(domain); ok {
+			return &DomainMatch{
+				Rule:   rule,
+				Reason: fmt.Sprintf("excluded by %s (entry %s)", list.Path, entry),
+			}, nil
+		}
+	}
+
+	includes, err := r.ruleLists(args, "--hostlist", "--hostlist-domains")
+	if err != nil {
+		return nil, err
+	}
+	autoLists := optionValues(args, "--hostlist-auto")
+	for _, path := range autoLists {
+		list, err := r.hostlists.Get(path)
+		if err != nil {
+			// Auto hostlists may not exist until nfqws learns its first domain
+			continue
+		}
+		includes = append(includes, list)
+	}
+
+	if len(includes) == 0 && len(autoLists) == 0 {
+		return &DomainMatch{
+			Rule:    rule,
+			Matched: true,
+			Reason:  "rule has no hostlist filter and matches all hosts",
+		}, nil
+	}
+
+	for _, list := range includes {
+		if entry, ok := list.Match(domain); ok {
+			return &DomainMatch{
+				Rule:    rule,
+				Matched: true,
+				Reason:  fmt.Sprintf("listed in %s (entry %s)", list.Path, entry),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ruleLists loads the hostlist files and inline domain lists referenced by the given options.
+func (r *Runner) ruleLists(args []string, fileOpt, domainsOpt string) ([]*hostlist.List, error) {
+	var lists []*hostlist.List
+
+	for _, path := range optionValues(args, fileOpt) {
+		list, err := r.hostlists.Get(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load hostlist %s: %w", path, err)
+		}
+		lists = append(lists, list)
+	}
+
+	for _, domains := range optionValues(args, domainsOpt) {
+		lists = append(lists, hostlist.NewInline(strings.Split(domains, ",")))
+	}
+
+	return lists, nil
+}
+
+// HostlistMemory returns an estimate of the memory used by loaded hostlists.
+func (r *Runner) HostlistMemory() int64 {
+	return r.hostlists.MemoryBytes()
+}
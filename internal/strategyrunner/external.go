@@ -0,0 +1,85 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Management modes of processes and firewall rules.
+const (
+	// ManagementManaged means the runner creates and removes the resource
+	ManagementManaged = "managed"
+
+	// ManagementExternal means the resource is handled outside zapret-ng
+	ManagementExternal = "external"
+)
+
+// validateManagement validates a management mode.
+func validateManagement(name, mode string) error {
+	if mode != ManagementManaged && mode != ManagementExternal {
+		return fmt.Errorf("invalid %s: %s (must be '%s' or '%s')", name, mode, ManagementManaged, ManagementExternal)
+	}
+	return nil
+}
+
+// externalProcesses reports whether nfqws processes are managed outside the runner.
+func (r *Runner) externalProcesses() bool {
+	return r.config.ProcessManagement == ManagementExternal
+}
+
+// externalFirewall reports whether firewall rules are managed outside the runner.
+func (r *Runner) externalFirewall() bool {
+	return r.config.FirewallManagement == ManagementExternal
+}
+
+// boundQueues returns the queue numbers that have a consumer bound in the
+// network namespace of the runner. Caller must hold r.mu.
+func (r *Runner) boundQueues() (map[int]bool, error) {
+	queues, err := r.queueState.Queues(r.config.NetworkNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := make(map[int]bool, len(queues))
+	for _, q := range queues {
+		bound[q.Queue] = true
+	}
+	return bound, nil
+}
+
+// unboundQueues returns the queues of active rules without a bound consumer.
+// Caller must hold r.mu.
+func (r *Runner) unboundQueues() ([]int, error) {
+	bound, err := r.boundQueues()
+	if err != nil {
+		return nil, err
+	}
+
+	var unbound []int
+	for _, rule := range r.rules {
+		if rule.active() && !bound[rule.QueueNum] {
+			unbound = append(unbound, rule.QueueNum)
+		}
+	}
+	sort.Ints(unbound)
+	return unbound, nil
+}
+
+// consumers returns the number of queues served by nfqws: running processes,
+// or bound queues when processes are external, -1 if the kernel doesn't
+// expose which queues are bound. Caller must hold r.mu.
+func (r *Runner) consumers(processes int) int {
+	if !r.externalProcesses() || !r.running {
+		return processes
+	}
+
+	unbound, err := r.unboundQueues()
+	if errors.Is(err, ErrKernelQueuesUnavailable) {
+		return -1
+	}
+	if err != nil {
+		return 0
+	}
+	return len(r.rules) - r.pendingCount() - len(unbound)
+}
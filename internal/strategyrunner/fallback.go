@@ -0,0 +1,59 @@
+package strategyrunner
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// Strategy sources reported in the status.
+const (
+	// StrategySourceFile is the configured strategy file
+	StrategySourceFile = "file"
+
+	// StrategySourceFallback is the embedded minimal strategy applied while
+	// the configured strategy file is missing
+	StrategySourceFallback = "embedded-fallback"
+)
+
+// fallbackStrategy is a minimal known-good strategy. Its %LISTS% refers to
+// the directory the embedded hostlist is written to.
+//
+//go:embed fallback/strategy.bat
+var fallbackStrategy []byte
+
+// fallbackHostlist is the small hostlist used by fallbackStrategy.
+//
+//go:embed fallback/list-fallback.txt
+var fallbackHostlist []byte
+
+// resolveStrategy returns the strategy file to apply for cfg and its source.
+// With fallback_strategy enabled a missing strategy file is replaced by the
+// embedded strategy, written to the volatile state directory so it goes
+// through the same parse pipeline as a real file.
+func resolveStrategy(cfg *Config) (string, string, error) {
+	_, err := os.Stat(cfg.StrategyFile)
+	if err == nil || !cfg.FallbackStrategy || !os.IsNotExist(err) {
+		return cfg.StrategyFile, StrategySourceFile, nil
+	}
+
+	dir := filepath.Join(cfg.State.VolatileDir, "fallback")
+	if !statepaths.Writable(dir) {
+		dir = filepath.Join(os.TempDir(), "zapret-fallback")
+	}
+
+	if err := statepaths.WriteAtomic(filepath.Join(dir, "list-fallback.txt"), fallbackHostlist, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write fallback hostlist: %w", err)
+	}
+
+	strategy := bytes.ReplaceAll(fallbackStrategy, []byte("%LISTS%"), []byte(dir+string(filepath.Separator)))
+	path := filepath.Join(dir, "strategy.bat")
+	if err := statepaths.WriteAtomic(path, strategy, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write fallback strategy: %w", err)
+	}
+	return path, StrategySourceFallback, nil
+}
@@ -0,0 +1,8 @@
+@echo off
+:: Minimal fallback strategy embedded in zapret-ng. It is applied only when
+:: the configured strategy file is missing and fallback_strategy is enabled.
+:: Conservative desync for a small built-in hostlist; configure a real
+:: strategy for full coverage.
+--filter-tcp=80 --hostlist="%LISTS%list-fallback.txt" --dpi-desync=fake,split2 --dpi-desync-fooling=md5sig --new
+--filter-tcp=443 --hostlist="%LISTS%list-fallback.txt" --dpi-desync=fake,split2 --dpi-desync-repeats=6 --dpi-desync-fooling=md5sig --new
+--filter-udp=443 --hostlist="%LISTS%list-fallback.txt" --dpi-desync=fake --dpi-desync-repeats=6
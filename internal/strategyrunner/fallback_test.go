@@ -0,0 +1,115 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRunnerFallbackStrategy starts the runner with the strategy file
+// missing: with fallback_strategy the embedded strategy is applied until a
+// real file replaces it, without it the start fails and nothing is applied.
+func TestRunnerFallbackStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "enabled", enabled: true},
+		{name: "disabled", enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestRunner(t, testStrategy, fmt.Sprintf("fallback_strategy: %v\n", tt.enabled))
+			if err := os.Remove(tr.strategy); err != nil {
+				t.Fatal(err)
+			}
+			// The fallback strategy fools DPI with bad checksums
+			tr.replaceBinary(t, strings.Replace(testNFQWS, "--new", "--new --dpi-desync-fooling", 1))
+
+			// The daemon checks the config before it starts the runner
+			cfg, err := LoadStrategyConfig(tr.config)
+			if err != nil {
+				t.Fatalf("LoadStrategyConfig() error = %v", err)
+			}
+			if err := cfg.Validate(); (err == nil) != tt.enabled {
+				t.Errorf("Validate() error = %v, want an error only without the fallback", err)
+			}
+
+			err = tr.Start(t.Context())
+			if !tt.enabled {
+				if err == nil {
+					t.Fatal("Start() without the strategy file succeeded")
+				}
+				if status := tr.GetStatus(); status.Running || status.StrategySource == StrategySourceFallback {
+					t.Errorf("status after the failed start: running %v, source %q", status.Running, status.StrategySource)
+				}
+				if got := tr.procManager.Count(); got != 0 {
+					t.Errorf("%d processes after the failed start", got)
+				}
+				if got := tr.fw.queues(); len(got) != 0 {
+					t.Errorf("firewall rules after the failed start: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			// The embedded strategy and its hostlist are applied from the volatile state dir
+			dir := filepath.Join(tr.dir, "run", "fallback")
+			if status := tr.GetStatus(); !status.Running || status.StrategySource != StrategySourceFallback {
+				t.Errorf("status: running %v, source %q, want the fallback running", status.Running, status.StrategySource)
+			}
+			rules := tr.Rules()
+			if len(rules) != 3 || tr.procManager.Count() != 3 {
+				t.Fatalf("%d fallback rules with %d processes, want 3 of each", len(rules), tr.procManager.Count())
+			}
+			list := filepath.Join(dir, "list-fallback.txt")
+			args := tr.processArgs()
+			for _, rule := range rules {
+				if !slices.Contains(args[rule.QueueNum], "--hostlist="+list) || len(rule.MissingFiles) != 0 {
+					t.Errorf("fallback rule %s %s: nfqws args %q, missing %q, want the embedded hostlist", rule.Protocol, rule.Ports, args[rule.QueueNum], rule.MissingFiles)
+				}
+			}
+			if data, err := os.ReadFile(list); err != nil || !slices.Equal(data, fallbackHostlist) {
+				t.Errorf("fallback hostlist = %q, %v, want the embedded one", data, err)
+			}
+			snap := tr.Snapshot(t.Context(), SnapshotHealth|SnapshotEvents, 0)
+			if snap.Health != HealthDegraded || snap.HealthReason != "fallback strategy applied" {
+				t.Errorf("health = %s (%s), want degraded by the fallback", snap.Health, snap.HealthReason)
+			}
+			if !slices.ContainsFunc(snap.Events, func(e Event) bool { return e.Kind == "fallback_strategy" }) {
+				t.Error("no fallback_strategy event")
+			}
+			tr.checkConsistent(t)
+
+			// A real strategy file replaces the fallback entirely
+			tr.writeStrategy(t, testStrategy)
+			for _, full := range []bool{false, true} {
+				if err := tr.RestartFiltered(t.Context(), nil, full); err != nil {
+					t.Fatalf("Restart() error = %v", err)
+				}
+				if source := tr.GetStatus().StrategySource; source != StrategySourceFile {
+					t.Errorf("StrategySource = %q, want %q", source, StrategySourceFile)
+				}
+				rules := tr.Rules()
+				if len(rules) != 2 {
+					t.Errorf("%d rules, want the 2 of the strategy file", len(rules))
+				}
+				for _, rule := range rules {
+					if strings.Contains(rule.NFQWSArgs, dir) {
+						t.Errorf("rule %s %s still uses the fallback: %q", rule.Protocol, rule.Ports, rule.NFQWSArgs)
+					}
+				}
+				if snap := tr.Snapshot(t.Context(), SnapshotHealth, 0); snap.Health != HealthHealthy {
+					t.Errorf("health = %s (%s), want healthy", snap.Health, snap.HealthReason)
+				}
+				tr.checkConsistent(t)
+			}
+		})
+	}
+}
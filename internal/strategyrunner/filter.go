@@ -0,0 +1,191 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ports"
+)
+
+// ErrFilterNoMatch is returned when a rule filter selects none of the strategy rules.
+var ErrFilterNoMatch = errors.New("rule filter matches no rules")
+
+// labelOptions are the nfqws options whose list files name a rule.
+var labelOptions = []string{"--hostlist", "--ipset", "--filter-l7"}
+
+// ruleLabel returns a short name of the rule derived from the lists it
+// serves, e.g. "youtube" for --hostlist=list-youtube.txt. Rules without
+// lists are named by protocol and ports.
+func ruleLabel(rule ParsedRule) string {
+	args := parseNFQWSArgs(rule.NFQWSArgs)
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, opt := range labelOptions {
+		for _, value := range optionValues(args, opt) {
+			for _, v := range strings.Split(value, ",") {
+				name := strings.TrimSuffix(filepath.Base(v), filepath.Ext(v))
+				name = strings.TrimPrefix(strings.TrimPrefix(name, "list-"), "ipset-")
+				if name == "" || seen[name] {
+					continue
+				}
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return rule.Protocol + "-" + rule.Ports
+	}
+	return strings.Join(names, "+")
+}
+
+// RuleFilter restricts a restart to the rules it selects. Selectors combine
+// with AND semantics; empty selectors match any rule.
+type RuleFilter struct {
+	// Label is a case-insensitive glob matched against the rule label or any
+	// of its "+"-separated parts, e.g. "youtube*"
+	Label string
+
+	// Protocol matches the rule protocol ("tcp" or "udp")
+	Protocol string
+
+	// Port selects rules whose port spec contains the port, given as a
+	// number or a port alias such as "https"
+	Port string
+
+	// Indices selects rules by their 1-based position in the strategy
+	Indices []int
+}
+
+// IsZero reports whether the filter selects every rule. A nil filter is zero.
+func (f *RuleFilter) IsZero() bool {
+	return f == nil || (f.Label == "" && f.Protocol == "" && f.Port == "" && len(f.Indices) == 0)
+}
+
+// Validate validates the filter selectors.
+func (f *RuleFilter) Validate() error {
+	if f.IsZero() {
+		return nil
+	}
+	if _, err := path.Match(f.Label, ""); err != nil {
+		return fmt.Errorf("invalid label pattern %q: %w", f.Label, err)
+	}
+	if f.Protocol != "" && f.Protocol != "tcp" && f.Protocol != "udp" {
+		return fmt.Errorf("invalid protocol %q: must be tcp or udp", f.Protocol)
+	}
+	if f.Port != "" && !isAliasName(f.Port) {
+		if _, err := ports.ParsePort(f.Port); err != nil {
+			return err
+		}
+	}
+	for _, index := range f.Indices {
+		if index < 1 {
+			return fmt.Errorf("invalid rule index %d: indices start at 1", index)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the filter selects rule at the 1-based index.
+// A port alias is resolved with aliases for the protocol of the rule.
+func (f *RuleFilter) Matches(index int, rule *ParsedRule, aliases PortAliases) bool {
+	if f.IsZero() {
+		return true
+	}
+	if f.Label != "" && !labelMatches(f.Label, rule.Label) {
+		return false
+	}
+	if f.Protocol != "" && f.Protocol != rule.Protocol {
+		return false
+	}
+	if f.Port != "" {
+		port, _, err := aliases.Resolve(f.Port, rule.Protocol)
+		if err != nil || !portsContain(rule.Ports, port) {
+			return false
+		}
+	}
+	if len(f.Indices) > 0 {
+		found := false
+		for _, i := range f.Indices {
+			if i == index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the filter, e.g. "label=youtube* proto=udp".
+func (f *RuleFilter) String() string {
+	if f.IsZero() {
+		return ""
+	}
+
+	var parts []string
+	if f.Label != "" {
+		parts = append(parts, "label="+f.Label)
+	}
+	if f.Protocol != "" {
+		parts = append(parts, "proto="+f.Protocol)
+	}
+	if f.Port != "" {
+		parts = append(parts, "port="+f.Port)
+	}
+	if len(f.Indices) > 0 {
+		indices := make([]string, len(f.Indices))
+		for i, index := range f.Indices {
+			indices[i] = strconv.Itoa(index)
+		}
+		parts = append(parts, "rules="+strings.Join(indices, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// labelMatches reports whether pattern matches label or one of its parts.
+func labelMatches(pattern, label string) bool {
+	pattern = strings.ToLower(pattern)
+	label = strings.ToLower(label)
+
+	if ok, _ := path.Match(pattern, label); ok {
+		return true
+	}
+	for _, part := range strings.Split(label, "+") {
+		if ok, _ := path.Match(pattern, part); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// portsContain reports whether the port spec (e.g. "80,443,1024-65535") contains port.
+func portsContain(spec, port string) bool {
+	p, err := ports.ParsePort(port)
+	if err != nil {
+		return false
+	}
+	set, err := ports.Parse(spec)
+	return err == nil && set.Contains(p)
+}
+
+// applyFilter marks the rules filter doesn't select as filtered out and
+// returns the number of selected rules.
+func applyFilter(rules []ParsedRule, filter *RuleFilter, aliases PortAliases) int {
+	selected := 0
+	for i := range rules {
+		rules[i].FilteredOut = !filter.Matches(i+1, &rules[i], aliases)
+		if !rules[i].FilteredOut {
+			selected++
+		}
+	}
+	return selected
+}
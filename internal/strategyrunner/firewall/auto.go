@@ -0,0 +1,106 @@
+//go:build linux
+
+package firewall
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// autoProbeTable is the scratch table the auto backend creates and deletes
+// to find out whether the kernel supports nf_tables.
+const autoProbeTable = "inet zapretng_probe"
+
+// resolveBackend selects the backend for BackendAuto inside cfg.Namespace:
+// nftables if nft can create a scratch table, iptables otherwise.
+func resolveBackend(cfg *Config) (string, error) {
+	var backend string
+	err := netns.Do(cfg.Namespace, func() error {
+		nftErr := probeNftables()
+		if nftErr == nil {
+			backend = "nftables"
+			cfg.Logger.Info("firewall backend selected", slog.String("backend", backend))
+			if n := legacyIptablesRules(); n > 0 {
+				cfg.Logger.Warn("legacy iptables rules coexist with the nftables rules, packets pass both",
+					slog.Int("rules", n),
+					slog.String("hint", "check iptables-legacy-save, or set firewall.backend: iptables"),
+				)
+			}
+			return nil
+		}
+
+		if _, err := exec.LookPath("iptables"); err != nil {
+			return fmt.Errorf("no usable firewall backend: nftables: %v; iptables: %w", nftErr, err)
+		}
+		backend = "iptables"
+		cfg.Logger.Info("firewall backend selected",
+			slog.String("backend", backend),
+			slog.String("reason", nftErr.Error()),
+		)
+		if iptablesVariant() == "nf_tables" {
+			cfg.Logger.Warn("iptables is the iptables-nft shim, which needs the nf_tables support nft could not use",
+				slog.String("hint", "install iptables-legacy or fix nftables"),
+			)
+		}
+		return nil
+	})
+	return backend, err
+}
+
+// probeNftables creates and deletes a scratch table in one nft transaction.
+func probeNftables() error {
+	if _, err := exec.LookPath("nft"); err != nil {
+		return fmt.Errorf("nft command not found: %w", err)
+	}
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add table %[1]s\ndelete table %[1]s\n", autoProbeTable))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("kernel rejected an nftables table: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// iptablesVariant returns the backend iptables reports in its version,
+// "nf_tables" for the iptables-nft shim or "legacy", or "" if unknown.
+func iptablesVariant() string {
+	output, err := exec.Command("iptables", "--version").Output()
+	if err != nil {
+		return ""
+	}
+	version := string(output)
+	switch {
+	case strings.Contains(version, "nf_tables"):
+		return "nf_tables"
+	case strings.Contains(version, "legacy"):
+		return "legacy"
+	}
+	return ""
+}
+
+// legacyIptablesRules counts the rules in the legacy xtables. With the
+// iptables-nft shim they are only visible to iptables-legacy-save.
+func legacyIptablesRules() int {
+	name := "iptables-legacy-save"
+	if _, err := exec.LookPath(name); err != nil {
+		if iptablesVariant() != "legacy" {
+			return 0
+		}
+		name = "iptables-save"
+	}
+
+	output, err := exec.Command(name).Output()
+	if err != nil {
+		return 0
+	}
+	rules := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "-A ") {
+			rules++
+		}
+	}
+	return rules
+}
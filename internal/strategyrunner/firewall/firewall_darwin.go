@@ -2,7 +2,15 @@
 
 package firewall
 
-import "context"
+import (
+	"context"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+)
+
+func init() {
+	features.Register(features.KindFirewall, "noop", "no rules")
+}
 
 // NoopFirewall is a no-op firewall for macOS.
 type NoopFirewall struct{}
@@ -2,16 +2,44 @@
 
 package firewall
 
-import "fmt"
+import (
+	"fmt"
 
-// NewFirewall creates a new firewall instance based on the backend.
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+)
+
+func init() {
+	features.Register(features.KindFirewall, "nftables", "nft CLI, sets and counters")
+	features.Register(features.KindFirewall, "iptables", "iptables and ip6tables, raw table notrack")
+	features.Register(features.KindFirewall, BackendAuto, "nftables, iptables on kernels without nf_tables")
+}
+
+// NewFirewall creates a new firewall instance based on the backend, operating
+// inside cfg.Namespace if set. BackendAuto is replaced in cfg with the backend
+// the kernel supports.
 func NewFirewall(cfg *Config) (Firewall, error) {
+	if cfg.Backend == BackendAuto {
+		backend, err := resolveBackend(cfg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Backend = backend
+	}
+
+	var (
+		fw  Firewall
+		err error
+	)
 	switch cfg.Backend {
 	case "nftables":
-		return NewNftablesFirewall(cfg)
+		fw, err = NewNftablesFirewall(cfg)
 	case "iptables":
-		return NewIptablesFirewall(cfg)
+		fw, err = NewIptablesFirewall(cfg)
 	default:
 		return nil, fmt.Errorf("unknown firewall backend: %s", cfg.Backend)
 	}
+	if err != nil || cfg.Namespace == "" {
+		return fw, err
+	}
+	return NewNamespacedFirewall(fw, cfg.Namespace), nil
 }
@@ -2,7 +2,15 @@
 
 package firewall
 
-import "context"
+import (
+	"context"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+)
+
+func init() {
+	features.Register(features.KindFirewall, "noop", "no rules, WinDivert captures packets")
+}
 
 // NoopFirewall is a no-op firewall for Windows.
 // On Windows, WinDivert handles packet capture without firewall rules.
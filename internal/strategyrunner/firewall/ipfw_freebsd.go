@@ -6,15 +6,23 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
 )
 
+func init() {
+	features.Register(features.KindFirewall, "ipfw", "FreeBSD ipfw rules")
+}
+
 // IpfwFirewall implements Firewall using FreeBSD ipfw.
 type IpfwFirewall struct {
 	config   *Config
-	ruleNums []int // Track rule numbers for cleanup
-	nextRule int   // Next rule number to use
+	ruleNums []int         // Track rule numbers for cleanup
+	byQueue  map[int][]int // Rule numbers per queue, for RemoveRule
+	nextRule int           // Next rule number to use
 	mu       sync.Mutex
 }
 
@@ -23,6 +31,7 @@ func NewIpfwFirewall(cfg *Config) (*IpfwFirewall, error) {
 	return &IpfwFirewall{
 		config:   cfg,
 		ruleNums: []int{},
+		byQueue:  make(map[int][]int),
 		nextRule: 100, // Start from rule 100
 	}, nil
 }
@@ -51,6 +60,10 @@ func (f *IpfwFirewall) AddRule(ctx context.Context, rule *Rule) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	if rule.Notrack {
+		return fmt.Errorf("notrack is not supported by ipfw")
+	}
+
 	ruleNum := f.nextRule
 	f.nextRule++
 
@@ -79,6 +92,24 @@ func (f *IpfwFirewall) AddRule(ctx context.Context, rule *Rule) error {
 	}
 
 	f.ruleNums = append(f.ruleNums, ruleNum)
+	f.byQueue[rule.QueueNum] = append(f.byQueue[rule.QueueNum], ruleNum)
+
+	return nil
+}
+
+// RemoveRule deletes the ipfw rules added for rule's divert port.
+func (f *IpfwFirewall) RemoveRule(ctx context.Context, rule *Rule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, ruleNum := range f.byQueue[rule.QueueNum] {
+		cmd := exec.CommandContext(ctx, "ipfw", "delete", fmt.Sprintf("%d", ruleNum))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to delete ipfw rule %d: %w, output: %s", ruleNum, err, string(output))
+		}
+		f.ruleNums = slices.DeleteFunc(f.ruleNums, func(n int) bool { return n == ruleNum })
+	}
+	delete(f.byQueue, rule.QueueNum)
 
 	return nil
 }
@@ -99,6 +130,7 @@ func (f *IpfwFirewall) RemoveAll(ctx context.Context) error {
 	}
 
 	f.ruleNums = nil
+	f.byQueue = make(map[int][]int)
 
 	if len(errs) > 0 {
 		return fmt.Errorf("cleanup errors: %v", strings.Join(errs, "; "))
@@ -5,6 +5,9 @@ package firewall
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -13,15 +16,67 @@ import (
 
 // IptablesFirewall implements Firewall using iptables.
 type IptablesFirewall struct {
-	ipt4   *iptables.IPTables
-	ipt6   *iptables.IPTables
-	config *Config
-	rules  []string // Track rule specs for cleanup
-	mu     sync.Mutex
+	ipt4      iptablesHandler
+	ipt6      iptablesHandler
+	config    *Config
+	table     string   // Table of the chain holding the queue rules
+	parent    string   // Built-in chain jumping to the queue rules
+	chain     string   // Chain holding the queue rules
+	rawParent string   // Built-in raw table chain jumping to the notrack rules
+	rawChain  string   // Raw table chain holding the notrack rules
+	rules     []string // Track rule specs for cleanup
+	rawReady  bool
+	owned     Ownership // Chain and jump rule created by Setup, deleted by RemoveAll
+	mu        sync.Mutex
+}
+
+// iptablesHandler is the part of *iptables.IPTables the firewall uses, so
+// tests can run it against a fake.
+type iptablesHandler interface {
+	ChainExists(table, chain string) (bool, error)
+	NewChain(table, chain string) error
+	ClearChain(table, chain string) error
+	DeleteChain(table, chain string) error
+	ClearAndDeleteChain(table, chain string) error
+	List(table, chain string) ([]string, error)
+	ListWithCounters(table, chain string) ([]string, error)
+	Exists(table, chain string, rulespec ...string) (bool, error)
+	Insert(table, chain string, pos int, rulespec ...string) error
+	Append(table, chain string, rulespec ...string) error
+	AppendUnique(table, chain string, rulespec ...string) error
+	Delete(table, chain string, rulespec ...string) error
+	DeleteIfExists(table, chain string, rulespec ...string) error
+}
+
+// iptablesComment marks the rules added by zapret-ng, so they can be told
+// apart in a chain it didn't create.
+const iptablesComment = "Added by zapret-ng"
+
+// iptablesCommentMatch is the comment match of every rule zapret-ng adds to
+// its chains.
+var iptablesCommentMatch = []string{"-m", "comment", "--comment", iptablesComment}
+
+// iptablesHook returns the table and built-in chain the queue rules are
+// attached to for hook, and the raw table chain of the notrack rules.
+// Routed packets are tracked on arrival, so their notrack rules run in
+// PREROUTING.
+func iptablesHook(hook string) (table, parent, rawParent string) {
+	switch hook {
+	case HookForward:
+		return "filter", "FORWARD", "PREROUTING"
+	case HookPostrouting:
+		return "mangle", "POSTROUTING", "PREROUTING"
+	default:
+		return "filter", "OUTPUT", "OUTPUT"
+	}
 }
 
 // NewIptablesFirewall creates a new iptables firewall instance.
 func NewIptablesFirewall(cfg *Config) (*IptablesFirewall, error) {
+	if err := ValidateIptablesChain(cfg.ChainName); err != nil {
+		return nil, err
+	}
+
 	ipt4, err := iptables.New()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create iptables handler (IPv4): %w", err)
@@ -32,64 +87,410 @@ func NewIptablesFirewall(cfg *Config) (*IptablesFirewall, error) {
 		return nil, fmt.Errorf("failed to create iptables handler (IPv6): %w", err)
 	}
 
-	return &IptablesFirewall{
-		ipt4:   ipt4,
-		ipt6:   ipt6,
-		config: cfg,
-		rules:  []string{},
-	}, nil
+	chain, rawChain := IptablesChains(cfg.ChainName)
+	table, parent, rawParent := iptablesHook(cfg.hook())
+	i := &IptablesFirewall{
+		ipt4:      ipt4,
+		ipt6:      ipt6,
+		config:    cfg,
+		table:     table,
+		parent:    parent,
+		chain:     chain,
+		rawParent: rawParent,
+		rawChain:  rawChain,
+		rules:     []string{},
+	}
+	if cfg.Owned != nil {
+		i.owned = *cfg.Owned
+	}
+	return i, nil
 }
 
-// Setup creates the iptables chain and links it to OUTPUT.
+// Setup creates the iptables chain and links it to the built-in chain of the hook.
+// A chain or jump rule that existed before in any address family is recorded
+// as not owned: RemoveAll then deletes only the rules with our comment.
 func (i *IptablesFirewall) Setup(ctx context.Context) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	chainName := "zapret_output"
+	chainName := i.chain
+	chainCreated, jumpAdded := true, true
 
 	// Create custom chain for both IPv4 and IPv6
-	for _, ipt := range []*iptables.IPTables{i.ipt4, i.ipt6} {
-		// Try to create chain (might already exist)
-		if err := ipt.NewChain("filter", chainName); err != nil {
-			// Chain might already exist, that's ok
-			if !strings.Contains(err.Error(), "File exists") {
-				return fmt.Errorf("failed to create chain: %w", err)
-			}
+	for _, family := range []struct {
+		ipt  iptablesHandler
+		ipv6 bool
+	}{{i.ipt4, false}, {i.ipt6, true}} {
+		ipt := family.ipt
+
+		exists, err := ipt.ChainExists(i.table, chainName)
+		if err != nil {
+			return fmt.Errorf("failed to check for chain: %w", err)
+		}
+		if exists {
+			chainCreated = false
+		} else if err := ipt.NewChain(i.table, chainName); err != nil {
+			return fmt.Errorf("failed to create chain: %w", err)
+		}
+
+		if err := insertExclusions(ipt, i.table, chainName, i.exclusionSpecs(family.ipv6)); err != nil {
+			return err
 		}
 
-		// Add jump rule from OUTPUT to zapret_output
+		// Add jump rule from OUTPUT to our chain
 		spec := []string{"-j", chainName}
-		if err := ipt.AppendUnique("filter", "OUTPUT", spec...); err != nil {
+		if exists, err := ipt.Exists(i.table, i.parent, spec...); err == nil && exists {
+			jumpAdded = false
+		}
+		if err := ipt.AppendUnique(i.table, i.parent, spec...); err != nil {
 			// Rule might already exist, that's ok
 			if !strings.Contains(err.Error(), "already exists") {
 				return fmt.Errorf("failed to add jump rule: %w", err)
 			}
 		}
+
+		// Crashed runs may have left extra jumps behind; keep exactly one
+		if err := i.dedupeJump(ipt, i.table, i.parent, chainName); err != nil {
+			return err
+		}
+
+		// The raw chain is created with the first notrack rule; drop one left
+		// by a previous run so it doesn't outlive rules that no longer ask for it
+		if err := i.removeRawChain(ipt); err != nil {
+			return err
+		}
+	}
+	i.rawReady = false
+
+	// A repeated Setup finds what the first one created
+	i.owned.Chain = i.owned.Chain || chainCreated
+	i.owned.Jump = i.owned.Jump || jumpAdded || i.owned.Chain
+	if !i.owned.Chain {
+		i.config.Logger.Info("using existing iptables chain, only rules added by zapret-ng will be removed from it",
+			slog.String("table", i.table),
+			slog.String("chain", chainName),
+		)
+	}
+
+	return nil
+}
+
+// Ownership returns the chain and jump rule created by Setup.
+func (i *IptablesFirewall) Ownership() Ownership {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.owned
+}
+
+// ensureRawChain creates the raw table chain for notrack rules and links it
+// to the raw OUTPUT or PREROUTING chain, which run before conntrack.
+func (i *IptablesFirewall) ensureRawChain() error {
+	if i.rawReady {
+		return nil
+	}
+
+	for _, family := range []struct {
+		ipt  iptablesHandler
+		ipv6 bool
+	}{{i.ipt4, false}, {i.ipt6, true}} {
+		ipt := family.ipt
+		exists, err := ipt.ChainExists("raw", i.rawChain)
+		if err != nil {
+			return fmt.Errorf("failed to check for raw chain: %w", err)
+		}
+		if !exists {
+			if err := ipt.NewChain("raw", i.rawChain); err != nil {
+				return fmt.Errorf("failed to create raw chain: %w", err)
+			}
+		}
+		if err := insertExclusions(ipt, "raw", i.rawChain, i.exclusionSpecs(family.ipv6)); err != nil {
+			return err
+		}
+		if err := ipt.AppendUnique("raw", i.rawParent, "-j", i.rawChain); err != nil {
+			return fmt.Errorf("failed to add raw jump rule: %w", err)
+		}
+		if err := i.dedupeJump(ipt, "raw", i.rawParent, i.rawChain); err != nil {
+			return err
+		}
 	}
 
+	i.rawReady = true
 	return nil
 }
 
+// exclusionSpecs returns the return rules of the excluded networks of one
+// address family. iptables can't negate several networks in one rule, so
+// each network gets a rule at the top of the chain instead of a "! -d" match.
+func (i *IptablesFirewall) exclusionSpecs(ipv6 bool) [][]string {
+	v4, v6 := splitFamilies(i.config.ExcludeNetworks)
+	prefixes := v4
+	if ipv6 {
+		prefixes = v6
+	}
+
+	specs := make([][]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		specs = append(specs, slices.Concat([]string{"-d", prefix}, iptablesCommentMatch, []string{"-j", "RETURN"}))
+	}
+	return specs
+}
+
+// insertExclusions inserts specs at the top of chain in table. A rule left
+// by a previous run is moved rather than added twice.
+func insertExclusions(ipt iptablesHandler, table, chain string, specs [][]string) error {
+	for pos, spec := range specs {
+		if err := ipt.DeleteIfExists(table, chain, spec...); err != nil {
+			return fmt.Errorf("failed to delete excluded network rule: %w", err)
+		}
+		if err := ipt.Insert(table, chain, pos+1, spec...); err != nil {
+			return fmt.Errorf("failed to add excluded network rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeRawChain removes the raw table chain and its jump rule if they
+// exist. Next to a queue chain zapret-ng didn't create only the rules with
+// our comment are deleted, and the raw chain once no other rules are left
+// in it.
+func (i *IptablesFirewall) removeRawChain(ipt iptablesHandler) error {
+	if !i.owned.Chain {
+		if err := deleteOwnRules(ipt, "raw", i.rawChain); err != nil {
+			return err
+		}
+		if rules, err := ipt.List("raw", i.rawChain); err != nil || countRules(rules) > 0 {
+			return nil
+		}
+	}
+	return deleteRawChain(ipt, i.rawParent, i.rawChain)
+}
+
+// deleteOwnRules deletes the rules with our comment from chain in table if
+// it exists. Rules are deleted by number from the last one, so the numbers
+// of the others don't shift.
+func deleteOwnRules(ipt iptablesHandler, table, chain string) error {
+	exists, err := ipt.ChainExists(table, chain)
+	if err != nil || !exists {
+		return nil
+	}
+
+	rules, err := ipt.List(table, chain)
+	if err != nil {
+		return fmt.Errorf("failed to list %s rules: %w", chain, err)
+	}
+	var own []int
+	num := 0
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule, "-A ") {
+			continue
+		}
+		num++
+		if strings.Contains(rule, iptablesComment) {
+			own = append(own, num)
+		}
+	}
+	for _, num := range slices.Backward(own) {
+		if err := ipt.Delete(table, chain, strconv.Itoa(num)); err != nil {
+			return fmt.Errorf("failed to delete rule %d of %s: %w", num, chain, err)
+		}
+	}
+	return nil
+}
+
+// countRules counts the rules in a chain listed by iptables -S.
+func countRules(rules []string) int {
+	n := 0
+	for _, rule := range rules {
+		if strings.HasPrefix(rule, "-A ") {
+			n++
+		}
+	}
+	return n
+}
+
+// deleteRawChain removes the raw table chain and its jump rule from parent
+// if they exist. Kernels without the raw table have nothing to remove.
+func deleteRawChain(ipt iptablesHandler, parent, chain string) error {
+	exists, err := ipt.ChainExists("raw", chain)
+	if err != nil || !exists {
+		return nil
+	}
+
+	if err := ipt.DeleteIfExists("raw", parent, "-j", chain); err != nil {
+		return fmt.Errorf("failed to delete raw jump rule: %w", err)
+	}
+	if err := ipt.ClearAndDeleteChain("raw", chain); err != nil {
+		return fmt.Errorf("failed to delete raw chain: %w", err)
+	}
+	return nil
+}
+
+// dedupeJump removes duplicate jump rules from parent to chain in table, keeping one.
+func (i *IptablesFirewall) dedupeJump(ipt iptablesHandler, table, parent, chain string) error {
+	rules, err := ipt.List(table, parent)
+	if err != nil {
+		return fmt.Errorf("failed to list %s rules: %w", parent, err)
+	}
+
+	jumps := 0
+	for _, rule := range rules {
+		if isJumpRule(rule, parent, chain) {
+			jumps++
+		}
+	}
+
+	for ; jumps > 1; jumps-- {
+		if err := ipt.Delete(table, parent, "-j", chain); err != nil {
+			return fmt.Errorf("failed to delete duplicate jump rule: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// isJumpRule reports whether a rule listed by iptables -S is exactly our
+// unconditional jump from parent to chain.
+func isJumpRule(rule, parent, chain string) bool {
+	return strings.Join(strings.Fields(rule), " ") == fmt.Sprintf("-A %s -j %s", parent, chain)
+}
+
 // AddRule adds a firewall rule.
+// IPv6-specific interface and port overrides are applied to the ip6tables rule.
 func (i *IptablesFirewall) AddRule(ctx context.Context, rule *Rule) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	chainName := "zapret_output"
+	chainName := i.chain
+
+	// Add rule to both IPv4 and IPv6
+	for _, family := range []struct {
+		ipt  iptablesHandler
+		ipv6 bool
+	}{{i.ipt4, false}, {i.ipt6, true}} {
+		for _, spec := range buildIptablesSpecs(rule, family.ipv6) {
+			if err := family.ipt.Append(i.table, chainName, spec...); err != nil {
+				return fmt.Errorf("failed to add iptables rule: %w", err)
+			}
+			i.rules = append(i.rules, strings.Join(spec, " "))
+		}
+	}
+
+	if !rule.Notrack {
+		return nil
+	}
+
+	if err := i.ensureRawChain(); err != nil {
+		return err
+	}
+
+	for _, family := range []struct {
+		ipt  iptablesHandler
+		ipv6 bool
+	}{{i.ipt4, false}, {i.ipt6, true}} {
+		for _, spec := range buildIptablesRawSpecs(rule, family.ipv6, i.rawParent == "PREROUTING") {
+			if err := family.ipt.Append("raw", i.rawChain, spec...); err != nil {
+				return fmt.Errorf("failed to add notrack rule: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveRule deletes the rule specifications AddRule appended for rule.
+func (i *IptablesFirewall) RemoveRule(ctx context.Context, rule *Rule) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	chainName := i.chain
+
+	for _, family := range []struct {
+		ipt  iptablesHandler
+		ipv6 bool
+	}{{i.ipt4, false}, {i.ipt6, true}} {
+		for _, spec := range buildIptablesSpecs(rule, family.ipv6) {
+			if err := family.ipt.DeleteIfExists(i.table, chainName, spec...); err != nil {
+				return fmt.Errorf("failed to delete iptables rule: %w", err)
+			}
+			i.rules = slices.DeleteFunc(i.rules, func(s string) bool {
+				return s == strings.Join(spec, " ")
+			})
+		}
+		if !rule.Notrack {
+			continue
+		}
+		for _, spec := range buildIptablesRawSpecs(rule, family.ipv6, i.rawParent == "PREROUTING") {
+			if err := family.ipt.DeleteIfExists("raw", i.rawChain, spec...); err != nil {
+				return fmt.Errorf("failed to delete notrack rule: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Render returns the iptables and ip6tables commands AddRule runs for rule.
+func (i *IptablesFirewall) Render(rule *Rule) ([]string, error) {
+	chainName := i.chain
+
+	var cmds []string
+	for _, family := range []struct {
+		cmd  string
+		ipv6 bool
+	}{{"iptables", false}, {"ip6tables", true}} {
+		for _, spec := range buildIptablesSpecs(rule, family.ipv6) {
+			cmds = append(cmds, fmt.Sprintf("%s -t %s -A %s %s", family.cmd, i.table, chainName, strings.Join(spec, " ")))
+		}
+		if !rule.Notrack {
+			continue
+		}
+		for _, spec := range buildIptablesRawSpecs(rule, family.ipv6, i.rawParent == "PREROUTING") {
+			cmds = append(cmds, fmt.Sprintf("%s -t raw -A %s %s", family.cmd, i.rawChain, strings.Join(spec, " ")))
+		}
+	}
+	return cmds, nil
+}
 
-	// Build rule specification
-	spec := []string{
-		"-p", rule.Protocol,
+// buildIptablesRawSpecs builds the notrack rule specifications for the given
+// address family. PREROUTING knows no output interface, so prerouting leaves
+// out its match.
+func buildIptablesRawSpecs(rule *Rule, ipv6, prerouting bool) [][]string {
+	var specs [][]string
+	for _, match := range buildIptablesMatches(rule, ipv6, prerouting) {
+		specs = append(specs, append(match, "-j", "NOTRACK"))
 	}
+	return specs
+}
 
-	// Add interface if specified
-	if rule.Interface != "" {
-		spec = append(spec, "-o", rule.Interface)
+// buildIptablesSpecs builds the rule specifications of a rule for the given
+// address family, one queue rule per port match. Each split rule has its own
+// rate limit bucket.
+func buildIptablesSpecs(rule *Rule, ipv6 bool) [][]string {
+	var specs [][]string
+	for _, match := range buildIptablesMatches(rule, ipv6, false) {
+		// Later packets of a connection skip the queue and the rate limit counter
+		match = append(match, iptablesConnbytesMatches(rule)...)
+		specs = append(specs, buildIptablesSpec(rule, slices.Clone(match)))
+		if rule.RateLimit > 0 {
+			// Count packets that exceeded the limit and fell through unqueued
+			specs = append(specs, append(match, "-j", "RETURN"))
+		}
 	}
+	return specs
+}
+
+// buildIptablesSpec builds the queue rule specification from the match part.
+func buildIptablesSpec(rule *Rule, match []string) []string {
+	spec := match
 
-	// Add port matching
-	portStr := buildIptablesPorts(rule.Ports)
-	spec = append(spec, "--dport", portStr)
+	// Only queue packets within the rate limit
+	if rule.RateLimit > 0 {
+		spec = append(spec,
+			"-m", "limit",
+			"--limit", fmt.Sprintf("%d/second", rule.RateLimit),
+			"--limit-burst", fmt.Sprintf("%d", rule.RateLimit),
+		)
+	}
 
 	// Add NFQUEUE target
 	spec = append(spec,
@@ -98,16 +499,71 @@ func (i *IptablesFirewall) AddRule(ctx context.Context, rule *Rule) error {
 		"--queue-bypass",
 	)
 
-	// Add rule to both IPv4 and IPv6
-	for _, ipt := range []*iptables.IPTables{i.ipt4, i.ipt6} {
-		if err := ipt.Append("filter", chainName, spec...); err != nil {
-			return fmt.Errorf("failed to add iptables rule: %w", err)
+	return spec
+}
+
+// buildIptablesMatches builds the match parts of the rule specifications for
+// the given address family, one per port match built by buildIptablesPorts.
+// noOutput leaves out the output interface.
+func buildIptablesMatches(rule *Rule, ipv6, noOutput bool) [][]string {
+	var matches [][]string
+	for _, ports := range buildIptablesPorts(rule.PortsFor(ipv6)) {
+		spec := []string{
+			"-p", rule.Protocol,
+		}
+
+		// Add interfaces if specified
+		if rule.InputInterface != "" {
+			spec = append(spec, "-i", rule.InputInterface)
 		}
+		if iface := rule.InterfaceFor(ipv6); iface != "" && !noOutput {
+			spec = append(spec, "-o", iface)
+		}
+
+		// Add port matching
+		spec = append(spec, ports...)
+
+		// Add packet mark matches
+		spec = append(spec, iptablesMarkMatches(rule.Mark)...)
+
+		spec = append(spec, iptablesCommentMatch...)
+
+		matches = append(matches, spec)
+	}
+	return matches
+}
+
+// iptablesConnbytesMatches builds the -m connbytes specification restricting
+// the queue to the first packets of each connection.
+func iptablesConnbytesMatches(rule *Rule) []string {
+	if rule.ConnbytesLimit <= 0 || rule.Notrack {
+		return nil
+	}
+	return []string{
+		"-m", "connbytes",
+		"--connbytes-dir", "original",
+		"--connbytes-mode", "packets",
+		"--connbytes", fmt.Sprintf("1:%d", rule.ConnbytesLimit),
 	}
+}
 
-	i.rules = append(i.rules, strings.Join(spec, " "))
+// iptablesMarkMatches builds the -m mark specifications of the packet mark matches.
+func iptablesMarkMatches(m MarkMatch) []string {
+	var spec []string
 
-	return nil
+	if m.Match {
+		spec = append(spec, "-m", "mark")
+		if m.Negate {
+			spec = append(spec, "!")
+		}
+		spec = append(spec, "--mark", fmt.Sprintf("0x%x/0x%x", m.MatchValue, m.MatchMask))
+	}
+
+	if m.Exclude != 0 {
+		spec = append(spec, "-m", "mark", "!", "--mark", fmt.Sprintf("0x%x/0x%x", m.Exclude, m.Exclude))
+	}
+
+	return spec
 }
 
 // RemoveAll removes all rules and cleans up the firewall setup.
@@ -115,27 +571,44 @@ func (i *IptablesFirewall) RemoveAll(ctx context.Context) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	chainName := "zapret_output"
+	chainName := i.chain
 	var errs []string
 
 	// For both IPv4 and IPv6
-	for _, ipt := range []*iptables.IPTables{i.ipt4, i.ipt6} {
+	for _, ipt := range []iptablesHandler{i.ipt4, i.ipt6} {
+		if err := i.removeRawChain(ipt); err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		if !i.owned.Chain {
+			// Leave a chain zapret-ng didn't create in place
+			if err := deleteOwnRules(ipt, i.table, chainName); err != nil {
+				errs = append(errs, err.Error())
+			}
+			if i.owned.Jump {
+				if err := ipt.DeleteIfExists(i.table, i.parent, "-j", chainName); err != nil {
+					errs = append(errs, fmt.Sprintf("failed to delete jump rule: %v", err))
+				}
+			}
+			continue
+		}
+
 		// Flush the custom chain
-		if err := ipt.ClearChain("filter", chainName); err != nil {
+		if err := ipt.ClearChain(i.table, chainName); err != nil {
 			// Chain might not exist, that's ok
 			if !strings.Contains(err.Error(), "No such file") {
 				errs = append(errs, fmt.Sprintf("failed to clear chain: %v", err))
 			}
 		}
 
-		// Remove the jump rule from OUTPUT to zapret_output
+		// Remove the jump rule from OUTPUT to our chain
 		spec := []string{"-j", chainName}
-		if err := ipt.DeleteIfExists("filter", "OUTPUT", spec...); err != nil {
+		if err := ipt.DeleteIfExists(i.table, i.parent, spec...); err != nil {
 			// Rule might not exist, that's ok
 		}
 
 		// Delete the custom chain
-		if err := ipt.DeleteChain("filter", chainName); err != nil {
+		if err := ipt.DeleteChain(i.table, chainName); err != nil {
 			// Chain might not exist, that's ok
 			if !strings.Contains(err.Error(), "No such file") {
 				errs = append(errs, fmt.Sprintf("failed to delete chain: %v", err))
@@ -143,21 +616,118 @@ func (i *IptablesFirewall) RemoveAll(ctx context.Context) error {
 		}
 	}
 
+	if !i.owned.Chain {
+		i.config.Logger.Info("removed zapret-ng rules, leaving the existing iptables chain in place",
+			slog.String("table", i.table),
+			slog.String("chain", chainName),
+		)
+	}
 	i.rules = nil
+	i.rawReady = false
 
 	if len(errs) > 0 {
 		return fmt.Errorf("cleanup errors: %v", strings.Join(errs, "; "))
 	}
 
+	i.owned = Ownership{}
 	return nil
 }
 
+// Counters returns the counters of the NFQUEUE rules in both address families.
+func (i *IptablesFirewall) Counters(ctx context.Context) (map[int]Counter, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	chainName := i.chain
+	counters := make(map[int]Counter)
+
+	for _, ipt := range []iptablesHandler{i.ipt4, i.ipt6} {
+		rules, err := ipt.ListWithCounters(i.table, chainName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list chain: %w", err)
+		}
+		for _, rule := range rules {
+			queue, c, ok := parseIptablesCounter(rule)
+			if !ok {
+				continue
+			}
+			total := counters[queue]
+			total.Packets += c.Packets
+			total.Bytes += c.Bytes
+			counters[queue] = total
+		}
+	}
+
+	return counters, nil
+}
+
+// parseIptablesCounter extracts the queue number and counters from a rule listed with -c.
+func parseIptablesCounter(rule string) (int, Counter, bool) {
+	fields := strings.Fields(rule)
+	var c Counter
+	queue := -1
+	for j := 0; j < len(fields)-1; j++ {
+		switch fields[j] {
+		case "-c":
+			if j+2 < len(fields) {
+				c.Packets, _ = strconv.ParseUint(fields[j+1], 10, 64)
+				c.Bytes, _ = strconv.ParseUint(fields[j+2], 10, 64)
+			}
+		case "--queue-num":
+			queue, _ = strconv.Atoi(fields[j+1])
+		}
+	}
+	return queue, c, queue >= 0
+}
+
 // Close closes the iptables firewall.
 func (i *IptablesFirewall) Close() error {
 	return nil
 }
 
-// buildIptablesPorts converts a port list to iptables format.
-func buildIptablesPorts(ports []string) string {
-	return strings.Join(ports, ",")
+// iptablesMultiportMax is the most ports one multiport match takes; a range
+// counts as two.
+const iptablesMultiportMax = 15
+
+// buildIptablesPorts converts a port list to iptables port matches. A single
+// port or range uses --dport, longer lists -m multiport --dports split into
+// matches of at most iptablesMultiportMax ports. Ranges are written a:b.
+// Elements may hold comma-separated lists themselves.
+func buildIptablesPorts(ports []string) [][]string {
+	var parts []string
+	for _, port := range splitPorts(ports) {
+		if port != "" {
+			parts = append(parts, port)
+		}
+	}
+	if len(parts) == 0 {
+		return [][]string{nil}
+	}
+
+	var matches [][]string
+	var chunk []string
+	weight := 0
+	flush := func() {
+		if len(chunk) == 1 {
+			matches = append(matches, []string{"--dport", chunk[0]})
+		} else {
+			matches = append(matches, []string{"-m", "multiport", "--dports", strings.Join(chunk, ",")})
+		}
+		chunk, weight = nil, 0
+	}
+
+	for _, port := range parts {
+		port = strings.Replace(port, "-", ":", 1)
+		w := 1
+		if strings.Contains(port, ":") {
+			w = 2
+		}
+		if weight+w > iptablesMultiportMax {
+			flush()
+		}
+		chunk = append(chunk, port)
+		weight += w
+	}
+	flush()
+	return matches
 }
@@ -0,0 +1,567 @@
+//go:build linux
+
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeIptables models the chains and rules of one address family for the
+// calls the iptables firewall makes.
+type fakeIptables struct {
+	chains map[string][]string // "table/chain" to rule specs joined by spaces
+}
+
+func newFakeIptables() *fakeIptables {
+	f := &fakeIptables{chains: make(map[string][]string)}
+	for _, builtin := range []string{
+		"filter/INPUT", "filter/FORWARD", "filter/OUTPUT",
+		"mangle/PREROUTING", "mangle/POSTROUTING",
+		"raw/PREROUTING", "raw/OUTPUT",
+	} {
+		f.chains[builtin] = []string{}
+	}
+	return f
+}
+
+// newTestIptables returns an iptables firewall for the output hook running
+// against fakes of both address families.
+func newTestIptables(ipt4, ipt6 *fakeIptables) *IptablesFirewall {
+	chain, rawChain := IptablesChains("output")
+	table, parent, rawParent := iptablesHook(HookOutput)
+	return &IptablesFirewall{
+		ipt4:      ipt4,
+		ipt6:      ipt6,
+		config:    &Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+		table:     table,
+		parent:    parent,
+		chain:     chain,
+		rawParent: rawParent,
+		rawChain:  rawChain,
+	}
+}
+
+var errIptablesMissing = errors.New("iptables: No such file or directory")
+
+func (f *fakeIptables) ChainExists(table, chain string) (bool, error) {
+	_, ok := f.chains[table+"/"+chain]
+	return ok, nil
+}
+
+func (f *fakeIptables) NewChain(table, chain string) error {
+	if _, ok := f.chains[table+"/"+chain]; ok {
+		return errors.New("iptables: Chain already exists")
+	}
+	f.chains[table+"/"+chain] = []string{}
+	return nil
+}
+
+func (f *fakeIptables) ClearChain(table, chain string) error {
+	if _, ok := f.chains[table+"/"+chain]; !ok {
+		return errIptablesMissing
+	}
+	f.chains[table+"/"+chain] = []string{}
+	return nil
+}
+
+func (f *fakeIptables) DeleteChain(table, chain string) error {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return errIptablesMissing
+	}
+	if len(rules) > 0 {
+		return errors.New("iptables: Directory not empty")
+	}
+	for key, rules := range f.chains {
+		if slices.ContainsFunc(rules, func(r string) bool { return r == "-j "+chain }) && strings.HasPrefix(key, table+"/") {
+			return errors.New("iptables: Too many links")
+		}
+	}
+	delete(f.chains, table+"/"+chain)
+	return nil
+}
+
+func (f *fakeIptables) ClearAndDeleteChain(table, chain string) error {
+	if _, ok := f.chains[table+"/"+chain]; !ok {
+		return nil
+	}
+	f.chains[table+"/"+chain] = []string{}
+	return f.DeleteChain(table, chain)
+}
+
+func (f *fakeIptables) List(table, chain string) ([]string, error) {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return nil, errIptablesMissing
+	}
+	list := []string{"-N " + chain}
+	for _, rule := range rules {
+		list = append(list, "-A "+chain+" "+rule)
+	}
+	return list, nil
+}
+
+func (f *fakeIptables) ListWithCounters(table, chain string) ([]string, error) {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return nil, errIptablesMissing
+	}
+	list := []string{"-N " + chain}
+	for _, rule := range rules {
+		list = append(list, "-A "+chain+" "+rule+" -c 0 0")
+	}
+	return list, nil
+}
+
+func (f *fakeIptables) Exists(table, chain string, rulespec ...string) (bool, error) {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return false, errIptablesMissing
+	}
+	return slices.Contains(rules, strings.Join(rulespec, " ")), nil
+}
+
+func (f *fakeIptables) Insert(table, chain string, pos int, rulespec ...string) error {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return errIptablesMissing
+	}
+	f.chains[table+"/"+chain] = slices.Insert(rules, pos-1, strings.Join(rulespec, " "))
+	return nil
+}
+
+func (f *fakeIptables) Append(table, chain string, rulespec ...string) error {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return errIptablesMissing
+	}
+	f.chains[table+"/"+chain] = append(rules, strings.Join(rulespec, " "))
+	return nil
+}
+
+func (f *fakeIptables) AppendUnique(table, chain string, rulespec ...string) error {
+	if exists, err := f.Exists(table, chain, rulespec...); err != nil || exists {
+		return err
+	}
+	return f.Append(table, chain, rulespec...)
+}
+
+func (f *fakeIptables) Delete(table, chain string, rulespec ...string) error {
+	rules, ok := f.chains[table+"/"+chain]
+	if !ok {
+		return errIptablesMissing
+	}
+	if len(rulespec) == 1 {
+		if num, err := strconv.Atoi(rulespec[0]); err == nil {
+			if num < 1 || num > len(rules) {
+				return fmt.Errorf("iptables: Index of deletion too big")
+			}
+			f.chains[table+"/"+chain] = slices.Delete(rules, num-1, num)
+			return nil
+		}
+	}
+	i := slices.Index(rules, strings.Join(rulespec, " "))
+	if i < 0 {
+		return errors.New("iptables: Bad rule (does a matching rule exist in that chain?)")
+	}
+	f.chains[table+"/"+chain] = slices.Delete(rules, i, i+1)
+	return nil
+}
+
+func (f *fakeIptables) DeleteIfExists(table, chain string, rulespec ...string) error {
+	if exists, err := f.Exists(table, chain, rulespec...); err != nil || !exists {
+		return nil
+	}
+	return f.Delete(table, chain, rulespec...)
+}
+
+func TestIptablesSetupDedupesJumps(t *testing.T) {
+	ipt4, ipt6 := newFakeIptables(), newFakeIptables()
+	// A crashed run left its chain and two jumps behind in IPv4
+	ipt4.chains["filter/zapret_output"] = []string{}
+	ipt4.chains["filter/OUTPUT"] = []string{"-j zapret_output", "-p tcp -j ACCEPT", "-j zapret_output"}
+	i := newTestIptables(ipt4, ipt6)
+
+	if err := i.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		rules, _ := f.List("filter", "OUTPUT")
+		jumps := 0
+		for _, rule := range rules {
+			if isJumpRule(rule, "OUTPUT", "zapret_output") {
+				jumps++
+			}
+		}
+		if jumps != 1 {
+			t.Errorf("%s: %d jumps to the chain, want 1: %q", name, jumps, rules)
+		}
+	}
+	if got := ipt4.chains["filter/OUTPUT"]; !slices.Contains(got, "-p tcp -j ACCEPT") {
+		t.Errorf("other OUTPUT rule was removed: %q", got)
+	}
+}
+
+func TestIptablesSetupForeignChain(t *testing.T) {
+	ipt4, ipt6 := newFakeIptables(), newFakeIptables()
+	for _, f := range []*fakeIptables{ipt4, ipt6} {
+		f.chains["filter/zapret_output"] = []string{"-p udp -j ACCEPT"}
+		f.chains["filter/OUTPUT"] = []string{"-j zapret_output"}
+	}
+	i := newTestIptables(ipt4, ipt6)
+
+	if err := i.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if got := i.Ownership(); got.Chain || got.Jump {
+		t.Errorf("Ownership() = %+v, want nothing owned", got)
+	}
+
+	rule := &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}
+	if err := i.AddRule(t.Context(), rule); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	if err := i.RemoveAll(t.Context()); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		if got := f.chains["filter/zapret_output"]; !reflect.DeepEqual(got, []string{"-p udp -j ACCEPT"}) {
+			t.Errorf("%s: chain rules = %q, want only the foreign rule", name, got)
+		}
+		if got := f.chains["filter/OUTPUT"]; !reflect.DeepEqual(got, []string{"-j zapret_output"}) {
+			t.Errorf("%s: OUTPUT rules = %q, want the existing jump kept", name, got)
+		}
+	}
+}
+
+func TestIptablesSetupAndRemoveAll(t *testing.T) {
+	ipt4, ipt6 := newFakeIptables(), newFakeIptables()
+	i := newTestIptables(ipt4, ipt6)
+
+	if err := i.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if got := i.Ownership(); !got.Chain || !got.Jump {
+		t.Errorf("Ownership() = %+v, want chain and jump owned", got)
+	}
+	if err := i.AddRule(t.Context(), &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	if err := i.RemoveAll(t.Context()); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		if _, ok := f.chains["filter/zapret_output"]; ok {
+			t.Errorf("%s: chain left after RemoveAll", name)
+		}
+		if got := f.chains["filter/OUTPUT"]; len(got) != 0 {
+			t.Errorf("%s: OUTPUT rules = %q, want none", name, got)
+		}
+	}
+}
+
+func TestBuildIptablesPorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports []string
+		want  [][]string
+	}{
+		{
+			name:  "no ports",
+			ports: nil,
+			want:  [][]string{nil},
+		},
+		{
+			name:  "single port",
+			ports: []string{"443"},
+			want:  [][]string{{"--dport", "443"}},
+		},
+		{
+			name:  "range",
+			ports: []string{"1024-65535"},
+			want:  [][]string{{"--dport", "1024:65535"}},
+		},
+		{
+			name:  "mixed list",
+			ports: []string{"80", "443", "1024-65535"},
+			want:  [][]string{{"-m", "multiport", "--dports", "80,443,1024:65535"}},
+		},
+		{
+			name:  "comma-joined element",
+			ports: []string{"80,443, 1024-65535"},
+			want:  [][]string{{"-m", "multiport", "--dports", "80,443,1024:65535"}},
+		},
+		{
+			name:  "empty parts",
+			ports: []string{"80,,443", ""},
+			want:  [][]string{{"-m", "multiport", "--dports", "80,443"}},
+		},
+		{
+			name:  "exactly fifteen ports",
+			ports: []string{"1,2,3,4,5,6,7,8,9,10,11,12,13,14,15"},
+			want: [][]string{
+				{"-m", "multiport", "--dports", "1,2,3,4,5,6,7,8,9,10,11,12,13,14,15"},
+			},
+		},
+		{
+			name:  "more than fifteen ports",
+			ports: []string{"1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17"},
+			want: [][]string{
+				{"-m", "multiport", "--dports", "1,2,3,4,5,6,7,8,9,10,11,12,13,14,15"},
+				{"-m", "multiport", "--dports", "16,17"},
+			},
+		},
+		{
+			name:  "range counts as two ports",
+			ports: []string{"1,2,3,4,5,6,7,8,9,10,11,12,13,14,100-200"},
+			want: [][]string{
+				{"-m", "multiport", "--dports", "1,2,3,4,5,6,7,8,9,10,11,12,13,14"},
+				{"--dport", "100:200"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildIptablesPorts(tt.ports)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildIptablesPorts(%q) = %q, want %q", tt.ports, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildIptablesSpecsSplitsPorts(t *testing.T) {
+	rule := &Rule{
+		Protocol: "udp",
+		Ports:    []string{"50000-50100", "19294-19344", "443", "80", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+		QueueNum: 200,
+	}
+
+	queue := []string{"-m", "comment", "--comment", iptablesComment, "-j", "NFQUEUE", "--queue-num", "200", "--queue-bypass"}
+	want := [][]string{
+		append([]string{"-p", "udp", "-m", "multiport", "--dports", "50000:50100,19294:19344,443,80,1,2,3,4,5,6,7,8,9"}, queue...),
+		append([]string{"-p", "udp", "--dport", "10"}, queue...),
+	}
+	if got := buildIptablesSpecs(rule, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildIptablesSpecs() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildIptablesSpecsFamilyOverrides(t *testing.T) {
+	rule := &Rule{
+		Protocol:    "udp",
+		Ports:       []string{"443", "50000-50100"},
+		PortsV6:     []string{"443"},
+		QueueNum:    201,
+		Interface:   "eth0",
+		InterfaceV6: "he-ipv6",
+	}
+
+	queue := []string{"-m", "comment", "--comment", iptablesComment, "-j", "NFQUEUE", "--queue-num", "201", "--queue-bypass"}
+	want4 := [][]string{
+		append([]string{"-p", "udp", "-o", "eth0", "-m", "multiport", "--dports", "443,50000:50100"}, queue...),
+	}
+	if got := buildIptablesSpecs(rule, false); !reflect.DeepEqual(got, want4) {
+		t.Errorf("buildIptablesSpecs(ipv4) = %q, want %q", got, want4)
+	}
+
+	want6 := [][]string{
+		append([]string{"-p", "udp", "-o", "he-ipv6", "--dport", "443"}, queue...),
+	}
+	if got := buildIptablesSpecs(rule, true); !reflect.DeepEqual(got, want6) {
+		t.Errorf("buildIptablesSpecs(ipv6) = %q, want %q", got, want6)
+	}
+}
+
+func TestIptablesRenderConnbytes(t *testing.T) {
+	const (
+		match     = "-m comment --comment Added by zapret-ng"
+		connbytes = "-m connbytes --connbytes-dir original --connbytes-mode packets --connbytes"
+	)
+	tests := []struct {
+		name string
+		rule *Rule
+		want []string
+	}{
+		{
+			name: "limit",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200, ConnbytesLimit: 6},
+			want: []string{
+				"iptables -t filter -A zapret_output -p tcp --dport 443 " + match + " " + connbytes + " 1:6 -j NFQUEUE --queue-num 200 --queue-bypass",
+				"ip6tables -t filter -A zapret_output -p tcp --dport 443 " + match + " " + connbytes + " 1:6 -j NFQUEUE --queue-num 200 --queue-bypass",
+			},
+		},
+		{
+			// Each split port rule has the limit
+			name: "limit with split ports",
+			rule: &Rule{Protocol: "udp", Ports: []string{"1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16"}, PortsV6: []string{"443"}, QueueNum: 201, ConnbytesLimit: 2},
+			want: []string{
+				"iptables -t filter -A zapret_output -p udp -m multiport --dports 1,2,3,4,5,6,7,8,9,10,11,12,13,14,15 " + match + " " + connbytes + " 1:2 -j NFQUEUE --queue-num 201 --queue-bypass",
+				"iptables -t filter -A zapret_output -p udp --dport 16 " + match + " " + connbytes + " 1:2 -j NFQUEUE --queue-num 201 --queue-bypass",
+				"ip6tables -t filter -A zapret_output -p udp --dport 443 " + match + " " + connbytes + " 1:2 -j NFQUEUE --queue-num 201 --queue-bypass",
+			},
+		},
+		{
+			// Packets past the limit are neither queued nor counted as rate limited
+			name: "limit with a rate limit",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 202, ConnbytesLimit: 6, RateLimit: 100},
+			want: []string{
+				"iptables -t filter -A zapret_output -p tcp --dport 443 " + match + " " + connbytes + " 1:6 -m limit --limit 100/second --limit-burst 100 -j NFQUEUE --queue-num 202 --queue-bypass",
+				"iptables -t filter -A zapret_output -p tcp --dport 443 " + match + " " + connbytes + " 1:6 -j RETURN",
+				"ip6tables -t filter -A zapret_output -p tcp --dport 443 " + match + " " + connbytes + " 1:6 -m limit --limit 100/second --limit-burst 100 -j NFQUEUE --queue-num 202 --queue-bypass",
+				"ip6tables -t filter -A zapret_output -p tcp --dport 443 " + match + " " + connbytes + " 1:6 -j RETURN",
+			},
+		},
+		{
+			name: "no limit",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 203},
+			want: []string{
+				"iptables -t filter -A zapret_output -p tcp --dport 443 " + match + " -j NFQUEUE --queue-num 203 --queue-bypass",
+				"ip6tables -t filter -A zapret_output -p tcp --dport 443 " + match + " -j NFQUEUE --queue-num 203 --queue-bypass",
+			},
+		},
+		{
+			// Untracked packets have no connection to count
+			name: "notrack",
+			rule: &Rule{Protocol: "udp", Ports: []string{"443"}, QueueNum: 204, ConnbytesLimit: 6, Notrack: true},
+			want: []string{
+				"iptables -t filter -A zapret_output -p udp --dport 443 " + match + " -j NFQUEUE --queue-num 204 --queue-bypass",
+				"iptables -t raw -A zapret_raw -p udp --dport 443 " + match + " -j NOTRACK",
+				"ip6tables -t filter -A zapret_output -p udp --dport 443 " + match + " -j NFQUEUE --queue-num 204 --queue-bypass",
+				"ip6tables -t raw -A zapret_raw -p udp --dport 443 " + match + " -j NOTRACK",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestIptables(newFakeIptables(), newFakeIptables()).Render(tt.rule)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIptablesRenderMarks(t *testing.T) {
+	tests := []struct {
+		name string
+		mark MarkMatch
+		want string // the matches between the ports and the comment
+	}{
+		{name: "match", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xffffffff}, want: "-m mark --mark 0x100/0xffffffff"},
+		{name: "match with a mask", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xff00}, want: "-m mark --mark 0x100/0xff00"},
+		{name: "negated match", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xff00, Negate: true}, want: "-m mark ! --mark 0x100/0xff00"},
+		{name: "exclude", mark: MarkMatch{Exclude: 0x40000000}, want: "-m mark ! --mark 0x40000000/0x40000000"},
+		{
+			name: "match and exclude",
+			mark: MarkMatch{Match: true, MatchValue: 0x1, MatchMask: 0xff, Exclude: 0x40000000},
+			want: "-m mark --mark 0x1/0xff -m mark ! --mark 0x40000000/0x40000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &Rule{Protocol: "udp", Ports: []string{"443"}, QueueNum: 200, Interface: "wg0", Notrack: true, Mark: tt.mark}
+			got, err := newTestIptables(newFakeIptables(), newFakeIptables()).Render(rule)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			// Notrack rules skip the same packets as the queue rules
+			var want []string
+			for _, cmd := range []string{"iptables", "ip6tables"} {
+				want = append(want,
+					cmd+" -t filter -A zapret_output -p udp -o wg0 --dport 443 "+tt.want+" -m comment --comment Added by zapret-ng -j NFQUEUE --queue-num 200 --queue-bypass",
+					cmd+" -t raw -A zapret_raw -p udp -o wg0 --dport 443 "+tt.want+" -m comment --comment Added by zapret-ng -j NOTRACK",
+				)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Render() =\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+func TestIptablesRawChainLifecycle(t *testing.T) {
+	ipt4, ipt6 := newFakeIptables(), newFakeIptables()
+	i := newTestIptables(ipt4, ipt6)
+	if err := i.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	// Only a notrack rule creates the raw chain
+	tracked := &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}
+	if err := i.AddRule(t.Context(), tracked); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		if _, ok := f.chains["raw/zapret_raw"]; ok {
+			t.Errorf("%s: raw chain created for a tracked rule", name)
+		}
+	}
+
+	untracked := &Rule{Protocol: "udp", Ports: []string{"50000-50100"}, QueueNum: 201, Notrack: true}
+	if err := i.AddRule(t.Context(), untracked); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	want := []string{"-p udp --dport 50000:50100 -m comment --comment Added by zapret-ng -j NOTRACK"}
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		if got := f.chains["raw/zapret_raw"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: raw rules = %q, want %q", name, got, want)
+		}
+		if got := f.chains["raw/OUTPUT"]; !reflect.DeepEqual(got, []string{"-j zapret_raw"}) {
+			t.Errorf("%s: raw OUTPUT rules = %q, want the jump", name, got)
+		}
+	}
+
+	// RemoveRule takes the notrack rule with the queue rule
+	if err := i.RemoveRule(t.Context(), untracked); err != nil {
+		t.Fatalf("RemoveRule() error = %v", err)
+	}
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		if got := f.chains["raw/zapret_raw"]; len(got) != 0 {
+			t.Errorf("%s: raw rules after RemoveRule = %q, want none", name, got)
+		}
+	}
+
+	if err := i.AddRule(t.Context(), untracked); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	if err := i.RemoveAll(t.Context()); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	for name, f := range map[string]*fakeIptables{"ipv4": ipt4, "ipv6": ipt6} {
+		if _, ok := f.chains["raw/zapret_raw"]; ok {
+			t.Errorf("%s: raw chain left after RemoveAll", name)
+		}
+		if got := f.chains["raw/OUTPUT"]; len(got) != 0 {
+			t.Errorf("%s: raw OUTPUT rules = %q, want none", name, got)
+		}
+	}
+
+	// A later run creates the raw chain again
+	if err := i.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := i.AddRule(t.Context(), untracked); err != nil {
+		t.Fatalf("AddRule() after RemoveAll error = %v", err)
+	}
+	if got := ipt4.chains["raw/zapret_raw"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("raw rules after RemoveAll = %q, want %q", got, want)
+	}
+}
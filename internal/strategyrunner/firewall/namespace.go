@@ -0,0 +1,105 @@
+package firewall
+
+import (
+	"context"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// NamespacedFirewall runs every operation of the wrapped firewall inside a
+// network namespace, so the backend commands change only that namespace.
+type NamespacedFirewall struct {
+	fw        Firewall
+	namespace string
+}
+
+// NewNamespacedFirewall wraps fw to operate inside the named network namespace.
+func NewNamespacedFirewall(fw Firewall, namespace string) *NamespacedFirewall {
+	return &NamespacedFirewall{fw: fw, namespace: namespace}
+}
+
+// Setup prepares the firewall inside the namespace.
+func (n *NamespacedFirewall) Setup(ctx context.Context) error {
+	return netns.Do(n.namespace, func() error {
+		return n.fw.Setup(ctx)
+	})
+}
+
+// AddRule adds a rule inside the namespace.
+func (n *NamespacedFirewall) AddRule(ctx context.Context, rule *Rule) error {
+	return netns.Do(n.namespace, func() error {
+		return n.fw.AddRule(ctx, rule)
+	})
+}
+
+// RemoveAll removes all rules inside the namespace.
+func (n *NamespacedFirewall) RemoveAll(ctx context.Context) error {
+	return netns.Do(n.namespace, func() error {
+		return n.fw.RemoveAll(ctx)
+	})
+}
+
+// RemoveRule removes a single rule inside the namespace.
+func (n *NamespacedFirewall) RemoveRule(ctx context.Context, rule *Rule) error {
+	remover, ok := n.fw.(RuleRemover)
+	if !ok {
+		return ErrRemoveRuleUnsupported
+	}
+	return netns.Do(n.namespace, func() error {
+		return remover.RemoveRule(ctx, rule)
+	})
+}
+
+// Close closes the wrapped firewall.
+func (n *NamespacedFirewall) Close() error {
+	return n.fw.Close()
+}
+
+// Counters returns the counters of the rules inside the namespace.
+func (n *NamespacedFirewall) Counters(ctx context.Context) (map[int]Counter, error) {
+	reader, ok := n.fw.(CounterReader)
+	if !ok {
+		return nil, ErrCountersUnsupported
+	}
+
+	var counters map[int]Counter
+	err := netns.Do(n.namespace, func() error {
+		var err error
+		counters, err = reader.Counters(ctx)
+		return err
+	})
+	return counters, err
+}
+
+// Render returns the commands of the wrapped firewall; they apply to the namespace.
+func (n *NamespacedFirewall) Render(rule *Rule) ([]string, error) {
+	renderer, ok := n.fw.(Renderer)
+	if !ok {
+		return nil, ErrRenderUnsupported
+	}
+	return renderer.Render(rule)
+}
+
+// Mode returns the fallback mode of the wrapped firewall, "" if none.
+func (n *NamespacedFirewall) Mode() string {
+	if reporter, ok := n.fw.(ModeReporter); ok {
+		return reporter.Mode()
+	}
+	return ""
+}
+
+// Ownership returns the containers the wrapped firewall created.
+func (n *NamespacedFirewall) Ownership() Ownership {
+	if reporter, ok := n.fw.(OwnershipReporter); ok {
+		return reporter.Ownership()
+	}
+	return Ownership{}
+}
+
+// Reconnects returns the netlink reconnects of the wrapped firewall.
+func (n *NamespacedFirewall) Reconnects() uint64 {
+	if counter, ok := n.fw.(ReconnectCounter); ok {
+		return counter.Reconnects()
+	}
+	return 0
+}
@@ -5,21 +5,66 @@ package firewall
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // NftablesFirewall implements Firewall using nft CLI.
 type NftablesFirewall struct {
-	config     *Config
-	mu         sync.Mutex
-	ruleCount  int
-	tableName  string
-	chainName  string
-	comment    string
+	config    *Config
+	mu        sync.Mutex
+	ruleCount int
+	tableName string
+	chainName string
+	comment   string
+	logger    *slog.Logger
+	rawReady  bool
+	handles   map[int][]nftHandle // Rules added per queue, for RemoveRule
+	owned     Ownership           // Containers created by Setup, deleted by RemoveAll
+
+	// noSets is set once the kernel rejected an anonymous port set; rules
+	// are then expanded into one rule per port or range
+	noSets atomic.Bool
+
+	// reconnects counts operations retried after a netlink buffer overrun
+	reconnects atomic.Uint64
+
+	// run executes a command and returns its combined output; nil runs it
+	// with os/exec, tests set a fake nft
+	run func(name string, args ...string) ([]byte, error)
 }
 
+// nftHandle identifies a rule added by AddRule.
+type nftHandle struct {
+	chain  string
+	handle string
+}
+
+// nftHandleRe matches the handle nft --echo --handle prints for an added rule.
+var nftHandleRe = regexp.MustCompile(`# handle (\d+)`)
+
+// nftSetUnsupportedRe matches the EOPNOTSUPP nft reports when the kernel
+// can't create the anonymous (interval) set of a rule. Other errors, such as
+// "Invalid argument" for a bad port or interface, are errors in the rule
+// itself and must not disable sets.
+var nftSetUnsupportedRe = regexp.MustCompile(`(?i)operation not supported`)
+
+// nftOverrunRe matches nft errors caused by a netlink buffer overrun
+// (ENOBUFS) rather than by the command, seen when other tools flood netlink.
+// Every nft run opens a new netlink socket, so running it again reconnects.
+var nftOverrunRe = regexp.MustCompile(`(?i)no buffer space available|ENOBUFS`)
+
+// nftMissingRe matches nft errors for an object that doesn't exist.
+var nftMissingRe = regexp.MustCompile(`(?i)no such file or directory|does not exist`)
+
+// noSetsMode is reported while rules are expanded instead of using sets.
+const noSetsMode = "no set support, expanded rules"
+
 // NewNftablesFirewall creates a new nftables firewall instance.
 func NewNftablesFirewall(cfg *Config) (*NftablesFirewall, error) {
 	// Check if nft is available
@@ -27,75 +72,730 @@ func NewNftablesFirewall(cfg *Config) (*NftablesFirewall, error) {
 		return nil, fmt.Errorf("nft command not found: %w", err)
 	}
 
-	return &NftablesFirewall{
+	n := &NftablesFirewall{
 		config:    cfg,
 		tableName: cfg.TableName,
 		chainName: cfg.ChainName,
 		comment:   "Added by zapret-ng",
-	}, nil
+		logger:    cfg.Logger,
+		handles:   make(map[int][]nftHandle),
+	}
+	if cfg.Owned != nil {
+		n.owned = *cfg.Owned
+	}
+	return n, nil
 }
 
 // Setup creates the nftables table and chain.
+// An existing chain is reused when its type, hook and priority match the desired
+// definition, and recreated otherwise so two chains never process the same packets.
+// A table or chain that existed before is recorded as not owned: it is never
+// flushed or deleted, only the rules with our comment are removed from it.
 func (n *NftablesFirewall) Setup(ctx context.Context) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	// Check if table exists and clean it up
-	if err := n.runCommand("nft", "list", "tables"); err == nil {
-		// Check if our table exists
-		output, _ := exec.Command("nft", "list", "tables").Output()
-		if strings.Contains(string(output), n.tableName) {
-			// Delete existing table (this will cascade to chains and rules)
-			_ = n.runCommand("nft", "delete", "table", n.tableName)
+	// Create inet table (handles both IPv4 and IPv6) unless it exists
+	_, err := n.exec("nft", "list", "table", n.tableName)
+	switch {
+	case err == nil:
+		if !n.owned.Table {
+			n.logger.Info("using existing nftables table, only rules added by zapret-ng will be removed from it",
+				slog.String("table", n.tableName),
+			)
 		}
+	case nftMissingRe.MatchString(err.Error()):
+		if err := n.runCommand("nft", "add", "table", n.tableName); err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+		n.owned.Table = true
+	default:
+		return fmt.Errorf("failed to check for table: %w", err)
 	}
 
-	// Create inet table (handles both IPv4 and IPv6)
-	if err := n.runCommand("nft", "add", "table", n.tableName); err != nil {
-		return fmt.Errorf("failed to create table: %w", err)
+	// The raw chain is created with the first notrack rule; drop one left by
+	// a previous run so it doesn't outlive rules that no longer ask for it
+	n.rawReady = false
+	n.removeRawChain()
+	n.handles = make(map[int][]nftHandle)
+
+	desired := nftQueueChain(n.config.hook())
+
+	output, err := n.exec("nft", "list", "chain", n.tableName, n.chainName)
+	if err == nil {
+		existing, ok := parseChainSpec(output)
+		if !n.ownsChain() {
+			if !ok || existing != desired {
+				return fmt.Errorf("chain %s in table %s is %s, not %s, and was not created by zapret-ng; refusing to replace it",
+					n.chainName, n.tableName, existing, desired)
+			}
+			n.logger.Info("using existing nftables chain, only rules added by zapret-ng will be removed from it",
+				slog.String("table", n.tableName),
+				slog.String("chain", n.chainName),
+			)
+			n.deleteOwnRules(n.chainName)
+			return n.addExclusions(n.chainName)
+		}
+		if ok && existing == desired {
+			// Reuse the chain but drop anything left over from a previous run
+			if err := n.runCommand("nft", "flush", "chain", n.tableName, n.chainName); err != nil {
+				return fmt.Errorf("failed to flush existing chain: %w", err)
+			}
+			return n.addExclusions(n.chainName)
+		}
+
+		n.logger.Warn("existing chain definition differs, recreating",
+			slog.String("table", n.tableName),
+			slog.String("chain", n.chainName),
+			slog.String("old", existing.String()),
+			slog.String("new", desired.String()),
+		)
+		if err := n.runCommand("nft", "delete", "chain", n.tableName, n.chainName); err != nil {
+			return fmt.Errorf("failed to delete mismatched chain: %w", err)
+		}
 	}
 
 	// Create output chain with filter hook
-	chainDef := fmt.Sprintf("{ type filter hook output priority 0; }")
-	if err := n.runCommand("nft", "add", "chain", n.tableName, n.chainName, chainDef); err != nil {
+	if err := n.runCommand("nft", "add", "chain", n.tableName, n.chainName, desired.Definition()); err != nil {
 		return fmt.Errorf("failed to create chain: %w", err)
 	}
+	n.owned.Chain = true
+
+	return n.addExclusions(n.chainName)
+}
+
+// ownsChain reports whether the queue chain was created by Setup, on its own
+// or with the table.
+func (n *NftablesFirewall) ownsChain() bool {
+	return n.owned.Table || n.owned.Chain
+}
+
+// Ownership returns the containers created by Setup.
+func (n *NftablesFirewall) Ownership() Ownership {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.owned
+}
+
+// nftQueueChain returns the definition of the chain holding the queue rules
+// for hook. Postrouting runs after source NAT, so nfqws sees the addresses
+// packets leave with.
+func nftQueueChain(hook string) chainSpec {
+	priority := nftPriorityNames["filter"]
+	if hook == HookPostrouting {
+		priority = nftPriorityNames["srcnat"] + 1
+	}
+	return chainSpec{Type: "filter", Hook: hook, Priority: priority}
+}
+
+// rawHook returns the hook of the notrack chain for the queue hook. Routed
+// packets are tracked on arrival, so their notrack rules run in prerouting.
+func rawHook(hook string) string {
+	if hook == HookOutput {
+		return HookOutput
+	}
+	return "prerouting"
+}
+
+// rawChainName returns the name of the chain holding the notrack rules.
+func (n *NftablesFirewall) rawChainName() string {
+	return n.chainName + "_raw"
+}
+
+// ensureRawChain creates the raw priority chain for notrack rules. It runs
+// before conntrack so notrack applies to the first packet of a flow.
+func (n *NftablesFirewall) ensureRawChain() error {
+	if n.rawReady {
+		return nil
+	}
 
+	spec := chainSpec{Type: "filter", Hook: rawHook(n.config.hook()), Priority: nftPriorityNames["raw"]}
+	if err := n.runCommand("nft", "add", "chain", n.tableName, n.rawChainName(), spec.Definition()); err != nil {
+		return fmt.Errorf("failed to create raw chain: %w", err)
+	}
+	if err := n.addExclusions(n.rawChainName()); err != nil {
+		return err
+	}
+
+	n.rawReady = true
 	return nil
 }
 
-// runCommand executes nft command
+// excludeQueue keys the handles of the excluded network rules, which belong
+// to no queue.
+const excludeQueue = -1
+
+// addExclusions returns packets to the excluded networks from chain before
+// any queue rule sees them. If the kernel rejects the anonymous set, each
+// network gets its own rule, and so do all later port sets.
+func (n *NftablesFirewall) addExclusions(chain string) error {
+	added := len(n.handles[excludeQueue])
+	err := n.addExclusionRules(chain)
+	if err != nil && !n.noSets.Load() && n.exclusionsUseSet() && nftSetUnsupportedRe.MatchString(err.Error()) {
+		if err := n.deleteHandles(excludeQueue, added); err != nil {
+			return err
+		}
+		n.disableSets(err, slog.String("chain", chain))
+		err = n.addExclusionRules(chain)
+	}
+	return err
+}
+
+// exclusionsUseSet reports whether the excluded networks of a family are
+// matched with an anonymous set.
+func (n *NftablesFirewall) exclusionsUseSet() bool {
+	v4, v6 := splitFamilies(n.config.ExcludeNetworks)
+	return len(v4) > 1 || len(v6) > 1
+}
+
+// disableSets expands all later rules into one rule per port, range or
+// network after the kernel rejected an anonymous set. It is logged once.
+func (n *NftablesFirewall) disableSets(err error, attrs ...any) {
+	if n.noSets.Swap(true) {
+		return
+	}
+	n.logger.Warn("kernel rejected an nftables set, expanding rules per port and network from now on",
+		append(attrs, slog.Any("error", err))...)
+}
+
+// addExclusionRules adds the rules built by buildExclusions to chain.
+func (n *NftablesFirewall) addExclusionRules(chain string) error {
+	for _, ruleStr := range n.buildExclusions() {
+		if err := n.addRule(chain, excludeQueue, ruleStr); err != nil {
+			return fmt.Errorf("failed to add excluded networks rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// buildExclusions builds the return rules of the excluded networks. The inet
+// table sees both families, so IPv4 prefixes are matched with "ip daddr" and
+// IPv6 ones with "ip6 daddr"; no networks means no rules.
+func (n *NftablesFirewall) buildExclusions() []string {
+	v4, v6 := splitFamilies(n.config.ExcludeNetworks)
+	comment := fmt.Sprintf(`comment "%s (excluded networks)"`, n.comment)
+
+	var ruleStrs []string
+	for _, family := range []struct {
+		match    string
+		prefixes []string
+	}{{"ip daddr", v4}, {"ip6 daddr", v6}} {
+		switch {
+		case len(family.prefixes) == 0:
+		case len(family.prefixes) == 1 || n.noSets.Load():
+			for _, prefix := range family.prefixes {
+				ruleStrs = append(ruleStrs, fmt.Sprintf("%s %s return %s", family.match, prefix, comment))
+			}
+		default:
+			ruleStrs = append(ruleStrs, fmt.Sprintf("%s { %s } return %s",
+				family.match, strings.Join(family.prefixes, ", "), comment))
+		}
+	}
+	return ruleStrs
+}
+
+// deleteRawChain deletes the raw chain if it exists.
+func (n *NftablesFirewall) deleteRawChain() {
+	_ = n.runCommand("nft", "flush", "chain", n.tableName, n.rawChainName())
+	_ = n.runCommand("nft", "delete", "chain", n.tableName, n.rawChainName())
+}
+
+// removeRawChain deletes the raw chain. Next to a queue chain zapret-ng
+// didn't create only the rules with our comment are deleted, and the raw
+// chain once no other rules are left in it.
+func (n *NftablesFirewall) removeRawChain() {
+	if n.ownsChain() {
+		n.deleteRawChain()
+		return
+	}
+
+	n.deleteOwnRules(n.rawChainName())
+	output, err := n.exec("nft", "list", "chain", n.tableName, n.rawChainName())
+	if err == nil && nftChainRules(output) == 0 {
+		_ = n.runCommand("nft", "delete", "chain", n.tableName, n.rawChainName())
+	}
+}
+
+// deleteOwnRules deletes the rules with our comment from chain.
+func (n *NftablesFirewall) deleteOwnRules(chain string) {
+	output, err := n.exec("nft", "-a", "list", "chain", n.tableName, chain)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, n.comment) {
+			continue
+		}
+		if m := nftHandleRe.FindStringSubmatch(line); m != nil {
+			_ = n.runCommand("nft", "delete", "rule", n.tableName, chain, "handle", m[1])
+		}
+	}
+}
+
+// nftChainRules counts the rules in the output of nft list chain.
+func nftChainRules(output string) int {
+	rules := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", line == "}",
+			strings.HasPrefix(line, "table "), strings.HasPrefix(line, "chain "),
+			strings.HasPrefix(line, "type "), strings.HasPrefix(line, "policy "),
+			strings.HasPrefix(line, "comment "):
+			continue
+		}
+		rules++
+	}
+	return rules
+}
+
+// chainSpec describes the base chain parameters.
+type chainSpec struct {
+	Type     string
+	Hook     string
+	Priority int
+}
+
+// Definition returns the nft chain definition block.
+func (c chainSpec) Definition() string {
+	return fmt.Sprintf("{ type %s hook %s priority %d; }", c.Type, c.Hook, c.Priority)
+}
+
+// String returns a human-readable representation of the chain parameters.
+func (c chainSpec) String() string {
+	return fmt.Sprintf("type %s hook %s priority %d", c.Type, c.Hook, c.Priority)
+}
+
+// nftPriorityNames maps symbolic priorities printed by nft to their values.
+var nftPriorityNames = map[string]int{
+	"raw":      -300,
+	"mangle":   -150,
+	"dstnat":   -100,
+	"filter":   0,
+	"security": 50,
+	"srcnat":   100,
+}
+
+// parseChainSpec extracts type, hook and priority from `nft list chain` output.
+// It returns false for regular chains without a hook.
+func parseChainSpec(output string) (chainSpec, bool) {
+	var spec chainSpec
+
+	fields := strings.Fields(strings.NewReplacer(";", " ", "{", " ", "}", " ").Replace(output))
+	found := false
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "type":
+			spec.Type = fields[i+1]
+		case "hook":
+			spec.Hook = fields[i+1]
+			found = true
+		case "priority":
+			value := fields[i+1]
+			// Priorities may be printed as "filter", "filter + 10" or a number
+			if base, ok := nftPriorityNames[value]; ok {
+				spec.Priority = base
+				if i+3 < len(fields) && (fields[i+2] == "+" || fields[i+2] == "-") {
+					if offset, err := strconv.Atoi(fields[i+3]); err == nil {
+						if fields[i+2] == "-" {
+							offset = -offset
+						}
+						spec.Priority += offset
+					}
+				}
+			} else if p, err := strconv.Atoi(value); err == nil {
+				spec.Priority = p
+			}
+		}
+	}
+
+	return spec, found
+}
+
+// runCommand executes nft command. After a netlink buffer overrun it is run
+// once more; a delete whose object is gone by then succeeded the first time.
 func (n *NftablesFirewall) runCommand(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	output, err := cmd.CombinedOutput()
+	_, err := n.exec(name, args...)
+	if err == nil || !nftOverrunRe.MatchString(err.Error()) {
+		return err
+	}
+
+	n.reconnected(err)
+	_, err = n.exec(name, args...)
+	if err != nil && len(args) > 0 && args[0] == "delete" && nftMissingRe.MatchString(err.Error()) {
+		return nil
+	}
+	return err
+}
+
+// exec runs a command and returns its combined output.
+func (n *NftablesFirewall) exec(name string, args ...string) (string, error) {
+	run := n.run
+	if run == nil {
+		run = func(name string, args ...string) ([]byte, error) {
+			return exec.Command(name, args...).CombinedOutput()
+		}
+	}
+	output, err := run(name, args...)
 	if err != nil {
-		return fmt.Errorf("command failed: %s: %w\nOutput: %s", strings.Join(append([]string{name}, args...), " "), err, string(output))
+		return "", fmt.Errorf("command failed: %s: %w\nOutput: %s", strings.Join(append([]string{name}, args...), " "), err, string(output))
+	}
+	return string(output), nil
+}
+
+// reconnected records an operation retried after a netlink buffer overrun.
+func (n *NftablesFirewall) reconnected(err error) {
+	n.reconnects.Add(1)
+	n.logger.Warn("netlink buffer overrun, retrying nft on a new socket", slog.Any("error", err))
+	if n.config.OnReconnect != nil {
+		n.config.OnReconnect(err)
+	}
+}
+
+// Reconnects returns the number of operations retried after a netlink
+// buffer overrun.
+func (n *NftablesFirewall) Reconnects() uint64 {
+	return n.reconnects.Load()
+}
+
+// addRule adds a rule to chain and remembers its handle under queue. After
+// a netlink buffer overrun the rule may have been added anyway, so the chain
+// is listed before adding it again.
+func (n *NftablesFirewall) addRule(chain string, queue int, ruleStr string) error {
+	output, err := n.exec("nft", "--echo", "--handle", "add", "rule", n.tableName, chain, ruleStr)
+	if err != nil && nftOverrunRe.MatchString(err.Error()) {
+		n.reconnected(err)
+		handle, found, listErr := n.unknownHandle(chain)
+		if listErr != nil {
+			return fmt.Errorf("%w (checking for the rule failed: %v)", err, listErr)
+		}
+		if found {
+			n.handles[queue] = append(n.handles[queue], nftHandle{chain: chain, handle: handle})
+			return nil
+		}
+		output, err = n.exec("nft", "--echo", "--handle", "add", "rule", n.tableName, chain, ruleStr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if m := nftHandleRe.FindStringSubmatch(output); m != nil {
+		n.handles[queue] = append(n.handles[queue], nftHandle{chain: chain, handle: m[1]})
 	}
 	return nil
 }
 
+// unknownHandle returns the handle of a rule with our comment in chain that
+// isn't remembered under any queue: one added by an attempt that reported
+// an error. Rules are only added with n.mu held, so there is at most one.
+func (n *NftablesFirewall) unknownHandle(chain string) (string, bool, error) {
+	output, err := n.exec("nft", "-a", "list", "chain", n.tableName, chain)
+	if err != nil {
+		return "", false, err
+	}
+
+	known := make(map[string]bool)
+	for _, handles := range n.handles {
+		for _, h := range handles {
+			if h.chain == chain {
+				known[h.handle] = true
+			}
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, n.comment) {
+			continue
+		}
+		if m := nftHandleRe.FindStringSubmatch(line); m != nil && !known[m[1]] {
+			return m[1], true, nil
+		}
+	}
+	return "", false, nil
+}
+
 // AddRule adds a firewall rule using nft CLI.
+// Rules with IPv6-specific overrides are split into one rule per address family.
+// If the kernel rejects the anonymous set matching several ports, the rule is
+// expanded into one rule per port or range, and so are all later rules.
 func (n *NftablesFirewall) AddRule(ctx context.Context, rule *Rule) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	// Build the nftables rule string
+	added := len(n.handles[rule.QueueNum])
+	err := n.addQueueRules(rule)
+	if err != nil && !n.noSets.Load() && usesSet(rule) && nftSetUnsupportedRe.MatchString(err.Error()) {
+		if err := n.deleteHandles(rule.QueueNum, added); err != nil {
+			return err
+		}
+		n.disableSets(err, slog.Int("queue", rule.QueueNum))
+		err = n.addQueueRules(rule)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !rule.Notrack {
+		return nil
+	}
+
+	rawStrs, err := n.buildRawRules(rule)
+	if err != nil {
+		return err
+	}
+
+	if err := n.ensureRawChain(); err != nil {
+		return err
+	}
+
+	for _, rawStr := range rawStrs {
+		if err := n.addRule(n.rawChainName(), rule.QueueNum, rawStr); err != nil {
+			return fmt.Errorf("failed to add notrack rule: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// addQueueRules adds the queue rules of rule to the main chain.
+func (n *NftablesFirewall) addQueueRules(rule *Rule) error {
+	ruleStrs, err := n.buildRules(rule)
+	if err != nil {
+		return err
+	}
+
+	for _, ruleStr := range ruleStrs {
+		// Execute nft command
+		if err := n.addRule(n.chainName, rule.QueueNum, ruleStr); err != nil {
+			return fmt.Errorf("failed to add rule: %w", err)
+		}
+
+		n.ruleCount++
+	}
+	return nil
+}
+
+// usesSet reports whether the rule matches several ports or ranges, which
+// needs an anonymous set unless rules are expanded.
+func usesSet(rule *Rule) bool {
+	for _, ipv6 := range []bool{false, true} {
+		if len(splitPorts(rule.PortsFor(ipv6))) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveRule deletes the rules AddRule added for rule's queue by their handles.
+func (n *NftablesFirewall) RemoveRule(ctx context.Context, rule *Rule) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.deleteHandles(rule.QueueNum, 0)
+}
+
+// deleteHandles deletes the rules of queue added after the first from ones.
+func (n *NftablesFirewall) deleteHandles(queue, from int) error {
+	handles := n.handles[queue]
+	for len(handles) > from {
+		h := handles[len(handles)-1]
+		if err := n.runCommand("nft", "delete", "rule", n.tableName, h.chain, "handle", h.handle); err != nil {
+			n.handles[queue] = handles
+			return fmt.Errorf("failed to delete rule: %w", err)
+		}
+		handles = handles[:len(handles)-1]
+		if h.chain == n.chainName {
+			n.ruleCount--
+		}
+	}
+	if len(handles) == 0 {
+		delete(n.handles, queue)
+	} else {
+		n.handles[queue] = handles
+	}
+
+	return nil
+}
+
+// Mode reports when rules are expanded because the kernel lacks set support.
+func (n *NftablesFirewall) Mode() string {
+	if n.noSets.Load() {
+		return noSetsMode
+	}
+	return ""
+}
+
+// Render returns the nft commands AddRule runs for rule.
+func (n *NftablesFirewall) Render(rule *Rule) ([]string, error) {
+	ruleStrs, err := n.buildRules(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	rawStrs, err := n.buildRawRules(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]string, 0, len(ruleStrs)+len(rawStrs))
+	for _, ruleStr := range ruleStrs {
+		cmds = append(cmds, fmt.Sprintf("nft add rule %s %s %s", n.tableName, n.chainName, ruleStr))
+	}
+	for _, rawStr := range rawStrs {
+		cmds = append(cmds, fmt.Sprintf("nft add rule %s %s %s", n.tableName, n.rawChainName(), rawStr))
+	}
+	return cmds, nil
+}
+
+// buildRawRules builds the notrack rule expressions of a rule, or none if
+// the rule keeps connection tracking.
+func (n *NftablesFirewall) buildRawRules(rule *Rule) ([]string, error) {
+	if !rule.Notrack {
+		return nil, nil
+	}
+
+	families := []string{""}
+	if rule.HasFamilyOverrides() {
+		families = []string{"ipv4", "ipv6"}
+	}
+
+	var rawStrs []string
+	for _, family := range families {
+		variants, err := n.buildMatches(rule, family, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, matches := range variants {
+			rawStrs = append(rawStrs, strings.Join(append(matches,
+				"notrack",
+				fmt.Sprintf(`comment "%s"`, n.comment),
+			), " "))
+		}
+	}
+
+	return rawStrs, nil
+}
+
+// buildRules builds the nft rule expressions of a rule.
+func (n *NftablesFirewall) buildRules(rule *Rule) ([]string, error) {
+	families := []string{""}
+	if rule.HasFamilyOverrides() {
+		families = []string{"ipv4", "ipv6"}
+	}
+
+	var ruleStrs []string
+	for _, family := range families {
+		variants, err := n.buildMatches(rule, family, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, matches := range variants {
+			// Later packets of a connection skip the queue and the rate limit counter
+			matches = append(matches, nftConnbytesMatches(rule)...)
+			ruleStrs = append(ruleStrs, n.buildQueueRule(rule, matches))
+			if rule.RateLimit > 0 {
+				// Count packets that exceeded the limit and fell through unqueued
+				ruleStrs = append(ruleStrs, strings.Join(append(matches,
+					"counter",
+					fmt.Sprintf(`comment "%s (rate limited q%d)"`, n.comment, rule.QueueNum),
+				), " "))
+			}
+		}
+	}
+
+	return ruleStrs, nil
+}
+
+// buildMatches builds the match expressions of a rule for the given family ("" for both).
+// It returns one set of matches per kernel rule: a single one, or one per port
+// or range while sets are unsupported. raw builds them for the notrack chain,
+// which knows no output interface before routing.
+func (n *NftablesFirewall) buildMatches(rule *Rule, family string, raw bool) ([][]string, error) {
 	var ruleParts []string
+	ipv6 := family == "ipv6"
 
-	// Add interface match if specified and not "any"
-	if rule.Interface != "" && rule.Interface != "any" {
-		ruleParts = append(ruleParts, fmt.Sprintf(`oifname "%s"`, rule.Interface))
+	// Restrict to a single address family when splitting
+	if family != "" {
+		ruleParts = append(ruleParts, fmt.Sprintf("meta nfproto %s", family))
 	}
 
+	// Add interface matches if specified and not "any"
+	if rule.InputInterface != "" && rule.InputInterface != "any" {
+		ruleParts = append(ruleParts, fmt.Sprintf(`iifname "%s"`, rule.InputInterface))
+	}
+	if iface := rule.InterfaceFor(ipv6); iface != "" && iface != "any" && !(raw && n.config.hook() != HookOutput) {
+		ruleParts = append(ruleParts, fmt.Sprintf(`oifname "%s"`, iface))
+	}
+
+	// Add packet mark matches
+	ruleParts = append(ruleParts, nftMarkMatches(rule.Mark)...)
+
 	// Add protocol match
 	ruleParts = append(ruleParts, rule.Protocol)
 
 	// Add port match - build port specification
-	portSpec, err := n.buildPortSpec(rule.Ports)
-	if err != nil {
-		return fmt.Errorf("failed to build port specification: %w", err)
+	ports := rule.PortsFor(ipv6)
+	if !n.noSets.Load() {
+		portSpec, err := n.buildPortSpec(ports)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build port specification: %w", err)
+		}
+		return [][]string{append(ruleParts, fmt.Sprintf("dport %s", portSpec))}, nil
+	}
+
+	parts := splitPorts(ports)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("failed to build port specification: no ports specified")
+	}
+	variants := make([][]string, 0, len(parts))
+	for _, part := range parts {
+		variants = append(variants, append(append([]string{}, ruleParts...), fmt.Sprintf("dport %s", part)))
+	}
+	return variants, nil
+}
+
+// nftMarkMatches builds the packet mark match expressions.
+func nftMarkMatches(m MarkMatch) []string {
+	var parts []string
+
+	if m.Match {
+		op := "=="
+		if m.Negate {
+			op = "!="
+		}
+		if m.MatchMask == 0xffffffff {
+			parts = append(parts, fmt.Sprintf("meta mark %s 0x%x", op, m.MatchValue))
+		} else {
+			parts = append(parts, fmt.Sprintf("meta mark and 0x%x %s 0x%x", m.MatchMask, op, m.MatchValue))
+		}
+	}
+
+	if m.Exclude != 0 {
+		parts = append(parts, fmt.Sprintf("meta mark and 0x%x == 0", m.Exclude))
+	}
+
+	return parts
+}
+
+// nftConnbytesMatches builds the match restricting the queue to the first
+// packets of each connection.
+func nftConnbytesMatches(rule *Rule) []string {
+	if rule.ConnbytesLimit <= 0 || rule.Notrack {
+		return nil
+	}
+	return []string{fmt.Sprintf("ct original packets 1-%d", rule.ConnbytesLimit)}
+}
+
+// buildQueueRule builds the rule sending matched packets to the queue.
+func (n *NftablesFirewall) buildQueueRule(rule *Rule, matches []string) string {
+	ruleParts := append([]string{}, matches...)
+
+	// Only queue packets within the rate limit
+	if rule.RateLimit > 0 {
+		ruleParts = append(ruleParts, fmt.Sprintf("limit rate %d/second", rule.RateLimit))
 	}
-	ruleParts = append(ruleParts, fmt.Sprintf("dport %s", portSpec))
 
 	// Add counter
 	ruleParts = append(ruleParts, "counter")
@@ -106,16 +806,7 @@ func (n *NftablesFirewall) AddRule(ctx context.Context, rule *Rule) error {
 	// Add comment
 	ruleParts = append(ruleParts, fmt.Sprintf(`comment "%s"`, n.comment))
 
-	// Build full rule
-	ruleStr := strings.Join(ruleParts, " ")
-
-	// Execute nft command
-	if err := n.runCommand("nft", "add", "rule", n.tableName, n.chainName, ruleStr); err != nil {
-		return fmt.Errorf("failed to add rule: %w", err)
-	}
-
-	n.ruleCount++
-	return nil
+	return strings.Join(ruleParts, " ")
 }
 
 // buildPortSpec builds port specification for nftables rule.
@@ -125,15 +816,7 @@ func (n *NftablesFirewall) buildPortSpec(ports []string) (string, error) {
 		return "", fmt.Errorf("no ports specified")
 	}
 
-	// Join all port specs and parse
-	var allPorts []string
-	for _, portSpec := range ports {
-		// Split by comma to handle "80,443,1024-2048" format
-		parts := strings.Split(portSpec, ",")
-		for _, part := range parts {
-			allPorts = append(allPorts, strings.TrimSpace(part))
-		}
-	}
+	allPorts := splitPorts(ports)
 
 	if len(allPorts) == 0 {
 		return "", fmt.Errorf("no ports after parsing")
@@ -148,50 +831,101 @@ func (n *NftablesFirewall) buildPortSpec(ports []string) (string, error) {
 	return fmt.Sprintf("{ %s }", strings.Join(allPorts, ", ")), nil
 }
 
+// splitPorts splits port specs like "80,443,1024-2048" into single ports and ranges.
+func splitPorts(ports []string) []string {
+	var parts []string
+	for _, portSpec := range ports {
+		for _, part := range strings.Split(portSpec, ",") {
+			parts = append(parts, strings.TrimSpace(part))
+		}
+	}
+	return parts
+}
+
 // RemoveAll removes all rules and cleans up the firewall setup.
 func (n *NftablesFirewall) RemoveAll(ctx context.Context) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	// Check if table exists
-	output, err := exec.Command("nft", "list", "tables").Output()
+	output, err := n.exec("nft", "list", "tables")
 	if err != nil {
 		// nft command failed, nothing to clean
 		return nil
 	}
 
-	if !strings.Contains(string(output), n.tableName) {
+	if !strings.Contains(output, n.tableName) {
 		// Table doesn't exist, nothing to clean
 		return nil
 	}
 
-	// Check if chain exists and delete rules with our comment
-	chainOutput, err := exec.Command("nft", "-a", "list", "chain", n.tableName, n.chainName).Output()
-	if err == nil {
-		// Parse handles of rules with our comment
-		lines := strings.Split(string(chainOutput), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, n.comment) {
-				// Extract handle number from line like: "... handle 42"
-				fields := strings.Fields(line)
-				for i, field := range fields {
-					if field == "handle" && i+1 < len(fields) {
-						handle := fields[i+1]
-						_ = n.runCommand("nft", "delete", "rule", n.tableName, n.chainName, "handle", handle)
-					}
-				}
-			}
-		}
+	// Delete rules with our comment, the only ones removed from a chain
+	// zapret-ng didn't create
+	n.deleteOwnRules(n.chainName)
+	n.removeRawChain()
+	n.rawReady = false
+	n.handles = make(map[int][]nftHandle)
+
+	// Delete the containers Setup created
+	switch {
+	case n.owned.Table:
+		_ = n.runCommand("nft", "delete", "chain", n.tableName, n.chainName)
+		_ = n.runCommand("nft", "delete", "table", n.tableName)
+	case n.owned.Chain:
+		_ = n.runCommand("nft", "delete", "chain", n.tableName, n.chainName)
+		n.logger.Info("removed zapret-ng chain, leaving the existing nftables table in place",
+			slog.String("table", n.tableName),
+			slog.String("chain", n.chainName),
+		)
+	default:
+		n.logger.Info("removed zapret-ng rules, leaving the existing nftables table and chain in place",
+			slog.String("table", n.tableName),
+			slog.String("chain", n.chainName),
+		)
 	}
-
-	// Delete chain and table
-	_ = n.runCommand("nft", "delete", "chain", n.tableName, n.chainName)
-	_ = n.runCommand("nft", "delete", "table", n.tableName)
+	n.owned = Ownership{}
 
 	n.ruleCount = 0
 	return nil
 }
 
+// nftCounterRe matches the counter and queue number of a queue rule in nft output.
+// Newer nft versions print "queue flags bypass to N" instead of "queue num N bypass".
+var nftCounterRe = regexp.MustCompile(`counter packets (\d+) bytes (\d+) .*queue .*(?:num|to) (\d+)`)
+
+// Counters returns the counters of the queue rules in the chain.
+func (n *NftablesFirewall) Counters(ctx context.Context) (map[int]Counter, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	output, err := n.exec("nft", "list", "chain", n.tableName, n.chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chain: %w", err)
+	}
+
+	return parseNftCounters(output), nil
+}
+
+// parseNftCounters sums the counters of queue rules per queue number.
+func parseNftCounters(output string) map[int]Counter {
+	counters := make(map[int]Counter)
+	for _, line := range strings.Split(output, "\n") {
+		m := nftCounterRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		packets, _ := strconv.ParseUint(m[1], 10, 64)
+		bytes, _ := strconv.ParseUint(m[2], 10, 64)
+		queue, _ := strconv.Atoi(m[3])
+
+		c := counters[queue]
+		c.Packets += packets
+		c.Bytes += bytes
+		counters[queue] = c
+	}
+	return counters
+}
+
 // Close closes the nftables firewall and removes all rules.
 func (n *NftablesFirewall) Close() error {
 	return n.RemoveAll(context.Background())
@@ -0,0 +1,724 @@
+//go:build linux
+
+package firewall
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestNftables returns an nftables firewall that only builds rules.
+func newTestNftables() *NftablesFirewall {
+	return &NftablesFirewall{
+		config:    &Config{},
+		tableName: "inet zapret",
+		chainName: "output",
+		comment:   "Added by zapret-ng",
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		handles:   make(map[int][]nftHandle),
+	}
+}
+
+// fakeNft models the tables, chains and rules the nft CLI manages, for the
+// commands the nftables firewall runs.
+type fakeNft struct {
+	tables map[string]map[string]*fakeNftChain
+	handle int
+	calls  []string
+
+	// addErr, if set, is called with each rule added and fails the add
+	// with the error it returns
+	addErr func(rule string) error
+}
+
+// fakeNftChain is a chain with its definition, such as
+// "type filter hook output priority 0;", and rules.
+type fakeNftChain struct {
+	def   string
+	rules []fakeNftRule
+}
+
+type fakeNftRule struct {
+	handle int
+	text   string
+}
+
+func newFakeNft() *fakeNft {
+	return &fakeNft{tables: make(map[string]map[string]*fakeNftChain)}
+}
+
+// newTestNftablesWith returns an nftables firewall running commands against f.
+func newTestNftablesWith(f *fakeNft, cfg *Config) *NftablesFirewall {
+	n := newTestNftables()
+	n.config = cfg
+	n.run = f.run
+	return n
+}
+
+// addChain adds a chain to table, creating the table if needed.
+func (f *fakeNft) addChain(table, chain, def string, rules ...string) {
+	if f.tables[table] == nil {
+		f.tables[table] = make(map[string]*fakeNftChain)
+	}
+	c := &fakeNftChain{def: def}
+	for _, rule := range rules {
+		f.handle++
+		c.rules = append(c.rules, fakeNftRule{handle: f.handle, text: rule})
+	}
+	f.tables[table][chain] = c
+}
+
+// rules returns the rule texts of chain in table.
+func (f *fakeNft) rules(table, chain string) []string {
+	c := f.tables[table][chain]
+	if c == nil {
+		return nil
+	}
+	var rules []string
+	for _, r := range c.rules {
+		rules = append(rules, r.text)
+	}
+	return rules
+}
+
+var errNftMissing = errors.New("Error: No such file or directory")
+
+func (f *fakeNft) run(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+
+	withHandles := false
+	if len(args) > 0 && args[0] == "-a" {
+		withHandles, args = true, args[1:]
+	}
+	if len(args) > 2 && args[0] == "--echo" && args[1] == "--handle" {
+		withHandles, args = true, args[2:]
+	}
+	if len(args) < 2 {
+		return nil, fmt.Errorf("fake nft: unsupported command %q", args)
+	}
+
+	switch args[0] + " " + args[1] {
+	case "list tables":
+		var out strings.Builder
+		for table := range f.tables {
+			fmt.Fprintf(&out, "table %s\n", table)
+		}
+		return []byte(out.String()), nil
+	case "list table":
+		if f.tables[args[2]] == nil {
+			return nil, errNftMissing
+		}
+		return []byte("table " + args[2] + " {\n}\n"), nil
+	case "add table":
+		if f.tables[args[2]] == nil {
+			f.tables[args[2]] = make(map[string]*fakeNftChain)
+		}
+		return nil, nil
+	case "delete table":
+		if f.tables[args[2]] == nil {
+			return nil, errNftMissing
+		}
+		delete(f.tables, args[2])
+		return nil, nil
+	}
+
+	table := f.tables[args[2]]
+	if table == nil {
+		return nil, errNftMissing
+	}
+	chain := table[args[3]]
+
+	switch args[0] + " " + args[1] {
+	case "add chain":
+		if chain == nil {
+			def := ""
+			if len(args) > 4 {
+				def = strings.TrimSpace(strings.Trim(args[4], "{}"))
+			}
+			table[args[3]] = &fakeNftChain{def: def}
+		}
+		return nil, nil
+	case "add rule":
+		if chain == nil {
+			return nil, errNftMissing
+		}
+		if f.addErr != nil {
+			if err := f.addErr(args[4]); err != nil {
+				return nil, err
+			}
+		}
+		f.handle++
+		chain.rules = append(chain.rules, fakeNftRule{handle: f.handle, text: args[4]})
+		if withHandles {
+			return fmt.Appendf(nil, "add rule %s %s %s # handle %d\n", args[2], args[3], args[4], f.handle), nil
+		}
+		return nil, nil
+	}
+
+	if chain == nil {
+		return nil, errNftMissing
+	}
+	switch args[0] + " " + args[1] {
+	case "list chain":
+		var out strings.Builder
+		fmt.Fprintf(&out, "table %s {\n\tchain %s {\n", args[2], args[3])
+		if chain.def != "" {
+			fmt.Fprintf(&out, "\t\t%s\n", chain.def)
+		}
+		for _, r := range chain.rules {
+			if withHandles {
+				fmt.Fprintf(&out, "\t\t%s # handle %d\n", r.text, r.handle)
+			} else {
+				fmt.Fprintf(&out, "\t\t%s\n", r.text)
+			}
+		}
+		out.WriteString("\t}\n}\n")
+		return []byte(out.String()), nil
+	case "flush chain":
+		chain.rules = nil
+		return nil, nil
+	case "delete chain":
+		if len(chain.rules) > 0 {
+			return nil, errors.New("Error: Could not process rule: Device or resource busy")
+		}
+		delete(table, args[3])
+		return nil, nil
+	case "delete rule":
+		for i, r := range chain.rules {
+			if fmt.Sprint(r.handle) == args[5] {
+				chain.rules = append(chain.rules[:i], chain.rules[i+1:]...)
+				return nil, nil
+			}
+		}
+		return nil, errNftMissing
+	}
+	return nil, fmt.Errorf("fake nft: unsupported command %q", args)
+}
+
+func TestParseChainSpec(t *testing.T) {
+	tests := []struct {
+		output string
+		want   chainSpec
+		ok     bool
+	}{
+		{"type filter hook output priority 0; policy accept;", chainSpec{"filter", "output", 0}, true},
+		{"type filter hook output priority filter; policy accept;", chainSpec{"filter", "output", 0}, true},
+		{"type filter hook postrouting priority srcnat + 1;", chainSpec{"filter", "postrouting", 101}, true},
+		{"type filter hook prerouting priority raw;", chainSpec{"filter", "prerouting", -300}, true},
+		{"type filter hook output priority mangle - 5;", chainSpec{"filter", "output", -155}, true},
+		{"type filter hook forward priority -10;", chainSpec{"filter", "forward", -10}, true},
+		{"chain regular {\n}", chainSpec{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseChainSpec(tt.output)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseChainSpec(%q) = %v, %v, want %v, %v", tt.output, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNftablesSetupCreatesTableAndChain(t *testing.T) {
+	f := newFakeNft()
+	n := newTestNftablesWith(f, &Config{})
+
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	chain := f.tables["inet zapret"]["output"]
+	if chain == nil {
+		t.Fatal("queue chain was not created")
+	}
+	if got, _ := parseChainSpec(chain.def); got != nftQueueChain(HookOutput) {
+		t.Errorf("chain = %v, want %v", got, nftQueueChain(HookOutput))
+	}
+	if got := n.Ownership(); !got.Table {
+		t.Errorf("Ownership() = %+v, want the table owned", got)
+	}
+}
+
+func TestNftablesSetupReusesMatchingChain(t *testing.T) {
+	f := newFakeNft()
+	n := newTestNftablesWith(f, &Config{})
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := n.AddRule(t.Context(), &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+
+	// Printed symbolically, as newer nft versions do
+	f.tables["inet zapret"]["output"].def = "type filter hook output priority filter; policy accept;"
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("second Setup() error = %v", err)
+	}
+
+	for _, call := range f.calls {
+		if call == "delete chain inet zapret output" {
+			t.Errorf("matching chain was recreated")
+		}
+	}
+	if rules := f.rules("inet zapret", "output"); len(rules) != 0 {
+		t.Errorf("rules left from the previous run = %q, want none", rules)
+	}
+}
+
+func TestNftablesSetupRecreatesMismatchedChain(t *testing.T) {
+	f := newFakeNft()
+	n := newTestNftablesWith(f, &Config{})
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	f.tables["inet zapret"]["output"].def = "type filter hook output priority filter + 10;"
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("second Setup() error = %v", err)
+	}
+
+	got, _ := parseChainSpec(f.tables["inet zapret"]["output"].def)
+	if got != nftQueueChain(HookOutput) {
+		t.Errorf("chain = %v, want %v", got, nftQueueChain(HookOutput))
+	}
+}
+
+func TestNftablesSetupForeignChain(t *testing.T) {
+	const foreign = "ip daddr 10.0.0.0/8 accept"
+
+	t.Run("matching chain keeps other rules", func(t *testing.T) {
+		f := newFakeNft()
+		f.addChain("inet zapret", "output", "type filter hook output priority 0;",
+			foreign, `tcp dport 443 queue num 200 bypass comment "Added by zapret-ng"`)
+		n := newTestNftablesWith(f, &Config{})
+
+		if err := n.Setup(t.Context()); err != nil {
+			t.Fatalf("Setup() error = %v", err)
+		}
+		if got := f.rules("inet zapret", "output"); !reflect.DeepEqual(got, []string{foreign}) {
+			t.Errorf("rules = %q, want only %q", got, foreign)
+		}
+		if got := n.Ownership(); got != (Ownership{}) {
+			t.Errorf("Ownership() = %+v, want nothing owned", got)
+		}
+
+		if err := n.RemoveAll(t.Context()); err != nil {
+			t.Fatalf("RemoveAll() error = %v", err)
+		}
+		if got := f.rules("inet zapret", "output"); !reflect.DeepEqual(got, []string{foreign}) {
+			t.Errorf("rules after RemoveAll = %q, want only %q", got, foreign)
+		}
+	})
+
+	t.Run("mismatched chain is refused", func(t *testing.T) {
+		f := newFakeNft()
+		f.addChain("inet zapret", "output", "type filter hook output priority 10;", foreign)
+		n := newTestNftablesWith(f, &Config{})
+
+		if err := n.Setup(t.Context()); err == nil {
+			t.Fatal("Setup() succeeded, want an error for a foreign chain with another priority")
+		}
+		if got := f.rules("inet zapret", "output"); !reflect.DeepEqual(got, []string{foreign}) {
+			t.Errorf("rules = %q, want only %q", got, foreign)
+		}
+	})
+}
+
+func TestNftablesRawChainLifecycle(t *testing.T) {
+	f := newFakeNft()
+	n := newTestNftablesWith(f, &Config{})
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	// Only a notrack rule creates the raw chain
+	tracked := &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}
+	if err := n.AddRule(t.Context(), tracked); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	if f.tables["inet zapret"]["output_raw"] != nil {
+		t.Fatal("raw chain created for a tracked rule")
+	}
+
+	untracked := &Rule{Protocol: "udp", Ports: []string{"50000-50100"}, QueueNum: 201, Notrack: true}
+	for range 2 {
+		if err := n.AddRule(t.Context(), untracked); err != nil {
+			t.Fatalf("AddRule() error = %v", err)
+		}
+		if err := n.RemoveRule(t.Context(), untracked); err != nil {
+			t.Fatalf("RemoveRule() error = %v", err)
+		}
+	}
+	if err := n.AddRule(t.Context(), untracked); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+
+	raw := f.tables["inet zapret"]["output_raw"]
+	if raw == nil {
+		t.Fatal("raw chain was not created")
+	}
+	if got, _ := parseChainSpec(raw.def); got.Priority != nftPriorityNames["raw"] {
+		t.Errorf("raw chain = %v, want raw priority", got)
+	}
+	want := []string{`udp dport 50000-50100 notrack comment "Added by zapret-ng"`}
+	if got := f.rules("inet zapret", "output_raw"); !reflect.DeepEqual(got, want) {
+		t.Errorf("raw rules = %q, want %q", got, want)
+	}
+
+	// RemoveRule takes the notrack rule with the queue rule
+	if err := n.RemoveRule(t.Context(), untracked); err != nil {
+		t.Fatalf("RemoveRule() error = %v", err)
+	}
+	if got := f.rules("inet zapret", "output_raw"); len(got) != 0 {
+		t.Errorf("raw rules after RemoveRule = %q, want none", got)
+	}
+	if got := len(f.rules("inet zapret", "output")); got != 1 {
+		t.Errorf("%d queue rules after RemoveRule, want the tracked rule only", got)
+	}
+
+	if err := n.AddRule(t.Context(), untracked); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	if err := n.RemoveAll(t.Context()); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	if len(f.tables) != 0 {
+		t.Errorf("tables left after RemoveAll: %v", f.tables)
+	}
+
+	// A later run creates the raw chain again
+	if err := n.Setup(t.Context()); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := n.AddRule(t.Context(), untracked); err != nil {
+		t.Fatalf("AddRule() after RemoveAll error = %v", err)
+	}
+	if got := f.rules("inet zapret", "output_raw"); !reflect.DeepEqual(got, want) {
+		t.Errorf("raw rules after RemoveAll = %q, want %q", got, want)
+	}
+}
+
+func TestNftablesBuildRulesFamilyOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *Rule
+		want []string
+	}{
+		{
+			name: "same matches for both families",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"80", "443"}, QueueNum: 200, Interface: "eth0"},
+			want: []string{
+				`oifname "eth0" tcp dport { 80, 443 } counter queue num 200 bypass comment "Added by zapret-ng"`,
+			},
+		},
+		{
+			name: "IPv6 ports",
+			rule: &Rule{Protocol: "udp", Ports: []string{"443", "50000-50100"}, PortsV6: []string{"443"}, QueueNum: 201},
+			want: []string{
+				`meta nfproto ipv4 udp dport { 443, 50000-50100 } counter queue num 201 bypass comment "Added by zapret-ng"`,
+				`meta nfproto ipv6 udp dport 443 counter queue num 201 bypass comment "Added by zapret-ng"`,
+			},
+		},
+		{
+			name: "IPv6 interface",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 202, Interface: "eth0", InterfaceV6: "he-ipv6"},
+			want: []string{
+				`meta nfproto ipv4 oifname "eth0" tcp dport 443 counter queue num 202 bypass comment "Added by zapret-ng"`,
+				`meta nfproto ipv6 oifname "he-ipv6" tcp dport 443 counter queue num 202 bypass comment "Added by zapret-ng"`,
+			},
+		},
+		{
+			name: "IPv6 interface equal to the IPv4 one",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 203, Interface: "eth0", InterfaceV6: "eth0"},
+			want: []string{
+				`oifname "eth0" tcp dport 443 counter queue num 203 bypass comment "Added by zapret-ng"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestNftables().buildRules(tt.rule)
+			if err != nil {
+				t.Fatalf("buildRules: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildRules() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNftablesRenderConnbytes(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *Rule
+		want []string
+	}{
+		{
+			name: "limit",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200, ConnbytesLimit: 6},
+			want: []string{
+				`nft add rule inet zapret output tcp dport 443 ct original packets 1-6 counter queue num 200 bypass comment "Added by zapret-ng"`,
+			},
+		},
+		{
+			name: "limit with IPv6 ports",
+			rule: &Rule{Protocol: "udp", Ports: []string{"443", "50000-50100"}, PortsV6: []string{"443"}, QueueNum: 201, ConnbytesLimit: 2},
+			want: []string{
+				`nft add rule inet zapret output meta nfproto ipv4 udp dport { 443, 50000-50100 } ct original packets 1-2 counter queue num 201 bypass comment "Added by zapret-ng"`,
+				`nft add rule inet zapret output meta nfproto ipv6 udp dport 443 ct original packets 1-2 counter queue num 201 bypass comment "Added by zapret-ng"`,
+			},
+		},
+		{
+			// Packets past the limit are neither queued nor counted as rate limited
+			name: "limit with a rate limit",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 202, ConnbytesLimit: 6, RateLimit: 100},
+			want: []string{
+				`nft add rule inet zapret output tcp dport 443 ct original packets 1-6 limit rate 100/second counter queue num 202 bypass comment "Added by zapret-ng"`,
+				`nft add rule inet zapret output tcp dport 443 ct original packets 1-6 counter comment "Added by zapret-ng (rate limited q202)"`,
+			},
+		},
+		{
+			name: "no limit",
+			rule: &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 203},
+			want: []string{
+				`nft add rule inet zapret output tcp dport 443 counter queue num 203 bypass comment "Added by zapret-ng"`,
+			},
+		},
+		{
+			// Untracked packets have no connection to count
+			name: "notrack",
+			rule: &Rule{Protocol: "udp", Ports: []string{"443"}, QueueNum: 204, ConnbytesLimit: 6, Notrack: true},
+			want: []string{
+				`nft add rule inet zapret output udp dport 443 counter queue num 204 bypass comment "Added by zapret-ng"`,
+				`nft add rule inet zapret output_raw udp dport 443 notrack comment "Added by zapret-ng"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestNftables().Render(tt.rule)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNftablesRenderMarks(t *testing.T) {
+	tests := []struct {
+		name string
+		mark MarkMatch
+		want string // the matches between the interface and the protocol
+	}{
+		{name: "match", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xffffffff}, want: "meta mark == 0x100"},
+		{name: "match with a mask", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xff00}, want: "meta mark and 0xff00 == 0x100"},
+		{name: "negated match", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xffffffff, Negate: true}, want: "meta mark != 0x100"},
+		{name: "negated match with a mask", mark: MarkMatch{Match: true, MatchValue: 0x100, MatchMask: 0xff00, Negate: true}, want: "meta mark and 0xff00 != 0x100"},
+		{name: "exclude", mark: MarkMatch{Exclude: 0x40000000}, want: "meta mark and 0x40000000 == 0"},
+		{
+			name: "match and exclude",
+			mark: MarkMatch{Match: true, MatchValue: 0x1, MatchMask: 0xff, Exclude: 0x40000000},
+			want: "meta mark and 0xff == 0x1 meta mark and 0x40000000 == 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &Rule{Protocol: "udp", Ports: []string{"443"}, QueueNum: 200, Interface: "wg0", Notrack: true, Mark: tt.mark}
+			got, err := newTestNftables().Render(rule)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			// Notrack rules skip the same packets as the queue rules
+			want := []string{
+				`nft add rule inet zapret output oifname "wg0" ` + tt.want + ` udp dport 443 counter queue num 200 bypass comment "Added by zapret-ng"`,
+				`nft add rule inet zapret output_raw oifname "wg0" ` + tt.want + ` udp dport 443 notrack comment "Added by zapret-ng"`,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Render() =\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+func TestNftablesRetryAfterOverrun(t *testing.T) {
+	errOverrun := errors.New("netlink: Error: No buffer space available")
+	rule := &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}
+	queueRule := `tcp dport 443 counter queue num 200 bypass comment "Added by zapret-ng"`
+
+	tests := []struct {
+		name      string
+		command   string // the command overrunning, as "add rule" or "delete rule"
+		times     int    // how many runs of it overrun
+		applied   bool   // whether the overrunning runs took effect
+		wantErr   bool
+		wantAdds  int      // rule adds run
+		wantRules []string // the chain after AddRule
+	}{
+		{name: "add lost", command: "add rule", times: 1, wantAdds: 2, wantRules: []string{queueRule}},
+		{name: "add applied", command: "add rule", times: 1, applied: true, wantAdds: 1, wantRules: []string{queueRule}},
+		{name: "add retried once", command: "add rule", times: 2, wantErr: true, wantAdds: 2},
+		{name: "delete applied", command: "delete rule", times: 1, applied: true, wantAdds: 1, wantRules: []string{queueRule}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeNft()
+			var reported []error
+			n := newTestNftablesWith(f, &Config{OnReconnect: func(err error) { reported = append(reported, err) }})
+			if err := n.Setup(t.Context()); err != nil {
+				t.Fatalf("Setup() error = %v", err)
+			}
+
+			adds, overruns := 0, tt.times
+			n.run = func(name string, args ...string) ([]byte, error) {
+				cmd := strings.Join(args, " ")
+				if strings.Contains(cmd, "add rule") {
+					adds++
+				}
+				if !strings.Contains(cmd, tt.command) || overruns == 0 {
+					return f.run(name, args...)
+				}
+				overruns--
+				if tt.applied {
+					f.run(name, args...)
+				}
+				return nil, errOverrun
+			}
+
+			err := n.AddRule(t.Context(), rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if adds != tt.wantAdds {
+				t.Errorf("rule added %d times, want %d", adds, tt.wantAdds)
+			}
+			if got := f.rules("inet zapret", "output"); !reflect.DeepEqual(got, tt.wantRules) {
+				t.Errorf("rules = %q, want %q", got, tt.wantRules)
+			}
+
+			// A rule added by an overrunning run is known, and a delete
+			// that took effect before overrunning succeeded
+			if !tt.wantErr {
+				if err := n.RemoveRule(t.Context(), rule); err != nil {
+					t.Fatalf("RemoveRule() error = %v", err)
+				}
+				if got := f.rules("inet zapret", "output"); len(got) != 0 {
+					t.Errorf("rules after RemoveRule = %q, want none", got)
+				}
+			}
+
+			// Each overrun is retried once, on a new nft run
+			if got := n.Reconnects(); got != 1 {
+				t.Errorf("Reconnects() = %d, want 1", got)
+			}
+			if len(reported) != 1 || !errors.Is(reported[0], errOverrun) {
+				t.Errorf("OnReconnect called with %v, want the overrun once", reported)
+			}
+		})
+	}
+}
+
+func TestNftablesSetFallback(t *testing.T) {
+	errUnsupported := errors.New("Error: Could not process rule: Operation not supported")
+	errInvalid := errors.New("Error: Could not process rule: Invalid argument")
+	// failing fails the rules containing s with err
+	failing := func(s string, err error) func(string) error {
+		return func(rule string) error {
+			if strings.Contains(rule, s) {
+				return err
+			}
+			return nil
+		}
+	}
+	tests := []struct {
+		name      string
+		addErr    func(string) error
+		rule      *Rule
+		wantErr   bool
+		wantRules []string
+		wantMode  string
+	}{
+		{
+			name:   "set rejected as unsupported",
+			addErr: failing("{", errUnsupported),
+			rule:   &Rule{Protocol: "tcp", Ports: []string{"80", "443"}, QueueNum: 200},
+			wantRules: []string{
+				`ip daddr 10.0.0.0/8 return comment "Added by zapret-ng (excluded networks)"`,
+				`ip daddr 192.168.0.0/16 return comment "Added by zapret-ng (excluded networks)"`,
+				`tcp dport 80 counter queue num 200 bypass comment "Added by zapret-ng"`,
+				`tcp dport 443 counter queue num 200 bypass comment "Added by zapret-ng"`,
+			},
+			wantMode: noSetsMode,
+		},
+		{
+			name:    "invalid argument in a set rule",
+			addErr:  failing("dport", errInvalid),
+			rule:    &Rule{Protocol: "tcp", Ports: []string{"80", "443"}, QueueNum: 200},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported without a set",
+			addErr:  failing("dport", errUnsupported),
+			rule:    &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeNft()
+			n := newTestNftablesWith(f, &Config{ExcludeNetworks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}})
+			var logs bytes.Buffer
+			n.logger = slog.New(slog.NewTextHandler(&logs, nil))
+			if err := n.Setup(t.Context()); err != nil {
+				t.Fatalf("Setup() error = %v", err)
+			}
+			f.addErr = tt.addErr
+			if !tt.wantErr {
+				// Setup added the exclusions with a set; add them again
+				// to see them expanded too
+				f.tables["inet zapret"]["output"].rules = nil
+				n.handles = make(map[int][]nftHandle)
+				if err := n.addExclusions("output"); err != nil {
+					t.Fatalf("addExclusions() error = %v", err)
+				}
+			}
+
+			err := n.AddRule(t.Context(), tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("AddRule() succeeded, want the error returned")
+				}
+				if n.Mode() != "" {
+					t.Errorf("Mode() = %q after an unrelated error, want sets kept", n.Mode())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddRule() error = %v", err)
+			}
+			if got := f.rules("inet zapret", "output"); !reflect.DeepEqual(got, tt.wantRules) {
+				t.Errorf("rules =\n%q\nwant\n%q", got, tt.wantRules)
+			}
+			if got := n.Mode(); got != tt.wantMode {
+				t.Errorf("Mode() = %q, want %q", got, tt.wantMode)
+			}
+			if got := strings.Count(logs.String(), "kernel rejected an nftables set"); got != 1 {
+				t.Errorf("downgrade logged %d times, want once:\n%s", got, logs.String())
+			}
+		})
+	}
+}
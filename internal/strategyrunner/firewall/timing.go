@@ -0,0 +1,202 @@
+package firewall
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCountersUnsupported is returned when the firewall backend cannot report counters.
+var ErrCountersUnsupported = errors.New("firewall backend does not report counters")
+
+// OpStats contains timing statistics for a single firewall operation.
+type OpStats struct {
+	// Count is the number of times the operation was called
+	Count int
+
+	// Total is the accumulated duration of all calls
+	Total time.Duration
+
+	// Last is the duration of the most recent call
+	Last time.Duration
+
+	// Max is the longest observed duration
+	Max time.Duration
+}
+
+// TimedFirewall wraps a Firewall and records the latency of every call.
+// Operations slower than the threshold are logged as warnings.
+type TimedFirewall struct {
+	fw        Firewall
+	threshold time.Duration
+	logger    *slog.Logger
+	now       func() time.Time // time source of the measurements
+	mu        sync.Mutex
+	stats     map[string]*OpStats
+	last      time.Duration
+	max       time.Duration
+}
+
+// NewTimedFirewall wraps fw with timing instrumentation.
+// A zero threshold disables slow operation warnings.
+func NewTimedFirewall(fw Firewall, threshold time.Duration, logger *slog.Logger) *TimedFirewall {
+	return &TimedFirewall{
+		fw:        fw,
+		threshold: threshold,
+		logger:    logger,
+		now:       time.Now,
+		stats:     make(map[string]*OpStats),
+	}
+}
+
+// Setup prepares the wrapped firewall.
+func (t *TimedFirewall) Setup(ctx context.Context) error {
+	start := t.now()
+	err := t.fw.Setup(ctx)
+	t.record("setup", t.now().Sub(start))
+	return err
+}
+
+// AddRule adds a rule to the wrapped firewall.
+func (t *TimedFirewall) AddRule(ctx context.Context, rule *Rule) error {
+	start := t.now()
+	err := t.fw.AddRule(ctx, rule)
+	t.record("add_rule", t.now().Sub(start),
+		slog.String("protocol", rule.Protocol),
+		slog.Any("ports", rule.Ports),
+		slog.Int("queue", rule.QueueNum),
+	)
+	return err
+}
+
+// RemoveRule removes a single rule from the wrapped firewall.
+func (t *TimedFirewall) RemoveRule(ctx context.Context, rule *Rule) error {
+	remover, ok := t.fw.(RuleRemover)
+	if !ok {
+		return ErrRemoveRuleUnsupported
+	}
+
+	start := t.now()
+	err := remover.RemoveRule(ctx, rule)
+	t.record("remove_rule", t.now().Sub(start),
+		slog.String("protocol", rule.Protocol),
+		slog.Any("ports", rule.Ports),
+		slog.Int("queue", rule.QueueNum),
+	)
+	return err
+}
+
+// RemoveAll removes all rules from the wrapped firewall.
+func (t *TimedFirewall) RemoveAll(ctx context.Context) error {
+	start := t.now()
+	err := t.fw.RemoveAll(ctx)
+	t.record("remove_all", t.now().Sub(start))
+	return err
+}
+
+// Close closes the wrapped firewall.
+func (t *TimedFirewall) Close() error {
+	start := t.now()
+	err := t.fw.Close()
+	t.record("close", t.now().Sub(start))
+	return err
+}
+
+// Counters returns per-queue counters if the wrapped firewall supports them.
+func (t *TimedFirewall) Counters(ctx context.Context) (map[int]Counter, error) {
+	reader, ok := t.fw.(CounterReader)
+	if !ok {
+		return nil, ErrCountersUnsupported
+	}
+
+	start := t.now()
+	counters, err := reader.Counters(ctx)
+	t.record("counters", t.now().Sub(start))
+	return counters, err
+}
+
+// Render returns the commands the wrapped firewall would run to add rule.
+func (t *TimedFirewall) Render(rule *Rule) ([]string, error) {
+	renderer, ok := t.fw.(Renderer)
+	if !ok {
+		return nil, ErrRenderUnsupported
+	}
+	return renderer.Render(rule)
+}
+
+// Mode returns the fallback mode of the wrapped firewall, "" if none.
+func (t *TimedFirewall) Mode() string {
+	if reporter, ok := t.fw.(ModeReporter); ok {
+		return reporter.Mode()
+	}
+	return ""
+}
+
+// Reconnects returns the netlink reconnects of the wrapped firewall, 0 if it
+// doesn't reconnect.
+func (t *TimedFirewall) Reconnects() uint64 {
+	if counter, ok := t.fw.(ReconnectCounter); ok {
+		return counter.Reconnects()
+	}
+	return 0
+}
+
+// Ownership returns the containers the wrapped firewall created, none if it
+// doesn't track them.
+func (t *TimedFirewall) Ownership() Ownership {
+	if reporter, ok := t.fw.(OwnershipReporter); ok {
+		return reporter.Ownership()
+	}
+	return Ownership{}
+}
+
+// Stats returns a copy of the per-operation statistics.
+func (t *TimedFirewall) Stats() map[string]OpStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make(map[string]OpStats, len(t.stats))
+	for op, s := range t.stats {
+		result[op] = *s
+	}
+	return result
+}
+
+// Latency returns the duration of the last operation and the longest one observed.
+func (t *TimedFirewall) Latency() (last, max time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last, t.max
+}
+
+// record stores the duration of an operation and warns if it was slow.
+func (t *TimedFirewall) record(op string, d time.Duration, attrs ...any) {
+	t.mu.Lock()
+	s, ok := t.stats[op]
+	if !ok {
+		s = &OpStats{}
+		t.stats[op] = s
+	}
+	s.Count++
+	s.Total += d
+	s.Last = d
+	if d > s.Max {
+		s.Max = d
+	}
+	t.last = d
+	if d > t.max {
+		t.max = d
+	}
+	t.mu.Unlock()
+
+	if t.threshold > 0 && d > t.threshold {
+		attrs = append([]any{
+			slog.String("operation", op),
+			slog.Duration("duration", d),
+			slog.Duration("threshold", t.threshold),
+		}, attrs...)
+		t.logger.Warn("slow firewall operation", attrs...)
+	}
+}
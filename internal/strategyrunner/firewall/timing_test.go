@@ -0,0 +1,180 @@
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowFirewall is a firewall whose operations take the durations in took on
+// the clock of now and fail with the errors in errs.
+type slowFirewall struct {
+	now   time.Time
+	took  map[string]time.Duration
+	errs  map[string]error
+	calls []string
+}
+
+func (f *slowFirewall) op(name string) error {
+	f.calls = append(f.calls, name)
+	f.now = f.now.Add(f.took[name])
+	return f.errs[name]
+}
+
+func (f *slowFirewall) Setup(ctx context.Context) error { return f.op("setup") }
+
+func (f *slowFirewall) AddRule(ctx context.Context, rule *Rule) error { return f.op("add_rule") }
+
+func (f *slowFirewall) RemoveAll(ctx context.Context) error { return f.op("remove_all") }
+
+func (f *slowFirewall) Close() error { return f.op("close") }
+
+// slowRemover also removes single rules and reads counters.
+type slowRemover struct{ *slowFirewall }
+
+func (f slowRemover) RemoveRule(ctx context.Context, rule *Rule) error { return f.op("remove_rule") }
+
+func (f slowRemover) Counters(ctx context.Context) (map[int]Counter, error) {
+	return map[int]Counter{200: {Packets: 3}}, f.op("counters")
+}
+
+// newTestTimed wraps fw in a timed firewall reading the time of f and logging to log.
+func newTestTimed(fw Firewall, f *slowFirewall, threshold time.Duration, log *bytes.Buffer) *TimedFirewall {
+	t := NewTimedFirewall(fw, threshold, slog.New(slog.NewTextHandler(log, nil)))
+	t.now = func() time.Time { return f.now }
+	return t
+}
+
+func TestTimedFirewallRecordsDurations(t *testing.T) {
+	errBusy := errors.New("Device or resource busy")
+	f := &slowFirewall{
+		took: map[string]time.Duration{
+			"setup":       300 * time.Millisecond,
+			"add_rule":    20 * time.Millisecond,
+			"remove_rule": 10 * time.Millisecond,
+			"counters":    5 * time.Millisecond,
+			"remove_all":  100 * time.Millisecond,
+			"close":       time.Millisecond,
+		},
+		errs: map[string]error{"remove_all": errBusy},
+	}
+	var log bytes.Buffer
+	timed := newTestTimed(slowRemover{f}, f, time.Second, &log)
+	ctx := t.Context()
+	rule := &Rule{Protocol: "tcp", Ports: []string{"443"}, QueueNum: 200}
+
+	// Calls and their results pass through
+	if err := timed.Setup(ctx); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	for range 2 {
+		if err := timed.AddRule(ctx, rule); err != nil {
+			t.Fatalf("AddRule() error = %v", err)
+		}
+	}
+	f.took["add_rule"] = 40 * time.Millisecond
+	if err := timed.AddRule(ctx, rule); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	if err := timed.RemoveRule(ctx, rule); err != nil {
+		t.Fatalf("RemoveRule() error = %v", err)
+	}
+	if counters, err := timed.Counters(ctx); err != nil || counters[200].Packets != 3 {
+		t.Fatalf("Counters() = %v, %v, want the wrapped counters", counters, err)
+	}
+	if err := timed.RemoveAll(ctx); !errors.Is(err, errBusy) {
+		t.Fatalf("RemoveAll() error = %v, want %v", err, errBusy)
+	}
+	if err := timed.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	want := []string{"setup", "add_rule", "add_rule", "add_rule", "remove_rule", "counters", "remove_all", "close"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %q, want %q", f.calls, want)
+	}
+
+	// A failed call is timed like any other
+	wantStats := map[string]OpStats{
+		"setup":       {Count: 1, Total: 300 * time.Millisecond, Last: 300 * time.Millisecond, Max: 300 * time.Millisecond},
+		"add_rule":    {Count: 3, Total: 80 * time.Millisecond, Last: 40 * time.Millisecond, Max: 40 * time.Millisecond},
+		"remove_rule": {Count: 1, Total: 10 * time.Millisecond, Last: 10 * time.Millisecond, Max: 10 * time.Millisecond},
+		"counters":    {Count: 1, Total: 5 * time.Millisecond, Last: 5 * time.Millisecond, Max: 5 * time.Millisecond},
+		"remove_all":  {Count: 1, Total: 100 * time.Millisecond, Last: 100 * time.Millisecond, Max: 100 * time.Millisecond},
+		"close":       {Count: 1, Total: time.Millisecond, Last: time.Millisecond, Max: time.Millisecond},
+	}
+	if got := timed.Stats(); !reflect.DeepEqual(got, wantStats) {
+		t.Errorf("Stats() = %+v, want %+v", got, wantStats)
+	}
+	if last, max := timed.Latency(); last != time.Millisecond || max != 300*time.Millisecond {
+		t.Errorf("Latency() = %s, %s, want 1ms, 300ms", last, max)
+	}
+	if log.Len() != 0 {
+		t.Errorf("operations under the threshold logged:\n%s", log.String())
+	}
+}
+
+func TestTimedFirewallUnsupported(t *testing.T) {
+	f := &slowFirewall{}
+	var log bytes.Buffer
+	timed := newTestTimed(f, f, time.Second, &log)
+
+	if err := timed.RemoveRule(t.Context(), &Rule{QueueNum: 200}); !errors.Is(err, ErrRemoveRuleUnsupported) {
+		t.Errorf("RemoveRule() error = %v, want %v", err, ErrRemoveRuleUnsupported)
+	}
+	if _, err := timed.Counters(t.Context()); !errors.Is(err, ErrCountersUnsupported) {
+		t.Errorf("Counters() error = %v, want %v", err, ErrCountersUnsupported)
+	}
+	if _, err := timed.Render(&Rule{QueueNum: 200}); !errors.Is(err, ErrRenderUnsupported) {
+		t.Errorf("Render() error = %v, want %v", err, ErrRenderUnsupported)
+	}
+	if len(f.calls) != 0 || len(timed.Stats()) != 0 {
+		t.Errorf("unsupported operations reached the firewall (%q) or were timed (%v)", f.calls, timed.Stats())
+	}
+}
+
+func TestTimedFirewallSlowWarning(t *testing.T) {
+	rule := &Rule{Protocol: "udp", Ports: []string{"443", "50000-50100"}, QueueNum: 201}
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		took      time.Duration
+		want      string // the warning logged, "" for none
+	}{
+		{
+			name:      "slow",
+			threshold: 500 * time.Millisecond,
+			took:      750 * time.Millisecond,
+			want:      `level=WARN msg="slow firewall operation" operation=add_rule duration=750ms threshold=500ms protocol=udp ports="[443 50000-50100]" queue=201`,
+		},
+		{name: "at the threshold", threshold: 500 * time.Millisecond, took: 500 * time.Millisecond},
+		{name: "disabled", took: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &slowFirewall{took: map[string]time.Duration{"add_rule": tt.took}}
+			var log bytes.Buffer
+			timed := newTestTimed(f, f, tt.threshold, &log)
+			if err := timed.AddRule(t.Context(), rule); err != nil {
+				t.Fatalf("AddRule() error = %v", err)
+			}
+
+			got := log.String()
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("logged %q, want nothing", got)
+				}
+				return
+			}
+			// Drop the time attribute
+			if _, got, _ = strings.Cut(strings.TrimSpace(got), " "); got != tt.want {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,19 @@ package firewall
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
 )
 
+// ErrRenderUnsupported is returned when the firewall backend cannot render rules.
+var ErrRenderUnsupported = errors.New("firewall backend does not render rules")
+
+// ErrRemoveRuleUnsupported is returned when the firewall backend cannot remove single rules.
+var ErrRemoveRuleUnsupported = errors.New("firewall backend does not remove single rules")
+
 // Firewall is the interface for firewall implementations.
 type Firewall interface {
 	// Setup prepares the firewall (creates tables/chains)
@@ -19,6 +30,140 @@ type Firewall interface {
 	Close() error
 }
 
+// Counter contains packet and byte counts of the rules feeding a queue.
+type Counter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// CounterReader is implemented by backends that can report per-queue counters.
+// Reading counters requires a round trip to the kernel.
+type CounterReader interface {
+	// Counters returns the counters of all queue rules keyed by queue number
+	Counters(ctx context.Context) (map[int]Counter, error)
+}
+
+// Renderer is implemented by backends that can show the commands a rule is installed with.
+type Renderer interface {
+	// Render returns the backend commands that AddRule would run for rule
+	Render(rule *Rule) ([]string, error)
+}
+
+// RuleRemover is implemented by backends that can remove a single rule while
+// the others stay installed.
+type RuleRemover interface {
+	// RemoveRule removes everything AddRule installed for rule
+	RemoveRule(ctx context.Context, rule *Rule) error
+}
+
+// ModeReporter is implemented by backends that can fall back to a less
+// capable mode when the kernel lacks a feature.
+type ModeReporter interface {
+	// Mode describes the fallback in use, "" when running normally
+	Mode() string
+}
+
+// Ownership records which firewall containers Setup created. A table or
+// chain found already existing may hold rules of other tools, so RemoveAll
+// deletes only the rules added by zapret-ng from it and leaves it in place.
+type Ownership struct {
+	// Table is set if the nftables table was created by Setup
+	Table bool `json:"table"`
+
+	// Chain is set if the chain holding the queue rules was created by Setup
+	// (in every address family for iptables)
+	Chain bool `json:"chain"`
+
+	// Jump is set if the iptables jump rule to the chain was added by Setup
+	Jump bool `json:"jump,omitempty"`
+}
+
+// OwnershipReporter is implemented by backends that track which containers
+// they created.
+type OwnershipReporter interface {
+	// Ownership returns the containers created by Setup
+	Ownership() Ownership
+}
+
+// Hooks the queue rules can be attached to.
+const (
+	// HookOutput queues packets the host itself sends
+	HookOutput = "output"
+
+	// HookForward queues packets routed through the host, e.g. from a LAN
+	HookForward = "forward"
+
+	// HookPostrouting queues both, after source NAT
+	HookPostrouting = "postrouting"
+)
+
+// ValidateHook checks that hook is one of the supported hooks ("" is HookOutput).
+func ValidateHook(hook string) error {
+	switch hook {
+	case "", HookOutput, HookForward, HookPostrouting:
+		return nil
+	}
+	return fmt.Errorf("invalid hook %q (must be '%s', '%s' or '%s')", hook, HookOutput, HookForward, HookPostrouting)
+}
+
+// BackendAuto selects nftables, or iptables on kernels without nf_tables.
+const BackendAuto = "auto"
+
+// IptablesChainMaxLen is the longest chain name iptables accepts.
+const IptablesChainMaxLen = 28
+
+// IptablesChains returns the filter and raw table chains the iptables backend
+// uses for chainName. Chains are prefixed with "zapret_" so they can't clash
+// with built-in chains; the default "output" keeps the names of releases
+// that ignored chain_name.
+func IptablesChains(chainName string) (filter, raw string) {
+	if chainName == "" || chainName == "output" {
+		return "zapret_output", "zapret_raw"
+	}
+	filter = "zapret_" + chainName
+	return filter, filter + "_raw"
+}
+
+// ValidateIptablesChain checks that the chains for chainName are valid
+// iptables chain names.
+func ValidateIptablesChain(chainName string) error {
+	if strings.ContainsAny(chainName, " \t!") {
+		return fmt.Errorf("chain_name %q contains whitespace or '!'", chainName)
+	}
+	filter, raw := IptablesChains(chainName)
+	for _, name := range []string{filter, raw} {
+		if len(name) > IptablesChainMaxLen {
+			return fmt.Errorf("chain_name %q gives iptables chain %s, longer than %d characters", chainName, name, IptablesChainMaxLen)
+		}
+	}
+	return nil
+}
+
+// ReconnectCounter is implemented by backends that recover from netlink
+// buffer overruns by reconnecting.
+type ReconnectCounter interface {
+	// Reconnects returns the number of netlink reconnects so far
+	Reconnects() uint64
+}
+
+// MarkMatch restricts queued traffic by packet mark (fwmark).
+type MarkMatch struct {
+	// Match enables the positive match: only packets with mark & MatchMask == MatchValue are queued
+	Match bool
+
+	// MatchValue is the mark value compared after masking
+	MatchValue uint32
+
+	// MatchMask selects the compared mark bits
+	MatchMask uint32
+
+	// Negate inverts the match: only packets with a different masked mark are queued
+	Negate bool
+
+	// Exclude skips packets with any of these mark bits set (0 disables)
+	Exclude uint32
+}
+
 // Rule represents a firewall rule.
 type Rule struct {
 	// Protocol is the protocol ("tcp" or "udp")
@@ -33,13 +178,61 @@ type Rule struct {
 	// Interface is the network interface ("" for all)
 	Interface string
 
+	// InterfaceV6 overrides Interface for IPv6 traffic ("" to use Interface)
+	InterfaceV6 string
+
+	// InputInterface matches the interface forwarded packets arrive on ("" for
+	// all); only the forward hook sees it
+	InputInterface string
+
+	// PortsV6 overrides Ports for IPv6 traffic (nil to use Ports)
+	PortsV6 []string
+
+	// RateLimit is the maximum packets per second sent to the queue (0 for unlimited).
+	// Packets over the limit skip the queue and are accepted without desync.
+	RateLimit int
+
+	// Mark restricts the rule to packets with matching marks
+	Mark MarkMatch
+
+	// ConnbytesLimit queues only the first packets of each connection in the
+	// original direction (0 queues all). Untracked connections have no
+	// counters, so the runner rejects it together with Notrack.
+	ConnbytesLimit int
+
+	// Notrack exempts the matched traffic from connection tracking with a rule
+	// in a raw priority chain, so high rate flows can't exhaust the conntrack table
+	Notrack bool
+
 	// Comment is a rule comment
 	Comment string
 }
 
+// HasFamilyOverrides reports whether IPv6 traffic needs different matches than IPv4.
+func (r *Rule) HasFamilyOverrides() bool {
+	return (r.InterfaceV6 != "" && r.InterfaceV6 != r.Interface) || len(r.PortsV6) > 0
+}
+
+// InterfaceFor returns the interface to match for the given address family.
+func (r *Rule) InterfaceFor(ipv6 bool) string {
+	if ipv6 && r.InterfaceV6 != "" {
+		return r.InterfaceV6
+	}
+	return r.Interface
+}
+
+// PortsFor returns the ports to match for the given address family.
+func (r *Rule) PortsFor(ipv6 bool) []string {
+	if ipv6 && len(r.PortsV6) > 0 {
+		return r.PortsV6
+	}
+	return r.Ports
+}
+
 // Config contains firewall configuration.
 type Config struct {
-	// Backend is the firewall backend ("nftables" or "iptables")
+	// Backend is the firewall backend ("nftables", "iptables" or BackendAuto,
+	// which NewFirewall replaces with the selected one)
 	Backend string
 
 	// TableName is the nftables table name
@@ -50,4 +243,47 @@ type Config struct {
 
 	// Interface is the network interface
 	Interface string
+
+	// Namespace is the network namespace the rules are installed in ("" for the host)
+	Namespace string
+
+	// Hook is where the queue rules see packets, one of the Hook constants
+	// ("" for HookOutput)
+	Hook string
+
+	// ExcludeNetworks are destination prefixes returned from the chains before
+	// any queue rule, each in its own address family
+	ExcludeNetworks []netip.Prefix
+
+	// Owned seeds the ownership of a firewall that removes the objects of an
+	// earlier instance without running Setup, e.g. after a crash (nil owns
+	// nothing)
+	Owned *Ownership
+
+	// Logger is used by backends to report notable changes
+	Logger *slog.Logger
+
+	// OnReconnect is called with the error when a backend recovered from a
+	// netlink failure by running the operation on a new socket (optional)
+	OnReconnect func(err error)
+}
+
+// splitFamilies splits prefixes into IPv4 and IPv6 ones.
+func splitFamilies(prefixes []netip.Prefix) (v4, v6 []string) {
+	for _, prefix := range prefixes {
+		if prefix.Addr().Is4() {
+			v4 = append(v4, prefix.String())
+		} else {
+			v6 = append(v6, prefix.String())
+		}
+	}
+	return v4, v6
+}
+
+// hook returns the configured hook, HookOutput if unset.
+func (c *Config) hook() string {
+	if c.Hook == "" {
+		return HookOutput
+	}
+	return c.Hook
 }
@@ -0,0 +1,159 @@
+package strategyrunner
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+// forceRemoveTimeout bounds the best-effort firewall removal of a forced stop.
+const forceRemoveTimeout = 2 * time.Second
+
+// firewallState records installed firewall objects, so a daemon killed before
+// removing them can clean up on the next start.
+type firewallState struct {
+	Backend   string    `json:"backend"`
+	TableName string    `json:"table_name"`
+	ChainName string    `json:"chain_name"`
+	Hook      string    `json:"hook,omitempty"`
+	Namespace string    `json:"network_namespace,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Owned lists the containers the run created; nil while Setup runs and in
+	// files of older releases, when only rules are removed
+	Owned *firewall.Ownership `json:"owned,omitempty"`
+}
+
+// writeFirewallState records the current firewall setup.
+func (r *Runner) writeFirewallState() error {
+	path := r.config.Firewall.StateFile
+	if path == "" {
+		return nil
+	}
+
+	return statepaths.WriteJSON(path, firewallState{
+		Backend:   r.config.Firewall.Backend,
+		TableName: r.config.Firewall.TableName,
+		ChainName: r.config.Firewall.ChainName,
+		Hook:      r.config.Firewall.Hook,
+		Namespace: r.config.NetworkNamespace,
+		CreatedAt: time.Now(),
+		Owned:     r.fwOwned.Load(),
+	})
+}
+
+// removeFirewallState deletes the state file after the firewall was cleaned up.
+func (r *Runner) removeFirewallState() {
+	path := r.config.Firewall.StateFile
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		r.logger.Warn("failed to remove firewall state file", slog.String("path", path), slog.Any("error", err))
+	}
+}
+
+// cleanupStaleFirewall removes firewall objects left behind by a run that
+// didn't stop cleanly, as recorded in the state file.
+func (r *Runner) cleanupStaleFirewall(ctx context.Context) {
+	path := r.config.Firewall.StateFile
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		r.logger.Warn("failed to read firewall state file", slog.String("path", path), slog.Any("error", err))
+		return
+	}
+
+	var state firewallState
+	if err := json.Unmarshal(data, &state); err != nil {
+		r.logger.Warn("ignoring invalid firewall state file", slog.String("path", path), slog.Any("error", err))
+		r.removeFirewallState()
+		return
+	}
+
+	r.logger.Warn("removing firewall rules left by a previous run",
+		slog.String("backend", state.Backend),
+		slog.String("table", state.TableName),
+		slog.String("chain", state.ChainName),
+		slog.Time("created_at", state.CreatedAt),
+	)
+	if state.Owned == nil {
+		r.logger.Warn("state file doesn't record which firewall containers the previous run created, removing only its rules",
+			slog.String("path", path),
+		)
+	}
+
+	fw, err := firewall.NewFirewall(&firewall.Config{
+		Backend:   state.Backend,
+		TableName: state.TableName,
+		ChainName: state.ChainName,
+		Namespace: state.Namespace,
+		Hook:      state.Hook,
+		Owned:     state.Owned,
+		Logger:    r.logger,
+	})
+	if err != nil {
+		r.logger.Warn("failed to create firewall for stale cleanup", slog.Any("error", err))
+		return
+	}
+	defer fw.Close()
+
+	if err := fw.RemoveAll(ctx); err != nil {
+		r.logger.Warn("failed to remove stale firewall rules", slog.Any("error", err))
+		return
+	}
+	r.removeFirewallState()
+}
+
+// ForceStop tears down without waiting: nfqws processes are killed and firewall
+// rules removed on a best-effort basis. It doesn't take the runner lock, so it
+// works while a graceful stop is stuck. If the rules can't be removed in time,
+// the firewall state file is kept for the next start to clean up.
+func (r *Runner) ForceStop() {
+	r.lifeMu.Lock()
+	if r.cancelLifecycle != nil {
+		r.cancelLifecycle()
+	}
+	r.lifeMu.Unlock()
+
+	r.logger.Warn("forcing strategy runner stop")
+	r.procManager.KillAll()
+
+	if r.externalFirewall() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), forceRemoveTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.fw.RemoveAll(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			r.removeFirewallState()
+			return
+		}
+		r.logger.Error("forced firewall removal failed", slog.Any("error", err))
+	case <-ctx.Done():
+		r.logger.Error("forced firewall removal timed out")
+	}
+
+	if err := r.writeFirewallState(); err != nil {
+		r.logger.Error("failed to write firewall state file", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,171 @@
+package strategyrunner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Hook phases.
+const (
+	HookPreStart  = "pre_start"
+	HookPostStart = "post_start"
+	HookPreStop   = "pre_stop"
+	HookPostStop  = "post_stop"
+)
+
+// HooksConfig contains commands run around firewall changes.
+type HooksConfig struct {
+	// Timeout is the default time limit of a hook; the hook is killed when it expires
+	Timeout time.Duration `yaml:"timeout" env:"ZAPRET_HOOKS_TIMEOUT" env-default:"30s"`
+
+	// PreStart hooks run after parsing the strategy, before firewall rules are added
+	PreStart []Hook `yaml:"pre_start"`
+
+	// PostStart hooks run after all nfqws processes are started
+	PostStart []Hook `yaml:"post_start"`
+
+	// PreStop hooks run before nfqws processes are stopped
+	PreStop []Hook `yaml:"pre_stop"`
+
+	// PostStop hooks run after firewall rules are removed
+	PostStop []Hook `yaml:"post_stop"`
+}
+
+// empty reports whether no hook is configured.
+func (c *HooksConfig) empty() bool {
+	return len(c.PreStart) == 0 && len(c.PostStart) == 0 && len(c.PreStop) == 0 && len(c.PostStop) == 0
+}
+
+// Hook is a shell command run at a lifecycle phase.
+type Hook struct {
+	// Name identifies the hook in logs (defaults to the command)
+	Name string `yaml:"name"`
+
+	// Command is run with sh -c
+	Command string `yaml:"command"`
+
+	// Timeout overrides hooks.timeout for this hook
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Fatal makes a failure abort start; otherwise failures are logged as warnings.
+	// Stop always proceeds, but a fatal stop hook failure is reported as a stop error.
+	Fatal bool `yaml:"fatal"`
+}
+
+// phase returns the hooks of a phase.
+func (c *HooksConfig) phase(phase string) []Hook {
+	switch phase {
+	case HookPreStart:
+		return c.PreStart
+	case HookPostStart:
+		return c.PostStart
+	case HookPreStop:
+		return c.PreStop
+	case HookPostStop:
+		return c.PostStop
+	}
+	return nil
+}
+
+// Validate validates the hooks configuration.
+func (c *HooksConfig) Validate() error {
+	for _, phase := range []string{HookPreStart, HookPostStart, HookPreStop, HookPostStop} {
+		for i, hook := range c.phase(phase) {
+			if hook.Command == "" {
+				return fmt.Errorf("%s[%d]: command must be specified", phase, i)
+			}
+			if hook.Timeout < 0 {
+				return fmt.Errorf("%s[%d]: timeout must not be negative", phase, i)
+			}
+		}
+	}
+	return nil
+}
+
+// runHooks runs the hooks of phase in order. It returns the first fatal failure;
+// non-fatal failures are logged and skipped. Caller must hold r.mu, so hooks never
+// interleave with a concurrent reload.
+func (r *Runner) runHooks(ctx context.Context, phase string, ruleCount int) error {
+	hooks := r.config.Hooks.phase(phase)
+	for _, hook := range hooks {
+		name := hook.Name
+		if name == "" {
+			name = hook.Command
+		}
+		timeout := hook.Timeout
+		if timeout == 0 {
+			timeout = r.config.Hooks.Timeout
+		}
+
+		err := runHook(ctx, hook.Command, timeout, []string{
+			"ZAPRET_PHASE=" + phase,
+			"ZAPRET_RULE_COUNT=" + strconv.Itoa(ruleCount),
+			"ZAPRET_HOOK=" + name,
+		}, r.logger.With(slog.String("hook", name), slog.String("phase", phase)))
+		if err == nil {
+			continue
+		}
+
+		if hook.Fatal {
+			return fmt.Errorf("%s hook %q failed: %w", phase, name, err)
+		}
+		r.logger.Warn("hook failed",
+			slog.String("hook", name),
+			slog.String("phase", phase),
+			slog.Any("error", err),
+		)
+	}
+	return nil
+}
+
+// runHook runs command with sh -c, logging each output line.
+func runHook(ctx context.Context, command string, timeout time.Duration, env []string, logger *slog.Logger) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+
+	// A timeout kills whatever the shell forked along with it
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return signalGroup(cmd.Process, syscall.SIGKILL)
+	}
+	// Children that left the group may keep the output pipe open
+	cmd.WaitDelay = time.Second
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	logger.Info("running hook")
+	start := time.Now()
+	err := cmd.Run()
+
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		logger.Info("hook output", slog.String("line", scanner.Text()))
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("hook finished", slog.Duration("duration", time.Since(start)))
+	return nil
+}
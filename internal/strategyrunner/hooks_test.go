@@ -0,0 +1,183 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// processGone reports whether pid has exited, counting a zombie as gone.
+func processGone(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// The state follows the parenthesized command name
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+// waitGone waits up to timeout for pid to exit.
+func waitGone(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !processGone(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func TestRunHookTimeoutKillsGroup(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc to check processes in")
+	}
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	// The shell forks a child that would outlive it if only the shell were killed
+	command := fmt.Sprintf("sleep 30 & echo $! > %s; wait", pidFile)
+	start := time.Now()
+	err := runHook(t.Context(), command, 200*time.Millisecond, nil, slog.New(slog.DiscardHandler))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("runHook() error = %v, want a timeout", err)
+	}
+	// Without the child holding the output pipe there is no WaitDelay to sit out
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("runHook() took %s to time out", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waitGone(pid, 2*time.Second) {
+		t.Errorf("child pid %d of the hook survived its timeout", pid)
+	}
+}
+
+func TestRunHooksEnv(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	out := filepath.Join(tr.dir, "env")
+	tr.Runner.config.Hooks.PostStart = []Hook{{Name: "dump", Command: "env > " + out}}
+
+	if err := tr.runHooks(t.Context(), HookPostStart, 3); err != nil {
+		t.Fatalf("runHooks() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := strings.Split(string(data), "\n")
+	for _, want := range []string{"ZAPRET_PHASE=post_start", "ZAPRET_RULE_COUNT=3", "ZAPRET_HOOK=dump"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("hook environment lacks %s", want)
+		}
+	}
+}
+
+func TestRunHooksFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		hooks     []Hook
+		wantErr   bool
+		wantWarns int
+	}{
+		{
+			name:    "fatal failure",
+			hooks:   []Hook{{Name: "check", Command: "exit 1", Fatal: true}},
+			wantErr: true,
+		},
+		{
+			name:      "non-fatal failure",
+			hooks:     []Hook{{Name: "check", Command: "exit 1"}},
+			wantWarns: 1,
+		},
+		{
+			name:      "non-fatal failure then success",
+			hooks:     []Hook{{Command: "exit 1"}, {Command: "exit 2"}, {Command: "true", Fatal: true}},
+			wantWarns: 2,
+		},
+		{
+			name:  "success",
+			hooks: []Hook{{Command: "true", Fatal: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			r := &Runner{
+				config: &Config{Hooks: HooksConfig{Timeout: 5 * time.Second, PreStart: tt.hooks}},
+				logger: slog.New(slog.NewTextHandler(&log, nil)),
+			}
+
+			err := r.runHooks(t.Context(), HookPreStart, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runHooks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Count(log.String(), `msg="hook failed"`); got != tt.wantWarns {
+				t.Errorf("logged %d hook failures, want %d", got, tt.wantWarns)
+			}
+		})
+	}
+}
+
+func TestRunnerStartHookFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		wantErr  bool
+	}{
+		{
+			name:     "fatal pre-start",
+			settings: "hooks:\n  pre_start:\n    - command: exit 1\n      fatal: true\n",
+			wantErr:  true,
+		},
+		{
+			name:     "non-fatal pre-start",
+			settings: "hooks:\n  pre_start:\n    - command: exit 1\n",
+		},
+		{
+			name:     "non-fatal post-start",
+			settings: "hooks:\n  post_start:\n    - command: exit 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestRunner(t, testStrategy, tt.settings)
+			err := tr.Start(t.Context())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Start() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				tr.checkConsistent(t)
+				return
+			}
+
+			// A fatal pre-start hook aborts before anything is installed
+			if status := tr.GetStatus(); status.Running {
+				t.Error("runner running after a fatal pre-start hook failed")
+			}
+			if rules := tr.fw.queues(); len(rules) != 0 {
+				t.Errorf("firewall rules for queues %v after a fatal pre-start hook failed", rules)
+			}
+			if got := tr.procManager.Count(); got != 0 {
+				t.Errorf("%d processes after a fatal pre-start hook failed", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package strategyrunner
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/hostlist"
+)
+
+// hostlistSources returns the configured sources with paths resolved against the lists directory.
+func (r *Runner) hostlistSources() []hostlist.Source {
+	sources := make([]hostlist.Source, len(r.config.HostlistUpdate.Sources))
+	for i, src := range r.config.HostlistUpdate.Sources {
+		if !filepath.IsAbs(src.Path) {
+			src.Path = filepath.Join(r.config.ListsPath, src.Path)
+		}
+		sources[i] = src
+	}
+	return sources
+}
+
+// updateHostlists refreshes hostlists every interval and reloads when any changed.
+// Sources checked successfully within the interval are skipped, so reloads
+// don't cause extra downloads.
+func (r *Runner) updateHostlists(ctx context.Context, sources []hostlist.Source, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changed := r.updater.Update(ctx, sources, interval)
+		if len(changed) > 0 && ctx.Err() == nil {
+			r.logger.Info("hostlists changed, reloading", slog.Any("files", changed))
+			r.reloads.Trigger("hostlist")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// HostlistStatus returns the update state of the configured hostlist sources.
+func (r *Runner) HostlistStatus() []hostlist.SourceState {
+	return r.updater.Status()
+}
@@ -0,0 +1,193 @@
+package strategyrunner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultListsPath is the default lists_path, where hostlist files are
+	// installed to
+	DefaultListsPath = "/etc/zapret-ng/lists"
+
+	// strategiesDirName is the directory next to the strategy config holding installed presets
+	strategiesDirName = "strategies"
+)
+
+// InstallRequest describes a strategy preset to install.
+type InstallRequest struct {
+	// Name is the preset name, used as the strategy file base name
+	Name string
+
+	// Strategy is the content of the .bat strategy file
+	Strategy []byte
+
+	// Lists maps hostlist file names to their content
+	Lists map[string][]byte
+
+	// Activate switches the strategy config to the installed preset and restarts
+	Activate bool
+}
+
+// InstallStrategy writes a strategy preset and its hostlists to disk.
+// It returns the paths of all installed files.
+func (r *Runner) InstallStrategy(ctx context.Context, req *InstallRequest) ([]string, error) {
+	if err := validateFileName(req.Name); err != nil {
+		return nil, fmt.Errorf("invalid preset name: %w", err)
+	}
+	if len(req.Strategy) == 0 {
+		return nil, fmt.Errorf("strategy file is empty")
+	}
+	for name := range req.Lists {
+		if err := validateFileName(name); err != nil {
+			return nil, fmt.Errorf("invalid list name: %w", err)
+		}
+	}
+
+	strategiesDir := filepath.Join(filepath.Dir(r.mainCfg.ConfigPath), strategiesDirName)
+	strategyPath := filepath.Join(strategiesDir, req.Name+".bat")
+
+	r.mu.RLock()
+	listsPath := r.config.ListsPath
+	r.mu.RUnlock()
+
+	var installed []string
+
+	if err := os.MkdirAll(listsPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lists directory: %w", err)
+	}
+	for name, data := range req.Lists {
+		path := filepath.Join(listsPath, name)
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return installed, err
+		}
+		installed = append(installed, path)
+	}
+
+	if err := os.MkdirAll(strategiesDir, 0755); err != nil {
+		return installed, fmt.Errorf("failed to create strategies directory: %w", err)
+	}
+	if err := writeFileAtomic(strategyPath, req.Strategy, 0644); err != nil {
+		return installed, err
+	}
+	installed = append(installed, strategyPath)
+
+	r.logger.Info("installed strategy preset",
+		slog.String("name", req.Name),
+		slog.String("path", strategyPath),
+		slog.Int("lists", len(req.Lists)),
+	)
+
+	if !req.Activate {
+		return installed, nil
+	}
+
+	if err := setStrategyFile(r.mainCfg.ConfigPath, strategyPath); err != nil {
+		return installed, err
+	}
+
+	r.logger.Info("activating strategy preset", slog.String("name", req.Name))
+	if err := r.Restart(ctx); err != nil {
+		return installed, fmt.Errorf("failed to restart with new strategy: %w", err)
+	}
+
+	return installed, nil
+}
+
+// validateFileName ensures name is a plain file name without path components.
+func validateFileName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("name must not be empty")
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%q must not contain path separators", name)
+	}
+	return nil
+}
+
+// setStrategyFile updates strategy_file in the strategy config, preserving comments.
+func setStrategyFile(configPath, strategyPath string) error {
+	var doc yaml.Node
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read strategy config: %w", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse strategy config: %w", err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("strategy config root must be a mapping")
+	}
+
+	updated := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "strategy_file" {
+			root.Content[i+1].SetString(strategyPath)
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		key := &yaml.Node{}
+		key.SetString("strategy_file")
+		value := &yaml.Node{}
+		value.SetString(strategyPath)
+		root.Content = append(root.Content, key, value)
+	}
+
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode strategy config: %w", err)
+	}
+	enc.Close()
+
+	return writeFileAtomic(configPath, []byte(buf.String()), 0644)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", path, err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	return nil
+}
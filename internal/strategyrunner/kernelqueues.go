@@ -0,0 +1,340 @@
+package strategyrunner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// nfqueuePath lists the NFQUEUE queues that have a bound consumer.
+const nfqueuePath = "/proc/net/netfilter/nfnetlink_queue"
+
+// nfqueueThreadPath is nfqueuePath of the calling thread's network namespace;
+// /proc/net follows the namespace of the process, not of the thread.
+const nfqueueThreadPath = "/proc/thread-self/net/netfilter/nfnetlink_queue"
+
+// ErrKernelQueuesUnavailable reports that the kernel doesn't expose the queue
+// state: nfnetlink_queue loads on the first queue bind, and hardened kernels
+// may hide /proc/net/netfilter entirely.
+var ErrKernelQueuesUnavailable = errors.New("kernel queue state unavailable")
+
+// NFQUEUE copy modes reported by the kernel.
+var nfqueueCopyModes = map[int]string{
+	0: "none",
+	1: "meta",
+	2: "packet",
+}
+
+// KernelQueue is a bound queue as reported by the kernel.
+type KernelQueue struct {
+	// Queue is the queue number
+	Queue int
+
+	// PortID is the netlink port of the consumer, usually its pid
+	PortID uint32
+
+	// Length is the number of packets waiting for a verdict
+	Length int
+
+	// CopyMode is "none", "meta" or "packet"
+	CopyMode string
+
+	// CopyRange is the number of packet bytes copied to the consumer
+	CopyRange int
+
+	// Dropped counts packets dropped because the queue was full
+	Dropped uint64
+
+	// UserDropped counts packets that couldn't be sent to the consumer
+	// (netlink socket buffer full)
+	UserDropped uint64
+}
+
+// parseKernelQueues parses nfnetlink_queue lines. Columns are positional
+// ("queue portid length copy_mode copy_range dropped user_dropped id_seq 1");
+// kernels print a varying number of trailing columns, so missing ones are
+// left zero and extra ones ignored. Lines not starting with a queue number
+// are skipped.
+func parseKernelQueues(r io.Reader) ([]KernelQueue, error) {
+	var queues []KernelQueue
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		queue, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		field := func(i int) uint64 {
+			if i >= len(fields) {
+				return 0
+			}
+			v, _ := strconv.ParseUint(fields[i], 10, 64)
+			return v
+		}
+
+		copyMode := int(field(3))
+		mode, ok := nfqueueCopyModes[copyMode]
+		if !ok {
+			mode = strconv.Itoa(copyMode)
+		}
+
+		queues = append(queues, KernelQueue{
+			Queue:       queue,
+			PortID:      uint32(field(1)),
+			Length:      int(field(2)),
+			CopyMode:    mode,
+			CopyRange:   int(field(4)),
+			Dropped:     field(5),
+			UserDropped: field(6),
+		})
+	}
+	return queues, scanner.Err()
+}
+
+// readKernelQueues reads the nfnetlink_queue file at path. A missing or
+// hidden file wraps ErrKernelQueuesUnavailable; an empty one means no queue
+// is bound.
+func readKernelQueues(path string) ([]KernelQueue, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return nil, fmt.Errorf("%w: %v", ErrKernelQueuesUnavailable, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bound queues: %w", err)
+	}
+	defer file.Close()
+
+	return parseKernelQueues(file)
+}
+
+// kernelQueues returns the queues bound in the network namespace ("" for the host).
+func kernelQueues(namespace string) ([]KernelQueue, error) {
+	if namespace == "" {
+		return readKernelQueues(nfqueuePath)
+	}
+
+	var queues []KernelQueue
+	err := netns.Do(namespace, func() error {
+		var err error
+		queues, err = readKernelQueues(nfqueueThreadPath)
+		return err
+	})
+	return queues, err
+}
+
+// kernelQueueSource provides the NFQUEUE state of the kernel.
+type kernelQueueSource interface {
+	// Queues returns the queues bound in the network namespace ("" for the
+	// host), or an error wrapping ErrKernelQueuesUnavailable if the kernel
+	// doesn't expose them
+	Queues(namespace string) ([]KernelQueue, error)
+}
+
+// procKernelQueues reads the queue state from /proc.
+type procKernelQueues struct{}
+
+// Queues implements kernelQueueSource.
+func (procKernelQueues) Queues(namespace string) ([]KernelQueue, error) {
+	return kernelQueues(namespace)
+}
+
+// kernelQueueState is the kernel queue state the runner's checks read. It
+// logs when the state becomes unavailable or available again, once per
+// change, so callers skip their checks without logging on every call.
+type kernelQueueState struct {
+	source kernelQueueSource
+	logger *slog.Logger
+
+	mu          sync.Mutex
+	checked     bool
+	unavailable bool
+}
+
+// newKernelQueueState returns the kernel queue state read from source.
+func newKernelQueueState(source kernelQueueSource, logger *slog.Logger) *kernelQueueState {
+	return &kernelQueueState{source: source, logger: logger}
+}
+
+// Queues implements kernelQueueSource.
+func (s *kernelQueueState) Queues(namespace string) ([]KernelQueue, error) {
+	queues, err := s.source.Queues(namespace)
+	unavailable := errors.Is(err, ErrKernelQueuesUnavailable)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if unavailable && (!s.checked || !s.unavailable) {
+		s.logger.Info("kernel queue state unavailable, skipping queue binding checks and drain waits until it appears",
+			slog.Any("error", err),
+			slog.String("hint", "nfnetlink_queue loads when nfqws binds its first queue; `modprobe nfnetlink_queue` loads it now"),
+		)
+	}
+	if err == nil && s.checked && s.unavailable {
+		s.logger.Info("kernel queue state available", slog.Int("bound_queues", len(queues)))
+	}
+	if err == nil || unavailable {
+		s.checked = true
+		s.unavailable = unavailable
+	}
+	return queues, err
+}
+
+// Unavailable reports whether the last read found the state unavailable.
+func (s *kernelQueueState) Unavailable() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unavailable
+}
+
+// consumerPID returns the pid of the process owning a netlink port, or 0 if
+// it can't be resolved. The first netlink socket of a process is
+// autobound to its pid, which is what nfqws uses.
+func consumerPID(portID uint32) int {
+	if portID == 0 {
+		return 0
+	}
+	if _, err := os.Stat(fmt.Sprintf("/proc/%d", portID)); err != nil {
+		return 0
+	}
+	return int(portID)
+}
+
+// foreignQueues returns the queues bound in the network namespace of cfg by
+// consumers other than nfqws started from cfg.BinaryPath, mapped to their pid
+// (0 if unresolved). New rules aren't given these numbers: nfqws can't bind a
+// queue another program holds. Nil if the state can't be read, and with
+// process_management: external, where the bound nfqws aren't ours to judge.
+func (r *Runner) foreignQueues(cfg *Config) map[int]int {
+	if cfg.ProcessManagement == ManagementExternal {
+		return nil
+	}
+	queues, err := r.queueState.Queues(cfg.NetworkNamespace)
+	if err != nil {
+		return nil
+	}
+
+	binary := cfg.BinaryPath
+	if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+		binary = resolved
+	}
+	foreign := make(map[int]int)
+	for _, q := range queues {
+		pid := consumerPID(q.PortID)
+		if pid != 0 {
+			// Left behind by an earlier run counts as ours
+			if id, err := readProcessIdentity(pid); err == nil && id.Exe == binary {
+				continue
+			}
+		}
+		foreign[q.Queue] = pid
+	}
+	return foreign
+}
+
+// KernelQueueInfo is the kernel view of a queue joined with the rule feeding it.
+type KernelQueueInfo struct {
+	KernelQueue
+
+	// Bound reports whether a consumer is bound to the queue
+	Bound bool
+
+	// PID is the consumer process, 0 if unbound or unresolved
+	PID int
+
+	// Label and SourceLine identify the rule feeding the queue ("" and 0 if none)
+	Label      string
+	SourceLine int
+
+	// Installed reports whether a firewall rule currently feeds the queue
+	Installed bool
+
+	// DropsGrowing reports that a drop counter increased since the previous query
+	DropsGrowing bool
+
+	// Problems lists anomalies, e.g. an unbound queue with an installed rule
+	Problems []string
+
+	// Unknown reports that the kernel queue state is unavailable; Bound,
+	// PID, Length and the drop counters are unknown then
+	Unknown bool
+}
+
+// KernelQueues returns the queues of the applied rules and the queues bound in
+// the kernel, sorted by queue number. If the kernel doesn't expose the queue
+// state the rule queues are returned marked Unknown.
+func (r *Runner) KernelQueues() ([]KernelQueueInfo, error) {
+	r.mu.RLock()
+	rules := append([]ParsedRule(nil), r.rules...)
+	namespace := r.config.NetworkNamespace
+	r.mu.RUnlock()
+
+	queues, err := r.queueState.Queues(namespace)
+	unknown := errors.Is(err, ErrKernelQueuesUnavailable)
+	if err != nil && !unknown {
+		return nil, err
+	}
+
+	infos := make(map[int]*KernelQueueInfo)
+	for _, q := range queues {
+		infos[q.Queue] = &KernelQueueInfo{KernelQueue: q, Bound: true, PID: consumerPID(q.PortID)}
+	}
+	for _, rule := range rules {
+		if rule.FilteredOut {
+			continue
+		}
+		info, ok := infos[rule.QueueNum]
+		if !ok {
+			info = &KernelQueueInfo{KernelQueue: KernelQueue{Queue: rule.QueueNum}, Unknown: unknown}
+			infos[rule.QueueNum] = info
+		}
+		info.Label = rule.Label
+		info.SourceLine = rule.SourceLine
+		info.Installed = rule.active() && !rule.ScheduledOff
+	}
+
+	r.kernelMu.Lock()
+	defer r.kernelMu.Unlock()
+
+	drops := make(map[int]uint64, len(infos))
+	result := make([]KernelQueueInfo, 0, len(infos))
+	for _, info := range infos {
+		if info.Unknown {
+			result = append(result, *info)
+			continue
+		}
+		total := info.Dropped + info.UserDropped
+		drops[info.Queue] = total
+		if last, ok := r.kernelDrops[info.Queue]; ok && total > last {
+			info.DropsGrowing = true
+		}
+
+		if info.Installed && !info.Bound {
+			info.Problems = append(info.Problems, "rule installed but no consumer bound, traffic passes without desync")
+		}
+		if info.Bound && info.Label == "" {
+			info.Problems = append(info.Problems, "bound but not used by any rule")
+		}
+		if info.DropsGrowing {
+			info.Problems = append(info.Problems, "drops grew since the previous check, nfqws can't keep up")
+		}
+		result = append(result, *info)
+	}
+	r.kernelDrops = drops
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Queue < result[j].Queue })
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package strategyrunner
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestReadKernelQueuesFixtures(t *testing.T) {
+	tests := []struct {
+		file string
+		want []KernelQueue
+	}{
+		{
+			// Current kernels: fixed-width columns ending with id_sequence and 1
+			file: "columns9",
+			want: []KernelQueue{
+				{Queue: 200, PortID: 41231, CopyMode: "packet", CopyRange: 65531},
+				{Queue: 201, PortID: 41232, Length: 17, CopyMode: "packet", CopyRange: 1024, Dropped: 12, UserDropped: 3},
+				{Queue: 65535, CopyMode: "meta", CopyRange: 64},
+			},
+		},
+		{
+			// Without the trailing columns, separated by tabs
+			file: "columns7",
+			want: []KernelQueue{
+				{Queue: 200, PortID: 41231, CopyMode: "packet", CopyRange: 65531},
+				{Queue: 201, PortID: 41232, Length: 17, CopyMode: "packet", CopyRange: 1024, Dropped: 12, UserDropped: 3},
+			},
+		},
+		{
+			// Headers, blank lines, extra and missing columns, unknown copy modes
+			file: "noise",
+			want: []KernelQueue{
+				{Queue: 200, PortID: 41231, CopyMode: "packet", CopyRange: 65531},
+				{Queue: 300, PortID: 1, CopyMode: "9"},
+			},
+		},
+		{
+			// nfnetlink_queue is loaded but nothing is bound
+			file: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readKernelQueues(filepath.Join("testdata", "nfnetlink_queue", tt.file))
+			if err != nil {
+				t.Fatalf("readKernelQueues() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readKernelQueues() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadKernelQueuesMissing(t *testing.T) {
+	_, err := readKernelQueues(filepath.Join(t.TempDir(), "nfnetlink_queue"))
+	if !errors.Is(err, ErrKernelQueuesUnavailable) {
+		t.Errorf("readKernelQueues() of a missing file error = %v, want ErrKernelQueuesUnavailable", err)
+	}
+}
+
+func TestKernelQueues(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	tr.mu.RLock()
+	tcp, udp := tr.rules[0].QueueNum, tr.rules[1].QueueNum
+	tr.mu.RUnlock()
+
+	// The TCP queue is bound, the UDP one lost its consumer and a foreign
+	// program holds another queue
+	tr.kernel.set(
+		KernelQueue{Queue: tcp, CopyMode: "packet", CopyRange: 65531},
+		KernelQueue{Queue: 999, CopyMode: "packet", Dropped: 5},
+	)
+	infos, err := tr.KernelQueues()
+	if err != nil {
+		t.Fatalf("KernelQueues() error = %v", err)
+	}
+	if got, want := queueNumbers(infos), []int{min(tcp, udp), max(tcp, udp), 999}; !slices.Equal(got, want) {
+		t.Fatalf("KernelQueues() queues = %v, want %v", got, want)
+	}
+	byQueue := make(map[int]KernelQueueInfo)
+	for _, info := range infos {
+		byQueue[info.Queue] = info
+	}
+	if info := byQueue[tcp]; !info.Bound || !info.Installed || len(info.Problems) != 0 {
+		t.Errorf("tcp queue = %+v, want bound, installed and healthy", info)
+	}
+	if info := byQueue[udp]; info.Bound || !info.Installed || len(info.Problems) != 1 {
+		t.Errorf("udp queue = %+v, want installed but unbound", info)
+	}
+	if info := byQueue[999]; !info.Bound || info.Label != "" || len(info.Problems) != 1 {
+		t.Errorf("foreign queue = %+v, want bound without a rule", info)
+	}
+
+	// Drops are reported as growing relative to the previous query
+	tr.kernel.set(
+		KernelQueue{Queue: tcp, CopyMode: "packet", CopyRange: 65531, UserDropped: 4},
+		KernelQueue{Queue: 999, CopyMode: "packet", Dropped: 5},
+	)
+	infos, _ = tr.KernelQueues()
+	for _, info := range infos {
+		if want := info.Queue == tcp; info.DropsGrowing != want {
+			t.Errorf("queue %d DropsGrowing = %v, want %v", info.Queue, info.DropsGrowing, want)
+		}
+	}
+
+	// Without kernel state the rule queues are still listed
+	tr.kernel.mu.Lock()
+	tr.kernel.queues, tr.kernel.err = nil, ErrKernelQueuesUnavailable
+	tr.kernel.mu.Unlock()
+	infos, err = tr.KernelQueues()
+	if err != nil {
+		t.Fatalf("KernelQueues() with unavailable state error = %v", err)
+	}
+	if len(infos) != 2 || !infos[0].Unknown || !infos[1].Unknown {
+		t.Errorf("KernelQueues() = %+v, want both rule queues marked unknown", infos)
+	}
+}
+
+// queueNumbers returns the queue numbers of infos.
+func queueNumbers(infos []KernelQueueInfo) []int {
+	var queues []int
+	for _, info := range infos {
+		queues = append(queues, info.Queue)
+	}
+	return queues
+}
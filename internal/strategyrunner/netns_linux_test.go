@@ -0,0 +1,73 @@
+//go:build linux
+
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestRunnerNetworkNamespace applies a strategy with the real nftables
+// backend inside a scratch namespace and checks the rules exist there and
+// nowhere else. It needs root, ip(8) and nft(8).
+func TestRunnerNetworkNamespace(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces needs root")
+	}
+	for _, tool := range []string{"ip", "nft"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+	ns := fmt.Sprintf("zapret-test-%d", os.Getpid())
+	if out, err := exec.Command("ip", "netns", "add", ns).CombinedOutput(); err != nil {
+		t.Skipf("ip netns add: %v: %s", err, out)
+	}
+	t.Cleanup(func() { exec.Command("ip", "netns", "delete", ns).Run() })
+
+	const table = "inet zapret_nstest"
+	tr := newTestRunner(t, testStrategy, fmt.Sprintf(`network_namespace: %s
+firewall:
+  backend: nftables
+  table_name: %s
+`, ns, table))
+	fw, err := newFirewall(tr.Runner.config, tr.logger, tr.netlinkReconnected)
+	if err != nil {
+		t.Fatalf("newFirewall() error = %v", err)
+	}
+	tr.Runner.fw = fw
+	tr.makeFirewall = newFirewall
+
+	listTable := func(namespace string) (string, error) {
+		args := append([]string{"nft", "list", "table"}, strings.Fields(table)...)
+		if namespace != "" {
+			args = append([]string{"ip", "netns", "exec", namespace}, args...)
+		}
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		return string(out), err
+	}
+
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	out, err := listTable(ns)
+	if err != nil {
+		t.Fatalf("table missing inside the namespace: %v: %s", err, out)
+	}
+	if n := strings.Count(out, "queue"); n < 2 {
+		t.Errorf("table inside the namespace has %d queue rules, want 2:\n%s", n, out)
+	}
+	if out, err := listTable(""); err == nil {
+		t.Errorf("table installed in the host namespace:\n%s", out)
+	}
+
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if out, err := listTable(ns); err == nil {
+		t.Errorf("table left inside the namespace after Stop:\n%s", out)
+	}
+}
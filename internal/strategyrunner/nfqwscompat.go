@@ -0,0 +1,213 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compatibility modes for nfqws options the installed binary doesn't support.
+const (
+	// CompatStrict fails rules using unsupported options
+	CompatStrict = "strict"
+
+	// CompatLenient strips unsupported options that are safe to drop and
+	// fails rules only for the others
+	CompatLenient = "lenient"
+)
+
+// compatHelpTimeout bounds running nfqws --help.
+const compatHelpTimeout = 5 * time.Second
+
+// strippableOptions are options that only refine a desync method. Without
+// them the rule still works, only less effectively, so lenient mode may drop
+// them. Every other unsupported option fails the rule.
+var strippableOptions = map[string]bool{
+	"--dpi-desync-fake-tls-mod":         true,
+	"--dpi-desync-fake-tls":             true,
+	"--dpi-desync-fake-quic":            true,
+	"--dpi-desync-fake-http":            true,
+	"--dpi-desync-fake-unknown":         true,
+	"--dpi-desync-fake-unknown-udp":     true,
+	"--dpi-desync-fake-syndata":         true,
+	"--dpi-desync-fake-wireguard":       true,
+	"--dpi-desync-fake-dht":             true,
+	"--dpi-desync-fake-discord":         true,
+	"--dpi-desync-fake-stun":            true,
+	"--dpi-desync-fakedsplit-pattern":   true,
+	"--dpi-desync-split-seqovl-pattern": true,
+	"--dpi-desync-udplen-pattern":       true,
+	"--dpi-desync-repeats":              true,
+	"--dpi-desync-autottl":              true,
+	"--dpi-desync-autottl6":             true,
+	"--dpi-desync-cutoff":               true,
+	"--dpi-desync-start":                true,
+	"--dpi-desync-ts-increment":         true,
+	"--dpi-desync-badseq-increment":     true,
+	"--dpi-desync-badack-increment":     true,
+	"--dup":                             true,
+	"--dup-cutoff":                      true,
+	"--orig-ttl":                        true,
+	"--orig-mod-cutoff":                 true,
+}
+
+// helpOptionRegex matches option names in nfqws --help output.
+var helpOptionRegex = regexp.MustCompile(`--[a-z0-9][a-z0-9-]*`)
+
+// nfqwsOptions returns the options supported by the nfqws binary at path,
+// read from its --help output.
+func nfqwsOptions(path string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), compatHelpTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "--help")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	// nfqws exits with a non-zero status after printing help
+	err := cmd.Run()
+
+	options := make(map[string]bool)
+	for _, opt := range helpOptionRegex.FindAllString(out.String(), -1) {
+		options[opt] = true
+	}
+	if len(options) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no options found in help output")
+		}
+		return nil, fmt.Errorf("failed to list options of %s: %w", path, err)
+	}
+	return options, nil
+}
+
+// optionName returns the option name of an argument ("--opt" of "--opt=value").
+func optionName(arg string) string {
+	name, _, _ := strings.Cut(arg, "=")
+	return name
+}
+
+// checkCompat removes the unsupported options of args that may be stripped.
+// It returns the remaining arguments, the removed options, and the
+// unsupported options that can't be stripped. In strict mode nothing is
+// stripped. A value given as a separate argument is removed with its option.
+func checkCompat(args []string, supported map[string]bool, mode string) (kept, stripped, failed []string) {
+	kept = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			kept = append(kept, arg)
+			continue
+		}
+
+		name := optionName(arg)
+		if supported[name] {
+			kept = append(kept, arg)
+			continue
+		}
+
+		if mode != CompatLenient || !strippableOptions[name] {
+			failed = append(failed, name)
+			kept = append(kept, arg)
+			continue
+		}
+
+		stripped = append(stripped, name)
+		if !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+		}
+	}
+	return kept, stripped, failed
+}
+
+// applyCompat checks the arguments of rules against the options the installed
+// nfqws supports. Rules keep working with strippable options removed in
+// lenient mode; rules with other unsupported options are marked failed.
+// cfg is the config the rules are applied with, which differs from the
+// running one while a reload is validated.
+func (r *Runner) applyCompat(rules []ParsedRule, cfg *Config, logger *slog.Logger) {
+	if cfg.ProcessManagement == ManagementExternal {
+		// The externally supervised nfqws may be a different build
+		return
+	}
+
+	supported, err := r.supportedOptions()
+	if err != nil {
+		logger.Warn("cannot determine supported nfqws options, skipping compatibility check", slog.Any("error", err))
+		return
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.CompatError != "" {
+			// Failed while parsing
+			continue
+		}
+		kept, stripped, failed := checkCompat(parseNFQWSArgs(rule.NFQWSArgs), supported, cfg.CompatMode)
+
+		if len(failed) > 0 {
+			sort.Strings(failed)
+			rule.CompatError = "unsupported nfqws options: " + strings.Join(failed, ", ")
+			logger.Error("rule uses options the installed nfqws doesn't support, rule failed",
+				slog.Int("line", rule.SourceLine),
+				slog.Any("options", failed),
+				slog.String("compat_mode", cfg.CompatMode),
+			)
+			continue
+		}
+		if len(stripped) > 0 {
+			rule.NFQWSArgs = joinNFQWSArgs(kept)
+			rule.StrippedArgs = stripped
+			logger.Warn("stripped options the installed nfqws doesn't support, rule runs degraded",
+				slog.Int("line", rule.SourceLine),
+				slog.Any("removed", stripped),
+			)
+		}
+	}
+}
+
+// supportedOptions returns the options of the configured nfqws binary.
+func (r *Runner) supportedOptions() (map[string]bool, error) {
+	return r.compat.options(r.config.BinaryPath)
+}
+
+// optionCache caches the options of an nfqws binary until the binary path
+// changes or the file at it is replaced, as an nfqws upgrade does.
+type optionCache struct {
+	mu      sync.Mutex
+	path    string
+	size    int64
+	modTime time.Time
+	cached  map[string]bool
+}
+
+// options returns the options of the nfqws binary at path.
+func (c *optionCache) options(path string) (map[string]bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A binary that can't be stated is probed anyway to report why it fails
+	var size int64
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		size, modTime = info.Size(), info.ModTime()
+	}
+	if c.cached != nil && c.path == path && c.size == size && c.modTime.Equal(modTime) {
+		return c.cached, nil
+	}
+
+	options, err := nfqwsOptions(path)
+	if err != nil {
+		return nil, err
+	}
+	c.path, c.size, c.modTime, c.cached = path, size, modTime, options
+	return options, nil
+}
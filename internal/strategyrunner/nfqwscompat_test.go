@@ -0,0 +1,180 @@
+package strategyrunner
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckCompat(t *testing.T) {
+	supported := map[string]bool{"--filter-tcp": true, "--dpi-desync": true, "--hostlist": true}
+
+	tests := []struct {
+		name         string
+		args         []string
+		mode         string
+		wantKept     []string
+		wantStripped []string
+		wantFailed   []string
+	}{
+		{
+			name:     "supported",
+			args:     []string{"--filter-tcp=443", "--dpi-desync=fake", "--hostlist", "list.txt"},
+			mode:     CompatLenient,
+			wantKept: []string{"--filter-tcp=443", "--dpi-desync=fake", "--hostlist", "list.txt"},
+		},
+		{
+			name:         "stripped in lenient mode",
+			args:         []string{"--dpi-desync=fake", "--dpi-desync-autottl=2", "--dpi-desync-repeats", "6", "--hostlist=list.txt"},
+			mode:         CompatLenient,
+			wantKept:     []string{"--dpi-desync=fake", "--hostlist=list.txt"},
+			wantStripped: []string{"--dpi-desync-autottl", "--dpi-desync-repeats"},
+		},
+		{
+			name:       "kept and failed in strict mode",
+			args:       []string{"--dpi-desync=fake", "--dpi-desync-autottl=2"},
+			mode:       CompatStrict,
+			wantKept:   []string{"--dpi-desync=fake", "--dpi-desync-autottl=2"},
+			wantFailed: []string{"--dpi-desync-autottl"},
+		},
+		{
+			name:       "changing the desync fails in lenient mode",
+			args:       []string{"--dpi-desync=fake", "--dpi-desync-split-pos=1"},
+			mode:       CompatLenient,
+			wantKept:   []string{"--dpi-desync=fake", "--dpi-desync-split-pos=1"},
+			wantFailed: []string{"--dpi-desync-split-pos"},
+		},
+		{
+			// A flag without a value doesn't take the next option with it
+			name:         "stripped flag",
+			args:         []string{"--dup", "--dpi-desync=fake"},
+			mode:         CompatLenient,
+			wantKept:     []string{"--dpi-desync=fake"},
+			wantStripped: []string{"--dup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, stripped, failed := checkCompat(tt.args, supported, tt.mode)
+			if !slices.Equal(kept, tt.wantKept) {
+				t.Errorf("kept = %q, want %q", kept, tt.wantKept)
+			}
+			if !slices.Equal(stripped, tt.wantStripped) {
+				t.Errorf("stripped = %q, want %q", stripped, tt.wantStripped)
+			}
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("failed = %q, want %q", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestNFQWSOptions(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "nfqws")
+	if err := os.WriteFile(binary, []byte(testNFQWS), 0755); err != nil {
+		t.Fatal(err)
+	}
+	options, err := nfqwsOptions(binary)
+	if err != nil {
+		t.Fatalf("nfqwsOptions() error = %v", err)
+	}
+	for _, opt := range []string{"--qnum", "--dpi-desync", "--dpi-desync-fake-quic", "--new"} {
+		if !options[opt] {
+			t.Errorf("option %s missing from %v", opt, options)
+		}
+	}
+
+	silent := filepath.Join(dir, "silent")
+	if err := os.WriteFile(silent, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nfqwsOptions(silent); err == nil {
+		t.Error("nfqwsOptions() of a binary without options succeeded")
+	}
+}
+
+// compatStrategy has a rule refining its desync with an option the stub
+// nfqws lacks and one changing it with another.
+const compatStrategy = `--filter-tcp=443 --dpi-desync=fake --dpi-desync-autottl=2 --new
+--filter-udp=443 --dpi-desync=fake --dpi-desync-split-pos=1
+`
+
+// compatRules returns the rules of tr by protocol.
+func compatRules(tr *testRunner) map[string]ParsedRule {
+	rules := make(map[string]ParsedRule)
+	for _, rule := range tr.Rules() {
+		rules[rule.Protocol] = rule
+	}
+	return rules
+}
+
+func TestRunnerCompatLenient(t *testing.T) {
+	tr := newTestRunner(t, compatStrategy, "compat_mode: lenient\n")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	rules := compatRules(tr)
+
+	// The refinement is stripped and the rule runs without it
+	tcp := rules["tcp"]
+	if tcp.CompatError != "" || !slices.Equal(tcp.StrippedArgs, []string{"--dpi-desync-autottl"}) {
+		t.Errorf("tcp rule: CompatError %q, stripped %q, want --dpi-desync-autottl stripped", tcp.CompatError, tcp.StrippedArgs)
+	}
+	if strings.Contains(tcp.NFQWSArgs, "autottl") {
+		t.Errorf("tcp rule args = %q, want the option removed", tcp.NFQWSArgs)
+	}
+
+	// The other option can't be dropped and fails its rule
+	udp := rules["udp"]
+	if udp.CompatError != "unsupported nfqws options: --dpi-desync-split-pos" {
+		t.Errorf("udp rule CompatError = %q, want --dpi-desync-split-pos unsupported", udp.CompatError)
+	}
+	if got := tr.procManager.Count(); got != 1 {
+		t.Errorf("%d nfqws processes, want only the tcp rule's", got)
+	}
+	tr.checkConsistent(t)
+}
+
+func TestRunnerCompatStrict(t *testing.T) {
+	// Both rules fail, which is only started with nothing to apply allowed
+	tr := newTestRunner(t, compatStrategy, "compat_mode: strict\nempty_ruleset_policy: allow\n")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	rules := compatRules(tr)
+	if tcp := rules["tcp"]; tcp.CompatError != "unsupported nfqws options: --dpi-desync-autottl" || len(tcp.StrippedArgs) != 0 {
+		t.Errorf("tcp rule: CompatError %q, stripped %q, want --dpi-desync-autottl unsupported", tcp.CompatError, tcp.StrippedArgs)
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d nfqws processes, want none", got)
+	}
+}
+
+func TestRunnerCompatVersionGated(t *testing.T) {
+	tr := newTestRunner(t, compatStrategy, "compat_mode: lenient\n")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if udp := compatRules(tr)["udp"]; udp.CompatError == "" {
+		t.Fatal("udp rule runs with an option the installed nfqws lacks")
+	}
+
+	// A newer nfqws knows both options: nothing is stripped or failed
+	tr.replaceBinary(t, strings.Replace(testNFQWS, "--new", "--new --dpi-desync-autottl --dpi-desync-split-pos", 1))
+	if err := tr.RestartFiltered(t.Context(), nil, true); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	for proto, rule := range compatRules(tr) {
+		if rule.CompatError != "" || len(rule.StrippedArgs) != 0 {
+			t.Errorf("%s rule after the upgrade: CompatError %q, stripped %q, want neither", proto, rule.CompatError, rule.StrippedArgs)
+		}
+	}
+	if got := tr.procManager.Count(); got != 2 {
+		t.Errorf("%d nfqws processes after the upgrade, want 2", got)
+	}
+	tr.checkConsistent(t)
+}
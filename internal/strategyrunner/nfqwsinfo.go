@@ -0,0 +1,115 @@
+package strategyrunner
+
+import (
+	"fmt"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/features"
+)
+
+// Kinds of nfqws binaries.
+const (
+	NFQWSKindNFQWS       = "nfqws"
+	NFQWSKindUnknown     = "unknown"
+	NFQWSKindUnavailable = "unavailable"
+)
+
+// optionalNFQWSOptions are nfqws options not every build supports, with the
+// feature depending on each.
+var optionalNFQWSOptions = []struct {
+	Option string
+	Use    string
+}{
+	{Option: copyRangeOption, Use: "queue_copy_range"},
+	{Option: "--hostlist-auto", Use: "auto hostlists"},
+	{Option: "--filter-l7", Use: "protocol filters and rule labels"},
+	{Option: "--ipset", Use: "ipset filters"},
+	{Option: "--dpi-desync-fake-tls-mod", Use: "fake TLS modifications"},
+	{Option: "--debug", Use: "collect_stats"},
+}
+
+// NFQWSInfo classifies the configured nfqws binary.
+type NFQWSInfo struct {
+	// Binary is the path of the nfqws binary
+	Binary string
+
+	// Kind is "nfqws", "unknown" (runs but doesn't look like nfqws) or
+	// "unavailable" (can't be run)
+	Kind string
+
+	// Options is the number of options listed by --help
+	Options int
+
+	// Supported and Unsupported list the optional options by support
+	Supported   []string
+	Unsupported []string
+
+	// Error explains why the binary is unavailable
+	Error string
+}
+
+// RuntimeCapabilities is what the runner found out about the system.
+type RuntimeCapabilities struct {
+	Kernel []KernelCapability
+	NFQWS  NFQWSInfo
+
+	// Subsystems are the optional subsystems of the runner
+	Subsystems []features.Subsystem
+
+	// ExternalProcesses reports that nfqws is supervised externally, so the
+	// binary is informational only
+	ExternalProcesses bool
+}
+
+// Capabilities returns the kernel capabilities and the nfqws binary
+// classification. Kernel capabilities are probed here until a start has probed them.
+func (r *Runner) Capabilities() RuntimeCapabilities {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	caps := r.kernelCaps
+	if caps == nil {
+		caps = probeCapabilities(r.capProber, r.config.Firewall.Backend, r.config.NetworkNamespace)
+	}
+
+	info := NFQWSInfo{Binary: r.config.BinaryPath}
+	supported, err := r.supportedOptions()
+	switch {
+	case err != nil:
+		info.Kind, info.Error = NFQWSKindUnavailable, err.Error()
+	case supported["--dpi-desync"] && supported["--qnum"]:
+		info.Kind = NFQWSKindNFQWS
+	default:
+		info.Kind = NFQWSKindUnknown
+	}
+	info.Options = len(supported)
+	if err == nil {
+		for _, opt := range optionalNFQWSOptions {
+			if supported[opt.Option] {
+				info.Supported = append(info.Supported, opt.Option)
+			} else {
+				info.Unsupported = append(info.Unsupported, opt.Option+" ("+opt.Use+")")
+			}
+		}
+	}
+
+	update := r.config.HostlistUpdate
+	subsystems := []features.Subsystem{
+		{
+			Name:    "hostlist_updater",
+			Enabled: update.Interval > 0 && len(update.Sources) > 0,
+			Detail:  fmt.Sprintf("%d sources every %s", len(update.Sources), update.Interval),
+		},
+		{
+			Name:    "collect_stats",
+			Enabled: r.config.Process.CollectStats,
+			Detail:  "per-queue desync counters from nfqws debug output",
+		},
+	}
+
+	return RuntimeCapabilities{
+		Kernel:            caps,
+		NFQWS:             info,
+		Subsystems:        subsystems,
+		ExternalProcesses: r.externalProcesses(),
+	}
+}
@@ -0,0 +1,156 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ethtool"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
+)
+
+// OffloadFinding reports offload features enabled on an interface.
+type OffloadFinding struct {
+	Interface string
+
+	// Features are the problematic features found enabled
+	Features []string
+
+	// FixCommand disables the features manually
+	FixCommand string
+
+	// Fixed is set when auto_fix_offload disabled the features
+	Fixed bool
+
+	// Err is set when the interface could not be inspected or fixed
+	Err string
+}
+
+// namespacedDevice performs ethtool requests inside a network namespace.
+type namespacedDevice struct {
+	dev       ethtool.Device
+	namespace string
+}
+
+// Get implements ethtool.Device.
+func (d namespacedDevice) Get(iface string, cmd uint32) (uint32, error) {
+	var value uint32
+	err := netns.Do(d.namespace, func() error {
+		var err error
+		value, err = d.dev.Get(iface, cmd)
+		return err
+	})
+	return value, err
+}
+
+// Set implements ethtool.Device.
+func (d namespacedDevice) Set(iface string, cmd uint32, value uint32) error {
+	return netns.Do(d.namespace, func() error {
+		return d.dev.Set(iface, cmd, value)
+	})
+}
+
+// offloadDevice returns the device for the interfaces of the configured namespace.
+func (r *Runner) offloadDevice() ethtool.Device {
+	if r.config.NetworkNamespace == "" {
+		return r.offloadDev
+	}
+	return namespacedDevice{dev: r.offloadDev, namespace: r.config.NetworkNamespace}
+}
+
+// offloadInterfaces returns the configured interfaces that can be inspected.
+func (r *Runner) offloadInterfaces() []string {
+	var ifaces []string
+	for _, iface := range []string{r.config.Interface, r.config.InterfaceV6, r.config.LanInterface} {
+		if iface == "" || iface == "any" || slices.Contains(ifaces, iface) {
+			continue
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// checkOffload inspects offload features of the configured interfaces and
+// disables them if auto_fix_offload is set. Caller must hold r.mu.
+func (r *Runner) checkOffload() {
+	r.offload = nil
+	if !r.config.CheckOffload && !r.config.AutoFixOffload {
+		return
+	}
+
+	ifaces := r.offloadInterfaces()
+	if len(ifaces) == 0 {
+		r.logger.Info("skipping offload check, no specific interface configured")
+		return
+	}
+
+	for _, iface := range ifaces {
+		finding := OffloadFinding{Interface: iface}
+
+		features, err := ethtool.Check(r.offloadDevice(), iface)
+		if err != nil {
+			r.logger.Warn("failed to check interface offloads",
+				slog.String("interface", iface),
+				slog.Any("error", err),
+			)
+			finding.Err = err.Error()
+			r.offload = append(r.offload, finding)
+			continue
+		}
+		if len(features) == 0 {
+			continue
+		}
+
+		finding.Features = ethtool.Names(features)
+		finding.FixCommand = ethtool.FixCommand(iface, features)
+
+		if r.config.AutoFixOffload {
+			changed, err := ethtool.SetEnabled(r.offloadDevice(), iface, features, false)
+			if len(changed) > 0 {
+				r.offloadRestore[iface] = changed
+			}
+			if err == nil {
+				finding.Fixed = true
+				r.logger.Info("disabled interface offloads",
+					slog.String("interface", iface),
+					slog.Any("features", finding.Features),
+				)
+				r.events.Add("offload_fixed", fmt.Sprintf("%s: disabled %v", iface, finding.Features))
+				r.offload = append(r.offload, finding)
+				continue
+			}
+			finding.Err = err.Error()
+		}
+
+		r.logger.Warn("!!! interface offloads are enabled and may break desync; disable them with: "+finding.FixCommand,
+			slog.String("interface", iface),
+			slog.Any("features", finding.Features),
+		)
+		r.offload = append(r.offload, finding)
+	}
+}
+
+// restoreOffload re-enables features disabled by auto_fix_offload. Caller must hold r.mu.
+func (r *Runner) restoreOffload() error {
+	var errs []error
+	for iface, features := range r.offloadRestore {
+		if _, err := ethtool.SetEnabled(r.offloadDevice(), iface, features, true); err != nil {
+			r.logger.Warn("failed to restore interface offloads",
+				slog.String("interface", iface),
+				slog.Any("error", err),
+			)
+			errs = append(errs, err)
+			continue
+		}
+		r.logger.Info("restored interface offloads",
+			slog.String("interface", iface),
+			slog.Any("features", ethtool.Names(features)),
+		)
+		delete(r.offloadRestore, iface)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("offload restore errors: %v", errs)
+	}
+	return nil
+}
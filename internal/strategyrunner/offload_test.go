@@ -0,0 +1,125 @@
+package strategyrunner
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeOffloadDevice reports every offload feature of an interface as enabled
+// until they are switched off, and records the values set per interface.
+type fakeOffloadDevice struct {
+	mu  sync.Mutex
+	on  map[string]uint32
+	set map[string][]uint32
+}
+
+func newFakeOffloadDevice(ifaces ...string) *fakeOffloadDevice {
+	d := &fakeOffloadDevice{on: make(map[string]uint32), set: make(map[string][]uint32)}
+	for _, iface := range ifaces {
+		d.on[iface] = 1
+	}
+	return d
+}
+
+func (d *fakeOffloadDevice) Get(iface string, cmd uint32) (uint32, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.on[iface], nil
+}
+
+func (d *fakeOffloadDevice) Set(iface string, cmd uint32, value uint32) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.on[iface] = value
+	d.set[iface] = append(d.set[iface], value)
+	return nil
+}
+
+func (d *fakeOffloadDevice) values(iface string) []uint32 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.set[iface]
+}
+
+func TestOffloadCheckWarnsOnly(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "interface: eth0\ncheck_offload: true\n")
+	dev := newFakeOffloadDevice("eth0")
+	tr.offloadDev = dev
+
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	findings := tr.GetStatus().Offload
+	if len(findings) != 1 {
+		t.Fatalf("Offload = %+v, want one finding", findings)
+	}
+	got := findings[0]
+	if want := []string{"gro", "gso", "tso"}; got.Interface != "eth0" || !reflect.DeepEqual(got.Features, want) {
+		t.Errorf("finding = %+v, want eth0 with %v", got, want)
+	}
+	if got.Fixed {
+		t.Error("finding is fixed without auto_fix_offload")
+	}
+	if want := "ethtool -K eth0 gro off gso off tso off"; got.FixCommand != want {
+		t.Errorf("FixCommand = %q, want %q", got.FixCommand, want)
+	}
+	if set := dev.values("eth0"); len(set) != 0 {
+		t.Errorf("features were changed: %v", set)
+	}
+}
+
+func TestOffloadAutoFixRestoresOnStop(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "interface: eth0\nlan_interface: br-lan\nfirewall:\n  hook: forward\nauto_fix_offload: true\n")
+	dev := newFakeOffloadDevice("eth0")
+	tr.offloadDev = dev
+
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	findings := tr.GetStatus().Offload
+	if len(findings) != 1 || findings[0].Interface != "eth0" || !findings[0].Fixed {
+		t.Fatalf("Offload = %+v, want eth0 fixed and br-lan clean", findings)
+	}
+	if want := []uint32{0, 0, 0}; !reflect.DeepEqual(dev.values("eth0"), want) {
+		t.Errorf("values set on start = %v, want %v", dev.values("eth0"), want)
+	}
+	if set := dev.values("br-lan"); len(set) != 0 {
+		t.Errorf("br-lan had no offloads but was changed: %v", set)
+	}
+
+	if err := tr.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if want := []uint32{0, 0, 0, 1, 1, 1}; !reflect.DeepEqual(dev.values("eth0"), want) {
+		t.Errorf("values set after stop = %v, want %v", dev.values("eth0"), want)
+	}
+}
+
+func TestOffloadInterfaces(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{name: "any", cfg: Config{Interface: "any", LanInterface: "any"}},
+		{name: "wan only", cfg: Config{Interface: "eth0"}, want: []string{"eth0"}},
+		{
+			name: "deduplicated",
+			cfg:  Config{Interface: "eth0", InterfaceV6: "eth0", LanInterface: "br-lan"},
+			want: []string{"eth0", "br-lan"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Runner{config: &tt.cfg}
+			if got := r.offloadInterfaces(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("offloadInterfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
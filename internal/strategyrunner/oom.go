@@ -0,0 +1,197 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Exit classes of a process that exited on its own.
+const (
+	// ExitError is an exit with a status
+	ExitError = "error"
+
+	// ExitSignal is a kill by a signal
+	ExitSignal = "signal"
+
+	// ExitOOM is a kill by the kernel OOM killer
+	ExitOOM = "oom"
+)
+
+// oomHint is the advice given for an nfqws process killed by the OOM killer.
+const oomHint = "reduce the hostlists of the rule or raise its memory_limit (process.memory_limit or a rule override)"
+
+// oomSource exposes the kernel state OOM kills are detected from.
+type oomSource interface {
+	// KernelLog returns the kernel log messages logged at or after since,
+	// measured on the monotonic clock from boot
+	KernelLog(since time.Duration) ([]string, error)
+
+	// CgroupOOMKills returns the oom_kill counter of memory.events of the
+	// cgroup at dir
+	CgroupOOMKills(dir string) (uint64, error)
+
+	// Uptime returns the time since boot on the monotonic clock, the clock
+	// of the kernel log timestamps
+	Uptime() (time.Duration, error)
+}
+
+// Uptime implements oomSource.
+func (kernelOOM) Uptime() (time.Duration, error) {
+	return monotonicNow()
+}
+
+// kmsgMaxRecords bounds the kernel log records read to classify one exit.
+const kmsgMaxRecords = 8192
+
+// kmsgSlack widens the kernel log window before the process start, since the
+// kernel log clock and the Go monotonic clock are read at different moments.
+const kmsgSlack = time.Second
+
+// oomKilledRe matches the kernel log lines naming the process the OOM killer
+// took: "Out of memory: Killed process 123 (nfqws) ...", the same line of a
+// memory cgroup, and the "oom-kill:...,pid=123,..." summary.
+var oomKilledRe = regexp.MustCompile(`Killed process (\d+) |oom-kill:.*[:,]pid=(\d+)`)
+
+// oomKilledPID returns the PID named by an OOM killer log message.
+func oomKilledPID(msg string) (int, bool) {
+	m := oomKilledRe.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(m[1] + m[2])
+	return pid, err == nil
+}
+
+// parseKmsgRecord splits a /dev/kmsg record ("prio,seq,usec,flags;text\n"
+// followed by continuation lines) into its timestamp and message.
+func parseKmsgRecord(record string) (time.Duration, string, bool) {
+	header, text, ok := strings.Cut(record, ";")
+	if !ok {
+		return 0, "", false
+	}
+	fields := strings.Split(header, ",")
+	if len(fields) < 3 {
+		return 0, "", false
+	}
+	usec, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	text, _, _ = strings.Cut(text, "\n")
+	return time.Duration(usec) * time.Microsecond, text, true
+}
+
+// classifyExit tells how the process pid of tracked exited from the error of
+// its Wait, after running for uptime. A SIGKILL is attributed to the OOM
+// killer when the oom_kill counter of the process cgroup went up, or when
+// the kernel logged the kill of pid since the process started. Caller must
+// hold pm.mu.
+func (pm *ProcessManager) classifyExit(tracked *trackedProcess, pid int, waitErr error, uptime time.Duration) string {
+	var exitErr *exec.ExitError
+	if !errors.As(waitErr, &exitErr) {
+		return ExitError
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ExitError
+	}
+	if status.Signal() != syscall.SIGKILL {
+		return ExitSignal
+	}
+
+	if tracked.cgroup != "" {
+		kills, err := pm.oom.CgroupOOMKills(tracked.cgroup)
+		if err == nil {
+			if kills > tracked.cgroupOOMKills {
+				tracked.cgroupOOMKills = kills
+				return ExitOOM
+			}
+			return ExitSignal
+		}
+		pm.logger.Debug("cannot read cgroup memory events", slog.String("cgroup", tracked.cgroup), slog.Any("error", err))
+	}
+
+	now, err := pm.oom.Uptime()
+	if err != nil {
+		return ExitSignal
+	}
+	msgs, err := pm.oom.KernelLog(max(now-uptime-kmsgSlack, 0))
+	if err != nil {
+		pm.logger.Debug("cannot read the kernel log to tell an OOM kill", slog.Int("pid", pid), slog.Any("error", err))
+		return ExitSignal
+	}
+	for _, msg := range msgs {
+		if killed, ok := oomKilledPID(msg); ok && killed == pid {
+			return ExitOOM
+		}
+	}
+	return ExitSignal
+}
+
+// exitReason describes an exit of class for events and logs, naming the
+// memory limit of an OOM kill.
+func exitReason(class string, waitErr error, memoryLimit int64) string {
+	if class != ExitOOM {
+		return exitStatus(waitErr)
+	}
+	if memoryLimit > 0 {
+		return fmt.Sprintf("killed by the OOM killer at memory_limit %s", formatMemory(memoryLimit))
+	}
+	return "killed by the OOM killer (no memory_limit set)"
+}
+
+// processMemoryLimit returns the memory limit of the nfqws of rule in bytes,
+// 0 for none. Caller must hold r.mu.
+func (r *Runner) processMemoryLimit(rule ParsedRule) int64 {
+	if rule.MemoryLimit > 0 {
+		return rule.MemoryLimit
+	}
+	// Validated when the config was loaded
+	limit, _ := parseMemoryLimit(r.config.Process.MemoryLimit)
+	return limit
+}
+
+// parseMemoryLimit parses a memory size: a number of bytes with an optional
+// K, M or G suffix (powers of 1024). "" is no limit.
+func parseMemoryLimit(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	unit := int64(1)
+	num := strings.TrimSuffix(strings.ToUpper(s), "B")
+	switch {
+	case strings.HasSuffix(num, "K"), strings.HasSuffix(num, "KI"):
+		unit = 1 << 10
+	case strings.HasSuffix(num, "M"), strings.HasSuffix(num, "MI"):
+		unit = 1 << 20
+	case strings.HasSuffix(num, "G"), strings.HasSuffix(num, "GI"):
+		unit = 1 << 30
+	}
+	num = strings.TrimRight(num, "KMGI")
+	n, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/unit {
+		return 0, fmt.Errorf("invalid memory size %q (want e.g. 64M or 1G)", s)
+	}
+	return n * unit, nil
+}
+
+// formatMemory formats a size in bytes with the largest whole unit.
+func formatMemory(b int64) string {
+	switch {
+	case b%(1<<30) == 0:
+		return fmt.Sprintf("%d GiB", b>>30)
+	case b%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", b>>20)
+	case b%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", b>>10)
+	}
+	return fmt.Sprintf("%d B", b)
+}
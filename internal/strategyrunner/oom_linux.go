@@ -0,0 +1,137 @@
+//go:build linux
+
+package strategyrunner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// kmsgPath is the kernel log device.
+const kmsgPath = "/dev/kmsg"
+
+// kernelOOM reads OOM kills from /dev/kmsg and cgroup v2 memory.events.
+type kernelOOM struct{}
+
+// KernelLog implements oomSource. Records are read without blocking from
+// the oldest one the kernel still holds, at most kmsgMaxRecords of them.
+func (kernelOOM) KernelLog(since time.Duration) ([]string, error) {
+	// A raw descriptor, so reading past the last record returns EAGAIN
+	// instead of waiting in the poller
+	fd, err := unix.Open(kmsgPath, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", kmsgPath, err)
+	}
+	defer unix.Close(fd)
+
+	var msgs []string
+	buf := make([]byte, 8192)
+	for range kmsgMaxRecords {
+		n, err := unix.Read(fd, buf)
+		switch {
+		case errors.Is(err, unix.EAGAIN):
+			return msgs, nil
+		case errors.Is(err, unix.EPIPE):
+			// The record was overwritten while reading; go on with the next
+			continue
+		case err != nil:
+			return msgs, fmt.Errorf("read %s: %w", kmsgPath, err)
+		case n == 0:
+			return msgs, nil
+		}
+		at, msg, ok := parseKmsgRecord(string(buf[:n]))
+		if ok && at >= since {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// CgroupOOMKills implements oomSource.
+func (kernelOOM) CgroupOOMKills(dir string) (uint64, error) {
+	f, err := os.Open(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			return strconv.ParseUint(value, 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("memory.events has no oom_kill counter")
+}
+
+// monotonicNow returns the time since boot on the monotonic clock, the
+// clock of the kernel log timestamps.
+func monotonicNow() (time.Duration, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0, err
+	}
+	return time.Duration(ts.Nano()), nil
+}
+
+// memoryCgroup prepares the cgroup v2 group of queue below parent with
+// memory.max set to limit, creating parent and enabling its memory
+// controller as needed. It returns the group directory and an open
+// descriptor of it for starting a process inside.
+func memoryCgroup(parent string, queue int, limit int64) (string, *os.File, error) {
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", nil, fmt.Errorf("create cgroup %s: %w", parent, err)
+	}
+	control, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return "", nil, fmt.Errorf("%s is not a cgroup v2 group: %w", parent, err)
+	}
+	if !strings.Contains(" "+strings.TrimSpace(string(control))+" ", " memory ") {
+		if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory"), 0); err != nil {
+			return "", nil, fmt.Errorf("enable the memory controller in %s: %w", parent, err)
+		}
+	}
+
+	dir := filepath.Join(parent, fmt.Sprintf("queue-%d", queue))
+	if err := os.Mkdir(dir, 0755); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", nil, fmt.Errorf("create cgroup %s: %w", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(limit, 10)), 0); err != nil {
+		return "", nil, fmt.Errorf("set memory.max of %s: %w", dir, err)
+	}
+	// Without swap the limit is reached instead of swapping the process out
+	_ = os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0)
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, f, nil
+}
+
+// startInCgroup makes cmd start inside the cgroup open as dir.
+func startInCgroup(cmd *exec.Cmd, dir *os.File) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+}
+
+// removeMemoryCgroup removes the cgroup of a queue once its processes exited.
+func removeMemoryCgroup(dir string) error {
+	return unix.Rmdir(dir)
+}
@@ -0,0 +1,42 @@
+//go:build !linux
+
+package strategyrunner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// kernelOOM reports OOM kills where the kernel exposes them; it doesn't here.
+type kernelOOM struct{}
+
+// KernelLog implements oomSource.
+func (kernelOOM) KernelLog(since time.Duration) ([]string, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// CgroupOOMKills implements oomSource.
+func (kernelOOM) CgroupOOMKills(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// monotonicNow returns the time since boot on the monotonic clock; it isn't
+// available here.
+func monotonicNow() (time.Duration, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// memoryCgroup fails: memory limits need cgroup v2, which only Linux has.
+func memoryCgroup(parent string, queue int, limit int64) (string, *os.File, error) {
+	return "", nil, errors.New("memory_limit needs Linux cgroup v2")
+}
+
+// startInCgroup does nothing; memoryCgroup never succeeds here.
+func startInCgroup(cmd *exec.Cmd, dir *os.File) {}
+
+// removeMemoryCgroup does nothing; no cgroups are created here.
+func removeMemoryCgroup(dir string) error {
+	return nil
+}
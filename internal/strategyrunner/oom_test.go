@@ -0,0 +1,314 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOOM is an oomSource with a kernel log and cgroup counters set by the
+// test. The kernel log honours since against the timestamps of its records.
+type fakeOOM struct {
+	mu      sync.Mutex
+	uptime  time.Duration
+	records []kmsgRecord
+	logErr  error
+	kills   map[string]uint64
+	since   []time.Duration
+}
+
+type kmsgRecord struct {
+	at  time.Duration
+	msg string
+}
+
+func (f *fakeOOM) KernelLog(since time.Duration) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.since = append(f.since, since)
+	if f.logErr != nil {
+		return nil, f.logErr
+	}
+	var msgs []string
+	for _, r := range f.records {
+		if r.at >= since {
+			msgs = append(msgs, r.msg)
+		}
+	}
+	return msgs, nil
+}
+
+func (f *fakeOOM) CgroupOOMKills(dir string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	kills, ok := f.kills[dir]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return kills, nil
+}
+
+func (f *fakeOOM) Uptime() (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.uptime, nil
+}
+
+// log adds a kernel log record at uptime at.
+func (f *fakeOOM) log(at time.Duration, msg string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, kmsgRecord{at, msg})
+}
+
+// exitError runs script with sh and returns the error of its Wait.
+func exitError(t *testing.T, script string) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", script).Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("sh -c %q: %v, want it to fail", script, err)
+	}
+	return err
+}
+
+func TestOOMKilledPID(t *testing.T) {
+	tests := []struct {
+		msg    string
+		want   int
+		wantOK bool
+	}{
+		{msg: "Out of memory: Killed process 4242 (nfqws) total-vm:81234kB, anon-rss:65536kB", want: 4242, wantOK: true},
+		{msg: "Memory cgroup out of memory: Killed process 17 (nfqws) total-vm:1kB", want: 17, wantOK: true},
+		{msg: "oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=/,mems_allowed=0,oom_memcg=/zapret-ng/queue-0,task=nfqws,pid=4243,uid=0", want: 4243, wantOK: true},
+		{msg: "nfqws invoked oom-killer: gfp_mask=0xcc0(GFP_KERNEL), order=0"},
+		{msg: "eth0: link up"},
+	}
+
+	for _, tt := range tests {
+		got, ok := oomKilledPID(tt.msg)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("oomKilledPID(%q) = %d, %v, want %d, %v", tt.msg, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseKmsgRecord(t *testing.T) {
+	tests := []struct {
+		record  string
+		wantAt  time.Duration
+		wantMsg string
+		wantOK  bool
+	}{
+		{
+			record:  "3,1234,5678901234,-;Out of memory: Killed process 4242 (nfqws)\n",
+			wantAt:  5678901234 * time.Microsecond,
+			wantMsg: "Out of memory: Killed process 4242 (nfqws)",
+			wantOK:  true,
+		},
+		{
+			// Continuation lines carry key/value pairs, not the message
+			record:  "6,99,100,c;line one\n SUBSYSTEM=net\n DEVICE=n2\n",
+			wantAt:  100 * time.Microsecond,
+			wantMsg: "line one",
+			wantOK:  true,
+		},
+		{record: "no header\n"},
+		{record: "3,1234;text\n"},
+		{record: "3,1234,soon,-;text\n"},
+	}
+
+	for _, tt := range tests {
+		at, msg, ok := parseKmsgRecord(tt.record)
+		if at != tt.wantAt || msg != tt.wantMsg || ok != tt.wantOK {
+			t.Errorf("parseKmsgRecord(%q) = %v, %q, %v, want %v, %q, %v", tt.record, at, msg, ok, tt.wantAt, tt.wantMsg, tt.wantOK)
+		}
+	}
+}
+
+func TestClassifyExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exits by signal need a Unix shell")
+	}
+	killed := exitError(t, "kill -KILL $$")
+	terminated := exitError(t, "kill -TERM $$")
+	failed := exitError(t, "exit 3")
+
+	const pid = 4242
+	// The process started at uptime 1h and ran for a minute
+	now, uptime := time.Hour+time.Minute, time.Minute
+	oomLine := fmt.Sprintf("Out of memory: Killed process %d (nfqws)", pid)
+
+	tests := []struct {
+		name       string
+		err        error
+		cgroup     string
+		kills      map[string]uint64
+		records    []kmsgRecord
+		logErr     error
+		want       string
+		wantKills  uint64
+		wantLogged bool // whether the kernel log was read
+	}{
+		{name: "not an exit", err: errors.New("wait failed"), want: ExitError},
+		{name: "exit status", err: failed, want: ExitError},
+		{name: "other signal", err: terminated, want: ExitSignal},
+		{
+			name:      "cgroup counter went up",
+			err:       killed,
+			cgroup:    "/cg/queue-0",
+			kills:     map[string]uint64{"/cg/queue-0": 3},
+			want:      ExitOOM,
+			wantKills: 3,
+		},
+		{
+			name:      "cgroup counter unchanged",
+			err:       killed,
+			cgroup:    "/cg/queue-0",
+			kills:     map[string]uint64{"/cg/queue-0": 2},
+			records:   []kmsgRecord{{time.Hour + time.Second, oomLine}},
+			want:      ExitSignal,
+			wantKills: 2,
+		},
+		{
+			name:       "unreadable cgroup falls back to the kernel log",
+			err:        killed,
+			cgroup:     "/cg/gone",
+			records:    []kmsgRecord{{time.Hour + time.Second, oomLine}},
+			want:       ExitOOM,
+			wantKills:  2,
+			wantLogged: true,
+		},
+		{
+			name:       "kernel log names the process",
+			err:        killed,
+			records:    []kmsgRecord{{time.Hour - 10*time.Minute, "eth0: link up"}, {time.Hour + time.Second, oomLine}},
+			want:       ExitOOM,
+			wantLogged: true,
+		},
+		{
+			name:       "kernel log names another process",
+			err:        killed,
+			records:    []kmsgRecord{{time.Hour + time.Second, "Out of memory: Killed process 4243 (nfqws)"}},
+			want:       ExitSignal,
+			wantLogged: true,
+		},
+		{
+			// An earlier process had the PID
+			name:       "kill logged before the start",
+			err:        killed,
+			records:    []kmsgRecord{{time.Hour - 2*kmsgSlack, oomLine}},
+			want:       ExitSignal,
+			wantLogged: true,
+		},
+		{
+			name:       "kill logged within the slack",
+			err:        killed,
+			records:    []kmsgRecord{{time.Hour - kmsgSlack/2, oomLine}},
+			want:       ExitOOM,
+			wantLogged: true,
+		},
+		{
+			name:       "unreadable kernel log",
+			err:        killed,
+			logErr:     os.ErrPermission,
+			want:       ExitSignal,
+			wantLogged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oom := &fakeOOM{uptime: now, records: tt.records, logErr: tt.logErr, kills: tt.kills}
+			pm := NewProcessManager("nfqws", slog.New(slog.DiscardHandler))
+			pm.oom = oom
+			tracked := &trackedProcess{cgroup: tt.cgroup, cgroupOOMKills: 2}
+			if tt.cgroup == "" {
+				tracked.cgroupOOMKills = 0
+			}
+
+			if got := pm.classifyExit(tracked, pid, tt.err, uptime); got != tt.want {
+				t.Errorf("classifyExit() = %q, want %q", got, tt.want)
+			}
+			if tracked.cgroupOOMKills != tt.wantKills {
+				t.Errorf("cgroup oom_kill counter = %d, want %d", tracked.cgroupOOMKills, tt.wantKills)
+			}
+			if logged := len(oom.since) > 0; logged != tt.wantLogged {
+				t.Errorf("kernel log read = %v, want %v", logged, tt.wantLogged)
+			}
+			// The log is read from the process start, widened by the slack
+			if len(oom.since) > 0 && oom.since[0] != time.Hour-kmsgSlack {
+				t.Errorf("kernel log read since %s, want %s", oom.since[0], time.Hour-kmsgSlack)
+			}
+		})
+	}
+}
+
+func TestProcessRestartAfterOOMKill(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub nfqws is a shell script")
+	}
+	binary := filepath.Join(t.TempDir(), "nfqws")
+	if err := os.WriteFile(binary, []byte(testNFQWS), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	oom := &fakeOOM{uptime: time.Hour}
+	pm := NewProcessManager(binary, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	pm.oom = oom
+	events := make(chan string, 4)
+	pm.onEvent = func(kind, message string) { events <- kind + ": " + message }
+	t.Cleanup(func() { pm.StopAll() })
+	if err := pm.Start(&ProcessConfig{QueueNum: 3}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	pid := pm.Processes()[0].PID
+
+	// The kernel logs the kill of the process and SIGKILLs it
+	oom.log(time.Hour, fmt.Sprintf("Out of memory: Killed process %d (nfqws) total-vm:81234kB", pid))
+	pm.mu.Lock()
+	proc := pm.processes[0].proc
+	pm.mu.Unlock()
+	if err := proc.Kill(); err != nil {
+		t.Fatal(err)
+	}
+
+	var event string
+	select {
+	case event = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event for the killed process")
+	}
+	if !strings.HasPrefix(event, "process_oom_killed: queue 3: killed by the OOM killer") || !strings.Contains(event, oomHint) {
+		t.Errorf("event = %q, want an OOM kill with the hint", event)
+	}
+	info := pm.Processes()[0]
+	if info.LastExit != ExitOOM || info.OOMKills != 1 {
+		t.Errorf("LastExit = %q, OOMKills = %d, want an OOM kill counted", info.LastExit, info.OOMKills)
+	}
+
+	// and the queue gets a new process after the backoff
+	deadline := time.Now().Add(restartBackoffMin + 5*time.Second)
+	for {
+		info = pm.Processes()[0]
+		if info.Running && info.PID != pid {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process not restarted: %+v", info)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info.Restarts != 1 || info.OOMKills != 1 || info.Degraded {
+		t.Errorf("after the restart: %+v, want one restart keeping the OOM kill count", info)
+	}
+}
@@ -0,0 +1,155 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// outputRingSize is how many recent output lines are kept per process
+	outputRingSize = 200
+
+	// outputBacklog bounds the lines waiting to be logged. A burst beyond it
+	// is dropped from the log rather than blocking nfqws on a full pipe; the
+	// ring still keeps the latest lines.
+	outputBacklog = 1024
+
+	// maxOutputLine bounds the buffered partial line so a runaway line cannot
+	// grow memory
+	maxOutputLine = 64 * 1024
+
+	// outputWaitDelay bounds how long reaping a process waits for its output
+	// pipes to close, e.g. when a child of nfqws keeps them open
+	outputWaitDelay = 2 * time.Second
+)
+
+// outputRing keeps the last lines written by a process.
+type outputRing struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+}
+
+// add appends line, evicting the oldest once the ring is full.
+func (r *outputRing) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.lines) < outputRingSize {
+		r.lines = append(r.lines, line)
+		return
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % outputRingSize
+}
+
+// snapshot returns the kept lines, oldest first.
+func (r *outputRing) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := make([]string, 0, len(r.lines))
+	lines = append(lines, r.lines[r.next:]...)
+	return append(lines, r.lines[:r.next]...)
+}
+
+// processOutput receives the stdout and stderr of a process. Lines are kept
+// in the ring, counted into stats when collected and queued for the logger.
+// Write never blocks on the logger so nfqws can't stall on its own output.
+type processOutput struct {
+	ring       *outputRing
+	classifier *StatsClassifier
+	counters   *statCounters
+	partial    []byte
+
+	lines   chan string
+	dropped atomic.Uint64
+}
+
+// newProcessOutput returns the output of a process kept in ring, with stats
+// counted into counters if classifier is set.
+func newProcessOutput(ring *outputRing, classifier *StatsClassifier, counters *statCounters) *processOutput {
+	return &processOutput{
+		ring:       ring,
+		classifier: classifier,
+		counters:   counters,
+		lines:      make(chan string, outputBacklog),
+	}
+}
+
+// Write implements io.Writer. exec calls it from a single goroutine when
+// stdout and stderr share the writer.
+func (o *processOutput) Write(p []byte) (int, error) {
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := data[:i]
+		if len(o.partial) > 0 {
+			line = append(o.partial, line...)
+			o.partial = o.partial[:0]
+		}
+		o.line(line)
+		data = data[i+1:]
+	}
+
+	if len(o.partial)+len(data) <= maxOutputLine {
+		o.partial = append(o.partial, data...)
+	} else {
+		o.partial = o.partial[:0]
+	}
+
+	return len(p), nil
+}
+
+// line handles a complete output line.
+func (o *processOutput) line(line []byte) {
+	if o.classifier != nil {
+		o.classifier.classify(line, o.counters)
+	}
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+
+	s := string(line)
+	o.ring.add(s)
+	select {
+	case o.lines <- s:
+	default:
+		o.dropped.Add(1)
+	}
+}
+
+// close flushes an unterminated last line and ends forward. It must be called
+// once the process was reaped, when no Write can follow.
+func (o *processOutput) close() {
+	if len(o.partial) > 0 {
+		o.line(o.partial)
+		o.partial = nil
+	}
+	close(o.lines)
+}
+
+// forward logs the queued lines at level until close is called.
+func (o *processOutput) forward(logger *slog.Logger, level slog.Level) {
+	ctx := context.Background()
+	for line := range o.lines {
+		o.reportDropped(logger)
+		logger.Log(ctx, level, line)
+	}
+	o.reportDropped(logger)
+}
+
+// reportDropped logs how many lines were dropped since the last report.
+func (o *processOutput) reportDropped(logger *slog.Logger) {
+	if n := o.dropped.Swap(0); n > 0 {
+		logger.Warn("nfqws output lines dropped from the log, it wrote faster than they were logged", slog.Uint64("lines", n))
+	}
+}
@@ -0,0 +1,265 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// exportArgsWidth caps the nfqws arguments shown in exported override comments.
+const exportArgsWidth = 160
+
+// RuleSelector selects parsed rules. Empty fields match any rule.
+type RuleSelector struct {
+	// Label matches the rule label exactly, e.g. "discord+youtube"
+	Label string `yaml:"label"`
+
+	// Protocol matches the rule protocol ("tcp" or "udp")
+	Protocol string `yaml:"protocol"`
+
+	// Ports matches the rule port spec exactly as written after substitution,
+	// with port aliases (e.g. "https") resolved to their numbers and before
+	// any ports were excluded
+	Ports string `yaml:"ports"`
+
+	// Line matches the source line of the rule in the strategy file
+	Line int `yaml:"line"`
+}
+
+// Matches reports whether the selector matches the rule.
+func (s *RuleSelector) Matches(rule *ParsedRule) bool {
+	if s.Label != "" && s.Label != rule.Label {
+		return false
+	}
+	if s.Protocol != "" && s.Protocol != rule.Protocol {
+		return false
+	}
+	if s.Ports != "" && s.Ports != rule.selectorPorts() {
+		return false
+	}
+	if s.Line != 0 && s.Line != rule.SourceLine {
+		return false
+	}
+	return true
+}
+
+// SelectorFor returns the selector of an exported override for rules[i]. A
+// label no other rule has selects the rule and survives edits moving it in
+// the strategy file. Otherwise protocol, port spec and source line together
+// tell apart the rules of one strategy line, and stay stable across reloads
+// as long as the strategy file does.
+func SelectorFor(rules []ParsedRule, i int) RuleSelector {
+	rule := &rules[i]
+	unique := rule.Label != ""
+	for j := range rules {
+		if j != i && rules[j].Label == rule.Label {
+			unique = false
+			break
+		}
+	}
+	if unique {
+		return RuleSelector{Label: rule.Label}
+	}
+	return RuleSelector{Protocol: rule.Protocol, Ports: rule.selectorPorts(), Line: rule.SourceLine}
+}
+
+// ExportOverrides renders an overrides skeleton with one entry per rule,
+// selected by SelectorFor, enabled and with no extra arguments. The other
+// override settings are commented out, and the rule's label, queue, source
+// line and arguments are added as comments. A selector that also matches
+// other rules is marked, since its settings would apply to all.
+func ExportOverrides(rules []ParsedRule) string {
+	var b strings.Builder
+	b.WriteString("# Overrides skeleton for the applied rules. Copy the entries to the overrides\n")
+	b.WriteString("# list of the strategy config, edit or uncomment the settings to change and reload.\n")
+	b.WriteString("overrides:\n")
+
+	for i := range rules {
+		rule := &rules[i]
+		selector := SelectorFor(rules, i)
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  # rule %d, queue %d, line %d", i+1, rule.QueueNum, rule.SourceLine)
+		if rule.Label != "" {
+			fmt.Fprintf(&b, ", %s", rule.Label)
+		}
+		b.WriteString("\n")
+		args := rule.NFQWSArgs
+		if len(args) > exportArgsWidth {
+			args = args[:exportArgsWidth] + "..."
+		}
+		fmt.Fprintf(&b, "  # args: %s\n", args)
+		for j := range rules {
+			if j != i && selector.Matches(&rules[j]) {
+				fmt.Fprintf(&b, "  # note: also matches rule %d\n", j+1)
+			}
+		}
+
+		if selector.Label != "" {
+			fmt.Fprintf(&b, "  - label: %q\n", selector.Label)
+		} else {
+			fmt.Fprintf(&b, "  - protocol: %s\n", selector.Protocol)
+			fmt.Fprintf(&b, "    ports: %q\n", selector.Ports)
+			fmt.Fprintf(&b, "    line: %d\n", selector.Line)
+		}
+		b.WriteString("    enabled: true\n")
+		b.WriteString("    extra_args: \"\"\n")
+
+		rateLimit := "1000"
+		if rule.RateLimit > 0 {
+			rateLimit = fmt.Sprint(rule.RateLimit)
+		}
+		fmt.Fprintf(&b, "    # rate_limit: %s\n", rateLimit)
+		fmt.Fprintf(&b, "    # notrack: %t\n", !rule.Notrack)
+		b.WriteString("    # active_hours: [\"08:00-23:00\"]\n")
+		b.WriteString("    # memory_limit: 64M\n")
+	}
+	return b.String()
+}
+
+// resolved returns the selector with the port aliases in Ports replaced by
+// their numbers. A spec that doesn't resolve is kept and matches no rule.
+func (s RuleSelector) resolved(aliases PortAliases) RuleSelector {
+	if ports, _, err := aliases.Resolve(s.Ports, s.Protocol); err == nil {
+		s.Ports = ports
+	}
+	return s
+}
+
+// RuleOverride customizes the rules matched by its selector.
+type RuleOverride struct {
+	RuleSelector `yaml:",inline"`
+
+	// Enabled set to false drops the matched rules from the strategy
+	Enabled *bool `yaml:"enabled"`
+
+	// ExtraArgs are nfqws arguments appended to those of the matched rules.
+	// Every matching override adds its own.
+	ExtraArgs string `yaml:"extra_args"`
+
+	// RateLimit caps the packets per second delivered to the queue; excess packets skip desync
+	RateLimit *int `yaml:"rate_limit"`
+
+	// Notrack exempts the matched traffic from connection tracking, so high
+	// rate flows (e.g. game traffic over a wide UDP range) can't exhaust the
+	// conntrack table
+	Notrack *bool `yaml:"notrack"`
+
+	// ActiveHours restricts queueing to daily "HH:MM-HH:MM" windows in the
+	// configured timezone; nfqws keeps running outside them
+	ActiveHours []string `yaml:"active_hours"`
+
+	// MemoryLimit replaces process.memory_limit for the nfqws of the matched
+	// rules, e.g. "128M" for a rule with large hostlists
+	MemoryLimit string `yaml:"memory_limit"`
+
+	// PortsV6 replaces the ports of the matched rules for IPv6 traffic, e.g.
+	// when a service is reached on other ports over IPv6
+	PortsV6 string `yaml:"ports_v6"`
+
+	// InterfaceV6 replaces interface_v6 for the matched rules
+	InterfaceV6 string `yaml:"interface_v6"`
+
+	// ExcludePorts are removed from the ports of the matched rules, e.g. a
+	// range of a game the rule's wide port spec shouldn't queue. Every
+	// matching override removes its own.
+	ExcludePorts string `yaml:"exclude_ports"`
+}
+
+// Validate validates the override.
+func (o *RuleOverride) Validate() error {
+	if o.RateLimit != nil && *o.RateLimit <= 0 {
+		return fmt.Errorf("rate_limit must be positive, got %d", *o.RateLimit)
+	}
+	if _, err := ParseSchedule(o.ActiveHours); err != nil {
+		return fmt.Errorf("active_hours: %w", err)
+	}
+	if _, err := parseMemoryLimit(o.MemoryLimit); err != nil {
+		return fmt.Errorf("memory_limit: %w", err)
+	}
+	args := parseNFQWSArgs(o.ExtraArgs)
+	if _, removed := stripControlledOptions(args); len(removed) > 0 {
+		return fmt.Errorf("extra_args: %s is set by the runner", removed[0])
+	}
+	if slices.Contains(args, "--new") {
+		return fmt.Errorf("extra_args: --new would split the rule, add a rule to the strategy instead")
+	}
+	return nil
+}
+
+// applyOverrides applies all matching overrides to the rules in order and
+// returns the rules left enabled. Later overrides take precedence over
+// earlier ones.
+func applyOverrides(rules []ParsedRule, overrides []RuleOverride, aliases PortAliases, logger *slog.Logger) []ParsedRule {
+	result := make([]ParsedRule, 0, len(rules))
+	for i := range rules {
+		enabled := true
+		for _, o := range overrides {
+			selector := o.resolved(aliases)
+			if !selector.Matches(&rules[i]) {
+				continue
+			}
+			if o.Enabled != nil {
+				enabled = *o.Enabled
+			}
+			if o.ExtraArgs != "" {
+				rules[i].NFQWSArgs = strings.TrimSpace(rules[i].NFQWSArgs + " " + o.ExtraArgs)
+			}
+			if o.RateLimit != nil {
+				rules[i].RateLimit = *o.RateLimit
+			}
+			if o.Notrack != nil {
+				rules[i].Notrack = *o.Notrack
+			}
+			if len(o.ActiveHours) > 0 {
+				// Validated when the config was loaded
+				rules[i].Schedule, _ = ParseSchedule(o.ActiveHours)
+			}
+			if o.MemoryLimit != "" {
+				rules[i].MemoryLimit, _ = parseMemoryLimit(o.MemoryLimit)
+			}
+			if o.PortsV6 != "" {
+				// Validated when the config was loaded
+				rules[i].PortsV6, _, _ = aliases.Resolve(o.PortsV6, rules[i].Protocol)
+			}
+			if o.InterfaceV6 != "" {
+				rules[i].InterfaceV6 = o.InterfaceV6
+			}
+			if o.ExcludePorts != "" {
+				// Validated when the config was loaded; removed by applyExclusions
+				excluded, _, _ := aliases.Resolve(o.ExcludePorts, rules[i].Protocol)
+				rules[i].ExcludedPorts = joinPortSpecs(rules[i].ExcludedPorts, excluded)
+			}
+		}
+
+		if !enabled {
+			logger.Info("rule disabled by override",
+				slog.String("protocol", rules[i].Protocol),
+				slog.String("ports", rules[i].Ports),
+				slog.Int("line", rules[i].SourceLine),
+			)
+			continue
+		}
+		result = append(result, rules[i])
+	}
+	return result
+}
+
+// checkOverrides rejects rules whose overridden settings conflict with the
+// config. Notrack traffic has no conntrack entry, so a connbytes_limit match
+// on it can't count packets and would never match.
+func checkOverrides(rules []ParsedRule, cfg *Config) error {
+	if cfg.Firewall.ConnbytesLimit <= 0 {
+		return nil
+	}
+	for i := range rules {
+		if rules[i].Notrack {
+			return fmt.Errorf("line %d: %s %s: notrack can't be combined with firewall.connbytes_limit, untracked connections have no packet counters",
+				rules[i].SourceLine, rules[i].Protocol, rules[i].Ports)
+		}
+	}
+	return nil
+}
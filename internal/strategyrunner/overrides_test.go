@@ -0,0 +1,232 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func TestApplyOverridesFamily(t *testing.T) {
+	aliases, err := NewPortAliases(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []ParsedRule{
+		{Protocol: "tcp", Ports: "80,443", SourceLine: 1},
+		{Protocol: "udp", Ports: "443,50000-50100", SourceLine: 2},
+	}
+	overrides := []RuleOverride{
+		{RuleSelector: RuleSelector{Protocol: "tcp"}, PortsV6: "https"},
+		{RuleSelector: RuleSelector{Line: 2}, PortsV6: "443", InterfaceV6: "he-ipv6"},
+	}
+
+	applyOverrides(rules, overrides, aliases, slog.New(slog.DiscardHandler))
+
+	if rules[0].PortsV6 != "443" || rules[0].InterfaceV6 != "" {
+		t.Errorf("tcp rule got ports_v6 %q, interface_v6 %q, want \"443\" and none", rules[0].PortsV6, rules[0].InterfaceV6)
+	}
+	if rules[1].PortsV6 != "443" || rules[1].InterfaceV6 != "he-ipv6" {
+		t.Errorf("udp rule got ports_v6 %q, interface_v6 %q, want \"443\" and \"he-ipv6\"", rules[1].PortsV6, rules[1].InterfaceV6)
+	}
+}
+
+func TestApplyOverridesEnabledExtraArgs(t *testing.T) {
+	aliases, err := NewPortAliases(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	no, yes := false, true
+	rules := []ParsedRule{
+		{Protocol: "tcp", Ports: "443", SourceLine: 1, Label: "youtube", NFQWSArgs: "--dpi-desync=fake"},
+		{Protocol: "udp", Ports: "443", SourceLine: 2, Label: "youtube", NFQWSArgs: "--dpi-desync=fake"},
+		{Protocol: "udp", Ports: "50000-50100", SourceLine: 3, Label: "discord", NFQWSArgs: "--dpi-desync=fake"},
+	}
+	overrides := []RuleOverride{
+		{RuleSelector: RuleSelector{Label: "youtube"}, ExtraArgs: "--dpi-desync-repeats=6"},
+		{RuleSelector: RuleSelector{Protocol: "udp", Label: "youtube"}, ExtraArgs: "--dpi-desync-cutoff=d2"},
+		{RuleSelector: RuleSelector{Label: "discord"}, Enabled: &no},
+		// A later override enables the rule again
+		{RuleSelector: RuleSelector{Line: 3}, Enabled: &yes},
+		{RuleSelector: RuleSelector{Label: "discord"}, Enabled: &no},
+	}
+
+	got := applyOverrides(rules, overrides, aliases, slog.New(slog.DiscardHandler))
+
+	if len(got) != 2 {
+		t.Fatalf("applyOverrides() kept %d rules, want 2 with the discord rule disabled", len(got))
+	}
+	if want := "--dpi-desync=fake --dpi-desync-repeats=6"; got[0].NFQWSArgs != want {
+		t.Errorf("tcp rule args = %q, want %q", got[0].NFQWSArgs, want)
+	}
+	if want := "--dpi-desync=fake --dpi-desync-repeats=6 --dpi-desync-cutoff=d2"; got[1].NFQWSArgs != want {
+		t.Errorf("udp rule args = %q, want %q", got[1].NFQWSArgs, want)
+	}
+}
+
+func TestRuleOverrideValidateExtraArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		wantErr string
+	}{
+		{args: ""},
+		{args: "--dpi-desync-repeats=6 --hostlist=/opt/list.txt"},
+		{args: "--qnum=5", wantErr: "extra_args: --qnum=5 is set by the runner"},
+		{args: "--dpi-desync=fake --daemon", wantErr: "extra_args: --daemon is set by the runner"},
+		{args: "--dpi-desync=fake --new --filter-udp=443", wantErr: "extra_args: --new would split the rule"},
+	}
+
+	for _, tt := range tests {
+		o := RuleOverride{ExtraArgs: tt.args}
+		err := o.Validate()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("Validate() of extra_args %q error = %v", tt.args, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Validate() of extra_args %q error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// loadExported loads the overrides of an exported skeleton with the strategy
+// config loader, validated like the runner does.
+func loadExported(t *testing.T, exported string) []RuleOverride {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "strategy.yaml")
+	if err := os.WriteFile(path, []byte(exported), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadStrategyConfig(path)
+	if err != nil {
+		t.Fatalf("LoadStrategyConfig() of the export error = %v\n%s", err, exported)
+	}
+	for i := range cfg.Overrides {
+		if err := cfg.Overrides[i].Validate(); err != nil {
+			t.Fatalf("overrides[%d].Validate() error = %v", i, err)
+		}
+	}
+	return cfg.Overrides
+}
+
+// checkRoundTrip checks that each rule matches exactly one of the exported
+// overrides, that none disables or changes it, and that each override
+// selects exactly one rule.
+func checkRoundTrip(t *testing.T, rules []ParsedRule) {
+	t.Helper()
+	aliases, err := NewPortAliases(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := ExportOverrides(rules)
+	overrides := loadExported(t, exported)
+	if len(overrides) != len(rules) {
+		t.Fatalf("export has %d overrides for %d rules:\n%s", len(overrides), len(rules), exported)
+	}
+
+	selected := make([]int, len(overrides))
+	for i := range rules {
+		var matches []int
+		for j := range overrides {
+			selector := overrides[j].resolved(aliases)
+			if selector.Matches(&rules[i]) {
+				matches = append(matches, j)
+				selected[j]++
+			}
+		}
+		if len(matches) != 1 {
+			t.Errorf("rule %d (line %d, %s) matches overrides %v, want exactly one:\n%s", i+1, rules[i].SourceLine, rules[i].Label, matches, exported)
+		}
+	}
+	for j, n := range selected {
+		if n != 1 {
+			t.Errorf("overrides[%d] %+v selects %d rules, want 1", j, overrides[j].RuleSelector, n)
+		}
+	}
+
+	applied := applyOverrides(append([]ParsedRule(nil), rules...), overrides, aliases, slog.New(slog.DiscardHandler))
+	if len(applied) != len(rules) {
+		t.Fatalf("applying the export kept %d of %d rules", len(applied), len(rules))
+	}
+	for i := range applied {
+		if applied[i].NFQWSArgs != rules[i].NFQWSArgs {
+			t.Errorf("applying the export changed rule %d args to %q", i+1, applied[i].NFQWSArgs)
+		}
+	}
+}
+
+func TestExportOverridesRoundTrip(t *testing.T) {
+	for _, file := range []string{"general.bat", "discord.bat", "multiline.bat", "gamefilter.bat"} {
+		t.Run(file, func(t *testing.T) {
+			strategy, err := newTestParser(true).Parse(filepath.Join("testdata", "strategies", file))
+			if err != nil {
+				t.Fatalf("Parse(%s): %v", file, err)
+			}
+			checkRoundTrip(t, strategy.Rules)
+		})
+	}
+}
+
+func TestSelectorFor(t *testing.T) {
+	rules := []ParsedRule{
+		{Protocol: "tcp", Ports: "443", SourceLine: 2, Label: "youtube"},
+		{Protocol: "udp", Ports: "443", SourceLine: 3, Label: "youtube"},
+		{Protocol: "udp", Ports: "50000-50100", SourceLine: 4, Label: "discord"},
+	}
+	tests := []struct {
+		index int
+		want  RuleSelector
+	}{
+		{index: 0, want: RuleSelector{Protocol: "tcp", Ports: "443", Line: 2}},
+		{index: 1, want: RuleSelector{Protocol: "udp", Ports: "443", Line: 3}},
+		{index: 2, want: RuleSelector{Label: "discord"}},
+	}
+
+	for _, tt := range tests {
+		if got := SelectorFor(rules, tt.index); got != tt.want {
+			t.Errorf("SelectorFor(rules, %d) = %+v, want %+v", tt.index, got, tt.want)
+		}
+	}
+}
+
+func FuzzExportOverrides(f *testing.F) {
+	f.Add("youtube", "youtube", "discord+cloudflare", "443", uint8(2))
+	f.Add("", "tcp-80", "", "80,443", uint8(0))
+	f.Add("a\"b", "c\\d", "#x: y", "1024-65535", uint8(7))
+
+	f.Fuzz(func(t *testing.T, label1, label2, label3, ports string, lineStep uint8) {
+		// Labels are names of list files; ports are specs of digits, commas and dashes
+		for _, label := range []string{label1, label2, label3} {
+			if !utf8.ValidString(label) || strings.ContainsFunc(label, func(r rune) bool { return !unicode.IsPrint(r) }) {
+				return
+			}
+		}
+		if ports == "" || strings.Trim(ports, "0123456789,-") != "" {
+			return
+		}
+
+		var rules []ParsedRule
+		for i, label := range []string{label1, label2, label3} {
+			rules = append(rules, ParsedRule{
+				Protocol:   []string{"tcp", "udp"}[i%2],
+				Ports:      ports,
+				SourceLine: 1 + i*int(lineStep),
+				Label:      label,
+				NFQWSArgs:  fmt.Sprintf("--dpi-desync=fake --hostlist=%s.txt", label),
+			})
+		}
+		// Rules sharing label, protocol, ports and line can't be told apart
+		for i := range rules {
+			selector := SelectorFor(rules, i)
+			for j := range rules {
+				if j != i && selector.Matches(&rules[j]) {
+					return
+				}
+			}
+		}
+		checkRoundTrip(t, rules)
+	})
+}
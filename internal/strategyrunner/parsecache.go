@@ -0,0 +1,263 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// parseCacheSize is how many parsed strategies are kept: the applied one and
+// a candidate checked by a reload or a simulation.
+const parseCacheSize = 2
+
+// parseCache keeps parse results across reloads, so reloading an unchanged
+// strategy, or parsing it again to apply a reload that was just checked,
+// doesn't tokenize and normalize every rule again. It is shared by the
+// parsers of a runner.
+type parseCache struct {
+	mu sync.Mutex
+
+	// strategies are parsed strategies keyed by parser options and file
+	// content, newest last
+	strategies []cachedStrategy
+
+	// args memoizes the normalized nfqws arguments of rules keyed by their
+	// raw arguments, while the files they reference are unchanged; entries
+	// unused by the last two parses are dropped
+	args map[string]cachedArgs
+	gen  uint64
+
+	// payloads memoizes payload file checks while the size and modification
+	// time of the file are unchanged
+	payloads map[string]cachedPayload
+}
+
+// cachedStrategy is a parsed strategy, the key it was parsed under and the
+// state of the files its rules reference.
+type cachedStrategy struct {
+	key      string
+	strategy *ParsedStrategy
+	files    fileStamps
+}
+
+// cachedArgs is a memoized argument normalization, the state of the files
+// it references and the parse that last used it.
+type cachedArgs struct {
+	normalized normalizedArgs
+	files      fileStamps
+	gen        uint64
+}
+
+// fileStamp is the size and modification time of a file, zero if missing.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// fileStamps maps file paths to their state when a result was cached.
+type fileStamps map[string]fileStamp
+
+// stampFile returns the current state of the file at path.
+func stampFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// stampArgs records the state of the files referenced by nfqws args into stamps.
+func stampArgs(stamps fileStamps, args string) {
+	parsed := parseNFQWSArgs(args)
+	for _, opt := range pathOptions() {
+		for _, path := range optionValues(parsed, opt) {
+			if _, ok := stamps[path]; !ok {
+				stamps[path] = stampFile(path)
+			}
+		}
+	}
+}
+
+// current reports whether every file is in the state it was stamped in.
+func (s fileStamps) current() bool {
+	for path, stamp := range s {
+		now := stampFile(path)
+		if now.exists != stamp.exists || now.size != stamp.size || !now.modTime.Equal(stamp.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedPayload is the check result of a payload file in the state it was read.
+type cachedPayload struct {
+	size    int64
+	modTime time.Time
+	problem string
+}
+
+// newParseCache creates an empty parse cache.
+func newParseCache() *parseCache {
+	return &parseCache{
+		args:     make(map[string]cachedArgs),
+		payloads: make(map[string]cachedPayload),
+	}
+}
+
+// strategy returns a copy of the strategy parsed under key, if cached and
+// the files its rules reference are unchanged.
+func (c *parseCache) strategy(key string) (*ParsedStrategy, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, cached := range c.strategies {
+		if cached.key != key {
+			continue
+		}
+		if !cached.files.current() {
+			c.strategies = slices.Delete(c.strategies, i, i+1)
+			return nil, false
+		}
+		return cloneStrategy(cached.strategy), true
+	}
+	return nil, false
+}
+
+// storeStrategy caches a copy of strategy under key, evicting the oldest entry
+// when full.
+func (c *parseCache) storeStrategy(key string, strategy *ParsedStrategy) {
+	files := make(fileStamps)
+	for _, rule := range strategy.Rules {
+		stampArgs(files, rule.NFQWSArgs)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.strategies = slices.DeleteFunc(c.strategies, func(cached cachedStrategy) bool { return cached.key == key })
+	if len(c.strategies) == parseCacheSize {
+		c.strategies = c.strategies[1:]
+	}
+	c.strategies = append(c.strategies, cachedStrategy{key: key, strategy: cloneStrategy(strategy), files: files})
+}
+
+// beginParse starts a parse of a strategy that wasn't cached and returns its
+// generation, which marks the argument entries it uses.
+func (c *parseCache) beginParse() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	return c.gen
+}
+
+// endParse drops the argument entries used by neither this parse nor the
+// previous one, so rules removed from the strategy don't accumulate.
+func (c *parseCache) endParse(gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, cached := range c.args {
+		if cached.gen+1 < gen {
+			delete(c.args, key)
+		}
+	}
+}
+
+// normalizedArgs returns the memoized normalization of raw arguments. An
+// entry whose referenced files changed is dropped.
+func (c *parseCache) normalizedArgs(key string, gen uint64) (normalizedArgs, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.args[key]
+	if !ok {
+		return normalizedArgs{}, false
+	}
+	if !cached.files.current() {
+		delete(c.args, key)
+		return normalizedArgs{}, false
+	}
+	cached.gen = max(cached.gen, gen)
+	c.args[key] = cached
+	return cached.normalized.clone(), true
+}
+
+// storeNormalizedArgs memoizes the normalization of raw arguments with the
+// state of the files it references.
+func (c *parseCache) storeNormalizedArgs(key string, gen uint64, normalized normalizedArgs) {
+	files := make(fileStamps)
+	stampArgs(files, normalized.args)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.args[key] = cachedArgs{normalized: normalized.clone(), files: files, gen: gen}
+}
+
+// payloadProblem checks the payload file at path against its format, reusing
+// the result of the last check while the file's size and modification time
+// are unchanged.
+func (c *parseCache) payloadProblem(path string, format payloadFormat) string {
+	// The problem names the payload kind, which depends on the option
+	key := format.Option + "=" + path
+
+	info, err := os.Stat(path)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.payloads, key)
+		c.mu.Unlock()
+		return payloadProblem(path, format, nil, err)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.payloads[key]
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.problem
+	}
+
+	data, err := os.ReadFile(path)
+	problem := payloadProblem(path, format, data, err)
+	c.mu.Lock()
+	if err == nil {
+		c.payloads[key] = cachedPayload{size: info.Size(), modTime: info.ModTime(), problem: problem}
+	} else {
+		delete(c.payloads, key)
+	}
+	c.mu.Unlock()
+	return problem
+}
+
+// payloadIssues returns the problems of the payload files referenced by rule.
+func (c *parseCache) payloadIssues(rule ParsedRule) []string {
+	var issues []string
+	for _, ref := range payloadRefs(rule) {
+		if problem := c.payloadProblem(ref.Path, ref.Format); problem != "" {
+			issues = append(issues, problem)
+		}
+	}
+	return issues
+}
+
+// parseKey returns the cache key of parsing data with the options of p.
+func (p *Parser) parseKey(data []byte) string {
+	return fmt.Sprintf("%q %q %q %t %d %v %s",
+		p.variables["BIN"], p.variables["LISTS"], p.variables["GameFilter"],
+		p.gameFilter, p.maxLine, p.portAliases, digestBytes(data))
+}
+
+// cloneStrategy returns a copy of strategy whose rules can be changed without
+// affecting the original.
+func cloneStrategy(strategy *ParsedStrategy) *ParsedStrategy {
+	clone := &ParsedStrategy{
+		Rules:      slices.Clone(strategy.Rules),
+		EmptyRules: slices.Clone(strategy.EmptyRules),
+	}
+	for i := range clone.Rules {
+		rule := &clone.Rules[i]
+		rule.PortAliases = slices.Clone(rule.PortAliases)
+		rule.PathConversions = slices.Clone(rule.PathConversions)
+	}
+	return clone
+}
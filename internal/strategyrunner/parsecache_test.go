@@ -0,0 +1,229 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeListFiles creates the hostlist files named in dir.
+func writeListFiles(tb testing.TB, dir string, names ...string) {
+	tb.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("example.com\n"), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// cachedParser returns a test parser with an empty cache.
+func cachedParser() (*Parser, *parseCache) {
+	parser := newTestParser(false)
+	parser.cache = newParseCache()
+	return parser, parser.cache
+}
+
+// argsEntry returns the key of the argument entry whose raw args contain s.
+func argsEntry(t *testing.T, cache *parseCache, s string) string {
+	t.Helper()
+	for key := range cache.args {
+		if strings.Contains(key, s) {
+			return key
+		}
+	}
+	t.Fatalf("no argument entry for %q", s)
+	return ""
+}
+
+func TestParseCacheFileChange(t *testing.T) {
+	dir := t.TempDir()
+	writeListFiles(t, dir, "a.txt", "b.txt")
+	listA, listB := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	strategyPath := filepath.Join(dir, "strategy.bat")
+	content := fmt.Sprintf("--filter-tcp=443 --hostlist=%s --dpi-desync=fake --new ^\n--filter-tcp=80 --hostlist=%s --dpi-desync=fake\n", listA, listB)
+	if err := os.WriteFile(strategyPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, cache := cachedParser()
+	if _, err := parser.Parse(strategyPath); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Mark both entries: an entry used again returns its marked args, one
+	// normalized again replaces them
+	keyA, keyB := argsEntry(t, cache, "a.txt"), argsEntry(t, cache, "b.txt")
+	for _, key := range []string{keyA, keyB} {
+		entry := cache.args[key]
+		entry.normalized.args = "--marked"
+		cache.args[key] = entry
+	}
+	parsed := func() map[string]string {
+		t.Helper()
+		strategy, err := parser.Parse(strategyPath)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		args := make(map[string]string)
+		for _, rule := range strategy.Rules {
+			args[rule.Ports] = rule.NFQWSArgs
+		}
+		return args
+	}
+
+	// Unchanged files reuse the whole strategy
+	if args := parsed(); strings.Contains(args["443"], "--marked") || strings.Contains(args["80"], "--marked") {
+		t.Errorf("unchanged strategy parsed again: %v", args)
+	}
+
+	// A new mtime of a.txt busts the strategy and the entry of its rule only
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(listA, later, later); err != nil {
+		t.Fatal(err)
+	}
+	args := parsed()
+	if strings.Contains(args["443"], "--marked") || !strings.Contains(args["443"], listA) {
+		t.Errorf("rule of the changed hostlist got args %q, want them normalized again", args["443"])
+	}
+	if args["80"] != "--marked" {
+		t.Errorf("rule of the unchanged hostlist got args %q, want the memoized ones", args["80"])
+	}
+	if stamp := cache.args[keyA].files[listA]; !stamp.modTime.Equal(later) {
+		t.Errorf("entry of a.txt stamped %v, want the new mtime %v", stamp.modTime, later)
+	}
+
+	// A removed file busts its entry too
+	if err := os.Remove(listB); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.normalizedArgs(keyB, cache.gen); ok {
+		t.Error("entry of a removed hostlist still used")
+	}
+}
+
+func TestParseCacheLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeListFiles(t, dir, "a.txt")
+	list := filepath.Join(dir, "a.txt")
+
+	cache := newParseCache()
+	normalized := normalizedArgs{args: "--hostlist=" + list}
+	cache.storeNormalizedArgs("a", 1, normalized)
+	cache.storeNormalizedArgs("none", 1, normalizedArgs{args: "--dpi-desync=fake"})
+
+	if got, ok := cache.normalizedArgs("a", 2); !ok || got.args != normalized.args {
+		t.Errorf("normalizedArgs() = %+v, %v, want the stored entry", got, ok)
+	}
+
+	// Growing the file changes its size
+	if err := os.WriteFile(list, []byte("example.com\nexample.org\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.normalizedArgs("a", 3); ok {
+		t.Error("normalizedArgs() hit after the referenced file changed")
+	}
+	if _, ok := cache.args["a"]; ok {
+		t.Error("stale entry kept in the cache")
+	}
+	if _, ok := cache.normalizedArgs("none", 3); !ok {
+		t.Error("normalizedArgs() of args referencing no file missed")
+	}
+
+	// Entries unused by the last two parses are dropped
+	cache.endParse(4)
+	if _, ok := cache.args["none"]; !ok {
+		t.Error("entry used by the previous parse dropped")
+	}
+	cache.endParse(5)
+	if _, ok := cache.args["none"]; ok {
+		t.Error("entry unused by the last two parses kept")
+	}
+}
+
+// largeStrategy generates a strategy of n rules over a few hostlists in dir.
+func largeStrategy(tb testing.TB, dir string, n int) string {
+	tb.Helper()
+	var lists []string
+	for i := range 10 {
+		lists = append(lists, fmt.Sprintf("list%d.txt", i))
+	}
+	writeListFiles(tb, dir, lists...)
+
+	var b strings.Builder
+	b.WriteString(`start "zapret" /min "%BIN%winws.exe" --wf-tcp=1-65535 ^` + "\n")
+	for i := range n {
+		proto := "tcp"
+		if i%2 == 1 {
+			proto = "udp"
+		}
+		fmt.Fprintf(&b, "--filter-%s=%d --hostlist=\"%s\" --dpi-desync=fake,split2 --dpi-desync-repeats=%d --dpi-desync-fooling=md5sig ^\n",
+			proto, 1000+i, filepath.Join(dir, lists[i%len(lists)]), 1+i%11)
+		if i < n-1 {
+			b.WriteString("--new ^\n")
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// benchmarkStrategy writes a generated 400-rule strategy and returns its path.
+func benchmarkStrategy(b *testing.B) (string, string) {
+	b.Helper()
+	dir := b.TempDir()
+	content := largeStrategy(b, dir, 400)
+	path := filepath.Join(dir, "strategy.bat")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path, content
+}
+
+func BenchmarkParseCold(b *testing.B) {
+	path, _ := benchmarkStrategy(b)
+	for b.Loop() {
+		parser, _ := cachedParser()
+		if _, err := parser.Parse(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseWarm(b *testing.B) {
+	path, _ := benchmarkStrategy(b)
+	parser, _ := cachedParser()
+	if _, err := parser.Parse(path); err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		if _, err := parser.Parse(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseOneLineEdit parses the strategy with a different edit of one
+// line each time, so each parse misses the strategy cache but reuses the
+// other rules.
+func BenchmarkParseOneLineEdit(b *testing.B) {
+	path, content := benchmarkStrategy(b)
+	parser, _ := cachedParser()
+	if _, err := parser.Parse(path); err != nil {
+		b.Fatal(err)
+	}
+	i := 0
+	for b.Loop() {
+		i++
+		b.StopTimer()
+		edited := strings.Replace(content, "--filter-tcp=1000 ", fmt.Sprintf("--filter-tcp=1000 --dpi-desync-ttl=%d ", 1+i%255), 1)
+		if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if _, err := parser.Parse(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,24 +2,47 @@ package strategyrunner
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ports"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/textnorm"
+)
+
+// Limits of the longest strategy line accepted. Real strategies have lines of
+// hundreds of kilobytes, well past bufio.Scanner's 64KB default.
+const (
+	defaultStrategyLine = 1 << 20
+	minStrategyLine     = 64 << 10
 )
 
 // Parser parses .bat strategy files into internal representation.
 type Parser struct {
-	variables      map[string]string
-	gameFilter     bool
+	variables       map[string]string
+	gameFilter      bool
 	gameFilterPorts string
-	logger         *slog.Logger
+	portAliases     PortAliases
+	maxLine         int
+	logger          *slog.Logger
+
+	// cache reuses parse results across parsers if set
+	cache *parseCache
 }
 
 // ParsedStrategy represents a parsed strategy with rules.
 type ParsedStrategy struct {
 	Rules []ParsedRule
+
+	// EmptyRules are the lines of --filter- rules skipped for having no
+	// nfqws arguments
+	EmptyRules []int
 }
 
 // ParsedRule represents a single parsed rule.
@@ -30,11 +53,103 @@ type ParsedRule struct {
 	// Ports is a comma-separated list of ports or ranges
 	Ports string
 
+	// PortAliases are the alias names the port spec was written with, e.g. "https"
+	PortAliases []string
+
+	// PortsWritten is the port spec as written in the strategy file, before
+	// %GameFilter% and port aliases were substituted ("" when unknown, e.g.
+	// for winws --wf-tcp/--wf-udp rules)
+	PortsWritten string
+
+	// PortsExpanded is the port spec after %GameFilter% substitution, with
+	// port aliases not yet resolved
+	PortsExpanded string
+
+	// PortsResolved is the port spec with aliases resolved, before the
+	// exclude_ports of overrides and never_queue were removed from Ports (""
+	// when nothing was excluded). Overrides select the rule by it.
+	PortsResolved string
+
+	// ExcludedPorts are the ports the exclude_ports of overrides remove from
+	// the rule ("" for none)
+	ExcludedPorts string
+
 	// NFQWSArgs contains all arguments for nfqws
 	NFQWSArgs string
 
-	// QueueNum is the sequential queue number
+	// QueueNum is the queue number; the parser numbers rules sequentially and
+	// the runner replaces it with a number that is stable across reloads
 	QueueNum int
+
+	// QueuePreserved reports that QueueNum was kept from a previous run
+	QueuePreserved bool
+
+	// QueuePinned reports that QueueNum was set by an explicit --qnum in the
+	// rule; the allocator keeps it instead of choosing a number
+	QueuePinned bool
+
+	// SourceLine is the line number in the strategy file the rule came from
+	SourceLine int
+
+	// RateLimit is the maximum packets per second queued (0 for unlimited)
+	RateLimit int
+
+	// Notrack exempts the matched traffic from connection tracking
+	Notrack bool
+
+	// PortsV6 replaces Ports for IPv6 traffic when set by an override
+	PortsV6 string
+
+	// InterfaceV6 replaces the interface_v6 setting for the rule when set by
+	// an override
+	InterfaceV6 string
+
+	// MemoryLimit is the memory limit of the rule's nfqws set by an override
+	// in bytes (0 uses process.memory_limit)
+	MemoryLimit int64
+
+	// PayloadIssues lists problems of the fake payload files the rule
+	// references; nfqws would send broken fakes without reporting it
+	PayloadIssues []string
+
+	// Schedule restricts queueing to daily windows (nil for always)
+	Schedule Schedule
+
+	// ScheduledOff is set outside the scheduled windows; the firewall rule is
+	// removed while nfqws keeps running
+	ScheduledOff bool
+
+	// NextTransition is when the schedule next switches the rule on or off
+	NextTransition time.Time
+
+	// MissingFiles lists referenced hostlist/ipset files that don't exist yet.
+	// Rules with missing files are pending: neither queued nor served until the files appear.
+	MissingFiles []string
+
+	// StrippedArgs lists options removed because the installed nfqws doesn't
+	// support them; the rule runs degraded
+	StrippedArgs []string
+
+	// CompatError is set when the rule uses unsupported options that can't be
+	// stripped; failed rules are neither queued nor served
+	CompatError string
+
+	// PathConversions lists the Windows-style paths found in file-taking
+	// options and what they were converted to; a rule with paths that
+	// couldn't be converted fails
+	PathConversions []PathConversion
+
+	// Label is a short name derived from the lists the rule serves
+	Label string
+
+	// FilteredOut is set when the active rule filter doesn't select the rule;
+	// filtered out rules are neither queued nor served
+	FilteredOut bool
+}
+
+// active reports whether the rule is installed: selected, not failed and not pending.
+func (r *ParsedRule) active() bool {
+	return !r.FilteredOut && r.CompatError == "" && len(r.MissingFiles) == 0
 }
 
 // NewParser creates a new BAT file parser.
@@ -47,80 +162,409 @@ func NewParser(binPath, listsPath, gameFilterPorts string, gameFilterEnabled boo
 		},
 		gameFilter:      gameFilterEnabled,
 		gameFilterPorts: gameFilterPorts,
+		portAliases:     builtinPortAliases,
+		maxLine:         defaultStrategyLine,
 		logger:          logger,
 	}
 }
 
-// Parse parses a .bat strategy file.
+// Parse parses a .bat strategy file. With a cache, a file parsed before
+// with the same options is not parsed again.
 func (p *Parser) Parse(filepath string) (*ParsedStrategy, error) {
-	file, err := os.Open(filepath)
+	data, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open strategy file: %w", err)
 	}
-	defer file.Close()
+	if p.cache == nil {
+		return p.parse(data, 0)
+	}
 
-	var rules []ParsedRule
-	queueNum := 0
-	filterRegex := regexp.MustCompile(`--filter-(tcp|udp)=([0-9,-]+)\s+(.*?)(?:--new|$)`)
+	key := p.parseKey(data)
+	if strategy, ok := p.cache.strategy(key); ok {
+		p.logger.Debug("strategy file unchanged, reusing its parsed rules", slog.String("path", filepath))
+		return strategy, nil
+	}
+
+	gen := p.cache.beginParse()
+	strategy, err := p.parse(data, gen)
+	p.cache.endParse(gen)
+	if err != nil {
+		return nil, err
+	}
+	// Converting Windows paths depends on the files present, not only on the content
+	if !slices.ContainsFunc(strategy.Rules, func(r ParsedRule) bool { return len(r.PathConversions) > 0 }) {
+		p.cache.storeStrategy(key, strategy)
+	}
+	return strategy, nil
+}
 
-	scanner := bufio.NewScanner(file)
+// parse parses the content of a strategy file. gen is the parse generation
+// of the argument cache.
+func (p *Parser) parse(data []byte, gen uint64) (*ParsedStrategy, error) {
+	strategy := &ParsedStrategy{}
+	var logical strings.Builder
+	var segments []lineSegment
+
+	lineNum := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), p.maxLine)
 	for scanner.Scan() {
-		line := scanner.Text()
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
 
-		// Skip comments and service lines
-		if p.isSkipLine(line) {
+		// Comments may sit between the lines of a continued command
+		if len(segments) > 0 && isCommentLine(line) {
 			continue
 		}
 
-		// Apply variable substitution
-		line = p.substituteVariables(line)
-
-		// Find all filter rules in the line
-		matches := filterRegex.FindAllStringSubmatch(line, -1)
-		if len(matches) == 0 {
+		// A trailing ^ continues the command on the next line
+		line, continued := strings.CutSuffix(line, "^")
+		if logical.Len() > 0 {
+			logical.WriteByte(' ')
+		}
+		segments = append(segments, lineSegment{line: lineNum, offset: logical.Len()})
+		logical.WriteString(line)
+		if logical.Len() > p.maxLine {
+			// The scanner only limits the physical lines
+			return nil, fmt.Errorf("lines %d-%d: command joined from ^ continuations is longer than the %d byte limit (max_line_length)",
+				segments[0].line, lineNum, p.maxLine)
+		}
+		if continued {
 			continue
 		}
 
-		for _, match := range matches {
-			protocol := match[1]
-			ports := match[2]
-			nfqwsArgs := strings.TrimSpace(match[3])
+		if err := p.parseLine(strategy, logical.String(), segments, gen); err != nil {
+			return nil, err
+		}
+		logical.Reset()
+		segments = segments[:0]
+	}
+	if logical.Len() > 0 {
+		// The last line ended with a continuation
+		if err := p.parseLine(strategy, logical.String(), segments, gen); err != nil {
+			return nil, err
+		}
+	}
 
-			// Skip empty args
-			if nfqwsArgs == "" {
-				continue
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("line %d: longer than the %d byte limit (max_line_length)", lineNum+1, p.maxLine)
+		}
+		return nil, fmt.Errorf("error reading strategy file: %w", err)
+	}
+
+	if len(strategy.Rules) == 0 {
+		return nil, fmt.Errorf("no filter rules found in strategy file")
+	}
+
+	return strategy, nil
+}
+
+// filterRegex matches a --filter- rule and its arguments up to the next --new.
+var filterRegex = regexp.MustCompile(`--filter-(tcp|udp)=([0-9A-Za-z_,-]+)\s+(.*?)(?:--new|$)`)
+
+// filterSpecRegex matches the port spec of a --filter- option as written,
+// before variables are substituted.
+var filterSpecRegex = regexp.MustCompile(`--filter-(?:tcp|udp)=(\S*)`)
+
+// lookalikeSpecRegex matches a --filter- port spec written with full-width
+// digits, typographic dashes or Unicode spaces around its commas.
+var lookalikeSpecRegex = regexp.MustCompile(
+	`--filter-(?:tcp|udp)=[0-9\x{ff10}-\x{ff19}\-\x{2010}-\x{2014}\x{2212}\x{ff0d}\x{200b}-\x{200d}\x{2060}\x{feff}]+` +
+		`(?:[\x{a0}\x{1680}\x{2000}-\x{200d}\x{202f}\x{205f}\x{3000}\x{2060}\x{feff}]*[,\x{3001}\x{ff0c}\x{ff64}][\x{a0}\x{1680}\x{2000}-\x{200d}\x{202f}\x{205f}\x{3000}\x{2060}\x{feff}]*` +
+		`[0-9\x{ff10}-\x{ff19}\-\x{2010}-\x{2014}\x{2212}\x{ff0d}\x{200b}-\x{200d}\x{2060}\x{feff}]+)*`)
+
+// foldLine returns line with the look-alikes of --filter- port specs folded
+// to ASCII, other Unicode spaces replaced by a space and zero-width
+// characters dropped.
+func foldLine(line string) string {
+	line = lookalikeSpecRegex.ReplaceAllStringFunc(line, func(spec string) string {
+		folded, _ := textnorm.Spec(spec)
+		return folded
+	})
+	return strings.Map(func(r rune) rune {
+		switch {
+		case textnorm.IsInvisible(r):
+			return -1
+		case textnorm.IsSpace(r):
+			return ' '
+		}
+		return r
+	}, line)
+}
+
+// lineSegment is a physical line of a logical line joined from ^ continuations.
+type lineSegment struct {
+	// line is the line number in the strategy file
+	line int
+
+	// offset is where the line starts in the logical line
+	offset int
+}
+
+// segmentLine returns the number of the physical line holding offset.
+func segmentLine(segments []lineSegment, offset int) int {
+	line := segments[0].line
+	for _, seg := range segments {
+		if seg.offset > offset {
+			break
+		}
+		line = seg.line
+	}
+	return line
+}
+
+// isCommentLine reports whether line is a batch comment.
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "::") || strings.HasPrefix(strings.ToLower(line), "rem ")
+}
+
+// parseLine adds the rules of a logical line to strategy. Rules get the
+// number of the physical line their --filter- starts on.
+func (p *Parser) parseLine(strategy *ParsedStrategy, line string, segments []lineSegment, gen uint64) error {
+	// Skip comments and service lines
+	if p.isSkipLine(line) {
+		return nil
+	}
+
+	// Unicode look-alikes are folded per physical line so each keeps its
+	// offset
+	var folded strings.Builder
+	foldedSegments := make([]lineSegment, len(segments))
+	for i, seg := range segments {
+		end := len(line)
+		if i+1 < len(segments) {
+			end = segments[i+1].offset
+		}
+		foldedSegments[i] = lineSegment{line: seg.line, offset: folded.Len()}
+		physical := line[seg.offset:end]
+		if f := foldLine(physical); f != physical {
+			p.logger.Warn("normalized non-ASCII characters in strategy line",
+				slog.Int("line", seg.line),
+				slog.String("written", physical),
+				slog.String("normalized", f),
+			)
+			physical = f
+		}
+		folded.WriteString(physical)
+	}
+	segments = foldedSegments
+	line = folded.String()
+
+	// Port specs as written, for the coverage report
+	written := filterSpecRegex.FindAllStringSubmatch(line, -1)
+
+	// Variables are substituted per physical line so each keeps its offset
+	var substituted strings.Builder
+	offsets := make([]lineSegment, len(segments))
+	for i, seg := range segments {
+		end := len(line)
+		if i+1 < len(segments) {
+			end = segments[i+1].offset
+		}
+		offsets[i] = lineSegment{line: seg.line, offset: substituted.Len()}
+		substituted.WriteString(p.substituteVariables(line[seg.offset:end]))
+	}
+	segments = offsets
+	line = substituted.String()
+
+	// Strip the Windows `start "" /min "%BIN%winws.exe"` invocation
+	full := len(line)
+	line, isWinws := stripWinwsPrefix(line)
+	stripped := full - len(line)
+
+	// Find all filter rules in the line
+	var matches [][]string
+	var locs [][]int
+	for _, loc := range filterRegex.FindAllStringSubmatchIndex(line, -1) {
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = line[loc[2*i]:loc[2*i+1]]
 			}
+		}
+		matches = append(matches, match)
+		locs = append(locs, loc[:2])
+	}
+	if len(matches) == 0 && isWinws {
+		// winws lines without --filter- rules select traffic with --wf-tcp/--wf-udp
+		matches = winwsMatches(line)
+		locs = nil
+	}
 
-			// Clean up the args (remove quotes and leading dashes)
-			nfqwsArgs = p.cleanArgs(nfqwsArgs)
+	// Substitution never adds or removes a --filter- option, so the n-th one
+	// keeps its index
+	specs := filterSpecRegex.FindAllStringIndex(line, -1)
+	spec := 0
 
-			rule := ParsedRule{
-				Protocol:  protocol,
-				Ports:     ports,
-				NFQWSArgs: nfqwsArgs,
-				QueueNum:  queueNum,
+	for i, match := range matches {
+		ruleLine := segments[0].line
+		if locs != nil {
+			ruleLine = segmentLine(segments, locs[i][0]+stripped)
+		}
+		protocol := match[1]
+		ports := strings.Trim(match[2], ",")
+		var portsWritten string
+		if locs != nil {
+			for spec < len(specs) && specs[spec][0] < locs[i][0] {
+				spec++
 			}
+			if spec < len(written) {
+				portsWritten = written[spec][1]
+			}
+		}
+		portsExpanded := ports
 
-			p.logger.Debug("parsed rule",
+		normalized := p.normalizeArgs(match[3], gen)
+		if len(normalized.windowsOnly) > 0 {
+			p.logger.Warn("dropping Windows-only options",
+				slog.Int("line", ruleLine),
+				slog.Any("options", normalized.windowsOnly),
+			)
+		}
+		pinned, isPinned, err := explicitQueue(normalized.controlled)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", ruleLine, err)
+		}
+		if dropped := slices.DeleteFunc(slices.Clone(normalized.controlled), isQnumOption); len(dropped) > 0 {
+			p.logger.Warn("dropping options set by the process manager",
+				slog.Int("line", ruleLine),
+				slog.Any("options", dropped),
+			)
+		}
+		nfqwsArgs, conversions := normalized.args, normalized.conversions
+		for _, c := range conversions {
+			if c.Error != "" {
+				p.logger.Error("cannot convert Windows path, rule failed",
+					slog.Int("line", ruleLine),
+					slog.String("option", c.Option),
+					slog.String("path", c.Original),
+					slog.String("error", c.Error),
+				)
+				continue
+			}
+			p.logger.Warn("converted Windows path",
+				slog.Int("line", ruleLine),
+				slog.String("option", c.Option),
+				slog.String("path", c.Original),
+				slog.String("converted", c.Converted),
+			)
+		}
+
+		// Skip empty args
+		if nfqwsArgs == "" {
+			p.logger.Warn("skipping rule without nfqws arguments",
+				slog.Int("line", ruleLine),
 				slog.String("protocol", protocol),
 				slog.String("ports", ports),
-				slog.Int("queue", queueNum),
 			)
+			strategy.EmptyRules = append(strategy.EmptyRules, ruleLine)
+			continue
+		}
+
+		ports, aliases, err := p.portAliases.Resolve(ports, protocol)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid %s port spec %q: %w", ruleLine, protocol, match[2], err)
+		}
+		if err := validatePortSpec(ports); err != nil {
+			return fmt.Errorf("line %d: invalid %s port spec %q: %w", ruleLine, protocol, match[2], err)
+		}
 
-			rules = append(rules, rule)
-			queueNum++
+		queueNum := len(strategy.Rules)
+		if isPinned {
+			p.logger.Info("pinning rule to the queue number of its --qnum",
+				slog.Int("line", ruleLine),
+				slog.Int("queue", pinned),
+			)
+			queueNum = pinned
+		}
+		rule := ParsedRule{
+			Protocol:        protocol,
+			Ports:           ports,
+			PortAliases:     aliases,
+			PortsWritten:    portsWritten,
+			PortsExpanded:   portsExpanded,
+			NFQWSArgs:       nfqwsArgs,
+			QueueNum:        queueNum,
+			QueuePinned:     isPinned,
+			SourceLine:      ruleLine,
+			PathConversions: conversions,
+			CompatError:     pathConversionError(conversions),
 		}
+		rule.Label = ruleLabel(rule)
+
+		p.logger.Debug("parsed rule",
+			slog.String("protocol", protocol),
+			slog.String("ports", ports),
+			slog.Int("queue", queueNum),
+		)
+
+		strategy.Rules = append(strategy.Rules, rule)
 	}
+	return nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading strategy file: %w", err)
+// normalizedArgs are the nfqws arguments of a rule as written, normalized
+// for nfqws, and what normalizing them removed or converted.
+type normalizedArgs struct {
+	args string
+
+	// windowsOnly are the dropped winws options
+	windowsOnly []string
+
+	// controlled are the dropped options set by the process manager
+	controlled []string
+
+	// conversions are the Windows paths converted
+	conversions []PathConversion
+}
+
+// clone returns a copy of a that shares no slices with it.
+func (a normalizedArgs) clone() normalizedArgs {
+	a.windowsOnly = slices.Clone(a.windowsOnly)
+	a.controlled = slices.Clone(a.controlled)
+	a.conversions = slices.Clone(a.conversions)
+	return a
+}
+
+// normalizeArgs normalizes the raw nfqws arguments of a rule. Results are
+// memoized in the cache under gen, except when Windows paths were converted:
+// which path a conversion picks depends on the files present.
+func (p *Parser) normalizeArgs(raw string, gen uint64) normalizedArgs {
+	key := p.variables["LISTS"] + "\x00" + raw
+	if p.cache != nil {
+		if normalized, ok := p.cache.normalizedArgs(key, gen); ok {
+			return normalized
+		}
 	}
 
-	if len(rules) == 0 {
-		return nil, fmt.Errorf("no filter rules found in strategy file")
+	// Drop winws options that would make nfqws refuse to start
+	var normalized normalizedArgs
+	args, removed := stripWindowsOnlyOptions(raw)
+	normalized.windowsOnly = removed
+
+	// Clean up the args (remove quotes and leading dashes)
+	args = p.cleanArgs(args)
+
+	// Queue number, daemon mode and pid file are set per process
+	if kept, removed := stripControlledOptions(parseNFQWSArgs(args)); len(removed) > 0 {
+		normalized.controlled = removed
+		args = joinNFQWSArgs(kept)
 	}
 
-	return &ParsedStrategy{Rules: rules}, nil
+	// Strategies ported from Windows may carry C:\ or backslash paths
+	normalized.args, normalized.conversions = normalizeWindowsPaths(args, p.variables["LISTS"])
+
+	if p.cache != nil && len(normalized.conversions) == 0 {
+		p.cache.storeNormalizedArgs(key, gen, normalized)
+	}
+	return normalized
+}
+
+// SetMaxLineLength sets the longest line Parse accepts, in bytes.
+func (p *Parser) SetMaxLineLength(n int) {
+	p.maxLine = n
 }
 
 // isSkipLine checks if a line should be skipped.
@@ -200,3 +644,9 @@ func (p *Parser) cleanArgs(args string) string {
 
 	return args
 }
+
+// validatePortSpec checks a comma-separated list of ports and port ranges.
+func validatePortSpec(spec string) error {
+	_, err := ports.Parse(spec)
+	return err
+}
@@ -0,0 +1,492 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// Paths the test parsers substitute for %BIN% and %LISTS%. They don't exist,
+// so Windows path conversion gives the same result on every machine.
+const (
+	testBinPath   = "/opt/zapret-ng/bin/"
+	testListsPath = "/opt/zapret-ng/lists/"
+)
+
+// newTestParser returns a parser with the test paths and GameFilter ports.
+func newTestParser(gameFilter bool) *Parser {
+	return NewParser(testBinPath, testListsPath, "1024-65535", gameFilter, slog.New(slog.DiscardHandler))
+}
+
+func TestParseGolden(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		gameFilter bool
+	}{
+		{name: "general", file: "general.bat", gameFilter: true},
+		{name: "general-no-gamefilter", file: "general.bat"},
+		{name: "discord", file: "discord.bat"},
+		{name: "multiline", file: "multiline.bat"},
+		{name: "gamefilter", file: "gamefilter.bat", gameFilter: true},
+		{name: "gamefilter-off", file: "gamefilter.bat"},
+		{name: "tpws", file: "tpws.bat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := newTestParser(tt.gameFilter).Parse(filepath.Join("testdata", "strategies", tt.file))
+			if err != nil {
+				t.Fatalf("Parse(%s): %v", tt.file, err)
+			}
+
+			got, err := json.MarshalIndent(strategy, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "strategies", tt.name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -run TestParseGolden -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Parse(%s) differs from %s (run with -update if the change is intended)\n got: %s\nwant: %s",
+					tt.file, golden, got, want)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	type rule struct {
+		protocol string
+		ports    string
+		args     string
+		line     int
+	}
+	tests := []struct {
+		name       string
+		content    string
+		gameFilter bool
+		want       []rule
+		wantEmpty  []int
+	}{
+		{
+			name:    "rules chained with --new",
+			content: "--filter-tcp=80 --dpi-desync=fake --new --filter-udp=443 --dpi-desync=fake --dpi-desync-repeats=6\n",
+			want: []rule{
+				{"tcp", "80", "--dpi-desync=fake", 1},
+				{"udp", "443", "--dpi-desync=fake --dpi-desync-repeats=6", 1},
+			},
+		},
+		{
+			name:    "continuations keep the line of each filter",
+			content: "winws.exe ^\n--filter-tcp=443 ^\n  --dpi-desync=split2 --new ^\n--filter-udp=443 --dpi-desync=fake\n",
+			want: []rule{
+				{"tcp", "443", "--dpi-desync=split2", 2},
+				{"udp", "443", "--dpi-desync=fake", 4},
+			},
+		},
+		{
+			name:    "CRLF and blanks after the caret",
+			content: "--filter-tcp=443 ^ \t\r\n--dpi-desync=fake\r\n",
+			want:    []rule{{"tcp", "443", "--dpi-desync=fake", 1}},
+		},
+		{
+			name:    "comments between continued lines",
+			content: "--filter-tcp=443 ^\n:: a comment\nrem another\n--dpi-desync=fake\n",
+			want:    []rule{{"tcp", "443", "--dpi-desync=fake", 1}},
+		},
+		{
+			name:       "GameFilter enabled",
+			content:    "--filter-udp=443,%GameFilter% --dpi-desync=fake\n",
+			gameFilter: true,
+			want:       []rule{{"udp", "443,1024-65535", "--dpi-desync=fake", 1}},
+		},
+		{
+			name:    "GameFilter disabled",
+			content: "--filter-udp=443,%GameFilter% --dpi-desync=fake --new --filter-tcp=%GameFilter%,80 --dpi-desync=fake\n",
+			want: []rule{
+				{"udp", "443", "--dpi-desync=fake", 1},
+				{"tcp", "80", "--dpi-desync=fake", 1},
+			},
+		},
+		{
+			name:      "rule without arguments is skipped",
+			content:   "--filter-tcp=80 --new\n--filter-tcp=443 --dpi-desync=fake\n",
+			want:      []rule{{"tcp", "443", "--dpi-desync=fake", 2}},
+			wantEmpty: []int{1},
+		},
+		{
+			name:    "lists path is substituted",
+			content: `--filter-tcp=443 --hostlist="%LISTS%list-general.txt" --dpi-desync=fake` + "\n",
+			want:    []rule{{"tcp", "443", `--hostlist="` + testListsPath + `list-general.txt" --dpi-desync=fake`, 1}},
+		},
+		{
+			name:    "trailing comma in the port list",
+			content: "--filter-tcp=80,443, --dpi-desync=fake\n",
+			want:    []rule{{"tcp", "80,443", "--dpi-desync=fake", 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := newTestParser(tt.gameFilter).parse([]byte(tt.content), 0)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if len(strategy.Rules) != len(tt.want) {
+				t.Fatalf("got %d rules, want %d: %+v", len(strategy.Rules), len(tt.want), strategy.Rules)
+			}
+			for i, want := range tt.want {
+				got := strategy.Rules[i]
+				if got.Protocol != want.protocol || got.Ports != want.ports || got.NFQWSArgs != want.args || got.SourceLine != want.line {
+					t.Errorf("rule %d = %s %s %q line %d, want %s %s %q line %d", i,
+						got.Protocol, got.Ports, got.NFQWSArgs, got.SourceLine,
+						want.protocol, want.ports, want.args, want.line)
+				}
+				if got.QueueNum != i {
+					t.Errorf("rule %d has queue %d, want %d", i, got.QueueNum, i)
+				}
+			}
+			if len(strategy.EmptyRules) != len(tt.wantEmpty) {
+				t.Errorf("EmptyRules = %v, want %v", strategy.EmptyRules, tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "no rules",
+			content: "@echo off\n:: nothing here\n",
+			want:    "no filter rules found",
+		},
+		{
+			name:    "port out of range",
+			content: "--filter-tcp=70000 --dpi-desync=fake\n",
+			want:    `line 1: invalid tcp port spec "70000"`,
+		},
+		{
+			name:    "reversed range",
+			content: "\n--filter-udp=600-500 --dpi-desync=fake\n",
+			want:    `line 2: invalid udp port spec "600-500"`,
+		},
+		{
+			name:    "open-ended range",
+			content: "--filter-tcp=80- --dpi-desync=fake\n",
+			want:    `line 1: invalid tcp port spec "80-"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestParser(false).parse([]byte(tt.content), 0)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parse() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLongLines(t *testing.T) {
+	t.Run("200KB rule", func(t *testing.T) {
+		// One rule with a huge hostlist, as generated strategies have
+		var domains []string
+		for size, i := 0, 0; size < 200<<10; i++ {
+			domains = append(domains, fmt.Sprintf("host%d.example.com", i))
+			size += len(domains[i]) + 1
+		}
+		want := []string{"--dpi-desync=fake", "--dpi-desync-fake-tls-mod=rnd,sni=a b", "--hostlist-domains=" + strings.Join(domains, ",")}
+		content := `--filter-tcp=443 --dpi-desync=fake "--dpi-desync-fake-tls-mod=rnd,sni=a b" ` + want[2] + "\n"
+
+		strategy, err := newTestParser(false).parse([]byte(content), 0)
+		if err != nil {
+			t.Fatalf("parse() error = %v", err)
+		}
+		if len(strategy.Rules) != 1 {
+			t.Fatalf("parse() = %d rules, want 1", len(strategy.Rules))
+		}
+		if got := parseNFQWSArgs(strategy.Rules[0].NFQWSArgs); !slices.Equal(got, want) {
+			t.Errorf("parseNFQWSArgs() = %d args, want %d intact args", len(got), len(want))
+		}
+	})
+
+	t.Run("128KB chained filters", func(t *testing.T) {
+		var line strings.Builder
+		line.WriteString(`start "zapret" /min "%BIN%winws.exe"`)
+		count := 0
+		for ; line.Len() < 128<<10; count++ {
+			if count > 0 {
+				line.WriteString(" --new")
+			}
+			fmt.Fprintf(&line, " --filter-tcp=%d --dpi-desync=fake --dpi-desync-repeats=6 --dpi-desync-fooling=md5sig,badseq", 1000+count)
+		}
+		line.WriteString("\n")
+
+		strategy, err := newTestParser(false).parse([]byte(line.String()), 0)
+		if err != nil {
+			t.Fatalf("parse() error = %v", err)
+		}
+		if len(strategy.Rules) != count {
+			t.Fatalf("parse() = %d rules, want %d", len(strategy.Rules), count)
+		}
+		for i, rule := range strategy.Rules {
+			if want := fmt.Sprint(1000 + i); rule.Protocol != "tcp" || rule.Ports != want {
+				t.Errorf("rule %d = %s %s, want tcp %s", i, rule.Protocol, rule.Ports, want)
+			}
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		p := newTestParser(false)
+		p.SetMaxLineLength(minStrategyLine)
+		part := "--dpi-desync-fake-quic=" + strings.Repeat("a", 30<<10)
+
+		long := "--filter-udp=443 " + part + part + part + "\n"
+		if _, err := p.parse([]byte(long), 0); err == nil || !strings.Contains(err.Error(), "line 1: longer than") {
+			t.Errorf("parse() of a long line error = %v, want the line limit", err)
+		}
+
+		// Each line fits, the joined command doesn't
+		joined := "@echo off\n--filter-udp=443 ^\n" + part + " ^\n" + part + " ^\n" + part + "\n"
+		if _, err := p.parse([]byte(joined), 0); err == nil || !strings.Contains(err.Error(), "lines 2-5: command joined from ^ continuations is longer than") {
+			t.Errorf("parse() of long continuations error = %v, want the joined line limit", err)
+		}
+	})
+}
+
+func TestParseControlledOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       string
+		wantQueue  int
+		wantPinned bool
+		wantErr    string
+	}{
+		{name: "daemon", args: "--daemon --dpi-desync=fake"},
+		{name: "pidfile with =", args: "--pidfile=/run/nfqws.pid --dpi-desync=fake"},
+		{name: "pidfile with a space", args: "--pidfile /run/nfqws.pid --dpi-desync=fake"},
+		{name: "qnum with =", args: "--qnum=7 --dpi-desync=fake", wantQueue: 7, wantPinned: true},
+		{name: "qnum with a space", args: "--dpi-desync=fake --qnum 7", wantQueue: 7, wantPinned: true},
+		{name: "qnum repeated", args: "--qnum=7 --daemon --dpi-desync=fake --qnum 7", wantQueue: 7, wantPinned: true},
+		{name: "qnum conflicting", args: "--qnum=7 --dpi-desync=fake --qnum=8", wantErr: "line 1: conflicting --qnum=7 and --qnum=8"},
+		{name: "qnum invalid", args: "--qnum=abc --dpi-desync=fake", wantErr: `line 1: invalid --qnum "abc"`},
+		{name: "qnum without number", args: "--qnum --dpi-desync=fake", wantErr: "line 1: --qnum needs a queue number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := newTestParser(false).parse([]byte("--filter-tcp=443 "+tt.args+"\n"), 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse() error = %v", err)
+			}
+			rule := strategy.Rules[0]
+			if rule.NFQWSArgs != "--dpi-desync=fake" {
+				t.Errorf("NFQWSArgs = %q, want the controlled options removed", rule.NFQWSArgs)
+			}
+			if rule.QueuePinned != tt.wantPinned || (tt.wantPinned && rule.QueueNum != tt.wantQueue) {
+				t.Errorf("queue = %d pinned %v, want %d pinned %v", rule.QueueNum, rule.QueuePinned, tt.wantQueue, tt.wantPinned)
+			}
+
+			// The launched nfqws sees each controlled option once
+			args := processArgs(&ProcessConfig{QueueNum: 12, Dir: "/run/zapret-ng/q12", Args: parseNFQWSArgs(rule.NFQWSArgs + " " + tt.args)})
+			for option := range controlledOptions {
+				n := 0
+				for _, arg := range args {
+					if arg == option || strings.HasPrefix(arg, option+"=") {
+						n++
+					}
+				}
+				if want := map[string]int{"--qnum": 1, "--pidfile": 1}[option]; n != want {
+					t.Errorf("processArgs() = %q has %s %d times, want %d", args, option, n, want)
+				}
+			}
+			if !slices.Contains(args, "--qnum=12") {
+				t.Errorf("processArgs() = %q, want --qnum=12", args)
+			}
+		})
+	}
+}
+
+func TestParseLookalikes(t *testing.T) {
+	const ascii = "--filter-tcp=80,443,1024-1100 --dpi-desync=fake --new --filter-udp=50000-50100 --dpi-desync-repeats=6\n"
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "no-break spaces", content: "--filter-tcp=80,\u00a0443,\u00a01024-1100\u00a0--dpi-desync=fake --new --filter-udp=50000-50100 --dpi-desync-repeats=6\n"},
+		{name: "full-width digits", content: "--filter-tcp=\uff18\uff10,443,1024-1100 --dpi-desync=fake --new --filter-udp=\uff15\uff10000-50100 --dpi-desync-repeats=6\n"},
+		{name: "dashes", content: "--filter-tcp=80,443,1024\u20131100 --dpi-desync=fake --new --filter-udp=50000\u221250100 --dpi-desync-repeats=6\n"},
+		{name: "ideographic comma", content: "--filter-tcp=80\u3001443\u30011024-1100 --dpi-desync=fake --new --filter-udp=50000-50100 --dpi-desync-repeats=6\n"},
+		{name: "zero-width characters", content: "\ufeff--filter-tcp=80,443\u200b,1024-1100 --dpi-desync=fake --new --filter-udp=50000-50100 --dpi-desync-repeats=6\u200b\n"},
+	}
+
+	want, err := newTestParser(false).parse([]byte(ascii), 0)
+	if err != nil {
+		t.Fatalf("parse() of the ASCII form error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestParser(false).parse([]byte(tt.content), 0)
+			if err != nil {
+				t.Fatalf("parse() error = %v", err)
+			}
+			if len(got.Rules) != len(want.Rules) {
+				t.Fatalf("parse() = %d rules, want %d", len(got.Rules), len(want.Rules))
+			}
+			for i := range want.Rules {
+				g, w := got.Rules[i], want.Rules[i]
+				if g.Protocol != w.Protocol || g.Ports != w.Ports || g.NFQWSArgs != w.NFQWSArgs {
+					t.Errorf("rule %d = %s %q %q, want %s %q %q", i, g.Protocol, g.Ports, g.NFQWSArgs, w.Protocol, w.Ports, w.NFQWSArgs)
+				}
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	corpus, err := filepath.Glob(filepath.Join("testdata", "strategies", "*.bat"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range corpus {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, true)
+		f.Add(data, false)
+	}
+	f.Add([]byte("--filter-tcp=,,, --new"), true)
+	f.Add([]byte("--filter-udp=%GameFilter%^\n^\n--new"), false)
+	f.Add([]byte("start \"\" /min \"%BIN%winws.exe\" --wf-tcp=80,443 --wf-udp=443"), false)
+
+	f.Fuzz(func(t *testing.T, data []byte, gameFilter bool) {
+		strategy, err := newTestParser(gameFilter).parse(data, 0)
+		if err != nil {
+			return
+		}
+		if len(strategy.Rules) == 0 {
+			t.Fatal("parse succeeded without rules")
+		}
+		for _, rule := range strategy.Rules {
+			if rule.Protocol != "tcp" && rule.Protocol != "udp" {
+				t.Errorf("rule has protocol %q", rule.Protocol)
+			}
+			if err := validatePortSpec(rule.Ports); err != nil {
+				t.Errorf("rule has invalid ports %q: %v", rule.Ports, err)
+			}
+			if rule.NFQWSArgs == "" {
+				t.Error("rule has no nfqws arguments")
+			}
+		}
+	})
+}
+
+func TestDedupeRules(t *testing.T) {
+	type rule struct {
+		line  int
+		queue int
+	}
+	tests := []struct {
+		name        string
+		rules       []ParsedRule
+		want        []rule
+		wantDropped int
+	}{
+		{
+			name: "whitespace and quoting variants",
+			rules: []ParsedRule{
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: `--dpi-desync=fake --hostlist=list.txt`, SourceLine: 1},
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: `--dpi-desync=fake   --hostlist="list.txt"`, SourceLine: 2, QueueNum: 1},
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: `"--dpi-desync=fake" --hostlist=list.txt `, SourceLine: 3, QueueNum: 2},
+			},
+			want:        []rule{{1, 0}},
+			wantDropped: 2,
+		},
+		{
+			name: "different ttl kept apart",
+			rules: []ParsedRule{
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: "--dpi-desync=fake --dpi-desync-ttl=4", SourceLine: 1},
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: "--dpi-desync=fake --dpi-desync-ttl=5", SourceLine: 2, QueueNum: 1},
+			},
+			want: []rule{{1, 0}, {2, 1}},
+		},
+		{
+			name: "protocol and ports are part of the identity",
+			rules: []ParsedRule{
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", SourceLine: 1},
+				{Protocol: "udp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", SourceLine: 2, QueueNum: 1},
+				{Protocol: "tcp", Ports: "80", NFQWSArgs: "--dpi-desync=fake", SourceLine: 3, QueueNum: 2},
+			},
+			want: []rule{{1, 0}, {2, 1}, {3, 2}},
+		},
+		{
+			name: "first occurrence kept and later queues closed up",
+			rules: []ParsedRule{
+				{Protocol: "udp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", SourceLine: 1},
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: "--dpi-desync=split2", SourceLine: 4, QueueNum: 1},
+				{Protocol: "udp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", SourceLine: 7, QueueNum: 2},
+				{Protocol: "tcp", Ports: "80", NFQWSArgs: "--dpi-desync=fake", SourceLine: 9, QueueNum: 3},
+			},
+			want:        []rule{{1, 0}, {4, 1}, {9, 2}},
+			wantDropped: 1,
+		},
+		{
+			name: "pinned queue of a duplicate kept",
+			rules: []ParsedRule{
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", SourceLine: 1},
+				{Protocol: "tcp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", SourceLine: 2, QueueNum: 9, QueuePinned: true},
+			},
+			want:        []rule{{1, 9}},
+			wantDropped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			got := dedupeRules(tt.rules, slog.New(slog.NewTextHandler(&log, nil)))
+
+			var gotRules []rule
+			for _, r := range got {
+				gotRules = append(gotRules, rule{r.SourceLine, r.QueueNum})
+			}
+			if !slices.Equal(gotRules, tt.want) {
+				t.Errorf("dedupeRules() = %v, want %v", gotRules, tt.want)
+			}
+			if n := strings.Count(log.String(), "dropping duplicate strategy rule"); n != tt.wantDropped {
+				t.Errorf("logged %d dropped duplicates, want %d:\n%s", n, tt.wantDropped, log.String())
+			}
+		})
+	}
+}
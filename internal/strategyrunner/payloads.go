@@ -0,0 +1,200 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// quicMinPayload is the smallest plausible fake QUIC Initial; clients pad
+// Initial packets to at least 1200 bytes and shorter ones are dropped.
+const quicMinPayload = 1200
+
+// payloadFormat describes the fake payload files taken by an nfqws option.
+type payloadFormat struct {
+	// Option is the nfqws option taking the payload file
+	Option string
+
+	// Kind names the payload type in findings, e.g. "tls"
+	Kind string
+
+	// Check reports a problem with non-empty file content, nil if it looks valid
+	Check func(data []byte) error
+}
+
+// payloadFormats lists the nfqws options taking payload files. Options
+// without a Check only have to be readable and non-empty.
+var payloadFormats = []payloadFormat{
+	{Option: "--dpi-desync-fake-tls", Kind: "tls", Check: checkTLSPayload},
+	{Option: "--dpi-desync-fake-quic", Kind: "quic", Check: minPayloadLength(quicMinPayload)},
+	{Option: "--dpi-desync-fake-http", Kind: "http"},
+	{Option: "--dpi-desync-fake-unknown", Kind: "unknown"},
+	{Option: "--dpi-desync-fake-unknown-udp", Kind: "unknown-udp"},
+	{Option: "--dpi-desync-fake-syndata", Kind: "syndata"},
+	{Option: "--dpi-desync-fake-wireguard", Kind: "wireguard"},
+	{Option: "--dpi-desync-fake-dht", Kind: "dht"},
+	{Option: "--dpi-desync-fake-discord", Kind: "discord"},
+	{Option: "--dpi-desync-fake-stun", Kind: "stun"},
+	{Option: "--dpi-desync-fakedsplit-pattern", Kind: "pattern"},
+	{Option: "--dpi-desync-split-seqovl-pattern", Kind: "pattern"},
+	{Option: "--dpi-desync-udplen-pattern", Kind: "pattern"},
+}
+
+// checkTLSPayload checks that data starts with a TLS handshake record header.
+func checkTLSPayload(data []byte) error {
+	if !bytes.HasPrefix(data, []byte{0x16, 0x03}) {
+		return errors.New("does not start with a TLS handshake record header (16 03)")
+	}
+	return nil
+}
+
+// minPayloadLength returns a check requiring at least n bytes.
+func minPayloadLength(n int) func(data []byte) error {
+	return func(data []byte) error {
+		if len(data) < n {
+			return fmt.Errorf("too short: %d bytes, expected at least %d", len(data), n)
+		}
+		return nil
+	}
+}
+
+// isPayloadFile reports whether an option value names a file rather than an
+// inline hex payload ("0x...") or the nfqws built-in default ("!").
+func isPayloadFile(value string) bool {
+	return value != "" && !strings.HasPrefix(value, "0x") && !strings.HasPrefix(value, "!")
+}
+
+// payloadRef is a payload file referenced by a rule.
+type payloadRef struct {
+	Path   string
+	Format payloadFormat
+}
+
+// payloadRefs returns the payload files referenced by rule.
+func payloadRefs(rule ParsedRule) []payloadRef {
+	args := parseNFQWSArgs(rule.NFQWSArgs)
+
+	var refs []payloadRef
+	for _, format := range payloadFormats {
+		for _, value := range optionValues(args, format.Option) {
+			if isPayloadFile(value) {
+				refs = append(refs, payloadRef{Path: value, Format: format})
+			}
+		}
+	}
+	return refs
+}
+
+// resolvePayloads resolves bare payload file names in the rules against dir,
+// so strategies can write --dpi-desync-fake-tls=tls_clienthello.bin.
+func resolvePayloads(rules []ParsedRule, dir string) {
+	if dir == "" {
+		return
+	}
+
+	resolve := func(value string) string {
+		if !isPayloadFile(value) || filepath.Base(value) != value {
+			return value
+		}
+		return filepath.Join(dir, value)
+	}
+
+	for i := range rules {
+		args := parseNFQWSArgs(rules[i].NFQWSArgs)
+		resolved := args
+		for _, format := range payloadFormats {
+			resolved = replaceOptionValues(resolved, format.Option, resolve)
+		}
+		// Keep the args string as written unless a name was resolved, since
+		// queue numbers are derived from it
+		if !slices.Equal(args, resolved) {
+			rules[i].NFQWSArgs = joinNFQWSArgs(resolved)
+		}
+	}
+}
+
+// payloadProblem checks the payload file at path against its format and
+// returns the problem found, or "" if it looks valid.
+func payloadProblem(path string, format payloadFormat, data []byte, readErr error) string {
+	switch {
+	case readErr != nil:
+		return fmt.Sprintf("%s payload %s: %v", format.Kind, path, readErr)
+	case len(data) == 0:
+		return fmt.Sprintf("%s payload %s: empty file", format.Kind, path)
+	case format.Check != nil:
+		if err := format.Check(data); err != nil {
+			return fmt.Sprintf("%s payload %s: %v", format.Kind, path, err)
+		}
+	}
+	return ""
+}
+
+// PayloadInfo describes a payload file referenced by the applied strategy.
+type PayloadInfo struct {
+	// Path is the payload file
+	Path string
+
+	// Kind is the payload type of the option referencing it
+	Kind string
+
+	// Size is the file size in bytes
+	Size int64
+
+	// SHA256 is the hex encoded SHA-256 of the content, "" if unreadable
+	SHA256 string
+
+	// SourceLines are the strategy lines of the rules using the payload
+	SourceLines []int
+
+	// Queues are the queue numbers of the rules using the payload
+	Queues []int
+
+	// Problem describes what is wrong with the file, "" if it looks valid
+	Problem string
+}
+
+// Payloads returns the payload files referenced by the applied rules, sorted by path.
+func (r *Runner) Payloads() []PayloadInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byKey := make(map[string]*PayloadInfo)
+	for _, rule := range r.rules {
+		for _, ref := range payloadRefs(rule) {
+			key := ref.Path + "\x00" + ref.Format.Kind
+			info, ok := byKey[key]
+			if !ok {
+				info = &PayloadInfo{Path: ref.Path, Kind: ref.Format.Kind}
+				data, err := os.ReadFile(ref.Path)
+				if err == nil {
+					sum := sha256.Sum256(data)
+					info.Size = int64(len(data))
+					info.SHA256 = hex.EncodeToString(sum[:])
+				}
+				info.Problem = payloadProblem(ref.Path, ref.Format, data, err)
+				byKey[key] = info
+			}
+			info.SourceLines = append(info.SourceLines, rule.SourceLine)
+			info.Queues = append(info.Queues, rule.QueueNum)
+		}
+	}
+
+	payloads := make([]PayloadInfo, 0, len(byKey))
+	for _, info := range byKey {
+		payloads = append(payloads, *info)
+	}
+	sort.Slice(payloads, func(i, j int) bool {
+		if payloads[i].Path != payloads[j].Path {
+			return payloads[i].Path < payloads[j].Path
+		}
+		return payloads[i].Kind < payloads[j].Kind
+	})
+	return payloads
+}
@@ -0,0 +1,176 @@
+package strategyrunner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// fileOptions are the nfqws options referencing files that must exist for the rule to work.
+// Auto hostlists are left out because nfqws creates them itself.
+var fileOptions = []string{"--hostlist", "--hostlist-exclude", "--ipset", "--ipset-exclude"}
+
+// missingFiles returns the files referenced by rule that don't exist yet.
+func missingFiles(rule ParsedRule) []string {
+	args := parseNFQWSArgs(rule.NFQWSArgs)
+
+	var missing []string
+	for _, opt := range fileOptions {
+		for _, path := range optionValues(args, opt) {
+			if _, err := os.Stat(path); err != nil {
+				missing = append(missing, path)
+			}
+		}
+	}
+	return missing
+}
+
+// waitForPaths polls until all paths exist or timeout expires.
+// It never fails: once the timeout expires startup proceeds and rules
+// depending on missing files start as pending.
+func waitForPaths(ctx context.Context, paths []string, timeout time.Duration, logger *slog.Logger) {
+	if len(paths) == 0 || timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	logged := false
+	for {
+		var missing []string
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				missing = append(missing, path)
+			}
+		}
+		if len(missing) == 0 {
+			if logged {
+				logger.Info("all awaited paths are available")
+			}
+			return
+		}
+
+		if !logged {
+			logger.Info("waiting for paths to appear",
+				slog.Any("paths", missing),
+				slog.Duration("timeout", timeout),
+			)
+			logged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Warn("gave up waiting for paths, starting with pending rules",
+				slog.Any("missing", missing),
+			)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryPending periodically activates pending rules whose files have appeared.
+func (r *Runner) retryPending(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if remaining := r.activatePending(ctx); remaining == 0 {
+			return
+		}
+	}
+}
+
+// activatePending activates the pending rules whose files all exist.
+// It returns the number of rules still pending.
+func (r *Runner) activatePending(ctx context.Context) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running || ctx.Err() != nil {
+		return 0
+	}
+
+	remaining, activated := 0, 0
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if len(rule.MissingFiles) == 0 || rule.CompatError != "" {
+			continue
+		}
+
+		rule.MissingFiles = missingFiles(*rule)
+		if len(rule.MissingFiles) > 0 {
+			remaining++
+			continue
+		}
+		r.updateSchedule(rule, time.Now())
+
+		if err := r.activateRule(ctx, *rule); err != nil {
+			r.logger.Error("failed to activate pending rule",
+				slog.Int("queue", rule.QueueNum),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		r.logger.Info("pending rule activated",
+			slog.Int("queue", rule.QueueNum),
+			slog.Int("line", rule.SourceLine),
+		)
+		r.events.Add("rule_activated", fmt.Sprintf("queue %d (line %d)", rule.QueueNum, rule.SourceLine))
+		activated++
+	}
+
+	if activated > 0 {
+		r.writeQueueMap()
+	}
+	return remaining
+}
+
+// activateRule adds the firewall rule and starts the nfqws process of rule,
+// skipping whichever of them is managed externally. The firewall rule of a
+// rule outside its active hours is left to the schedule.
+// Caller must hold r.mu.
+func (r *Runner) activateRule(ctx context.Context, rule ParsedRule) error {
+	if !r.externalFirewall() && !rule.ScheduledOff {
+		if err := r.fw.AddRule(ctx, r.convertToFirewallRule(rule)); err != nil {
+			return fmt.Errorf("add rule failed: %w", err)
+		}
+	}
+	if r.externalProcesses() {
+		return nil
+	}
+
+	return r.procManager.Start(r.processConfig(rule))
+}
+
+// processConfig returns the configuration of the nfqws process serving
+// rule, creating its queue directory. The auto hostlist of the rule is
+// redirected into the directory. Caller must hold r.mu.
+func (r *Runner) processConfig(rule ParsedRule) *ProcessConfig {
+	dir := r.ensureQueueDir(rule)
+	return &ProcessConfig{
+		QueueNum:  rule.QueueNum,
+		Args:      r.queueAutoHostlist(parseNFQWSArgs(rule.NFQWSArgs), dir),
+		Env:       ruleEnv(rule),
+		CopyRange: r.processCopyRange(),
+		Dir:       dir,
+		Stats:     r.stats,
+		Namespace: r.config.NetworkNamespace,
+
+		DesyncMark:  r.processDesyncMark(),
+		MemoryLimit: r.processMemoryLimit(rule),
+	}
+}
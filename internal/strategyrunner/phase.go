@@ -0,0 +1,51 @@
+package strategyrunner
+
+// Runner phases published at each boundary of start, stop and reload.
+const (
+	PhaseStopped           = "stopped"
+	PhaseStopping          = "stopping"
+	PhaseStarting          = "starting"
+	PhaseParsing           = "parsing"
+	PhaseApplyingFirewall  = "applying_firewall"
+	PhaseStartingProcesses = "starting_processes"
+	PhaseRunning           = "running"
+)
+
+// transitional reports whether phase is part of a start, stop or reload.
+func transitional(phase string) bool {
+	return phase != PhaseStopped && phase != PhaseRunning
+}
+
+// setPhase enters phase and publishes the status for readers that must not
+// wait for the runner lock. Caller must hold r.mu.
+func (r *Runner) setPhase(phase string) {
+	r.phase = phase
+	r.phaseDone, r.phaseTotal = 0, 0
+	status := r.status()
+	r.published.Store(status)
+}
+
+// setPhaseProgress publishes that done of total rules of the current phase
+// are being handled. Caller must hold r.mu.
+func (r *Runner) setPhaseProgress(done, total int) {
+	r.phaseDone, r.phaseTotal = done, total
+	r.published.Store(r.status())
+}
+
+// publishedStatus returns a copy of the status published at the last phase boundary.
+func (r *Runner) publishedStatus() *Status {
+	status := *r.published.Load()
+	return &status
+}
+
+// activeRules counts the rules start applies, the total of the
+// applying_firewall and starting_processes phases.
+func activeRules(rules []ParsedRule) int {
+	active := 0
+	for i := range rules {
+		if rules[i].active() {
+			active++
+		}
+	}
+	return active
+}
@@ -0,0 +1,148 @@
+package strategyrunner
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pollFallbackInterval is the polling interval used when fsnotify can't be
+// set up and config_check_interval is not configured.
+const pollFallbackInterval = 30 * time.Second
+
+// polledFile is the last seen state of a polled file.
+type polledFile struct {
+	size    int64
+	modTime time.Time
+	digest  string
+}
+
+// contentPoller digests watched targets for polling. Files are only reread
+// when their size or modification time changed, so large hostlists aren't
+// read on every check.
+type contentPoller struct {
+	targets []WatchTarget
+	files   map[string]polledFile
+	digests map[string]string
+}
+
+// newContentPoller creates a poller for targets and records their current contents.
+func newContentPoller(targets []WatchTarget) *contentPoller {
+	p := &contentPoller{
+		targets: targets,
+		files:   make(map[string]polledFile),
+		digests: make(map[string]string),
+	}
+	for _, target := range targets {
+		p.digests[target.Name] = p.digest(target)
+	}
+	return p
+}
+
+// changed returns the targets whose contents changed since the previous check.
+func (p *contentPoller) changed() []WatchTarget {
+	var changed []WatchTarget
+	for _, target := range p.targets {
+		digest := p.digest(target)
+		if digest != p.digests[target.Name] {
+			p.digests[target.Name] = digest
+			changed = append(changed, target)
+		}
+	}
+	return changed
+}
+
+// digest returns a digest of the contents of target in the same form as
+// watchDigest, or "" if it can't be read.
+func (p *contentPoller) digest(target WatchTarget) string {
+	if target.Name == WatchTargetApply {
+		return p.fileDigest(filepath.Join(target.Path, ApplyMarker))
+	}
+	if !target.Dir {
+		return p.fileDigest(target.Path)
+	}
+
+	entries, err := os.ReadDir(target.Path)
+	if err != nil {
+		return ""
+	}
+	var parts []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		parts = append(parts, entry.Name()+"="+p.fileDigest(filepath.Join(target.Path, entry.Name())))
+	}
+	return digestBytes([]byte(strings.Join(parts, "\n")))
+}
+
+// fileDigest returns the digest of the file at path, reusing the cached one
+// while its size and modification time are unchanged.
+func (p *contentPoller) fileDigest(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(p.files, path)
+		return ""
+	}
+
+	if cached, ok := p.files[path]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.digest
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		delete(p.files, path)
+		return ""
+	}
+	digest := digestBytes(data)
+	p.files[path] = polledFile{size: info.Size(), modTime: info.ModTime(), digest: digest}
+	return digest
+}
+
+// startPolling polls the watch targets when config_check_interval is set, or
+// at pollFallbackInterval when the fsnotify watcher couldn't be set up.
+// Caller must hold r.mu.
+func (r *Runner) startPolling() {
+	interval := r.config.ConfigCheckInterval
+	if interval <= 0 && r.watchFallback {
+		interval = pollFallbackInterval
+		r.logger.Warn("file watcher unavailable, polling watched files for changes instead",
+			slog.Duration("interval", interval),
+		)
+	}
+	if interval <= 0 {
+		return
+	}
+
+	r.pollInterval = interval
+	poller := newContentPoller(r.watchTargets())
+	pollCtx, cancelPoll := context.WithCancel(r.lifecycleContext())
+	r.cancelPoll = cancelPoll
+	go r.poll(pollCtx, poller, interval)
+}
+
+// poll checks the watched targets every interval until ctx is cancelled and
+// handles changed ones like the watcher does.
+func (r *Runner) poll(ctx context.Context, poller *contentPoller, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, target := range poller.changed() {
+			r.logger.Info("polling detected a change of a watched file",
+				slog.String("target", target.Name),
+				slog.String("path", target.Path),
+			)
+			r.onWatchEvent(target)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ports"
+)
+
+// PortAlias is a named port usable in port specs in place of its number.
+type PortAlias struct {
+	// Port is the port number
+	Port int
+
+	// Protocol restricts the alias to "tcp" or "udp" rules ("" for both)
+	Protocol string
+}
+
+// builtinPortAliases are available without configuration.
+var builtinPortAliases = map[string]PortAlias{
+	"http":  {Port: 80},
+	"https": {Port: 443},
+	"quic":  {Port: 443, Protocol: "udp"},
+	"dns":   {Port: 53},
+}
+
+// PortAliases maps alias names to ports.
+type PortAliases map[string]PortAlias
+
+// NewPortAliases returns the built-in aliases extended by user defined ones,
+// given as "443" or "443/udp". User aliases replace built-in ones of the same name.
+func NewPortAliases(user map[string]string) (PortAliases, error) {
+	aliases := make(PortAliases, len(builtinPortAliases)+len(user))
+	for name, alias := range builtinPortAliases {
+		aliases[name] = alias
+	}
+
+	for name, value := range user {
+		if !isAliasName(name) {
+			return nil, fmt.Errorf("invalid alias name %q: must start with a letter", name)
+		}
+		number, protocol, restricted := strings.Cut(value, "/")
+		if restricted && protocol != "tcp" && protocol != "udp" {
+			return nil, fmt.Errorf("alias %s: invalid protocol %q: must be tcp or udp", name, protocol)
+		}
+		port, err := ports.ParsePort(number)
+		if err != nil {
+			return nil, fmt.Errorf("alias %s: %w", name, err)
+		}
+		aliases[strings.ToLower(name)] = PortAlias{Port: port, Protocol: protocol}
+	}
+	return aliases, nil
+}
+
+// isAliasName reports whether a port spec item is an alias name rather than
+// a number or range.
+func isAliasName(s string) bool {
+	return s != "" && (s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z')
+}
+
+// Resolve replaces the alias names in a port spec (e.g. "https,50000-50100")
+// with their numbers for a rule of protocol ("" accepts aliases of either
+// protocol). It returns the numeric spec and the aliases used.
+func (a PortAliases) Resolve(spec, protocol string) (string, []string, error) {
+	parts := strings.Split(spec, ",")
+	var used []string
+	for i, part := range parts {
+		if !isAliasName(part) {
+			continue
+		}
+
+		name := strings.ToLower(part)
+		alias, ok := a[name]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown port alias %q", part)
+		}
+		if alias.Protocol != "" && protocol != "" && alias.Protocol != protocol {
+			return "", nil, fmt.Errorf("port alias %q is %s only and can't be used in a %s port spec", part, alias.Protocol, protocol)
+		}
+		parts[i] = strconv.Itoa(alias.Port)
+		used = append(used, name)
+	}
+	return strings.Join(parts, ","), used, nil
+}
@@ -1,52 +1,257 @@
 package strategyrunner
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/netns"
 )
 
 // ProcessManager manages nfqws daemon processes.
 type ProcessManager struct {
 	binaryPath string
-	processes  []*os.Process
+	processes  []*trackedProcess
 	logger     *slog.Logger
 	mu         sync.Mutex
+
+	// zombies are the zombie children found by the last reaper check, and
+	// zombiesReaped counts those the reaper had to reap
+	reapMu        sync.Mutex
+	zombies       map[int]bool
+	zombiesReaped atomic.Uint64
+
+	// restartsTotal counts restarts of crashed processes, across StopAll
+	restartsTotal atomic.Uint64
+
+	// maxRetries and backoffMax are the restart policy of crashed processes
+	maxRetries int
+	backoffMax time.Duration
+
+	// clock runs the restart backoff timers
+	clock clock
+
+	// onEvent is told about crashed and degraded processes if set
+	onEvent func(kind, message string)
+
+	// stateFile records the running processes in stateFormat ("" for none)
+	stateFile   string
+	stateFormat string
+
+	// cgroupDir is the cgroup v2 group holding a group per queue for the
+	// processes with a memory limit
+	cgroupDir string
+
+	// oom tells OOM kills apart from other kills
+	oom oomSource
+
+	// options are the options the binary lists in its --help
+	options optionCache
+}
+
+// Default restart policy until SetRestartPolicy is called.
+const (
+	defaultRestartMaxRetries = 5
+	defaultRestartBackoffMax = time.Minute
+
+	// restartBackoffMin is the delay before the first restart of a crash
+	restartBackoffMin = time.Second
+)
+
+// trackedProcess is a started nfqws process and the queue it serves.
+type trackedProcess struct {
+	cfg       *ProcessConfig
+	proc      *os.Process
+	queueNum  int
+	startedAt time.Time
+	stats     *statCounters
+
+	// output keeps the last output lines, across restarts
+	output *outputRing
+
+	// identity is captured at start; signals are only sent while the PID
+	// still has it
+	identity processIdentity
+
+	// suspended is set while the process is stopped by Suspend
+	suspended bool
+
+	// done is closed once the current process exited and was reaped
+	done chan struct{}
+
+	// stopping is set by StopAll and KillAll; the exit is expected then and
+	// the process isn't restarted
+	stopping bool
+
+	// retries counts restarts since the process last ran for backoffMax,
+	// restarts all restarts
+	retries  int
+	restarts int
+
+	// degraded is set once retries are exhausted; the queue stays unserved
+	degraded bool
+
+	// restartTimer is pending while a restart waits for its backoff
+	restartTimer clockTimer
+
+	// cgroup is the cgroup enforcing the memory limit ("" for none) and
+	// cgroupOOMKills its oom_kill counter when the process started
+	cgroup         string
+	cgroupOOMKills uint64
+
+	// lastExit classifies the last exit ("" while it never exited on its
+	// own) and oomKills counts the exits by the OOM killer
+	lastExit string
+	oomKills int
+}
+
+// running reports whether the current process hasn't exited yet.
+func (t *trackedProcess) running() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// ProcessInfo describes a tracked nfqws process.
+type ProcessInfo struct {
+	PID       int
+	QueueNum  int
+	StartedAt time.Time
+
+	// Running reports that the process hasn't exited. An exited process
+	// stays listed while it waits for its restart or once it is degraded, so
+	// the queue that lost its consumer shows
+	Running bool
+
+	// Args are the nfqws arguments of the rule, without the queue number
+	Args []string
+
+	// Suspended reports that the process is stopped, by Suspend or by a
+	// SIGSTOP from elsewhere
+	Suspended bool
+
+	// Restarts counts restarts after the process crashed
+	Restarts int
+
+	// Degraded reports that the process kept crashing and is no longer
+	// restarted; PID is the last process then
+	Degraded bool
+
+	// LastExit is ExitError, ExitSignal or ExitOOM for the last time the
+	// process exited on its own ("" if it never did)
+	LastExit string
+
+	// OOMKills counts the exits by the kernel OOM killer
+	OOMKills int
+
+	// MemoryLimit is the memory limit enforced on the process in bytes (0
+	// for none)
+	MemoryLimit int64
+
+	// Stats are the desync statistics, nil unless stats collection is enabled
+	Stats *QueueStats
 }
 
 // ProcessConfig contains configuration for a single nfqws process.
 type ProcessConfig struct {
 	QueueNum int
 	Args     []string
+
+	// Env is added to the daemon environment of the process
+	Env []string
+
+	// CopyRange is the number of bytes of each packet copied to nfqws (0 for
+	// the nfqws default, the whole packet)
+	CopyRange int
+
+	// DesyncMark is the mark nfqws sets on the packets it sends, replacing
+	// the strategy's --dpi-desync-fwmark (0 leaves it to the strategy)
+	DesyncMark uint32
+
+	// Dir is the runtime directory of the queue holding the pidfile ("" for
+	// none)
+	Dir string
+
+	// Namespace is the network namespace the process runs in ("" for the host)
+	Namespace string
+
+	// MemoryLimit is the memory the process may use in bytes, enforced by a
+	// cgroup; the OOM killer takes a process exceeding it (0 for no limit)
+	MemoryLimit int64
+
+	// Stats enables stats collection from the process debug output if set
+	Stats *StatsClassifier
 }
 
 // NewProcessManager creates a new process manager.
 func NewProcessManager(binaryPath string, logger *slog.Logger) *ProcessManager {
 	return &ProcessManager{
 		binaryPath: binaryPath,
-		processes:  []*os.Process{},
+		processes:  []*trackedProcess{},
 		logger:     logger,
+		maxRetries: defaultRestartMaxRetries,
+		backoffMax: defaultRestartBackoffMax,
+		clock:      systemClock{},
+		oom:        kernelOOM{},
 	}
 }
 
-// Start starts a new nfqws process.
+// SetMemoryCgroup sets the cgroup v2 group below which the processes with a
+// memory limit get a group per queue.
+func (pm *ProcessManager) SetMemoryCgroup(dir string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.cgroupDir = dir
+}
+
+// SetRestartPolicy sets how often a crashed process is restarted before its
+// queue is given up as degraded, and the cap of the exponential backoff
+// between restarts. A process running for backoffMax counts as recovered.
+func (pm *ProcessManager) SetRestartPolicy(maxRetries int, backoffMax time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.maxRetries = maxRetries
+	pm.backoffMax = backoffMax
+}
+
+// Start starts a new nfqws process and supervises it: a process exiting on
+// its own is restarted with exponential backoff.
 func (pm *ProcessManager) Start(cfg *ProcessConfig) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	// Build command arguments
-	args := []string{
-		"--daemon",
-		fmt.Sprintf("--qnum=%d", cfg.QueueNum),
+	tracked := &trackedProcess{cfg: cfg, queueNum: cfg.QueueNum, output: &outputRing{}}
+	if err := pm.spawn(tracked); err != nil {
+		return err
 	}
-	args = append(args, cfg.Args...)
+	pm.processes = append(pm.processes, tracked)
+	return nil
+}
 
-	cmd := exec.Command(pm.binaryPath, args...)
+// spawn starts the process of tracked and the goroutine waiting for it to
+// exit, which is the only Wait of the process. Caller must hold pm.mu.
+func (pm *ProcessManager) spawn(tracked *trackedProcess) error {
+	cfg := pm.launchConfig(tracked.cfg)
+	args := processArgs(cfg)
+
+	var stats *statCounters
+	if cfg.Stats != nil {
+		stats = &statCounters{}
+	}
+	out := newProcessOutput(tracked.output, cfg.Stats, stats)
+	cmd := pm.command(cfg, args, out)
 
 	pm.logger.Info("starting nfqws process",
 		slog.Int("queue", cfg.QueueNum),
@@ -54,65 +259,619 @@ func (pm *ProcessManager) Start(cfg *ProcessConfig) error {
 		slog.String("args", strings.Join(args, " ")),
 	)
 
-	// Start the process
-	if err := cmd.Start(); err != nil {
+	// Start the process; it inherits the namespace of the starting thread
+	cgroup := pm.limitMemory(tracked, cmd)
+	err := netns.Do(cfg.Namespace, cmd.Start)
+	if err != nil && cgroup != nil {
+		// Starting into a cgroup needs clone3 (Linux 5.7)
+		pm.logger.Warn("cannot start nfqws in its cgroup, memory_limit is not enforced",
+			slog.Int("queue", cfg.QueueNum),
+			slog.Any("error", err),
+		)
+		cgroup.Close()
+		cgroup, tracked.cgroup = nil, ""
+		cmd = pm.command(cfg, args, out)
+		err = netns.Do(cfg.Namespace, cmd.Start)
+	}
+	if cgroup != nil {
+		cgroup.Close()
+	}
+	if err != nil {
 		return fmt.Errorf("failed to start nfqws: %w", err)
 	}
 
-	// Track the process
-	pm.processes = append(pm.processes, cmd.Process)
+	identity, err := readProcessIdentity(cmd.Process.Pid)
+	if err != nil {
+		// Without /proc the process is signalled unverified
+		pm.logger.Debug("cannot read nfqws process identity", slog.Int("pid", cmd.Process.Pid), slog.Any("error", err))
+	}
+
+	// Debug output for stats is too verbose to log at info
+	level := slog.LevelInfo
+	if cfg.Stats != nil {
+		level = slog.LevelDebug
+	}
+	go out.forward(pm.logger.With(
+		slog.String("component", "nfqws"),
+		slog.Int("queue", cfg.QueueNum),
+		slog.Int("pid", cmd.Process.Pid),
+	), level)
+
+	done := make(chan struct{})
+	tracked.proc = cmd.Process
+	tracked.startedAt = time.Now()
+	tracked.stats = stats
+	tracked.identity = identity
+	tracked.suspended = false
+	tracked.done = done
+	go pm.supervise(tracked, cmd, out, done)
 
 	return nil
 }
 
+// command returns the command running nfqws with args for cfg, writing its
+// output to out.
+func (pm *ProcessManager) command(cfg *ProcessConfig, args []string, out *processOutput) *exec.Cmd {
+	cmd := exec.Command(pm.binaryPath, args...)
+	// Stopping signals the group so nothing nfqws forked is left behind
+	setProcessGroup(cmd)
+	env := cfg.Env
+	if cfg.Dir != "" {
+		env = append(env, "ZAPRET_QUEUE_DIR="+cfg.Dir)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = outputWaitDelay
+	return cmd
+}
+
+// limitMemory makes cmd start in the cgroup enforcing the memory limit of
+// tracked and returns the open cgroup to close once the process started. A
+// limit that can't be enforced is logged and nil returned. Caller must hold
+// pm.mu.
+func (pm *ProcessManager) limitMemory(tracked *trackedProcess, cmd *exec.Cmd) *os.File {
+	tracked.cgroup = ""
+	limit := tracked.cfg.MemoryLimit
+	if limit <= 0 {
+		return nil
+	}
+	dir, cgroup, err := memoryCgroup(pm.cgroupDir, tracked.queueNum, limit)
+	if err != nil {
+		pm.logger.Warn("cannot create the cgroup of nfqws, memory_limit is not enforced",
+			slog.Int("queue", tracked.queueNum),
+			slog.Any("error", err),
+		)
+		return nil
+	}
+	// The counter carries kills of earlier processes of the queue
+	tracked.cgroupOOMKills, _ = pm.oom.CgroupOOMKills(dir)
+	tracked.cgroup = dir
+	startInCgroup(cmd, cgroup)
+	return cgroup
+}
+
+// supervise waits for the process of tracked to exit, closes its output and,
+// unless it was stopped, schedules its restart.
+func (pm *ProcessManager) supervise(tracked *trackedProcess, cmd *exec.Cmd, out *processOutput, done chan struct{}) {
+	err := cmd.Wait()
+	out.close()
+	close(done)
+
+	pm.mu.Lock()
+	if tracked.stopping || tracked.done != done {
+		pm.mu.Unlock()
+		return
+	}
+	uptime := time.Since(tracked.startedAt)
+	class := pm.classifyExit(tracked, cmd.Process.Pid, err, uptime)
+	tracked.lastExit = class
+	reason := exitReason(class, err, pm.memoryLimit(tracked))
+	if class == ExitOOM {
+		tracked.oomKills++
+		pm.logger.Error("nfqws process was killed by the OOM killer",
+			slog.Int("queue", tracked.queueNum),
+			slog.Int("pid", cmd.Process.Pid),
+			slog.String("reason", reason),
+			slog.Duration("uptime", uptime),
+			slog.String("hint", oomHint),
+		)
+	} else {
+		pm.logger.Warn("nfqws process exited unexpectedly",
+			slog.Int("queue", tracked.queueNum),
+			slog.Int("pid", cmd.Process.Pid),
+			slog.String("status", exitStatus(err)),
+			slog.String("exit", class),
+			slog.Duration("uptime", uptime),
+		)
+	}
+	// A process that ran long enough recovered; its retries start over
+	if uptime >= pm.backoffMax {
+		tracked.retries = 0
+	}
+	kind, message := pm.scheduleRestart(tracked, reason)
+	if class == ExitOOM {
+		// Restarting into the same limit likely ends the same way
+		message += "; " + oomHint
+		if kind == "process_exited" {
+			kind = "process_oom_killed"
+		}
+	}
+	pm.mu.Unlock()
+
+	pm.event(kind, message)
+}
+
+// memoryLimit returns the memory limit enforced on tracked, 0 for none.
+// Caller must hold pm.mu.
+func (pm *ProcessManager) memoryLimit(tracked *trackedProcess) int64 {
+	if tracked.cgroup == "" {
+		return 0
+	}
+	return tracked.cfg.MemoryLimit
+}
+
+// restart starts the process of tracked again once its backoff elapsed.
+func (pm *ProcessManager) restart(tracked *trackedProcess) {
+	pm.mu.Lock()
+	if tracked.stopping {
+		pm.mu.Unlock()
+		return
+	}
+	tracked.restartTimer = nil
+	tracked.restarts++
+	pm.restartsTotal.Add(1)
+	err := pm.spawn(tracked)
+	if err == nil {
+		pm.logger.Info("nfqws process restarted",
+			slog.Int("queue", tracked.queueNum),
+			slog.Int("pid", tracked.proc.Pid),
+			slog.Int("restarts", tracked.restarts),
+		)
+		if err := pm.writeState(); err != nil {
+			pm.logger.Warn("failed to write process state file", slog.Any("error", err))
+		}
+		pm.mu.Unlock()
+		return
+	}
+	pm.logger.Error("failed to restart nfqws process", slog.Int("queue", tracked.queueNum), slog.Any("error", err))
+	kind, message := pm.scheduleRestart(tracked, err.Error())
+	pm.mu.Unlock()
+
+	pm.event(kind, message)
+}
+
+// scheduleRestart arms the restart of tracked after the backoff of its next
+// retry, or marks it degraded once retries are exhausted. It returns the
+// event describing the outcome. Caller must hold pm.mu.
+func (pm *ProcessManager) scheduleRestart(tracked *trackedProcess, reason string) (string, string) {
+	if tracked.retries >= pm.maxRetries {
+		tracked.degraded = true
+		pm.logger.Error("nfqws process keeps exiting, giving up restarting it",
+			slog.Int("queue", tracked.queueNum),
+			slog.Int("retries", tracked.retries),
+		)
+		return "process_degraded", fmt.Sprintf("queue %d: %s, not restarted after %d retries", tracked.queueNum, reason, tracked.retries)
+	}
+
+	delay := restartBackoff(tracked.retries, pm.backoffMax)
+	tracked.retries++
+	tracked.restartTimer = pm.clock.AfterFunc(delay, func() { pm.restart(tracked) })
+	pm.logger.Info("restarting nfqws process",
+		slog.Int("queue", tracked.queueNum),
+		slog.Duration("backoff", delay),
+		slog.Int("retry", tracked.retries),
+	)
+	return "process_exited", fmt.Sprintf("queue %d: %s, restarting in %s", tracked.queueNum, reason, delay)
+}
+
+// restartBackoff returns the delay before retry n (from 0): restartBackoffMin
+// doubling with each retry, capped at limit.
+func restartBackoff(n int, limit time.Duration) time.Duration {
+	delay := restartBackoffMin
+	for i := 0; i < n && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// exitStatus describes how a process exited from the error of cmd.Wait.
+func exitStatus(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
+
+// event passes an event to onEvent if set. Caller must not hold pm.mu.
+func (pm *ProcessManager) event(kind, message string) {
+	if pm.onEvent != nil {
+		pm.onEvent(kind, message)
+	}
+}
+
+// stopSupervision marks tracked as stopping so its exit isn't restarted and
+// cancels a pending restart. Caller must hold pm.mu.
+func (t *trackedProcess) stopSupervision() {
+	t.stopping = true
+	if t.restartTimer != nil {
+		t.restartTimer.Stop()
+		t.restartTimer = nil
+	}
+}
+
+// launchConfig returns cfg without the options the binary doesn't list in
+// its --help. Stock nfqws exits on an unknown option, so --copy-range is only
+// passed to builds supporting it, checked again at every launch because the
+// binary may have been replaced since the config was built.
+func (pm *ProcessManager) launchConfig(cfg *ProcessConfig) *ProcessConfig {
+	if cfg.CopyRange == 0 {
+		return cfg
+	}
+	supported, err := pm.options.options(pm.binaryPath)
+	if err == nil && supported[copyRangeOption] {
+		return cfg
+	}
+	pm.logger.Warn("nfqws doesn't support the copy range option, whole packets are copied",
+		slog.Int("queue", cfg.QueueNum),
+		slog.String("option", copyRangeOption),
+	)
+	launch := *cfg
+	launch.CopyRange = 0
+	return &launch
+}
+
+// processArgs returns the nfqws arguments for cfg. nfqws stays in the
+// foreground so its exit can be supervised; collecting stats additionally
+// reads its debug output.
+func processArgs(cfg *ProcessConfig) []string {
+	var args []string
+	if cfg.Stats != nil {
+		args = append(args, "--debug=1")
+	}
+	args = append(args, fmt.Sprintf("--qnum=%d", cfg.QueueNum))
+	if cfg.CopyRange > 0 {
+		args = append(args, fmt.Sprintf("%s=%d", copyRangeOption, cfg.CopyRange))
+	}
+	if cfg.Dir != "" {
+		args = append(args, "--pidfile="+filepath.Join(cfg.Dir, queuePidfileName))
+	}
+	// The parser already drops these; never pass a second copy regardless
+	kept, _ := stripControlledOptions(cfg.Args)
+	if cfg.DesyncMark != 0 {
+		// The firewall rules exclude this mark, so the two must agree
+		kept, _ = stripOptions(kept, map[string]bool{desyncMarkOption: true})
+		args = append(args, fmt.Sprintf("%s=0x%x", desyncMarkOption, cfg.DesyncMark))
+	}
+	return append(args, kept...)
+}
+
+// CommandLine returns the command line Start would run for cfg.
+func (pm *ProcessManager) CommandLine(cfg *ProcessConfig) string {
+	command := append([]string{pm.binaryPath}, processArgs(pm.launchConfig(cfg))...)
+	if cfg.Namespace != "" {
+		command = append([]string{"ip", "netns", "exec", cfg.Namespace}, command...)
+	}
+	return strings.Join(command, " ")
+}
+
 // StopAll stops all tracked processes gracefully.
 func (pm *ProcessManager) StopAll() error {
+	// Don't hold the lock while waiting, so KillAll can cut a slow stop short
 	pm.mu.Lock()
-	defer pm.mu.Unlock()
+	stopping := pm.processes
+	for _, tracked := range stopping {
+		tracked.stopSupervision()
+	}
+	pm.mu.Unlock()
 
 	var errs []string
 
-	for _, proc := range pm.processes {
-		pm.logger.Info("stopping nfqws process", slog.Int("pid", proc.Pid))
+	for _, tracked := range stopping {
+		errs = append(errs, pm.stop(tracked)...)
+	}
 
-		// Send SIGTERM
-		if err := proc.Signal(syscall.SIGTERM); err != nil {
-			pm.logger.Warn("failed to signal process", slog.Int("pid", proc.Pid), slog.Any("error", err))
-			errs = append(errs, fmt.Sprintf("process %d signal failed: %v", proc.Pid, err))
-		}
+	for _, tracked := range stopping {
+		pm.removeCgroup(tracked)
+	}
 
-		// Wait with timeout
-		done := make(chan error, 1)
-		go func() {
-			_, err := proc.Wait()
-			done <- err
-		}()
-
-		// Wait up to 5 seconds for graceful shutdown
-		select {
-		case <-done:
-			pm.logger.Info("nfqws process stopped", slog.Int("pid", proc.Pid))
-		case <-time.After(5 * time.Second):
-			pm.logger.Warn("process did not stop, killing", slog.Int("pid", proc.Pid))
-			if err := proc.Kill(); err != nil {
-				pm.logger.Error("failed to kill process", slog.Int("pid", proc.Pid), slog.Any("error", err))
-				errs = append(errs, fmt.Sprintf("process %d kill failed: %v", proc.Pid, err))
-			}
-		}
+	// KillAll may have dropped the processes in the meantime
+	pm.mu.Lock()
+	if len(pm.processes) >= len(stopping) {
+		pm.processes = pm.processes[len(stopping):]
 	}
+	// Processes that failed to stop stay recorded for the next start to kill
+	if len(errs) == 0 && len(pm.processes) == 0 {
+		pm.removeState()
+	}
+	pm.mu.Unlock()
 
-	pm.processes = nil
+	if len(errs) > 0 {
+		return fmt.Errorf("process cleanup errors: %v", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
 
+// Stop stops the process serving queueNum gracefully while the others keep
+// running. A queue without a process is left alone.
+func (pm *ProcessManager) Stop(queueNum int) error {
+	pm.mu.Lock()
+	idx := slices.IndexFunc(pm.processes, func(t *trackedProcess) bool { return t.queueNum == queueNum })
+	if idx < 0 {
+		pm.mu.Unlock()
+		return nil
+	}
+	tracked := pm.processes[idx]
+	tracked.stopSupervision()
+	// A copy, as StopAll may be walking the old slice
+	pm.processes = slices.Delete(slices.Clone(pm.processes), idx, idx+1)
+	pm.mu.Unlock()
+
+	errs := pm.stop(tracked)
+	pm.removeCgroup(tracked)
 	if len(errs) > 0 {
 		return fmt.Errorf("process cleanup errors: %v", strings.Join(errs, "; "))
 	}
+	return nil
+}
+
+// removeCgroup removes the cgroup of tracked, whose supervision was stopped,
+// once its process exited.
+func (pm *ProcessManager) removeCgroup(tracked *trackedProcess) {
+	if tracked.cgroup == "" || tracked.running() {
+		return
+	}
+	if err := removeMemoryCgroup(tracked.cgroup); err != nil {
+		pm.logger.Debug("failed to remove nfqws cgroup", slog.String("cgroup", tracked.cgroup), slog.Any("error", err))
+	}
+}
+
+// stop terminates the process of tracked, whose supervision was stopped,
+// killing it if it doesn't exit in time. It returns the errors met.
+func (pm *ProcessManager) stop(tracked *trackedProcess) []string {
+	proc := tracked.proc
+	if !pm.verify(tracked) {
+		return nil
+	}
+	pm.logger.Info("stopping nfqws process", slog.Int("pid", proc.Pid))
+
+	var errs []string
+
+	// Send SIGTERM; a stopped process only acts on it once continued
+	if err := signalGroup(proc, syscall.SIGTERM); err != nil {
+		pm.logger.Warn("failed to signal process", slog.Int("pid", proc.Pid), slog.Any("error", err))
+		errs = append(errs, fmt.Sprintf("process %d signal failed: %v", proc.Pid, err))
+	}
+	if sigCont != nil && (tracked.suspended || processStopped(proc.Pid)) {
+		_ = proc.Signal(sigCont)
+	}
+
+	// Wait up to 5 seconds for graceful shutdown. A process found stopped at
+	// the deadline was suspended again, not hung: continue it and wait more
+	exited := waitExit(tracked.done, 5*time.Second)
+	if !exited && sigCont != nil && processStopped(proc.Pid) {
+		pm.logger.Info("nfqws process is stopped, continuing it to exit", slog.Int("pid", proc.Pid))
+		_ = proc.Signal(sigCont)
+		exited = waitExit(tracked.done, 5*time.Second)
+	}
+	if exited {
+		pm.logger.Info("nfqws process stopped", slog.Int("pid", proc.Pid))
+		// Children got the SIGTERM too; any ignoring it would outlive nfqws
+		if err := killGroup(proc.Pid); err != nil {
+			pm.logger.Warn("failed to kill the rest of the process group", slog.Int("pid", proc.Pid), slog.Any("error", err))
+			errs = append(errs, fmt.Sprintf("process %d group kill failed: %v", proc.Pid, err))
+		}
+	} else {
+		pm.logger.Warn("process did not stop, killing", slog.Int("pid", proc.Pid))
+		if err := signalGroup(proc, syscall.SIGKILL); err != nil {
+			pm.logger.Error("failed to kill process", slog.Int("pid", proc.Pid), slog.Any("error", err))
+			errs = append(errs, fmt.Sprintf("process %d kill failed: %v", proc.Pid, err))
+		} else if !waitExit(tracked.done, 2*time.Second) {
+			errs = append(errs, fmt.Sprintf("process %d did not exit after SIGKILL", proc.Pid))
+		}
+	}
+
+	return errs
+}
+
+// waitExit reports whether done is closed within timeout.
+func waitExit(done <-chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
 
+// verify reports whether tracked may be signalled: its PID must still belong
+// to the process started. A process that exited or whose PID was reused by an
+// unrelated process is skipped and logged.
+func (pm *ProcessManager) verify(tracked *trackedProcess) bool {
+	err := verifyProcessIdentity(tracked.proc.Pid, tracked.identity)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errProcessGone):
+		pm.logger.Info("nfqws process already exited", slog.Int("pid", tracked.proc.Pid), slog.Int("queue", tracked.queueNum))
+	default:
+		pm.logger.Warn("not signalling nfqws process, its pid now belongs to another process",
+			slog.Int("pid", tracked.proc.Pid),
+			slog.Int("queue", tracked.queueNum),
+			slog.Any("error", err),
+		)
+	}
+	return false
+}
+
+// KillAll sends SIGKILL to all processes without waiting for them to exit.
+// It is used when a graceful stop exceeds the shutdown budget.
+func (pm *ProcessManager) KillAll() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, tracked := range pm.processes {
+		tracked.stopSupervision()
+		if !tracked.running() || !pm.verify(tracked) {
+			continue
+		}
+		if err := signalGroup(tracked.proc, syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			pm.logger.Warn("failed to kill process", slog.Int("pid", tracked.proc.Pid), slog.Any("error", err))
+			continue
+		}
+		pm.logger.Warn("killed nfqws process", slog.Int("pid", tracked.proc.Pid))
+	}
+	pm.processes = nil
+}
+
+// Signal sends sig to all tracked processes.
+func (pm *ProcessManager) Signal(sig os.Signal) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	var errs []string
+	for _, tracked := range pm.processes {
+		if !tracked.running() || !pm.verify(tracked) {
+			continue
+		}
+		if err := tracked.proc.Signal(sig); err != nil {
+			pm.logger.Warn("failed to signal process", slog.Int("pid", tracked.proc.Pid), slog.Any("error", err))
+			errs = append(errs, fmt.Sprintf("process %d signal failed: %v", tracked.proc.Pid, err))
+			continue
+		}
+		pm.logger.Debug("signalled nfqws process", slog.Int("pid", tracked.proc.Pid), slog.String("signal", sig.String()))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("process signal errors: %v", strings.Join(errs, "; "))
+	}
 	return nil
 }
 
-// Count returns the number of running processes.
+// Suspend stops all tracked processes with SIGSTOP and returns how many were
+// stopped. Their queues fill up meanwhile and packets bypass them once full.
+func (pm *ProcessManager) Suspend() (int, error) {
+	return pm.setSuspended(true)
+}
+
+// Resume continues all tracked processes with SIGCONT, including those
+// stopped from elsewhere, and returns how many were signalled.
+func (pm *ProcessManager) Resume() (int, error) {
+	return pm.setSuspended(false)
+}
+
+// setSuspended sends SIGSTOP or SIGCONT to all tracked processes.
+func (pm *ProcessManager) setSuspended(suspend bool) (int, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	sig := sigCont
+	if suspend {
+		sig = sigStop
+	}
+	if sig == nil {
+		return 0, errors.New("suspending processes is not supported on this platform")
+	}
+
+	var errs []string
+	n := 0
+	for _, tracked := range pm.processes {
+		if !tracked.running() || !pm.verify(tracked) {
+			continue
+		}
+		if err := tracked.proc.Signal(sig); err != nil {
+			pm.logger.Warn("failed to signal process", slog.Int("pid", tracked.proc.Pid), slog.Any("error", err))
+			errs = append(errs, fmt.Sprintf("process %d signal failed: %v", tracked.proc.Pid, err))
+			continue
+		}
+		tracked.suspended = suspend
+		n++
+	}
+
+	if len(errs) > 0 {
+		return n, fmt.Errorf("process signal errors: %v", strings.Join(errs, "; "))
+	}
+	return n, nil
+}
+
+// Count returns the number of running processes; crashed processes waiting
+// for their restart or given up as degraded aren't counted.
 func (pm *ProcessManager) Count() int {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	return len(pm.processes)
+
+	n := 0
+	for _, tracked := range pm.processes {
+		if tracked.running() {
+			n++
+		}
+	}
+	return n
+}
+
+// DegradedQueues returns the queues whose process is no longer restarted
+// after exhausting its retries.
+func (pm *ProcessManager) DegradedQueues() []int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	var queues []int
+	for _, tracked := range pm.processes {
+		if tracked.degraded {
+			queues = append(queues, tracked.queueNum)
+		}
+	}
+	return queues
+}
+
+// Output returns the last output lines of the process serving queueNum,
+// oldest first, or nil if no process serves it.
+func (pm *ProcessManager) Output(queueNum int) []string {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, tracked := range pm.processes {
+		if tracked.queueNum == queueNum {
+			return tracked.output.snapshot()
+		}
+	}
+	return nil
+}
+
+// RestartsTotal returns how often crashed processes were restarted.
+func (pm *ProcessManager) RestartsTotal() uint64 {
+	return pm.restartsTotal.Load()
+}
+
+// Processes returns information about the tracked processes.
+func (pm *ProcessManager) Processes() []ProcessInfo {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	infos := make([]ProcessInfo, 0, len(pm.processes))
+	for _, tracked := range pm.processes {
+		info := ProcessInfo{
+			PID:       tracked.proc.Pid,
+			QueueNum:  tracked.queueNum,
+			StartedAt: tracked.startedAt,
+			Running:   tracked.running(),
+			Args:      slices.Clone(tracked.cfg.Args),
+			Suspended: tracked.running() && (tracked.suspended || processStopped(tracked.proc.Pid)),
+			Restarts:  tracked.restarts,
+			Degraded:  tracked.degraded,
+
+			LastExit:    tracked.lastExit,
+			OOMKills:    tracked.oomKills,
+			MemoryLimit: pm.memoryLimit(tracked),
+		}
+		if tracked.stats != nil {
+			stats := tracked.stats.snapshot()
+			info.Stats = &stats
+		}
+		infos = append(infos, info)
+	}
+	return infos
 }
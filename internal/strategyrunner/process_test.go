@@ -0,0 +1,117 @@
+package strategyrunner
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProcessArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProcessConfig
+		want []string
+	}{
+		{
+			name: "strategy mark",
+			cfg:  ProcessConfig{QueueNum: 200, Args: []string{"--dpi-desync=fake", "--dpi-desync-fwmark=0x2"}},
+			want: []string{"--qnum=200", "--dpi-desync=fake", "--dpi-desync-fwmark=0x2"},
+		},
+		{
+			name: "desync mark",
+			cfg:  ProcessConfig{QueueNum: 200, Args: []string{"--dpi-desync=fake"}, DesyncMark: 0x40000000},
+			want: []string{"--qnum=200", "--dpi-desync-fwmark=0x40000000", "--dpi-desync=fake"},
+		},
+		{
+			// The firewall excludes only the configured mark
+			name: "desync mark replaces the strategy's",
+			cfg:  ProcessConfig{QueueNum: 200, Args: []string{"--dpi-desync-fwmark=0x2", "--dpi-desync=fake", "--dpi-desync-fwmark", "0x4"}, DesyncMark: 0x40000000},
+			want: []string{"--qnum=200", "--dpi-desync-fwmark=0x40000000", "--dpi-desync=fake"},
+		},
+		{
+			name: "controlled options",
+			cfg:  ProcessConfig{QueueNum: 201, Args: []string{"--qnum=5", "--dpi-desync=fake", "--qnum", "6"}},
+			want: []string{"--qnum=201", "--dpi-desync=fake"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := processArgs(&tt.cfg); !slices.Equal(got, tt.want) {
+				t.Errorf("processArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// waitEvent returns the next process event sent to events.
+func waitEvent(t *testing.T, events <-chan string) string {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no process event")
+		return ""
+	}
+}
+
+func TestProcessCrashBackoff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub nfqws is a shell script")
+	}
+	binary := filepath.Join(t.TempDir(), "nfqws")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	clk := newFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	pm := NewProcessManager(binary, slog.New(slog.DiscardHandler))
+	pm.clock = clk
+	pm.SetRestartPolicy(4, 5*time.Second)
+	events := make(chan string, 1)
+	pm.onEvent = func(kind, message string) { events <- kind + ": " + message }
+	t.Cleanup(func() { pm.StopAll() })
+	if err := pm.Start(&ProcessConfig{QueueNum: 7}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The backoff doubles from a second up to the cap, then the queue is
+	// given up
+	for i, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		want := "process_exited: queue 7: exit status 3, restarting in " + delay.String()
+		if event := waitEvent(t, events); event != want {
+			t.Fatalf("event %d = %q, want %q", i, event, want)
+		}
+		clk.Advance(delay - time.Millisecond)
+		if info := pm.Processes()[0]; info.Restarts != i || info.Running {
+			t.Fatalf("before the backoff of retry %d elapsed: %+v, want no restart", i+1, info)
+		}
+		clk.Advance(time.Millisecond)
+		if info := pm.Processes()[0]; info.Restarts != i+1 {
+			t.Fatalf("after the backoff of retry %d: %d restarts, want %d", i+1, info.Restarts, i+1)
+		}
+	}
+
+	want := "process_degraded: queue 7: exit status 3, not restarted after 4 retries"
+	if event := waitEvent(t, events); event != want {
+		t.Fatalf("last event = %q, want %q", event, want)
+	}
+	info := pm.Processes()[0]
+	if !info.Degraded || info.Running || info.Restarts != 4 {
+		t.Errorf("degraded process = %+v, want it degraded and stopped after 4 restarts", info)
+	}
+	if got := pm.DegradedQueues(); !slices.Equal(got, []int{7}) {
+		t.Errorf("DegradedQueues() = %v, want [7]", got)
+	}
+	if got := clk.pendingTimers(); got != 0 {
+		t.Errorf("%d restarts pending, want none", got)
+	}
+	if got := pm.Count(); got != 0 {
+		t.Errorf("Count() = %d, want the degraded process not counted", got)
+	}
+}
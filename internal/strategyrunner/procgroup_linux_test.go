@@ -0,0 +1,86 @@
+//go:build linux
+
+package strategyrunner
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// forkingNFQWS is a stub nfqws forking a child, which forks a grandchild.
+// Both write their pid next to the script; %s runs first in each, e.g. to
+// make them ignore SIGTERM.
+const forkingNFQWS = `#!/bin/sh
+dir=$(dirname "$0")
+sh -c '%[1]s; sleep 300 & echo $! > "$1/grandchild"; wait' sh "$dir" &
+echo $! > "$dir/child"
+while :; do sleep 1; done
+`
+
+func TestStopAllKillsProcessGroup(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc to check processes in")
+	}
+	tests := []struct {
+		name     string
+		children string
+	}{
+		{name: "children exit on SIGTERM", children: ":"},
+		{name: "children ignore SIGTERM", children: `trap "" TERM`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			binary := filepath.Join(dir, "nfqws")
+			if err := os.WriteFile(binary, fmt.Appendf(nil, forkingNFQWS, tt.children), 0755); err != nil {
+				t.Fatal(err)
+			}
+			pm := NewProcessManager(binary, slog.New(slog.DiscardHandler))
+			if err := pm.Start(&ProcessConfig{QueueNum: 7}); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			pids := map[string]int{"nfqws": pm.Processes()[0].PID}
+			for _, name := range []string{"child", "grandchild"} {
+				pids[name] = readPidFile(t, filepath.Join(dir, name))
+			}
+			t.Cleanup(func() {
+				for _, pid := range pids {
+					syscall.Kill(pid, syscall.SIGKILL)
+				}
+			})
+
+			if err := pm.StopAll(); err != nil {
+				t.Fatalf("StopAll() error = %v", err)
+			}
+			for name, pid := range pids {
+				if !waitGone(pid, time.Second) {
+					t.Errorf("%s (pid %d) survived StopAll", name, pid)
+				}
+			}
+		})
+	}
+}
+
+// readPidFile waits for a process to write its pid to path and returns it.
+func readPidFile(t *testing.T, path string) int {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(path)
+		if pid, convErr := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && convErr == nil {
+			return pid
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no pid written to %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package strategyrunner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd start in a process group of its own, led by the
+// started process.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalGroup sends sig to the process group led by proc, reaching anything
+// it forked as well. It falls back to proc alone if the group is gone.
+func signalGroup(proc *os.Process, sig syscall.Signal) error {
+	err := syscall.Kill(-proc.Pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return proc.Signal(sig)
+	}
+	return err
+}
+
+// killGroup sends SIGKILL to what is left of the process group led by the
+// exited process pid. The group ID stays reserved while the group has
+// members; an empty group is no error.
+func killGroup(pid int) error {
+	err := syscall.Kill(-pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package strategyrunner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup does nothing; Windows has no process groups to signal.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalGroup sends sig to proc.
+func signalGroup(proc *os.Process, sig syscall.Signal) error {
+	return proc.Signal(sig)
+}
+
+// killGroup does nothing; Windows has no process groups to kill.
+func killGroup(pid int) error { return nil }
@@ -0,0 +1,107 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// procRoot is where process information is read from.
+const procRoot = "/proc"
+
+// errProcessGone is returned when no process has the PID anymore.
+var errProcessGone = errors.New("process has exited")
+
+// processIdentity tells a process apart from a later one reusing its PID.
+type processIdentity struct {
+	// StartTime is the start time in clock ticks after boot (field 22 of
+	// /proc/<pid>/stat)
+	StartTime uint64
+
+	// Exe is the executable path ("" when unreadable, e.g. for zombies)
+	Exe string
+}
+
+// known reports whether the identity was captured. It isn't where /proc is
+// unavailable, and such processes are signalled unverified.
+func (id processIdentity) known() bool {
+	return id.StartTime != 0
+}
+
+// readProcessIdentity reads the identity of the process with pid.
+func readProcessIdentity(pid int) (processIdentity, error) {
+	dir := fmt.Sprintf("%s/%d", procRoot, pid)
+	data, err := os.ReadFile(dir + "/stat")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return processIdentity{}, errProcessGone
+		}
+		return processIdentity{}, err
+	}
+
+	startTime, err := parseStatStartTime(string(data))
+	if err != nil {
+		return processIdentity{}, fmt.Errorf("%s/stat: %w", dir, err)
+	}
+
+	// Zombies and processes of other users have no readable exe link
+	exe, _ := os.Readlink(dir + "/exe")
+	return processIdentity{StartTime: startTime, Exe: exe}, nil
+}
+
+// parseStatStartTime returns the start time field of /proc/<pid>/stat. The
+// command name may contain spaces and parentheses, so fields are counted
+// from the last ')'.
+func parseStatStartTime(stat string) (uint64, error) {
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, errors.New("malformed stat")
+	}
+	// Fields after the command name start at field 3 (state)
+	fields := strings.Fields(stat[end+1:])
+	const startTimeIndex = 22 - 3
+	if len(fields) <= startTimeIndex {
+		return 0, errors.New("stat has too few fields")
+	}
+	return strconv.ParseUint(fields[startTimeIndex], 10, 64)
+}
+
+// verifyProcessIdentity checks that pid still belongs to the process with
+// identity want. It returns errProcessGone if the process exited, and an
+// error describing the mismatch if the PID was reused. An unknown identity
+// can't be verified and always passes.
+func verifyProcessIdentity(pid int, want processIdentity) error {
+	if !want.known() {
+		return nil
+	}
+	got, err := readProcessIdentity(pid)
+	if err != nil {
+		return err
+	}
+	if got.StartTime != want.StartTime {
+		return fmt.Errorf("pid %d was reused: started at tick %d, expected %d", pid, got.StartTime, want.StartTime)
+	}
+	// An unreadable link is a zombie still waiting to be reaped, not another process
+	if got.Exe != "" && want.Exe != "" && got.Exe != want.Exe {
+		return fmt.Errorf("pid %d was reused: runs %s, expected %s", pid, got.Exe, want.Exe)
+	}
+	return nil
+}
+
+// processStopped reports whether the process with pid is stopped by a signal
+// (state T in /proc/<pid>/stat). Unreadable state counts as not stopped.
+func processStopped(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%d/stat", procRoot, pid))
+	if err != nil {
+		return false
+	}
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return false
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	return len(fields) > 0 && fields[0] == "T"
+}
@@ -0,0 +1,140 @@
+//go:build linux
+
+package strategyrunner
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseStatStartTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		stat    string
+		want    uint64
+		wantErr bool
+	}{
+		{
+			name: "plain",
+			stat: "4242 (nfqws) S 1 4242 4242 0 -1 4194560 120 0 0 0 1 2 0 0 20 0 1 0 987654 2293760 250 18446744073709551615",
+			want: 987654,
+		},
+		{
+			name: "command with spaces and parentheses",
+			stat: "4242 (nf qws) (x) S 1 4242 4242 0 -1 4194560 120 0 0 0 1 2 0 0 20 0 1 0 123 2293760 250",
+			want: 123,
+		},
+		{name: "too few fields", stat: "4242 (nfqws) S 1 4242", wantErr: true},
+		{name: "no command", stat: "4242 nfqws S 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStatStartTime(tt.stat)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseStatStartTime() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: parseStatStartTime() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// startIdentityProcess starts the stub nfqws under a process manager logging
+// to log and returns its tracked process.
+func startIdentityProcess(t *testing.T, log *bytes.Buffer) (*ProcessManager, *trackedProcess) {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "nfqws")
+	if err := os.WriteFile(binary, []byte(testNFQWS), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewProcessManager(binary, slog.New(slog.NewTextHandler(log, nil)))
+	if err := pm.Start(&ProcessConfig{QueueNum: 7, Args: []string{"--dpi-desync=fake"}}); err != nil {
+		t.Skipf("starting the stub nfqws: %v", err)
+	}
+	tracked := pm.processes[0]
+	if !tracked.identity.known() {
+		t.Skip("process identity unavailable")
+	}
+	return pm, tracked
+}
+
+func TestVerifyProcessIdentity(t *testing.T) {
+	var log bytes.Buffer
+	pm, tracked := startIdentityProcess(t, &log)
+	pid, identity := tracked.proc.Pid, tracked.identity
+	defer pm.StopAll()
+
+	if err := verifyProcessIdentity(pid, identity); err != nil {
+		t.Errorf("verifyProcessIdentity() of the running process = %v", err)
+	}
+	// A zombie has no exe link and is still the process
+	zombie := identity
+	zombie.Exe = ""
+	if err := verifyProcessIdentity(pid, zombie); err != nil {
+		t.Errorf("verifyProcessIdentity() without the exe = %v", err)
+	}
+	// Without an identity there is nothing to verify
+	if err := verifyProcessIdentity(pid, processIdentity{}); err != nil {
+		t.Errorf("verifyProcessIdentity() of an unknown identity = %v", err)
+	}
+	if err := verifyProcessIdentity(1<<22+1, identity); !errors.Is(err, errProcessGone) {
+		t.Errorf("verifyProcessIdentity() of a free pid = %v, want %v", err, errProcessGone)
+	}
+}
+
+func TestReusedPIDNeverSignalled(t *testing.T) {
+	tests := []struct {
+		name  string
+		alter func(id *processIdentity)
+	}{
+		{name: "start time", alter: func(id *processIdentity) { id.StartTime++ }},
+		{name: "exe", alter: func(id *processIdentity) { id.Exe = "/usr/sbin/nfqws-other" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			pm, tracked := startIdentityProcess(t, &log)
+			pid := tracked.proc.Pid
+			t.Cleanup(func() {
+				syscall.Kill(-pid, syscall.SIGKILL)
+				<-tracked.done
+			})
+
+			// The PID now looks like another process's
+			pm.mu.Lock()
+			tt.alter(&tracked.identity)
+			pm.mu.Unlock()
+			err := verifyProcessIdentity(pid, tracked.identity)
+			if err == nil || errors.Is(err, errProcessGone) {
+				t.Fatalf("verifyProcessIdentity() = %v, want the pid reported reused", err)
+			}
+
+			if n, _ := pm.Suspend(); n != 0 {
+				t.Errorf("Suspend() stopped %d processes, want none", n)
+			}
+			time.Sleep(50 * time.Millisecond)
+			if processStopped(pid) {
+				t.Error("process stopped by Suspend")
+			}
+			if err := pm.StopAll(); err != nil {
+				t.Errorf("StopAll() error = %v", err)
+			}
+			pm.KillAll()
+			if waitGone(pid, 200*time.Millisecond) {
+				t.Fatal("process signalled by StopAll or KillAll")
+			}
+			if !strings.Contains(log.String(), "not signalling nfqws process") {
+				t.Errorf("log doesn't report the skipped signal:\n%s", log.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package strategyrunner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// Process state file formats.
+const (
+	// ProcessStateJSON writes the process state as a JSON document
+	ProcessStateJSON = "json"
+
+	// ProcessStateText writes one "queue pid start_time" line per process,
+	// for shell scripts
+	ProcessStateText = "text"
+)
+
+// orphanStopTimeout is how long an orphaned nfqws gets to exit on SIGTERM
+// before it is killed.
+const orphanStopTimeout = 3 * time.Second
+
+// processState records the started nfqws processes, so processes left by a
+// daemon that was killed before stopping them are killed on the next start.
+type processState struct {
+	Binary    string              `json:"binary"`
+	Processes []processStateEntry `json:"processes"`
+	WrittenAt time.Time           `json:"written_at"`
+}
+
+// processStateEntry is a recorded nfqws process.
+type processStateEntry struct {
+	Queue int `json:"queue"`
+	PID   int `json:"pid"`
+
+	// StartTime and Exe identify the process, so a reused PID isn't killed;
+	// StartTime is 0 where /proc is unavailable
+	StartTime uint64 `json:"start_time,omitempty"`
+	Exe       string `json:"exe,omitempty"`
+}
+
+// identity returns the recorded identity of the process.
+func (e processStateEntry) identity() processIdentity {
+	return processIdentity{StartTime: e.StartTime, Exe: e.Exe}
+}
+
+// encodeProcessState encodes state in format.
+func encodeProcessState(state processState, format string) ([]byte, error) {
+	if format == ProcessStateText {
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "# nfqws processes started by zapret-daemon (%s)\n", state.Binary)
+		fmt.Fprintf(&b, "# queue pid start_time\n")
+		for _, p := range state.Processes {
+			fmt.Fprintf(&b, "%d %d %d\n", p.Queue, p.PID, p.StartTime)
+		}
+		return b.Bytes(), nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// decodeProcessState decodes a process state file of either format, so
+// changing state_format doesn't lose the processes of the previous run.
+func decodeProcessState(data []byte) (processState, error) {
+	var state processState
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err := json.Unmarshal(data, &state)
+		return state, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return state, fmt.Errorf("line %d: expected \"queue pid [start_time]\"", n)
+		}
+		var entry processStateEntry
+		var err error
+		if entry.Queue, err = strconv.Atoi(fields[0]); err != nil {
+			return state, fmt.Errorf("line %d: invalid queue %q", n, fields[0])
+		}
+		if entry.PID, err = strconv.Atoi(fields[1]); err != nil || entry.PID <= 0 {
+			return state, fmt.Errorf("line %d: invalid pid %q", n, fields[1])
+		}
+		if len(fields) > 2 {
+			if entry.StartTime, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+				return state, fmt.Errorf("line %d: invalid start time %q", n, fields[2])
+			}
+		}
+		state.Processes = append(state.Processes, entry)
+	}
+	return state, scanner.Err()
+}
+
+// SetStateFile sets where the started processes are recorded and in which
+// format ("" disables the state file).
+func (pm *ProcessManager) SetStateFile(path, format string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.stateFile = path
+	pm.stateFormat = format
+}
+
+// WriteState records the running processes in the state file.
+func (pm *ProcessManager) WriteState() error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.writeState()
+}
+
+// writeState records the running processes in the state file. Caller must
+// hold pm.mu.
+func (pm *ProcessManager) writeState() error {
+	if pm.stateFile == "" {
+		return nil
+	}
+
+	state := processState{Binary: pm.binaryPath, WrittenAt: time.Now()}
+	for _, tracked := range pm.processes {
+		if tracked.proc == nil || !tracked.running() {
+			continue
+		}
+		state.Processes = append(state.Processes, processStateEntry{
+			Queue:     tracked.queueNum,
+			PID:       tracked.proc.Pid,
+			StartTime: tracked.identity.StartTime,
+			Exe:       tracked.identity.Exe,
+		})
+	}
+
+	data, err := encodeProcessState(state, pm.stateFormat)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", pm.stateFile, err)
+	}
+	return statepaths.WriteAtomic(pm.stateFile, data, 0644)
+}
+
+// removeState deletes the state file once all processes stopped. Caller
+// must hold pm.mu.
+func (pm *ProcessManager) removeState() {
+	if pm.stateFile == "" {
+		return
+	}
+	if err := os.Remove(pm.stateFile); err != nil && !os.IsNotExist(err) {
+		pm.logger.Warn("failed to remove process state file", slog.String("path", pm.stateFile), slog.Any("error", err))
+	}
+}
+
+// OrphanProcess is an nfqws process recorded by a previous run.
+type OrphanProcess struct {
+	PID      int
+	QueueNum int
+
+	// Killed reports that the process was still running and was killed
+	Killed bool
+
+	// Skipped explains why a process wasn't signalled (exited, PID reused)
+	Skipped string
+}
+
+// killStaleProcesses kills the nfqws processes recorded in the process state
+// file by a run that didn't stop them, and removes the file. Processes that
+// exited or whose PID now belongs to another process are left alone. Caller
+// must hold r.mu.
+func (r *Runner) killStaleProcesses() ([]OrphanProcess, error) {
+	path := r.config.Process.StateFile
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process state file: %w", err)
+	}
+
+	state, err := decodeProcessState(data)
+	if err != nil {
+		r.logger.Warn("ignoring invalid process state file", slog.String("path", path), slog.Any("error", err))
+		r.removeProcessState()
+		return nil, nil
+	}
+
+	// Processes of this run are never orphans
+	own := make(map[int]bool)
+	for _, info := range r.procManager.Processes() {
+		own[info.PID] = true
+	}
+
+	var orphans []OrphanProcess
+	var errs []error
+	for _, entry := range state.Processes {
+		if own[entry.PID] {
+			continue
+		}
+		orphan := OrphanProcess{PID: entry.PID, QueueNum: entry.Queue}
+
+		err := verifyProcessIdentity(entry.PID, entry.identity())
+		switch {
+		case errors.Is(err, errProcessGone):
+			orphan.Skipped = "already exited"
+		case err != nil:
+			orphan.Skipped = "pid reused by another process"
+			r.logger.Debug("not killing recorded nfqws process", slog.Int("pid", entry.PID), slog.Any("error", err))
+		default:
+			r.logger.Warn("killing nfqws process left by a previous run",
+				slog.Int("pid", entry.PID),
+				slog.Int("queue", entry.Queue),
+			)
+			if err := killOrphan(entry); err != nil {
+				errs = append(errs, fmt.Errorf("pid %d: %w", entry.PID, err))
+				orphans = append(orphans, orphan)
+				continue
+			}
+			orphan.Killed = true
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	if len(errs) > 0 {
+		// Keep the file for the next attempt
+		return orphans, fmt.Errorf("failed to kill orphaned nfqws processes: %w", errors.Join(errs...))
+	}
+	r.removeProcessState()
+	return orphans, nil
+}
+
+// killedOrphans returns how many of orphans were killed.
+func killedOrphans(orphans []OrphanProcess) int {
+	n := 0
+	for _, o := range orphans {
+		if o.Killed {
+			n++
+		}
+	}
+	return n
+}
+
+// killOrphan stops the process group of an orphaned nfqws with SIGTERM, and
+// with SIGKILL if it doesn't exit within orphanStopTimeout. The process is
+// not a child of this daemon, so its exit is polled through /proc.
+func killOrphan(entry processStateEntry) error {
+	proc, err := os.FindProcess(entry.PID)
+	if err != nil {
+		return err
+	}
+	if err := signalGroup(proc, syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to signal: %w", err)
+	}
+
+	deadline := time.Now().Add(orphanStopTimeout)
+	for time.Now().Before(deadline) {
+		if errors.Is(verifyProcessIdentity(entry.PID, entry.identity()), errProcessGone) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := verifyProcessIdentity(entry.PID, entry.identity()); err != nil {
+		// Exited meanwhile; a reused PID is left alone too
+		return nil
+	}
+	if err := signalGroup(proc, syscall.SIGKILL); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill: %w", err)
+	}
+	return nil
+}
+
+// removeProcessState deletes the process state file. Caller must hold r.mu.
+func (r *Runner) removeProcessState() {
+	path := r.config.Process.StateFile
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		r.logger.Warn("failed to remove process state file", slog.String("path", path), slog.Any("error", err))
+	}
+}
+
+// CleanupResult reports what Cleanup removed.
+type CleanupResult struct {
+	// Processes are the nfqws processes recorded by a previous run
+	Processes []OrphanProcess
+
+	// Firewall describes the firewall objects removed, empty if firewall
+	// management is external
+	Firewall string
+}
+
+// Cleanup removes what a daemon that didn't stop cleanly left behind: the
+// nfqws processes recorded in the process state file, the firewall objects
+// recorded in the firewall state file and the table and chain with the
+// configured names. It refuses to run while the runner is started; the
+// caller must make sure no other daemon uses the same state.
+func (r *Runner) Cleanup(ctx context.Context) (*CleanupResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return nil, errors.New("strategy runner is running, stop it first")
+	}
+
+	result := &CleanupResult{}
+	var errs []error
+
+	orphans, err := r.killStaleProcesses()
+	result.Processes = orphans
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if !r.externalFirewall() {
+		r.cleanupStaleFirewall(ctx)
+		if err := r.fw.RemoveAll(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove firewall rules: %w", err))
+		} else {
+			r.removeFirewallState()
+			result.Firewall = fmt.Sprintf("%s table %s, chain %s",
+				r.config.Firewall.Backend, r.config.Firewall.TableName, r.config.Firewall.ChainName)
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,363 @@
+package strategyrunner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// Per-queue runtime layout under the volatile state directory:
+//
+//	queues/queues.json           recovery file listing the directories below
+//	queues/<n>/nfqws.pid         pidfile of the nfqws process of queue n
+//	queues/<n>/hostlist-auto.txt auto hostlist nfqws of queue n writes, seeded
+//	                             from the rule's --hostlist-auto list and
+//	                             merged back into it on cleanup
+//
+// The runner owns these directories: they are created per rule at start and
+// removed on a clean stop. Directories left by a run that didn't stop cleanly
+// are cleaned up on the next start unless they still belong to the same rule.
+const (
+	queueDirsName         = "queues"
+	queueDirsStateName    = "queues.json"
+	queueArchiveName      = "queues-archive"
+	queuePidfileName      = "nfqws.pid"
+	queueAutoHostlistName = "hostlist-auto.txt"
+)
+
+// queueDirEntry records the rule a queue directory was created for.
+type queueDirEntry struct {
+	Queue        int       `json:"queue"`
+	Rule         string    `json:"rule"`
+	Label        string    `json:"label,omitempty"`
+	SourceLine   int       `json:"source_line"`
+	HostlistAuto string    `json:"hostlist_auto,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// queueDirsState is the recovery file of the queue directories.
+type queueDirsState struct {
+	Queues []queueDirEntry `json:"queues"`
+}
+
+// queueDirsRoot returns the directory holding the queue directories, or ""
+// without a volatile state directory.
+func queueDirsRoot(cfg *Config) string {
+	if cfg.State.VolatileDir == "" {
+		return ""
+	}
+	return filepath.Join(cfg.State.VolatileDir, queueDirsName)
+}
+
+// queueDirRule returns the identity a queue directory is recorded under, so a
+// queue number reused by a different rule doesn't inherit its files.
+func queueDirRule(rule ParsedRule) string {
+	sum := sha256.Sum256([]byte(ruleKey(rule)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// prepareQueueDirs cleans up queue directories left by a previous run that
+// don't belong to a rule of the current strategy, and drops stale pidfiles
+// from those that do. Caller must hold r.mu.
+func (r *Runner) prepareQueueDirs(rules []ParsedRule) {
+	r.queueDirs = make(map[int]queueDirEntry)
+	root := queueDirsRoot(r.config)
+	if root == "" {
+		return
+	}
+
+	recorded := r.loadQueueDirsState(root)
+	current := make(map[int]string, len(rules))
+	for _, rule := range rules {
+		if rule.active() {
+			current[rule.QueueNum] = queueDirRule(rule)
+		}
+	}
+
+	dirs, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		r.logger.Warn("failed to read queue directories", slog.String("dir", root), slog.Any("error", err))
+		return
+	}
+
+	for _, d := range dirs {
+		queue, err := strconv.Atoi(d.Name())
+		if err != nil || !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, d.Name())
+		entry, known := recorded[queue]
+		if known && current[queue] == entry.Rule {
+			if err := os.Remove(filepath.Join(dir, queuePidfileName)); err != nil && !os.IsNotExist(err) {
+				r.logger.Warn("failed to remove stale pidfile", slog.Int("queue", queue), slog.Any("error", err))
+			}
+			r.queueDirs[queue] = entry
+			continue
+		}
+
+		r.logger.Warn("cleaning up queue directory left by a previous run",
+			slog.Int("queue", queue),
+			slog.String("dir", dir),
+		)
+		if !known {
+			entry = queueDirEntry{Queue: queue}
+		}
+		r.retireQueueDir(dir, entry)
+	}
+
+	r.writeQueueDirsState(root)
+}
+
+// ensureQueueDir creates and registers the runtime directory of rule. It
+// returns "" if the directory can't be created, in which case the process
+// runs without one. Caller must hold r.mu.
+func (r *Runner) ensureQueueDir(rule ParsedRule) string {
+	root := queueDirsRoot(r.config)
+	if root == "" {
+		return ""
+	}
+
+	dir := filepath.Join(root, strconv.Itoa(rule.QueueNum))
+	if old, ok := r.queueDirs[rule.QueueNum]; ok && old.Rule != queueDirRule(rule) {
+		// The queue changed rules; the domains learned belong to the old one
+		r.retireQueueDir(dir, old)
+		delete(r.queueDirs, rule.QueueNum)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		r.logger.Warn("failed to create queue directory", slog.Int("queue", rule.QueueNum), slog.Any("error", err))
+		return ""
+	}
+
+	entry := queueDirEntry{
+		Queue:      rule.QueueNum,
+		Rule:       queueDirRule(rule),
+		Label:      rule.Label,
+		SourceLine: rule.SourceLine,
+		CreatedAt:  time.Now(),
+	}
+	if lists := optionValues(parseNFQWSArgs(rule.NFQWSArgs), "--hostlist-auto"); len(lists) > 0 {
+		entry.HostlistAuto = lists[0]
+	}
+	if old, ok := r.queueDirs[rule.QueueNum]; ok && old.Rule == entry.Rule {
+		entry.CreatedAt = old.CreatedAt
+	}
+	if r.queueDirs == nil {
+		r.queueDirs = make(map[int]queueDirEntry)
+	}
+	r.queueDirs[rule.QueueNum] = entry
+	r.writeQueueDirsState(root)
+	return dir
+}
+
+// queueAutoHostlist points the --hostlist-auto option in args at the auto
+// hostlist of the queue directory dir, so processes sharing a list never
+// write it concurrently. A new queue list starts as a copy of the rule's
+// list, whose domains nfqws keeps matching; retireQueueDir merges the
+// domains learned since back. Without a directory args are returned as is.
+func (r *Runner) queueAutoHostlist(args []string, dir string) []string {
+	lists := optionValues(args, "--hostlist-auto")
+	if dir == "" || len(lists) == 0 {
+		return args
+	}
+
+	learned := filepath.Join(dir, queueAutoHostlistName)
+	if _, err := os.Stat(learned); os.IsNotExist(err) {
+		data, err := os.ReadFile(lists[0])
+		if err != nil && !os.IsNotExist(err) {
+			r.logger.Warn("failed to read auto hostlist, nfqws writes it directly",
+				slog.String("hostlist", lists[0]),
+				slog.Any("error", err),
+			)
+			return args
+		}
+		if err := statepaths.WriteAtomic(learned, data, 0644); err != nil {
+			r.logger.Warn("failed to seed queue auto hostlist, nfqws writes the list directly",
+				slog.String("hostlist", lists[0]),
+				slog.Any("error", err),
+			)
+			return args
+		}
+	}
+	return replaceOptionValues(args, "--hostlist-auto", func(string) string { return learned })
+}
+
+// removeQueueDirs removes the queue directories after a clean stop. Caller
+// must hold r.mu.
+func (r *Runner) removeQueueDirs() {
+	root := queueDirsRoot(r.config)
+	if root == "" {
+		return
+	}
+
+	for queue, entry := range r.queueDirs {
+		r.retireQueueDir(filepath.Join(root, strconv.Itoa(queue)), entry)
+	}
+	r.queueDirs = nil
+
+	if err := os.Remove(filepath.Join(root, queueDirsStateName)); err != nil && !os.IsNotExist(err) {
+		r.logger.Warn("failed to remove queue directory state", slog.Any("error", err))
+	}
+	// Only succeeds once nothing is left behind
+	os.Remove(root)
+}
+
+// removeQueueDir removes the directory of a queue dropped by a reload in
+// place. Caller must hold r.mu.
+func (r *Runner) removeQueueDir(queue int) {
+	root := queueDirsRoot(r.config)
+	entry, ok := r.queueDirs[queue]
+	if root == "" || !ok {
+		return
+	}
+
+	r.retireQueueDir(filepath.Join(root, strconv.Itoa(queue)), entry)
+	delete(r.queueDirs, queue)
+	r.writeQueueDirsState(root)
+}
+
+// retireQueueDir removes a queue directory. Learned domains are merged into
+// the auto hostlist of the rule first; a directory holding data that can't be
+// merged is moved to the archive instead of being deleted.
+func (r *Runner) retireQueueDir(dir string, entry queueDirEntry) {
+	learned := filepath.Join(dir, queueAutoHostlistName)
+	if entry.HostlistAuto != "" {
+		merged, err := mergeHostlist(learned, entry.HostlistAuto)
+		if err != nil {
+			r.logger.Warn("failed to merge learned domains",
+				slog.Int("queue", entry.Queue),
+				slog.String("hostlist", entry.HostlistAuto),
+				slog.Any("error", err),
+			)
+		} else {
+			if merged > 0 {
+				r.logger.Info("merged learned domains into auto hostlist",
+					slog.Int("queue", entry.Queue),
+					slog.String("hostlist", entry.HostlistAuto),
+					slog.Int("domains", merged),
+				)
+			}
+			os.Remove(learned)
+		}
+	}
+	os.Remove(filepath.Join(dir, queuePidfileName))
+
+	if leftover, _ := os.ReadDir(dir); len(leftover) > 0 {
+		archive := filepath.Join(filepath.Dir(filepath.Dir(dir)), queueArchiveName,
+			fmt.Sprintf("%d-%s", entry.Queue, time.Now().Format("20060102-150405")))
+		if err := os.MkdirAll(filepath.Dir(archive), 0755); err == nil {
+			if err := os.Rename(dir, archive); err == nil {
+				r.logger.Warn("archived queue directory", slog.Int("queue", entry.Queue), slog.String("archive", archive))
+				r.events.Add("queue_dir_archived", fmt.Sprintf("queue %d archived to %s", entry.Queue, archive))
+				return
+			}
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		r.logger.Warn("failed to remove queue directory", slog.String("dir", dir), slog.Any("error", err))
+	}
+}
+
+// loadQueueDirsState reads the recovery file, keyed by queue number.
+func (r *Runner) loadQueueDirsState(root string) map[int]queueDirEntry {
+	entries := make(map[int]queueDirEntry)
+	path := filepath.Join(root, queueDirsStateName)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries
+	}
+	if err != nil {
+		r.logger.Warn("failed to read queue directory state", slog.String("path", path), slog.Any("error", err))
+		return entries
+	}
+
+	var state queueDirsState
+	if err := json.Unmarshal(data, &state); err != nil {
+		r.logger.Warn("ignoring invalid queue directory state", slog.String("path", path), slog.Any("error", err))
+		return entries
+	}
+	for _, entry := range state.Queues {
+		entries[entry.Queue] = entry
+	}
+	return entries
+}
+
+// writeQueueDirsState records the registered queue directories.
+func (r *Runner) writeQueueDirsState(root string) {
+	state := queueDirsState{Queues: make([]queueDirEntry, 0, len(r.queueDirs))}
+	for _, entry := range r.queueDirs {
+		state.Queues = append(state.Queues, entry)
+	}
+	sort.Slice(state.Queues, func(i, j int) bool { return state.Queues[i].Queue < state.Queues[j].Queue })
+
+	if err := statepaths.WriteJSON(filepath.Join(root, queueDirsStateName), state); err != nil {
+		r.logger.Warn("failed to write queue directory state", slog.Any("error", err))
+	}
+}
+
+// mergeHostlist appends the domains of src missing from dst to dst and
+// returns how many were added. A missing src merges nothing.
+func mergeHostlist(src, dst string) (int, error) {
+	learned, err := os.ReadFile(src)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := os.ReadFile(dst)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	known := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		known[strings.TrimSpace(line)] = true
+	}
+
+	var out bytes.Buffer
+	out.Write(existing)
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	added := 0
+	scanner := bufio.NewScanner(bytes.NewReader(learned))
+	for scanner.Scan() {
+		domain := strings.TrimSpace(scanner.Text())
+		if domain == "" || strings.HasPrefix(domain, "#") || known[domain] {
+			continue
+		}
+		known[domain] = true
+		out.WriteString(domain + "\n")
+		added++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	if err := statepaths.WriteAtomic(dst, out.Bytes(), 0644); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
@@ -0,0 +1,238 @@
+package strategyrunner
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newQueueDirsRunner returns a runner keeping its queue directories under a
+// temporary volatile state directory, and that directory.
+func newQueueDirsRunner(t *testing.T) (*Runner, string) {
+	t.Helper()
+	volatile := t.TempDir()
+	r := &Runner{
+		config: &Config{State: StateConfig{VolatileDir: volatile}},
+		logger: slog.New(slog.DiscardHandler),
+		events: NewEventLog(),
+	}
+	return r, filepath.Join(volatile, queueDirsName)
+}
+
+// writeFiles creates files with their contents, making their directories.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// recordedQueues returns the queues listed in the recovery file under root.
+func recordedQueues(t *testing.T, root string) []int {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, queueDirsStateName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state queueDirsState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	var queues []int
+	for _, entry := range state.Queues {
+		queues = append(queues, entry.Queue)
+	}
+	return queues
+}
+
+func TestMergeHostlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		learned string // "" for a missing file
+		list    string // "" for a missing file
+		want    string
+		added   int
+	}{
+		{
+			name:  "no learned list",
+			list:  "a.example\n",
+			want:  "a.example\n",
+			added: 0,
+		},
+		{
+			name:    "new and known domains",
+			learned: "a.example\nb.example\n\n# comment\nb.example\n",
+			list:    "a.example\n",
+			want:    "a.example\nb.example\n",
+			added:   1,
+		},
+		{
+			name:    "list without a final newline",
+			learned: "b.example\n",
+			list:    "a.example",
+			want:    "a.example\nb.example\n",
+			added:   1,
+		},
+		{
+			name:    "missing list",
+			learned: "b.example\n",
+			want:    "b.example\n",
+			added:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src, dst := filepath.Join(dir, "learned.txt"), filepath.Join(dir, "auto.txt")
+			if tt.learned != "" {
+				writeFiles(t, map[string]string{src: tt.learned})
+			}
+			if tt.list != "" {
+				writeFiles(t, map[string]string{dst: tt.list})
+			}
+
+			added, err := mergeHostlist(src, dst)
+			if err != nil {
+				t.Fatalf("mergeHostlist() error = %v", err)
+			}
+			if added != tt.added {
+				t.Errorf("mergeHostlist() = %d, want %d", added, tt.added)
+			}
+			data, _ := os.ReadFile(dst)
+			if string(data) != tt.want {
+				t.Errorf("list = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueDirLifecycle(t *testing.T) {
+	r, root := newQueueDirsRunner(t)
+	list := filepath.Join(t.TempDir(), "auto.txt")
+	writeFiles(t, map[string]string{list: "known.example\n"})
+	rule := ParsedRule{Protocol: "tcp", Ports: "443", NFQWSArgs: "--hostlist-auto=" + list + " --dpi-desync=fake", QueueNum: 3}
+
+	r.prepareQueueDirs([]ParsedRule{rule})
+	dir := r.ensureQueueDir(rule)
+	if dir != filepath.Join(root, "3") {
+		t.Fatalf("ensureQueueDir() = %q, want the directory of queue 3", dir)
+	}
+	args := r.queueAutoHostlist(parseNFQWSArgs(rule.NFQWSArgs), dir)
+	learned := filepath.Join(dir, queueAutoHostlistName)
+	if args[0] != "--hostlist-auto="+learned {
+		t.Errorf("args = %q, want the auto hostlist in the queue directory", args)
+	}
+	if got := recordedQueues(t, root); len(got) != 1 || got[0] != 3 {
+		t.Errorf("recorded queues = %v, want [3]", got)
+	}
+
+	// nfqws learns a domain, which a clean stop merges back
+	writeFiles(t, map[string]string{learned: "known.example\nlearned.example\n"})
+	r.removeQueueDirs()
+	if data, _ := os.ReadFile(list); string(data) != "known.example\nlearned.example\n" {
+		t.Errorf("auto hostlist = %q, want the learned domain merged", data)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("queue directories left after the stop: %v", err)
+	}
+}
+
+func TestQueueDirReusedByOtherRule(t *testing.T) {
+	r, root := newQueueDirsRunner(t)
+	list := filepath.Join(t.TempDir(), "auto.txt")
+	writeFiles(t, map[string]string{list: ""})
+	old := ParsedRule{Protocol: "tcp", Ports: "443", NFQWSArgs: "--hostlist-auto=" + list, QueueNum: 0}
+	dir := r.ensureQueueDir(old)
+	writeFiles(t, map[string]string{filepath.Join(dir, queueAutoHostlistName): "learned.example\n"})
+
+	// The new rule of the queue starts empty; the old one keeps its domains
+	r.ensureQueueDir(ParsedRule{Protocol: "udp", Ports: "443", NFQWSArgs: "--dpi-desync=fake", QueueNum: 0})
+	if _, err := os.Stat(filepath.Join(dir, queueAutoHostlistName)); !os.IsNotExist(err) {
+		t.Errorf("learned list of the old rule left for the new one: %v", err)
+	}
+	if data, _ := os.ReadFile(list); string(data) != "learned.example\n" {
+		t.Errorf("auto hostlist = %q, want the old rule's domains merged", data)
+	}
+	if entry := r.queueDirs[0]; entry.HostlistAuto != "" {
+		t.Errorf("queue 0 recorded with auto hostlist %q, want none", entry.HostlistAuto)
+	}
+	if got := recordedQueues(t, root); len(got) != 1 {
+		t.Errorf("recorded queues = %v, want [0]", got)
+	}
+}
+
+func TestPrepareQueueDirsRecovery(t *testing.T) {
+	r, root := newQueueDirsRunner(t)
+	lists := t.TempDir()
+	kept, dropped := filepath.Join(lists, "kept.txt"), filepath.Join(lists, "dropped.txt")
+	writeFiles(t, map[string]string{kept: "", dropped: "old.example\n"})
+	current := ParsedRule{Protocol: "tcp", Ports: "443", NFQWSArgs: "--hostlist-auto=" + kept, QueueNum: 0}
+	removed := ParsedRule{Protocol: "tcp", Ports: "80", NFQWSArgs: "--hostlist-auto=" + dropped, QueueNum: 1}
+
+	// A run that crashed left the directories of both rules, with pidfiles,
+	// and one of an unrecorded queue holding data nobody knows about
+	state := queueDirsState{Queues: []queueDirEntry{
+		{Queue: 0, Rule: queueDirRule(current), HostlistAuto: kept},
+		{Queue: 1, Rule: queueDirRule(removed), HostlistAuto: dropped},
+	}}
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, map[string]string{
+		filepath.Join(root, queueDirsStateName):           string(data),
+		filepath.Join(root, "0", queuePidfileName):        "1234\n",
+		filepath.Join(root, "0", queueAutoHostlistName):   "kept.example\n",
+		filepath.Join(root, "1", queuePidfileName):        "1235\n",
+		filepath.Join(root, "1", queueAutoHostlistName):   "learned.example\n",
+		filepath.Join(root, "9", "core"):                  "unknown\n",
+		filepath.Join(root, "not-a-queue", "ignored.txt"): "",
+	})
+
+	r.prepareQueueDirs([]ParsedRule{current})
+
+	// The directory of the current rule is kept without its stale pidfile
+	if _, err := os.Stat(filepath.Join(root, "0", queuePidfileName)); !os.IsNotExist(err) {
+		t.Errorf("stale pidfile of queue 0 left: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "0", queueAutoHostlistName)); string(data) != "kept.example\n" {
+		t.Errorf("learned list of queue 0 = %q, want it kept", data)
+	}
+
+	// The removed rule's domains are merged and its directory removed
+	if data, _ := os.ReadFile(dropped); string(data) != "old.example\nlearned.example\n" {
+		t.Errorf("auto hostlist of the removed rule = %q, want the learned domain merged", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "1")); !os.IsNotExist(err) {
+		t.Errorf("directory of the removed rule left: %v", err)
+	}
+
+	// Unknown data is archived rather than deleted
+	if _, err := os.Stat(filepath.Join(root, "9")); !os.IsNotExist(err) {
+		t.Errorf("directory of the unrecorded queue left: %v", err)
+	}
+	archived, _ := filepath.Glob(filepath.Join(filepath.Dir(root), queueArchiveName, "9-*", "core"))
+	if len(archived) != 1 {
+		t.Errorf("archived files = %v, want the unrecorded queue archived", archived)
+	}
+	events, _ := r.events.Since(0)
+	if len(events) != 1 || events[0].Kind != "queue_dir_archived" || !strings.Contains(events[0].Message, "queue 9") {
+		t.Errorf("events = %+v, want queue 9 archived", events)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "not-a-queue")); err != nil {
+		t.Errorf("directory that isn't a queue touched: %v", err)
+	}
+	if got := recordedQueues(t, root); len(got) != 1 || got[0] != 0 {
+		t.Errorf("recorded queues = %v, want [0]", got)
+	}
+}
@@ -0,0 +1,96 @@
+package strategyrunner
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// queueMapEntry is the metadata of a queue published in the queue map file.
+type queueMapEntry struct {
+	Queue      int      `json:"queue"`
+	Label      string   `json:"label"`
+	Protocol   string   `json:"protocol"`
+	Ports      string   `json:"ports"`
+	Args       string   `json:"args"`
+	SourceLine int      `json:"source_line"`
+	RateLimit  int      `json:"rate_limit,omitempty"`
+	Pending    bool     `json:"pending"`
+	Missing    []string `json:"missing_files,omitempty"`
+	Stripped   []string `json:"stripped_args,omitempty"`
+	Failed     string   `json:"compat_error,omitempty"`
+	Filtered   bool     `json:"filtered_out,omitempty"`
+	Notrack    bool     `json:"notrack,omitempty"`
+	Schedule   []string `json:"active_hours,omitempty"`
+	Off        bool     `json:"scheduled_off,omitempty"`
+}
+
+// queueMap is the content of the queue map file.
+type queueMap struct {
+	StrategyFile string          `json:"strategy_file"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Queues       []queueMapEntry `json:"queues"`
+}
+
+// ruleEnv returns the environment describing rule to its nfqws process, so
+// nfqws-side logs and wrappers can tell which rule a queue belongs to. nfqws
+// has no option naming an instance, so this is the only way to label it.
+func ruleEnv(rule ParsedRule) []string {
+	return []string{
+		"ZAPRET_RULE_LABEL=" + rule.Label,
+		"ZAPRET_RULE_PORTS=" + rule.Ports,
+		"ZAPRET_SOURCE_LINE=" + strconv.Itoa(rule.SourceLine),
+	}
+}
+
+// writeQueueMap publishes the queue to rule mapping for external log
+// pipelines and scripts. Caller must hold r.mu.
+func (r *Runner) writeQueueMap() {
+	path := r.config.QueueMapFile
+	if path == "" {
+		return
+	}
+
+	m := queueMap{
+		StrategyFile: r.config.StrategyFile,
+		UpdatedAt:    time.Now(),
+		Queues:       make([]queueMapEntry, 0, len(r.rules)),
+	}
+	for _, rule := range r.rules {
+		m.Queues = append(m.Queues, queueMapEntry{
+			Queue:      rule.QueueNum,
+			Label:      rule.Label,
+			Protocol:   rule.Protocol,
+			Ports:      rule.Ports,
+			Args:       rule.NFQWSArgs,
+			SourceLine: rule.SourceLine,
+			RateLimit:  rule.RateLimit,
+			Pending:    len(rule.MissingFiles) > 0,
+			Missing:    rule.MissingFiles,
+			Stripped:   rule.StrippedArgs,
+			Failed:     rule.CompatError,
+			Filtered:   rule.FilteredOut,
+			Notrack:    rule.Notrack,
+			Schedule:   rule.Schedule.Strings(),
+			Off:        rule.ScheduledOff,
+		})
+	}
+
+	if err := statepaths.WriteJSON(path, m); err != nil {
+		r.logger.Warn("failed to write queue map file", slog.String("path", path), slog.Any("error", err))
+	}
+}
+
+// removeQueueMap deletes the queue map file once the queues are gone.
+func (r *Runner) removeQueueMap() {
+	path := r.config.QueueMapFile
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		r.logger.Warn("failed to remove queue map file", slog.String("path", path), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,130 @@
+package strategyrunner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readQueueMap reads the queue map file of tr named in its status.
+func (tr *testRunner) readQueueMap(t *testing.T) queueMap {
+	t.Helper()
+	path := tr.GetStatus().QueueMapFile
+	if path == "" {
+		t.Fatal("status names no queue map file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m queueMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return m
+}
+
+// checkQueueMap fails the test unless the queue map file of tr lists the
+// rules of tr, and the queues with firewall rules are the ones installed.
+func (tr *testRunner) checkQueueMap(t *testing.T) {
+	t.Helper()
+	m := tr.readQueueMap(t)
+	if m.StrategyFile != tr.strategy {
+		t.Errorf("queue map strategy file = %q, want %q", m.StrategyFile, tr.strategy)
+	}
+
+	rules := tr.Rules()
+	if len(m.Queues) != len(rules) {
+		t.Fatalf("queue map has %d queues, want the %d rules", len(m.Queues), len(rules))
+	}
+	installed := tr.fw.queues()
+	var applied []int
+	for i, entry := range m.Queues {
+		rule := rules[i]
+		want := queueMapEntry{
+			Queue:      rule.QueueNum,
+			Label:      rule.Label,
+			Protocol:   rule.Protocol,
+			Ports:      rule.Ports,
+			Args:       rule.NFQWSArgs,
+			SourceLine: rule.SourceLine,
+			RateLimit:  rule.RateLimit,
+			Pending:    len(rule.MissingFiles) > 0,
+			Missing:    rule.MissingFiles,
+			Stripped:   rule.StrippedArgs,
+			Failed:     rule.CompatError,
+			Filtered:   rule.FilteredOut,
+			Notrack:    rule.Notrack,
+			Schedule:   rule.Schedule.Strings(),
+			Off:        rule.ScheduledOff,
+		}
+		// Compare as written, where empty lists are left out
+		got, _ := json.Marshal(entry)
+		wantJSON, _ := json.Marshal(want)
+		if string(got) != string(wantJSON) {
+			t.Errorf("queue map entry %d = %s, want %s", i, got, wantJSON)
+		}
+		if !entry.Pending && !entry.Filtered && entry.Failed == "" && !entry.Off {
+			applied = append(applied, entry.Queue)
+		}
+
+		fwRule := tr.fw.rule(entry.Queue)
+		if fwRule == nil {
+			continue
+		}
+		if fwRule.Protocol != entry.Protocol || !slices.Equal(fwRule.Ports, splitPorts(entry.Ports)) {
+			t.Errorf("queue %d: firewall rule %s %v, queue map %s %s", entry.Queue, fwRule.Protocol, fwRule.Ports, entry.Protocol, entry.Ports)
+		}
+	}
+	slices.Sort(applied)
+	if !slices.Equal(installed, applied) {
+		t.Errorf("firewall rules on queues %v, queue map applies %v", installed, applied)
+	}
+}
+
+func TestQueueMapMatchesRules(t *testing.T) {
+	list := "--hostlist=" + filepath.Join(t.TempDir(), "missing.txt")
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if got := tr.GetStatus().QueueMapFile; got != filepath.Join(tr.dir, "run", "queues.json") {
+		t.Errorf("status QueueMapFile = %q, want it in the volatile state dir", got)
+	}
+	tr.checkQueueMap(t)
+
+	// A reload rewrites the map, here with a third rule and one waiting for its hostlist
+	tr.writeStrategy(t, stressStrategies[2]+"--new --filter-tcp=8443 --dpi-desync=fake "+list+"\n")
+	for _, full := range []bool{false, true} {
+		if err := tr.RestartFiltered(t.Context(), nil, full); err != nil {
+			t.Fatalf("Restart() error = %v", err)
+		}
+		m := tr.readQueueMap(t)
+		if len(m.Queues) != 4 || !m.Queues[3].Pending {
+			t.Fatalf("queue map after the reload = %+v, want four queues, the last pending", m.Queues)
+		}
+		tr.checkQueueMap(t)
+	}
+
+	// The atomic writes leave no temporary files behind
+	path := tr.GetStatus().QueueMapFile
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "."+filepath.Base(path)) {
+			t.Errorf("%s left next to the queue map", e.Name())
+		}
+	}
+
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("queue map after Stop: %v, want it removed", err)
+	}
+}
@@ -0,0 +1,322 @@
+package strategyrunner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// QueueConfig contains queue number assignment settings.
+type QueueConfig struct {
+	// First is the lowest queue number assigned to rules
+	First int `yaml:"first" env:"ZAPRET_QUEUE_FIRST" env-default:"0"`
+
+	// Count is the size of the queue number range
+	Count int `yaml:"count" env:"ZAPRET_QUEUE_COUNT" env-default:"256"`
+
+	// StateFile persists queue numbers across reloads and restarts. Relative to
+	// the state directory ("" keeps them in memory).
+	StateFile string `yaml:"state_file" env:"ZAPRET_QUEUE_STATE_FILE" env-default:"queue-numbers.json"`
+
+	// Grace is how long the number of a removed rule stays reserved for it
+	Grace time.Duration `yaml:"grace" env:"ZAPRET_QUEUE_GRACE" env-default:"168h"`
+}
+
+// Validate validates the queue configuration.
+func (c *QueueConfig) Validate() error {
+	if c.First < 0 || c.Count <= 0 || c.First+c.Count-1 > 65535 {
+		return fmt.Errorf("range of %d queues starting at %d must lie within 0-65535", c.Count, c.First)
+	}
+	if c.Grace < 0 {
+		return fmt.Errorf("grace must not be negative")
+	}
+	return nil
+}
+
+// queueEntry is a persisted queue number assignment.
+type queueEntry struct {
+	Queue    int       `json:"queue"`
+	Rule     string    `json:"rule"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// QueueAllocator assigns queue numbers that stay stable across reloads.
+// A rule is identified by a hash of its protocol, ports and normalized args,
+// so inserting a rule doesn't shift the numbers of the rules after it.
+type QueueAllocator struct {
+	statePath string
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*queueEntry // keyed by rule identity hash
+}
+
+// NewQueueAllocator creates an allocator and loads persisted assignments.
+func NewQueueAllocator(statePath string, logger *slog.Logger) *QueueAllocator {
+	a := &QueueAllocator{
+		statePath: statePath,
+		logger:    logger,
+		entries:   make(map[string]*queueEntry),
+	}
+	if err := a.load(); err != nil {
+		logger.Warn("failed to load queue state, numbers will be reassigned", slog.Any("error", err))
+	}
+	return a
+}
+
+// queueIdentity returns the identity hashes of rules. Identical rules (kept
+// when dedupe is off) are told apart by their occurrence.
+func queueIdentity(rules []ParsedRule) ([]string, error) {
+	ids := make([]string, len(rules))
+	occurrences := make(map[string]int, len(rules))
+	owners := make(map[string]string, len(rules))
+
+	for i, rule := range rules {
+		key := ruleKey(rule)
+		occurrences[key]++
+		if n := occurrences[key]; n > 1 {
+			key += "|#" + strconv.Itoa(n)
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		id := hex.EncodeToString(sum[:8])
+		if owner, ok := owners[id]; ok && owner != key {
+			return nil, fmt.Errorf("queue identity collision between line %d and another rule; change either rule slightly", rule.SourceLine)
+		}
+		owners[id] = key
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// Assign sets the queue number of each rule. Known rules keep their numbers,
+// new rules get the lowest free number in the range, and numbers of removed
+// rules are freed once cfg.Grace has passed since they were last seen.
+// Numbers in foreign, bound by another program (mapped to its pid), are
+// skipped; a known rule whose number another program took moves to a free one.
+// Rules pinned with --qnum keep their number, which must lie in the range and
+// be free of other pins and programs; a rule holding it moves to a free one.
+func (a *QueueAllocator) Assign(rules []ParsedRule, cfg QueueConfig, foreign map[int]int) error {
+	ids, err := queueIdentity(rules)
+	if err != nil {
+		return err
+	}
+	if len(rules) > cfg.Count {
+		return fmt.Errorf("queue range %d-%d exhausted: %d rules need queue numbers", cfg.First, cfg.First+cfg.Count-1, len(rules))
+	}
+
+	inRange := func(q int) bool { return q >= cfg.First && q < cfg.First+cfg.Count }
+	pins := make(map[int]*ParsedRule)
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.QueuePinned {
+			continue
+		}
+		q := rule.QueueNum
+		if !inRange(q) {
+			return fmt.Errorf("line %d: --qnum=%d is outside the queue range %d-%d", rule.SourceLine, q, cfg.First, cfg.First+cfg.Count-1)
+		}
+		if other, ok := pins[q]; ok {
+			return fmt.Errorf("lines %d and %d both pin queue %d with --qnum", other.SourceLine, rule.SourceLine, q)
+		}
+		if pid, taken := foreign[q]; taken {
+			return fmt.Errorf("line %d: queue %d of --qnum is bound by another program (pid %d)", rule.SourceLine, q, pid)
+		}
+		pins[q] = rule
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+
+	current := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		current[id] = true
+	}
+
+	// Free numbers outside the range and of removed rules past their grace period
+	for id, e := range a.entries {
+		if !inRange(e.Queue) || (!current[id] && now.Sub(e.LastSeen) > cfg.Grace) {
+			delete(a.entries, id)
+		}
+	}
+
+	used := make(map[int]string, len(a.entries))
+	for id, e := range a.entries {
+		if other, ok := used[e.Queue]; ok {
+			// Corrupt state: keep one owner, preferring a current rule
+			if current[other] || !current[id] {
+				delete(a.entries, id)
+				continue
+			}
+			delete(a.entries, other)
+		}
+		used[e.Queue] = id
+	}
+
+	// Pinned numbers are taken from the rules holding them, which move
+	for i := range rules {
+		if !rules[i].QueuePinned {
+			continue
+		}
+		q, id := rules[i].QueueNum, ids[i]
+		if owner, ok := used[q]; ok && owner != id {
+			delete(a.entries, owner)
+		}
+		if e, ok := a.entries[id]; ok && e.Queue != q {
+			delete(used, e.Queue)
+		}
+		used[q] = id
+	}
+
+	next := cfg.First
+	for i := range rules {
+		rule := &rules[i]
+		id := ids[i]
+
+		if rule.QueuePinned {
+			e, known := a.entries[id]
+			rule.QueuePreserved = known && e.Queue == rule.QueueNum
+			a.entries[id] = &queueEntry{Queue: rule.QueueNum, Rule: describeRule(*rule), LastSeen: now}
+			continue
+		}
+		if e, ok := a.entries[id]; ok {
+			if pid, taken := foreign[e.Queue]; taken {
+				a.logger.Warn("queue number is bound by another program, moving the rule to a free number",
+					slog.Int("queue", e.Queue),
+					slog.Int("pid", pid),
+					slog.Int("line", rule.SourceLine),
+				)
+				delete(a.entries, id)
+			}
+		}
+		if e, ok := a.entries[id]; ok {
+			rule.QueueNum = e.Queue
+			rule.QueuePreserved = true
+			e.Rule = describeRule(*rule)
+			e.LastSeen = now
+			continue
+		}
+
+		q, ok := a.lowestFree(used, &next, cfg, current, foreign)
+		if !ok {
+			return fmt.Errorf("queue range %d-%d exhausted", cfg.First, cfg.First+cfg.Count-1)
+		}
+		used[q] = id
+		a.entries[id] = &queueEntry{Queue: q, Rule: describeRule(*rule), LastSeen: now}
+		rule.QueueNum = q
+		rule.QueuePreserved = false
+
+		a.logger.Debug("assigned queue number",
+			slog.Int("queue", q),
+			slog.Int("line", rule.SourceLine),
+		)
+	}
+
+	if err := a.save(); err != nil {
+		a.logger.Warn("failed to save queue state", slog.Any("error", err))
+	}
+	return nil
+}
+
+// Preview sets the queue numbers Assign would set, without changing or
+// persisting the allocator state.
+func (a *QueueAllocator) Preview(rules []ParsedRule, cfg QueueConfig, foreign map[int]int) error {
+	a.mu.Lock()
+	clone := &QueueAllocator{
+		logger:  slog.New(slog.DiscardHandler),
+		entries: make(map[string]*queueEntry, len(a.entries)),
+	}
+	for id, e := range a.entries {
+		entry := *e
+		clone.entries[id] = &entry
+	}
+	a.mu.Unlock()
+
+	return clone.Assign(rules, cfg, foreign)
+}
+
+// lowestFree returns the lowest number at or after *next that is neither used
+// nor foreign. When the range is full it reclaims the number of the least
+// recently seen removed rule.
+func (a *QueueAllocator) lowestFree(used map[int]string, next *int, cfg QueueConfig, current map[string]bool, foreign map[int]int) (int, bool) {
+	for ; *next < cfg.First+cfg.Count; *next++ {
+		if pid, taken := foreign[*next]; taken {
+			a.logger.Info("skipping queue number bound by another program", slog.Int("queue", *next), slog.Int("pid", pid))
+			continue
+		}
+		if _, ok := used[*next]; !ok {
+			return *next, true
+		}
+	}
+
+	var oldest string
+	for id, e := range a.entries {
+		if _, taken := foreign[e.Queue]; current[id] || taken {
+			continue
+		}
+		if oldest == "" || e.LastSeen.Before(a.entries[oldest].LastSeen) {
+			oldest = id
+		}
+	}
+	if oldest == "" {
+		return 0, false
+	}
+
+	q := a.entries[oldest].Queue
+	a.logger.Warn("queue range full, reclaiming number of removed rule before its grace period ended",
+		slog.Int("queue", q),
+		slog.String("rule", a.entries[oldest].Rule),
+	)
+	delete(a.entries, oldest)
+	delete(used, q)
+	return q, true
+}
+
+// describeRule returns a short human-readable rule description for the state file.
+func describeRule(rule ParsedRule) string {
+	return fmt.Sprintf("%s %s (line %d)", rule.Protocol, rule.Ports, rule.SourceLine)
+}
+
+// load reads persisted assignments.
+func (a *QueueAllocator) load() error {
+	if a.statePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(a.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries map[string]*queueEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", a.statePath, err)
+	}
+	for id, e := range entries {
+		if e != nil {
+			a.entries[id] = e
+		}
+	}
+	return nil
+}
+
+// save persists assignments atomically. Caller must hold a.mu.
+func (a *QueueAllocator) save() error {
+	if a.statePath == "" {
+		return nil
+	}
+	return statepaths.WriteJSON(a.statePath, a.entries)
+}
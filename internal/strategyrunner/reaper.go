@@ -0,0 +1,107 @@
+package strategyrunner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zombieCheckInterval is how often /proc is scanned for children left
+// unreaped.
+const zombieCheckInterval = 30 * time.Second
+
+// zombieChildren returns the PIDs of the zombie processes whose parent is ppid.
+func zombieChildren(ppid int) ([]int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var zombies []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(fmt.Sprintf("%s/%d/stat", procRoot, pid))
+		if err != nil {
+			// Exited meanwhile
+			continue
+		}
+		// Fields after the command name: state, ppid, ...
+		end := strings.LastIndexByte(string(data), ')')
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(string(data[end+1:]))
+		if len(fields) < 2 || fields[0] != "Z" {
+			continue
+		}
+		if parent, err := strconv.Atoi(fields[1]); err == nil && parent == ppid {
+			zombies = append(zombies, pid)
+		}
+	}
+	return zombies, nil
+}
+
+// reapZombies reaps the zombie children of the daemon that were already
+// zombies at the previous check. Every child is waited for by the code that
+// started it, so a zombie lasting a whole check interval means a missing
+// Wait: it is reaped so the PID table doesn't fill up, and counted and
+// logged as an error so the bug gets fixed.
+func (pm *ProcessManager) reapZombies() {
+	pm.reapMu.Lock()
+	defer pm.reapMu.Unlock()
+
+	zombies, err := zombieChildren(os.Getpid())
+	if err != nil {
+		pm.logger.Debug("cannot scan for zombie children", slog.Any("error", err))
+		return
+	}
+
+	seen := make(map[int]bool, len(zombies))
+	for _, pid := range zombies {
+		if !pm.zombies[pid] {
+			// Its waiter may not have run yet
+			seen[pid] = true
+			continue
+		}
+		reaped, err := reapZombie(pid)
+		if err != nil {
+			pm.logger.Warn("failed to reap zombie child", slog.Int("pid", pid), slog.Any("error", err))
+			continue
+		}
+		if reaped {
+			pm.zombiesReaped.Add(1)
+			pm.logger.Error("reaped a zombie child nobody waited for, this is a bug",
+				slog.Int("pid", pid),
+				slog.Duration("check_interval", zombieCheckInterval),
+			)
+		}
+	}
+	pm.zombies = seen
+}
+
+// ZombiesReaped returns how many zombie children the reaper had to reap.
+func (pm *ProcessManager) ZombiesReaped() uint64 {
+	return pm.zombiesReaped.Load()
+}
+
+// runReaper reaps zombie children every interval until ctx is done.
+func (pm *ProcessManager) runReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pm.reapZombies()
+		}
+	}
+}
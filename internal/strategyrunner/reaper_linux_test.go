@@ -0,0 +1,85 @@
+//go:build linux
+
+package strategyrunner
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startZombie starts a child that exits at once and is never waited for,
+// and waits until it is a zombie.
+func startZombie(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("starting a child: %v", err)
+	}
+	pid := cmd.Process.Pid
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		zombies, err := zombieChildren(os.Getpid())
+		if err != nil {
+			t.Skipf("scanning for zombies: %v", err)
+		}
+		if slices.Contains(zombies, pid) {
+			return pid
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child %d never became a zombie", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReapZombies(t *testing.T) {
+	running := exec.Command("sleep", "30")
+	if err := running.Start(); err != nil {
+		t.Skipf("starting a child: %v", err)
+	}
+	t.Cleanup(func() {
+		running.Process.Kill()
+		running.Wait()
+	})
+	pid := startZombie(t)
+
+	var log bytes.Buffer
+	pm := NewProcessManager("nfqws", slog.New(slog.NewTextHandler(&log, nil)))
+
+	// A zombie is left to its waiter for one check
+	pm.reapZombies()
+	if zombies, _ := zombieChildren(os.Getpid()); !slices.Contains(zombies, pid) {
+		t.Fatal("zombie reaped at the first check")
+	}
+	if got := pm.ZombiesReaped(); got != 0 {
+		t.Errorf("ZombiesReaped() = %d after the first check, want 0", got)
+	}
+
+	// and reaped at the next one
+	pm.reapZombies()
+	if zombies, _ := zombieChildren(os.Getpid()); slices.Contains(zombies, pid) {
+		t.Error("zombie left after the second check")
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s/%d", procRoot, pid)); !os.IsNotExist(err) {
+		t.Errorf("reaped child still in %s: %v", procRoot, err)
+	}
+	if got := pm.ZombiesReaped(); got != 1 {
+		t.Errorf("ZombiesReaped() = %d, want 1", got)
+	}
+	if got := strings.Count(log.String(), "reaped a zombie child"); got != 1 {
+		t.Errorf("logged %d reaped zombies, want 1:\n%s", got, log.String())
+	}
+
+	// A running child is never touched
+	if processGone(running.Process.Pid) {
+		t.Error("running child reaped")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package strategyrunner
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reapZombie collects the exit status of the exited child pid without
+// blocking. It reports false if pid had no status to collect.
+func reapZombie(pid int) (bool, error) {
+	var status syscall.WaitStatus
+	got, err := syscall.Wait4(pid, &status, syscall.WNOHANG, nil)
+	if errors.Is(err, syscall.ECHILD) {
+		// Reaped by its waiter meanwhile
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return got == pid, nil
+}
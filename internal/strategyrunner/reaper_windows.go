@@ -0,0 +1,9 @@
+//go:build windows
+
+package strategyrunner
+
+// reapZombie does nothing: Windows has no zombie processes, and no /proc to
+// find them in.
+func reapZombie(pid int) (bool, error) {
+	return false, nil
+}
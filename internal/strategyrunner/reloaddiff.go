@@ -0,0 +1,346 @@
+package strategyrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+// reloadRuleFields are the config fields that only change the rules. A
+// reload changing nothing else is applied in place by diffing the rules.
+var reloadRuleFields = map[string]bool{
+	"StrategyFile":    true,
+	"GameFilter":      true,
+	"GameFilterPorts": true,
+	"BinPath":         true,
+	"ListsPath":       true,
+	"Dedupe":          true,
+	"PayloadsDir":     true,
+	"PortAliases":     true,
+	"Overrides":       true,
+	"NeverQueue":      true,
+	"MaxLineLength":   true,
+	"Lenient":         true,
+	"Deprecations":    true,
+	"Normalizations":  true,
+}
+
+// changedSetting returns the YAML key of the first setting of cfg outside
+// reloadRuleFields that differs from old, "" if there is none. A firewall
+// backend of "auto" matches the backend it selected before.
+func changedSetting(old, cfg *Config) string {
+	next := *cfg
+	if next.Firewall.Backend == firewall.BackendAuto {
+		next.Firewall.Backend = old.Firewall.Backend
+	}
+
+	ov, nv := reflect.ValueOf(*old), reflect.ValueOf(next)
+	t := ov.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || reloadRuleFields[field.Name] {
+			continue
+		}
+		if reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		if key, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); key != "" && key != "-" {
+			return key
+		}
+		return field.Name
+	}
+	return ""
+}
+
+// ruleDiff is what a reload in place changes, by queue number. Queue
+// numbers follow the rule identity (protocol, ports and args), so a rule
+// whose args changed is removed from one queue and added on another.
+type ruleDiff struct {
+	// added are the rules of queues the running strategy doesn't serve
+	added []ParsedRule
+
+	// removed are the running rules of queues the new strategy doesn't serve
+	removed []ParsedRule
+
+	// changed are the rules of queues both serve with a different firewall
+	// rule or process
+	changed []ruleChange
+
+	// kept counts the queues left alone
+	kept int
+}
+
+// ruleChange is a queue served by the running and the new strategy.
+type ruleChange struct {
+	old, new ParsedRule
+
+	// firewall and process report what differs
+	firewall, process bool
+}
+
+// empty reports whether the diff changes nothing.
+func (d *ruleDiff) empty() bool {
+	return len(d.added) == 0 && len(d.removed) == 0 && len(d.changed) == 0
+}
+
+// diffRules compares the active rules of old and rules by queue number.
+// Caller must hold r.mu.
+func (r *Runner) diffRules(old, rules []ParsedRule) *ruleDiff {
+	running := make(map[int]ParsedRule, len(old))
+	for _, rule := range old {
+		if rule.active() {
+			running[rule.QueueNum] = rule
+		}
+	}
+
+	diff := &ruleDiff{}
+	for _, rule := range rules {
+		if !rule.active() {
+			continue
+		}
+		prev, ok := running[rule.QueueNum]
+		if !ok {
+			diff.added = append(diff.added, rule)
+			continue
+		}
+		delete(running, rule.QueueNum)
+
+		change := ruleChange{
+			old:      prev,
+			new:      rule,
+			firewall: prev.ScheduledOff != rule.ScheduledOff || r.firewallKey(prev) != r.firewallKey(rule),
+			process:  prev.MemoryLimit != rule.MemoryLimit || !slices.Equal(parseNFQWSArgs(prev.NFQWSArgs), parseNFQWSArgs(rule.NFQWSArgs)),
+		}
+		if change.firewall || change.process {
+			diff.changed = append(diff.changed, change)
+		} else {
+			diff.kept++
+		}
+	}
+
+	for _, rule := range old {
+		if _, ok := running[rule.QueueNum]; ok {
+			diff.removed = append(diff.removed, rule)
+		}
+	}
+	return diff
+}
+
+// firewallKey identifies the firewall rule installed for rule. Caller must
+// hold r.mu.
+func (r *Runner) firewallKey(rule ParsedRule) string {
+	return fmt.Sprintf("%+v", *r.convertToFirewallRule(rule))
+}
+
+// errFullRestart is returned by applyInPlace when the reload needs a full
+// restart; the wrapped error is the reason.
+var errFullRestart = errors.New("full restart needed")
+
+// applyInPlace applies cfg and filter to the running runner changing only
+// the rules that differ: new queues get their nfqws process and firewall
+// rule before the rules of dropped queues are removed, and only processes
+// whose args changed are restarted. Settings other than the rules can't
+// change in place; errFullRestart is returned for them and before anything
+// was changed. It is also returned when a change fails midway, so the caller
+// restarts from a known state. A new strategy that fails to parse leaves the running rules
+// in place.
+func (r *Runner) applyInPlace(ctx context.Context, cfg *Config, filter *RuleFilter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case !r.running:
+		return fmt.Errorf("%w: runner is not running", errFullRestart)
+	case r.suspension != nil:
+		return fmt.Errorf("%w: processes are suspended", errFullRestart)
+	case !cfg.Hooks.empty():
+		// Hooks expect the lifecycle of a start and stop around the change
+		return fmt.Errorf("%w: hooks are configured", errFullRestart)
+	}
+	if key := changedSetting(r.config, cfg); key != "" {
+		return fmt.Errorf("%w: %s changed", errFullRestart, key)
+	}
+
+	strategyPath, source := r.rollbackPath, StrategySourceRollback
+	if strategyPath == "" {
+		var err error
+		if strategyPath, source, err = resolveStrategy(cfg); err != nil {
+			return err
+		}
+	}
+
+	// Parse with the new settings without touching the running rules
+	cfg.Firewall.Backend = r.config.Firewall.Backend
+	parser := newParser(cfg, r.logger, r.parseCache)
+	rules, pending, err := r.prepareRules(cfg, parser, filter, strategyPath, source)
+	if err != nil {
+		return err
+	}
+
+	now := r.clock.Now()
+	for i := range rules {
+		r.updateSchedule(&rules[i], now)
+	}
+	diff := r.diffRules(r.rules, rules)
+
+	r.logger.Info("reloading strategy in place",
+		slog.String("strategy_file", strategyPath),
+		slog.Int("added", len(diff.added)),
+		slog.Int("removed", len(diff.removed)),
+		slog.Int("changed", len(diff.changed)),
+		slog.Int("kept", diff.kept),
+	)
+
+	old := r.rules
+	r.config = cfg
+	r.parser = parser
+	r.filter = filter
+	r.rules = rules
+	r.strategySource = source
+	r.configVersion = applySetDigest(cfg.ConfigPath, cfg.StrategyFile)
+	r.applySet.configure(cfg.ConfigPath, cfg.StrategyFile, cfg.WatchTargets.QuietPeriod)
+	r.setEmptyRuleset(rules)
+	r.hostlists.Reset()
+
+	if !diff.empty() {
+		r.setPhase(PhaseApplyingFirewall)
+		if errs := r.applyDiff(ctx, diff); len(errs) > 0 {
+			// The running rules no longer match r.rules, the full restart
+			// that follows stops everything and applies cfg anew
+			return fmt.Errorf("%w: %d changes failed in place: %w", errFullRestart, len(errs), errors.Join(errs...))
+		}
+	}
+
+	if !r.externalProcesses() {
+		if err := r.procManager.WriteState(); err != nil {
+			r.logger.Warn("failed to write process state file", slog.Any("error", err))
+		}
+	}
+	r.writeQueueMap()
+	r.recordApply(strategyPath)
+	r.retainApplied(strategyPath)
+	r.watchRules(pending)
+	r.setPhase(PhaseRunning)
+
+	// Merge bursts of triggers that follow a reload
+	r.reloads.Hold(cfg.ReloadCooldown)
+	r.events.Add("reloaded_in_place", fmt.Sprintf("%d added, %d removed, %d changed, %d kept (%d rules before)",
+		len(diff.added), len(diff.removed), len(diff.changed), diff.kept, activeRules(old)))
+	return nil
+}
+
+// replaceQueue replaces the nfqws process of a changed queue without losing
+// the verdict of queued packets: the firewall rule is removed, the packets
+// already queued are left to the old process, which is then stopped, and the
+// rule is added back once the new process bound the queue. The queue is left
+// without a firewall rule when a step fails. Caller must hold r.mu.
+func (r *Runner) replaceQueue(ctx context.Context, change ruleChange) error {
+	if !r.externalFirewall() && !change.old.ScheduledOff {
+		if err := r.fw.RemoveRule(ctx, r.convertToFirewallRule(change.old)); err != nil {
+			return fmt.Errorf("failed to remove firewall rule: %w", err)
+		}
+		r.drainRuleQueues(ctx, []int{change.old.QueueNum})
+	}
+	if err := r.procManager.Stop(change.old.QueueNum); err != nil {
+		return fmt.Errorf("failed to stop process: %w", err)
+	}
+	if err := r.procManager.Start(r.processConfig(change.new)); err != nil {
+		return fmt.Errorf("failed to start process: %w", err)
+	}
+	if err := r.confirmBound(ctx, change.new.QueueNum); err != nil {
+		return err
+	}
+	if !r.externalFirewall() && !change.new.ScheduledOff {
+		if err := r.fw.AddRule(ctx, r.convertToFirewallRule(change.new)); err != nil {
+			return fmt.Errorf("failed to add firewall rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyDiff makes the changes of diff, new before old: processes of added
+// queues are started and their firewall rules added, then changed queues are
+// updated, and the rules and processes of removed queues go last, after the
+// packets they queued got a verdict. A changed process is replaced by
+// replaceQueue. A failed change is logged and the others are still made.
+// Caller must hold r.mu.
+func (r *Runner) applyDiff(ctx context.Context, diff *ruleDiff) []error {
+	var errs []error
+	fail := func(msg string, rule ParsedRule, err error) {
+		r.logger.Error(msg, slog.Int("queue", rule.QueueNum), slog.Int("line", rule.SourceLine), slog.Any("error", err))
+		errs = append(errs, fmt.Errorf("queue %d: %w", rule.QueueNum, err))
+	}
+
+	// A process is started before its queue is fed, so no packet waits for it
+	for _, rule := range diff.added {
+		if !r.externalProcesses() {
+			if err := r.procManager.Start(r.processConfig(rule)); err != nil {
+				fail("failed to start process", rule, err)
+				continue
+			}
+		}
+		if !r.externalFirewall() && !rule.ScheduledOff {
+			if err := r.fw.AddRule(ctx, r.convertToFirewallRule(rule)); err != nil {
+				fail("failed to add firewall rule", rule, err)
+			}
+		}
+	}
+
+	// A queue keeps its number, so its process is replaced and its firewall
+	// rule swapped; packets pass unmodified for that moment
+	for _, change := range diff.changed {
+		if change.process && !r.externalProcesses() {
+			r.logger.Info("restarting nfqws process with changed args", slog.Int("queue", change.new.QueueNum))
+			if err := r.replaceQueue(ctx, change); err != nil {
+				fail("failed to replace queue", change.new, err)
+			}
+			continue
+		}
+		if change.firewall && !r.externalFirewall() {
+			if !change.old.ScheduledOff {
+				if err := r.fw.RemoveRule(ctx, r.convertToFirewallRule(change.old)); err != nil {
+					fail("failed to remove firewall rule", change.old, err)
+					continue
+				}
+			}
+			if !change.new.ScheduledOff {
+				if err := r.fw.AddRule(ctx, r.convertToFirewallRule(change.new)); err != nil {
+					fail("failed to add firewall rule", change.new, err)
+				}
+			}
+		}
+	}
+
+	if len(diff.removed) == 0 {
+		return errs
+	}
+	var drain []int
+	for _, rule := range diff.removed {
+		if r.externalFirewall() || rule.ScheduledOff {
+			continue
+		}
+		if err := r.fw.RemoveRule(ctx, r.convertToFirewallRule(rule)); err != nil {
+			fail("failed to remove firewall rule", rule, err)
+			continue
+		}
+		drain = append(drain, rule.QueueNum)
+	}
+	if r.externalProcesses() {
+		return errs
+	}
+	r.drainRuleQueues(ctx, drain)
+	for _, rule := range diff.removed {
+		if err := r.procManager.Stop(rule.QueueNum); err != nil {
+			fail("failed to stop process", rule, err)
+			continue
+		}
+		r.removeQueueDir(rule.QueueNum)
+	}
+	return errs
+}
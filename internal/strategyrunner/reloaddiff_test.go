@@ -0,0 +1,211 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pinnedStrategy pins the tcp rule to queue 7, so changing its args keeps
+// the queue and replaces its process.
+var pinnedStrategy = strings.Replace(testStrategy, "--filter-tcp=443 ", "--filter-tcp=443 --qnum=7 ", 1)
+
+// fileKernelQueues reads the kernel queue state from a fake nfnetlink_queue file.
+type fileKernelQueues string
+
+// Queues implements kernelQueueSource.
+func (path fileKernelQueues) Queues(namespace string) ([]KernelQueue, error) {
+	return readKernelQueues(string(path))
+}
+
+// writeQueueFile replaces the fake nfnetlink_queue file at path with lines.
+func writeQueueFile(path string, lines ...string) error {
+	var data strings.Builder
+	for _, line := range lines {
+		data.WriteString(line + "\n")
+	}
+	// Renamed into place so a read never sees half of it
+	if err := os.WriteFile(path+".new", []byte(data.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".new", path)
+}
+
+// useQueueFile makes tr read the kernel queue state from an empty fake
+// nfnetlink_queue file and returns its path.
+func (tr *testRunner) useQueueFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(tr.dir, "nfnetlink_queue")
+	if err := writeQueueFile(path); err != nil {
+		t.Fatal(err)
+	}
+	tr.queueState = newKernelQueueState(fileKernelQueues(path), tr.logger)
+	return path
+}
+
+// setDrainTimeout sets queue_drain_timeout in the strategy config and in
+// the config the runner starts with, so a reload doesn't see it change.
+func (tr *testRunner) setDrainTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	data, err := os.ReadFile(tr.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), "queue_drain_timeout: 0s", "queue_drain_timeout: "+timeout.String(), 1))
+	if err := os.WriteFile(tr.config, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr.Runner.config.QueueDrainTimeout = timeout
+}
+
+// queuePID returns the pid of the running process of queue, 0 if none.
+func (tr *testRunner) queuePID(queue int) int {
+	for _, p := range tr.procManager.Processes() {
+		if p.Running && p.QueueNum == queue {
+			return p.PID
+		}
+	}
+	return 0
+}
+
+func TestReloadReplacesQueue(t *testing.T) {
+	tr := newTestRunner(t, pinnedStrategy, "")
+	queueFile := tr.useQueueFile(t)
+	tr.setDrainTimeout(t, 2*time.Second)
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	oldPID := tr.queuePID(7)
+	if oldPID == 0 {
+		t.Fatal("queue 7 has no process")
+	}
+	tr.fw.takeOps()
+
+	// Once the rule is removed the old process still has packets to answer;
+	// the new one binds the queue a while after it started
+	problems := make(chan string, 8)
+	report := func(format string, args ...any) {
+		select {
+		case problems <- fmt.Sprintf(format, args...):
+		default:
+		}
+	}
+	var bound atomic.Bool
+	done := make(chan struct{})
+	tr.fw.onRemove = func(queue int) {
+		if queue != 7 {
+			return
+		}
+		if err := writeQueueFile(queueFile, fmt.Sprintf("7 %d 4 2 65531 0 0 0 1", oldPID)); err != nil {
+			report("%v", err)
+		}
+		go func() {
+			defer close(done)
+			time.Sleep(4 * drainPollInterval)
+			if pid := tr.queuePID(7); pid != oldPID {
+				report("queue 7 process replaced by pid %d before its queue drained", pid)
+			}
+			if err := writeQueueFile(queueFile); err != nil {
+				report("%v", err)
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			pid := tr.queuePID(7)
+			for ; pid == 0 || pid == oldPID; pid = tr.queuePID(7) {
+				if time.Now().After(deadline) {
+					report("queue 7 got no new process")
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(4 * drainPollInterval)
+			bound.Store(true)
+			if err := writeQueueFile(queueFile, fmt.Sprintf("7 %d 0 2 65531 0 0 0 1", pid)); err != nil {
+				report("%v", err)
+			}
+		}()
+	}
+	tr.fw.onAdd = func(queue int) {
+		if queue == 7 && !bound.Load() {
+			report("queue 7 rule added before nfqws bound the queue")
+		}
+	}
+
+	tr.writeStrategy(t, strings.Replace(pinnedStrategy, "--dpi-desync-repeats=6", "--dpi-desync-repeats=3", 1))
+	if err := tr.Restart(t.Context()); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("queue 7 rule was never removed, firewall operations = %v", tr.fw.takeOps())
+	}
+	close(problems)
+	for problem := range problems {
+		t.Error(problem)
+	}
+
+	if ops := tr.fw.takeOps(); !slices.Equal(ops, []string{"remove 7", "add 7"}) {
+		t.Errorf("firewall operations = %v, want the queue 7 rule removed and added back", ops)
+	}
+	if pid := tr.queuePID(7); pid == 0 || pid == oldPID {
+		t.Errorf("queue 7 process = pid %d, want a new process replacing pid %d", pid, oldPID)
+	}
+	tr.checkConsistent(t)
+}
+
+func TestReloadUnboundQueueRestarts(t *testing.T) {
+	tr := newTestRunner(t, pinnedStrategy, "")
+	tr.useQueueFile(t)
+	tr.bindTimeout = 4 * drainPollInterval
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	tr.fw.takeOps()
+
+	// The new process never binds queue 7, so the reload restarts fully
+	// instead of leaving the queue without its rule
+	tr.writeStrategy(t, strings.Replace(pinnedStrategy, "--dpi-desync-repeats=6", "--dpi-desync-repeats=3", 1))
+	if err := tr.Restart(t.Context()); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if ops := tr.fw.takeOps(); !slices.Contains(ops, "remove all") {
+		t.Errorf("firewall operations = %v, want a full restart", ops)
+	}
+	tr.checkConsistent(t)
+	for _, p := range tr.procManager.Processes() {
+		if p.QueueNum == 7 && !slices.Contains(p.Args, "--dpi-desync-repeats=3") {
+			t.Errorf("queue 7 process args = %q, want the new strategy", p.Args)
+		}
+	}
+}
+
+func TestReloadFailedChangeRestarts(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	tr.fw.takeOps()
+
+	// Adding the rule of the new queue in place fails; the full restart
+	// that follows installs it
+	tr.fw.mu.Lock()
+	tr.fw.failAddAt = tr.fw.adds + 1
+	tr.fw.mu.Unlock()
+	tr.writeStrategy(t, strings.Replace(testStrategy, "--dpi-desync-repeats=2", "--dpi-desync-repeats=2 --new ^\n--filter-tcp=80 --dpi-desync=fake", 1))
+	if err := tr.Restart(t.Context()); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if ops := tr.fw.takeOps(); !slices.Contains(ops, "remove all") {
+		t.Errorf("firewall operations = %v, want a full restart", ops)
+	}
+	if rules := tr.Rules(); len(rules) != 3 {
+		t.Errorf("got %d rules, want 3", len(rules))
+	}
+	tr.checkConsistent(t)
+}
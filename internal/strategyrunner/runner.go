@@ -5,26 +5,88 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/ethtool"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/hostlist"
 	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
 )
 
 // Runner orchestrates the strategy runner lifecycle.
 type Runner struct {
-	config         *Config
-	mainCfg        *config.StrategyRunnerConfig
-	logger         *slog.Logger
-	parser         *Parser
-	fw             firewall.Firewall
-	procManager    *ProcessManager
-	watcher        *ConfigWatcher
-	mu             sync.RWMutex
-	running        bool
-	lastParsedLen  int
-	startTime      time.Time
+	config          *Config
+	mainCfg         *config.StrategyRunnerConfig
+	logger          *slog.Logger
+	parser          *Parser
+	parseCache      *parseCache
+	fw              *firewall.TimedFirewall
+	makeFirewall    firewallFactory
+	fwOwned         atomic.Pointer[firewall.Ownership] // Containers fw.Setup created, for the state file
+	procManager     *ProcessManager
+	watcher         *ConfigWatcher
+	watchMu         sync.Mutex
+	watchDigests    map[string]string
+	watchFallback   bool
+	pollInterval    time.Duration
+	cancelPoll      context.CancelFunc
+	hostlists       *hostlist.Index
+	reloads         *ReloadCoalescer
+	applySet        *applySet
+	configVersion   string
+	reloadGen       atomic.Uint64
+	events          *EventLog
+	reloadHistory   []ReloadRecord
+	lifeMu          sync.Mutex
+	opMu            sync.Mutex // Serializes Start, Stop and reloads
+	lifecycle       context.Context
+	cancelLifecycle context.CancelFunc
+	cancelRetry     context.CancelFunc
+	cancelUpdates   context.CancelFunc
+	cancelClock     context.CancelFunc
+	cancelSchedule  context.CancelFunc
+	cancelReaper    context.CancelFunc
+	updater         *hostlist.Updater
+	queues          *QueueAllocator
+	offloadDev      ethtool.Device
+	offload         []OffloadFinding
+	offloadRestore  map[string][]ethtool.Feature
+	stats           *StatsClassifier
+	emptyRuleset    bool
+	kernelMu        sync.Mutex
+	kernelDrops     map[int]uint64
+	queueDirs       map[int]queueDirEntry
+	conflictSys     conflictSystem
+	queueState      *kernelQueueState
+	conflicts       []Conflict
+	changelog       *Changelog
+	applied         *appliedStrategy
+	rollbackPath    string
+	quarantine      map[string]time.Time
+	canaryProbe     func(ctx context.Context, domain string) error
+	clock           clock
+	bindTimeout     time.Duration
+	capProber       capabilityProber
+	kernelCaps      []KernelCapability
+	compat          optionCache
+	reconnects      atomic.Uint64
+	reloadCount     atomic.Uint64
+	parseErrors     atomic.Uint64
+	suspension      *processSuspension
+	mu              sync.RWMutex
+	running         bool
+	rules           []ParsedRule
+	filter          *RuleFilter
+	strategySource  string
+	phase           string
+	phaseDone       int
+	phaseTotal      int
+	reloading       bool
+	published       atomic.Pointer[Status]
+	startTime       time.Time
 }
 
 // Status represents the runner status.
@@ -35,134 +97,546 @@ type Status struct {
 	ActiveProcesses int
 	FirewallBackend string
 	StartTime       time.Time
+
+	// Processes are the tracked nfqws processes, exited ones included
+	Processes []ProcessInfo
+
+	// Phase is the start, stop or reload step in progress, or "running"/"stopped"
+	Phase string
+
+	// PhaseDone and PhaseTotal count the rules handled so far by the
+	// applying_firewall and starting_processes phases (0 in other phases)
+	PhaseDone  int
+	PhaseTotal int
+
+	// StrategySource is "file", "embedded-fallback" while the strategy file is
+	// missing, or "canary-rollback" after a failed canary check
+	StrategySource string
+
+	// ConfigVersion is the combined digest of the config and strategy files
+	// the runner was started with
+	ConfigVersion string
+
+	// ApplyPending reports that changes wait for the watch quiet period
+	ApplyPending bool
+
+	// FirewallLastOp is the duration of the most recent firewall operation
+	FirewallLastOp time.Duration
+
+	// FirewallMaxOp is the longest firewall operation observed
+	FirewallMaxOp time.Duration
+
+	// HostlistMemory is the estimated memory used by the hostlist index in bytes
+	HostlistMemory int64
+
+	// PendingRules is the number of rules waiting for their files to appear
+	PendingRules int
+
+	// ProcessManagement and FirewallManagement are "managed" or "external"
+	ProcessManagement  string
+	FirewallManagement string
+
+	// UnboundQueues lists queues without a consumer when processes are external
+	UnboundQueues []int
+
+	// KernelQueuesUnavailable reports that the kernel doesn't expose which
+	// queues are bound, so ActiveProcesses and UnboundQueues of external
+	// processes are unknown
+	KernelQueuesUnavailable bool
+
+	// Offload lists interfaces with offload features that defeat desync
+	Offload []OffloadFinding
+
+	// QueueMapFile is where the queue to rule mapping is published ("" if disabled)
+	QueueMapFile string
+
+	// RuleFilter describes the active rule filter ("" when all rules are applied)
+	RuleFilter string
+
+	// FilteredRules is the number of rules left out by the rule filter
+	FilteredRules int
+
+	// ScheduledOffRules is the number of rules outside their active hours
+	ScheduledOffRules int
+
+	// PollInterval is how often watched files are polled for changes (0 if not polled)
+	PollInterval time.Duration
+
+	// WatchFallback reports that the fsnotify watcher failed and polling replaces it
+	WatchFallback bool
+
+	// EmptyRuleset reports that no rules are applied, allowed by empty_ruleset_policy
+	EmptyRuleset bool
+
+	// Conflicts lists other zapret-family services found at start
+	Conflicts []Conflict
+
+	// KernelCapabilities is the kernel capability set probed at start
+	KernelCapabilities []KernelCapability
+
+	// NetlinkReconnects counts firewall operations retried after a netlink
+	// buffer overrun since the daemon started
+	NetlinkReconnects uint64
+
+	// ZombiesReaped counts exited children nobody waited for, reaped by the
+	// periodic check; anything but 0 is a bug
+	ZombiesReaped uint64
+
+	// FirewallRules is the number of queue rules installed in the firewall
+	// (0 when firewall management is external)
+	FirewallRules int
+
+	// ProcessRestarts counts restarts of crashed nfqws processes since the
+	// daemon started
+	ProcessRestarts uint64
+
+	// ConfigReloads counts reloads, failed ones included, since the daemon
+	// started
+	ConfigReloads uint64
+
+	// ParseErrors counts strategy files that failed to parse on start or
+	// reload since the daemon started
+	ParseErrors uint64
+
+	// SuspendedUntil is when processes suspended by SuspendProcesses are
+	// resumed (zero if not suspended)
+	SuspendedUntil time.Time
+
+	// SuspendedQueues lists queues whose nfqws process is stopped
+	SuspendedQueues []int
+
+	// DegradedQueues lists queues whose nfqws process kept crashing and is no
+	// longer restarted
+	DegradedQueues []int
 }
 
 // NewRunner creates a new strategy runner.
 func NewRunner(mainCfg *config.StrategyRunnerConfig, logger *slog.Logger) (*Runner, error) {
+	return newRunner(mainCfg, logger, newFirewall)
+}
+
+// firewallFactory creates the firewall of cfg. onReconnect is called for
+// operations retried after a netlink buffer overrun.
+type firewallFactory func(cfg *Config, logger *slog.Logger, onReconnect func(error)) (*firewall.TimedFirewall, error)
+
+// newRunner creates a strategy runner whose firewalls come from makeFirewall.
+func newRunner(mainCfg *config.StrategyRunnerConfig, logger *slog.Logger, makeFirewall firewallFactory) (*Runner, error) {
 	// Load strategy config
 	cfg, err := LoadStrategyConfig(mainCfg.ConfigPath)
 	if err != nil {
 		return nil, err
 	}
+	logDeprecations(logger, mainCfg.ConfigPath, cfg.Deprecations)
+	logNormalizations(logger, mainCfg.ConfigPath, cfg.Normalizations)
+
+	// Wait for late mounts holding the strategy or its lists
+	waitForPaths(context.Background(), cfg.WaitForPaths, cfg.WaitTimeout, logger)
 
 	// Validate config
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
+	// Place state files, degrading to volatile state on a read-only root
+	resolveStatePaths(cfg, logger, slog.LevelInfo)
+
 	// Store binary path and other settings
 	cfg.BinaryPath = mainCfg.NFQWSBinary
 	cfg.ConfigPath = mainCfg.ConfigPath
 	cfg.Watch = mainCfg.Watch
 
-	// Create firewall instance
-	fw, err := firewall.NewFirewall(&firewall.Config{
-		Backend:   cfg.Firewall.Backend,
-		TableName: cfg.Firewall.TableName,
-		ChainName: cfg.Firewall.ChainName,
-		Interface: cfg.Interface,
-	})
+	// Create firewall instance; it reports reconnects once r is set
+	var r *Runner
+	fw, err := makeFirewall(cfg, logger, func(err error) { r.netlinkReconnected(err) })
 	if err != nil {
-		return nil, fmt.Errorf("failed to create firewall: %w", err)
+		return nil, err
 	}
 
-	// Create parser
-	parser := NewParser(
-		"/usr/bin",
-		"/etc/zapret-ng/lists",
-		cfg.GameFilterPorts,
-		cfg.GameFilter,
-		logger,
-	)
+	// Create parser; parse results are reused across reloads
+	cache := newParseCache()
+	parser := newParser(cfg, logger, cache)
 
 	// Create process manager
 	procManager := NewProcessManager(mainCfg.NFQWSBinary, logger)
 
-	return &Runner{
+	r = &Runner{
 		config:      cfg,
 		mainCfg:     mainCfg,
 		logger:      logger,
 		parser:      parser,
+		parseCache:  cache,
 		fw:          fw,
 		procManager: procManager,
+		hostlists:   hostlist.NewIndex(),
+		events:      NewEventLog(),
+		offloadDev:  ethtool.System{},
+		conflictSys: systemConflicts{},
+		queueState:  newKernelQueueState(procKernelQueues{}, logger),
+		capProber:   systemProber{},
+		canaryProbe: tlsProbe,
+		clock:       systemClock{},
+		bindTimeout: queueBindTimeout,
+		quarantine:  make(map[string]time.Time),
+		queues:      NewQueueAllocator(cfg.Queues.StateFile, logger),
 		running:     false,
+
+		makeFirewall:   makeFirewall,
+		offloadRestore: make(map[string][]ethtool.Feature),
+	}
+
+	procManager.onEvent = r.events.Add
+
+	r.updater = hostlist.NewUpdater(hostlist.UpdaterConfig{
+		StatePath:   cfg.HostlistUpdate.StateFile,
+		Concurrency: cfg.HostlistUpdate.Concurrency,
+		Logger:      logger,
+		OnEvent:     r.events.Add,
+	})
+
+	// Coalesce reload triggers while the network settles after boot
+	r.reloads = NewReloadCoalescer(r.reloadTriggered)
+	r.reloads.Hold(cfg.StartupSettle)
+	r.applySet = newApplySet(r.reloads.Trigger, logger)
+
+	r.setPhase(PhaseStopped)
+
+	return r, nil
+}
+
+// TriggerReload requests a reload of the strategy config as if a watched file
+// changed: it waits out the reload cooldown, merges with other triggers and
+// goes through the canary when that is enabled.
+func (r *Runner) TriggerReload(reason string) {
+	r.reloads.Trigger(reason)
+}
+
+// reloadTriggered performs a coalesced reload requested by a watcher or other trigger.
+func (r *Runner) reloadTriggered(reasons []string) {
+	r.logger.Info("config changed, restarting strategy runner",
+		slog.Any("triggers", reasons),
+	)
+	r.reloadAttempt(reasons, r.reloadGen.Add(1), 1)
+}
+
+// reloadAttempt performs attempt of a triggered reload. Reloads failing on an
+// invalid config are retried after a delay in case the file was caught mid-write,
+// unless a newer trigger (generation) superseded them.
+func (r *Runner) reloadAttempt(reasons []string, generation uint64, attempt int) {
+	ctx := context.Background()
+	r.mu.RLock()
+	canary := r.config.Canary.Enabled
+	r.mu.RUnlock()
+	err := r.restart(ctx, reasons, r.activeFilter(), reloadOptions{canary: canary, skipQuarantined: canary})
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrRunnerStopped) || errors.Is(err, context.Canceled) {
+		r.logger.Info("reload aborted, strategy runner is stopping", slog.Any("error", err))
+		return
+	}
+
+	var cfgErr *configError
+	r.mu.RLock()
+	delay, attempts := r.config.ReloadRetryDelay, r.config.ReloadRetryAttempts
+	r.mu.RUnlock()
+
+	if !errors.As(err, &cfgErr) || attempt > attempts {
+		r.logger.Error("failed to restart strategy runner", slog.Any("error", err))
+		return
+	}
+
+	r.logger.Warn("reload failed on the new config, keeping the current rules and retrying",
+		slog.Int("attempt", attempt),
+		slog.Int("max_attempts", attempts),
+		slog.Duration("delay", delay),
+		slog.Bool("incomplete", errors.Is(err, ErrConfigIncomplete)),
+		slog.Any("error", err),
+	)
+	r.events.Add("reload_retry", fmt.Sprintf("retry %d/%d in %s: %v", attempt, attempts, delay, err))
+
+	lifecycle := r.lifecycleContext()
+	go func() {
+		select {
+		case <-lifecycle.Done():
+			return
+		case <-time.After(delay):
+		}
+		if r.reloadGen.Load() != generation {
+			// A newer trigger reloads the settled file itself
+			return
+		}
+		r.reloadAttempt(reasons, generation, attempt+1)
+	}()
+}
+
+// Lifecycle errors of the runner.
+var (
+	// ErrRunnerStopped is returned by reloads and restarts requested after Stop
+	ErrRunnerStopped = errors.New("strategy runner stopped")
+
+	// ErrAlreadyRunning is returned by Start when the runner is running
+	ErrAlreadyRunning = errors.New("strategy runner already running")
+
+	// ErrNotRunning is returned by Stop when the runner wasn't running
+	ErrNotRunning = errors.New("strategy runner not running")
+)
+
+// bindLifecycle returns a context that is also cancelled when Stop begins.
+func (r *Runner) bindLifecycle(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	r.lifeMu.Lock()
+	lifecycle := r.lifecycle
+	r.lifeMu.Unlock()
+
+	if lifecycle == nil || lifecycle.Err() != nil {
+		return nil, nil, ErrRunnerStopped
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopAfter := context.AfterFunc(lifecycle, cancel)
+	return ctx, func() {
+		stopAfter()
+		cancel()
 	}, nil
 }
 
+// lifecycleContext returns the context cancelled when Stop begins.
+func (r *Runner) lifecycleContext() context.Context {
+	r.lifeMu.Lock()
+	defer r.lifeMu.Unlock()
+
+	if r.lifecycle == nil {
+		return context.Background()
+	}
+	return r.lifecycle
+}
+
 // Start starts the strategy runner.
+// Reloads are allowed from Start until Stop begins.
 func (r *Runner) Start(ctx context.Context) error {
+	r.lifeMu.Lock()
+	if r.lifecycle == nil || r.lifecycle.Err() != nil {
+		r.lifecycle, r.cancelLifecycle = context.WithCancel(context.Background())
+	}
+	r.lifeMu.Unlock()
+
+	ctx, cancel, err := r.bindLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	// A manual start applies the strategy file, not a rolled back copy
+	r.mu.Lock()
+	if !r.running {
+		r.rollbackPath = ""
+	}
+	r.mu.Unlock()
+
+	return r.start(ctx)
+}
+
+// start parses the strategy, applies firewall rules and starts nfqws processes.
+// It aborts with cleanup if ctx is cancelled before it completes.
+func (r *Runner) start(ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if r.running {
-		return errors.New("strategy runner already running")
+		return ErrAlreadyRunning
+	}
+
+	// Stop may have begun while a reload was waiting for the lock
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("start aborted: %w", err)
 	}
 
+	r.setPhase(PhaseParsing)
+	defer func() {
+		if !r.running {
+			r.setPhase(PhaseStopped)
+		}
+	}()
+
+	strategyPath, source := r.rollbackPath, StrategySourceRollback
+	if strategyPath == "" {
+		var err error
+		if strategyPath, source, err = resolveStrategy(r.config); err != nil {
+			return err
+		}
+	}
+	r.strategySource = source
+	r.configVersion = applySetDigest(r.config.ConfigPath, r.config.StrategyFile)
+	r.applySet.configure(r.config.ConfigPath, r.config.StrategyFile, r.config.WatchTargets.QuietPeriod)
+	r.clearApplyMarker()
+
 	r.logger.Info("starting strategy runner",
 		slog.String("interface", r.config.Interface),
-		slog.String("strategy_file", r.config.StrategyFile),
+		slog.String("hook", r.config.Firewall.Hook),
+		slog.String("strategy_file", strategyPath),
+		slog.String("strategy_source", source),
 		slog.String("firewall", r.config.Firewall.Backend),
+		slog.String("process_management", r.config.ProcessManagement),
+		slog.String("firewall_management", r.config.FirewallManagement),
 	)
 
-	// Track if we need to cleanup on error
-	var firewallSetup bool
+	// Track if we need to cleanup on error. A failed start leaves neither
+	// firewall rules nor processes behind, so the next start begins clean.
+	var firewallSetup, started bool
 	defer func() {
-		// If we had an error and firewall was setup, clean it up
-		if !r.running && firewallSetup {
-			r.logger.Info("startup failed, cleaning up firewall rules")
-			cleanupCtx := context.Background()
-			if err := r.fw.RemoveAll(cleanupCtx); err != nil {
-				r.logger.Error("failed to cleanup firewall rules", slog.Any("error", err))
+		// If we had an error after changing the system, clean up
+		if !r.running && started {
+			r.logger.Info("startup failed, removing the firewall rules and processes it added")
+			if firewallSetup {
+				cleanupCtx := context.Background()
+				if err := r.fw.RemoveAll(cleanupCtx); err != nil {
+					r.logger.Error("failed to cleanup firewall rules", slog.Any("error", err))
+				} else {
+					r.removeFirewallState()
+				}
 			}
 			// Also stop any processes that might have started
 			if err := r.procManager.StopAll(); err != nil {
 				r.logger.Error("failed to stop processes during cleanup", slog.Any("error", err))
 			}
+			if err := r.restoreOffload(); err != nil {
+				r.logger.Error("failed to restore offloads during cleanup", slog.Any("error", err))
+			}
 		}
 	}()
 
 	// 1. Parse strategy file
-	r.logger.Info("parsing strategy file", slog.String("path", r.config.StrategyFile))
-	strategy, err := r.parser.Parse(r.config.StrategyFile)
+	rules, pending, err := r.prepareRules(r.config, r.parser, r.filter, strategyPath, source)
 	if err != nil {
-		return fmt.Errorf("parse failed: %w", err)
+		return err
+	}
+	r.setEmptyRuleset(rules)
+
+	r.rules = rules
+	r.hostlists.Reset()
+	r.logger.Info("parsed strategy rules", slog.Int("count", len(rules)))
+
+	if err := r.runHooks(ctx, HookPreStart, len(rules)); err != nil {
+		return err
 	}
 
-	r.lastParsedLen = len(strategy.Rules)
-	r.logger.Info("parsed strategy rules", slog.Int("count", len(strategy.Rules)))
+	// nfqws left by a daemon that was killed would compete for the queues
+	if orphans, err := r.killStaleProcesses(); err != nil {
+		r.logger.Error("failed to clean up nfqws processes left by a previous run", slog.Any("error", err))
+	} else if killed := killedOrphans(orphans); killed > 0 {
+		r.events.Add("orphans_killed", fmt.Sprintf("killed %d nfqws processes left by a previous run", killed))
+	}
+
+	if err := r.checkConflicts(); err != nil {
+		return err
+	}
 
 	// 2. Setup firewall
-	r.logger.Info("setting up firewall",
-		slog.String("backend", r.config.Firewall.Backend),
-		slog.String("table", r.config.Firewall.TableName),
-		slog.String("chain", r.config.Firewall.ChainName),
-	)
-	if err := r.fw.Setup(ctx); err != nil {
-		return fmt.Errorf("firewall setup failed: %w", err)
+	started = true
+	r.setPhase(PhaseApplyingFirewall)
+	if r.externalFirewall() {
+		r.logger.Info("firewall management is external, not installing queue rules")
+	} else {
+		r.cleanupStaleFirewall(ctx)
+
+		r.logger.Info("setting up firewall",
+			slog.String("backend", r.config.Firewall.Backend),
+			slog.String("table", r.config.Firewall.TableName),
+			slog.String("chain", r.config.Firewall.ChainName),
+		)
+		r.fwOwned.Store(nil)
+		if err := r.writeFirewallState(); err != nil {
+			r.logger.Warn("failed to write firewall state file", slog.Any("error", err))
+		}
+		// Setup can fail after creating the table; RemoveAll only deletes
+		// what Setup created
+		firewallSetup = true
+		if err := r.fw.Setup(ctx); err != nil {
+			return fmt.Errorf("firewall setup failed: %w", err)
+		}
+
+		// Record what Setup created, so a crash never deletes a table or
+		// chain that existed before
+		owned := r.fw.Ownership()
+		r.fwOwned.Store(&owned)
+		if err := r.writeFirewallState(); err != nil {
+			r.logger.Warn("failed to write firewall state file", slog.Any("error", err))
+		}
 	}
-	firewallSetup = true
 
-	// 3. Add firewall rules
-	for _, rule := range strategy.Rules {
-		fwRule := r.convertToFirewallRule(rule)
+	// Warn about (or disable) NIC offloads that defeat desync
+	r.checkOffload()
+
+	// 3. Add firewall rules, leaving out rules outside their active hours
+	now := r.clock.Now()
+	if !r.externalFirewall() {
+		r.setPhaseProgress(0, activeRules(rules))
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("start aborted: %w", err)
+		}
+		if !rule.active() || r.externalFirewall() {
+			continue
+		}
+		r.setPhaseProgress(r.phaseDone+1, r.phaseTotal)
+		if r.updateSchedule(rule, now); rule.ScheduledOff {
+			r.logger.Info("rule is outside its active hours, not queueing yet",
+				slog.Int("queue", rule.QueueNum),
+				slog.Time("next_transition", rule.NextTransition),
+			)
+			continue
+		}
+		fwRule := r.convertToFirewallRule(*rule)
 		r.logger.Debug("adding firewall rule",
 			slog.String("protocol", rule.Protocol),
 			slog.String("ports", rule.Ports),
 			slog.Int("queue", rule.QueueNum),
 		)
 		if err := r.fw.AddRule(ctx, fwRule); err != nil {
-			return fmt.Errorf("add rule failed: %w", err)
+			return fmt.Errorf("add firewall rule for queue %d (line %d, %s %s) failed: %w",
+				rule.QueueNum, rule.SourceLine, rule.Protocol, rule.Ports, err)
 		}
 	}
 
 	// 4. Start nfqws processes
-	r.logger.Info("starting nfqws processes", slog.Int("count", len(strategy.Rules)))
-	for _, rule := range strategy.Rules {
-		procCfg := &ProcessConfig{
-			QueueNum: rule.QueueNum,
-			Args:     parseNFQWSArgs(rule.NFQWSArgs),
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("start aborted: %w", err)
+	}
+	r.setPhase(PhaseStartingProcesses)
+	r.stats = nil
+	if r.config.Process.CollectStats {
+		r.stats, err = NewStatsClassifier(r.config.Process.StatsPatterns)
+		if err != nil {
+			return fmt.Errorf("invalid stats patterns: %w", err)
 		}
-		if err := r.procManager.Start(procCfg); err != nil {
+	}
+
+	if r.externalProcesses() {
+		r.logger.Info("process management is external, expecting nfqws bound to the queues")
+	} else {
+		r.logger.Info("starting nfqws processes",
+			slog.Int("count", len(rules)),
+			slog.Bool("collect_stats", r.stats != nil),
+		)
+	}
+	if !r.externalProcesses() {
+		r.prepareQueueDirs(rules)
+		r.setPhaseProgress(0, activeRules(rules))
+	}
+	r.checkCopyRange(rules)
+	r.procManager.SetRestartPolicy(r.config.Process.RestartMaxRetries, r.config.Process.RestartBackoffMax)
+	r.procManager.SetStateFile(r.config.Process.StateFile, r.config.Process.StateFormat)
+	r.procManager.SetMemoryCgroup(r.config.Process.CgroupDir)
+	for _, rule := range rules {
+		if !rule.active() || r.externalProcesses() {
+			continue
+		}
+		r.setPhaseProgress(r.phaseDone+1, r.phaseTotal)
+		if err := r.procManager.Start(r.processConfig(rule)); err != nil {
 			// Log error but continue with other processes
 			r.logger.Error("failed to start process",
 				slog.Int("queue", rule.QueueNum),
@@ -171,43 +645,209 @@ func (r *Runner) Start(ctx context.Context) error {
 			// Don't return error - try to start the rest
 		}
 	}
+	if !r.externalProcesses() {
+		if err := r.procManager.WriteState(); err != nil {
+			r.logger.Warn("failed to write process state file", slog.Any("error", err))
+		}
+	}
 
-	// 5. Start config watcher if enabled
+	if err := r.runHooks(ctx, HookPostStart, len(rules)); err != nil {
+		return err
+	}
+
+	// 5. Start config watcher if enabled, polling where fsnotify doesn't work
 	if r.config.Watch {
-		r.logger.Info("starting config file watcher", slog.String("path", r.config.ConfigPath))
-		watcher, err := NewConfigWatcher(r.config.ConfigPath, func() {
-			r.logger.Info("config changed, restarting strategy runner")
-			ctx := context.Background()
-			if err := r.Restart(ctx); err != nil {
-				r.logger.Error("failed to restart strategy runner", slog.Any("error", err))
-			}
-		}, r.logger)
-		if err != nil {
-			r.logger.Warn("failed to create config watcher",
-				slog.String("path", r.config.ConfigPath),
-				slog.Any("error", err),
-			)
-		} else {
-			r.watcher = watcher
-			if err := r.watcher.Start(); err != nil {
-				r.logger.Warn("failed to start config watcher", slog.Any("error", err))
-			}
-		}
+		r.startWatcher()
 	}
+	r.startPolling()
 
 	r.running = true
 	r.startTime = time.Now()
+	r.writeQueueMap()
+	r.recordApply(strategyPath)
+	r.retainApplied(strategyPath)
+	r.setPhase(PhaseRunning)
+
+	// 6. Retry pending rules and switch scheduled ones
+	r.watchRules(pending)
+
+	// 7. Keep hostlists up to date
+	if sources := r.hostlistSources(); len(sources) > 0 && r.config.HostlistUpdate.Interval > 0 {
+		updateCtx, cancelUpdates := context.WithCancel(r.lifecycleContext())
+		r.cancelUpdates = cancelUpdates
+		go r.updateHostlists(updateCtx, sources, r.config.HostlistUpdate.Interval)
+	}
+
+	// 8. Correct wall clock based state when the system clock jumps
+	clockCtx, cancelClock := context.WithCancel(r.lifecycleContext())
+	r.cancelClock = cancelClock
+	go r.watchClock(clockCtx, clockCheckInterval)
+
+	// 9. Reap children whose Wait was missed
+	reaperCtx, cancelReaper := context.WithCancel(r.lifecycleContext())
+	r.cancelReaper = cancelReaper
+	go r.procManager.runReaper(reaperCtx, zombieCheckInterval)
+
+	// Merge bursts of triggers that follow a reload
+	r.reloads.Hold(r.config.ReloadCooldown)
 	r.logger.Info("strategy runner started successfully",
-		slog.Int("rules", len(strategy.Rules)),
+		slog.Int("rules", len(rules)),
+		slog.Int("pending", pending),
 		slog.Int("processes", r.procManager.Count()),
 		slog.Time("started_at", r.startTime),
 	)
+	r.events.Add("started", fmt.Sprintf("%d rules, %d processes", len(rules), r.procManager.Count()))
 
 	return nil
 }
 
-// Stop stops the strategy runner.
+// watchRules retries the pending rules of r.rules until their files appear
+// and switches its scheduled rules at their window boundaries, replacing
+// the watchers of the rules applied before. Caller must hold r.mu.
+func (r *Runner) watchRules(pending int) {
+	if r.cancelRetry != nil {
+		r.cancelRetry()
+		r.cancelRetry = nil
+	}
+	if r.cancelSchedule != nil {
+		r.cancelSchedule()
+		r.cancelSchedule = nil
+	}
+
+	if pending > 0 {
+		retryCtx, cancelRetry := context.WithCancel(r.lifecycleContext())
+		r.cancelRetry = cancelRetry
+		go r.retryPending(retryCtx, r.config.PendingRetryInterval)
+	}
+	if r.hasSchedules() {
+		scheduleCtx, cancelSchedule := context.WithCancel(r.lifecycleContext())
+		r.cancelSchedule = cancelSchedule
+		go r.watchSchedule(scheduleCtx, clockCheckInterval)
+	}
+}
+
+// prepareRules parses the strategy at strategyPath for cfg and readies its
+// rules to be applied: queue numbers assigned, filter, compatibility and
+// capability checks applied, pending rules marked. It returns the rules and
+// the number of pending ones. Caller must hold r.mu.
+func (r *Runner) prepareRules(cfg *Config, parser *Parser, filter *RuleFilter, strategyPath, source string) ([]ParsedRule, int, error) {
+	if source == StrategySourceFallback {
+		r.logger.Error("strategy file missing, applying the embedded fallback strategy; service is degraded until a strategy is configured",
+			slog.String("strategy_file", cfg.StrategyFile),
+		)
+		r.events.Add("fallback_strategy", "strategy file missing: "+cfg.StrategyFile)
+	}
+
+	r.logger.Info("parsing strategy file", slog.String("path", strategyPath))
+	strategy, err := parser.Parse(strategyPath)
+	if err != nil {
+		r.parseErrors.Add(1)
+		return nil, 0, fmt.Errorf("parse failed: %w", err)
+	}
+	rules := strategy.Rules
+	resolvePayloads(rules, cfg.PayloadsDir)
+
+	if cfg.Dedupe {
+		rules = dedupeRules(rules, r.logger)
+	}
+	rules = applyOverrides(rules, cfg.Overrides, parser.portAliases, r.logger)
+	rules = applyExclusions(rules, cfg.NeverQueue, parser.portAliases, r.logger)
+	if err := checkOverrides(rules, cfg); err != nil {
+		return nil, 0, err
+	}
+	r.applyCompat(rules, cfg, r.logger)
+	r.kernelCaps = r.checkCapabilities(cfg)
+	if !r.externalFirewall() {
+		applyCapabilities(rules, r.kernelCaps, cfg.CompatMode, r.logger)
+	}
+
+	// Keep queue numbers stable across reloads
+	if err := r.queues.Assign(rules, cfg.Queues, r.foreignQueues(cfg)); err != nil {
+		return nil, 0, fmt.Errorf("queue assignment failed: %w", err)
+	}
+
+	// Apply only the rules selected by the active filter
+	if !filter.IsZero() {
+		selected := applyFilter(rules, filter, parser.portAliases)
+		if selected == 0 {
+			return nil, 0, fmt.Errorf("%w: %s", ErrFilterNoMatch, filter)
+		}
+		r.logger.Warn("rule filter active, applying only selected rules",
+			slog.String("filter", filter.String()),
+			slog.Int("selected", selected),
+			slog.Int("filtered_out", len(rules)-selected),
+		)
+	}
+
+	// Rules whose lists are missing start as pending and are activated once the files appear
+	pending := 0
+	for i := range rules {
+		rule := &rules[i]
+		if rule.FilteredOut {
+			continue
+		}
+		rule.MissingFiles = missingFiles(*rule)
+		if len(rule.MissingFiles) > 0 {
+			pending++
+			r.logger.Warn("rule files missing, rule is pending",
+				slog.Int("queue", rule.QueueNum),
+				slog.Int("line", rule.SourceLine),
+				slog.Any("missing", rule.MissingFiles),
+			)
+		}
+
+		// Broken fakes make desync fail silently; the rule still runs
+		rule.PayloadIssues = r.parseCache.payloadIssues(*rule)
+		for _, issue := range rule.PayloadIssues {
+			r.logger.Warn("rule payload file looks invalid",
+				slog.Int("queue", rule.QueueNum),
+				slog.Int("line", rule.SourceLine),
+				slog.String("problem", issue),
+			)
+		}
+	}
+
+	// Evaluated after every step that can drop rules
+	if err := checkEmptyRuleset(rules, cfg.EmptyRulesetPolicy); err != nil {
+		return nil, 0, err
+	}
+	return rules, pending, nil
+}
+
+// Stop stops the strategy runner. It first cancels the lifecycle context, so a reload
+// in flight aborts at its next checkpoint and no reload starts after Stop returns.
+// It returns ErrNotRunning if the runner was already stopped.
 func (r *Runner) Stop(ctx context.Context) error {
+	// A reload the cancel aborts may leave the runner stopped already
+	r.mu.RLock()
+	active := r.running || r.reloading
+	r.mu.RUnlock()
+
+	r.lifeMu.Lock()
+	if r.cancelLifecycle != nil {
+		r.cancelLifecycle()
+	}
+	r.lifeMu.Unlock()
+
+	// Wait for the start or reload in flight to abort
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+
+	r.mu.RLock()
+	running := r.running
+	r.mu.RUnlock()
+	if !running {
+		if active {
+			return nil
+		}
+		return ErrNotRunning
+	}
+
+	return r.stop(ctx)
+}
+
+// stop stops the watcher and processes and removes firewall rules.
+func (r *Runner) stop(ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -217,10 +857,42 @@ func (r *Runner) Stop(ctx context.Context) error {
 	}
 
 	r.logger.Info("stopping strategy runner")
+	r.setPhase(PhaseStopping)
+
+	// Drop reloads that were waiting for a settle window or quiet period
+	r.reloads.Cancel()
+	r.applySet.cancel()
+
+	// Stop retrying pending rules of this run
+	if r.cancelRetry != nil {
+		r.cancelRetry()
+		r.cancelRetry = nil
+	}
+	if r.cancelUpdates != nil {
+		r.cancelUpdates()
+		r.cancelUpdates = nil
+	}
+	if r.cancelClock != nil {
+		r.cancelClock()
+		r.cancelClock = nil
+	}
+	if r.cancelSchedule != nil {
+		r.cancelSchedule()
+		r.cancelSchedule = nil
+	}
+	if r.cancelReaper != nil {
+		r.cancelReaper()
+		r.cancelReaper = nil
+	}
 
 	var errs []error
 
-	// 1. Stop watcher
+	if err := r.runHooks(ctx, HookPreStop, len(r.rules)); err != nil {
+		r.logger.Warn("pre-stop hook failed, stopping anyway", slog.Any("error", err))
+		errs = append(errs, err)
+	}
+
+	// 1. Stop watcher and polling
 	if r.watcher != nil {
 		r.logger.Info("stopping config watcher")
 		if err := r.watcher.Stop(); err != nil {
@@ -229,23 +901,64 @@ func (r *Runner) Stop(ctx context.Context) error {
 		}
 		r.watcher = nil
 	}
+	if r.cancelPoll != nil {
+		r.cancelPoll()
+		r.cancelPoll = nil
+	}
+	r.watchFallback = false
+	r.pollInterval = 0
 
-	// 2. Stop nfqws processes
-	r.logger.Info("stopping nfqws processes", slog.Int("count", r.procManager.Count()))
-	if err := r.procManager.StopAll(); err != nil {
-		r.logger.Warn("error stopping processes", slog.Any("error", err))
+	// 2. Remove firewall rules, so no more packets are queued
+	if !r.externalFirewall() {
+		r.logger.Info("removing firewall rules")
+		if err := r.fw.RemoveAll(ctx); err != nil {
+			r.logger.Warn("error removing firewall rules", slog.Any("error", err))
+			errs = append(errs, err)
+		} else {
+			r.removeFirewallState()
+		}
+	}
+
+	// 3. Stop nfqws processes once they answered the packets still queued;
+	// suspended ones are continued to do so
+	if r.suspension != nil {
+		if _, err := r.procManager.Resume(); err != nil {
+			r.logger.Warn("failed to resume suspended processes", slog.Any("error", err))
+		}
+	}
+	r.endSuspension()
+	if !r.externalProcesses() {
+		if !r.externalFirewall() {
+			r.drainAppliedQueues(ctx)
+		}
+		r.logger.Info("stopping nfqws processes", slog.Int("count", r.procManager.Count()))
+		if err := r.procManager.StopAll(); err != nil {
+			r.logger.Warn("error stopping processes", slog.Any("error", err))
+			errs = append(errs, err)
+		} else {
+			r.removeQueueDirs()
+		}
+	}
+
+	// 4. Restore offloads disabled by auto_fix_offload
+	if err := r.restoreOffload(); err != nil {
 		errs = append(errs, err)
 	}
 
-	// 3. Remove firewall rules
-	r.logger.Info("removing firewall rules")
-	if err := r.fw.RemoveAll(ctx); err != nil {
-		r.logger.Warn("error removing firewall rules", slog.Any("error", err))
+	if err := r.runHooks(ctx, HookPostStop, len(r.rules)); err != nil {
 		errs = append(errs, err)
 	}
 
+	r.removeQueueMap()
 	r.running = false
+	if r.reloading {
+		// The reload starts the runner again right away
+		r.setPhase(PhaseStarting)
+	} else {
+		r.setPhase(PhaseStopped)
+	}
 	r.logger.Info("strategy runner stopped")
+	r.events.Add("stopped", "")
 
 	if len(errs) > 0 {
 		return fmt.Errorf("stop errors: %v", errs)
@@ -254,73 +967,497 @@ func (r *Runner) Stop(ctx context.Context) error {
 	return nil
 }
 
-// Restart restarts the strategy runner with new configuration.
+// Restart restarts the strategy runner with new configuration, applying all
+// rules and clearing the rule filter.
 func (r *Runner) Restart(ctx context.Context) error {
-	r.logger.Info("restarting strategy runner")
+	return r.RestartFiltered(ctx, nil, false)
+}
 
-	// Stop existing runner
-	if err := r.Stop(ctx); err != nil {
-		r.logger.Error("error stopping runner", slog.Any("error", err))
-		// Continue anyway
+// RestartFiltered restarts the strategy runner applying only the rules
+// selected by filter. The filter stays active across later reloads until the
+// next restart without one. A filter selecting no rules fails the restart
+// before the running rules are touched. Only the rules that changed are
+// replaced unless force is set or settings other than the rules changed, in
+// which case the runner is stopped and started again.
+func (r *Runner) RestartFiltered(ctx context.Context, filter *RuleFilter, force bool) error {
+	if err := filter.Validate(); err != nil {
+		return fmt.Errorf("invalid rule filter: %w", err)
+	}
+	if filter.IsZero() {
+		filter = nil
 	}
+	return r.restart(ctx, []string{"manual"}, filter, reloadOptions{force: force})
+}
 
-	// Reload configuration
-	r.logger.Info("reloading configuration", slog.String("path", r.mainCfg.ConfigPath))
-	cfg, err := LoadStrategyConfig(r.mainCfg.ConfigPath)
+// RestartVerified restarts like RestartFiltered, then runs the canary check
+// and rolls back to the previous strategy if it fails. Unlike automatic
+// reloads it applies quarantined strategies.
+func (r *Runner) RestartVerified(ctx context.Context, filter *RuleFilter, force bool) error {
+	if err := filter.Validate(); err != nil {
+		return fmt.Errorf("invalid rule filter: %w", err)
+	}
+	if filter.IsZero() {
+		filter = nil
+	}
+	return r.restart(ctx, []string{"manual", "canary"}, filter, reloadOptions{canary: true, force: force})
+}
+
+// activeFilter returns the rule filter of the running strategy (nil if none).
+func (r *Runner) activeFilter() *RuleFilter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.filter
+}
+
+// restart restarts the runner with filter and records the reload in the
+// history. Reloads wait for each other and for a start or stop in flight.
+func (r *Runner) restart(ctx context.Context, triggers []string, filter *RuleFilter, opts reloadOptions) error {
+	ctx, cancel, err := r.bindLifecycle(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	r.opMu.Lock()
+	defer r.opMu.Unlock()
+	if ctx.Err() != nil {
+		// Stop began while the reload waited
+		return ErrRunnerStopped
+	}
+
+	var previous *appliedStrategy
+	if opts.canary {
+		r.mu.RLock()
+		previous = r.applied
+		r.mu.RUnlock()
+		if previous == nil {
+			r.logger.Warn("no previously applied strategy to roll back to, skipping the canary check")
+		}
+	}
+
+	start := time.Now()
+	err = r.reload(ctx, filter, opts)
+	if err == nil && previous != nil {
+		err = r.verifyCanary(ctx, previous)
+	}
+	r.recordReload(triggers, time.Since(start), err)
+	return err
+}
+
+// recordReload adds a reload to the history, keeping the newest first.
+func (r *Runner) recordReload(triggers []string, d time.Duration, err error) {
+	r.reloadCount.Add(1)
+	record := ReloadRecord{
+		Time:     time.Now(),
+		Triggers: triggers,
+		Duration: d,
+	}
 	if err != nil {
-		return fmt.Errorf("failed to reload config: %w", err)
+		record.Err = err.Error()
+		r.events.Add("reload_failed", err.Error())
+	} else {
+		r.events.Add("reload", strings.Join(triggers, ", "))
 	}
 
-	// Validate new config
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reloadHistory = append([]ReloadRecord{record}, r.reloadHistory...)
+	if len(r.reloadHistory) > reloadHistorySize {
+		r.reloadHistory = r.reloadHistory[:reloadHistorySize]
+	}
+}
+
+// loadReloadConfig loads and validates the strategy config for a reload and
+// checks that its strategy file parses and has rules selected by filter.
+func (r *Runner) loadReloadConfig(filter *RuleFilter) (*Config, error) {
+	path := r.mainCfg.ConfigPath
+	if err := checkConfigComplete(path); err != nil {
+		return nil, &configError{fmt.Errorf("failed to reload config: %w", err)}
+	}
+
+	cfg, err := LoadStrategyConfig(path)
+	if err != nil {
+		return nil, &configError{fmt.Errorf("failed to reload config: %w", err)}
+	}
+	logDeprecations(r.logger, path, cfg.Deprecations)
+	logNormalizations(r.logger, path, cfg.Normalizations)
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("new config validation failed: %w", err)
+		return nil, &configError{fmt.Errorf("new config validation failed: %w", err)}
+	}
+	strategyPath, _, err := resolveStrategy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	parser := newParser(cfg, r.logger, r.parseCache)
+	strategy, err := parser.Parse(strategyPath)
+	if err != nil {
+		r.parseErrors.Add(1)
+		return nil, &configError{fmt.Errorf("new strategy file invalid: %w", err)}
+	}
+
+	// Indices refer to the rules after deduplication, as listed by ListRules
+	rules := strategy.Rules
+	if cfg.Dedupe {
+		rules = dedupeRules(rules, slog.New(slog.DiscardHandler))
+	}
+	if !filter.IsZero() {
+		if applyFilter(rules, filter, parser.portAliases) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrFilterNoMatch, filter)
+		}
+	}
+
+	// Refuse a config leaving nothing to apply before the running rules are torn down
+	if cfg.EmptyRulesetPolicy == EmptyRulesetError {
+		r.applyCompat(rules, cfg, slog.New(slog.DiscardHandler))
+		if cfg.FirewallManagement != ManagementExternal {
+			r.mu.RLock()
+			caps := r.kernelCaps
+			r.mu.RUnlock()
+			applyCapabilities(rules, caps, cfg.CompatMode, slog.New(slog.DiscardHandler))
+		}
+		if err := checkEmptyRuleset(rules, cfg.EmptyRulesetPolicy); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// logDeprecations logs the deprecation warnings of the config at path.
+func logDeprecations(logger *slog.Logger, path string, warnings []string) {
+	for _, w := range warnings {
+		logger.Warn("deprecated config key, run zapret-daemon check-config --migrate --write to update the file",
+			slog.String("path", path),
+			slog.String("detail", w),
+		)
+	}
+}
+
+// logNormalizations logs the values of the config at path that were read
+// after folding look-alike characters.
+func logNormalizations(logger *slog.Logger, path string, warnings []string) {
+	for _, w := range warnings {
+		logger.Warn("normalized config value with non-ASCII characters",
+			slog.String("path", path),
+			slog.String("detail", w),
+		)
+	}
+}
+
+// newParser creates a strategy parser for cfg reusing results from cache.
+func newParser(cfg *Config, logger *slog.Logger, cache *parseCache) *Parser {
+	parser := NewParser(
+		cfg.BinPath,
+		cfg.ListsPath,
+		cfg.GameFilterPorts,
+		cfg.GameFilter,
+		logger,
+	)
+	// Validated when the config was loaded
+	if aliases, err := NewPortAliases(cfg.PortAliases); err == nil {
+		parser.portAliases = aliases
+	}
+	if cfg.MaxLineLength > 0 {
+		parser.SetMaxLineLength(cfg.MaxLineLength)
+	}
+	parser.cache = cache
+	return parser
+}
+
+// reload validates the new strategy config, stops the runner and starts it
+// again with the new config and rule filter.
+func (r *Runner) reload(ctx context.Context, filter *RuleFilter, opts reloadOptions) error {
+	r.logger.Info("restarting strategy runner")
+
+	// Load and validate the new configuration before tearing down the running
+	// one, so a broken or half-written config leaves the current rules in place
+	r.logger.Info("reloading configuration", slog.String("path", r.mainCfg.ConfigPath))
+	cfg, err := r.loadReloadConfig(filter)
+	if err != nil {
+		return err
+	}
+	if opts.skipQuarantined {
+		if err := r.checkQuarantine(cfg); err != nil {
+			return err
+		}
 	}
 
 	cfg.BinaryPath = r.mainCfg.NFQWSBinary
 	cfg.ConfigPath = r.mainCfg.ConfigPath
 	cfg.Watch = r.mainCfg.Watch
+	resolveStatePaths(cfg, r.logger, slog.LevelDebug)
 
-	// Update runner config
+	// The strategy file is applied again, not a rolled back copy
 	r.mu.Lock()
-	r.config = cfg
+	r.rollbackPath = ""
 	r.mu.Unlock()
 
-	// Recreate firewall instance with new config
-	fw, err := firewall.NewFirewall(&firewall.Config{
-		Backend:   cfg.Firewall.Backend,
-		TableName: cfg.Firewall.TableName,
-		ChainName: cfg.Firewall.ChainName,
-		Interface: cfg.Interface,
-	})
+	return r.apply(ctx, cfg, filter, opts.force)
+}
+
+// apply applies cfg and filter to the runner. The rules that changed are
+// replaced in place; if that isn't possible, or force is set, the runner is
+// stopped and started again.
+func (r *Runner) apply(ctx context.Context, cfg *Config, filter *RuleFilter, force bool) error {
+	r.mu.Lock()
+	r.reloading = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.reloading = false
+		if !r.running && r.phase != PhaseStopped {
+			r.setPhase(PhaseStopped)
+		}
+	}()
+
+	if !force {
+		err := r.applyInPlace(ctx, cfg, filter)
+		if !errors.Is(err, errFullRestart) {
+			return err
+		}
+		r.logger.Info("cannot reload in place, restarting", slog.Any("reason", err))
+	}
+
+	// Stop existing runner
+	if err := r.stop(ctx); err != nil {
+		r.logger.Error("error stopping runner", slog.Any("error", err))
+		// Continue anyway
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("restart aborted: %w", err)
+	}
+
+	// Recreate firewall instance with new config. It settles an "auto"
+	// backend in cfg, so cfg is only shared once it returned
+	fw, err := r.makeFirewall(cfg, r.logger, r.netlinkReconnected)
 	if err != nil {
-		return fmt.Errorf("failed to create firewall: %w", err)
+		return err
 	}
 
+	// Update runner config
 	r.mu.Lock()
+	previous := r.fw
+	r.config = cfg
+	r.parser = newParser(cfg, r.logger, r.parseCache)
+	r.filter = filter
 	r.fw = fw
 	r.mu.Unlock()
 
+	// The stop is done with the previous firewall, release its handles
+	if err := previous.Close(); err != nil {
+		r.logger.Warn("failed to close the previous firewall", slog.Any("error", err))
+	}
+
 	// Start with new configuration
-	return r.Start(ctx)
+	return r.start(ctx)
 }
 
-// GetStatus returns the current runner status.
+// GetStatus returns the current runner status. It never waits for a start,
+// stop or reload: while one holds the runner, the status published at its
+// last phase boundary is returned.
 func (r *Runner) GetStatus() *Status {
-	r.mu.RLock()
+	if !r.mu.TryRLock() {
+		return r.publishedStatus()
+	}
 	defer r.mu.RUnlock()
 
-	return &Status{
+	return r.status()
+}
+
+// firewallBackend names the firewall backend and the fallback it runs in,
+// e.g. "nftables (no set support, expanded rules)". Caller must hold r.mu.
+func (r *Runner) firewallBackend() string {
+	if mode := r.fw.Mode(); mode != "" {
+		return fmt.Sprintf("%s (%s)", r.config.Firewall.Backend, mode)
+	}
+	return r.config.Firewall.Backend
+}
+
+// status builds the runner status. Caller must hold r.mu.
+func (r *Runner) status() *Status {
+	lastOp, maxOp := r.fw.Latency()
+
+	// The rules of a stopped runner are kept for the next start but not applied
+	activeQueues := 0
+	if r.running {
+		activeQueues = len(r.rules)
+	}
+
+	status := &Status{
 		Running:         r.running,
+		Phase:           r.phase,
+		PhaseDone:       r.phaseDone,
+		PhaseTotal:      r.phaseTotal,
 		StrategyFile:    r.config.StrategyFile,
-		ActiveQueues:    r.lastParsedLen,
+		StrategySource:  r.strategySource,
+		ConfigVersion:   r.configVersion,
+		ApplyPending:    r.applySet.pending(),
+		ActiveQueues:    activeQueues,
 		ActiveProcesses: r.procManager.Count(),
-		FirewallBackend: r.config.Firewall.Backend,
+		Processes:       r.procManager.Processes(),
+		FirewallBackend: r.firewallBackend(),
 		StartTime:       r.startTime,
+		FirewallLastOp:  lastOp,
+		FirewallMaxOp:   maxOp,
+		HostlistMemory:  r.hostlists.MemoryBytes(),
+		PendingRules:    r.pendingCount(),
+		Offload:         r.offload,
+		QueueMapFile:    r.config.QueueMapFile,
+		RuleFilter:      r.filter.String(),
+		FilteredRules:   r.filteredCount(),
+
+		ScheduledOffRules: r.scheduledOffCount(),
+		PollInterval:      r.pollInterval,
+		WatchFallback:     r.watchFallback,
+		EmptyRuleset:      r.running && r.emptyRuleset,
+		Conflicts:         r.conflicts,
+
+		KernelCapabilities: r.kernelCaps,
+		NetlinkReconnects:  r.reconnects.Load(),
+		ZombiesReaped:      r.procManager.ZombiesReaped(),
+		FirewallRules:      r.firewallRuleCount(),
+		ProcessRestarts:    r.procManager.RestartsTotal(),
+		ConfigReloads:      r.reloadCount.Load(),
+		ParseErrors:        r.parseErrors.Load(),
+
+		SuspendedUntil: r.suspendedUntil(),
+
+		ProcessManagement:  r.config.ProcessManagement,
+		FirewallManagement: r.config.FirewallManagement,
+	}
+
+	if r.externalProcesses() && r.running {
+		unbound, err := r.unboundQueues()
+		switch {
+		case errors.Is(err, ErrKernelQueuesUnavailable):
+			// Logged once by queueState
+			status.KernelQueuesUnavailable = true
+		case err != nil:
+			r.logger.Warn("failed to check queue consumers", slog.Any("error", err))
+		}
+		status.UnboundQueues = unbound
+		status.ActiveProcesses = max(r.consumers(0), 0)
+	}
+	if !r.externalProcesses() && r.running {
+		status.SuspendedQueues = r.suspendedQueues()
+		status.DegradedQueues = r.procManager.DegradedQueues()
+	}
+
+	return status
+}
+
+// pendingCount returns the number of pending rules. Caller must hold r.mu.
+func (r *Runner) pendingCount() int {
+	n := 0
+	for _, rule := range r.rules {
+		if len(rule.MissingFiles) > 0 {
+			n++
+		}
 	}
+	return n
+}
+
+// filteredCount returns the number of rules left out by the rule filter. Caller must hold r.mu.
+func (r *Runner) filteredCount() int {
+	n := 0
+	for _, rule := range r.rules {
+		if rule.FilteredOut {
+			n++
+		}
+	}
+	return n
+}
+
+// firewallRuleCount returns the number of queue rules installed in the
+// firewall. Caller must hold r.mu.
+func (r *Runner) firewallRuleCount() int {
+	if !r.running || r.externalFirewall() {
+		return 0
+	}
+	n := 0
+	for i := range r.rules {
+		if r.rules[i].active() && !r.rules[i].ScheduledOff {
+			n++
+		}
+	}
+	return n
+}
+
+// scheduledOffCount returns the number of rules outside their active hours. Caller must hold r.mu.
+func (r *Runner) scheduledOffCount() int {
+	n := 0
+	for _, rule := range r.rules {
+		if rule.ScheduledOff {
+			n++
+		}
+	}
+	return n
+}
+
+// RenderRule returns the firewall commands that install rule.
+func (r *Runner) RenderRule(rule ParsedRule) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.externalFirewall() {
+		return nil, errors.New("firewall management is external")
+	}
+	return r.fw.Render(r.convertToFirewallRule(rule))
+}
+
+// Rules returns the rules applied by the last successful start.
+func (r *Runner) Rules() []ParsedRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]ParsedRule, len(r.rules))
+	copy(rules, r.rules)
+	return rules
 }
 
 // Helper functions
 
+// newFirewall creates the configured firewall backend wrapped with timing
+// instrumentation. onReconnect is called when it recovers from a netlink
+// buffer overrun. An "auto" backend in cfg is replaced with the selected one.
+func newFirewall(cfg *Config, logger *slog.Logger, onReconnect func(error)) (*firewall.TimedFirewall, error) {
+	exclude, err := cfg.ExcludePrefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	fwCfg := &firewall.Config{
+		Backend:         cfg.Firewall.Backend,
+		TableName:       cfg.Firewall.TableName,
+		ChainName:       cfg.Firewall.ChainName,
+		Interface:       cfg.Interface,
+		Namespace:       cfg.NetworkNamespace,
+		Hook:            cfg.Firewall.Hook,
+		ExcludeNetworks: exclude,
+		Logger:          logger,
+		OnReconnect:     onReconnect,
+	}
+	fw, err := firewall.NewFirewall(fwCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create firewall: %w", err)
+	}
+
+	// Status, diagnostics and the state file name the backend in use, not "auto"
+	cfg.Firewall.Backend = fwCfg.Backend
+
+	return firewall.NewTimedFirewall(fw, cfg.Firewall.SlowOpThreshold, logger), nil
+}
+
+// netlinkReconnected records a firewall operation retried after a netlink
+// buffer overrun; the backend logs err. The count outlives the firewall,
+// which reloads recreate.
+func (r *Runner) netlinkReconnected(err error) {
+	r.reconnects.Add(1)
+	r.events.Add("netlink_reconnect", "firewall operation retried after a netlink buffer overrun")
+}
+
 // convertToFirewallRule converts a parsed rule to a firewall rule.
 func (r *Runner) convertToFirewallRule(rule ParsedRule) *firewall.Rule {
 	interface_ := ""
@@ -328,26 +1465,64 @@ func (r *Runner) convertToFirewallRule(rule ParsedRule) *firewall.Rule {
 		interface_ = r.config.Interface
 	}
 
+	interfaceV6 := r.config.InterfaceV6
+	if rule.InterfaceV6 != "" {
+		interfaceV6 = rule.InterfaceV6
+	}
+	if interfaceV6 == "any" {
+		interfaceV6 = ""
+	}
+
+	inputInterface := ""
+	if r.config.LanInterface != "any" {
+		inputInterface = r.config.LanInterface
+	}
+
+	// Validated when the config was loaded
+	marks, _ := r.config.Firewall.Marks()
+
 	return &firewall.Rule{
-		Protocol:  rule.Protocol,
-		Ports:     splitPorts(rule.Ports),
-		QueueNum:  rule.QueueNum,
-		Interface: interface_,
-		Comment:   "Added by zapret",
+		Protocol:    rule.Protocol,
+		Ports:       splitPorts(rule.Ports),
+		PortsV6:     splitPorts(rule.PortsV6),
+		QueueNum:    rule.QueueNum,
+		Interface:   interface_,
+		InterfaceV6: interfaceV6,
+		RateLimit:   rule.RateLimit,
+		Mark:        marks,
+		Notrack:     rule.Notrack,
+		Comment:     "Added by zapret",
+
+		InputInterface: inputInterface,
+		ConnbytesLimit: r.config.Firewall.ConnbytesLimit,
 	}
 }
 
-// splitPorts splits a port string into a slice.
+// processDesyncMark returns the desync_fwmark passed to nfqws (0 if unset).
+func (r *Runner) processDesyncMark() uint32 {
+	// Validated when the config was loaded
+	mark, _ := r.config.Firewall.DesyncMark()
+	return mark
+}
+
+// splitPorts splits a port string like "80,443,1024-65535" into its ports
+// and ranges.
 func splitPorts(portStr string) []string {
-	// Handle individual ports and ranges as-is
-	return []string{portStr}
+	var ports []string
+	for _, port := range strings.Split(portStr, ",") {
+		if port = strings.TrimSpace(port); port != "" {
+			ports = append(ports, port)
+		}
+	}
+	return ports
 }
 
 // parseNFQWSArgs parses nfqws arguments from a string.
 func parseNFQWSArgs(argsStr string) []string {
-	// Simple split on spaces, preserving quoted strings
+	// Simple split on spaces, preserving quoted strings. A builder keeps
+	// lines of hundreds of kilobytes linear.
 	var args []string
-	var current string
+	var current strings.Builder
 	inQuotes := false
 
 	for _, ch := range argsStr {
@@ -356,18 +1531,18 @@ func parseNFQWSArgs(argsStr string) []string {
 			inQuotes = !inQuotes
 		case ' ':
 			if inQuotes {
-				current += string(ch)
-			} else if current != "" {
-				args = append(args, current)
-				current = ""
+				current.WriteRune(ch)
+			} else if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
 			}
 		default:
-			current += string(ch)
+			current.WriteRune(ch)
 		}
 	}
 
-	if current != "" {
-		args = append(args, current)
+	if current.Len() > 0 {
+		args = append(args, current.String())
 	}
 
 	return args
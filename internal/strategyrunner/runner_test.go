@@ -0,0 +1,1062 @@
+package strategyrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+func TestSplitPorts(t *testing.T) {
+	tests := []struct {
+		ports string
+		want  []string
+	}{
+		{"443", []string{"443"}},
+		{"1024-65535", []string{"1024-65535"}},
+		{"80,443,1024-65535", []string{"80", "443", "1024-65535"}},
+		{" 80 , 443 ,", []string{"80", "443"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitPorts(tt.ports); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPorts(%q) = %q, want %q", tt.ports, got, tt.want)
+		}
+	}
+}
+
+func TestConvertToFirewallRuleSplitsPorts(t *testing.T) {
+	r := &Runner{config: &Config{Interface: "any", LanInterface: "any"}}
+
+	got := r.convertToFirewallRule(ParsedRule{Protocol: "udp", Ports: "443,50000-50100", QueueNum: 201})
+	if want := []string{"443", "50000-50100"}; !reflect.DeepEqual(got.Ports, want) {
+		t.Errorf("Ports = %q, want %q", got.Ports, want)
+	}
+	if got.Interface != "" {
+		t.Errorf("Interface = %q, want none for \"any\"", got.Interface)
+	}
+}
+
+func TestConvertToFirewallRuleFamilyOverrides(t *testing.T) {
+	tests := []struct {
+		name            string
+		configIfaceV6   string
+		rule            ParsedRule
+		wantPortsV6     []string
+		wantInterfaceV6 string
+	}{
+		{
+			name: "no overrides",
+			rule: ParsedRule{Protocol: "tcp", Ports: "443"},
+		},
+		{
+			name:            "config interface_v6",
+			configIfaceV6:   "he-ipv6",
+			rule:            ParsedRule{Protocol: "tcp", Ports: "443"},
+			wantInterfaceV6: "he-ipv6",
+		},
+		{
+			name:            "rule overrides replace the config",
+			configIfaceV6:   "he-ipv6",
+			rule:            ParsedRule{Protocol: "udp", Ports: "443,50000-50100", PortsV6: "443,3478", InterfaceV6: "wg6"},
+			wantPortsV6:     []string{"443", "3478"},
+			wantInterfaceV6: "wg6",
+		},
+		{
+			name:          "any falls back to interface",
+			configIfaceV6: "he-ipv6",
+			rule:          ParsedRule{Protocol: "tcp", Ports: "443", InterfaceV6: "any"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Runner{config: &Config{Interface: "eth0", InterfaceV6: tt.configIfaceV6, LanInterface: "any"}}
+			got := r.convertToFirewallRule(tt.rule)
+			if !reflect.DeepEqual(got.PortsV6, tt.wantPortsV6) {
+				t.Errorf("PortsV6 = %q, want %q", got.PortsV6, tt.wantPortsV6)
+			}
+			if got.InterfaceV6 != tt.wantInterfaceV6 {
+				t.Errorf("InterfaceV6 = %q, want %q", got.InterfaceV6, tt.wantInterfaceV6)
+			}
+		})
+	}
+}
+
+// testNFQWS stands in for nfqws: it lists its options for --help and
+// otherwise waits to be stopped. It doesn't exec sleep, which would change
+// the executable the runner recorded and keep it from signalling the stub.
+const testNFQWS = `#!/bin/sh
+if [ "$1" = "--help" ]; then
+	echo "--qnum --daemon --user --uid --filter-tcp --filter-udp --filter-l7 --hostlist --hostlist-auto"
+	echo "--dpi-desync --dpi-desync-repeats --dpi-desync-fwmark --dpi-desync-fake-quic --new"
+	exit 1
+fi
+while :; do sleep 1; done
+`
+
+// testStrategy has a TCP and a UDP rule.
+const testStrategy = `start "zapret" /min "%BIN%winws.exe" --wf-tcp=443 --wf-udp=443 ^
+--filter-tcp=443 --dpi-desync=fake --dpi-desync-repeats=6 --new ^
+--filter-udp=443 --dpi-desync=fake --dpi-desync-repeats=2
+`
+
+// testRunner is a runner started from files in a temporary directory, with
+// a stub nfqws and fake firewall, kernel and system state.
+type testRunner struct {
+	*Runner
+	fw       *fakeFirewall
+	kernel   *fakeKernelQueues
+	dir      string
+	strategy string
+	config   string
+}
+
+// newTestRunner creates a runner for strategy with the YAML settings added
+// to the test config. It is stopped when the test ends.
+func newTestRunner(t testing.TB, strategy, settings string) *testRunner {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub nfqws is a shell script")
+	}
+
+	dir := t.TempDir()
+	tr := &testRunner{
+		fw:       newFakeFirewall(),
+		kernel:   &fakeKernelQueues{err: ErrKernelQueuesUnavailable},
+		dir:      dir,
+		strategy: filepath.Join(dir, "strategy.bat"),
+		config:   filepath.Join(dir, "strategy.yaml"),
+	}
+
+	binary := filepath.Join(dir, "nfqws")
+	if err := os.WriteFile(binary, []byte(testNFQWS), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []string{"state", "run"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tr.writeStrategy(t, strategy)
+	tr.writeConfig(t, settings)
+
+	mainCfg := &config.StrategyRunnerConfig{ConfigPath: tr.config, NFQWSBinary: binary}
+	makeFirewall := func(cfg *Config, logger *slog.Logger, onReconnect func(error)) (*firewall.TimedFirewall, error) {
+		return firewall.NewTimedFirewall(tr.fw, cfg.Firewall.SlowOpThreshold, logger), nil
+	}
+	r, err := newRunner(mainCfg, slog.New(slog.DiscardHandler), makeFirewall)
+	if err != nil {
+		t.Fatalf("newRunner() error = %v", err)
+	}
+	r.conflictSys = fakeConflicts{}
+	r.capProber = fakeProber{}
+	r.queueState = newKernelQueueState(tr.kernel, r.logger)
+	tr.Runner = r
+
+	t.Cleanup(func() {
+		if err := r.Stop(context.Background()); err != nil && !errors.Is(err, ErrNotRunning) {
+			t.Errorf("Stop() error = %v", err)
+		}
+	})
+	return tr
+}
+
+// writeStrategy replaces the strategy file.
+func (tr *testRunner) writeStrategy(t testing.TB, strategy string) {
+	t.Helper()
+	if err := os.WriteFile(tr.strategy, []byte(strategy), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeConfig replaces the strategy config with the test defaults and settings.
+func (tr *testRunner) writeConfig(t testing.TB, settings string) {
+	t.Helper()
+	data := fmt.Sprintf(`strategy_file: %s
+bin_path: %s
+lists_path: %s
+exclude_networks: []
+reload_cooldown: 0s
+queue_drain_timeout: 0s
+state:
+  dir: %s
+  volatile_dir: %s
+%s`, tr.strategy, tr.dir, tr.dir, filepath.Join(tr.dir, "state"), filepath.Join(tr.dir, "run"), settings)
+	if err := os.WriteFile(tr.config, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkConsistent fails the test unless every active rule has exactly one
+// process and one firewall rule and nothing else runs or is installed.
+func (tr *testRunner) checkConsistent(t *testing.T) {
+	t.Helper()
+	for _, problem := range tr.inconsistencies() {
+		t.Error(problem)
+	}
+}
+
+// inconsistencies describes how rules, processes and firewall rules disagree.
+func (tr *testRunner) inconsistencies() []string {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	var problems []string
+	want := make(map[int]bool)
+	for _, rule := range tr.rules {
+		if !tr.running || !rule.active() {
+			continue
+		}
+		if want[rule.QueueNum] {
+			problems = append(problems, fmt.Sprintf("queue %d is used by two rules", rule.QueueNum))
+		}
+		want[rule.QueueNum] = true
+	}
+
+	running := make(map[int]int)
+	for _, p := range tr.procManager.Processes() {
+		if p.Running {
+			running[p.QueueNum]++
+		}
+	}
+	for queue, n := range running {
+		switch {
+		case !want[queue]:
+			problems = append(problems, fmt.Sprintf("queue %d has a process but no active rule", queue))
+		case n > 1:
+			problems = append(problems, fmt.Sprintf("queue %d has %d processes", queue, n))
+		}
+	}
+	installed := tr.fw.queues()
+	for queue := range want {
+		if running[queue] == 0 {
+			problems = append(problems, fmt.Sprintf("queue %d has no process", queue))
+		}
+		if !slices.Contains(installed, queue) {
+			problems = append(problems, fmt.Sprintf("queue %d has no firewall rule", queue))
+		}
+	}
+	for _, queue := range installed {
+		if !want[queue] {
+			problems = append(problems, fmt.Sprintf("firewall rule for queue %d left without a rule", queue))
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+// fakeFirewall is a firewall backend keeping the installed rules in memory.
+type fakeFirewall struct {
+	mu     sync.Mutex
+	setup  bool
+	rules  map[int]*firewall.Rule
+	ops    []string
+	addErr map[int]error // AddRule fails for these queues
+
+	setupErr  error // Setup fails with it
+	failAddAt int   // The AddRule call with this number fails, counted from 1
+	adds      int
+
+	// onAdd and onRemove are called with the queue of each AddRule and
+	// RemoveRule, with f.mu held
+	onAdd, onRemove func(queue int)
+
+	// onSetup is called by Setup before it takes f.mu
+	onSetup func()
+}
+
+func newFakeFirewall() *fakeFirewall {
+	return &fakeFirewall{rules: make(map[int]*firewall.Rule), addErr: make(map[int]error)}
+}
+
+func (f *fakeFirewall) Setup(ctx context.Context) error {
+	if f.onSetup != nil {
+		f.onSetup()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, "setup")
+	if f.setupErr != nil {
+		return f.setupErr
+	}
+	f.setup = true
+	return nil
+}
+
+func (f *fakeFirewall) AddRule(ctx context.Context, rule *firewall.Rule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, fmt.Sprintf("add %d", rule.QueueNum))
+	if f.onAdd != nil {
+		f.onAdd(rule.QueueNum)
+	}
+	f.adds++
+	if f.adds == f.failAddAt {
+		return errors.New("malformed port spec")
+	}
+	if err := f.addErr[rule.QueueNum]; err != nil {
+		return err
+	}
+	if !f.setup {
+		return errors.New("firewall not set up")
+	}
+	if _, ok := f.rules[rule.QueueNum]; ok {
+		return fmt.Errorf("rule for queue %d already installed", rule.QueueNum)
+	}
+	f.rules[rule.QueueNum] = rule
+	return nil
+}
+
+func (f *fakeFirewall) RemoveRule(ctx context.Context, rule *firewall.Rule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, fmt.Sprintf("remove %d", rule.QueueNum))
+	if f.onRemove != nil {
+		f.onRemove(rule.QueueNum)
+	}
+	delete(f.rules, rule.QueueNum)
+	return nil
+}
+
+func (f *fakeFirewall) RemoveAll(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, "remove all")
+	f.setup = false
+	clear(f.rules)
+	return nil
+}
+
+func (f *fakeFirewall) Close() error {
+	return nil
+}
+
+// queues returns the queues with an installed rule in order.
+func (f *fakeFirewall) queues() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Sorted(maps.Keys(f.rules))
+}
+
+// rule returns a copy of the installed rule of queue, nil if none.
+func (f *fakeFirewall) rule(queue int) *firewall.Rule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule, ok := f.rules[queue]
+	if !ok {
+		return nil
+	}
+	c := *rule
+	return &c
+}
+
+// takeOps returns the operations since the last call.
+func (f *fakeFirewall) takeOps() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ops := f.ops
+	f.ops = nil
+	return ops
+}
+
+// fakeKernelQueues serves a settable kernel queue state. It is unavailable
+// until set, as the stub nfqws binds no queue.
+type fakeKernelQueues struct {
+	mu     sync.Mutex
+	queues []KernelQueue
+	err    error
+}
+
+func (f *fakeKernelQueues) Queues(namespace string) ([]KernelQueue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.queues), f.err
+}
+
+func (f *fakeKernelQueues) set(queues ...KernelQueue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queues, f.err = queues, nil
+}
+
+// fakeConflicts reports a system without other zapret services.
+type fakeConflicts struct{}
+
+func (fakeConflicts) ServiceActive(unit string) (bool, error)   { return false, nil }
+func (fakeConflicts) Tables(namespace string) ([]string, error) { return nil, nil }
+func (fakeConflicts) Processes() ([]conflictProcess, error)     { return nil, nil }
+
+// fakeProber reports a kernel accepting every probe rule.
+type fakeProber struct{}
+
+func (fakeProber) Exists(path string) bool                  { return false }
+func (fakeProber) Modules() (map[string]bool, error)        { return nil, nil }
+func (fakeProber) CheckNft(namespace, ruleset string) error { return nil }
+
+func TestRunnerStartStop(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	tr.checkConsistent(t)
+	if got := len(tr.fw.queues()); got != 2 {
+		t.Errorf("%d firewall rules installed, want 2", got)
+	}
+	if got := tr.procManager.Count(); got != 2 {
+		t.Errorf("%d processes running, want 2", got)
+	}
+
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d processes left after Stop", got)
+	}
+	if got := tr.fw.queues(); len(got) != 0 {
+		t.Errorf("firewall rules left after Stop: %v", got)
+	}
+	if err := tr.Stop(t.Context()); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("second Stop() error = %v, want %v", err, ErrNotRunning)
+	}
+}
+
+// rollbackStrategy has five rules, one per line.
+const rollbackStrategy = `start "zapret" /min "%BIN%winws.exe" --wf-tcp=80,443,8080,8443 --wf-udp=443 ^
+--filter-tcp=80 --dpi-desync=fake --new ^
+--filter-tcp=443 --dpi-desync=fake --new ^
+--filter-tcp=8080 --dpi-desync=fake --new ^
+--filter-tcp=8443 --dpi-desync=fake --new ^
+--filter-udp=443 --dpi-desync=fake
+`
+
+func TestRunnerStartRollsBackFailedRule(t *testing.T) {
+	tr := newTestRunner(t, rollbackStrategy, "")
+	tr.fw.failAddAt = 3
+
+	err := tr.Start(t.Context())
+	if err == nil {
+		t.Fatal("Start() succeeded, want the third rule to fail")
+	}
+	if !strings.Contains(err.Error(), "malformed port spec") || !strings.Contains(err.Error(), "line 4, tcp 8080") {
+		t.Errorf("Start() error = %v, want the firewall error with the failing rule's line", err)
+	}
+	if ops := tr.fw.takeOps(); !slices.Contains(ops, "remove all") {
+		t.Errorf("firewall operations = %v, want RemoveAll after the failure", ops)
+	}
+	if got := tr.fw.queues(); len(got) != 0 {
+		t.Errorf("firewall rules left after a failed Start: %v", got)
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d processes left after a failed Start", got)
+	}
+	if tr.GetStatus().Running {
+		t.Error("runner reports running after a failed Start")
+	}
+
+	// Nothing left behind gets in the way of the next Start
+	tr.fw.failAddAt = 0
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() after a rollback error = %v", err)
+	}
+	tr.checkConsistent(t)
+	if got := len(tr.fw.queues()); got != 5 {
+		t.Errorf("%d firewall rules installed, want 5", got)
+	}
+}
+
+func TestRunnerStartRollsBackFailedSetup(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	tr.fw.setupErr = errors.New("chain creation failed")
+
+	if err := tr.Start(t.Context()); err == nil || !strings.Contains(err.Error(), "chain creation failed") {
+		t.Fatalf("Start() error = %v, want the setup error", err)
+	}
+	// Setup may have created the table before failing
+	if ops := tr.fw.takeOps(); !slices.Equal(ops, []string{"setup", "remove all"}) {
+		t.Errorf("firewall operations = %v, want setup then remove all", ops)
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d processes started after a failed Setup", got)
+	}
+}
+
+func TestRunnerStartRejectsNotrackConnbytes(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, `firewall:
+  connbytes_limit: 6
+overrides:
+  - protocol: udp
+    notrack: true
+`)
+
+	err := tr.Start(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "line 3: udp 443: notrack can't be combined") {
+		t.Fatalf("Start() error = %v, want the udp rule rejected", err)
+	}
+	if ops := tr.fw.takeOps(); len(ops) != 0 {
+		t.Errorf("firewall operations = %v, want none", ops)
+	}
+
+	// Without a connbytes limit the override applies
+	tr = newTestRunner(t, testStrategy, `overrides:
+  - protocol: udp
+    notrack: true
+`)
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() without connbytes_limit error = %v", err)
+	}
+}
+
+func TestRunnerDesyncFwmark(t *testing.T) {
+	strategy := strings.Replace(testStrategy, "--dpi-desync-repeats=6", "--dpi-desync-repeats=6 --dpi-desync-fwmark=0x2", 1)
+	tr := newTestRunner(t, strategy, `firewall:
+  exclude_mark: "0x1"
+  desync_fwmark: "0x40000000"
+`)
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Every nfqws marks its packets with the mark the rules skip
+	tr.fw.mu.Lock()
+	for queue, rule := range tr.fw.rules {
+		if rule.Mark.Exclude != 0x40000001 {
+			t.Errorf("queue %d rule excludes marks 0x%x, want 0x40000001", queue, rule.Mark.Exclude)
+		}
+	}
+	tr.fw.mu.Unlock()
+	if got := tr.procManager.Count(); got != 2 {
+		t.Fatalf("%d nfqws processes, want 2", got)
+	}
+	for _, p := range tr.procManager.Processes() {
+		var marks []string
+		for _, arg := range processArgv(t, p.PID) {
+			if strings.HasPrefix(arg, desyncMarkOption) {
+				marks = append(marks, arg)
+			}
+		}
+		if !slices.Equal(marks, []string{"--dpi-desync-fwmark=0x40000000"}) {
+			t.Errorf("queue %d nfqws mark options = %q, want only the desync_fwmark", p.QueueNum, marks)
+		}
+	}
+}
+
+func TestRunnerPinnedQueue(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if got := tr.fw.queues(); !slices.Equal(got, []int{0, 1}) {
+		t.Fatalf("queues = %v, want [0 1]", got)
+	}
+
+	// Pinning the tcp rule to the number of the udp rule moves the udp rule
+	tr.writeStrategy(t, strings.Replace(testStrategy, "--filter-tcp=443 ", "--filter-tcp=443 --qnum 1 ", 1))
+	if err := tr.Restart(t.Context()); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	tr.checkConsistent(t)
+	for _, rule := range tr.Rules() {
+		if rule.Protocol == "tcp" && rule.QueueNum != 1 {
+			t.Errorf("tcp rule got queue %d, want its --qnum 1", rule.QueueNum)
+		}
+		if rule.Protocol == "udp" && rule.QueueNum == 1 {
+			t.Error("udp rule kept the pinned queue 1")
+		}
+	}
+	for _, p := range tr.procManager.Processes() {
+		if slices.ContainsFunc(p.Args, func(arg string) bool { return strings.HasPrefix(arg, "--qnum") }) {
+			t.Errorf("queue %d process got the rule's --qnum in %q", p.QueueNum, p.Args)
+		}
+	}
+
+	// Two rules pinning one number fail before anything changes
+	tr.writeStrategy(t, strings.ReplaceAll(testStrategy, "--dpi-desync=fake ", "--dpi-desync=fake --qnum=1 "))
+	if err := tr.Restart(t.Context()); err == nil || !strings.Contains(err.Error(), "lines 2 and 3 both pin queue 1 with --qnum") {
+		t.Errorf("Restart() error = %v, want the conflicting pins named", err)
+	}
+	tr.checkConsistent(t)
+
+	tr.writeStrategy(t, strings.Replace(testStrategy, "--filter-tcp=443 ", "--filter-tcp=443 --qnum=300 ", 1))
+	if err := tr.Restart(t.Context()); err == nil || !strings.Contains(err.Error(), "line 2: --qnum=300 is outside the queue range 0-255") {
+		t.Errorf("Restart() error = %v, want the pin outside the range rejected", err)
+	}
+	tr.checkConsistent(t)
+}
+
+func TestRunnerQueueAutoHostlist(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	list := filepath.Join(tr.dir, "auto.txt")
+	if err := os.WriteFile(list, []byte("known.example\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr.writeStrategy(t, "--filter-tcp=443 --hostlist-auto="+list+" --dpi-desync=fake\n")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// nfqws writes a copy of the list in its queue directory
+	learned := filepath.Join(tr.dir, "run", queueDirsName, "0", queueAutoHostlistName)
+	procs := tr.procManager.Processes()
+	if len(procs) != 1 || !slices.Contains(procs[0].Args, "--hostlist-auto="+learned) {
+		t.Fatalf("process args = %v, want the auto hostlist in the queue directory", procs)
+	}
+	data, err := os.ReadFile(learned)
+	if err != nil || string(data) != "known.example\n" {
+		t.Fatalf("queue auto hostlist = %q, %v, want a copy of the rule's list", data, err)
+	}
+
+	f, err := os.OpenFile(learned, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("learned.example\n")
+	f.Close()
+
+	// A clean stop merges what was learned back
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if data, err := os.ReadFile(list); err != nil || string(data) != "known.example\nlearned.example\n" {
+		t.Errorf("auto hostlist after Stop = %q, %v, want the learned domain added once", data, err)
+	}
+	if _, err := os.Stat(filepath.Dir(learned)); !os.IsNotExist(err) {
+		t.Errorf("queue directory left after Stop: %v", err)
+	}
+}
+
+// processArgv returns the command line of a running process.
+func processArgv(t *testing.T, pid int) []string {
+	t.Helper()
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		t.Skipf("reading the command line: %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+}
+
+// replaceBinary replaces the stub nfqws of tr by script the way a package
+// upgrade does, renaming a new file over it.
+func (tr *testRunner) replaceBinary(t *testing.T, script string) {
+	t.Helper()
+	binary := filepath.Join(tr.dir, "nfqws")
+	if err := os.WriteFile(binary+".new", []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(binary+".new", binary); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunnerCopyRange(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "queue_copy_range: 2048\n")
+	hasCopyRange := func() bool {
+		t.Helper()
+		procs := tr.procManager.Processes()
+		if len(procs) != 2 {
+			t.Fatalf("got %d processes, want 2", len(procs))
+		}
+		return slices.ContainsFunc(processArgv(t, procs[0].PID), func(arg string) bool {
+			return strings.HasPrefix(arg, copyRangeOption)
+		})
+	}
+
+	// The stub doesn't list --copy-range, like stock nfqws
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if hasCopyRange() {
+		t.Errorf("nfqws without %s got the option", copyRangeOption)
+	}
+
+	// An upgraded binary listing the option gets it once restarted
+	tr.replaceBinary(t, strings.Replace(testNFQWS, "--new", "--new "+copyRangeOption, 1))
+	if err := tr.RestartFiltered(t.Context(), nil, true); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if argv := processArgv(t, tr.procManager.Processes()[0].PID); !slices.Contains(argv, copyRangeOption+"=2048") {
+		t.Errorf("nfqws command line = %q, want %s=2048", argv, copyRangeOption)
+	}
+}
+
+func TestProcessManagerChecksCopyRange(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+
+	// A config built for a binary supporting the option, launched after the
+	// binary was replaced by one without it
+	pm := NewProcessManager(filepath.Join(tr.dir, "nfqws"), slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { pm.StopAll() })
+	if err := pm.Start(&ProcessConfig{QueueNum: 7, CopyRange: 2048}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	argv := processArgv(t, pm.Processes()[0].PID)
+	if slices.ContainsFunc(argv, func(arg string) bool { return strings.HasPrefix(arg, copyRangeOption) }) {
+		t.Errorf("nfqws command line = %q, want no %s", argv, copyRangeOption)
+	}
+	if got := pm.CommandLine(&ProcessConfig{QueueNum: 7, CopyRange: 2048}); strings.Contains(got, copyRangeOption) {
+		t.Errorf("CommandLine() = %q, want no %s", got, copyRangeOption)
+	}
+}
+
+func TestRunnerRestartAfterStop(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if err := tr.Restart(t.Context()); !errors.Is(err, ErrRunnerStopped) {
+		t.Errorf("Restart() after Stop error = %v, want %v", err, ErrRunnerStopped)
+	}
+	if tr.GetStatus().Running {
+		t.Error("runner came back up after Stop")
+	}
+}
+
+// closingFirewall is a fake firewall appending its id to closed when closed.
+type closingFirewall struct {
+	*fakeFirewall
+	id     int
+	closed *[]int
+}
+
+func (f closingFirewall) Close() error {
+	*f.closed = append(*f.closed, f.id)
+	return nil
+}
+
+func TestRunnerFullReloadClosesFirewall(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	var made, closed []int
+	newClosing := func(id int, cfg *Config) *firewall.TimedFirewall {
+		fw := closingFirewall{fakeFirewall: tr.fw, id: id, closed: &closed}
+		return firewall.NewTimedFirewall(fw, cfg.Firewall.SlowOpThreshold, tr.logger)
+	}
+	tr.Runner.fw = newClosing(0, tr.Runner.config)
+	tr.makeFirewall = func(cfg *Config, logger *slog.Logger, onReconnect func(error)) (*firewall.TimedFirewall, error) {
+		made = append(made, len(made)+1)
+		return newClosing(len(made), cfg), nil
+	}
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// An in-place reload keeps the firewall
+	if err := tr.RestartFiltered(t.Context(), nil, false); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if len(made) != 0 || len(closed) != 0 {
+		t.Errorf("in-place reload made firewalls %v and closed %v, want none", made, closed)
+	}
+
+	// Each full reload closes the firewall it replaces, never the new one
+	for range 2 {
+		if err := tr.RestartFiltered(t.Context(), nil, true); err != nil {
+			t.Fatalf("Restart() error = %v", err)
+		}
+		if want := made[:len(made)-1]; !slices.Equal(closed, append([]int{0}, want...)) {
+			t.Errorf("closed firewalls %v after making %v, want all but the last", closed, made)
+		}
+	}
+	tr.checkConsistent(t)
+}
+
+// TestRunnerStopRacesReloads stops the runner while reloads are in flight
+// and checks none of them brings rules or processes back. Run it with -race.
+func TestRunnerStopRacesReloads(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			err := tr.RestartFiltered(context.Background(), nil, i%2 == 0)
+			if err != nil && !errors.Is(err, ErrRunnerStopped) && !errors.Is(err, context.Canceled) {
+				t.Errorf("RestartFiltered() error = %v", err)
+			}
+		})
+		wg.Go(func() { tr.TriggerReload("test") })
+	}
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	wg.Wait()
+
+	if tr.GetStatus().Running {
+		t.Error("a reload brought the runner back up after Stop")
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d processes left after Stop", got)
+	}
+	if got := tr.fw.queues(); len(got) != 0 {
+		t.Errorf("firewall rules left after Stop: %v", got)
+	}
+}
+
+// TestRunnerReloadRetriesPartialWrite triggers a reload while the config or
+// strategy file is half written: the reload fails, keeps the running rules
+// and is retried, and the retry applies the file once it is complete.
+func TestRunnerReloadRetriesPartialWrite(t *testing.T) {
+	const settings = "reload_retry_delay: 300ms\nreload_retry_attempts: 3\n"
+	tests := []struct {
+		name      string
+		config    bool // whether the config is written partially, else the strategy file
+		partial   func(tr *testRunner, complete string) string
+		wantRetry string // in the reload_retry event
+	}{
+		{
+			name:      "empty config",
+			config:    true,
+			partial:   func(tr *testRunner, complete string) string { return "" },
+			wantRetry: ErrConfigIncomplete.Error(),
+		},
+		{
+			name:   "config ending in a quoted value",
+			config: true,
+			partial: func(tr *testRunner, complete string) string {
+				return `strategy_file: "` + tr.strategy[:len(tr.strategy)/2]
+			},
+			wantRetry: ErrConfigIncomplete.Error(),
+		},
+		{
+			name:   "config ending in a key",
+			config: true,
+			partial: func(tr *testRunner, complete string) string {
+				return complete[:strings.Index(complete, "lists_path")+5]
+			},
+			wantRetry: "failed to reload config",
+		},
+		{
+			name:      "empty strategy file",
+			partial:   func(tr *testRunner, complete string) string { return "" },
+			wantRetry: "no filter rules found",
+		},
+		{
+			name:      "strategy file ending after the start line",
+			partial:   func(tr *testRunner, complete string) string { return complete[:strings.Index(complete, "\n")+1] },
+			wantRetry: "no filter rules found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestRunner(t, testStrategy, settings)
+			// Triggers after the start are handled at once
+			tr.Runner.config.ReloadCooldown = 0
+			if err := tr.Start(t.Context()); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			pids := tr.runningPIDs()
+			_, cursor := tr.events.Since(0)
+
+			// The complete files have a third rule
+			path, complete := tr.strategy, stressStrategies[2]
+			if tt.config {
+				tr.writeStrategy(t, stressStrategies[2])
+				path = tr.config
+				data, err := os.ReadFile(tr.config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				complete = string(data)
+			}
+			if err := os.WriteFile(path, []byte(tt.partial(tr, complete)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tr.TriggerReload("watcher:test")
+
+			// The first attempt sees the partial file and keeps the rules
+			var events []Event
+			deadline := time.Now().Add(5 * time.Second)
+			for !slices.ContainsFunc(events, func(e Event) bool { return e.Kind == "reload_retry" }) {
+				if time.Now().After(deadline) {
+					t.Fatalf("no reload_retry event, got %+v", events)
+				}
+				time.Sleep(5 * time.Millisecond)
+				events, _ = tr.events.Since(cursor)
+			}
+			if err := os.WriteFile(path, []byte(complete), 0644); err != nil {
+				t.Fatal(err)
+			}
+			retry := events[slices.IndexFunc(events, func(e Event) bool { return e.Kind == "reload_retry" })]
+			if !strings.HasPrefix(retry.Message, "retry 1/3 in 300ms: ") || !strings.Contains(retry.Message, tt.wantRetry) {
+				t.Errorf("reload_retry event = %q, want the first retry for %q", retry.Message, tt.wantRetry)
+			}
+			if got := tr.runningPIDs(); !slices.Equal(got, pids) {
+				t.Errorf("running processes after the failed reload %v, want %v untouched", got, pids)
+			}
+
+			// and the retry applies the complete one
+			deadline = time.Now().Add(5 * time.Second)
+			for len(tr.Rules()) != 3 || len(tr.inconsistencies()) > 0 {
+				if time.Now().After(deadline) {
+					t.Fatalf("%d rules after the retry, want the 3 of the complete file", len(tr.Rules()))
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			tr.checkConsistent(t)
+			var kinds []string
+			events, _ = tr.events.Since(cursor)
+			for _, e := range events {
+				if e.Kind == "reload_failed" || e.Kind == "reload_retry" {
+					kinds = append(kinds, e.Kind)
+				}
+			}
+			if want := []string{"reload_failed", "reload_retry"}; !slices.Equal(kinds, want) {
+				t.Errorf("events %q, want %q once", kinds, want)
+			}
+		})
+	}
+}
+
+func TestConfigWatcherStopCancelsDebounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strategy.yaml")
+	if err := os.WriteFile(path, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	target := WatchTarget{Name: WatchTargetConfig, Path: path, WatchPolicy: WatchPolicy{Debounce: 50 * time.Millisecond}}
+	cw, err := NewConfigWatcher([]WatchTarget{target}, func(WatchTarget) { calls.Add(1) }, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewConfigWatcher() error = %v", err)
+	}
+	if err := cw.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	cw.schedule(target)
+	if err := cw.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	time.Sleep(3 * target.Debounce)
+
+	if n := calls.Load(); n != 0 {
+		t.Errorf("change handled %d times after Stop, want 0", n)
+	}
+}
+
+// blockSetup makes the next Setup of the fake firewall block until the
+// returned release is called. entered is closed once Setup blocks.
+func (tr *testRunner) blockSetup() (entered <-chan struct{}, release func()) {
+	in, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	tr.fw.onSetup = func() {
+		once.Do(func() {
+			close(in)
+			<-unblock
+		})
+	}
+	return in, sync.OnceFunc(func() { close(unblock) })
+}
+
+func TestRunnerStatusFirewallLatency(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if status := tr.GetStatus(); status.FirewallLastOp != 0 || status.FirewallMaxOp != 0 {
+		t.Errorf("latency before any firewall operation = %s, %s, want none", status.FirewallLastOp, status.FirewallMaxOp)
+	}
+	tr.fw.onSetup = func() { time.Sleep(50 * time.Millisecond) }
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The slow Setup is the longest operation; adding a rule came after it
+	status := tr.GetStatus()
+	if status.FirewallMaxOp < 50*time.Millisecond {
+		t.Errorf("FirewallMaxOp = %s, want the 50ms Setup", status.FirewallMaxOp)
+	}
+	if status.FirewallLastOp >= status.FirewallMaxOp {
+		t.Errorf("FirewallLastOp = %s, want the quick AddRule after the slow Setup", status.FirewallLastOp)
+	}
+}
+
+func TestGetStatusDuringSlowReload(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	entered, release := tr.blockSetup()
+	defer release()
+	done := make(chan error, 1)
+	go func() { done <- tr.RestartFiltered(context.Background(), nil, true) }()
+	<-entered
+
+	// The reload holds the runner in Setup; GetStatus returns the status
+	// published at its last phase boundary instead of waiting
+	for range 10 {
+		start := time.Now()
+		status := tr.GetStatus()
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Fatalf("GetStatus() took %v during a reload", elapsed)
+		}
+		if !transitional(status.Phase) {
+			t.Errorf("GetStatus() phase = %q during a reload, want a transitional phase", status.Phase)
+		}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { tr.GetStatus() }); allocs > 2 {
+		t.Errorf("GetStatus() during a reload allocated %v times, want at most a status copy", allocs)
+	}
+
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("RestartFiltered() error = %v", err)
+	}
+	if status := tr.GetStatus(); status.Phase != PhaseRunning || !status.Running {
+		t.Errorf("GetStatus() after the reload = phase %q, running %v", status.Phase, status.Running)
+	}
+}
+
+// BenchmarkGetStatus measures GetStatus on an idle runner and while a slow
+// reload holds it; the latter must not be slower.
+func BenchmarkGetStatus(b *testing.B) {
+	tr := newTestRunner(b, testStrategy, "")
+	if err := tr.Start(b.Context()); err != nil {
+		b.Fatalf("Start() error = %v", err)
+	}
+
+	b.Run("idle", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			tr.GetStatus()
+		}
+	})
+
+	b.Run("reloading", func(b *testing.B) {
+		entered, release := tr.blockSetup()
+		done := make(chan error, 1)
+		go func() { done <- tr.RestartFiltered(context.Background(), nil, true) }()
+		<-entered
+
+		b.ReportAllocs()
+		for b.Loop() {
+			tr.GetStatus()
+		}
+
+		b.StopTimer()
+		release()
+		if err := <-done; err != nil {
+			b.Fatalf("RestartFiltered() error = %v", err)
+		}
+	})
+}
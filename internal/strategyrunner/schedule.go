@@ -0,0 +1,265 @@
+package strategyrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minutesPerDay is the number of minutes in a day; "24:00" ends a window at midnight.
+const minutesPerDay = 24 * 60
+
+// TimeWindow is a daily window in minutes since midnight. A window whose end
+// is before its start crosses midnight, e.g. 22:00-02:00.
+type TimeWindow struct {
+	Start int
+	End   int
+}
+
+// ParseTimeWindow parses a "HH:MM-HH:MM" window.
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: expected HH:MM-HH:MM", s)
+	}
+
+	var w TimeWindow
+	var err error
+	if w.Start, err = parseClock(start); err != nil || w.Start == minutesPerDay {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: bad start time", s)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: bad end time", s)
+	}
+	if w.Start == w.End {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: start equals end", s)
+	}
+	return w, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight, allowing "24:00".
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok || len(hh) != 2 || len(mm) != 2 {
+		return 0, errors.New("expected HH:MM")
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil {
+		return 0, err
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, errors.New("out of range")
+	}
+	return h*60 + m, nil
+}
+
+// Contains reports whether the minute of the day falls into the window.
+func (w TimeWindow) Contains(minute int) bool {
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	// Crosses midnight
+	return minute >= w.Start || minute < w.End
+}
+
+// String returns the window as "HH:MM-HH:MM".
+func (w TimeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// Schedule is a set of daily windows a rule is active in. Overlapping
+// windows are merged implicitly: the rule is active while any window is.
+type Schedule []TimeWindow
+
+// ParseSchedule parses a list of "HH:MM-HH:MM" windows.
+func ParseSchedule(windows []string) (Schedule, error) {
+	schedule := make(Schedule, 0, len(windows))
+	for _, s := range windows {
+		w, err := ParseTimeWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, w)
+	}
+	return schedule, nil
+}
+
+// Active reports whether t falls into any window, in the time zone loc.
+func (s Schedule) Active(t time.Time, loc *time.Location) bool {
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s {
+		if w.Contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextTransition returns when the schedule next switches between active and
+// inactive after t, or the zero time if it never does.
+func (s Schedule) NextTransition(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	year, month, day := local.Date()
+
+	// Transitions can only happen at window boundaries, and one happens
+	// within a day unless the schedule is constant
+	var candidates []time.Time
+	for offset := 0; offset <= 1; offset++ {
+		for _, w := range s {
+			for _, minute := range []int{w.Start, w.End} {
+				c := time.Date(year, month, day+offset, minute/60, minute%60, 0, 0, loc)
+				if c.After(t) {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	current := s.Active(t, loc)
+	for _, c := range candidates {
+		if s.Active(c, loc) != current {
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+// Strings returns the windows as "HH:MM-HH:MM".
+func (s Schedule) Strings() []string {
+	windows := make([]string, len(s))
+	for i, w := range s {
+		windows[i] = w.String()
+	}
+	return windows
+}
+
+// location returns the time zone rule schedules are evaluated in.
+func (r *Runner) location() *time.Location {
+	if r.config.Timezone == "" {
+		return time.Local
+	}
+	// Validated when the config was loaded
+	loc, err := time.LoadLocation(r.config.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// updateSchedule sets whether rule is switched off by its schedule at now and
+// when that changes next. It returns whether the rule's state changed.
+func (r *Runner) updateSchedule(rule *ParsedRule, now time.Time) bool {
+	if len(rule.Schedule) == 0 {
+		return false
+	}
+
+	loc := r.location()
+	off := !rule.Schedule.Active(now, loc)
+	rule.NextTransition = rule.Schedule.NextTransition(now, loc)
+
+	changed := off != rule.ScheduledOff
+	rule.ScheduledOff = off
+	return changed
+}
+
+// hasSchedules reports whether any applied rule has active hours. Caller must hold r.mu.
+func (r *Runner) hasSchedules() bool {
+	for _, rule := range r.rules {
+		if len(rule.Schedule) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// watchSchedule switches scheduled rules at their window boundaries until ctx
+// is cancelled. It compares the wall clock at every check instead of arming
+// timers for the boundaries, so clock jumps can't delay a transition.
+func (r *Runner) watchSchedule(ctx context.Context, interval time.Duration) {
+	ticker := r.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		r.applySchedule(ctx, r.clock.Now())
+	}
+}
+
+// applySchedule adds the firewall rules of scheduled rules entering their
+// active hours and removes those leaving them. nfqws keeps running either way.
+func (r *Runner) applySchedule(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running || ctx.Err() != nil {
+		return
+	}
+
+	changed := 0
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if len(rule.Schedule) == 0 || !rule.active() {
+			continue
+		}
+
+		wasOff := rule.ScheduledOff
+		if !r.updateSchedule(rule, now) {
+			continue
+		}
+
+		if err := r.switchScheduledRule(ctx, *rule); err != nil {
+			// Retried at the next check
+			rule.ScheduledOff = wasOff
+			r.logger.Error("failed to switch scheduled rule",
+				slog.Int("queue", rule.QueueNum),
+				slog.Bool("active", !rule.ScheduledOff),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		state := "active"
+		if rule.ScheduledOff {
+			state = "inactive"
+		}
+		r.logger.Info("scheduled rule switched",
+			slog.Int("queue", rule.QueueNum),
+			slog.Int("line", rule.SourceLine),
+			slog.String("state", state),
+			slog.Time("next_transition", rule.NextTransition),
+		)
+		r.events.Add("rule_scheduled", fmt.Sprintf("queue %d (line %d) %s", rule.QueueNum, rule.SourceLine, state))
+		changed++
+	}
+
+	if changed > 0 {
+		r.writeQueueMap()
+		r.published.Store(r.status())
+	}
+}
+
+// switchScheduledRule adds or removes the firewall rule of rule to match its
+// scheduled state. Caller must hold r.mu.
+func (r *Runner) switchScheduledRule(ctx context.Context, rule ParsedRule) error {
+	if !rule.ScheduledOff {
+		return r.fw.AddRule(ctx, r.convertToFirewallRule(rule))
+	}
+
+	return r.fw.RemoveRule(ctx, r.convertToFirewallRule(rule))
+}
@@ -0,0 +1,270 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns 2026-03-10 at hh:mm in loc.
+func at(hh, mm int, loc *time.Location) time.Time {
+	return time.Date(2026, 3, 10, hh, mm, 0, 0, loc)
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TimeWindow
+		wantErr bool
+	}{
+		{in: "08:00-23:00", want: TimeWindow{Start: 8 * 60, End: 23 * 60}},
+		{in: "22:30-02:15", want: TimeWindow{Start: 22*60 + 30, End: 2*60 + 15}},
+		{in: "18:00-24:00", want: TimeWindow{Start: 18 * 60, End: minutesPerDay}},
+		{in: " 08:00 - 09:00 ", want: TimeWindow{Start: 8 * 60, End: 9 * 60}},
+		{in: "24:00-02:00", wantErr: true},
+		{in: "08:00-08:00", wantErr: true},
+		{in: "08:00", wantErr: true},
+		{in: "8:00-09:00", wantErr: true},
+		{in: "08:60-09:00", wantErr: true},
+		{in: "08:00-24:01", wantErr: true},
+		{in: "25:00-02:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimeWindow(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimeWindow(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimeWindow(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	tests := []struct {
+		name       string
+		windows    []string
+		hh, mm     int
+		wantActive bool
+	}{
+		{"inside", []string{"18:00-23:00"}, 20, 0, true},
+		{"at the start", []string{"18:00-23:00"}, 18, 0, true},
+		{"at the end", []string{"18:00-23:00"}, 23, 0, false},
+		{"before", []string{"18:00-23:00"}, 17, 59, false},
+		{"until midnight", []string{"18:00-24:00"}, 23, 59, true},
+		{"crossing midnight, evening", []string{"22:00-02:00"}, 23, 30, true},
+		{"crossing midnight, after midnight", []string{"22:00-02:00"}, 0, 30, true},
+		{"crossing midnight, at the end", []string{"22:00-02:00"}, 2, 0, false},
+		{"crossing midnight, midday", []string{"22:00-02:00"}, 12, 0, false},
+		{"overlapping, first only", []string{"08:00-12:00", "11:00-14:00"}, 9, 0, true},
+		{"overlapping, both", []string{"08:00-12:00", "11:00-14:00"}, 11, 30, true},
+		{"overlapping, second only", []string{"08:00-12:00", "11:00-14:00"}, 13, 0, true},
+		{"overlapping, after both", []string{"08:00-12:00", "11:00-14:00"}, 14, 0, false},
+		{"overlapping across midnight", []string{"22:00-03:00", "01:00-05:00"}, 4, 0, true},
+		{"between windows", []string{"06:00-08:00", "20:00-22:00"}, 12, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.windows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Active(at(tt.hh, tt.mm, time.UTC), time.UTC); got != tt.wantActive {
+				t.Errorf("Active(%02d:%02d) = %v, want %v", tt.hh, tt.mm, got, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestScheduleNextTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []string
+		now     time.Time
+		want    time.Time
+	}{
+		{
+			name:    "before the window",
+			windows: []string{"18:00-23:00"},
+			now:     at(12, 0, time.UTC),
+			want:    at(18, 0, time.UTC),
+		},
+		{
+			name:    "inside the window",
+			windows: []string{"18:00-23:00"},
+			now:     at(18, 0, time.UTC),
+			want:    at(23, 0, time.UTC),
+		},
+		{
+			name:    "after the window",
+			windows: []string{"18:00-23:00"},
+			now:     at(23, 30, time.UTC),
+			want:    at(18, 0, time.UTC).AddDate(0, 0, 1),
+		},
+		{
+			name:    "crossing midnight, before midnight",
+			windows: []string{"22:00-02:00"},
+			now:     at(23, 0, time.UTC),
+			want:    at(2, 0, time.UTC).AddDate(0, 0, 1),
+		},
+		{
+			name:    "crossing midnight, after midnight",
+			windows: []string{"22:00-02:00"},
+			now:     at(1, 0, time.UTC),
+			want:    at(2, 0, time.UTC),
+		},
+		{
+			name:    "overlapping windows end together",
+			windows: []string{"08:00-12:00", "11:00-14:00"},
+			now:     at(9, 0, time.UTC),
+			want:    at(14, 0, time.UTC),
+		},
+		{
+			name:    "adjacent windows",
+			windows: []string{"08:00-12:00", "12:00-14:00"},
+			now:     at(9, 0, time.UTC),
+			want:    at(14, 0, time.UTC),
+		},
+		{
+			name:    "overlapping across midnight",
+			windows: []string{"22:00-03:00", "01:00-05:00"},
+			now:     at(23, 0, time.UTC),
+			want:    at(5, 0, time.UTC).AddDate(0, 0, 1),
+		},
+		{
+			name:    "always active",
+			windows: []string{"00:00-24:00"},
+			now:     at(12, 0, time.UTC),
+			want:    time.Time{},
+		},
+		{
+			name:    "covering the day across midnight",
+			windows: []string{"12:00-06:00", "06:00-12:00"},
+			now:     at(12, 0, time.UTC),
+			want:    time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.windows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.NextTransition(tt.now, time.UTC); !got.Equal(tt.want) {
+				t.Errorf("NextTransition(%s) = %s, want %s", tt.now.Format(time.DateTime), got.Format(time.DateTime), tt.want.Format(time.DateTime))
+			}
+		})
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	schedule, err := ParseSchedule([]string{"18:00-23:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	// 16:00 UTC is 19:00 in Moscow
+	now := at(16, 0, time.UTC)
+	if schedule.Active(now, time.UTC) {
+		t.Error("Active() in UTC = true at 16:00")
+	}
+	if !schedule.Active(now, moscow) {
+		t.Error("Active() in Moscow = false at 19:00")
+	}
+	if got, want := schedule.NextTransition(now, moscow), at(23, 0, moscow); !got.Equal(want) {
+		t.Errorf("NextTransition() in Moscow = %s, want %s", got, want)
+	}
+}
+
+func TestRunnerScheduleFakeClock(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, `timezone: UTC
+overrides:
+  - protocol: udp
+    active_hours: ["13:00-14:00"]
+`)
+	clk := newFakeClock(at(12, 30, time.UTC))
+	tr.clock = clk
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	var queue int
+	for _, rule := range tr.Rules() {
+		if rule.Protocol == "udp" {
+			queue = rule.QueueNum
+		}
+	}
+	queued := func() bool { return slices.Contains(tr.fw.queues(), queue) }
+	if queued() {
+		t.Fatal("rule queued outside its active hours")
+	}
+
+	// The clock and schedule watchers tick on the fake clock
+	clk.waitTickers(t, 2)
+	tr.fw.takeOps()
+	clk.Advance(30 * time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for !queued() {
+		if time.Now().After(deadline) {
+			t.Fatal("rule not queued when its active hours began")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if ops := tr.fw.takeOps(); !slices.Equal(ops, []string{fmt.Sprintf("add %d", queue)}) {
+		t.Errorf("firewall operations = %v, want the scheduled rule added", ops)
+	}
+	for _, rule := range tr.Rules() {
+		if rule.QueueNum == queue && (rule.ScheduledOff || !rule.NextTransition.Equal(at(14, 0, time.UTC))) {
+			t.Errorf("status of the scheduled rule: off = %v, next transition %s, want on until 14:00", rule.ScheduledOff, rule.NextTransition)
+		}
+	}
+
+	// Stop cancels the scheduler: the end of the window changes nothing
+	if err := tr.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	clk.waitTickers(t, 0)
+	tr.fw.takeOps()
+	clk.Advance(time.Hour)
+	if ops := tr.fw.takeOps(); len(ops) != 0 {
+		t.Errorf("firewall operations after Stop = %v, want none", ops)
+	}
+}
+
+func TestRunnerClockJumpAppliesSchedule(t *testing.T) {
+	tr := newTestRunner(t, testStrategy, `timezone: UTC
+overrides:
+  - protocol: udp
+    active_hours: ["13:00-14:00"]
+`)
+	clk := newFakeClock(at(12, 30, time.UTC))
+	tr.clock = clk
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	started := tr.GetStatus().StartTime
+	tr.fw.takeOps()
+
+	// NTP steps the wall clock into the window without a tick in between
+	clk.mu.Lock()
+	clk.now = at(13, 30, time.UTC)
+	clk.mu.Unlock()
+	tr.onClockJump(time.Hour)
+
+	if ops := tr.fw.takeOps(); len(ops) != 1 || !strings.HasPrefix(ops[0], "add ") {
+		t.Errorf("firewall operations = %v, want the scheduled rule added", ops)
+	}
+	if got := tr.GetStatus().StartTime; !got.Equal(started.Add(time.Hour)) {
+		t.Errorf("StartTime = %s after the jump, want it shifted to %s", got, started.Add(time.Hour))
+	}
+	events, _ := tr.events.Since(0)
+	if !slices.ContainsFunc(events, func(e Event) bool { return e.Kind == "clock_jump" }) {
+		t.Errorf("events = %+v, want the clock jump reported", events)
+	}
+}
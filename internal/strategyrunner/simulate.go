@@ -0,0 +1,220 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+// Operation kinds of a simulated apply.
+const (
+	OpHook     = "hook"
+	OpFirewall = "firewall"
+	OpProcess  = "process"
+)
+
+// Operation is a step a real apply would perform.
+type Operation struct {
+	// Kind is "hook", "firewall" or "process"
+	Kind string
+
+	// Description is the command or action performed
+	Description string
+}
+
+// Simulation is the outcome of validating a candidate strategy against the
+// running configuration.
+type Simulation struct {
+	// Rules are the rules the candidate strategy would apply
+	Rules []ParsedRule
+
+	// Operations lists what a real apply would do, in order (only when simulating)
+	Operations []Operation
+
+	// Warnings are problems that don't prevent applying the strategy
+	Warnings []string
+
+	// EmptyRules are the lines of rules skipped for having no nfqws arguments
+	EmptyRules []int
+}
+
+// ValidateStrategy parses a candidate strategy with the live config, overrides
+// and queue allocations. With simulate set it also plans the operations a real
+// apply would perform. Nothing is applied and no runner state is changed, so
+// it is safe to call while the runner is active.
+func (r *Runner) ValidateStrategy(content []byte, simulate bool) (*Simulation, error) {
+	tmp, err := os.CreateTemp("", "zapret-validate-*.bat")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.simulate(tmp.Name(), simulate)
+}
+
+// simulate validates the strategy file at path like ValidateStrategy. Caller
+// must hold r.mu.
+func (r *Runner) simulate(path string, simulate bool) (*Simulation, error) {
+	strategy, err := r.parser.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse failed: %w", err)
+	}
+	if len(strategy.Rules) == 0 {
+		return nil, errors.New("strategy contains no rules")
+	}
+	resolvePayloads(strategy.Rules, r.config.PayloadsDir)
+
+	if r.config.Dedupe {
+		strategy.Rules = dedupeRules(strategy.Rules, slog.New(slog.DiscardHandler))
+	}
+	strategy.Rules = applyOverrides(strategy.Rules, r.config.Overrides, r.parser.portAliases, slog.New(slog.DiscardHandler))
+	strategy.Rules = applyExclusions(strategy.Rules, r.config.NeverQueue, r.parser.portAliases, slog.New(slog.DiscardHandler))
+	if err := checkOverrides(strategy.Rules, r.config); err != nil {
+		return nil, err
+	}
+	r.applyCompat(strategy.Rules, r.config, r.logger)
+	if !r.externalFirewall() {
+		applyCapabilities(strategy.Rules, r.kernelCaps, r.config.CompatMode, slog.New(slog.DiscardHandler))
+	}
+
+	if err := r.queues.Preview(strategy.Rules, r.config.Queues, r.foreignQueues(r.config)); err != nil {
+		return nil, fmt.Errorf("queue assignment failed: %w", err)
+	}
+
+	sim := &Simulation{Rules: strategy.Rules, EmptyRules: strategy.EmptyRules}
+	for _, line := range strategy.EmptyRules {
+		sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: rule has no nfqws arguments and is skipped", line))
+	}
+	for i := range sim.Rules {
+		rule := &sim.Rules[i]
+		for _, c := range rule.PathConversions {
+			if c.Error == "" {
+				sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: converted Windows path %s=%s to %s",
+					rule.SourceLine, c.Option, c.Original, c.Converted))
+			}
+		}
+		if rule.CompatError != "" {
+			sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: rule would fail: %s", rule.SourceLine, rule.CompatError))
+		}
+		if len(rule.StrippedArgs) > 0 {
+			sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: rule would run degraded without %v", rule.SourceLine, rule.StrippedArgs))
+		}
+		rule.MissingFiles = missingFiles(*rule)
+		if len(rule.MissingFiles) > 0 {
+			sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: rule would be pending, missing files: %v",
+				rule.SourceLine, rule.MissingFiles))
+		}
+		rule.PayloadIssues = r.parseCache.payloadIssues(*rule)
+		for _, issue := range rule.PayloadIssues {
+			sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: %s", rule.SourceLine, issue))
+		}
+		if rule.active() && !r.externalProcesses() {
+			for _, issue := range copyRangeIssues(*rule, r.processCopyRange()) {
+				sim.Warnings = append(sim.Warnings, fmt.Sprintf("line %d: %s", rule.SourceLine, issue))
+			}
+		}
+	}
+
+	if simulate {
+		sim.Operations = r.planApply(sim.Rules)
+	}
+	return sim, nil
+}
+
+// planApply returns the operations start would perform for rules, following
+// the same steps. Caller must hold r.mu.
+func (r *Runner) planApply(rules []ParsedRule) []Operation {
+	var ops []Operation
+	add := func(kind, format string, args ...any) {
+		ops = append(ops, Operation{Kind: kind, Description: fmt.Sprintf(format, args...)})
+	}
+
+	hooks := func(phase string) {
+		for _, hook := range r.config.Hooks.phase(phase) {
+			add(OpHook, "run %s hook: %s", phase, hook.Command)
+		}
+	}
+
+	// Stopping the current strategy comes first when it is running
+	if r.running {
+		hooks(HookPreStop)
+		if !r.externalFirewall() {
+			add(OpFirewall, "remove %s table %q", r.config.Firewall.Backend, r.config.Firewall.TableName)
+		}
+		if !r.externalProcesses() {
+			add(OpProcess, "stop %d nfqws processes", r.procManager.Count())
+		}
+		hooks(HookPostStop)
+	}
+
+	// 1. Hooks after parsing
+	hooks(HookPreStart)
+
+	// 2-3. Firewall setup and rules
+	if !r.externalFirewall() {
+		add(OpFirewall, "set up %s table %q chain %q",
+			r.config.Firewall.Backend, r.config.Firewall.TableName, r.config.Firewall.ChainName)
+		for _, rule := range rules {
+			if !rule.active() {
+				continue
+			}
+			commands, err := r.fw.Render(r.convertToFirewallRule(rule))
+			if errors.Is(err, firewall.ErrRenderUnsupported) || (err == nil && len(commands) == 0) {
+				add(OpFirewall, "add rule %s %s -> queue %d", rule.Protocol, rule.Ports, rule.QueueNum)
+				continue
+			}
+			if err != nil {
+				add(OpFirewall, "add rule %s %s -> queue %d (render failed: %v)", rule.Protocol, rule.Ports, rule.QueueNum, err)
+				continue
+			}
+			for _, cmd := range commands {
+				add(OpFirewall, "%s", cmd)
+			}
+		}
+	}
+
+	// 4. Processes
+	if !r.externalProcesses() {
+		var stats *StatsClassifier
+		if r.config.Process.CollectStats {
+			stats = &StatsClassifier{}
+		}
+		for _, rule := range rules {
+			if !rule.active() {
+				continue
+			}
+			add(OpProcess, "start %s", r.procManager.CommandLine(r.planProcess(rule, stats)))
+		}
+	}
+
+	hooks(HookPostStart)
+	return ops
+}
+
+// planProcess returns the process config start uses for rule.
+func (r *Runner) planProcess(rule ParsedRule, stats *StatsClassifier) *ProcessConfig {
+	return &ProcessConfig{
+		QueueNum:  rule.QueueNum,
+		Args:      parseNFQWSArgs(rule.NFQWSArgs),
+		CopyRange: r.processCopyRange(),
+		Stats:     stats,
+		Namespace: r.config.NetworkNamespace,
+
+		DesyncMark:  r.processDesyncMark(),
+		MemoryLimit: r.processMemoryLimit(rule),
+	}
+}
@@ -0,0 +1,158 @@
+package strategyrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/strategyrunner/firewall"
+)
+
+// SnapshotFields selects the parts of a snapshot to collect.
+type SnapshotFields uint
+
+const (
+	SnapshotStatus SnapshotFields = 1 << iota
+	SnapshotRules
+	SnapshotCounters
+	SnapshotProcesses
+	SnapshotHealth
+	SnapshotReloads
+	SnapshotEvents
+
+	// SnapshotAll selects every part of the snapshot
+	SnapshotAll = SnapshotStatus | SnapshotRules | SnapshotCounters | SnapshotProcesses |
+		SnapshotHealth | SnapshotReloads | SnapshotEvents
+)
+
+// Health states reported in snapshots.
+const (
+	HealthStopped   = "stopped"
+	HealthHealthy   = "healthy"
+	HealthDegraded  = "degraded"
+	HealthReloading = "reloading"
+)
+
+// Snapshot is a consistent view of the runner state taken under a single lock.
+type Snapshot struct {
+	TakenAt time.Time
+
+	Status    *Status
+	Rules     []ParsedRule
+	Processes []ProcessInfo
+	Health    string
+	Reloads   []ReloadRecord
+
+	// HealthReason explains a degraded health
+	HealthReason string
+
+	// Counters are keyed by queue number
+	Counters map[int]firewall.Counter
+
+	// CountersErr is set when counters were requested but could not be read
+	CountersErr error
+
+	// Events are the events after the requested cursor
+	Events []Event
+
+	// EventCursor is the cursor to pass to get only newer events
+	EventCursor uint64
+}
+
+// Snapshot collects the selected parts of the runner state. Rules, processes and
+// counters all come from the same instant, so their counts always agree.
+// eventsSince is the cursor returned by a previous snapshot (0 for all events).
+// While a start, stop or reload holds the runner, only the published status,
+// the "reloading" health and the events are filled in, so polling never waits
+// for a reload.
+func (r *Runner) Snapshot(ctx context.Context, fields SnapshotFields, eventsSince uint64) *Snapshot {
+	if !r.mu.TryRLock() {
+		return r.transitionSnapshot(fields, eventsSince)
+	}
+	defer r.mu.RUnlock()
+
+	snap := &Snapshot{TakenAt: time.Now()}
+
+	if fields&SnapshotStatus != 0 {
+		snap.Status = r.status()
+	}
+	if fields&SnapshotRules != 0 {
+		snap.Rules = make([]ParsedRule, len(r.rules))
+		copy(snap.Rules, r.rules)
+	}
+	if fields&(SnapshotProcesses|SnapshotHealth) != 0 {
+		processes := r.procManager.Processes()
+		if fields&SnapshotProcesses != 0 {
+			snap.Processes = processes
+		}
+		if fields&SnapshotHealth != 0 {
+			snap.Health, snap.HealthReason = r.health(r.consumers(r.procManager.Count()))
+		}
+	}
+	if fields&SnapshotCounters != 0 && r.running {
+		if r.externalFirewall() {
+			snap.CountersErr = errors.New("firewall management is external")
+		} else {
+			snap.Counters, snap.CountersErr = r.fw.Counters(ctx)
+		}
+	}
+	if fields&SnapshotReloads != 0 {
+		snap.Reloads = make([]ReloadRecord, len(r.reloadHistory))
+		copy(snap.Reloads, r.reloadHistory)
+	}
+	if fields&SnapshotEvents != 0 {
+		snap.Events, snap.EventCursor = r.events.Since(eventsSince)
+	}
+
+	return snap
+}
+
+// transitionSnapshot returns the parts of a snapshot available without the
+// runner lock, from the status published at the last phase boundary.
+func (r *Runner) transitionSnapshot(fields SnapshotFields, eventsSince uint64) *Snapshot {
+	snap := &Snapshot{TakenAt: time.Now()}
+	status := r.publishedStatus()
+
+	if fields&SnapshotStatus != 0 {
+		snap.Status = status
+	}
+	if fields&SnapshotHealth != 0 {
+		// Outside of transitions the lock is only held briefly, e.g. while a
+		// pending rule is activated; report the state of the last phase
+		switch {
+		case transitional(status.Phase):
+			snap.Health = HealthReloading
+		case status.Running:
+			snap.Health = HealthHealthy
+		default:
+			snap.Health = HealthStopped
+		}
+	}
+	if fields&SnapshotEvents != 0 {
+		snap.Events, snap.EventCursor = r.events.Since(eventsSince)
+	}
+	return snap
+}
+
+// health derives the health state and the reason it is degraded from the
+// rules, the number of queues served by nfqws (-1 if unknown) and whether
+// the fallback strategy is applied. Caller must hold r.mu.
+func (r *Runner) health(served int) (string, string) {
+	if !r.running {
+		return HealthStopped, ""
+	}
+	if r.emptyRuleset {
+		if r.config.EmptyRulesetPolicy == EmptyRulesetAllow {
+			return HealthHealthy, ""
+		}
+		return HealthDegraded, emptyRulesetReason
+	}
+	if r.strategySource == StrategySourceFallback {
+		return HealthDegraded, "fallback strategy applied"
+	}
+	if served >= 0 && served < len(r.rules) {
+		return HealthDegraded, fmt.Sprintf("%d of %d queues not served by nfqws", len(r.rules)-served, len(r.rules))
+	}
+	return HealthHealthy, ""
+}
@@ -0,0 +1,82 @@
+package strategyrunner
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/statepaths"
+)
+
+// StateConfig contains the directories runtime state is kept in.
+type StateConfig struct {
+	// Dir keeps state that should survive reboots (queue numbers, hostlist validators)
+	Dir string `yaml:"dir" env:"ZAPRET_STATE_DIR" env-default:"/etc/zapret-ng/state"`
+
+	// VolatileDir keeps state that only needs to survive daemon restarts. It is
+	// also used for persistent state when Dir is not writable (read-only root).
+	VolatileDir string `yaml:"volatile_dir" env:"ZAPRET_STATE_VOLATILE_DIR" env-default:"/run/zapret"`
+}
+
+// stateFile is a state file of a feature.
+type stateFile struct {
+	feature string
+	kind    statepaths.Kind
+	path    *string
+}
+
+// stateFiles returns the state files of cfg. Firewall and process state and
+// the queue map are volatile because rules, processes and queues don't
+// survive a reboot.
+func stateFiles(cfg *Config) []stateFile {
+	return []stateFile{
+		{"queue numbers", statepaths.Persistent, &cfg.Queues.StateFile},
+		{"hostlist update state", statepaths.Persistent, &cfg.HostlistUpdate.StateFile},
+		{"changelog", statepaths.Persistent, &cfg.Changelog.File},
+		{"firewall state", statepaths.Volatile, &cfg.Firewall.StateFile},
+		{"process state", statepaths.Volatile, &cfg.Process.StateFile},
+		{"queue map", statepaths.Volatile, &cfg.QueueMapFile},
+	}
+}
+
+// resolveStatePaths places the relative state file names of cfg in the state
+// directories, falling back to volatile-only state when the persistent
+// directory is not writable, and logs which state features are active.
+func resolveStatePaths(cfg *Config, logger *slog.Logger, level slog.Level) {
+	layout := statepaths.Probe(cfg.State.VolatileDir, cfg.State.Dir)
+
+	if !layout.PersistentWritable {
+		logger.Warn("persistent state directory is not writable, keeping state in the volatile directory only",
+			slog.String("dir", cfg.State.Dir),
+			slog.String("volatile_dir", cfg.State.VolatileDir),
+		)
+	}
+	if !layout.VolatileWritable {
+		logger.Warn("volatile state directory is not writable", slog.String("dir", cfg.State.VolatileDir))
+	}
+
+	for _, f := range stateFiles(cfg) {
+		if *f.path == "" {
+			logger.Log(context.Background(), level, "state feature disabled", slog.String("feature", f.feature))
+			continue
+		}
+
+		path, kind := layout.Resolve(f.kind, *f.path)
+		*f.path = path
+
+		switch {
+		case path == "":
+			logger.Warn("state feature disabled, no writable state directory", slog.String("feature", f.feature))
+		case kind != f.kind:
+			logger.Warn("state feature is volatile, it won't survive a reboot",
+				slog.String("feature", f.feature),
+				slog.String("path", path),
+			)
+		default:
+			logger.Log(context.Background(), level, "state feature active",
+				slog.String("feature", f.feature),
+				slog.String("path", path),
+				slog.String("storage", kind.String()),
+			)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testStateConfig returns a config with every state feature enabled.
+func testStateConfig(volatileDir, persistentDir string) *Config {
+	cfg := &Config{QueueMapFile: "queues.json"}
+	cfg.State.VolatileDir = volatileDir
+	cfg.State.Dir = persistentDir
+	cfg.Queues.StateFile = "queue-numbers.json"
+	cfg.HostlistUpdate.StateFile = "hostlist-state.json"
+	cfg.Changelog.File = "changelog.jsonl"
+	cfg.Firewall.StateFile = "firewall-state.json"
+	cfg.Process.StateFile = "processes.json"
+	return cfg
+}
+
+// unwritableDir returns a state directory that cannot be created, even by
+// root, standing in for one on a read-only root filesystem.
+func unwritableDir(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "rootfs")
+	if err := os.WriteFile(file, nil, 0444); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(file, "etc", "zapret-ng", "state")
+}
+
+func TestResolveStatePaths(t *testing.T) {
+	volatile := filepath.Join(t.TempDir(), "run")
+	persistent := filepath.Join(t.TempDir(), "state")
+
+	cfg := testStateConfig(volatile, persistent)
+	cfg.Process.StateFile = ""
+	cfg.Changelog.File = "/var/log/zapret/changelog.jsonl"
+	resolveStatePaths(cfg, slog.New(slog.DiscardHandler), slog.LevelInfo)
+
+	tests := []struct {
+		feature string
+		got     string
+		want    string
+	}{
+		{"queue numbers", cfg.Queues.StateFile, filepath.Join(persistent, "queue-numbers.json")},
+		{"hostlist update state", cfg.HostlistUpdate.StateFile, filepath.Join(persistent, "hostlist-state.json")},
+		{"changelog", cfg.Changelog.File, "/var/log/zapret/changelog.jsonl"},
+		{"firewall state", cfg.Firewall.StateFile, filepath.Join(volatile, "firewall-state.json")},
+		{"process state", cfg.Process.StateFile, ""},
+		{"queue map", cfg.QueueMapFile, filepath.Join(volatile, "queues.json")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s path = %q, want %q", tt.feature, tt.got, tt.want)
+		}
+	}
+}
+
+func TestResolveStatePathsReadOnlyRoot(t *testing.T) {
+	volatile := filepath.Join(t.TempDir(), "run")
+	cfg := testStateConfig(volatile, unwritableDir(t))
+
+	var log bytes.Buffer
+	resolveStatePaths(cfg, slog.New(slog.NewTextHandler(&log, nil)), slog.LevelInfo)
+
+	// Persistent state degrades to the volatile directory instead of failing
+	for _, path := range []string{cfg.Queues.StateFile, cfg.HostlistUpdate.StateFile, cfg.Changelog.File} {
+		if filepath.Dir(path) != volatile {
+			t.Errorf("persistent state file %q is not in the volatile directory %s", path, volatile)
+		}
+	}
+	if want := filepath.Join(volatile, "firewall-state.json"); cfg.Firewall.StateFile != want {
+		t.Errorf("firewall state path = %q, want %q", cfg.Firewall.StateFile, want)
+	}
+
+	out := log.String()
+	if !strings.Contains(out, "persistent state directory is not writable") {
+		t.Errorf("log misses the read-only warning:\n%s", out)
+	}
+	if n := strings.Count(out, "it won't survive a reboot"); n != 3 {
+		t.Errorf("logged %d volatile fallbacks, want 3:\n%s", n, out)
+	}
+}
+
+func TestResolveStatePathsNothingWritable(t *testing.T) {
+	cfg := testStateConfig(unwritableDir(t), unwritableDir(t))
+	resolveStatePaths(cfg, slog.New(slog.DiscardHandler), slog.LevelInfo)
+
+	for _, f := range stateFiles(cfg) {
+		if *f.path != "" {
+			t.Errorf("%s path = %q, want the feature disabled", f.feature, *f.path)
+		}
+	}
+}
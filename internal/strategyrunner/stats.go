@@ -0,0 +1,112 @@
+package strategyrunner
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync/atomic"
+)
+
+// Stat names used as keys in process.stats_patterns.
+const (
+	StatDesyncApplied         = "desync_applied"
+	StatHostlistHits          = "hostlist_hits"
+	StatAutoHostlistAdditions = "autohostlist_additions"
+	StatParseErrors           = "parse_errors"
+)
+
+// defaultStatsPatterns classify nfqws --debug output lines.
+// nfqws output changes between versions, so each can be replaced in the config.
+var defaultStatsPatterns = map[string]string{
+	StatDesyncApplied:         `^sending |^dpi desync mode `,
+	StatHostlistHits:          `^hostlist check .*: positive`,
+	StatAutoHostlistAdditions: `^auto hostlist.*: adding `,
+	StatParseErrors:           `(?i)parse error|cannot parse|invalid packet`,
+}
+
+// QueueStats contains desync statistics of a single nfqws process.
+type QueueStats struct {
+	DesyncApplied         uint64
+	HostlistHits          uint64
+	AutoHostlistAdditions uint64
+	ParseErrors           uint64
+}
+
+// statCounters are the live counters of a process, updated from its output.
+type statCounters struct {
+	values [4]atomic.Uint64
+}
+
+// snapshot returns the current counter values.
+func (c *statCounters) snapshot() QueueStats {
+	return QueueStats{
+		DesyncApplied:         c.values[0].Load(),
+		HostlistHits:          c.values[1].Load(),
+		AutoHostlistAdditions: c.values[2].Load(),
+		ParseErrors:           c.values[3].Load(),
+	}
+}
+
+// statIndex maps stat names to counter slots.
+var statIndex = map[string]int{
+	StatDesyncApplied:         0,
+	StatHostlistHits:          1,
+	StatAutoHostlistAdditions: 2,
+	StatParseErrors:           3,
+}
+
+// StatsClassifier classifies nfqws output lines into stat counters.
+type StatsClassifier struct {
+	patterns [4]*regexp.Regexp
+}
+
+// NewStatsClassifier compiles the default patterns with the given replacements.
+func NewStatsClassifier(overrides map[string]string) (*StatsClassifier, error) {
+	c := &StatsClassifier{}
+
+	for name := range overrides {
+		if _, ok := statIndex[name]; !ok {
+			return nil, fmt.Errorf("unknown stat %q (must be one of: %s)", name, statNames())
+		}
+	}
+
+	for name, idx := range statIndex {
+		pattern := defaultStatsPatterns[name]
+		if p, ok := overrides[name]; ok {
+			pattern = p
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s: %w", name, err)
+		}
+		c.patterns[idx] = re
+	}
+
+	return c, nil
+}
+
+// classify increments the counter matching line, if any. A line counts towards
+// at most one stat. Most output lines match nothing, so a cheap byte check
+// skips blank lines before any pattern runs.
+func (c *StatsClassifier) classify(line []byte, counters *statCounters) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	for i, re := range c.patterns {
+		if re.Match(line) {
+			counters.values[i].Add(1)
+			return
+		}
+	}
+}
+
+// statNames returns the known stat names in sorted order.
+func statNames() []string {
+	names := make([]string, 0, len(statIndex))
+	for name := range statIndex {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
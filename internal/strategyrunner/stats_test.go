@@ -0,0 +1,90 @@
+package strategyrunner
+
+import (
+	"strings"
+	"testing"
+)
+
+// classifyLines are nfqws --debug output lines and the stat each counts towards.
+var classifyLines = []struct {
+	line string
+	stat string // "" for none
+}{
+	{line: "sending fake request : 1 bytes", stat: StatDesyncApplied},
+	{line: "dpi desync mode fake,split2", stat: StatDesyncApplied},
+	{line: "hostlist check for discord.com : positive", stat: StatHostlistHits},
+	{line: "auto hostlist (profile 1) : adding discord.gg", stat: StatAutoHostlistAdditions},
+	{line: "cannot parse TLS ClientHello", stat: StatParseErrors},
+	{line: "packet: id=1 len=52 mark=00000000 ifout=2(eth0)", stat: ""},
+	{line: "TCP: fixing checksum", stat: ""},
+	{line: "   ", stat: ""},
+}
+
+func TestClassifyLine(t *testing.T) {
+	c, err := NewStatsClassifier(nil)
+	if err != nil {
+		t.Fatalf("NewStatsClassifier() error = %v", err)
+	}
+	for _, tt := range classifyLines {
+		var counters statCounters
+		c.classify([]byte(tt.line), &counters)
+		want := make([]uint64, 4)
+		if tt.stat != "" {
+			want[statIndex[tt.stat]] = 1
+		}
+		for i := range want {
+			if got := counters.values[i].Load(); got != want[i] {
+				t.Errorf("classify(%q) counter %d = %d, want %d", tt.line, i, got, want[i])
+			}
+		}
+	}
+
+	// Unknown lines are skipped without allocating
+	unknown := []byte("packet: id=1 len=52 mark=00000000 ifout=2(eth0)")
+	var counters statCounters
+	if allocs := testing.AllocsPerRun(100, func() { c.classify(unknown, &counters) }); allocs != 0 {
+		t.Errorf("classify() of an unknown line allocated %v times, want 0", allocs)
+	}
+}
+
+func TestNewStatsClassifierOverrides(t *testing.T) {
+	c, err := NewStatsClassifier(map[string]string{StatHostlistHits: `^HIT `})
+	if err != nil {
+		t.Fatalf("NewStatsClassifier() error = %v", err)
+	}
+	var counters statCounters
+	c.classify([]byte("HIT discord.com"), &counters)
+	if got := counters.snapshot().HostlistHits; got != 1 {
+		t.Errorf("HostlistHits = %d with the replaced pattern, want 1", got)
+	}
+
+	if _, err := NewStatsClassifier(map[string]string{"hits": "x"}); err == nil || !strings.Contains(err.Error(), `unknown stat "hits"`) {
+		t.Errorf("NewStatsClassifier() error = %v, want the unknown stat named", err)
+	}
+	if _, err := NewStatsClassifier(map[string]string{StatParseErrors: "("}); err == nil || !strings.Contains(err.Error(), "invalid pattern for parse_errors") {
+		t.Errorf("NewStatsClassifier() error = %v, want the invalid pattern named", err)
+	}
+}
+
+func BenchmarkClassifyLine(b *testing.B) {
+	c, err := NewStatsClassifier(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bm := range []struct {
+		name string
+		line string
+	}{
+		{name: "known", line: "hostlist check for discord.com : positive"},
+		{name: "unknown", line: "packet: id=1 len=52 mark=00000000 ifout=2(eth0)"},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			line := []byte(bm.line)
+			var counters statCounters
+			b.ReportAllocs()
+			for b.Loop() {
+				c.classify(line, &counters)
+			}
+		})
+	}
+}
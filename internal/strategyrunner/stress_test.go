@@ -0,0 +1,318 @@
+package strategyrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// stressStrategies are rewritten into the strategy file during the stress
+// run: the test strategy, the same rules with changed arguments, and an
+// additional rule.
+var stressStrategies = []string{
+	testStrategy,
+	`start "zapret" /min "%BIN%winws.exe" --wf-tcp=443 --wf-udp=443 ^
+--filter-tcp=443 --dpi-desync=fake --dpi-desync-repeats=4 --new ^
+--filter-udp=443 --dpi-desync=fake --dpi-desync-repeats=2
+`,
+	`start "zapret" /min "%BIN%winws.exe" --wf-tcp=80,443 --wf-udp=443 ^
+--filter-tcp=443 --dpi-desync=fake --dpi-desync-repeats=6 --new ^
+--filter-tcp=80 --dpi-desync=fake --new ^
+--filter-udp=443 --dpi-desync=fake --dpi-desync-repeats=2
+`,
+}
+
+// stressSettings restart killed processes quickly and without giving up.
+const stressSettings = `process:
+  restart_max_retries: 1000
+  restart_backoff_max: 1s
+`
+
+// TestRunnerStress runs the runner while restarts, watcher triggers, strategy
+// rewrites, process kills, suspends, Stop and Start and all kinds of reads
+// happen concurrently, then checks the bookkeeping is consistent and no
+// goroutine outlives Stop. Run it with -race.
+func TestRunnerStress(t *testing.T) {
+	duration := 3 * time.Second
+	if testing.Short() {
+		duration = 500 * time.Millisecond
+	}
+
+	ignore := goleak.IgnoreCurrent()
+	tr := newTestRunner(t, testStrategy, stressSettings)
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), duration)
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		ops atomic.Int64
+	)
+	// loop runs op with the given pause until the stress run ends
+	loop := func(pause time.Duration, op func(rng *rand.Rand)) {
+		wg.Go(func() {
+			rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+			for ctx.Err() == nil {
+				op(rng)
+				ops.Add(1)
+				time.Sleep(time.Duration(rng.Int64N(int64(pause) + 1)))
+			}
+		})
+	}
+
+	// Writers
+	for range 2 {
+		loop(20*time.Millisecond, func(rng *rand.Rand) {
+			err := tr.RestartFiltered(context.Background(), nil, rng.IntN(2) == 0)
+			if err != nil && !errors.Is(err, ErrRunnerStopped) && !errors.Is(err, ErrNotRunning) && !errors.Is(err, context.Canceled) {
+				t.Errorf("RestartFiltered() error = %v", err)
+			}
+		})
+	}
+	loop(10*time.Millisecond, func(rng *rand.Rand) { tr.TriggerReload("stress") })
+	loop(30*time.Millisecond, func(rng *rand.Rand) {
+		// Replace the file the way editors do, a reload never sees it half written
+		tmp := tr.strategy + ".tmp"
+		if err := os.WriteFile(tmp, []byte(stressStrategies[rng.IntN(len(stressStrategies))]), 0644); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := os.Rename(tmp, tr.strategy); err != nil {
+			t.Error(err)
+		}
+	})
+	loop(30*time.Millisecond, func(rng *rand.Rand) {
+		procs := tr.procManager.Processes()
+		if len(procs) == 0 {
+			return
+		}
+		// Crash the whole process group, as the stub runs sleep in a child
+		if p := procs[rng.IntN(len(procs))]; p.Running && p.PID > 0 {
+			killGroup(p.PID)
+		}
+	})
+	loop(50*time.Millisecond, func(rng *rand.Rand) {
+		if _, _, err := tr.SuspendProcesses(time.Second); err == nil {
+			time.Sleep(time.Duration(rng.IntN(20)) * time.Millisecond)
+			tr.ResumeProcesses()
+		}
+	})
+	loop(200*time.Millisecond, func(rng *rand.Rand) {
+		if err := tr.Stop(context.Background()); err != nil && !errors.Is(err, ErrNotRunning) {
+			t.Errorf("Stop() error = %v", err)
+		}
+		time.Sleep(time.Duration(rng.IntN(20)) * time.Millisecond)
+		if err := tr.Start(context.Background()); err != nil && !errors.Is(err, ErrAlreadyRunning) {
+			t.Errorf("Start() error = %v", err)
+		}
+	})
+
+	// Readers
+	loop(5*time.Millisecond, func(rng *rand.Rand) {
+		for _, problem := range statusProblems(tr.GetStatus()) {
+			t.Error(problem)
+		}
+	})
+	loop(5*time.Millisecond, func(rng *rand.Rand) {
+		snap := tr.Snapshot(context.Background(), SnapshotAll, uint64(rng.IntN(50)))
+		if snap.Status != nil {
+			for _, problem := range statusProblems(snap.Status) {
+				t.Error(problem)
+			}
+		}
+		for _, problem := range duplicateQueues(snap.Rules) {
+			t.Error(problem)
+		}
+	})
+	loop(5*time.Millisecond, func(rng *rand.Rand) {
+		for _, problem := range duplicateQueues(tr.Rules()) {
+			t.Error(problem)
+		}
+	})
+	loop(20*time.Millisecond, func(rng *rand.Rand) {
+		tr.RunDiagnostics()
+		tr.KernelQueues()
+		tr.Changelog(time.Time{}, 0, 10)
+	})
+
+	wg.Wait()
+	t.Logf("%d operations in %v", ops.Load(), duration)
+
+	// Let the runner settle: a last reload may still be pending and killed
+	// processes are restarted after their backoff
+	tr.ResumeProcesses()
+	if err := tr.Start(context.Background()); err != nil && !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("Start() after the stress run error = %v", err)
+	}
+	var problems []string
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		problems = tr.inconsistencies()
+		if len(problems) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+
+	if err := tr.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if got := tr.procManager.Count(); got != 0 {
+		t.Errorf("%d processes left after Stop", got)
+	}
+	if got := tr.fw.queues(); len(got) != 0 {
+		t.Errorf("firewall rules left after Stop: %v", got)
+	}
+	goleak.VerifyNone(t, ignore)
+}
+
+// statusProblems describes impossible values in status.
+func statusProblems(status *Status) []string {
+	var problems []string
+	for name, v := range map[string]int{
+		"ActiveQueues":    status.ActiveQueues,
+		"ActiveProcesses": status.ActiveProcesses,
+		"PhaseDone":       status.PhaseDone,
+		"PhaseTotal":      status.PhaseTotal,
+		"PendingRules":    status.PendingRules,
+		"FilteredRules":   status.FilteredRules,
+		"FirewallRules":   status.FirewallRules,
+	} {
+		if v < 0 {
+			problems = append(problems, fmt.Sprintf("status %s = %d", name, v))
+		}
+	}
+	if status.PhaseDone > status.PhaseTotal {
+		problems = append(problems, fmt.Sprintf("status phase %d/%d", status.PhaseDone, status.PhaseTotal))
+	}
+	for _, p := range status.Processes {
+		if p.Restarts < 0 {
+			problems = append(problems, fmt.Sprintf("queue %d restarts = %d", p.QueueNum, p.Restarts))
+		}
+	}
+	return problems
+}
+
+// duplicateQueues describes queue numbers shared by active rules.
+func duplicateQueues(rules []ParsedRule) []string {
+	var problems []string
+	seen := make(map[int]bool)
+	for _, rule := range rules {
+		if !rule.active() {
+			continue
+		}
+		if seen[rule.QueueNum] {
+			problems = append(problems, fmt.Sprintf("queue %d is used by two rules", rule.QueueNum))
+		}
+		seen[rule.QueueNum] = true
+	}
+	return problems
+}
+
+// TestGetStatusDuringReloads reads the status while reloads alternate between
+// strategies of two and three rules and checks every read describes one of
+// them completely: a status outside a phase transition never mixes the rules,
+// firewall and processes of two strategies. Run it with -race.
+func TestGetStatusDuringReloads(t *testing.T) {
+	reloads := 8
+	if testing.Short() {
+		reloads = 4
+	}
+
+	tr := newTestRunner(t, stressStrategies[1], "")
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		reads    atomic.Int64
+		mu       sync.Mutex
+		versions = make(map[string]int) // queues seen for each config version
+	)
+	wg.Go(func() {
+		defer cancel()
+		for i := range reloads {
+			// Strategies 1 and 2 have two and three rules
+			if err := os.WriteFile(tr.strategy, []byte(stressStrategies[1+i%2]), 0644); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := tr.RestartFiltered(context.Background(), nil, i%3 == 0); err != nil {
+				t.Errorf("RestartFiltered() error = %v", err)
+				return
+			}
+			// Give the readers a running state between the reloads
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+
+	// check describes how status is not one whole strategy
+	check := func(status *Status) []string {
+		problems := statusProblems(status)
+		if transitional(status.Phase) {
+			return problems
+		}
+		if !status.Running {
+			return append(problems, fmt.Sprintf("phase %s with the runner stopped", status.Phase))
+		}
+		if status.ActiveQueues != 2 && status.ActiveQueues != 3 {
+			problems = append(problems, fmt.Sprintf("%d active queues, want 2 or 3", status.ActiveQueues))
+		}
+		if status.FirewallRules != status.ActiveQueues || status.ActiveProcesses != status.ActiveQueues {
+			problems = append(problems, fmt.Sprintf("%d queues with %d firewall rules and %d processes",
+				status.ActiveQueues, status.FirewallRules, status.ActiveProcesses))
+		}
+		queues := make(map[int]bool)
+		for _, p := range status.Processes {
+			if p.Running {
+				queues[p.QueueNum] = true
+			}
+		}
+		if len(queues) != status.ActiveQueues {
+			problems = append(problems, fmt.Sprintf("%d queues with processes on %d of them", status.ActiveQueues, len(queues)))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if n, ok := versions[status.ConfigVersion]; ok && n != status.ActiveQueues {
+			problems = append(problems, fmt.Sprintf("config version %s with %d queues, seen with %d",
+				status.ConfigVersion, status.ActiveQueues, n))
+		}
+		versions[status.ConfigVersion] = status.ActiveQueues
+		return problems
+	}
+	for range 4 {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				for _, problem := range check(tr.GetStatus()) {
+					t.Error(problem)
+				}
+				reads.Add(1)
+			}
+		})
+	}
+
+	wg.Wait()
+	t.Logf("%d reads during %d reloads", reads.Load(), reloads)
+	if len(versions) < 2 {
+		t.Errorf("statuses of %d config versions read, want both strategies", len(versions))
+	}
+}
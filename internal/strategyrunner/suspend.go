@@ -0,0 +1,129 @@
+package strategyrunner
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// processSuspension is a suspension of the nfqws processes, ended by
+// ResumeProcesses, by its timer or by the runner stopping.
+type processSuspension struct {
+	until time.Time
+	timer clockTimer
+}
+
+// SuspendProcesses stops all nfqws processes with SIGSTOP, e.g. so a backup
+// gets the CPU. Unlike stopping the runner the firewall rules stay: queued
+// packets wait for nfqws and bypass it once the kernel queue is full. The
+// processes are resumed after timeout (process.suspend_timeout if 0) so a
+// forgotten suspend can't last. It returns how many processes were stopped
+// and when they will be resumed.
+func (r *Runner) SuspendProcesses(timeout time.Duration) (int, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running {
+		return 0, time.Time{}, errors.New("strategy runner is not running")
+	}
+	if r.externalProcesses() {
+		return 0, time.Time{}, errors.New("nfqws processes are managed externally")
+	}
+	if timeout <= 0 {
+		timeout = r.config.Process.SuspendTimeout
+	}
+
+	n, err := r.procManager.Suspend()
+	if n == 0 && err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to suspend processes: %w", err)
+	}
+
+	// A repeated suspend extends the deadline
+	if r.suspension != nil {
+		r.suspension.timer.Stop()
+	}
+	suspension := &processSuspension{until: r.clock.Now().Add(timeout)}
+	suspension.timer = r.clock.AfterFunc(timeout, func() { r.autoResume(suspension) })
+	r.suspension = suspension
+
+	r.logger.Info("nfqws processes suspended",
+		slog.Int("processes", n),
+		slog.Duration("timeout", timeout),
+	)
+	r.events.Add("processes_suspended", fmt.Sprintf("%d processes, resume at %s", n, suspension.until.Format(time.RFC3339)))
+	return n, suspension.until, err
+}
+
+// ResumeProcesses continues the nfqws processes with SIGCONT and cancels the
+// automatic resume. Processes stopped by a SIGSTOP from elsewhere are
+// continued too. It returns how many processes were signalled.
+func (r *Runner) ResumeProcesses() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running {
+		return 0, errors.New("strategy runner is not running")
+	}
+	if r.externalProcesses() {
+		return 0, errors.New("nfqws processes are managed externally")
+	}
+	return r.resumeProcesses("resumed")
+}
+
+// autoResume resumes the processes when suspension times out, unless it was
+// ended or replaced meanwhile.
+func (r *Runner) autoResume(suspension *processSuspension) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.suspension != suspension {
+		return
+	}
+	r.logger.Warn("suspend timeout reached, resuming nfqws processes")
+	if _, err := r.resumeProcesses("resumed automatically after the suspend timeout"); err != nil {
+		r.logger.Warn("failed to resume nfqws processes", slog.Any("error", err))
+	}
+}
+
+// resumeProcesses continues the processes and ends the suspension. Caller
+// must hold r.mu.
+func (r *Runner) resumeProcesses(reason string) (int, error) {
+	r.endSuspension()
+
+	n, err := r.procManager.Resume()
+	r.logger.Info("nfqws processes resumed", slog.Int("processes", n))
+	r.events.Add("processes_resumed", fmt.Sprintf("%d processes %s", n, reason))
+	if err != nil {
+		return n, fmt.Errorf("failed to resume processes: %w", err)
+	}
+	return n, nil
+}
+
+// endSuspension cancels the automatic resume. Caller must hold r.mu.
+func (r *Runner) endSuspension() {
+	if r.suspension != nil {
+		r.suspension.timer.Stop()
+		r.suspension = nil
+	}
+}
+
+// suspendedUntil returns when suspended processes will be resumed, zero if
+// none are suspended. Caller must hold r.mu.
+func (r *Runner) suspendedUntil() time.Time {
+	if r.suspension == nil {
+		return time.Time{}
+	}
+	return r.suspension.until
+}
+
+// suspendedQueues returns the queues whose nfqws process is stopped.
+func (r *Runner) suspendedQueues() []int {
+	var queues []int
+	for _, info := range r.procManager.Processes() {
+		if info.Suspended {
+			queues = append(queues, info.QueueNum)
+		}
+	}
+	return queues
+}
@@ -0,0 +1,131 @@
+package strategyrunner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startSuspendRunner starts a test runner on a fake clock.
+func startSuspendRunner(t *testing.T) (*testRunner, *fakeClock) {
+	t.Helper()
+	tr := newTestRunner(t, testStrategy, "")
+	clk := newFakeClock(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC))
+	tr.clock = clk
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	return tr, clk
+}
+
+// checkSuspended checks that every nfqws process is stopped, or none is.
+func checkSuspended(t *testing.T, tr *testRunner, want bool) {
+	t.Helper()
+	procs := tr.procManager.Processes()
+	if len(procs) != 2 {
+		t.Fatalf("%d processes, want 2", len(procs))
+	}
+	for _, proc := range procs {
+		if proc.Suspended != want {
+			t.Errorf("queue %d: Suspended = %v, want %v", proc.QueueNum, proc.Suspended, want)
+		}
+		// The kernel stops and continues the process asynchronously
+		deadline := time.Now().Add(5 * time.Second)
+		for processStopped(proc.PID) != want {
+			if time.Now().After(deadline) {
+				t.Errorf("queue %d: process stopped = %v, want %v", proc.QueueNum, !want, want)
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// resumeEvents returns the messages of the processes_resumed events.
+func resumeEvents(tr *testRunner) []string {
+	events, _ := tr.events.Since(0)
+	var messages []string
+	for _, e := range events {
+		if e.Kind == "processes_resumed" {
+			messages = append(messages, e.Message)
+		}
+	}
+	return messages
+}
+
+func TestRunnerSuspendAutoResume(t *testing.T) {
+	tr, clk := startSuspendRunner(t)
+	queues := tr.fw.queues()
+
+	n, until, err := tr.SuspendProcesses(10 * time.Minute)
+	if err != nil || n != 2 {
+		t.Fatalf("SuspendProcesses() = %d, %v, want 2 processes", n, err)
+	}
+	if want := clk.Now().Add(10 * time.Minute); !until.Equal(want) {
+		t.Errorf("resume at %s, want %s", until, want)
+	}
+	checkSuspended(t, tr, true)
+	if got := tr.fw.queues(); !slices.Equal(got, queues) {
+		t.Errorf("firewall rules for queues %v while suspended, want %v kept", got, queues)
+	}
+
+	// A repeated suspend replaces the deadline
+	clk.Advance(5 * time.Minute)
+	if _, until, err = tr.SuspendProcesses(10 * time.Minute); err != nil {
+		t.Fatalf("SuspendProcesses() error = %v", err)
+	}
+	if got := tr.GetStatus().SuspendedUntil; !got.Equal(until) {
+		t.Errorf("SuspendedUntil = %s, want %s", got, until)
+	}
+	if got := clk.pendingTimers(); got != 1 {
+		t.Errorf("%d resume timers pending, want 1", got)
+	}
+	clk.Advance(9 * time.Minute)
+	checkSuspended(t, tr, true)
+	if got := resumeEvents(tr); len(got) != 0 {
+		t.Fatalf("resumed %v before the extended deadline", got)
+	}
+
+	// The timer resumes the processes at the deadline
+	clk.Advance(time.Minute)
+	checkSuspended(t, tr, false)
+	if got := tr.GetStatus().SuspendedUntil; !got.IsZero() {
+		t.Errorf("SuspendedUntil = %s after the automatic resume, want zero", got)
+	}
+	if got := resumeEvents(tr); len(got) != 1 || !strings.Contains(got[0], "automatically") {
+		t.Errorf("resume events = %q, want one automatic resume", got)
+	}
+	if got := tr.fw.queues(); !slices.Equal(got, queues) {
+		t.Errorf("firewall rules for queues %v after the resume, want %v", got, queues)
+	}
+}
+
+func TestRunnerResumeCancelsTimer(t *testing.T) {
+	tr, clk := startSuspendRunner(t)
+
+	// Without a timeout the configured one applies
+	_, until, err := tr.SuspendProcesses(0)
+	if err != nil {
+		t.Fatalf("SuspendProcesses() error = %v", err)
+	}
+	if want := clk.Now().Add(tr.Runner.config.Process.SuspendTimeout); !until.Equal(want) {
+		t.Errorf("resume at %s, want after the suspend timeout at %s", until, want)
+	}
+	checkSuspended(t, tr, true)
+
+	n, err := tr.ResumeProcesses()
+	if err != nil || n != 2 {
+		t.Fatalf("ResumeProcesses() = %d, %v, want 2 processes", n, err)
+	}
+	checkSuspended(t, tr, false)
+	if got := clk.pendingTimers(); got != 0 {
+		t.Errorf("%d resume timers pending after the resume, want 0", got)
+	}
+
+	// The cancelled timer resumes nothing
+	clk.Advance(time.Hour)
+	if got := resumeEvents(tr); len(got) != 1 || strings.Contains(got[0], "automatically") {
+		t.Errorf("resume events = %q, want only the explicit resume", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package strategyrunner
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals stopping and continuing a process.
+var (
+	sigStop os.Signal = syscall.SIGSTOP
+	sigCont os.Signal = syscall.SIGCONT
+)
@@ -0,0 +1,11 @@
+//go:build windows
+
+package strategyrunner
+
+import "os"
+
+// Signals stopping and continuing a process; Windows has neither.
+var (
+	sigStop os.Signal
+	sigCont os.Signal
+)
@@ -0,0 +1,9 @@
+@echo off
+:: GameFilter in every position a port list can hold it
+set "BIN=%~dp0bin\"
+set "LISTS=%~dp0lists\"
+start "zapret: gamefilter" /min "%BIN%winws.exe" --wf-tcp=80,443,%GameFilter% --wf-udp=443,%GameFilter% ^
+--filter-tcp=443,%GameFilter% --ipset="%LISTS%ipset-all.txt" --dpi-desync=fake,multidisorder --dpi-desync-split-pos=midsld --new ^
+--filter-tcp=%GameFilter%,80 --ipset="%LISTS%ipset-all.txt" --dpi-desync=fake,split2 --dpi-desync-fooling=md5sig --new ^
+--filter-udp=%GameFilter% --ipset="%LISTS%ipset-all.txt" --dpi-desync=fake --dpi-desync-any-protocol=1 --dpi-desync-cutoff=n2 --new ^
+--filter-udp=443,%GameFilter%,50000-50100 --ipset="%LISTS%ipset-all.txt" --dpi-desync=fake --dpi-desync-repeats=6
@@ -0,0 +1,21 @@
+@echo off
+rem Every --filter block on its own continued line, CRLF line endings,
+rem trailing blanks after ^ and comments between continued lines
+set "BIN=%~dp0bin\"
+set "LISTS=%~dp0lists\"
+
+start "zapret: multiline" /min "%BIN%winws.exe" --wf-tcp=80,443 --wf-udp=443 ^  
+:: YouTube QUIC
+--filter-udp=443 --hostlist="%LISTS%list-youtube.txt" ^
+  --dpi-desync=fake --dpi-desync-repeats=11 ^	
+  --dpi-desync-fake-quic="%BIN%quic_initial_www_google_com.bin" --new ^
+:: YouTube TLS, split over three lines
+--filter-tcp=443 ^
+  --hostlist="%LISTS%list-youtube.txt" ^
+  --dpi-desync=multisplit --dpi-desync-split-seqovl=681 --dpi-desync-split-pos=1 ^
+  --dpi-desync-split-seqovl-pattern="%BIN%tls_clienthello_www_google_com.bin" --new ^
+rem plain HTTP
+--filter-tcp=80 --hostlist="%LISTS%list-general.txt" ^
+  --dpi-desync=fake,split2 --dpi-desync-autottl=2 --dpi-desync-fooling=md5sig
+
+--filter-tcp=8080 --hostlist="%LISTS%list-general.txt" --dpi-desync=fake ^
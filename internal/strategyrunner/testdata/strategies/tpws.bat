@@ -0,0 +1,7 @@
+@echo off
+:: winws for QUIC next to tpws entries for HTTP and TLS, as in the
+:: router ports of the strategies
+set "BIN=%~dp0bin\"
+set "LISTS=%~dp0lists\"
+start "zapret: winws" /min "%BIN%winws.exe" --wf-udp=443 --filter-udp=443 --hostlist="%LISTS%list-general.txt" --dpi-desync=fake --dpi-desync-repeats=6
+start "zapret: tpws" /min "%BIN%tpws.exe" --port=988 --filter-tcp=80 --hostlist="%LISTS%list-general.txt" --methodeol --new --filter-tcp=443 --hostlist="%LISTS%list-general.txt" --split-pos=1,midsld --disorder
@@ -0,0 +1,142 @@
+package strategyrunner
+
+import (
+	"log/slog"
+	"path/filepath"
+	"syscall"
+)
+
+// watchTargets returns the files and directories watched for changes.
+func (r *Runner) watchTargets() []WatchTarget {
+	// The apply marker comes first: it lives in the directory of the config
+	// file, which may also be the directory watched for the strategy file
+	targets := []WatchTarget{r.applyTarget()}
+	if r.config.ConfigPath != "" {
+		targets = append(targets, WatchTarget{
+			Name:        WatchTargetConfig,
+			Path:        r.config.ConfigPath,
+			WatchPolicy: r.config.WatchTargets.Config,
+		})
+	}
+	strategy := WatchTarget{
+		Name:        WatchTargetStrategy,
+		Path:        r.config.StrategyFile,
+		WatchPolicy: r.config.WatchTargets.Strategy,
+	}
+	if r.strategySource == StrategySourceFallback {
+		// A missing file can't be watched; watch its directory to replace
+		// the fallback strategy as soon as the file is created
+		strategy.Path = filepath.Dir(r.config.StrategyFile)
+		strategy.Dir = true
+	}
+	targets = append(targets,
+		strategy,
+		WatchTarget{
+			Name:        WatchTargetLists,
+			Path:        r.config.ListsPath,
+			Dir:         true,
+			WatchPolicy: r.config.WatchTargets.Lists,
+		},
+	)
+	return targets
+}
+
+// startWatcher starts watching the targets. Caller must hold r.mu.
+func (r *Runner) startWatcher() {
+	targets := r.watchTargets()
+
+	// Remember the contents the runner started with, for the diff policy
+	digests := make(map[string]string, len(targets))
+	for _, target := range targets {
+		if target.Policy != PolicyDiff {
+			continue
+		}
+		digest, err := watchDigest(target)
+		if err != nil {
+			r.logger.Debug("failed to digest watched path", slog.String("path", target.Path), slog.Any("error", err))
+			continue
+		}
+		digests[target.Name] = digest
+	}
+	r.watchMu.Lock()
+	r.watchDigests = digests
+	r.watchMu.Unlock()
+
+	r.logger.Info("starting config file watcher", slog.Int("targets", len(targets)))
+	watcher, err := NewConfigWatcher(targets, r.onWatchEvent, r.logger)
+	if err != nil {
+		r.logger.Warn("failed to create config watcher", slog.Any("error", err))
+		r.watchFallback = true
+		return
+	}
+	r.watcher = watcher
+	if err := r.watcher.Start(); err != nil {
+		r.logger.Warn("failed to start config watcher", slog.Any("error", err))
+		r.watchFallback = true
+	}
+}
+
+// onWatchEvent handles a debounced change of target according to its policy.
+// Reloads wait for the apply set to settle, unless the apply marker was created.
+func (r *Runner) onWatchEvent(target WatchTarget) {
+	if target.Name == WatchTargetApply {
+		r.onApplyMarker(target)
+		return
+	}
+
+	switch target.Policy {
+	case PolicySignal:
+		r.signalLists()
+		return
+
+	case PolicyDiff:
+		if !r.watchContentChanged(target) {
+			r.logger.Info("watched content unchanged, skipping reload", slog.String("target", target.Name))
+			return
+		}
+	}
+
+	r.applySet.changed("watcher:" + target.Name)
+}
+
+// watchContentChanged reports whether the contents of target differ from the
+// last seen ones and records the new contents. Unreadable contents count as changed.
+func (r *Runner) watchContentChanged(target WatchTarget) bool {
+	digest, err := watchDigest(target)
+	if err != nil {
+		r.logger.Debug("failed to digest watched path", slog.String("path", target.Path), slog.Any("error", err))
+		return true
+	}
+
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+
+	if r.watchDigests[target.Name] == digest {
+		return false
+	}
+	if r.watchDigests == nil {
+		// Polling without the watcher
+		r.watchDigests = make(map[string]string)
+	}
+	r.watchDigests[target.Name] = digest
+	return true
+}
+
+// signalLists sends SIGHUP to the nfqws processes so they reread their hostlists.
+func (r *Runner) signalLists() {
+	r.mu.RLock()
+	external := r.externalProcesses()
+	r.mu.RUnlock()
+
+	if external {
+		r.logger.Info("hostlists changed, nfqws is managed externally and must reread them itself")
+		return
+	}
+
+	r.logger.Info("hostlists changed, signalling nfqws to reread them", slog.Int("processes", r.procManager.Count()))
+	if err := r.procManager.Signal(syscall.SIGHUP); err != nil {
+		r.logger.Warn("failed to signal nfqws processes", slog.Any("error", err))
+	}
+	r.hostlists.Reset()
+	r.events.Add("hostlist_reread", "sent SIGHUP to nfqws")
+}
@@ -1,51 +1,193 @@
 package strategyrunner
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
-// ConfigWatcher watches for changes to the strategy config file.
+// Watch trigger policies.
+const (
+	// PolicyReload restarts the runner on every change
+	PolicyReload = "reload"
+
+	// PolicyDiff restarts the runner only when the watched content actually changed
+	PolicyDiff = "diff"
+
+	// PolicySignal sends SIGHUP to nfqws so it rereads its hostlists, without a restart
+	PolicySignal = "signal"
+)
+
+// Watch target names.
+const (
+	WatchTargetConfig   = "config"
+	WatchTargetStrategy = "strategy"
+	WatchTargetLists    = "lists"
+
+	// WatchTargetApply watches for the apply marker
+	WatchTargetApply = "apply"
+)
+
+// WatchConfig contains per-target file watcher settings.
+type WatchConfig struct {
+	// Config applies to the strategy YAML config file
+	Config WatchPolicy `yaml:"config"`
+
+	// Strategy applies to the .bat strategy file
+	Strategy WatchPolicy `yaml:"strategy"`
+
+	// Lists applies to the hostlist directory
+	Lists WatchPolicy `yaml:"lists"`
+
+	// QuietPeriod defers reloads until the config and strategy files were
+	// unchanged for this long, so changes spanning both are applied at once.
+	// 0 reloads as soon as a target's debounce passed.
+	QuietPeriod time.Duration `yaml:"quiet_period"`
+}
+
+// WatchPolicy controls how changes of a watched target are handled.
+type WatchPolicy struct {
+	// Debounce merges changes within this period into one trigger
+	Debounce time.Duration `yaml:"debounce"`
+
+	// Policy is "reload", "diff" or "signal"
+	Policy string `yaml:"policy"`
+}
+
+// defaultWatchConfig returns the watcher settings used when none are configured.
+func defaultWatchConfig() WatchConfig {
+	return WatchConfig{
+		Config:   WatchPolicy{Debounce: time.Second, Policy: PolicyReload},
+		Strategy: WatchPolicy{Debounce: time.Second, Policy: PolicyReload},
+		Lists:    WatchPolicy{Debounce: 10 * time.Second, Policy: PolicySignal},
+
+		QuietPeriod: 2 * time.Second,
+	}
+}
+
+// Validate validates the watcher configuration.
+func (c *WatchConfig) Validate() error {
+	if c.QuietPeriod < 0 {
+		return fmt.Errorf("quiet_period must not be negative")
+	}
+
+	targets := []struct {
+		name   string
+		policy WatchPolicy
+	}{
+		{WatchTargetConfig, c.Config},
+		{WatchTargetStrategy, c.Strategy},
+		{WatchTargetLists, c.Lists},
+	}
+
+	for _, t := range targets {
+		if t.policy.Debounce < 0 {
+			return fmt.Errorf("%s: debounce must not be negative", t.name)
+		}
+		switch t.policy.Policy {
+		case PolicyReload, PolicyDiff:
+		case PolicySignal:
+			// nfqws only rereads hostlists on SIGHUP, not its arguments
+			if t.name != WatchTargetLists {
+				return fmt.Errorf("%s: policy %q only applies to lists", t.name, PolicySignal)
+			}
+		default:
+			return fmt.Errorf("%s: invalid policy %q (must be %q, %q or %q)",
+				t.name, t.policy.Policy, PolicyReload, PolicyDiff, PolicySignal)
+		}
+	}
+	return nil
+}
+
+// WatchTarget is a watched file or directory and how its changes are handled.
+type WatchTarget struct {
+	// Name is "config", "strategy", "lists" or "apply"
+	Name string
+
+	// Path is the watched file or directory
+	Path string
+
+	// Dir reports whether changes of any file in Path count
+	Dir bool
+
+	WatchPolicy
+}
+
+// matches reports whether a change of name concerns the target.
+func (t WatchTarget) matches(name string) bool {
+	name = filepath.Clean(name)
+	if t.Name == WatchTargetApply {
+		return name == filepath.Join(filepath.Clean(t.Path), ApplyMarker)
+	}
+	if !t.Dir {
+		return name == filepath.Clean(t.Path)
+	}
+	return filepath.Dir(name) == filepath.Clean(t.Path)
+}
+
+// ConfigWatcher watches the strategy config, the strategy file and the
+// hostlist directory, debouncing changes of each target separately.
 type ConfigWatcher struct {
-	watcher    *fsnotify.Watcher
-	configPath string
-	onChange   func()
-	debounce   time.Duration
-	stopCh     chan struct{}
-	logger     *slog.Logger
+	watcher  *fsnotify.Watcher
+	targets  []WatchTarget
+	onChange func(WatchTarget)
+	stopCh   chan struct{}
+	logger   *slog.Logger
+
+	// mu guards timers, the pending debounced onChange calls keyed by target name
+	mu     sync.Mutex
+	timers map[string]*time.Timer
 }
 
-// NewConfigWatcher creates a new config watcher.
-func NewConfigWatcher(path string, onChange func(), logger *slog.Logger) (*ConfigWatcher, error) {
+// NewConfigWatcher creates a watcher for targets. Targets that can't be
+// watched are logged and skipped; it fails only if none can be watched.
+func NewConfigWatcher(targets []WatchTarget, onChange func(WatchTarget), logger *slog.Logger) (*ConfigWatcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create fsnotify watcher: %w", err)
 	}
 
-	// Watch the config file directory (not the file itself, for better compatibility)
-	if err := watcher.Add(path); err != nil {
+	var watched []WatchTarget
+	var errs []error
+	for _, target := range targets {
+		if err := watcher.Add(target.Path); err != nil {
+			logger.Warn("failed to watch path",
+				slog.String("target", target.Name),
+				slog.String("path", target.Path),
+				slog.Any("error", err),
+			)
+			errs = append(errs, fmt.Errorf("%s: %w", target.Name, err))
+			continue
+		}
+		watched = append(watched, target)
+	}
+	if len(watched) == 0 {
 		watcher.Close()
-		return nil, fmt.Errorf("failed to watch config file: %w", err)
+		return nil, fmt.Errorf("failed to watch any path: %w", errors.Join(errs...))
 	}
 
 	return &ConfigWatcher{
-		watcher:    watcher,
-		configPath: path,
-		onChange:   onChange,
-		debounce:   1 * time.Second,
-		stopCh:     make(chan struct{}),
-		logger:     logger,
+		watcher:  watcher,
+		targets:  watched,
+		onChange: onChange,
+		stopCh:   make(chan struct{}),
+		logger:   logger,
+		timers:   make(map[string]*time.Timer),
 	}, nil
 }
 
-// Start begins watching for config file changes.
+// Start begins watching for changes.
 func (cw *ConfigWatcher) Start() error {
 	go func() {
-		var debounceTimer *time.Timer
-
 		for {
 			select {
 			case event, ok := <-cw.watcher.Events:
@@ -53,24 +195,18 @@ func (cw *ConfigWatcher) Start() error {
 					return
 				}
 
-				// Only care about Write events
-				if event.Op&fsnotify.Write == fsnotify.Write {
-					cw.logger.Info("config file change detected",
-						slog.String("path", event.Name),
-						slog.String("op", event.Op.String()),
-					)
-
-					// Reset debounce timer
-					if debounceTimer != nil {
-						debounceTimer.Stop()
-					}
-
-					debounceTimer = time.AfterFunc(cw.debounce, func() {
-						cw.logger.Info("triggering strategy runner restart due to config change")
-						cw.onChange()
-					})
+				target, ok := cw.target(event)
+				if !ok {
+					continue
 				}
 
+				cw.logger.Debug("watched file change detected",
+					slog.String("target", target.Name),
+					slog.String("path", event.Name),
+					slog.String("op", event.Op.String()),
+				)
+				cw.schedule(target)
+
 			case err, ok := <-cw.watcher.Errors:
 				if !ok {
 					return
@@ -87,8 +223,104 @@ func (cw *ConfigWatcher) Start() error {
 	return nil
 }
 
-// Stop stops watching for config file changes.
+// target returns the target an event concerns, if it is a relevant change.
+// Files only count writes; in directories, files being replaced or removed count too.
+func (cw *ConfigWatcher) target(event fsnotify.Event) (WatchTarget, bool) {
+	for _, target := range cw.targets {
+		if !target.matches(event.Name) {
+			continue
+		}
+
+		relevant := fsnotify.Write
+		if target.Name == WatchTargetApply {
+			// Removing the marker after handling it is no change
+			return target, event.Op&(fsnotify.Create|fsnotify.Write) != 0
+		}
+		if target.Dir {
+			// Skip editor swap files and temp files of atomic replaces
+			if base := filepath.Base(event.Name); strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") {
+				return WatchTarget{}, false
+			}
+			relevant |= fsnotify.Create | fsnotify.Remove | fsnotify.Rename
+		}
+		return target, event.Op&relevant != 0
+	}
+	return WatchTarget{}, false
+}
+
+// schedule (re)starts the debounce timer of target.
+func (cw *ConfigWatcher) schedule(target WatchTarget) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if timer := cw.timers[target.Name]; timer != nil {
+		timer.Stop()
+	}
+
+	cw.timers[target.Name] = time.AfterFunc(target.Debounce, func() {
+		// Stop may have won the race against the timer
+		select {
+		case <-cw.stopCh:
+			return
+		default:
+		}
+
+		cw.logger.Info("handling watched file change",
+			slog.String("target", target.Name),
+			slog.String("policy", target.Policy),
+		)
+		cw.onChange(target)
+	})
+}
+
+// Stop stops watching for changes and cancels pending debounced changes.
 func (cw *ConfigWatcher) Stop() error {
 	close(cw.stopCh)
+
+	cw.mu.Lock()
+	for name, timer := range cw.timers {
+		timer.Stop()
+		delete(cw.timers, name)
+	}
+	cw.mu.Unlock()
+
 	return cw.watcher.Close()
 }
+
+// watchDigest returns a digest of the contents of target, so a no-op save
+// can be told apart from a real change.
+func watchDigest(target WatchTarget) (string, error) {
+	if target.Name == WatchTargetApply {
+		target = WatchTarget{Path: filepath.Join(target.Path, ApplyMarker)}
+	}
+	if !target.Dir {
+		data, err := os.ReadFile(target.Path)
+		if err != nil {
+			return "", err
+		}
+		return digestBytes(data), nil
+	}
+
+	entries, err := os.ReadDir(target.Path)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(target.Path, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, entry.Name()+"="+digestBytes(data))
+	}
+	return digestBytes([]byte(strings.Join(parts, "\n"))), nil
+}
+
+// digestBytes returns the hex SHA-256 of data.
+func digestBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
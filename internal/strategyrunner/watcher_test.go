@@ -0,0 +1,356 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(c *WatchConfig)
+		wantErr string
+	}{
+		{name: "defaults", edit: func(c *WatchConfig) {}},
+		{name: "diff everywhere", edit: func(c *WatchConfig) {
+			c.Config.Policy, c.Strategy.Policy, c.Lists.Policy = PolicyDiff, PolicyDiff, PolicyDiff
+		}},
+		{name: "reload lists", edit: func(c *WatchConfig) { c.Lists.Policy = PolicyReload }},
+		{name: "no debounce", edit: func(c *WatchConfig) { c.Strategy.Debounce = 0 }},
+		{
+			name:    "signal for the strategy",
+			edit:    func(c *WatchConfig) { c.Strategy.Policy = PolicySignal },
+			wantErr: `strategy: policy "signal" only applies to lists`,
+		},
+		{
+			name:    "signal for the config",
+			edit:    func(c *WatchConfig) { c.Config.Policy = PolicySignal },
+			wantErr: `config: policy "signal" only applies to lists`,
+		},
+		{
+			name:    "unknown policy",
+			edit:    func(c *WatchConfig) { c.Lists.Policy = "restart" },
+			wantErr: `lists: invalid policy "restart" (must be "reload", "diff" or "signal")`,
+		},
+		{
+			name:    "no policy",
+			edit:    func(c *WatchConfig) { c.Config.Policy = "" },
+			wantErr: `config: invalid policy ""`,
+		},
+		{
+			name:    "negative debounce",
+			edit:    func(c *WatchConfig) { c.Lists.Debounce = -time.Second },
+			wantErr: "lists: debounce must not be negative",
+		},
+		{
+			name:    "negative quiet period",
+			edit:    func(c *WatchConfig) { c.QuietPeriod = -time.Second },
+			wantErr: "quiet_period must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultWatchConfig()
+			tt.edit(&c)
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigWatcherTarget(t *testing.T) {
+	dir := filepath.Join("/etc", "zapret-ng")
+	lists := filepath.Join(dir, "lists")
+	cw := &ConfigWatcher{targets: []WatchTarget{
+		{Name: WatchTargetApply, Path: dir},
+		{Name: WatchTargetConfig, Path: filepath.Join(dir, "strategy.yaml")},
+		{Name: WatchTargetStrategy, Path: filepath.Join(dir, "general.bat")},
+		{Name: WatchTargetLists, Path: lists, Dir: true},
+	}}
+
+	tests := []struct {
+		name string
+		op   fsnotify.Op
+		want string // the target, "" for none
+	}{
+		{name: filepath.Join(dir, "strategy.yaml"), op: fsnotify.Write, want: WatchTargetConfig},
+		{name: filepath.Join(dir, "general.bat"), op: fsnotify.Write, want: WatchTargetStrategy},
+		{name: filepath.Join(dir, ApplyMarker), op: fsnotify.Create, want: WatchTargetApply},
+		// Files only count writes
+		{name: filepath.Join(dir, "general.bat"), op: fsnotify.Chmod},
+		{name: filepath.Join(dir, "strategy.yaml"), op: fsnotify.Remove},
+		{name: filepath.Join(dir, "other.yaml"), op: fsnotify.Write},
+		// Lists count files written, created, removed and renamed
+		{name: filepath.Join(lists, "list-general.txt"), op: fsnotify.Write, want: WatchTargetLists},
+		{name: filepath.Join(lists, "list-general.txt"), op: fsnotify.Create, want: WatchTargetLists},
+		{name: filepath.Join(lists, "list-general.txt"), op: fsnotify.Remove, want: WatchTargetLists},
+		{name: filepath.Join(lists, "list-general.txt"), op: fsnotify.Rename, want: WatchTargetLists},
+		{name: filepath.Join(lists, "list-general.txt"), op: fsnotify.Chmod},
+		// but not editor swap files or backups
+		{name: filepath.Join(lists, ".list-general.txt.swp"), op: fsnotify.Write},
+		{name: filepath.Join(lists, "list-general.txt~"), op: fsnotify.Create},
+		// or files in subdirectories
+		{name: filepath.Join(lists, "old", "list-general.txt"), op: fsnotify.Write},
+	}
+
+	for _, tt := range tests {
+		target, ok := cw.target(fsnotify.Event{Name: tt.name, Op: tt.op})
+		got := ""
+		if ok {
+			got = target.Name
+		}
+		if got != tt.want {
+			t.Errorf("target(%s %s) = %q, want %q", tt.op, tt.name, got, tt.want)
+		}
+	}
+}
+
+// hupNFQWS returns a stub nfqws that appends its PID to the file hups
+// whenever it receives SIGHUP, and to ready once it handles the signal.
+func hupNFQWS(hups, ready string) string {
+	// Sleep in the background, so the trap runs as soon as the signal arrives
+	loop := fmt.Sprintf("trap 'echo $$ >> %s' HUP\necho $$ >> %s\nwhile :; do sleep 1 & wait $!; done", hups, ready)
+	return strings.Replace(testNFQWS, "while :; do sleep 1; done", loop, 1)
+}
+
+// readPIDs returns the PIDs recorded in path, sorted.
+func readPIDs(t *testing.T, path string) []int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pids []int
+	for _, line := range strings.Fields(string(data)) {
+		pid, err := strconv.Atoi(line)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+	return pids
+}
+
+// runningPIDs returns the PIDs of the running nfqws processes of tr, sorted.
+func (tr *testRunner) runningPIDs() []int {
+	var pids []int
+	for _, p := range tr.procManager.Processes() {
+		if p.Running {
+			pids = append(pids, p.PID)
+		}
+	}
+	slices.Sort(pids)
+	return pids
+}
+
+// startWatching starts tr with the file watcher on, recording the reloads
+// its changes trigger instead of running them. The stub nfqws records the
+// SIGHUPs it gets in the returned file.
+func (tr *testRunner) startWatching(t *testing.T) (hups string, triggered func() []string) {
+	t.Helper()
+	dir := t.TempDir()
+	hups, ready := filepath.Join(dir, "hups"), filepath.Join(dir, "ready")
+	tr.replaceBinary(t, hupNFQWS(hups, ready))
+
+	var mu sync.Mutex
+	var reasons []string
+	tr.applySet.trigger = func(reason string) {
+		mu.Lock()
+		defer mu.Unlock()
+		reasons = append(reasons, reason)
+	}
+	tr.mainCfg.Watch = true
+	tr.Runner.config.Watch = true
+	if err := tr.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Until its trap is set, SIGHUP kills the stub
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(readPIDs(t, ready), tr.runningPIDs()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("stub nfqws %v not ready, running %v", readPIDs(t, ready), tr.runningPIDs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return hups, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(reasons)
+	}
+}
+
+// watchTarget returns the watch target of tr named name.
+func (tr *testRunner) watchTarget(t *testing.T, name string) WatchTarget {
+	t.Helper()
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	for _, target := range tr.watchTargets() {
+		if target.Name == name {
+			return target
+		}
+	}
+	t.Fatalf("no watch target %s", name)
+	return WatchTarget{}
+}
+
+func TestRunnerWatchPolicy(t *testing.T) {
+	const changed = "--filter-tcp=443 --dpi-desync=fake --new\n--filter-udp=443 --dpi-desync=fake\n"
+
+	tests := []struct {
+		name     string
+		settings string
+		target   string
+		edit     string // the strategy written before the change is handled, "" to keep it
+		want     []string
+		wantHup  bool
+	}{
+		{
+			name:   "reload",
+			target: WatchTargetStrategy,
+			want:   []string{"watcher:strategy"},
+		},
+		{
+			name:     "reload lists",
+			settings: "  lists:\n    debounce: 1s\n    policy: reload\n",
+			target:   WatchTargetLists,
+			want:     []string{"watcher:lists"},
+		},
+		{
+			name:     "diff, unchanged",
+			settings: "  strategy:\n    debounce: 1s\n    policy: diff\n",
+			target:   WatchTargetStrategy,
+		},
+		{
+			name:     "diff, changed",
+			settings: "  strategy:\n    debounce: 1s\n    policy: diff\n",
+			target:   WatchTargetStrategy,
+			edit:     changed,
+			want:     []string{"watcher:strategy"},
+		},
+		{
+			name:    "signal",
+			target:  WatchTargetLists,
+			wantHup: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newTestRunner(t, testStrategy, "watch_targets:\n  quiet_period: 0s\n"+tt.settings)
+			hups, triggered := tr.startWatching(t)
+			if tt.edit != "" {
+				tr.writeStrategy(t, tt.edit)
+			}
+			pids := tr.runningPIDs()
+
+			tr.onWatchEvent(tr.watchTarget(t, tt.target))
+
+			if got := triggered(); !slices.Equal(got, tt.want) {
+				t.Errorf("reloads triggered = %q, want %q", got, tt.want)
+			}
+			want := []int(nil)
+			if tt.wantHup {
+				want = pids
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			got := readPIDs(t, hups)
+			for !slices.Equal(got, want) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+				got = readPIDs(t, hups)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("processes signalled = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRunnerHostlistEditSignalsOnly(t *testing.T) {
+	const debounce = 50 * time.Millisecond
+	tr := newTestRunner(t, testStrategy, fmt.Sprintf("watch_targets:\n  lists:\n    debounce: %s\n    policy: signal\n", debounce))
+	list := filepath.Join(tr.dir, "list-general.txt")
+	if err := os.WriteFile(list, []byte("discord.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hups, triggered := tr.startWatching(t)
+	pids := tr.runningPIDs()
+	if len(pids) != 2 {
+		t.Fatalf("%d running processes, want 2", len(pids))
+	}
+	reloads := tr.GetStatus().ConfigReloads
+	_, cursor := tr.events.Since(0)
+	tr.fw.takeOps()
+
+	// Edit the hostlist the way scripts do, a line at a time
+	f, err := os.OpenFile(list, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []string{"youtube.com", "googlevideo.com", "ggpht.com"} {
+		if _, err := f.WriteString(host + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	got := readPIDs(t, hups)
+	for !slices.Equal(got, pids) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		got = readPIDs(t, hups)
+	}
+	if !slices.Equal(got, pids) {
+		t.Fatalf("processes signalled = %v, want each of %v once", got, pids)
+	}
+	// Nothing else follows the signal
+	time.Sleep(3 * debounce)
+	if got := readPIDs(t, hups); !slices.Equal(got, pids) {
+		t.Errorf("processes signalled = %v, want each of %v once", got, pids)
+	}
+
+	if got := triggered(); len(got) != 0 {
+		t.Errorf("reloads triggered: %q, want none", got)
+	}
+	if got := tr.GetStatus().ConfigReloads; got != reloads {
+		t.Errorf("ConfigReloads = %d, want %d", got, reloads)
+	}
+	if got := tr.runningPIDs(); !slices.Equal(got, pids) {
+		t.Errorf("running processes %v, want %v untouched", got, pids)
+	}
+	if ops := tr.fw.takeOps(); len(ops) != 0 {
+		t.Errorf("firewall operations %q, want none", ops)
+	}
+	var kinds []string
+	events, _ := tr.events.Since(cursor)
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	if !slices.Equal(kinds, []string{"hostlist_reread"}) {
+		t.Errorf("events %q, want only hostlist_reread", kinds)
+	}
+}
@@ -0,0 +1,153 @@
+package strategyrunner
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// windowsDrive matches a drive letter prefix such as "C:" or "c:\".
+var windowsDrive = regexp.MustCompile(`^[A-Za-z]:`)
+
+// windowsScriptDir matches the batch file directory prefix "%~dp0", which
+// ends with a separator.
+var windowsScriptDir = regexp.MustCompile(`(?i)^%~dp0`)
+
+// PathConversion records a Windows-style path found in a file-taking option.
+type PathConversion struct {
+	// Option is the nfqws option taking the path
+	Option string
+
+	// Original is the path as written in the strategy
+	Original string
+
+	// Converted is the Linux path used instead ("" when conversion failed)
+	Converted string
+
+	// Error explains why the path couldn't be converted
+	Error string
+}
+
+// pathOptions returns the nfqws options taking file paths.
+func pathOptions() []string {
+	opts := append([]string{"--hostlist-auto", "--hostlist-auto-debug"}, fileOptions...)
+	for _, format := range payloadFormats {
+		opts = append(opts, format.Option)
+	}
+	return opts
+}
+
+// isWindowsPath reports whether value is a Windows-style path: it has a drive
+// letter or the batch file directory, is a UNC path or uses backslash
+// separators.
+func isWindowsPath(value string) bool {
+	return windowsDrive.MatchString(value) || windowsScriptDir.MatchString(value) || strings.Contains(value, `\`)
+}
+
+// convertWindowsPath converts a Windows-style path to a Linux path. The drive
+// letter, batch file directory or UNC host and share are stripped and
+// separators flipped; the
+// result is used when it exists. Otherwise a file of the same name in
+// listsDir is used. Paths without a drive or UNC prefix are kept with flipped
+// separators even when missing, since they were meant as Linux paths.
+func convertWindowsPath(value, listsDir string) (string, error) {
+	rooted := false
+	rest := value
+	switch {
+	case strings.HasPrefix(rest, `\\`) || strings.HasPrefix(rest, `//`):
+		// \\server\share\dir\file: host and share have no Linux counterpart
+		parts := strings.SplitN(strings.TrimLeft(strings.ReplaceAll(rest, `\`, "/"), "/"), "/", 3)
+		rest = ""
+		if len(parts) == 3 {
+			rest = "/" + parts[2]
+		}
+		rooted = true
+	case windowsDrive.MatchString(rest):
+		rest = rest[2:]
+		rooted = true
+	case windowsScriptDir.MatchString(rest):
+		// The directory of the Windows install has no Linux counterpart either
+		rest = "/" + rest[len("%~dp0"):]
+		rooted = true
+	}
+
+	linux := strings.ReplaceAll(rest, `\`, "/")
+	if linux != "" {
+		linux = path.Clean(linux)
+	}
+	if rooted && linux != "" && !strings.HasPrefix(linux, "/") {
+		linux = "/" + linux
+	}
+
+	if linux == "" || linux == "/" || linux == "." {
+		return "", fmt.Errorf("no file name in %q", value)
+	}
+	if fileExists(linux) {
+		return linux, nil
+	}
+	if listsDir != "" {
+		if candidate := path.Join(listsDir, path.Base(linux)); fileExists(candidate) {
+			return candidate, nil
+		}
+	}
+	if !rooted {
+		return linux, nil
+	}
+	return "", fmt.Errorf("%s does not exist and %s is not in %s", linux, path.Base(linux), listsDir)
+}
+
+// fileExists reports whether path names an existing file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// normalizeWindowsPaths converts Windows-style paths in the file-taking
+// options of args. It returns the args with converted paths and every
+// conversion attempted, including failed ones whose paths are left as written.
+func normalizeWindowsPaths(args, listsDir string) (string, []PathConversion) {
+	parsed := parseNFQWSArgs(args)
+	converted := parsed
+
+	var conversions []PathConversion
+	for _, opt := range pathOptions() {
+		converted = replaceOptionValues(converted, opt, func(value string) string {
+			if !isPayloadFile(value) || !isWindowsPath(value) {
+				return value
+			}
+			conv := PathConversion{Option: opt, Original: value}
+			linux, err := convertWindowsPath(value, listsDir)
+			if err != nil {
+				conv.Error = err.Error()
+				conversions = append(conversions, conv)
+				return value
+			}
+			conv.Converted = linux
+			conversions = append(conversions, conv)
+			return linux
+		})
+	}
+
+	if slices.Equal(parsed, converted) {
+		return args, conversions
+	}
+	return joinNFQWSArgs(converted), conversions
+}
+
+// pathConversionError returns the rule error for failed conversions, "" if
+// every path was converted.
+func pathConversionError(conversions []PathConversion) string {
+	var failed []string
+	for _, c := range conversions {
+		if c.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s=%s (%s)", c.Option, c.Original, c.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return "unconvertible Windows paths: " + strings.Join(failed, "; ")
+}
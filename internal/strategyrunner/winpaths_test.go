@@ -0,0 +1,123 @@
+package strategyrunner
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// windowsStyle returns path on drive C: with backslash separators.
+func windowsStyle(path string) string {
+	return `C:` + strings.ReplaceAll(path, "/", `\`)
+}
+
+func TestIsWindowsPath(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{`C:\zapret\lists\list.txt`, true},
+		{`d:/zapret/list.txt`, true},
+		{`\\nas\share\list.txt`, true},
+		{`%~dp0list.txt`, true},
+		{`%~DP0lists\list.txt`, true},
+		{`lists\list.txt`, true},
+		{`/etc/zapret-ng/lists/list.txt`, false},
+		{`list.txt`, false},
+		{`0x00000000`, false},
+	}
+
+	for _, tt := range tests {
+		if got := isWindowsPath(tt.value); got != tt.want {
+			t.Errorf("isWindowsPath(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestConvertWindowsPath(t *testing.T) {
+	root := t.TempDir()
+	lists := filepath.Join(root, "lists")
+	present := filepath.Join(root, "zapret", "list.txt")
+	writeFiles(t, map[string]string{
+		present:                                  "a.example\n",
+		filepath.Join(lists, "list-general.txt"): "b.example\n",
+	})
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "drive letter", value: windowsStyle(present), want: present},
+		{name: "lowercase drive, forward slashes", value: "c:" + present, want: present},
+		{name: "mixed separators", value: strings.Replace(windowsStyle(present), `\zapret\`, `/zapret\`, 1), want: present},
+		{name: "redundant separators", value: strings.Replace(windowsStyle(present), `\zapret\`, `\\zapret\.\`, 1), want: present},
+		{name: "drive letter, in the lists dir", value: `C:\zapret\lists\list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		{name: "drive letter, missing", value: `C:\zapret\lists\missing.txt`, wantErr: true},
+		{name: "drive letter only", value: `C:`, wantErr: true},
+		{name: "drive root", value: `C:\`, wantErr: true},
+		{name: "script dir", value: `%~dp0lists\list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		{name: "script dir, uppercase", value: `%~DP0list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		{name: "script dir, missing", value: `%~dp0lists\missing.txt`, wantErr: true},
+		{name: "UNC", value: `\\nas\share\zapret\list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		{name: "UNC, forward slashes", value: `//nas/share/zapret/list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		{name: "UNC, mixed separators", value: `\\nas/share\zapret/list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		{name: "UNC, missing", value: `\\nas\share\missing.txt`, wantErr: true},
+		{name: "UNC share only", value: `\\nas\share`, wantErr: true},
+		{name: "relative, in the lists dir", value: `lists\list-general.txt`, want: filepath.Join(lists, "list-general.txt")},
+		// A relative path was meant for Linux, missing or not
+		{name: "relative, missing", value: `lists\missing.txt`, want: "lists/missing.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertWindowsPath(tt.value, lists)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertWindowsPath(%q) = %q, %v, wantErr %v", tt.value, got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("convertWindowsPath(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWindowsPaths(t *testing.T) {
+	lists := t.TempDir()
+	writeFiles(t, map[string]string{filepath.Join(lists, "list-general.txt"): ""})
+	args := `--filter-tcp=443 --hostlist=C:\zapret\lists\list-general.txt --hostlist-exclude=\\nas\share\exclude.txt ` +
+		`--dpi-desync=fake --dpi-desync-fake-tls=0x00000000`
+
+	got, conversions := normalizeWindowsPaths(args, lists)
+	want := "--filter-tcp=443 --hostlist=" + filepath.Join(lists, "list-general.txt") +
+		` --hostlist-exclude=\\nas\share\exclude.txt --dpi-desync=fake --dpi-desync-fake-tls=0x00000000`
+	if got != want {
+		t.Errorf("normalizeWindowsPaths() = %q, want %q", got, want)
+	}
+
+	// Both paths are recorded; the unconvertible one is left as written
+	var originals []string
+	for _, c := range conversions {
+		originals = append(originals, c.Original)
+	}
+	if !slices.Equal(originals, []string{`C:\zapret\lists\list-general.txt`, `\\nas\share\exclude.txt`}) {
+		t.Fatalf("conversions of %q, want both paths", originals)
+	}
+	if conversions[0].Error != "" || conversions[1].Converted != "" || conversions[1].Error == "" {
+		t.Errorf("conversions = %+v, want the first converted and the second failed", conversions)
+	}
+	if msg := pathConversionError(conversions); !strings.Contains(msg, `--hostlist-exclude=\\nas\share\exclude.txt`) {
+		t.Errorf("pathConversionError() = %q, want the failed path named", msg)
+	}
+	if msg := pathConversionError(conversions[:1]); msg != "" {
+		t.Errorf("pathConversionError() = %q for converted paths, want none", msg)
+	}
+
+	// Linux paths are left alone
+	linux := "--hostlist=" + filepath.Join(lists, "list-general.txt")
+	if got, conversions := normalizeWindowsPaths(linux, lists); got != linux || len(conversions) != 0 {
+		t.Errorf("normalizeWindowsPaths(%q) = %q, %v, want it unchanged", linux, got, conversions)
+	}
+}
@@ -0,0 +1,93 @@
+// Package textnorm folds the Unicode look-alikes that values copied from
+// spreadsheets, chats and word processors carry, such as no-break spaces,
+// full-width digits and typographic dashes, into the ASCII forms the
+// parsers of port specs and durations expect.
+package textnorm
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Fold returns the ASCII form of full-width characters and of dashes and
+// commas used in port specs, r itself otherwise.
+func Fold(r rune) rune {
+	switch {
+	case r >= '\uff01' && r <= '\uff5e':
+		// Full-width forms mirror ASCII 0x21-0x7e
+		return r - '\uff01' + '!'
+	case r == '\u2010', r == '\u2011', r == '\u2012', r == '\u2013', r == '\u2014', r == '\u2212':
+		// Hyphens, dashes and the minus sign
+		return '-'
+	case r == '\u3001', r == '\uff64':
+		// Ideographic commas
+		return ','
+	}
+	return r
+}
+
+// IsSpace reports whether r is whitespace other than the ASCII space, tab
+// and line breaks, e.g. the no-break space U+00A0.
+func IsSpace(r rune) bool {
+	return r > unicode.MaxASCII && unicode.IsSpace(r)
+}
+
+// IsInvisible reports whether r is a zero-width character, e.g. U+200B or a
+// byte order mark, which is dropped.
+func IsInvisible(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return false
+}
+
+// Spec returns a port spec such as "80,443,1024-65535" with look-alikes
+// folded and all whitespace removed, and whether anything changed.
+func Spec(s string) (string, bool) {
+	folded := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || IsInvisible(r) {
+			return -1
+		}
+		return Fold(r)
+	}, s)
+	return folded, folded != s
+}
+
+// durationPartRe matches a number and its unit in a folded duration.
+var durationPartRe = regexp.MustCompile(`(\d+(?:\.\d+)?)(\D*)`)
+
+// durationUnits maps Russian unit names to Go duration units.
+var durationUnits = map[string]string{
+	"мкс": "us",
+	"мс":  "ms",
+	"с":   "s", "сек": "s", "секунда": "s", "секунды": "s", "секунд": "s",
+	"м": "m", "мин": "m", "минута": "m", "минуты": "m", "минут": "m",
+	"ч": "h", "час": "h", "часа": "h", "часов": "h",
+}
+
+// Duration returns a duration such as "5 сек" or "１,５ ч" in the form
+// time.ParseDuration accepts ("5s", "1.5h"), and whether anything changed.
+// Unknown units are kept for the parser to report.
+func Duration(s string) (string, bool) {
+	folded := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || IsInvisible(r) {
+			return -1
+		}
+		if r = Fold(r); r == ',' {
+			// Decimal comma
+			return '.'
+		}
+		return unicode.ToLower(r)
+	}, s)
+
+	folded = durationPartRe.ReplaceAllStringFunc(folded, func(part string) string {
+		m := durationPartRe.FindStringSubmatch(part)
+		if unit, ok := durationUnits[strings.TrimSuffix(m[2], ".")]; ok {
+			return m[1] + unit
+		}
+		return part
+	})
+	return folded, folded != s
+}
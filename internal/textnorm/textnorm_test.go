@@ -0,0 +1,125 @@
+package textnorm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpec(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		changed bool
+	}{
+		{in: "80,443,1024-65535", want: "80,443,1024-65535"},
+		{in: "８０,４４３", want: "80,443", changed: true},
+		{in: "80,\u00a0443", want: "80,443", changed: true},
+		{in: "1024–1100", want: "1024-1100", changed: true},
+		{in: "1024−1100", want: "1024-1100", changed: true},
+		{in: "80、443", want: "80,443", changed: true},
+		{in: "\ufeff443\u200b", want: "443", changed: true},
+		{in: "80, 443", want: "80,443", changed: true},
+	}
+
+	for _, tt := range tests {
+		got, changed := Spec(tt.in)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("Spec(%q) = %q, %v, want %q, %v", tt.in, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		changed bool
+	}{
+		{in: "5s", want: "5s"},
+		{in: "5 сек", want: "5s", changed: true},
+		{in: "5\u00a0s", want: "5s", changed: true},
+		{in: "１,５ ч", want: "1.5h", changed: true},
+		{in: "10 мин 30 с", want: "10m30s", changed: true},
+		{in: "250 мс", want: "250ms", changed: true},
+		{in: "2 ЧАСА", want: "2h", changed: true},
+		{in: "5 сек.", want: "5s", changed: true},
+		// Unknown units are left for the parser to report
+		{in: "5 дней", want: "5дней", changed: true},
+	}
+
+	for _, tt := range tests {
+		got, changed := Duration(tt.in)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("Duration(%q) = %q, %v, want %q, %v", tt.in, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+// pollute writes s with the look-alikes users paste in: full-width digits,
+// typographic dashes, ideographic commas, no-break spaces and zero-width
+// characters, choosing per character from seed.
+func pollute(s string, seed uint64) string {
+	var b strings.Builder
+	for _, r := range s {
+		pick := seed % 3
+		seed = seed*6364136223846793005 + 1442695040888963407
+		switch {
+		case r >= '0' && r <= '9' && pick == 0:
+			b.WriteRune(r - '0' + '０')
+		case r == '-' && pick == 0:
+			b.WriteRune('–')
+		case r == '-' && pick == 1:
+			b.WriteRune('−')
+		case r == ',' && pick == 0:
+			b.WriteString("、")
+		case r == ',' && pick == 1:
+			b.WriteString(",\u00a0")
+		case r == '.' && pick == 0:
+			b.WriteRune(',')
+		default:
+			b.WriteRune(r)
+		}
+		if pick == 2 && seed%5 == 0 {
+			b.WriteRune('\u200b')
+		}
+	}
+	return b.String()
+}
+
+func FuzzSpec(f *testing.F) {
+	f.Add("80,443,1024-65535", uint64(1))
+	f.Add("50000-50100", uint64(7))
+	f.Add("443", uint64(0))
+
+	f.Fuzz(func(t *testing.T, spec string, seed uint64) {
+		// Valid specs only hold digits, commas and dashes
+		if strings.Trim(spec, "0123456789,-") != "" {
+			return
+		}
+		polluted := pollute(spec, seed)
+		if got, _ := Spec(polluted); got != spec {
+			t.Errorf("Spec(%q) = %q, want the ASCII form %q", polluted, got, spec)
+		}
+	})
+}
+
+func FuzzDuration(f *testing.F) {
+	f.Add("5s", uint64(1))
+	f.Add("1.5h", uint64(3))
+	f.Add("10m30s", uint64(0))
+	f.Add("250ms", uint64(2))
+
+	f.Fuzz(func(t *testing.T, d string, seed uint64) {
+		want, err := time.ParseDuration(d)
+		if err != nil || strings.ContainsFunc(d, func(r rune) bool { return r > 0x7f }) {
+			return
+		}
+		polluted := pollute(d, seed)
+		folded, _ := Duration(polluted)
+		got, err := time.ParseDuration(folded)
+		if err != nil || got != want {
+			t.Errorf("Duration(%q) = %q, parsed as %v, %v, want %v", polluted, folded, got, err, want)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+)
+
+// Options selects the daemon to connect to and how.
+type Options struct {
+	// Socket is the path of the daemon's unix socket, used when Address is empty
+	Socket string
+
+	// Address is the daemon's network address, e.g. "router.lan:8080"
+	Address string
+
+	// Token is sent as a bearer token to Address; the unix socket needs none
+	Token string
+
+	// TLS connects to Address over HTTPS with this configuration (nil for HTTP)
+	TLS *tls.Config
+
+	// Timeout bounds every request, 0 for no limit beyond the call's context
+	Timeout time.Duration
+}
+
+// Client is a daemon.ZapretDaemon with helpers for common tasks.
+type Client struct {
+	daemon.ZapretDaemon
+
+	target string
+}
+
+// healthPollInterval is how often WaitForHealthy asks the daemon.
+const healthPollInterval = 500 * time.Millisecond
+
+// New returns a client for the daemon selected by opts. No connection is
+// made until the first call.
+func New(opts Options) (*Client, error) {
+	var (
+		httpClient *http.Client
+		baseURL    string
+		target     string
+	)
+	switch {
+	case opts.Address != "":
+		scheme := "http"
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if opts.TLS != nil {
+			scheme = "https"
+			transport.TLSClientConfig = opts.TLS
+		}
+		var rt http.RoundTripper = transport
+		if opts.Token != "" {
+			rt = &tokenTransport{token: opts.Token, base: transport}
+		}
+		httpClient = &http.Client{Transport: rt}
+		baseURL = scheme + "://" + opts.Address
+		target = baseURL
+	case opts.Socket != "":
+		httpClient = &http.Client{
+			Transport: &http.Transport{DialContext: unixDialer(opts.Socket)},
+		}
+		baseURL = "http://unix"
+		target = "unix:" + opts.Socket
+	default:
+		return nil, errors.New("no connection method configured: set Socket or Address")
+	}
+	httpClient.Timeout = opts.Timeout
+
+	return &Client{
+		ZapretDaemon: daemon.NewZapretDaemonProtobufClient(baseURL, httpClient),
+		target:       target,
+	}, nil
+}
+
+// Target describes the daemon the client connects to, e.g.
+// "unix:/run/zapret-ng/zapret.sock" or "https://router.lan:8443".
+func (c *Client) Target() string {
+	return c.target
+}
+
+// Snapshot returns the daemon snapshot limited to fields (all if none), with
+// errors classified.
+func (c *Client) Snapshot(ctx context.Context, fields ...string) (*daemon.SnapshotResponse, error) {
+	snap, err := c.GetSnapshot(ctx, &daemon.SnapshotRequest{Fields: fields})
+	if err != nil {
+		return nil, Classify(err)
+	}
+	return snap, nil
+}
+
+// WaitForHealthy polls the daemon until it reports healthy, e.g. after it was
+// started or restarted. An unreachable daemon is polled again, so a daemon
+// still binding its socket is waited for; ctx bounds the wait.
+func (c *Client) WaitForHealthy(ctx context.Context) error {
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	var last error
+	for {
+		snap, err := c.Snapshot(ctx, "health")
+		switch {
+		case err == nil && snap.GetHealth() == "healthy":
+			return nil
+		case err == nil:
+			last = fmt.Errorf("daemon is %s", snap.GetHealth())
+		case errors.Is(err, ErrDaemonUnreachable) || errors.Is(err, ErrTimeout):
+			last = err
+		default:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w waiting for a healthy daemon: %w", ErrTimeout, last)
+		case <-ticker.C:
+		}
+	}
+}
+
+// LoadTLSConfig returns the TLS configuration for an HTTPS address, trusting
+// the certificates in caFile in place of the system roots when it is set.
+func LoadTLSConfig(caFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// unixDialer returns a dial function connecting to socketPath whatever the
+// requested address.
+func unixDialer(socketPath string) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socketPath)
+	}
+}
+
+// tokenTransport adds the Authorization header to every request.
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
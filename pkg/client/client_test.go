@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/config"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/internal/daemonserver"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+// newDaemonHandler returns the HTTP handler of an in-process daemon without
+// a strategy runner, requiring token on network connections.
+func newDaemonHandler(t *testing.T, token string) http.Handler {
+	t.Helper()
+	server, err := daemonserver.NewServer(slog.New(slog.DiscardHandler), &config.Config{})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return daemonserver.NewHTTPHandler(daemon.NewZapretDaemonServer(server), server, &config.ServerConfig{
+		AuthToken:          token,
+		WriteTimeout:       15 * time.Second,
+		LongRequestTimeout: time.Minute,
+	})
+}
+
+// startTCPDaemon serves handler on a loopback address.
+func startTCPDaemon(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Config.ConnContext = daemonserver.ConnContext
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// startSocketDaemon serves handler on a unix socket and returns its path.
+func startSocketDaemon(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, t.TempDir() may exceed that
+	dir, err := os.MkdirTemp("", "zapret-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "zapret.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: handler, ConnContext: daemonserver.ConnContext}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+	return socket
+}
+
+func TestClientAddress(t *testing.T) {
+	srv := startTCPDaemon(t, newDaemonHandler(t, "secret"))
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	c, err := New(Options{Address: address, Token: "secret", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.Target() != srv.URL {
+		t.Errorf("Target() = %q, want %q", c.Target(), srv.URL)
+	}
+
+	snap, err := c.Snapshot(t.Context(), "status", "health")
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if snap.Health != "stopped" {
+		t.Errorf("Health = %q, want stopped without a strategy runner", snap.Health)
+	}
+
+	// Every RPC is available on the client itself
+	if _, err := c.GetStatus(t.Context(), &daemon.StatusRequest{}); err != nil {
+		t.Errorf("GetStatus() error = %v", err)
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	srv := startTCPDaemon(t, newDaemonHandler(t, "secret"))
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	for _, token := range []string{"", "wrong"} {
+		c, err := New(Options{Address: address, Token: token})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if _, err := c.Snapshot(t.Context()); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Snapshot() with token %q error = %v, want ErrUnauthorized", token, err)
+		}
+	}
+}
+
+func TestClientSocket(t *testing.T) {
+	socket := startSocketDaemon(t, newDaemonHandler(t, "secret"))
+
+	// Connections over the socket need no token
+	c, err := New(Options{Socket: socket})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if want := "unix:" + socket; c.Target() != want {
+		t.Errorf("Target() = %q, want %q", c.Target(), want)
+	}
+	if _, err := c.Snapshot(t.Context(), "health"); err != nil {
+		t.Errorf("Snapshot() error = %v", err)
+	}
+}
+
+func TestClientTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(newDaemonHandler(t, "secret"))
+	defer srv.Close()
+
+	tlsConfig, err := LoadTLSConfig("", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig.RootCAs = x509.NewCertPool()
+	tlsConfig.RootCAs.AddCert(srv.Certificate())
+
+	c, err := New(Options{Address: strings.TrimPrefix(srv.URL, "https://"), Token: "secret", TLS: tlsConfig})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !strings.HasPrefix(c.Target(), "https://") {
+		t.Errorf("Target() = %q, want https", c.Target())
+	}
+	if _, err := c.Snapshot(t.Context(), "health"); err != nil {
+		t.Errorf("Snapshot() over TLS error = %v", err)
+	}
+}
+
+func TestClientUnreachable(t *testing.T) {
+	// A listener closed right away leaves a port nobody listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "missing socket", opts: Options{Socket: filepath.Join(t.TempDir(), "zapret.sock")}},
+		{name: "closed port", opts: Options{Address: address}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if _, err := c.Snapshot(t.Context()); !errors.Is(err, ErrDaemonUnreachable) {
+				t.Errorf("Snapshot() error = %v, want ErrDaemonUnreachable", err)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := startTCPDaemon(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer close(release)
+
+	c, err := New(Options{Address: strings.TrimPrefix(srv.URL, "http://"), Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := c.Snapshot(t.Context()); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Snapshot() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestNewWithoutTarget(t *testing.T) {
+	if _, err := New(Options{Token: "secret"}); err == nil {
+		t.Error("New() without socket or address succeeded, want error")
+	}
+}
+
+// healthDaemon reports the health states in order, then the last one forever.
+type healthDaemon struct {
+	daemon.ZapretDaemon
+	states []string
+	calls  atomic.Int32
+}
+
+func (d *healthDaemon) GetSnapshot(ctx context.Context, req *daemon.SnapshotRequest) (*daemon.SnapshotResponse, error) {
+	i := min(int(d.calls.Add(1))-1, len(d.states)-1)
+	return &daemon.SnapshotResponse{Health: d.states[i]}, nil
+}
+
+func TestWaitForHealthy(t *testing.T) {
+	fake := &healthDaemon{states: []string{"reloading", "healthy"}}
+	srv := startTCPDaemon(t, daemon.NewZapretDaemonServer(fake))
+	c, err := New(Options{Address: strings.TrimPrefix(srv.URL, "http://")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := c.WaitForHealthy(t.Context()); err != nil {
+		t.Fatalf("WaitForHealthy() error = %v", err)
+	}
+	if n := fake.calls.Load(); n != 2 {
+		t.Errorf("daemon asked %d times, want 2", n)
+	}
+}
+
+func TestWaitForHealthyTimeout(t *testing.T) {
+	srv := startTCPDaemon(t, daemon.NewZapretDaemonServer(&healthDaemon{states: []string{"degraded"}}))
+	c, err := New(Options{Address: strings.TrimPrefix(srv.URL, "http://")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	err = c.WaitForHealthy(ctx)
+	if !errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), "daemon is degraded") {
+		t.Errorf("WaitForHealthy() error = %v, want ErrTimeout naming the last state", err)
+	}
+}
+
+func TestWaitForHealthyFailsFast(t *testing.T) {
+	srv := startTCPDaemon(t, newDaemonHandler(t, "secret"))
+	c, err := New(Options{Address: strings.TrimPrefix(srv.URL, "http://"), Token: "wrong"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	if err := c.WaitForHealthy(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("WaitForHealthy() error = %v, want ErrUnauthorized without waiting", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "nil", err: nil, want: nil},
+		{name: "deadline", err: fmt.Errorf("call: %w", context.DeadlineExceeded), want: ErrTimeout},
+		{name: "missing socket", err: &net.OpError{Op: "dial", Err: os.ErrNotExist}, want: ErrDaemonUnreachable},
+		{name: "unknown host", err: &net.DNSError{Err: "no such host", Name: "router.lan"}, want: ErrDaemonUnreachable},
+		{name: "unauthenticated", err: twirp.NewError(twirp.Unauthenticated, "unauthorized"), want: ErrUnauthorized},
+		{name: "permission denied", err: twirp.NewError(twirp.PermissionDenied, "forbidden"), want: ErrUnauthorized},
+		{name: "twirp deadline", err: twirp.NewError(twirp.DeadlineExceeded, "restart still in progress"), want: ErrTimeout},
+		{name: "no runner", err: twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled"), want: ErrUnavailable},
+		{name: "unavailable", err: twirp.NewError(twirp.Unavailable, "reloading"), want: ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.err)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Classify() = %v, want nil", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("Classify() = %v, want the original error kept", got)
+			}
+		})
+	}
+
+	// Errors without a known cause are returned as is
+	invalid := twirp.InvalidArgumentError("fields", "unknown field")
+	if got := Classify(invalid); got != invalid {
+		t.Errorf("Classify(invalid argument) = %v, want it unchanged", got)
+	}
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	if cfg, err := LoadTLSConfig("", true); err != nil || !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Errorf("LoadTLSConfig(\"\", true) = %+v, %v, want system roots and skipped verification", cfg, err)
+	}
+
+	if _, err := LoadTLSConfig(filepath.Join(t.TempDir(), "ca.pem"), false); err == nil {
+		t.Error("LoadTLSConfig() of a missing file succeeded, want error")
+	}
+
+	bad := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bad, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTLSConfig(bad, false); err == nil {
+		t.Error("LoadTLSConfig() of a file without certificates succeeded, want error")
+	}
+}
@@ -0,0 +1,22 @@
+// Package client connects to the zapret daemon for programs other than the
+// zapret CLI, which uses it for all of its connections.
+//
+// A client talks to the daemon over its unix socket or its network address:
+//
+//	c, err := client.New(client.Options{Socket: "/run/zapret-ng/zapret.sock"})
+//	if err != nil {
+//		return err
+//	}
+//	if err := c.WaitForHealthy(ctx); err != nil {
+//		return err
+//	}
+//	snap, err := c.Snapshot(ctx, "status", "health")
+//
+// A Client is a daemon.ZapretDaemon, so every RPC of the service can be
+// called on it directly. Errors of those calls are Twirp errors; Classify
+// maps them onto the sentinel errors of this package:
+//
+//	if _, err := c.Restart(ctx, &daemon.RestartRequest{}); errors.Is(client.Classify(err), client.ErrDaemonUnreachable) {
+//		// the daemon is not running
+//	}
+package client
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/twitchtv/twirp"
+)
+
+// Errors returned by Classify, wrapping the error of the call.
+var (
+	// ErrDaemonUnreachable means no connection to the daemon could be made:
+	// it isn't running, or the socket or address is wrong
+	ErrDaemonUnreachable = errors.New("daemon unreachable")
+
+	// ErrUnauthorized means the daemon rejected the token
+	ErrUnauthorized = errors.New("daemon rejected the auth token")
+
+	// ErrTimeout means the call's context or Options.Timeout ran out
+	ErrTimeout = errors.New("daemon did not answer in time")
+
+	// ErrUnavailable means the daemon answered but can't serve the call,
+	// e.g. its strategy runner is not configured or a strategy failed
+	ErrUnavailable = errors.New("daemon can't serve the request")
+)
+
+// Classify wraps err in the sentinel error describing its cause, keeping err
+// itself reachable with errors.As. Errors without a known cause, such as
+// invalid arguments, are returned unchanged.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.Is(err, os.ErrNotExist), isDialError(err):
+		return fmt.Errorf("%w: %w", ErrDaemonUnreachable, err)
+	}
+
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) {
+		return err
+	}
+	switch twerr.Code() {
+	case twirp.Unauthenticated, twirp.PermissionDenied:
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	case twirp.DeadlineExceeded:
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case twirp.FailedPrecondition, twirp.Unavailable:
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// isDialError reports whether err comes from connecting to the daemon, such
+// as a refused connection or an unknown host.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/pkg/client"
+	"github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemon"
+	"github.com/twitchtv/twirp"
+)
+
+func ExampleNew() {
+	c, err := client.New(client.Options{
+		Socket:  "/run/zapret/zapret-daemon.sock",
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	snap, err := c.Snapshot(context.Background(), "status", "health")
+	if errors.Is(err, client.ErrDaemonUnreachable) {
+		log.Fatal("zapret daemon is not running")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s, %d queues\n", snap.Health, snap.Status.GetActiveQueues())
+}
+
+func ExampleNew_network() {
+	tlsConfig, err := client.LoadTLSConfig("/etc/zapret-ng/ca.pem", false)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := client.New(client.Options{
+		Address: "router.lan:8443",
+		Token:   "secret",
+		TLS:     tlsConfig,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("connecting to", c.Target())
+}
+
+func ExampleClient_WaitForHealthy() {
+	c, err := client.New(client.Options{Socket: "/run/zapret/zapret-daemon.sock"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Restart and wait until every queue is served again
+	if _, err := c.Restart(ctx, &daemon.RestartRequest{}); err != nil {
+		log.Fatal(client.Classify(err))
+	}
+	if err := c.WaitForHealthy(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleClassify() {
+	err := client.Classify(twirp.NewError(twirp.FailedPrecondition, "strategy runner is not enabled"))
+	fmt.Println(errors.Is(err, client.ErrUnavailable))
+	fmt.Println(err)
+	// Output:
+	// true
+	// daemon can't serve the request: twirp error failed_precondition: strategy runner is not enabled
+}
@@ -24,9 +24,28 @@ const (
 // RestartRequest is the request message for restarting the daemon.
 type RestartRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// force indicates whether to force restart even if the daemon is busy.
-	// (default: false)
-	Force         bool `protobuf:"varint,1,opt,name=force,proto3" json:"force,omitempty"`
+	// force stops every nfqws process and removes all firewall rules before
+	// applying the strategy again. Without it only the rules that changed are
+	// replaced, new ones before old ones, unless settings other than the
+	// strategy changed. (default: false)
+	Force bool `protobuf:"varint,1,opt,name=force,proto3" json:"force,omitempty"`
+	// only_label applies only rules whose label matches this case-insensitive
+	// glob (e.g. "youtube*"). Selectors combine with AND semantics; a restart
+	// without selectors applies all rules and clears the active filter.
+	OnlyLabel string `protobuf:"bytes,2,opt,name=only_label,json=onlyLabel,proto3" json:"only_label,omitempty"`
+	// only_proto applies only rules of this protocol (tcp or udp).
+	OnlyProto string `protobuf:"bytes,3,opt,name=only_proto,json=onlyProto,proto3" json:"only_proto,omitempty"`
+	// only_port applies only rules whose port spec contains this port.
+	OnlyPort string `protobuf:"bytes,4,opt,name=only_port,json=onlyPort,proto3" json:"only_port,omitempty"`
+	// only_rules applies only the rules at these 1-based positions (as listed by ListRules).
+	OnlyRules []int32 `protobuf:"varint,5,rep,packed,name=only_rules,json=onlyRules,proto3" json:"only_rules,omitempty"`
+	// canary verifies the new strategy with the canary probes after the
+	// restart and rolls back to the previous one if they fail.
+	Canary bool `protobuf:"varint,6,opt,name=canary,proto3" json:"canary,omitempty"`
+	// detach returns as soon as the restart has started instead of waiting
+	// for it to finish; restarted_at is then empty and GetStatus reports the
+	// progress.
+	Detach        bool `protobuf:"varint,7,opt,name=detach,proto3" json:"detach,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -68,6 +87,48 @@ func (x *RestartRequest) GetForce() bool {
 	return false
 }
 
+func (x *RestartRequest) GetOnlyLabel() string {
+	if x != nil {
+		return x.OnlyLabel
+	}
+	return ""
+}
+
+func (x *RestartRequest) GetOnlyProto() string {
+	if x != nil {
+		return x.OnlyProto
+	}
+	return ""
+}
+
+func (x *RestartRequest) GetOnlyPort() string {
+	if x != nil {
+		return x.OnlyPort
+	}
+	return ""
+}
+
+func (x *RestartRequest) GetOnlyRules() []int32 {
+	if x != nil {
+		return x.OnlyRules
+	}
+	return nil
+}
+
+func (x *RestartRequest) GetCanary() bool {
+	if x != nil {
+		return x.Canary
+	}
+	return false
+}
+
+func (x *RestartRequest) GetDetach() bool {
+	if x != nil {
+		return x.Detach
+	}
+	return false
+}
+
 // RestartResponse is the response message after restarting the daemon.
 type RestartResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -174,7 +235,94 @@ type StatusResponse struct {
 	// firewall_backend is the firewall backend being used (nftables or iptables).
 	FirewallBackend string `protobuf:"bytes,5,opt,name=firewall_backend,json=firewallBackend,proto3" json:"firewall_backend,omitempty"`
 	// start_time is the timestamp when the strategy runner was started (RFC3339 format).
-	StartTime     string `protobuf:"bytes,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	StartTime string `protobuf:"bytes,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// firewall_last_op_ms is the duration of the most recent firewall operation in milliseconds.
+	FirewallLastOpMs float64 `protobuf:"fixed64,7,opt,name=firewall_last_op_ms,json=firewallLastOpMs,proto3" json:"firewall_last_op_ms,omitempty"`
+	// firewall_max_op_ms is the longest firewall operation observed in milliseconds.
+	FirewallMaxOpMs float64 `protobuf:"fixed64,8,opt,name=firewall_max_op_ms,json=firewallMaxOpMs,proto3" json:"firewall_max_op_ms,omitempty"`
+	// hostlist_index_bytes is the estimated memory used by loaded hostlists.
+	HostlistIndexBytes int64 `protobuf:"varint,9,opt,name=hostlist_index_bytes,json=hostlistIndexBytes,proto3" json:"hostlist_index_bytes,omitempty"`
+	// pending_rules is the number of rules waiting for their hostlist files to appear.
+	PendingRules int32 `protobuf:"varint,10,opt,name=pending_rules,json=pendingRules,proto3" json:"pending_rules,omitempty"`
+	// offload lists interfaces with offload features that defeat desync.
+	Offload []*OffloadFinding `protobuf:"bytes,11,rep,name=offload,proto3" json:"offload,omitempty"`
+	// process_management is "managed" or "external" (nfqws supervised outside the daemon).
+	ProcessManagement string `protobuf:"bytes,12,opt,name=process_management,json=processManagement,proto3" json:"process_management,omitempty"`
+	// firewall_management is "managed" or "external" (queue rules installed by the user).
+	FirewallManagement string `protobuf:"bytes,13,opt,name=firewall_management,json=firewallManagement,proto3" json:"firewall_management,omitempty"`
+	// unbound_queues lists queues without a bound nfqws when process management is external.
+	UnboundQueues []int32 `protobuf:"varint,14,rep,packed,name=unbound_queues,json=unboundQueues,proto3" json:"unbound_queues,omitempty"`
+	// queue_map_file is where the queue to rule mapping is published (empty if disabled).
+	QueueMapFile string `protobuf:"bytes,15,opt,name=queue_map_file,json=queueMapFile,proto3" json:"queue_map_file,omitempty"`
+	// rule_filter describes the rule filter of the last restart (empty when all rules are applied).
+	RuleFilter string `protobuf:"bytes,16,opt,name=rule_filter,json=ruleFilter,proto3" json:"rule_filter,omitempty"`
+	// filtered_rules is the number of rules left out by the rule filter.
+	FilteredRules int32 `protobuf:"varint,17,opt,name=filtered_rules,json=filteredRules,proto3" json:"filtered_rules,omitempty"`
+	// strategy_source is "file", "embedded-fallback" while the strategy file
+	// is missing and the minimal embedded strategy is applied (fallback_strategy),
+	// or "canary-rollback" while the previous strategy is applied because the
+	// new one failed the canary check.
+	StrategySource string `protobuf:"bytes,18,opt,name=strategy_source,json=strategySource,proto3" json:"strategy_source,omitempty"`
+	// phase is the start, stop or reload step in progress (stopping, starting,
+	// parsing, applying_firewall, starting_processes), or running/stopped.
+	Phase string `protobuf:"bytes,19,opt,name=phase,proto3" json:"phase,omitempty"`
+	// scheduled_off_rules is the number of rules outside their active hours;
+	// their nfqws runs but no traffic is queued to it.
+	ScheduledOffRules int32 `protobuf:"varint,20,opt,name=scheduled_off_rules,json=scheduledOffRules,proto3" json:"scheduled_off_rules,omitempty"`
+	// config_poll_interval is how often watched files are polled for content
+	// changes (e.g. "30s"), empty if they are not polled.
+	ConfigPollInterval string `protobuf:"bytes,21,opt,name=config_poll_interval,json=configPollInterval,proto3" json:"config_poll_interval,omitempty"`
+	// watch_fallback indicates the fsnotify watcher failed and polling replaces it.
+	WatchFallback bool `protobuf:"varint,22,opt,name=watch_fallback,json=watchFallback,proto3" json:"watch_fallback,omitempty"`
+	// empty_ruleset indicates no rules are applied, as allowed by empty_ruleset_policy.
+	EmptyRuleset bool `protobuf:"varint,23,opt,name=empty_ruleset,json=emptyRuleset,proto3" json:"empty_ruleset,omitempty"`
+	// conflicts lists other zapret-family services found at start (legacy
+	// units, /opt/zapret tables, nfqws processes not started by the daemon).
+	Conflicts []*Conflict `protobuf:"bytes,24,rep,name=conflicts,proto3" json:"conflicts,omitempty"`
+	// kernel_capabilities is the kernel capability set probed at start.
+	KernelCapabilities []*KernelCapability `protobuf:"bytes,25,rep,name=kernel_capabilities,json=kernelCapabilities,proto3" json:"kernel_capabilities,omitempty"`
+	// netlink_reconnects counts firewall operations retried after a netlink
+	// buffer overrun since the daemon started.
+	NetlinkReconnects uint64 `protobuf:"varint,26,opt,name=netlink_reconnects,json=netlinkReconnects,proto3" json:"netlink_reconnects,omitempty"`
+	// suspended_until is when suspended nfqws processes are resumed
+	// automatically (RFC3339 format, empty if not suspended).
+	SuspendedUntil string `protobuf:"bytes,27,opt,name=suspended_until,json=suspendedUntil,proto3" json:"suspended_until,omitempty"`
+	// suspended_queues lists queues whose nfqws process is stopped.
+	SuspendedQueues []int32 `protobuf:"varint,28,rep,packed,name=suspended_queues,json=suspendedQueues,proto3" json:"suspended_queues,omitempty"`
+	// degraded_queues lists queues whose nfqws process kept crashing and is
+	// no longer restarted.
+	DegradedQueues []int32 `protobuf:"varint,29,rep,packed,name=degraded_queues,json=degradedQueues,proto3" json:"degraded_queues,omitempty"`
+	// kernel_queues_unavailable indicates that the kernel doesn't expose which
+	// queues are bound, so active_processes and unbound_queues are unknown
+	// when process management is external.
+	KernelQueuesUnavailable bool `protobuf:"varint,30,opt,name=kernel_queues_unavailable,json=kernelQueuesUnavailable,proto3" json:"kernel_queues_unavailable,omitempty"`
+	// config_version is the combined SHA-256 of the config and strategy files
+	// the runner was started with; it changes only when a reload applied them.
+	ConfigVersion string `protobuf:"bytes,31,opt,name=config_version,json=configVersion,proto3" json:"config_version,omitempty"`
+	// apply_pending indicates watched file changes waiting for the watch quiet
+	// period to pass (or the .zapret-apply marker) before they are applied.
+	ApplyPending bool `protobuf:"varint,32,opt,name=apply_pending,json=applyPending,proto3" json:"apply_pending,omitempty"`
+	// processes are the tracked nfqws processes. Exited processes waiting for
+	// their restart or given up as degraded are included with running=false.
+	Processes []*ProcessInfo `protobuf:"bytes,33,rep,name=processes,proto3" json:"processes,omitempty"`
+	// zombies_reaped counts exited children nobody waited for, reaped by the
+	// periodic check; anything but 0 is a bug.
+	ZombiesReaped uint64 `protobuf:"varint,34,opt,name=zombies_reaped,json=zombiesReaped,proto3" json:"zombies_reaped,omitempty"`
+	// firewall_rules is the number of queue rules installed in the firewall.
+	FirewallRules int32 `protobuf:"varint,35,opt,name=firewall_rules,json=firewallRules,proto3" json:"firewall_rules,omitempty"`
+	// process_restarts counts restarts of crashed nfqws processes since the
+	// daemon started.
+	ProcessRestarts uint64 `protobuf:"varint,36,opt,name=process_restarts,json=processRestarts,proto3" json:"process_restarts,omitempty"`
+	// config_reloads counts reloads, failed ones included, since the daemon
+	// started.
+	ConfigReloads uint64 `protobuf:"varint,37,opt,name=config_reloads,json=configReloads,proto3" json:"config_reloads,omitempty"`
+	// parse_errors counts strategy files that failed to parse on start or
+	// reload since the daemon started.
+	ParseErrors uint64 `protobuf:"varint,38,opt,name=parse_errors,json=parseErrors,proto3" json:"parse_errors,omitempty"`
+	// phase_done and phase_total count the rules handled so far by the
+	// applying_firewall and starting_processes phases (0 in other phases).
+	PhaseDone     int32 `protobuf:"varint,39,opt,name=phase_done,json=phaseDone,proto3" json:"phase_done,omitempty"`
+	PhaseTotal    int32 `protobuf:"varint,40,opt,name=phase_total,json=phaseTotal,proto3" json:"phase_total,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -251,28 +399,4731 @@ func (x *StatusResponse) GetStartTime() string {
 	return ""
 }
 
-var File_rpc_daemon_service_proto protoreflect.FileDescriptor
+func (x *StatusResponse) GetFirewallLastOpMs() float64 {
+	if x != nil {
+		return x.FirewallLastOpMs
+	}
+	return 0
+}
 
-const file_rpc_daemon_service_proto_rawDesc = "" +
-	"\n" +
-	"\x18rpc/daemon/service.proto\x12\x06daemon\"&\n" +
-	"\x0eRestartRequest\x12\x14\n" +
-	"\x05force\x18\x01 \x01(\bR\x05force\"N\n" +
-	"\x0fRestartResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\x12!\n" +
-	"\frestarted_at\x18\x02 \x01(\tR\vrestartedAt\"\x0f\n" +
-	"\rStatusRequest\"\xe9\x01\n" +
-	"\x0eStatusResponse\x12\x18\n" +
-	"\arunning\x18\x01 \x01(\bR\arunning\x12#\n" +
-	"\rstrategy_file\x18\x02 \x01(\tR\fstrategyFile\x12#\n" +
-	"\ractive_queues\x18\x03 \x01(\x05R\factiveQueues\x12)\n" +
-	"\x10active_processes\x18\x04 \x01(\x05R\x0factiveProcesses\x12)\n" +
-	"\x10firewall_backend\x18\x05 \x01(\tR\x0ffirewallBackend\x12\x1d\n" +
+func (x *StatusResponse) GetFirewallMaxOpMs() float64 {
+	if x != nil {
+		return x.FirewallMaxOpMs
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetHostlistIndexBytes() int64 {
+	if x != nil {
+		return x.HostlistIndexBytes
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPendingRules() int32 {
+	if x != nil {
+		return x.PendingRules
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetOffload() []*OffloadFinding {
+	if x != nil {
+		return x.Offload
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetProcessManagement() string {
+	if x != nil {
+		return x.ProcessManagement
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetFirewallManagement() string {
+	if x != nil {
+		return x.FirewallManagement
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetUnboundQueues() []int32 {
+	if x != nil {
+		return x.UnboundQueues
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetQueueMapFile() string {
+	if x != nil {
+		return x.QueueMapFile
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetRuleFilter() string {
+	if x != nil {
+		return x.RuleFilter
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetFilteredRules() int32 {
+	if x != nil {
+		return x.FilteredRules
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetStrategySource() string {
+	if x != nil {
+		return x.StrategySource
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetScheduledOffRules() int32 {
+	if x != nil {
+		return x.ScheduledOffRules
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetConfigPollInterval() string {
+	if x != nil {
+		return x.ConfigPollInterval
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetWatchFallback() bool {
+	if x != nil {
+		return x.WatchFallback
+	}
+	return false
+}
+
+func (x *StatusResponse) GetEmptyRuleset() bool {
+	if x != nil {
+		return x.EmptyRuleset
+	}
+	return false
+}
+
+func (x *StatusResponse) GetConflicts() []*Conflict {
+	if x != nil {
+		return x.Conflicts
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetKernelCapabilities() []*KernelCapability {
+	if x != nil {
+		return x.KernelCapabilities
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetNetlinkReconnects() uint64 {
+	if x != nil {
+		return x.NetlinkReconnects
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetSuspendedUntil() string {
+	if x != nil {
+		return x.SuspendedUntil
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetSuspendedQueues() []int32 {
+	if x != nil {
+		return x.SuspendedQueues
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetDegradedQueues() []int32 {
+	if x != nil {
+		return x.DegradedQueues
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetKernelQueuesUnavailable() bool {
+	if x != nil {
+		return x.KernelQueuesUnavailable
+	}
+	return false
+}
+
+func (x *StatusResponse) GetConfigVersion() string {
+	if x != nil {
+		return x.ConfigVersion
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetApplyPending() bool {
+	if x != nil {
+		return x.ApplyPending
+	}
+	return false
+}
+
+func (x *StatusResponse) GetProcesses() []*ProcessInfo {
+	if x != nil {
+		return x.Processes
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetZombiesReaped() uint64 {
+	if x != nil {
+		return x.ZombiesReaped
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetFirewallRules() int32 {
+	if x != nil {
+		return x.FirewallRules
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetProcessRestarts() uint64 {
+	if x != nil {
+		return x.ProcessRestarts
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetConfigReloads() uint64 {
+	if x != nil {
+		return x.ConfigReloads
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetParseErrors() uint64 {
+	if x != nil {
+		return x.ParseErrors
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPhaseDone() int32 {
+	if x != nil {
+		return x.PhaseDone
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPhaseTotal() int32 {
+	if x != nil {
+		return x.PhaseTotal
+	}
+	return 0
+}
+
+// KernelCapability is the probed state of a kernel feature the firewall rules depend on.
+type KernelCapability struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is "nfqueue", "conntrack", "notrack" or "limit".
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// state is "available", "missing" or "unknown" (module not loaded, may be
+	// built in or autoloaded).
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// module is the kernel module to load when the capability is missing.
+	Module string `protobuf:"bytes,3,opt,name=module,proto3" json:"module,omitempty"`
+	// detail explains how the state was determined.
+	Detail        string `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KernelCapability) Reset() {
+	*x = KernelCapability{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KernelCapability) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KernelCapability) ProtoMessage() {}
+
+func (x *KernelCapability) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KernelCapability.ProtoReflect.Descriptor instead.
+func (*KernelCapability) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *KernelCapability) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *KernelCapability) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *KernelCapability) GetModule() string {
+	if x != nil {
+		return x.Module
+	}
+	return ""
+}
+
+func (x *KernelCapability) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// Conflict is another zapret-family service competing for NFQUEUE and firewall rules.
+type Conflict struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// kind is "service", "nftables" or "process".
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// name identifies the artifact (unit, table or process).
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// remedy is a hint on how to resolve the conflict.
+	Remedy        string `protobuf:"bytes,3,opt,name=remedy,proto3" json:"remedy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Conflict) Reset() {
+	*x = Conflict{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Conflict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conflict) ProtoMessage() {}
+
+func (x *Conflict) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conflict.ProtoReflect.Descriptor instead.
+func (*Conflict) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Conflict) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Conflict) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Conflict) GetRemedy() string {
+	if x != nil {
+		return x.Remedy
+	}
+	return ""
+}
+
+// OffloadFinding reports offload features enabled on an interface.
+type OffloadFinding struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// interface is the inspected network interface.
+	Interface string `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	// features are the problematic offloads found enabled (gro, gso, tso).
+	Features []string `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
+	// fix_command is the ethtool command disabling the features.
+	FixCommand string `protobuf:"bytes,3,opt,name=fix_command,json=fixCommand,proto3" json:"fix_command,omitempty"`
+	// fixed indicates the daemon disabled the features (auto_fix_offload).
+	Fixed bool `protobuf:"varint,4,opt,name=fixed,proto3" json:"fixed,omitempty"`
+	// error is set when the interface could not be inspected or fixed.
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OffloadFinding) Reset() {
+	*x = OffloadFinding{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OffloadFinding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OffloadFinding) ProtoMessage() {}
+
+func (x *OffloadFinding) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OffloadFinding.ProtoReflect.Descriptor instead.
+func (*OffloadFinding) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OffloadFinding) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *OffloadFinding) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *OffloadFinding) GetFixCommand() string {
+	if x != nil {
+		return x.FixCommand
+	}
+	return ""
+}
+
+func (x *OffloadFinding) GetFixed() bool {
+	if x != nil {
+		return x.Fixed
+	}
+	return false
+}
+
+func (x *OffloadFinding) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// InstallStrategyRequest is the request message for installing a strategy preset.
+type InstallStrategyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is the preset name, used as the installed strategy file name.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// strategy is the content of the .bat strategy file.
+	Strategy []byte `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	// lists maps hostlist file names to their content.
+	Lists map[string][]byte `protobuf:"bytes,3,rep,name=lists,proto3" json:"lists,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// activate switches the strategy runner to the installed preset.
+	Activate      bool `protobuf:"varint,4,opt,name=activate,proto3" json:"activate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstallStrategyRequest) Reset() {
+	*x = InstallStrategyRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallStrategyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallStrategyRequest) ProtoMessage() {}
+
+func (x *InstallStrategyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallStrategyRequest.ProtoReflect.Descriptor instead.
+func (*InstallStrategyRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *InstallStrategyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InstallStrategyRequest) GetStrategy() []byte {
+	if x != nil {
+		return x.Strategy
+	}
+	return nil
+}
+
+func (x *InstallStrategyRequest) GetLists() map[string][]byte {
+	if x != nil {
+		return x.Lists
+	}
+	return nil
+}
+
+func (x *InstallStrategyRequest) GetActivate() bool {
+	if x != nil {
+		return x.Activate
+	}
+	return false
+}
+
+// InstallStrategyResponse is the response message after installing a strategy preset.
+type InstallStrategyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// message contains a status message about the install operation.
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// installed_paths lists the files written on the daemon host.
+	InstalledPaths []string `protobuf:"bytes,2,rep,name=installed_paths,json=installedPaths,proto3" json:"installed_paths,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InstallStrategyResponse) Reset() {
+	*x = InstallStrategyResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallStrategyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallStrategyResponse) ProtoMessage() {}
+
+func (x *InstallStrategyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallStrategyResponse.ProtoReflect.Descriptor instead.
+func (*InstallStrategyResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *InstallStrategyResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *InstallStrategyResponse) GetInstalledPaths() []string {
+	if x != nil {
+		return x.InstalledPaths
+	}
+	return nil
+}
+
+// ListRulesRequest is the request message for listing applied rules.
+type ListRulesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// render requests the firewall commands installing each rule.
+	Render        bool `protobuf:"varint,1,opt,name=render,proto3" json:"render,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRulesRequest) Reset() {
+	*x = ListRulesRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRulesRequest) ProtoMessage() {}
+
+func (x *ListRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRulesRequest.ProtoReflect.Descriptor instead.
+func (*ListRulesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRulesRequest) GetRender() bool {
+	if x != nil {
+		return x.Render
+	}
+	return false
+}
+
+// ListRulesResponse is the response message with applied rules.
+type ListRulesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// rules contains the applied rules in queue order.
+	Rules         []*RuleInfo `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRulesResponse) Reset() {
+	*x = ListRulesResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRulesResponse) ProtoMessage() {}
+
+func (x *ListRulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRulesResponse.ProtoReflect.Descriptor instead.
+func (*ListRulesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRulesResponse) GetRules() []*RuleInfo {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// RuleInfo describes a single applied strategy rule.
+type RuleInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// queue_num is the NFQUEUE number handling the rule.
+	QueueNum int32 `protobuf:"varint,1,opt,name=queue_num,json=queueNum,proto3" json:"queue_num,omitempty"`
+	// protocol is the rule protocol (tcp or udp).
+	Protocol string `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	// ports is the port specification of the rule.
+	Ports string `protobuf:"bytes,3,opt,name=ports,proto3" json:"ports,omitempty"`
+	// args contains the nfqws arguments of the rule.
+	Args string `protobuf:"bytes,4,opt,name=args,proto3" json:"args,omitempty"`
+	// source_line is the line in the strategy file the rule came from.
+	SourceLine int32 `protobuf:"varint,5,opt,name=source_line,json=sourceLine,proto3" json:"source_line,omitempty"`
+	// rate_limit is the maximum packets per second queued (0 for unlimited).
+	RateLimit int32 `protobuf:"varint,6,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	// packets is the number of packets sent to the queue (snapshots with counters only).
+	Packets uint64 `protobuf:"varint,7,opt,name=packets,proto3" json:"packets,omitempty"`
+	// bytes is the number of bytes sent to the queue (snapshots with counters only).
+	Bytes uint64 `protobuf:"varint,8,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	// missing_files lists referenced files that don't exist yet. A rule with
+	// missing files is pending and activates automatically once they appear.
+	MissingFiles []string `protobuf:"bytes,9,rep,name=missing_files,json=missingFiles,proto3" json:"missing_files,omitempty"`
+	// queue_preserved indicates queue_num was kept from before the last reload;
+	// false means the number was newly assigned.
+	QueuePreserved bool `protobuf:"varint,10,opt,name=queue_preserved,json=queuePreserved,proto3" json:"queue_preserved,omitempty"`
+	// firewall_commands are the backend commands installing the rule (ListRules with render only).
+	FirewallCommands []string `protobuf:"bytes,11,rep,name=firewall_commands,json=firewallCommands,proto3" json:"firewall_commands,omitempty"`
+	// stripped_args lists options removed because the installed nfqws doesn't
+	// support them (compat_mode: lenient); the rule runs degraded.
+	StrippedArgs []string `protobuf:"bytes,12,rep,name=stripped_args,json=strippedArgs,proto3" json:"stripped_args,omitempty"`
+	// compat_error is set when the rule failed because it uses unsupported options.
+	CompatError string `protobuf:"bytes,13,opt,name=compat_error,json=compatError,proto3" json:"compat_error,omitempty"`
+	// label is a short name derived from the lists the rule serves.
+	Label string `protobuf:"bytes,14,opt,name=label,proto3" json:"label,omitempty"`
+	// filtered_out indicates the active rule filter doesn't select the rule;
+	// it is neither queued nor served.
+	FilteredOut bool `protobuf:"varint,15,opt,name=filtered_out,json=filteredOut,proto3" json:"filtered_out,omitempty"`
+	// notrack indicates the matched traffic is exempt from connection tracking.
+	Notrack bool `protobuf:"varint,16,opt,name=notrack,proto3" json:"notrack,omitempty"`
+	// payload_issues lists problems of the fake payload files the rule
+	// references (empty, unreadable or not matching the payload type).
+	PayloadIssues []string `protobuf:"bytes,17,rep,name=payload_issues,json=payloadIssues,proto3" json:"payload_issues,omitempty"`
+	// active_hours are the daily windows (HH:MM-HH:MM) the rule queues traffic
+	// in; empty for always.
+	ActiveHours []string `protobuf:"bytes,18,rep,name=active_hours,json=activeHours,proto3" json:"active_hours,omitempty"`
+	// scheduled_off indicates the rule is outside its active hours: nfqws runs
+	// but the firewall rule is removed.
+	ScheduledOff bool `protobuf:"varint,19,opt,name=scheduled_off,json=scheduledOff,proto3" json:"scheduled_off,omitempty"`
+	// next_transition is when the schedule next switches the rule (RFC3339),
+	// empty for unscheduled rules.
+	NextTransition string `protobuf:"bytes,20,opt,name=next_transition,json=nextTransition,proto3" json:"next_transition,omitempty"`
+	// port_aliases are the alias names the port spec was written with (e.g. "https");
+	// ports holds the resolved numbers.
+	PortAliases []string `protobuf:"bytes,21,rep,name=port_aliases,json=portAliases,proto3" json:"port_aliases,omitempty"`
+	// ports_resolved is the port spec before exclude_ports and never_queue
+	// removed ports from it, empty when nothing was excluded; ports holds the
+	// applied spec.
+	PortsResolved string `protobuf:"bytes,22,opt,name=ports_resolved,json=portsResolved,proto3" json:"ports_resolved,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RuleInfo) Reset() {
+	*x = RuleInfo{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RuleInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuleInfo) ProtoMessage() {}
+
+func (x *RuleInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuleInfo.ProtoReflect.Descriptor instead.
+func (*RuleInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RuleInfo) GetQueueNum() int32 {
+	if x != nil {
+		return x.QueueNum
+	}
+	return 0
+}
+
+func (x *RuleInfo) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *RuleInfo) GetPorts() string {
+	if x != nil {
+		return x.Ports
+	}
+	return ""
+}
+
+func (x *RuleInfo) GetArgs() string {
+	if x != nil {
+		return x.Args
+	}
+	return ""
+}
+
+func (x *RuleInfo) GetSourceLine() int32 {
+	if x != nil {
+		return x.SourceLine
+	}
+	return 0
+}
+
+func (x *RuleInfo) GetRateLimit() int32 {
+	if x != nil {
+		return x.RateLimit
+	}
+	return 0
+}
+
+func (x *RuleInfo) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *RuleInfo) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *RuleInfo) GetMissingFiles() []string {
+	if x != nil {
+		return x.MissingFiles
+	}
+	return nil
+}
+
+func (x *RuleInfo) GetQueuePreserved() bool {
+	if x != nil {
+		return x.QueuePreserved
+	}
+	return false
+}
+
+func (x *RuleInfo) GetFirewallCommands() []string {
+	if x != nil {
+		return x.FirewallCommands
+	}
+	return nil
+}
+
+func (x *RuleInfo) GetStrippedArgs() []string {
+	if x != nil {
+		return x.StrippedArgs
+	}
+	return nil
+}
+
+func (x *RuleInfo) GetCompatError() string {
+	if x != nil {
+		return x.CompatError
+	}
+	return ""
+}
+
+func (x *RuleInfo) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *RuleInfo) GetFilteredOut() bool {
+	if x != nil {
+		return x.FilteredOut
+	}
+	return false
+}
+
+func (x *RuleInfo) GetNotrack() bool {
+	if x != nil {
+		return x.Notrack
+	}
+	return false
+}
+
+func (x *RuleInfo) GetPayloadIssues() []string {
+	if x != nil {
+		return x.PayloadIssues
+	}
+	return nil
+}
+
+func (x *RuleInfo) GetActiveHours() []string {
+	if x != nil {
+		return x.ActiveHours
+	}
+	return nil
+}
+
+func (x *RuleInfo) GetScheduledOff() bool {
+	if x != nil {
+		return x.ScheduledOff
+	}
+	return false
+}
+
+func (x *RuleInfo) GetNextTransition() string {
+	if x != nil {
+		return x.NextTransition
+	}
+	return ""
+}
+
+func (x *RuleInfo) GetPortAliases() []string {
+	if x != nil {
+		return x.PortAliases
+	}
+	return nil
+}
+
+func (x *RuleInfo) GetPortsResolved() string {
+	if x != nil {
+		return x.PortsResolved
+	}
+	return ""
+}
+
+// ExplainDomainRequest is the request message for explaining a domain.
+type ExplainDomainRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// domain is the host name to evaluate.
+	Domain        string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainDomainRequest) Reset() {
+	*x = ExplainDomainRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainDomainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainDomainRequest) ProtoMessage() {}
+
+func (x *ExplainDomainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainDomainRequest.ProtoReflect.Descriptor instead.
+func (*ExplainDomainRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ExplainDomainRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+// ExplainDomainResponse is the response message with rules relevant to a domain.
+type ExplainDomainResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// matches contains matching rules in evaluation order, followed by near misses.
+	Matches       []*DomainRuleMatch `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainDomainResponse) Reset() {
+	*x = ExplainDomainResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainDomainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainDomainResponse) ProtoMessage() {}
+
+func (x *ExplainDomainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainDomainResponse.ProtoReflect.Descriptor instead.
+func (*ExplainDomainResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExplainDomainResponse) GetMatches() []*DomainRuleMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+// DomainRuleMatch describes how a single rule relates to a domain.
+type DomainRuleMatch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// rule is the evaluated rule.
+	Rule *RuleInfo `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// matched indicates the rule would handle the domain.
+	Matched bool `protobuf:"varint,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	// reason explains why the rule matched or was skipped.
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DomainRuleMatch) Reset() {
+	*x = DomainRuleMatch{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DomainRuleMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DomainRuleMatch) ProtoMessage() {}
+
+func (x *DomainRuleMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DomainRuleMatch.ProtoReflect.Descriptor instead.
+func (*DomainRuleMatch) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DomainRuleMatch) GetRule() *RuleInfo {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+func (x *DomainRuleMatch) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+func (x *DomainRuleMatch) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// CheckPortRequest is the request message for checking port coverage.
+type CheckPortRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// protocol is "tcp" or "udp".
+	Protocol string `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	// port is the port number to evaluate.
+	Port          int32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckPortRequest) Reset() {
+	*x = CheckPortRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPortRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPortRequest) ProtoMessage() {}
+
+func (x *CheckPortRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPortRequest.ProtoReflect.Descriptor instead.
+func (*CheckPortRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CheckPortRequest) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *CheckPortRequest) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+// CheckPortResponse is the response message with the coverage of a port.
+type CheckPortResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// queued indicates that a rule queues the port.
+	Queued bool `protobuf:"varint,1,opt,name=queued,proto3" json:"queued,omitempty"`
+	// queue_num is the queue of the rule queueing the port.
+	QueueNum int32 `protobuf:"varint,2,opt,name=queue_num,json=queueNum,proto3" json:"queue_num,omitempty"`
+	// rules contains the rules of the protocol in firewall order.
+	Rules []*PortCoverage `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`
+	// external_firewall indicates that the firewall rules aren't installed by
+	// the daemon; the coverage is what the strategy asks for.
+	ExternalFirewall bool `protobuf:"varint,4,opt,name=external_firewall,json=externalFirewall,proto3" json:"external_firewall,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CheckPortResponse) Reset() {
+	*x = CheckPortResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPortResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPortResponse) ProtoMessage() {}
+
+func (x *CheckPortResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPortResponse.ProtoReflect.Descriptor instead.
+func (*CheckPortResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CheckPortResponse) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *CheckPortResponse) GetQueueNum() int32 {
+	if x != nil {
+		return x.QueueNum
+	}
+	return 0
+}
+
+func (x *CheckPortResponse) GetRules() []*PortCoverage {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *CheckPortResponse) GetExternalFirewall() bool {
+	if x != nil {
+		return x.ExternalFirewall
+	}
+	return false
+}
+
+// PortCoverage describes how a single rule's port spec covers a port.
+type PortCoverage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// rule is the evaluated rule.
+	Rule *RuleInfo `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// stages trace the port spec from the strategy file to the applied spec.
+	Stages []*PortStage `protobuf:"bytes,2,rep,name=stages,proto3" json:"stages,omitempty"`
+	// covered indicates that the applied port spec contains the port.
+	Covered bool `protobuf:"varint,3,opt,name=covered,proto3" json:"covered,omitempty"`
+	// queued indicates that the rule queues the port.
+	Queued bool `protobuf:"varint,4,opt,name=queued,proto3" json:"queued,omitempty"`
+	// reason explains why a covering rule doesn't queue the port, or the
+	// caveats of the one that does.
+	Reason        string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortCoverage) Reset() {
+	*x = PortCoverage{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortCoverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortCoverage) ProtoMessage() {}
+
+func (x *PortCoverage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortCoverage.ProtoReflect.Descriptor instead.
+func (*PortCoverage) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PortCoverage) GetRule() *RuleInfo {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+func (x *PortCoverage) GetStages() []*PortStage {
+	if x != nil {
+		return x.Stages
+	}
+	return nil
+}
+
+func (x *PortCoverage) GetCovered() bool {
+	if x != nil {
+		return x.Covered
+	}
+	return false
+}
+
+func (x *PortCoverage) GetQueued() bool {
+	if x != nil {
+		return x.Queued
+	}
+	return false
+}
+
+func (x *PortCoverage) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// PortStage is a port spec at one step of its transformations.
+type PortStage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name describes the transformation, e.g. "as written".
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// spec is the port spec after the transformation.
+	Spec string `protobuf:"bytes,2,opt,name=spec,proto3" json:"spec,omitempty"`
+	// resolved indicates that spec is numeric and could be evaluated.
+	Resolved bool `protobuf:"varint,3,opt,name=resolved,proto3" json:"resolved,omitempty"`
+	// covered indicates that spec contains the port.
+	Covered bool `protobuf:"varint,4,opt,name=covered,proto3" json:"covered,omitempty"`
+	// range is the range of spec containing the port, e.g. "1024-65535".
+	Range         string `protobuf:"bytes,5,opt,name=range,proto3" json:"range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortStage) Reset() {
+	*x = PortStage{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortStage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortStage) ProtoMessage() {}
+
+func (x *PortStage) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortStage.ProtoReflect.Descriptor instead.
+func (*PortStage) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PortStage) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PortStage) GetSpec() string {
+	if x != nil {
+		return x.Spec
+	}
+	return ""
+}
+
+func (x *PortStage) GetResolved() bool {
+	if x != nil {
+		return x.Resolved
+	}
+	return false
+}
+
+func (x *PortStage) GetCovered() bool {
+	if x != nil {
+		return x.Covered
+	}
+	return false
+}
+
+func (x *PortStage) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+// SnapshotRequest is the request message for getting a state snapshot.
+type SnapshotRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// fields selects the parts to collect: status, rules, counters, processes,
+	// health, reloads, events, rpc. Empty selects everything.
+	Fields []string `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty"`
+	// events_since is the event_cursor of a previous snapshot (0 for all retained events).
+	EventsSince   uint64 `protobuf:"varint,2,opt,name=events_since,json=eventsSince,proto3" json:"events_since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SnapshotRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *SnapshotRequest) GetEventsSince() uint64 {
+	if x != nil {
+		return x.EventsSince
+	}
+	return 0
+}
+
+// SnapshotResponse is a consistent view of the daemon state.
+type SnapshotResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// taken_at is when the snapshot was collected (RFC3339 format).
+	TakenAt string `protobuf:"bytes,1,opt,name=taken_at,json=takenAt,proto3" json:"taken_at,omitempty"`
+	// status is the strategy runner status.
+	Status *StatusResponse `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	// rules contains the applied rules, with counters if requested.
+	Rules []*RuleInfo `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`
+	// processes contains the running nfqws processes.
+	Processes []*ProcessInfo `protobuf:"bytes,4,rep,name=processes,proto3" json:"processes,omitempty"`
+	// health is the runner health: stopped, healthy, degraded, or reloading
+	// while a start, stop or reload is in progress (rules, processes and
+	// counters are then left out).
+	Health string `protobuf:"bytes,5,opt,name=health,proto3" json:"health,omitempty"`
+	// reloads contains recent reloads, newest first.
+	Reloads []*ReloadInfo `protobuf:"bytes,6,rep,name=reloads,proto3" json:"reloads,omitempty"`
+	// events contains the events after events_since, oldest first.
+	Events []*EventInfo `protobuf:"bytes,7,rep,name=events,proto3" json:"events,omitempty"`
+	// event_cursor is the value to pass as events_since on the next call.
+	EventCursor uint64 `protobuf:"varint,8,opt,name=event_cursor,json=eventCursor,proto3" json:"event_cursor,omitempty"`
+	// counters_error is set when counters were requested but could not be read.
+	CountersError string `protobuf:"bytes,9,opt,name=counters_error,json=countersError,proto3" json:"counters_error,omitempty"`
+	// health_reason explains a degraded health.
+	HealthReason string `protobuf:"bytes,12,opt,name=health_reason,json=healthReason,proto3" json:"health_reason,omitempty"`
+	// rpc_methods contains request counters and latencies per RPC method.
+	RpcMethods []*RpcMethodStats `protobuf:"bytes,10,rep,name=rpc_methods,json=rpcMethods,proto3" json:"rpc_methods,omitempty"`
+	// rpc_panics counts handler panics recovered since the daemon started.
+	RpcPanics     uint64 `protobuf:"varint,11,opt,name=rpc_panics,json=rpcPanics,proto3" json:"rpc_panics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotResponse) Reset() {
+	*x = SnapshotResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotResponse) ProtoMessage() {}
+
+func (x *SnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotResponse.ProtoReflect.Descriptor instead.
+func (*SnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SnapshotResponse) GetTakenAt() string {
+	if x != nil {
+		return x.TakenAt
+	}
+	return ""
+}
+
+func (x *SnapshotResponse) GetStatus() *StatusResponse {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *SnapshotResponse) GetRules() []*RuleInfo {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *SnapshotResponse) GetProcesses() []*ProcessInfo {
+	if x != nil {
+		return x.Processes
+	}
+	return nil
+}
+
+func (x *SnapshotResponse) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+func (x *SnapshotResponse) GetReloads() []*ReloadInfo {
+	if x != nil {
+		return x.Reloads
+	}
+	return nil
+}
+
+func (x *SnapshotResponse) GetEvents() []*EventInfo {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *SnapshotResponse) GetEventCursor() uint64 {
+	if x != nil {
+		return x.EventCursor
+	}
+	return 0
+}
+
+func (x *SnapshotResponse) GetCountersError() string {
+	if x != nil {
+		return x.CountersError
+	}
+	return ""
+}
+
+func (x *SnapshotResponse) GetHealthReason() string {
+	if x != nil {
+		return x.HealthReason
+	}
+	return ""
+}
+
+func (x *SnapshotResponse) GetRpcMethods() []*RpcMethodStats {
+	if x != nil {
+		return x.RpcMethods
+	}
+	return nil
+}
+
+func (x *SnapshotResponse) GetRpcPanics() uint64 {
+	if x != nil {
+		return x.RpcPanics
+	}
+	return 0
+}
+
+// RpcMethodStats contains the request counters of an RPC method.
+type RpcMethodStats struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// method is the RPC method name.
+	Method string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	// requests is the number of requests served.
+	Requests uint64 `protobuf:"varint,2,opt,name=requests,proto3" json:"requests,omitempty"`
+	// errors is the number of requests answered with an error.
+	Errors uint64 `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	// panics is the number of requests whose handler panicked.
+	Panics uint64 `protobuf:"varint,4,opt,name=panics,proto3" json:"panics,omitempty"`
+	// bucket_bounds are the upper bounds of the latency histogram in seconds.
+	BucketBounds []float64 `protobuf:"fixed64,5,rep,packed,name=bucket_bounds,json=bucketBounds,proto3" json:"bucket_bounds,omitempty"`
+	// bucket_counts are the cumulative request counts per bucket bound.
+	BucketCounts []uint64 `protobuf:"varint,6,rep,packed,name=bucket_counts,json=bucketCounts,proto3" json:"bucket_counts,omitempty"`
+	// duration_sum_seconds is the total time spent serving requests.
+	DurationSumSeconds float64 `protobuf:"fixed64,7,opt,name=duration_sum_seconds,json=durationSumSeconds,proto3" json:"duration_sum_seconds,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RpcMethodStats) Reset() {
+	*x = RpcMethodStats{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RpcMethodStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RpcMethodStats) ProtoMessage() {}
+
+func (x *RpcMethodStats) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RpcMethodStats.ProtoReflect.Descriptor instead.
+func (*RpcMethodStats) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RpcMethodStats) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *RpcMethodStats) GetRequests() uint64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *RpcMethodStats) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *RpcMethodStats) GetPanics() uint64 {
+	if x != nil {
+		return x.Panics
+	}
+	return 0
+}
+
+func (x *RpcMethodStats) GetBucketBounds() []float64 {
+	if x != nil {
+		return x.BucketBounds
+	}
+	return nil
+}
+
+func (x *RpcMethodStats) GetBucketCounts() []uint64 {
+	if x != nil {
+		return x.BucketCounts
+	}
+	return nil
+}
+
+func (x *RpcMethodStats) GetDurationSumSeconds() float64 {
+	if x != nil {
+		return x.DurationSumSeconds
+	}
+	return 0
+}
+
+// ProcessInfo describes a tracked nfqws process.
+type ProcessInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// pid is the process ID.
+	Pid int32 `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	// queue_num is the NFQUEUE number served by the process.
+	QueueNum int32 `protobuf:"varint,2,opt,name=queue_num,json=queueNum,proto3" json:"queue_num,omitempty"`
+	// started_at is when the process was started (RFC3339 format).
+	StartedAt string `protobuf:"bytes,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// stats contains desync statistics, unset unless process.collect_stats is enabled.
+	Stats *QueueStats `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
+	// suspended indicates that the process is stopped (SIGSTOP).
+	Suspended bool `protobuf:"varint,5,opt,name=suspended,proto3" json:"suspended,omitempty"`
+	// restarts is how often the process was restarted after crashing.
+	Restarts int32 `protobuf:"varint,6,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	// degraded indicates that the process kept crashing and is no longer
+	// restarted; pid is the last process.
+	Degraded bool `protobuf:"varint,7,opt,name=degraded,proto3" json:"degraded,omitempty"`
+	// running indicates that the process hasn't exited.
+	Running bool `protobuf:"varint,8,opt,name=running,proto3" json:"running,omitempty"`
+	// uptime_seconds is how long the process has been running, 0 once exited.
+	UptimeSeconds int64 `protobuf:"varint,9,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	// args are the nfqws arguments of the rule, without the queue number.
+	Args string `protobuf:"bytes,10,opt,name=args,proto3" json:"args,omitempty"`
+	// last_exit classifies the last time the process exited on its own:
+	// "error" (exit status), "signal" or "oom" (killed by the kernel OOM
+	// killer); empty if it never did.
+	LastExit string `protobuf:"bytes,11,opt,name=last_exit,json=lastExit,proto3" json:"last_exit,omitempty"`
+	// oom_kills is how often the process was killed by the OOM killer.
+	OomKills int32 `protobuf:"varint,12,opt,name=oom_kills,json=oomKills,proto3" json:"oom_kills,omitempty"`
+	// memory_limit_bytes is the memory limit enforced on the process, 0 for none.
+	MemoryLimitBytes int64 `protobuf:"varint,13,opt,name=memory_limit_bytes,json=memoryLimitBytes,proto3" json:"memory_limit_bytes,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ProcessInfo) Reset() {
+	*x = ProcessInfo{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessInfo) ProtoMessage() {}
+
+func (x *ProcessInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessInfo.ProtoReflect.Descriptor instead.
+func (*ProcessInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ProcessInfo) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *ProcessInfo) GetQueueNum() int32 {
+	if x != nil {
+		return x.QueueNum
+	}
+	return 0
+}
+
+func (x *ProcessInfo) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *ProcessInfo) GetStats() *QueueStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+func (x *ProcessInfo) GetSuspended() bool {
+	if x != nil {
+		return x.Suspended
+	}
+	return false
+}
+
+func (x *ProcessInfo) GetRestarts() int32 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *ProcessInfo) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+func (x *ProcessInfo) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *ProcessInfo) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *ProcessInfo) GetArgs() string {
+	if x != nil {
+		return x.Args
+	}
+	return ""
+}
+
+func (x *ProcessInfo) GetLastExit() string {
+	if x != nil {
+		return x.LastExit
+	}
+	return ""
+}
+
+func (x *ProcessInfo) GetOomKills() int32 {
+	if x != nil {
+		return x.OomKills
+	}
+	return 0
+}
+
+func (x *ProcessInfo) GetMemoryLimitBytes() int64 {
+	if x != nil {
+		return x.MemoryLimitBytes
+	}
+	return 0
+}
+
+// QueueStats contains desync statistics counted from nfqws debug output.
+type QueueStats struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// desync_applied is the number of desync actions applied.
+	DesyncApplied uint64 `protobuf:"varint,1,opt,name=desync_applied,json=desyncApplied,proto3" json:"desync_applied,omitempty"`
+	// hostlist_hits is the number of hostlist checks that matched.
+	HostlistHits uint64 `protobuf:"varint,2,opt,name=hostlist_hits,json=hostlistHits,proto3" json:"hostlist_hits,omitempty"`
+	// autohostlist_additions is the number of domains added to the auto hostlist.
+	AutohostlistAdditions uint64 `protobuf:"varint,3,opt,name=autohostlist_additions,json=autohostlistAdditions,proto3" json:"autohostlist_additions,omitempty"`
+	// parse_errors is the number of packets nfqws failed to parse.
+	ParseErrors   uint64 `protobuf:"varint,4,opt,name=parse_errors,json=parseErrors,proto3" json:"parse_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueStats) Reset() {
+	*x = QueueStats{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueStats) ProtoMessage() {}
+
+func (x *QueueStats) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueStats.ProtoReflect.Descriptor instead.
+func (*QueueStats) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *QueueStats) GetDesyncApplied() uint64 {
+	if x != nil {
+		return x.DesyncApplied
+	}
+	return 0
+}
+
+func (x *QueueStats) GetHostlistHits() uint64 {
+	if x != nil {
+		return x.HostlistHits
+	}
+	return 0
+}
+
+func (x *QueueStats) GetAutohostlistAdditions() uint64 {
+	if x != nil {
+		return x.AutohostlistAdditions
+	}
+	return 0
+}
+
+func (x *QueueStats) GetParseErrors() uint64 {
+	if x != nil {
+		return x.ParseErrors
+	}
+	return 0
+}
+
+// ReloadInfo describes a single strategy runner reload.
+type ReloadInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// time is when the reload finished (RFC3339 format).
+	Time string `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// triggers lists what requested the reload.
+	Triggers []string `protobuf:"bytes,2,rep,name=triggers,proto3" json:"triggers,omitempty"`
+	// duration_ms is how long the reload took in milliseconds.
+	DurationMs float64 `protobuf:"fixed64,3,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// error is the reload error, empty on success.
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadInfo) Reset() {
+	*x = ReloadInfo{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadInfo) ProtoMessage() {}
+
+func (x *ReloadInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadInfo.ProtoReflect.Descriptor instead.
+func (*ReloadInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ReloadInfo) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *ReloadInfo) GetTriggers() []string {
+	if x != nil {
+		return x.Triggers
+	}
+	return nil
+}
+
+func (x *ReloadInfo) GetDurationMs() float64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *ReloadInfo) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// EventInfo is a notable change in the strategy runner lifecycle.
+type EventInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// seq is the event sequence number.
+	Seq uint64 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	// time is when the event happened (RFC3339 format).
+	Time string `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// kind is the event kind.
+	Kind string `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	// message describes the event.
+	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventInfo) Reset() {
+	*x = EventInfo{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventInfo) ProtoMessage() {}
+
+func (x *EventInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventInfo.ProtoReflect.Descriptor instead.
+func (*EventInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *EventInfo) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *EventInfo) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *EventInfo) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *EventInfo) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// HostlistStatusRequest is the request message for getting hostlist update state.
+type HostlistStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostlistStatusRequest) Reset() {
+	*x = HostlistStatusRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostlistStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostlistStatusRequest) ProtoMessage() {}
+
+func (x *HostlistStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostlistStatusRequest.ProtoReflect.Descriptor instead.
+func (*HostlistStatusRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{26}
+}
+
+// HostlistStatusResponse contains the update state of each hostlist source.
+type HostlistStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sources       []*HostlistSource      `protobuf:"bytes,1,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostlistStatusResponse) Reset() {
+	*x = HostlistStatusResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostlistStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostlistStatusResponse) ProtoMessage() {}
+
+func (x *HostlistStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostlistStatusResponse.ProtoReflect.Descriptor instead.
+func (*HostlistStatusResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *HostlistStatusResponse) GetSources() []*HostlistSource {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+// HostlistSource describes a hostlist downloaded from a URL.
+type HostlistSource struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// url is where the list is downloaded from.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// path is the local list file.
+	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// last_success is the time of the last successful check (RFC3339), empty if never.
+	LastSuccess string `protobuf:"bytes,3,opt,name=last_success,json=lastSuccess,proto3" json:"last_success,omitempty"`
+	// last_error is the error of the last failed attempt, empty after a success.
+	LastError string `protobuf:"bytes,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// size is the size of the last downloaded list in bytes.
+	Size int64 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	// entries is the number of entries in the last downloaded list.
+	Entries int32 `protobuf:"varint,6,opt,name=entries,proto3" json:"entries,omitempty"`
+	// failures is the number of consecutive failed attempts.
+	Failures int32 `protobuf:"varint,7,opt,name=failures,proto3" json:"failures,omitempty"`
+	// next_attempt is when a failing source is retried (RFC3339), empty if not backing off.
+	NextAttempt   string `protobuf:"bytes,8,opt,name=next_attempt,json=nextAttempt,proto3" json:"next_attempt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostlistSource) Reset() {
+	*x = HostlistSource{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostlistSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostlistSource) ProtoMessage() {}
+
+func (x *HostlistSource) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostlistSource.ProtoReflect.Descriptor instead.
+func (*HostlistSource) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *HostlistSource) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *HostlistSource) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *HostlistSource) GetLastSuccess() string {
+	if x != nil {
+		return x.LastSuccess
+	}
+	return ""
+}
+
+func (x *HostlistSource) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *HostlistSource) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *HostlistSource) GetEntries() int32 {
+	if x != nil {
+		return x.Entries
+	}
+	return 0
+}
+
+func (x *HostlistSource) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *HostlistSource) GetNextAttempt() string {
+	if x != nil {
+		return x.NextAttempt
+	}
+	return ""
+}
+
+// ValidateStrategyRequest is the request message for validating a candidate strategy.
+type ValidateStrategyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// strategy is the content of the .bat strategy file.
+	Strategy []byte `protobuf:"bytes,1,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	// apply_simulation plans the operations a real apply would perform.
+	ApplySimulation bool `protobuf:"varint,2,opt,name=apply_simulation,json=applySimulation,proto3" json:"apply_simulation,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ValidateStrategyRequest) Reset() {
+	*x = ValidateStrategyRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateStrategyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateStrategyRequest) ProtoMessage() {}
+
+func (x *ValidateStrategyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateStrategyRequest.ProtoReflect.Descriptor instead.
+func (*ValidateStrategyRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ValidateStrategyRequest) GetStrategy() []byte {
+	if x != nil {
+		return x.Strategy
+	}
+	return nil
+}
+
+func (x *ValidateStrategyRequest) GetApplySimulation() bool {
+	if x != nil {
+		return x.ApplySimulation
+	}
+	return false
+}
+
+// ValidateStrategyResponse is the response message with the validation result.
+type ValidateStrategyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// valid indicates the strategy can be applied.
+	Valid bool `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// errors lists the problems preventing the strategy from being applied.
+	Errors []string `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	// warnings lists problems that don't prevent applying the strategy.
+	Warnings []string `protobuf:"bytes,3,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// rules are the rules the strategy would apply.
+	Rules []*RuleInfo `protobuf:"bytes,4,rep,name=rules,proto3" json:"rules,omitempty"`
+	// operations lists what a real apply would do, in order (only with apply_simulation).
+	Operations    []*PlannedOperation `protobuf:"bytes,5,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateStrategyResponse) Reset() {
+	*x = ValidateStrategyResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateStrategyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateStrategyResponse) ProtoMessage() {}
+
+func (x *ValidateStrategyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateStrategyResponse.ProtoReflect.Descriptor instead.
+func (*ValidateStrategyResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ValidateStrategyResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateStrategyResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ValidateStrategyResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *ValidateStrategyResponse) GetRules() []*RuleInfo {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *ValidateStrategyResponse) GetOperations() []*PlannedOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+// PlannedOperation is a step of a simulated apply.
+type PlannedOperation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// kind is "hook", "firewall" or "process".
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// description is the command or action performed.
+	Description   string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlannedOperation) Reset() {
+	*x = PlannedOperation{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedOperation) ProtoMessage() {}
+
+func (x *PlannedOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedOperation.ProtoReflect.Descriptor instead.
+func (*PlannedOperation) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *PlannedOperation) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *PlannedOperation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// ListPayloadsRequest is the request message for listing payload files.
+type ListPayloadsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPayloadsRequest) Reset() {
+	*x = ListPayloadsRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPayloadsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPayloadsRequest) ProtoMessage() {}
+
+func (x *ListPayloadsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPayloadsRequest.ProtoReflect.Descriptor instead.
+func (*ListPayloadsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{32}
+}
+
+// ListPayloadsResponse contains the payload files referenced by the applied strategy.
+type ListPayloadsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payloads      []*Payload             `protobuf:"bytes,1,rep,name=payloads,proto3" json:"payloads,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPayloadsResponse) Reset() {
+	*x = ListPayloadsResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPayloadsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPayloadsResponse) ProtoMessage() {}
+
+func (x *ListPayloadsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPayloadsResponse.ProtoReflect.Descriptor instead.
+func (*ListPayloadsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListPayloadsResponse) GetPayloads() []*Payload {
+	if x != nil {
+		return x.Payloads
+	}
+	return nil
+}
+
+// Payload describes a fake payload file referenced by strategy rules.
+type Payload struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// path is the payload file.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// kind is the payload type of the option referencing it (e.g. "tls", "quic").
+	Kind string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	// size is the file size in bytes.
+	Size int64 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// sha256 is the hex encoded SHA-256 of the content, empty if unreadable.
+	Sha256 string `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	// source_lines are the strategy lines of the rules using the payload.
+	SourceLines []int32 `protobuf:"varint,5,rep,packed,name=source_lines,json=sourceLines,proto3" json:"source_lines,omitempty"`
+	// queues are the queue numbers of the rules using the payload.
+	Queues []int32 `protobuf:"varint,6,rep,packed,name=queues,proto3" json:"queues,omitempty"`
+	// problem describes what is wrong with the file, empty if it looks valid.
+	Problem       string `protobuf:"bytes,7,opt,name=problem,proto3" json:"problem,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Payload) Reset() {
+	*x = Payload{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payload) ProtoMessage() {}
+
+func (x *Payload) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payload.ProtoReflect.Descriptor instead.
+func (*Payload) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Payload) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Payload) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Payload) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Payload) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *Payload) GetSourceLines() []int32 {
+	if x != nil {
+		return x.SourceLines
+	}
+	return nil
+}
+
+func (x *Payload) GetQueues() []int32 {
+	if x != nil {
+		return x.Queues
+	}
+	return nil
+}
+
+func (x *Payload) GetProblem() string {
+	if x != nil {
+		return x.Problem
+	}
+	return ""
+}
+
+// KernelQueuesRequest is the request message for the kernel queue state.
+type KernelQueuesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KernelQueuesRequest) Reset() {
+	*x = KernelQueuesRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KernelQueuesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KernelQueuesRequest) ProtoMessage() {}
+
+func (x *KernelQueuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KernelQueuesRequest.ProtoReflect.Descriptor instead.
+func (*KernelQueuesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{35}
+}
+
+// KernelQueuesResponse contains the queues of the applied rules and the queues
+// bound in the kernel, sorted by queue number.
+type KernelQueuesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queues        []*KernelQueue         `protobuf:"bytes,1,rep,name=queues,proto3" json:"queues,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KernelQueuesResponse) Reset() {
+	*x = KernelQueuesResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KernelQueuesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KernelQueuesResponse) ProtoMessage() {}
+
+func (x *KernelQueuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KernelQueuesResponse.ProtoReflect.Descriptor instead.
+func (*KernelQueuesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *KernelQueuesResponse) GetQueues() []*KernelQueue {
+	if x != nil {
+		return x.Queues
+	}
+	return nil
+}
+
+// KernelQueue is a NFQUEUE queue as reported by /proc/net/netfilter/nfnetlink_queue.
+type KernelQueue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// queue is the queue number.
+	Queue int32 `protobuf:"varint,1,opt,name=queue,proto3" json:"queue,omitempty"`
+	// label and source_line identify the rule feeding the queue (empty and 0 if none).
+	Label      string `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	SourceLine int32  `protobuf:"varint,3,opt,name=source_line,json=sourceLine,proto3" json:"source_line,omitempty"`
+	// installed indicates a firewall rule currently feeds the queue.
+	Installed bool `protobuf:"varint,4,opt,name=installed,proto3" json:"installed,omitempty"`
+	// bound indicates a consumer is bound to the queue.
+	Bound bool `protobuf:"varint,5,opt,name=bound,proto3" json:"bound,omitempty"`
+	// peer_portid is the netlink port of the consumer.
+	PeerPortid uint32 `protobuf:"varint,6,opt,name=peer_portid,json=peerPortid,proto3" json:"peer_portid,omitempty"`
+	// pid is the consumer process, 0 if unbound or unresolved.
+	Pid int32 `protobuf:"varint,7,opt,name=pid,proto3" json:"pid,omitempty"`
+	// length is the number of packets waiting for a verdict.
+	Length int32 `protobuf:"varint,8,opt,name=length,proto3" json:"length,omitempty"`
+	// copy_mode is "none", "meta" or "packet".
+	CopyMode string `protobuf:"bytes,9,opt,name=copy_mode,json=copyMode,proto3" json:"copy_mode,omitempty"`
+	// copy_range is the number of packet bytes copied to the consumer.
+	CopyRange int32 `protobuf:"varint,10,opt,name=copy_range,json=copyRange,proto3" json:"copy_range,omitempty"`
+	// dropped counts packets dropped because the queue was full.
+	Dropped uint64 `protobuf:"varint,11,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	// user_dropped counts packets that couldn't be sent to the consumer.
+	UserDropped uint64 `protobuf:"varint,12,opt,name=user_dropped,json=userDropped,proto3" json:"user_dropped,omitempty"`
+	// drops_growing indicates a drop counter increased since the previous query.
+	DropsGrowing bool `protobuf:"varint,13,opt,name=drops_growing,json=dropsGrowing,proto3" json:"drops_growing,omitempty"`
+	// problems lists anomalies found with the queue.
+	Problems []string `protobuf:"bytes,14,rep,name=problems,proto3" json:"problems,omitempty"`
+	// unknown indicates that the kernel doesn't expose the queue state
+	// (nfnetlink_queue not loaded or /proc/net/netfilter hidden); bound, pid,
+	// length and the drop counters are unknown.
+	Unknown       bool `protobuf:"varint,15,opt,name=unknown,proto3" json:"unknown,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KernelQueue) Reset() {
+	*x = KernelQueue{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KernelQueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KernelQueue) ProtoMessage() {}
+
+func (x *KernelQueue) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KernelQueue.ProtoReflect.Descriptor instead.
+func (*KernelQueue) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *KernelQueue) GetQueue() int32 {
+	if x != nil {
+		return x.Queue
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *KernelQueue) GetSourceLine() int32 {
+	if x != nil {
+		return x.SourceLine
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetInstalled() bool {
+	if x != nil {
+		return x.Installed
+	}
+	return false
+}
+
+func (x *KernelQueue) GetBound() bool {
+	if x != nil {
+		return x.Bound
+	}
+	return false
+}
+
+func (x *KernelQueue) GetPeerPortid() uint32 {
+	if x != nil {
+		return x.PeerPortid
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetLength() int32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetCopyMode() string {
+	if x != nil {
+		return x.CopyMode
+	}
+	return ""
+}
+
+func (x *KernelQueue) GetCopyRange() int32 {
+	if x != nil {
+		return x.CopyRange
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetUserDropped() uint64 {
+	if x != nil {
+		return x.UserDropped
+	}
+	return 0
+}
+
+func (x *KernelQueue) GetDropsGrowing() bool {
+	if x != nil {
+		return x.DropsGrowing
+	}
+	return false
+}
+
+func (x *KernelQueue) GetProblems() []string {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+func (x *KernelQueue) GetUnknown() bool {
+	if x != nil {
+		return x.Unknown
+	}
+	return false
+}
+
+// ChangelogRequest is the request message for the applied configuration changelog.
+type ChangelogRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// since returns only entries at or after this time (RFC3339, empty for all).
+	Since string `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	// after_seq returns only entries with a higher sequence number; pass the
+	// next_seq of the previous page to continue.
+	AfterSeq uint64 `protobuf:"varint,2,opt,name=after_seq,json=afterSeq,proto3" json:"after_seq,omitempty"`
+	// limit is the maximum number of entries returned (default 50, at most 500).
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangelogRequest) Reset() {
+	*x = ChangelogRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangelogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangelogRequest) ProtoMessage() {}
+
+func (x *ChangelogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangelogRequest.ProtoReflect.Descriptor instead.
+func (*ChangelogRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ChangelogRequest) GetSince() string {
+	if x != nil {
+		return x.Since
+	}
+	return ""
+}
+
+func (x *ChangelogRequest) GetAfterSeq() uint64 {
+	if x != nil {
+		return x.AfterSeq
+	}
+	return 0
+}
+
+func (x *ChangelogRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// ChangelogResponse is a page of the applied configuration changelog.
+type ChangelogResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// entries are the changelog entries, oldest first.
+	Entries []*ChangelogEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// next_seq is the after_seq of the next page, 0 when there are no more entries.
+	NextSeq uint64 `protobuf:"varint,2,opt,name=next_seq,json=nextSeq,proto3" json:"next_seq,omitempty"`
+	// chain_error describes the first break of the hash chain across all
+	// retained entries, empty if the chain is intact.
+	ChainError    string `protobuf:"bytes,3,opt,name=chain_error,json=chainError,proto3" json:"chain_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangelogResponse) Reset() {
+	*x = ChangelogResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangelogResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangelogResponse) ProtoMessage() {}
+
+func (x *ChangelogResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangelogResponse.ProtoReflect.Descriptor instead.
+func (*ChangelogResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ChangelogResponse) GetEntries() []*ChangelogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ChangelogResponse) GetNextSeq() uint64 {
+	if x != nil {
+		return x.NextSeq
+	}
+	return 0
+}
+
+func (x *ChangelogResponse) GetChainError() string {
+	if x != nil {
+		return x.ChainError
+	}
+	return ""
+}
+
+// ChangelogEntry records one successful apply of the configuration.
+type ChangelogEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// seq is the entry sequence number, increasing from 1.
+	Seq uint64 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	// time is when the configuration was applied (RFC3339 format).
+	Time string `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// strategy_file is the applied strategy file.
+	StrategyFile string `protobuf:"bytes,3,opt,name=strategy_file,json=strategyFile,proto3" json:"strategy_file,omitempty"`
+	// strategy_hash is the SHA-256 of the strategy file content.
+	StrategyHash string `protobuf:"bytes,4,opt,name=strategy_hash,json=strategyHash,proto3" json:"strategy_hash,omitempty"`
+	// firewall_backend is the firewall backend the rules were installed with.
+	FirewallBackend string `protobuf:"bytes,5,opt,name=firewall_backend,json=firewallBackend,proto3" json:"firewall_backend,omitempty"`
+	// rules are the applied rules.
+	Rules []*ChangelogRule `protobuf:"bytes,6,rep,name=rules,proto3" json:"rules,omitempty"`
+	// added are the rules not applied by the previous entry.
+	Added []*ChangelogRule `protobuf:"bytes,7,rep,name=added,proto3" json:"added,omitempty"`
+	// removed are the rules of the previous entry no longer applied.
+	Removed []*ChangelogRule `protobuf:"bytes,8,rep,name=removed,proto3" json:"removed,omitempty"`
+	// strategy_changed indicates the strategy content differs from the previous entry.
+	StrategyChanged bool `protobuf:"varint,9,opt,name=strategy_changed,json=strategyChanged,proto3" json:"strategy_changed,omitempty"`
+	// previous_backend is the firewall backend of the previous entry, set when it changed.
+	PreviousBackend string `protobuf:"bytes,10,opt,name=previous_backend,json=previousBackend,proto3" json:"previous_backend,omitempty"`
+	// prev_hash is the hash of the previous entry.
+	PrevHash string `protobuf:"bytes,11,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	// hash is the SHA-256 of the entry, chaining it to the next one.
+	Hash          string `protobuf:"bytes,12,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangelogEntry) Reset() {
+	*x = ChangelogEntry{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangelogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangelogEntry) ProtoMessage() {}
+
+func (x *ChangelogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangelogEntry.ProtoReflect.Descriptor instead.
+func (*ChangelogEntry) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ChangelogEntry) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ChangelogEntry) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *ChangelogEntry) GetStrategyFile() string {
+	if x != nil {
+		return x.StrategyFile
+	}
+	return ""
+}
+
+func (x *ChangelogEntry) GetStrategyHash() string {
+	if x != nil {
+		return x.StrategyHash
+	}
+	return ""
+}
+
+func (x *ChangelogEntry) GetFirewallBackend() string {
+	if x != nil {
+		return x.FirewallBackend
+	}
+	return ""
+}
+
+func (x *ChangelogEntry) GetRules() []*ChangelogRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *ChangelogEntry) GetAdded() []*ChangelogRule {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *ChangelogEntry) GetRemoved() []*ChangelogRule {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *ChangelogEntry) GetStrategyChanged() bool {
+	if x != nil {
+		return x.StrategyChanged
+	}
+	return false
+}
+
+func (x *ChangelogEntry) GetPreviousBackend() string {
+	if x != nil {
+		return x.PreviousBackend
+	}
+	return ""
+}
+
+func (x *ChangelogEntry) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *ChangelogEntry) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+// ChangelogRule is an applied rule as recorded in the changelog.
+type ChangelogRule struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Protocol string                 `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Ports    string                 `protobuf:"bytes,2,opt,name=ports,proto3" json:"ports,omitempty"`
+	Queue    int32                  `protobuf:"varint,3,opt,name=queue,proto3" json:"queue,omitempty"`
+	// args_hash identifies the nfqws arguments of the rule.
+	ArgsHash      string `protobuf:"bytes,4,opt,name=args_hash,json=argsHash,proto3" json:"args_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangelogRule) Reset() {
+	*x = ChangelogRule{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangelogRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangelogRule) ProtoMessage() {}
+
+func (x *ChangelogRule) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangelogRule.ProtoReflect.Descriptor instead.
+func (*ChangelogRule) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ChangelogRule) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *ChangelogRule) GetPorts() string {
+	if x != nil {
+		return x.Ports
+	}
+	return ""
+}
+
+func (x *ChangelogRule) GetQueue() int32 {
+	if x != nil {
+		return x.Queue
+	}
+	return 0
+}
+
+func (x *ChangelogRule) GetArgsHash() string {
+	if x != nil {
+		return x.ArgsHash
+	}
+	return ""
+}
+
+type CapabilitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapabilitiesRequest) Reset() {
+	*x = CapabilitiesRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapabilitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapabilitiesRequest) ProtoMessage() {}
+
+func (x *CapabilitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapabilitiesRequest.ProtoReflect.Descriptor instead.
+func (*CapabilitiesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{42}
+}
+
+type CapabilitiesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// platform is the GOOS/GOARCH the daemon was built for.
+	Platform string `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	// features are the compiled-in firewall backends and optional features.
+	Features []*CompiledFeature `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
+	// kernel_capabilities are the probed kernel features (empty when the
+	// strategy runner is disabled).
+	KernelCapabilities []*KernelCapability `protobuf:"bytes,3,rep,name=kernel_capabilities,json=kernelCapabilities,proto3" json:"kernel_capabilities,omitempty"`
+	// privileges are the results of the privilege checks.
+	Privileges []*PrivilegeCheck `protobuf:"bytes,4,rep,name=privileges,proto3" json:"privileges,omitempty"`
+	// nfqws classifies the configured nfqws binary (unset when the strategy
+	// runner is disabled).
+	Nfqws *NfqwsInfo `protobuf:"bytes,5,opt,name=nfqws,proto3" json:"nfqws,omitempty"`
+	// subsystems lists the optional subsystems and whether they are enabled.
+	Subsystems    []*Subsystem `protobuf:"bytes,6,rep,name=subsystems,proto3" json:"subsystems,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapabilitiesResponse) Reset() {
+	*x = CapabilitiesResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapabilitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapabilitiesResponse) ProtoMessage() {}
+
+func (x *CapabilitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapabilitiesResponse.ProtoReflect.Descriptor instead.
+func (*CapabilitiesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *CapabilitiesResponse) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *CapabilitiesResponse) GetFeatures() []*CompiledFeature {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *CapabilitiesResponse) GetKernelCapabilities() []*KernelCapability {
+	if x != nil {
+		return x.KernelCapabilities
+	}
+	return nil
+}
+
+func (x *CapabilitiesResponse) GetPrivileges() []*PrivilegeCheck {
+	if x != nil {
+		return x.Privileges
+	}
+	return nil
+}
+
+func (x *CapabilitiesResponse) GetNfqws() *NfqwsInfo {
+	if x != nil {
+		return x.Nfqws
+	}
+	return nil
+}
+
+func (x *CapabilitiesResponse) GetSubsystems() []*Subsystem {
+	if x != nil {
+		return x.Subsystems
+	}
+	return nil
+}
+
+// CompiledFeature is a backend or feature compiled into the daemon.
+type CompiledFeature struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// kind is "firewall" or "feature".
+	Kind          string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Name          string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Detail        string `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompiledFeature) Reset() {
+	*x = CompiledFeature{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompiledFeature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompiledFeature) ProtoMessage() {}
+
+func (x *CompiledFeature) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompiledFeature.ProtoReflect.Descriptor instead.
+func (*CompiledFeature) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CompiledFeature) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *CompiledFeature) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CompiledFeature) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// PrivilegeCheck is the result of checking a privilege of the daemon.
+type PrivilegeCheck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is "root" or a Linux capability such as "CAP_NET_ADMIN".
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ok            bool   `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Detail        string `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrivilegeCheck) Reset() {
+	*x = PrivilegeCheck{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrivilegeCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrivilegeCheck) ProtoMessage() {}
+
+func (x *PrivilegeCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrivilegeCheck.ProtoReflect.Descriptor instead.
+func (*PrivilegeCheck) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *PrivilegeCheck) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PrivilegeCheck) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *PrivilegeCheck) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// NfqwsInfo classifies the configured nfqws binary.
+type NfqwsInfo struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Binary string                 `protobuf:"bytes,1,opt,name=binary,proto3" json:"binary,omitempty"`
+	// kind is "nfqws", "unknown" or "unavailable".
+	Kind string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	// option_count is the number of options listed by --help.
+	OptionCount int32 `protobuf:"varint,3,opt,name=option_count,json=optionCount,proto3" json:"option_count,omitempty"`
+	// supported lists the optional options the binary supports.
+	Supported []string `protobuf:"bytes,4,rep,name=supported,proto3" json:"supported,omitempty"`
+	// unsupported lists the optional options it lacks, with the feature needing each.
+	Unsupported []string `protobuf:"bytes,5,rep,name=unsupported,proto3" json:"unsupported,omitempty"`
+	// error explains why the binary is unavailable.
+	Error string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	// external indicates nfqws is supervised externally.
+	External      bool `protobuf:"varint,7,opt,name=external,proto3" json:"external,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NfqwsInfo) Reset() {
+	*x = NfqwsInfo{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NfqwsInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NfqwsInfo) ProtoMessage() {}
+
+func (x *NfqwsInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NfqwsInfo.ProtoReflect.Descriptor instead.
+func (*NfqwsInfo) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *NfqwsInfo) GetBinary() string {
+	if x != nil {
+		return x.Binary
+	}
+	return ""
+}
+
+func (x *NfqwsInfo) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *NfqwsInfo) GetOptionCount() int32 {
+	if x != nil {
+		return x.OptionCount
+	}
+	return 0
+}
+
+func (x *NfqwsInfo) GetSupported() []string {
+	if x != nil {
+		return x.Supported
+	}
+	return nil
+}
+
+func (x *NfqwsInfo) GetUnsupported() []string {
+	if x != nil {
+		return x.Unsupported
+	}
+	return nil
+}
+
+func (x *NfqwsInfo) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *NfqwsInfo) GetExternal() bool {
+	if x != nil {
+		return x.External
+	}
+	return false
+}
+
+// Subsystem is an optional part of the daemon.
+type Subsystem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Enabled       bool                   `protobuf:"varint,2,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Detail        string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subsystem) Reset() {
+	*x = Subsystem{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subsystem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subsystem) ProtoMessage() {}
+
+func (x *Subsystem) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subsystem.ProtoReflect.Descriptor instead.
+func (*Subsystem) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *Subsystem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Subsystem) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Subsystem) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type DiagnosticsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnosticsRequest) Reset() {
+	*x = DiagnosticsRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnosticsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnosticsRequest) ProtoMessage() {}
+
+func (x *DiagnosticsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnosticsRequest.ProtoReflect.Descriptor instead.
+func (*DiagnosticsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{48}
+}
+
+type DiagnosticsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// checks are the findings in the order the checks ran.
+	Checks        []*DiagnosticCheck `protobuf:"bytes,1,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnosticsResponse) Reset() {
+	*x = DiagnosticsResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnosticsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnosticsResponse) ProtoMessage() {}
+
+func (x *DiagnosticsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnosticsResponse.ProtoReflect.Descriptor instead.
+func (*DiagnosticsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *DiagnosticsResponse) GetChecks() []*DiagnosticCheck {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+// DiagnosticCheck is a finding of a diagnostic check.
+type DiagnosticCheck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is the check, e.g. "privileges" or "hostlists".
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// state is "pass", "warn", "fail" or "skip".
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// message describes the finding.
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// suggestion is a one-line fix, empty when there is nothing to fix.
+	Suggestion    string `protobuf:"bytes,4,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnosticCheck) Reset() {
+	*x = DiagnosticCheck{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnosticCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnosticCheck) ProtoMessage() {}
+
+func (x *DiagnosticCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnosticCheck.ProtoReflect.Descriptor instead.
+func (*DiagnosticCheck) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *DiagnosticCheck) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DiagnosticCheck) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *DiagnosticCheck) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DiagnosticCheck) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+// SuspendProcessesRequest is the request message for suspending nfqws.
+type SuspendProcessesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// timeout is how long to suspend at most, as a Go duration like "45m"
+	// (empty for process.suspend_timeout).
+	Timeout       string `protobuf:"bytes,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendProcessesRequest) Reset() {
+	*x = SuspendProcessesRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendProcessesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendProcessesRequest) ProtoMessage() {}
+
+func (x *SuspendProcessesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendProcessesRequest.ProtoReflect.Descriptor instead.
+func (*SuspendProcessesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *SuspendProcessesRequest) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
+	}
+	return ""
+}
+
+// SuspendProcessesResponse is the response message for suspending nfqws.
+type SuspendProcessesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// processes is the number of processes stopped.
+	Processes int32 `protobuf:"varint,1,opt,name=processes,proto3" json:"processes,omitempty"`
+	// resume_at is when the processes are resumed automatically (RFC3339 format).
+	ResumeAt      string `protobuf:"bytes,2,opt,name=resume_at,json=resumeAt,proto3" json:"resume_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuspendProcessesResponse) Reset() {
+	*x = SuspendProcessesResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuspendProcessesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendProcessesResponse) ProtoMessage() {}
+
+func (x *SuspendProcessesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendProcessesResponse.ProtoReflect.Descriptor instead.
+func (*SuspendProcessesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *SuspendProcessesResponse) GetProcesses() int32 {
+	if x != nil {
+		return x.Processes
+	}
+	return 0
+}
+
+func (x *SuspendProcessesResponse) GetResumeAt() string {
+	if x != nil {
+		return x.ResumeAt
+	}
+	return ""
+}
+
+// ResumeProcessesRequest is the request message for resuming nfqws.
+type ResumeProcessesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeProcessesRequest) Reset() {
+	*x = ResumeProcessesRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeProcessesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeProcessesRequest) ProtoMessage() {}
+
+func (x *ResumeProcessesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeProcessesRequest.ProtoReflect.Descriptor instead.
+func (*ResumeProcessesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{53}
+}
+
+// ResumeProcessesResponse is the response message for resuming nfqws.
+type ResumeProcessesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// processes is the number of processes continued.
+	Processes     int32 `protobuf:"varint,1,opt,name=processes,proto3" json:"processes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeProcessesResponse) Reset() {
+	*x = ResumeProcessesResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeProcessesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeProcessesResponse) ProtoMessage() {}
+
+func (x *ResumeProcessesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeProcessesResponse.ProtoReflect.Descriptor instead.
+func (*ResumeProcessesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ResumeProcessesResponse) GetProcesses() int32 {
+	if x != nil {
+		return x.Processes
+	}
+	return 0
+}
+
+// StartRequest is the request message for starting the strategy runner.
+type StartRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRequest) Reset() {
+	*x = StartRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRequest) ProtoMessage() {}
+
+func (x *StartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{55}
+}
+
+// StartResponse is the response message for starting the strategy runner.
+type StartResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// message describes what was done.
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// running reports whether the strategy runner is running afterwards.
+	Running       bool `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartResponse) Reset() {
+	*x = StartResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartResponse) ProtoMessage() {}
+
+func (x *StartResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartResponse.ProtoReflect.Descriptor instead.
+func (*StartResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *StartResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StartResponse) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+// StopRequest is the request message for stopping the strategy runner.
+type StopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{57}
+}
+
+// StopResponse is the response message for stopping the strategy runner.
+type StopResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// message describes what was done.
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// running reports whether the strategy runner is running afterwards.
+	Running       bool `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopResponse) ProtoMessage() {}
+
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *StopResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StopResponse) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+// ProbeDNSRequest is the request message for probing the DNS answers of a domain.
+type ProbeDNSRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// domain is the domain to resolve.
+	Domain        string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeDNSRequest) Reset() {
+	*x = ProbeDNSRequest{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeDNSRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeDNSRequest) ProtoMessage() {}
+
+func (x *ProbeDNSRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeDNSRequest.ProtoReflect.Descriptor instead.
+func (*ProbeDNSRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *ProbeDNSRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+// ProbeDNSResponse is the response message with the DNS probe verdict.
+type ProbeDNSResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// domain is the normalized domain.
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// verdict is "ok", "mismatch", "bogus" or "error".
+	Verdict string `protobuf:"bytes,2,opt,name=verdict,proto3" json:"verdict,omitempty"`
+	// detail explains the verdict.
+	Detail string `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	// system_addrs are the addresses from the system resolver.
+	SystemAddrs []string `protobuf:"bytes,4,rep,name=system_addrs,json=systemAddrs,proto3" json:"system_addrs,omitempty"`
+	// system_error is why the system resolver failed, if it did.
+	SystemError string `protobuf:"bytes,5,opt,name=system_error,json=systemError,proto3" json:"system_error,omitempty"`
+	// doh_addrs are the addresses from the DoH server.
+	DohAddrs []string `protobuf:"bytes,6,rep,name=doh_addrs,json=dohAddrs,proto3" json:"doh_addrs,omitempty"`
+	// doh_error is why the DoH query failed, if it did.
+	DohError string `protobuf:"bytes,7,opt,name=doh_error,json=dohError,proto3" json:"doh_error,omitempty"`
+	// bogus_addrs are the system answers in a bogus range.
+	BogusAddrs []string `protobuf:"bytes,8,rep,name=bogus_addrs,json=bogusAddrs,proto3" json:"bogus_addrs,omitempty"`
+	// state is the diagnostic state of the verdict: "pass", "warn" or "fail".
+	State string `protobuf:"bytes,9,opt,name=state,proto3" json:"state,omitempty"`
+	// suggestion is a one-line fix, empty when there is nothing to fix.
+	Suggestion    string `protobuf:"bytes,10,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeDNSResponse) Reset() {
+	*x = ProbeDNSResponse{}
+	mi := &file_rpc_daemon_service_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeDNSResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeDNSResponse) ProtoMessage() {}
+
+func (x *ProbeDNSResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_daemon_service_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeDNSResponse.ProtoReflect.Descriptor instead.
+func (*ProbeDNSResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_daemon_service_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *ProbeDNSResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ProbeDNSResponse) GetVerdict() string {
+	if x != nil {
+		return x.Verdict
+	}
+	return ""
+}
+
+func (x *ProbeDNSResponse) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *ProbeDNSResponse) GetSystemAddrs() []string {
+	if x != nil {
+		return x.SystemAddrs
+	}
+	return nil
+}
+
+func (x *ProbeDNSResponse) GetSystemError() string {
+	if x != nil {
+		return x.SystemError
+	}
+	return ""
+}
+
+func (x *ProbeDNSResponse) GetDohAddrs() []string {
+	if x != nil {
+		return x.DohAddrs
+	}
+	return nil
+}
+
+func (x *ProbeDNSResponse) GetDohError() string {
+	if x != nil {
+		return x.DohError
+	}
+	return ""
+}
+
+func (x *ProbeDNSResponse) GetBogusAddrs() []string {
+	if x != nil {
+		return x.BogusAddrs
+	}
+	return nil
+}
+
+func (x *ProbeDNSResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ProbeDNSResponse) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+var File_rpc_daemon_service_proto protoreflect.FileDescriptor
+
+const file_rpc_daemon_service_proto_rawDesc = "" +
+	"\n" +
+	"\x18rpc/daemon/service.proto\x12\x06daemon\"\xd0\x01\n" +
+	"\x0eRestartRequest\x12\x14\n" +
+	"\x05force\x18\x01 \x01(\bR\x05force\x12\x1d\n" +
+	"\n" +
+	"only_label\x18\x02 \x01(\tR\tonlyLabel\x12\x1d\n" +
+	"\n" +
+	"only_proto\x18\x03 \x01(\tR\tonlyProto\x12\x1b\n" +
+	"\tonly_port\x18\x04 \x01(\tR\bonlyPort\x12\x1d\n" +
+	"\n" +
+	"only_rules\x18\x05 \x03(\x05R\tonlyRules\x12\x16\n" +
+	"\x06canary\x18\x06 \x01(\bR\x06canary\x12\x16\n" +
+	"\x06detach\x18\a \x01(\bR\x06detach\"N\n" +
+	"\x0fRestartResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12!\n" +
+	"\frestarted_at\x18\x02 \x01(\tR\vrestartedAt\"\x0f\n" +
+	"\rStatusRequest\"\x95\r\n" +
+	"\x0eStatusResponse\x12\x18\n" +
+	"\arunning\x18\x01 \x01(\bR\arunning\x12#\n" +
+	"\rstrategy_file\x18\x02 \x01(\tR\fstrategyFile\x12#\n" +
+	"\ractive_queues\x18\x03 \x01(\x05R\factiveQueues\x12)\n" +
+	"\x10active_processes\x18\x04 \x01(\x05R\x0factiveProcesses\x12)\n" +
+	"\x10firewall_backend\x18\x05 \x01(\tR\x0ffirewallBackend\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x06 \x01(\tR\tstartTime\x12-\n" +
+	"\x13firewall_last_op_ms\x18\a \x01(\x01R\x10firewallLastOpMs\x12+\n" +
+	"\x12firewall_max_op_ms\x18\b \x01(\x01R\x0ffirewallMaxOpMs\x120\n" +
+	"\x14hostlist_index_bytes\x18\t \x01(\x03R\x12hostlistIndexBytes\x12#\n" +
+	"\rpending_rules\x18\n" +
+	" \x01(\x05R\fpendingRules\x120\n" +
+	"\aoffload\x18\v \x03(\v2\x16.daemon.OffloadFindingR\aoffload\x12-\n" +
+	"\x12process_management\x18\f \x01(\tR\x11processManagement\x12/\n" +
+	"\x13firewall_management\x18\r \x01(\tR\x12firewallManagement\x12%\n" +
+	"\x0eunbound_queues\x18\x0e \x03(\x05R\runboundQueues\x12$\n" +
+	"\x0equeue_map_file\x18\x0f \x01(\tR\fqueueMapFile\x12\x1f\n" +
+	"\vrule_filter\x18\x10 \x01(\tR\n" +
+	"ruleFilter\x12%\n" +
+	"\x0efiltered_rules\x18\x11 \x01(\x05R\rfilteredRules\x12'\n" +
+	"\x0fstrategy_source\x18\x12 \x01(\tR\x0estrategySource\x12\x14\n" +
+	"\x05phase\x18\x13 \x01(\tR\x05phase\x12.\n" +
+	"\x13scheduled_off_rules\x18\x14 \x01(\x05R\x11scheduledOffRules\x120\n" +
+	"\x14config_poll_interval\x18\x15 \x01(\tR\x12configPollInterval\x12%\n" +
+	"\x0ewatch_fallback\x18\x16 \x01(\bR\rwatchFallback\x12#\n" +
+	"\rempty_ruleset\x18\x17 \x01(\bR\femptyRuleset\x12.\n" +
+	"\tconflicts\x18\x18 \x03(\v2\x10.daemon.ConflictR\tconflicts\x12I\n" +
+	"\x13kernel_capabilities\x18\x19 \x03(\v2\x18.daemon.KernelCapabilityR\x12kernelCapabilities\x12-\n" +
+	"\x12netlink_reconnects\x18\x1a \x01(\x04R\x11netlinkReconnects\x12'\n" +
+	"\x0fsuspended_until\x18\x1b \x01(\tR\x0esuspendedUntil\x12)\n" +
+	"\x10suspended_queues\x18\x1c \x03(\x05R\x0fsuspendedQueues\x12'\n" +
+	"\x0fdegraded_queues\x18\x1d \x03(\x05R\x0edegradedQueues\x12:\n" +
+	"\x19kernel_queues_unavailable\x18\x1e \x01(\bR\x17kernelQueuesUnavailable\x12%\n" +
+	"\x0econfig_version\x18\x1f \x01(\tR\rconfigVersion\x12#\n" +
+	"\rapply_pending\x18  \x01(\bR\fapplyPending\x121\n" +
+	"\tprocesses\x18! \x03(\v2\x13.daemon.ProcessInfoR\tprocesses\x12%\n" +
+	"\x0ezombies_reaped\x18\" \x01(\x04R\rzombiesReaped\x12%\n" +
+	"\x0efirewall_rules\x18# \x01(\x05R\rfirewallRules\x12)\n" +
+	"\x10process_restarts\x18$ \x01(\x04R\x0fprocessRestarts\x12%\n" +
+	"\x0econfig_reloads\x18% \x01(\x04R\rconfigReloads\x12!\n" +
+	"\fparse_errors\x18& \x01(\x04R\vparseErrors\x12\x1d\n" +
+	"\n" +
+	"phase_done\x18' \x01(\x05R\tphaseDone\x12\x1f\n" +
+	"\vphase_total\x18( \x01(\x05R\n" +
+	"phaseTotal\"l\n" +
+	"\x10KernelCapability\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x16\n" +
+	"\x06module\x18\x03 \x01(\tR\x06module\x12\x16\n" +
+	"\x06detail\x18\x04 \x01(\tR\x06detail\"J\n" +
+	"\bConflict\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06remedy\x18\x03 \x01(\tR\x06remedy\"\x97\x01\n" +
+	"\x0eOffloadFinding\x12\x1c\n" +
+	"\tinterface\x18\x01 \x01(\tR\tinterface\x12\x1a\n" +
+	"\bfeatures\x18\x02 \x03(\tR\bfeatures\x12\x1f\n" +
+	"\vfix_command\x18\x03 \x01(\tR\n" +
+	"fixCommand\x12\x14\n" +
+	"\x05fixed\x18\x04 \x01(\bR\x05fixed\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\xdf\x01\n" +
+	"\x16InstallStrategyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bstrategy\x18\x02 \x01(\fR\bstrategy\x12?\n" +
+	"\x05lists\x18\x03 \x03(\v2).daemon.InstallStrategyRequest.ListsEntryR\x05lists\x12\x1a\n" +
+	"\bactivate\x18\x04 \x01(\bR\bactivate\x1a8\n" +
+	"\n" +
+	"ListsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\\\n" +
+	"\x17InstallStrategyResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12'\n" +
+	"\x0finstalled_paths\x18\x02 \x03(\tR\x0einstalledPaths\"*\n" +
+	"\x10ListRulesRequest\x12\x16\n" +
+	"\x06render\x18\x01 \x01(\bR\x06render\";\n" +
+	"\x11ListRulesResponse\x12&\n" +
+	"\x05rules\x18\x01 \x03(\v2\x10.daemon.RuleInfoR\x05rules\"\xd5\x05\n" +
+	"\bRuleInfo\x12\x1b\n" +
+	"\tqueue_num\x18\x01 \x01(\x05R\bqueueNum\x12\x1a\n" +
+	"\bprotocol\x18\x02 \x01(\tR\bprotocol\x12\x14\n" +
+	"\x05ports\x18\x03 \x01(\tR\x05ports\x12\x12\n" +
+	"\x04args\x18\x04 \x01(\tR\x04args\x12\x1f\n" +
+	"\vsource_line\x18\x05 \x01(\x05R\n" +
+	"sourceLine\x12\x1d\n" +
+	"\n" +
+	"rate_limit\x18\x06 \x01(\x05R\trateLimit\x12\x18\n" +
+	"\apackets\x18\a \x01(\x04R\apackets\x12\x14\n" +
+	"\x05bytes\x18\b \x01(\x04R\x05bytes\x12#\n" +
+	"\rmissing_files\x18\t \x03(\tR\fmissingFiles\x12'\n" +
+	"\x0fqueue_preserved\x18\n" +
+	" \x01(\bR\x0equeuePreserved\x12+\n" +
+	"\x11firewall_commands\x18\v \x03(\tR\x10firewallCommands\x12#\n" +
+	"\rstripped_args\x18\f \x03(\tR\fstrippedArgs\x12!\n" +
+	"\fcompat_error\x18\r \x01(\tR\vcompatError\x12\x14\n" +
+	"\x05label\x18\x0e \x01(\tR\x05label\x12!\n" +
+	"\ffiltered_out\x18\x0f \x01(\bR\vfilteredOut\x12\x18\n" +
+	"\anotrack\x18\x10 \x01(\bR\anotrack\x12%\n" +
+	"\x0epayload_issues\x18\x11 \x03(\tR\rpayloadIssues\x12!\n" +
+	"\factive_hours\x18\x12 \x03(\tR\vactiveHours\x12#\n" +
+	"\rscheduled_off\x18\x13 \x01(\bR\fscheduledOff\x12'\n" +
+	"\x0fnext_transition\x18\x14 \x01(\tR\x0enextTransition\x12!\n" +
+	"\fport_aliases\x18\x15 \x03(\tR\vportAliases\x12%\n" +
+	"\x0eports_resolved\x18\x16 \x01(\tR\rportsResolved\".\n" +
+	"\x14ExplainDomainRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\"J\n" +
+	"\x15ExplainDomainResponse\x121\n" +
+	"\amatches\x18\x01 \x03(\v2\x17.daemon.DomainRuleMatchR\amatches\"i\n" +
+	"\x0fDomainRuleMatch\x12$\n" +
+	"\x04rule\x18\x01 \x01(\v2\x10.daemon.RuleInfoR\x04rule\x12\x18\n" +
+	"\amatched\x18\x02 \x01(\bR\amatched\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"B\n" +
+	"\x10CheckPortRequest\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12\x12\n" +
+	"\x04port\x18\x02 \x01(\x05R\x04port\"\xa1\x01\n" +
+	"\x11CheckPortResponse\x12\x16\n" +
+	"\x06queued\x18\x01 \x01(\bR\x06queued\x12\x1b\n" +
+	"\tqueue_num\x18\x02 \x01(\x05R\bqueueNum\x12*\n" +
+	"\x05rules\x18\x03 \x03(\v2\x14.daemon.PortCoverageR\x05rules\x12+\n" +
+	"\x11external_firewall\x18\x04 \x01(\bR\x10externalFirewall\"\xa9\x01\n" +
+	"\fPortCoverage\x12$\n" +
+	"\x04rule\x18\x01 \x01(\v2\x10.daemon.RuleInfoR\x04rule\x12)\n" +
+	"\x06stages\x18\x02 \x03(\v2\x11.daemon.PortStageR\x06stages\x12\x18\n" +
+	"\acovered\x18\x03 \x01(\bR\acovered\x12\x16\n" +
+	"\x06queued\x18\x04 \x01(\bR\x06queued\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\x7f\n" +
+	"\tPortStage\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04spec\x18\x02 \x01(\tR\x04spec\x12\x1a\n" +
+	"\bresolved\x18\x03 \x01(\bR\bresolved\x12\x18\n" +
+	"\acovered\x18\x04 \x01(\bR\acovered\x12\x14\n" +
+	"\x05range\x18\x05 \x01(\tR\x05range\"L\n" +
+	"\x0fSnapshotRequest\x12\x16\n" +
+	"\x06fields\x18\x01 \x03(\tR\x06fields\x12!\n" +
+	"\fevents_since\x18\x02 \x01(\x04R\veventsSince\"\xf0\x03\n" +
+	"\x10SnapshotResponse\x12\x19\n" +
+	"\btaken_at\x18\x01 \x01(\tR\atakenAt\x12.\n" +
+	"\x06status\x18\x02 \x01(\v2\x16.daemon.StatusResponseR\x06status\x12&\n" +
+	"\x05rules\x18\x03 \x03(\v2\x10.daemon.RuleInfoR\x05rules\x121\n" +
+	"\tprocesses\x18\x04 \x03(\v2\x13.daemon.ProcessInfoR\tprocesses\x12\x16\n" +
+	"\x06health\x18\x05 \x01(\tR\x06health\x12,\n" +
+	"\areloads\x18\x06 \x03(\v2\x12.daemon.ReloadInfoR\areloads\x12)\n" +
+	"\x06events\x18\a \x03(\v2\x11.daemon.EventInfoR\x06events\x12!\n" +
+	"\fevent_cursor\x18\b \x01(\x04R\veventCursor\x12%\n" +
+	"\x0ecounters_error\x18\t \x01(\tR\rcountersError\x12#\n" +
+	"\rhealth_reason\x18\f \x01(\tR\fhealthReason\x127\n" +
+	"\vrpc_methods\x18\n" +
+	" \x03(\v2\x16.daemon.RpcMethodStatsR\n" +
+	"rpcMethods\x12\x1d\n" +
+	"\n" +
+	"rpc_panics\x18\v \x01(\x04R\trpcPanics\"\xf0\x01\n" +
+	"\x0eRpcMethodStats\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x1a\n" +
+	"\brequests\x18\x02 \x01(\x04R\brequests\x12\x16\n" +
+	"\x06errors\x18\x03 \x01(\x04R\x06errors\x12\x16\n" +
+	"\x06panics\x18\x04 \x01(\x04R\x06panics\x12#\n" +
+	"\rbucket_bounds\x18\x05 \x03(\x01R\fbucketBounds\x12#\n" +
+	"\rbucket_counts\x18\x06 \x03(\x04R\fbucketCounts\x120\n" +
+	"\x14duration_sum_seconds\x18\a \x01(\x01R\x12durationSumSeconds\"\x98\x03\n" +
+	"\vProcessInfo\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\x05R\x03pid\x12\x1b\n" +
+	"\tqueue_num\x18\x02 \x01(\x05R\bqueueNum\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x03 \x01(\tR\tstartedAt\x12(\n" +
+	"\x05stats\x18\x04 \x01(\v2\x12.daemon.QueueStatsR\x05stats\x12\x1c\n" +
+	"\tsuspended\x18\x05 \x01(\bR\tsuspended\x12\x1a\n" +
+	"\brestarts\x18\x06 \x01(\x05R\brestarts\x12\x1a\n" +
+	"\bdegraded\x18\a \x01(\bR\bdegraded\x12\x18\n" +
+	"\arunning\x18\b \x01(\bR\arunning\x12%\n" +
+	"\x0euptime_seconds\x18\t \x01(\x03R\ruptimeSeconds\x12\x12\n" +
+	"\x04args\x18\n" +
+	" \x01(\tR\x04args\x12\x1b\n" +
+	"\tlast_exit\x18\v \x01(\tR\blastExit\x12\x1b\n" +
+	"\toom_kills\x18\f \x01(\x05R\boomKills\x12,\n" +
+	"\x12memory_limit_bytes\x18\r \x01(\x03R\x10memoryLimitBytes\"\xb2\x01\n" +
+	"\n" +
+	"QueueStats\x12%\n" +
+	"\x0edesync_applied\x18\x01 \x01(\x04R\rdesyncApplied\x12#\n" +
+	"\rhostlist_hits\x18\x02 \x01(\x04R\fhostlistHits\x125\n" +
+	"\x16autohostlist_additions\x18\x03 \x01(\x04R\x15autohostlistAdditions\x12!\n" +
+	"\fparse_errors\x18\x04 \x01(\x04R\vparseErrors\"s\n" +
+	"\n" +
+	"ReloadInfo\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\tR\x04time\x12\x1a\n" +
+	"\btriggers\x18\x02 \x03(\tR\btriggers\x12\x1f\n" +
+	"\vduration_ms\x18\x03 \x01(\x01R\n" +
+	"durationMs\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"_\n" +
+	"\tEventInfo\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\x17\n" +
+	"\x15HostlistStatusRequest\"J\n" +
+	"\x16HostlistStatusResponse\x120\n" +
+	"\asources\x18\x01 \x03(\v2\x16.daemon.HostlistSourceR\asources\"\xe5\x01\n" +
+	"\x0eHostlistSource\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12!\n" +
+	"\flast_success\x18\x03 \x01(\tR\vlastSuccess\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x04 \x01(\tR\tlastError\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x03R\x04size\x12\x18\n" +
+	"\aentries\x18\x06 \x01(\x05R\aentries\x12\x1a\n" +
+	"\bfailures\x18\a \x01(\x05R\bfailures\x12!\n" +
+	"\fnext_attempt\x18\b \x01(\tR\vnextAttempt\"`\n" +
+	"\x17ValidateStrategyRequest\x12\x1a\n" +
+	"\bstrategy\x18\x01 \x01(\fR\bstrategy\x12)\n" +
+	"\x10apply_simulation\x18\x02 \x01(\bR\x0fapplySimulation\"\xc6\x01\n" +
+	"\x18ValidateStrategyResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x16\n" +
+	"\x06errors\x18\x02 \x03(\tR\x06errors\x12\x1a\n" +
+	"\bwarnings\x18\x03 \x03(\tR\bwarnings\x12&\n" +
+	"\x05rules\x18\x04 \x03(\v2\x10.daemon.RuleInfoR\x05rules\x128\n" +
+	"\n" +
+	"operations\x18\x05 \x03(\v2\x18.daemon.PlannedOperationR\n" +
+	"operations\"H\n" +
+	"\x10PlannedOperation\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\x15\n" +
+	"\x13ListPayloadsRequest\"C\n" +
+	"\x14ListPayloadsResponse\x12+\n" +
+	"\bpayloads\x18\x01 \x03(\v2\x0f.daemon.PayloadR\bpayloads\"\xb2\x01\n" +
+	"\aPayload\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12!\n" +
+	"\fsource_lines\x18\x05 \x03(\x05R\vsourceLines\x12\x16\n" +
+	"\x06queues\x18\x06 \x03(\x05R\x06queues\x12\x18\n" +
+	"\aproblem\x18\a \x01(\tR\aproblem\"\x15\n" +
+	"\x13KernelQueuesRequest\"C\n" +
+	"\x14KernelQueuesResponse\x12+\n" +
+	"\x06queues\x18\x01 \x03(\v2\x13.daemon.KernelQueueR\x06queues\"\xad\x03\n" +
+	"\vKernelQueue\x12\x14\n" +
+	"\x05queue\x18\x01 \x01(\x05R\x05queue\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x1f\n" +
+	"\vsource_line\x18\x03 \x01(\x05R\n" +
+	"sourceLine\x12\x1c\n" +
+	"\tinstalled\x18\x04 \x01(\bR\tinstalled\x12\x14\n" +
+	"\x05bound\x18\x05 \x01(\bR\x05bound\x12\x1f\n" +
+	"\vpeer_portid\x18\x06 \x01(\rR\n" +
+	"peerPortid\x12\x10\n" +
+	"\x03pid\x18\a \x01(\x05R\x03pid\x12\x16\n" +
+	"\x06length\x18\b \x01(\x05R\x06length\x12\x1b\n" +
+	"\tcopy_mode\x18\t \x01(\tR\bcopyMode\x12\x1d\n" +
+	"\n" +
+	"copy_range\x18\n" +
+	" \x01(\x05R\tcopyRange\x12\x18\n" +
+	"\adropped\x18\v \x01(\x04R\adropped\x12!\n" +
+	"\fuser_dropped\x18\f \x01(\x04R\vuserDropped\x12#\n" +
+	"\rdrops_growing\x18\r \x01(\bR\fdropsGrowing\x12\x1a\n" +
+	"\bproblems\x18\x0e \x03(\tR\bproblems\x12\x18\n" +
+	"\aunknown\x18\x0f \x01(\bR\aunknown\"[\n" +
+	"\x10ChangelogRequest\x12\x14\n" +
+	"\x05since\x18\x01 \x01(\tR\x05since\x12\x1b\n" +
+	"\tafter_seq\x18\x02 \x01(\x04R\bafterSeq\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"\x81\x01\n" +
+	"\x11ChangelogResponse\x120\n" +
+	"\aentries\x18\x01 \x03(\v2\x16.daemon.ChangelogEntryR\aentries\x12\x19\n" +
+	"\bnext_seq\x18\x02 \x01(\x04R\anextSeq\x12\x1f\n" +
+	"\vchain_error\x18\x03 \x01(\tR\n" +
+	"chainError\"\xbd\x03\n" +
+	"\x0eChangelogEntry\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\x12#\n" +
+	"\rstrategy_file\x18\x03 \x01(\tR\fstrategyFile\x12#\n" +
+	"\rstrategy_hash\x18\x04 \x01(\tR\fstrategyHash\x12)\n" +
+	"\x10firewall_backend\x18\x05 \x01(\tR\x0ffirewallBackend\x12+\n" +
+	"\x05rules\x18\x06 \x03(\v2\x15.daemon.ChangelogRuleR\x05rules\x12+\n" +
+	"\x05added\x18\a \x03(\v2\x15.daemon.ChangelogRuleR\x05added\x12/\n" +
+	"\aremoved\x18\b \x03(\v2\x15.daemon.ChangelogRuleR\aremoved\x12)\n" +
+	"\x10strategy_changed\x18\t \x01(\bR\x0fstrategyChanged\x12)\n" +
+	"\x10previous_backend\x18\n" +
+	" \x01(\tR\x0fpreviousBackend\x12\x1b\n" +
+	"\tprev_hash\x18\v \x01(\tR\bprevHash\x12\x12\n" +
+	"\x04hash\x18\f \x01(\tR\x04hash\"t\n" +
+	"\rChangelogRule\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12\x14\n" +
+	"\x05ports\x18\x02 \x01(\tR\x05ports\x12\x14\n" +
+	"\x05queue\x18\x03 \x01(\x05R\x05queue\x12\x1b\n" +
+	"\targs_hash\x18\x04 \x01(\tR\bargsHash\"\x15\n" +
+	"\x13CapabilitiesRequest\"\xc6\x02\n" +
+	"\x14CapabilitiesResponse\x12\x1a\n" +
+	"\bplatform\x18\x01 \x01(\tR\bplatform\x123\n" +
+	"\bfeatures\x18\x02 \x03(\v2\x17.daemon.CompiledFeatureR\bfeatures\x12I\n" +
+	"\x13kernel_capabilities\x18\x03 \x03(\v2\x18.daemon.KernelCapabilityR\x12kernelCapabilities\x126\n" +
+	"\n" +
+	"privileges\x18\x04 \x03(\v2\x16.daemon.PrivilegeCheckR\n" +
+	"privileges\x12'\n" +
+	"\x05nfqws\x18\x05 \x01(\v2\x11.daemon.NfqwsInfoR\x05nfqws\x121\n" +
+	"\n" +
+	"subsystems\x18\x06 \x03(\v2\x11.daemon.SubsystemR\n" +
+	"subsystems\"Q\n" +
+	"\x0fCompiledFeature\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"L\n" +
+	"\x0ePrivilegeCheck\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\xcc\x01\n" +
+	"\tNfqwsInfo\x12\x16\n" +
+	"\x06binary\x18\x01 \x01(\tR\x06binary\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12!\n" +
+	"\foption_count\x18\x03 \x01(\x05R\voptionCount\x12\x1c\n" +
+	"\tsupported\x18\x04 \x03(\tR\tsupported\x12 \n" +
+	"\vunsupported\x18\x05 \x03(\tR\vunsupported\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x1a\n" +
+	"\bexternal\x18\a \x01(\bR\bexternal\"Q\n" +
+	"\tSubsystem\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aenabled\x18\x02 \x01(\bR\aenabled\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\"\x14\n" +
+	"\x12DiagnosticsRequest\"F\n" +
+	"\x13DiagnosticsResponse\x12/\n" +
+	"\x06checks\x18\x01 \x03(\v2\x17.daemon.DiagnosticCheckR\x06checks\"u\n" +
+	"\x0fDiagnosticCheck\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\x04 \x01(\tR\n" +
+	"suggestion\"3\n" +
+	"\x17SuspendProcessesRequest\x12\x18\n" +
+	"\atimeout\x18\x01 \x01(\tR\atimeout\"U\n" +
+	"\x18SuspendProcessesResponse\x12\x1c\n" +
+	"\tprocesses\x18\x01 \x01(\x05R\tprocesses\x12\x1b\n" +
+	"\tresume_at\x18\x02 \x01(\tR\bresumeAt\"\x18\n" +
+	"\x16ResumeProcessesRequest\"7\n" +
+	"\x17ResumeProcessesResponse\x12\x1c\n" +
+	"\tprocesses\x18\x01 \x01(\x05R\tprocesses\"\x0e\n" +
+	"\fStartRequest\"C\n" +
+	"\rStartResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\arunning\x18\x02 \x01(\bR\arunning\"\r\n" +
+	"\vStopRequest\"B\n" +
+	"\fStopResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\arunning\x18\x02 \x01(\bR\arunning\")\n" +
+	"\x0fProbeDNSRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\"\xb3\x02\n" +
+	"\x10ProbeDNSResponse\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x18\n" +
+	"\averdict\x18\x02 \x01(\tR\averdict\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\x12!\n" +
+	"\fsystem_addrs\x18\x04 \x03(\tR\vsystemAddrs\x12!\n" +
+	"\fsystem_error\x18\x05 \x01(\tR\vsystemError\x12\x1b\n" +
+	"\tdoh_addrs\x18\x06 \x03(\tR\bdohAddrs\x12\x1b\n" +
+	"\tdoh_error\x18\a \x01(\tR\bdohError\x12\x1f\n" +
+	"\vbogus_addrs\x18\b \x03(\tR\n" +
+	"bogusAddrs\x12\x14\n" +
+	"\x05state\x18\t \x01(\tR\x05state\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\n" +
+	" \x01(\tR\n" +
+	"suggestion2\xe3\n" +
 	"\n" +
-	"start_time\x18\x06 \x01(\tR\tstartTime2\x86\x01\n" +
 	"\fZapretDaemon\x12:\n" +
 	"\aRestart\x12\x16.daemon.RestartRequest\x1a\x17.daemon.RestartResponse\x12:\n" +
-	"\tGetStatus\x12\x15.daemon.StatusRequest\x1a\x16.daemon.StatusResponseB=Z;github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemonb\x06proto3"
+	"\tGetStatus\x12\x15.daemon.StatusRequest\x1a\x16.daemon.StatusResponse\x12@\n" +
+	"\tListRules\x12\x18.daemon.ListRulesRequest\x1a\x19.daemon.ListRulesResponse\x12L\n" +
+	"\rExplainDomain\x12\x1c.daemon.ExplainDomainRequest\x1a\x1d.daemon.ExplainDomainResponse\x12@\n" +
+	"\tCheckPort\x12\x18.daemon.CheckPortRequest\x1a\x19.daemon.CheckPortResponse\x12R\n" +
+	"\x0fInstallStrategy\x12\x1e.daemon.InstallStrategyRequest\x1a\x1f.daemon.InstallStrategyResponse\x12@\n" +
+	"\vGetSnapshot\x12\x17.daemon.SnapshotRequest\x1a\x18.daemon.SnapshotResponse\x12R\n" +
+	"\x11GetHostlistStatus\x12\x1d.daemon.HostlistStatusRequest\x1a\x1e.daemon.HostlistStatusResponse\x12U\n" +
+	"\x10ValidateStrategy\x12\x1f.daemon.ValidateStrategyRequest\x1a .daemon.ValidateStrategyResponse\x12I\n" +
+	"\fListPayloads\x12\x1b.daemon.ListPayloadsRequest\x1a\x1c.daemon.ListPayloadsResponse\x12L\n" +
+	"\x0fGetKernelQueues\x12\x1b.daemon.KernelQueuesRequest\x1a\x1c.daemon.KernelQueuesResponse\x12C\n" +
+	"\fGetChangelog\x12\x18.daemon.ChangelogRequest\x1a\x19.daemon.ChangelogResponse\x12L\n" +
+	"\x0fGetCapabilities\x12\x1b.daemon.CapabilitiesRequest\x1a\x1c.daemon.CapabilitiesResponse\x12I\n" +
+	"\x0eRunDiagnostics\x12\x1a.daemon.DiagnosticsRequest\x1a\x1b.daemon.DiagnosticsResponse\x12U\n" +
+	"\x10SuspendProcesses\x12\x1f.daemon.SuspendProcessesRequest\x1a .daemon.SuspendProcessesResponse\x12R\n" +
+	"\x0fResumeProcesses\x12\x1e.daemon.ResumeProcessesRequest\x1a\x1f.daemon.ResumeProcessesResponse\x124\n" +
+	"\x05Start\x12\x14.daemon.StartRequest\x1a\x15.daemon.StartResponse\x121\n" +
+	"\x04Stop\x12\x13.daemon.StopRequest\x1a\x14.daemon.StopResponse\x12=\n" +
+	"\bProbeDNS\x12\x17.daemon.ProbeDNSRequest\x1a\x18.daemon.ProbeDNSResponseB=Z;github.com/Sergeydigl3/zapret-discord-youtube-ng/rpc/daemonb\x06proto3"
 
 var (
 	file_rpc_daemon_service_proto_rawDescOnce sync.Once
@@ -286,23 +5137,148 @@ func file_rpc_daemon_service_proto_rawDescGZIP() []byte {
 	return file_rpc_daemon_service_proto_rawDescData
 }
 
-var file_rpc_daemon_service_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_rpc_daemon_service_proto_msgTypes = make([]protoimpl.MessageInfo, 62)
 var file_rpc_daemon_service_proto_goTypes = []any{
-	(*RestartRequest)(nil),  // 0: daemon.RestartRequest
-	(*RestartResponse)(nil), // 1: daemon.RestartResponse
-	(*StatusRequest)(nil),   // 2: daemon.StatusRequest
-	(*StatusResponse)(nil),  // 3: daemon.StatusResponse
+	(*RestartRequest)(nil),           // 0: daemon.RestartRequest
+	(*RestartResponse)(nil),          // 1: daemon.RestartResponse
+	(*StatusRequest)(nil),            // 2: daemon.StatusRequest
+	(*StatusResponse)(nil),           // 3: daemon.StatusResponse
+	(*KernelCapability)(nil),         // 4: daemon.KernelCapability
+	(*Conflict)(nil),                 // 5: daemon.Conflict
+	(*OffloadFinding)(nil),           // 6: daemon.OffloadFinding
+	(*InstallStrategyRequest)(nil),   // 7: daemon.InstallStrategyRequest
+	(*InstallStrategyResponse)(nil),  // 8: daemon.InstallStrategyResponse
+	(*ListRulesRequest)(nil),         // 9: daemon.ListRulesRequest
+	(*ListRulesResponse)(nil),        // 10: daemon.ListRulesResponse
+	(*RuleInfo)(nil),                 // 11: daemon.RuleInfo
+	(*ExplainDomainRequest)(nil),     // 12: daemon.ExplainDomainRequest
+	(*ExplainDomainResponse)(nil),    // 13: daemon.ExplainDomainResponse
+	(*DomainRuleMatch)(nil),          // 14: daemon.DomainRuleMatch
+	(*CheckPortRequest)(nil),         // 15: daemon.CheckPortRequest
+	(*CheckPortResponse)(nil),        // 16: daemon.CheckPortResponse
+	(*PortCoverage)(nil),             // 17: daemon.PortCoverage
+	(*PortStage)(nil),                // 18: daemon.PortStage
+	(*SnapshotRequest)(nil),          // 19: daemon.SnapshotRequest
+	(*SnapshotResponse)(nil),         // 20: daemon.SnapshotResponse
+	(*RpcMethodStats)(nil),           // 21: daemon.RpcMethodStats
+	(*ProcessInfo)(nil),              // 22: daemon.ProcessInfo
+	(*QueueStats)(nil),               // 23: daemon.QueueStats
+	(*ReloadInfo)(nil),               // 24: daemon.ReloadInfo
+	(*EventInfo)(nil),                // 25: daemon.EventInfo
+	(*HostlistStatusRequest)(nil),    // 26: daemon.HostlistStatusRequest
+	(*HostlistStatusResponse)(nil),   // 27: daemon.HostlistStatusResponse
+	(*HostlistSource)(nil),           // 28: daemon.HostlistSource
+	(*ValidateStrategyRequest)(nil),  // 29: daemon.ValidateStrategyRequest
+	(*ValidateStrategyResponse)(nil), // 30: daemon.ValidateStrategyResponse
+	(*PlannedOperation)(nil),         // 31: daemon.PlannedOperation
+	(*ListPayloadsRequest)(nil),      // 32: daemon.ListPayloadsRequest
+	(*ListPayloadsResponse)(nil),     // 33: daemon.ListPayloadsResponse
+	(*Payload)(nil),                  // 34: daemon.Payload
+	(*KernelQueuesRequest)(nil),      // 35: daemon.KernelQueuesRequest
+	(*KernelQueuesResponse)(nil),     // 36: daemon.KernelQueuesResponse
+	(*KernelQueue)(nil),              // 37: daemon.KernelQueue
+	(*ChangelogRequest)(nil),         // 38: daemon.ChangelogRequest
+	(*ChangelogResponse)(nil),        // 39: daemon.ChangelogResponse
+	(*ChangelogEntry)(nil),           // 40: daemon.ChangelogEntry
+	(*ChangelogRule)(nil),            // 41: daemon.ChangelogRule
+	(*CapabilitiesRequest)(nil),      // 42: daemon.CapabilitiesRequest
+	(*CapabilitiesResponse)(nil),     // 43: daemon.CapabilitiesResponse
+	(*CompiledFeature)(nil),          // 44: daemon.CompiledFeature
+	(*PrivilegeCheck)(nil),           // 45: daemon.PrivilegeCheck
+	(*NfqwsInfo)(nil),                // 46: daemon.NfqwsInfo
+	(*Subsystem)(nil),                // 47: daemon.Subsystem
+	(*DiagnosticsRequest)(nil),       // 48: daemon.DiagnosticsRequest
+	(*DiagnosticsResponse)(nil),      // 49: daemon.DiagnosticsResponse
+	(*DiagnosticCheck)(nil),          // 50: daemon.DiagnosticCheck
+	(*SuspendProcessesRequest)(nil),  // 51: daemon.SuspendProcessesRequest
+	(*SuspendProcessesResponse)(nil), // 52: daemon.SuspendProcessesResponse
+	(*ResumeProcessesRequest)(nil),   // 53: daemon.ResumeProcessesRequest
+	(*ResumeProcessesResponse)(nil),  // 54: daemon.ResumeProcessesResponse
+	(*StartRequest)(nil),             // 55: daemon.StartRequest
+	(*StartResponse)(nil),            // 56: daemon.StartResponse
+	(*StopRequest)(nil),              // 57: daemon.StopRequest
+	(*StopResponse)(nil),             // 58: daemon.StopResponse
+	(*ProbeDNSRequest)(nil),          // 59: daemon.ProbeDNSRequest
+	(*ProbeDNSResponse)(nil),         // 60: daemon.ProbeDNSResponse
+	nil,                              // 61: daemon.InstallStrategyRequest.ListsEntry
 }
 var file_rpc_daemon_service_proto_depIdxs = []int32{
-	0, // 0: daemon.ZapretDaemon.Restart:input_type -> daemon.RestartRequest
-	2, // 1: daemon.ZapretDaemon.GetStatus:input_type -> daemon.StatusRequest
-	1, // 2: daemon.ZapretDaemon.Restart:output_type -> daemon.RestartResponse
-	3, // 3: daemon.ZapretDaemon.GetStatus:output_type -> daemon.StatusResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	6,  // 0: daemon.StatusResponse.offload:type_name -> daemon.OffloadFinding
+	5,  // 1: daemon.StatusResponse.conflicts:type_name -> daemon.Conflict
+	4,  // 2: daemon.StatusResponse.kernel_capabilities:type_name -> daemon.KernelCapability
+	22, // 3: daemon.StatusResponse.processes:type_name -> daemon.ProcessInfo
+	61, // 4: daemon.InstallStrategyRequest.lists:type_name -> daemon.InstallStrategyRequest.ListsEntry
+	11, // 5: daemon.ListRulesResponse.rules:type_name -> daemon.RuleInfo
+	14, // 6: daemon.ExplainDomainResponse.matches:type_name -> daemon.DomainRuleMatch
+	11, // 7: daemon.DomainRuleMatch.rule:type_name -> daemon.RuleInfo
+	17, // 8: daemon.CheckPortResponse.rules:type_name -> daemon.PortCoverage
+	11, // 9: daemon.PortCoverage.rule:type_name -> daemon.RuleInfo
+	18, // 10: daemon.PortCoverage.stages:type_name -> daemon.PortStage
+	3,  // 11: daemon.SnapshotResponse.status:type_name -> daemon.StatusResponse
+	11, // 12: daemon.SnapshotResponse.rules:type_name -> daemon.RuleInfo
+	22, // 13: daemon.SnapshotResponse.processes:type_name -> daemon.ProcessInfo
+	24, // 14: daemon.SnapshotResponse.reloads:type_name -> daemon.ReloadInfo
+	25, // 15: daemon.SnapshotResponse.events:type_name -> daemon.EventInfo
+	21, // 16: daemon.SnapshotResponse.rpc_methods:type_name -> daemon.RpcMethodStats
+	23, // 17: daemon.ProcessInfo.stats:type_name -> daemon.QueueStats
+	28, // 18: daemon.HostlistStatusResponse.sources:type_name -> daemon.HostlistSource
+	11, // 19: daemon.ValidateStrategyResponse.rules:type_name -> daemon.RuleInfo
+	31, // 20: daemon.ValidateStrategyResponse.operations:type_name -> daemon.PlannedOperation
+	34, // 21: daemon.ListPayloadsResponse.payloads:type_name -> daemon.Payload
+	37, // 22: daemon.KernelQueuesResponse.queues:type_name -> daemon.KernelQueue
+	40, // 23: daemon.ChangelogResponse.entries:type_name -> daemon.ChangelogEntry
+	41, // 24: daemon.ChangelogEntry.rules:type_name -> daemon.ChangelogRule
+	41, // 25: daemon.ChangelogEntry.added:type_name -> daemon.ChangelogRule
+	41, // 26: daemon.ChangelogEntry.removed:type_name -> daemon.ChangelogRule
+	44, // 27: daemon.CapabilitiesResponse.features:type_name -> daemon.CompiledFeature
+	4,  // 28: daemon.CapabilitiesResponse.kernel_capabilities:type_name -> daemon.KernelCapability
+	45, // 29: daemon.CapabilitiesResponse.privileges:type_name -> daemon.PrivilegeCheck
+	46, // 30: daemon.CapabilitiesResponse.nfqws:type_name -> daemon.NfqwsInfo
+	47, // 31: daemon.CapabilitiesResponse.subsystems:type_name -> daemon.Subsystem
+	50, // 32: daemon.DiagnosticsResponse.checks:type_name -> daemon.DiagnosticCheck
+	0,  // 33: daemon.ZapretDaemon.Restart:input_type -> daemon.RestartRequest
+	2,  // 34: daemon.ZapretDaemon.GetStatus:input_type -> daemon.StatusRequest
+	9,  // 35: daemon.ZapretDaemon.ListRules:input_type -> daemon.ListRulesRequest
+	12, // 36: daemon.ZapretDaemon.ExplainDomain:input_type -> daemon.ExplainDomainRequest
+	15, // 37: daemon.ZapretDaemon.CheckPort:input_type -> daemon.CheckPortRequest
+	7,  // 38: daemon.ZapretDaemon.InstallStrategy:input_type -> daemon.InstallStrategyRequest
+	19, // 39: daemon.ZapretDaemon.GetSnapshot:input_type -> daemon.SnapshotRequest
+	26, // 40: daemon.ZapretDaemon.GetHostlistStatus:input_type -> daemon.HostlistStatusRequest
+	29, // 41: daemon.ZapretDaemon.ValidateStrategy:input_type -> daemon.ValidateStrategyRequest
+	32, // 42: daemon.ZapretDaemon.ListPayloads:input_type -> daemon.ListPayloadsRequest
+	35, // 43: daemon.ZapretDaemon.GetKernelQueues:input_type -> daemon.KernelQueuesRequest
+	38, // 44: daemon.ZapretDaemon.GetChangelog:input_type -> daemon.ChangelogRequest
+	42, // 45: daemon.ZapretDaemon.GetCapabilities:input_type -> daemon.CapabilitiesRequest
+	48, // 46: daemon.ZapretDaemon.RunDiagnostics:input_type -> daemon.DiagnosticsRequest
+	51, // 47: daemon.ZapretDaemon.SuspendProcesses:input_type -> daemon.SuspendProcessesRequest
+	53, // 48: daemon.ZapretDaemon.ResumeProcesses:input_type -> daemon.ResumeProcessesRequest
+	55, // 49: daemon.ZapretDaemon.Start:input_type -> daemon.StartRequest
+	57, // 50: daemon.ZapretDaemon.Stop:input_type -> daemon.StopRequest
+	59, // 51: daemon.ZapretDaemon.ProbeDNS:input_type -> daemon.ProbeDNSRequest
+	1,  // 52: daemon.ZapretDaemon.Restart:output_type -> daemon.RestartResponse
+	3,  // 53: daemon.ZapretDaemon.GetStatus:output_type -> daemon.StatusResponse
+	10, // 54: daemon.ZapretDaemon.ListRules:output_type -> daemon.ListRulesResponse
+	13, // 55: daemon.ZapretDaemon.ExplainDomain:output_type -> daemon.ExplainDomainResponse
+	16, // 56: daemon.ZapretDaemon.CheckPort:output_type -> daemon.CheckPortResponse
+	8,  // 57: daemon.ZapretDaemon.InstallStrategy:output_type -> daemon.InstallStrategyResponse
+	20, // 58: daemon.ZapretDaemon.GetSnapshot:output_type -> daemon.SnapshotResponse
+	27, // 59: daemon.ZapretDaemon.GetHostlistStatus:output_type -> daemon.HostlistStatusResponse
+	30, // 60: daemon.ZapretDaemon.ValidateStrategy:output_type -> daemon.ValidateStrategyResponse
+	33, // 61: daemon.ZapretDaemon.ListPayloads:output_type -> daemon.ListPayloadsResponse
+	36, // 62: daemon.ZapretDaemon.GetKernelQueues:output_type -> daemon.KernelQueuesResponse
+	39, // 63: daemon.ZapretDaemon.GetChangelog:output_type -> daemon.ChangelogResponse
+	43, // 64: daemon.ZapretDaemon.GetCapabilities:output_type -> daemon.CapabilitiesResponse
+	49, // 65: daemon.ZapretDaemon.RunDiagnostics:output_type -> daemon.DiagnosticsResponse
+	52, // 66: daemon.ZapretDaemon.SuspendProcesses:output_type -> daemon.SuspendProcessesResponse
+	54, // 67: daemon.ZapretDaemon.ResumeProcesses:output_type -> daemon.ResumeProcessesResponse
+	56, // 68: daemon.ZapretDaemon.Start:output_type -> daemon.StartResponse
+	58, // 69: daemon.ZapretDaemon.Stop:output_type -> daemon.StopResponse
+	60, // 70: daemon.ZapretDaemon.ProbeDNS:output_type -> daemon.ProbeDNSResponse
+	52, // [52:71] is the sub-list for method output_type
+	33, // [33:52] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
 }
 
 func init() { file_rpc_daemon_service_proto_init() }
@@ -316,7 +5292,7 @@ func file_rpc_daemon_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rpc_daemon_service_proto_rawDesc), len(file_rpc_daemon_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   62,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
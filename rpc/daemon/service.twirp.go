@@ -39,6 +39,68 @@ type ZapretDaemon interface {
 
 	// GetStatus returns the current status of the strategy runner.
 	GetStatus(context.Context, *StatusRequest) (*StatusResponse, error)
+
+	// ListRules returns the rules applied by the strategy runner.
+	ListRules(context.Context, *ListRulesRequest) (*ListRulesResponse, error)
+
+	// ExplainDomain reports which rules would handle traffic to a domain.
+	ExplainDomain(context.Context, *ExplainDomainRequest) (*ExplainDomainResponse, error)
+
+	// CheckPort reports which rule queues a protocol and port, and how each
+	// rule's port spec was derived.
+	CheckPort(context.Context, *CheckPortRequest) (*CheckPortResponse, error)
+
+	// InstallStrategy writes a strategy preset and its hostlists on the daemon host.
+	InstallStrategy(context.Context, *InstallStrategyRequest) (*InstallStrategyResponse, error)
+
+	// GetSnapshot returns status, rules, processes and recent events in one consistent view.
+	GetSnapshot(context.Context, *SnapshotRequest) (*SnapshotResponse, error)
+
+	// GetHostlistStatus returns the update state of downloaded hostlists.
+	GetHostlistStatus(context.Context, *HostlistStatusRequest) (*HostlistStatusResponse, error)
+
+	// ValidateStrategy checks a candidate strategy against the running configuration
+	// and optionally plans the operations applying it would perform.
+	ValidateStrategy(context.Context, *ValidateStrategyRequest) (*ValidateStrategyResponse, error)
+
+	// ListPayloads returns the fake payload files referenced by the applied strategy.
+	ListPayloads(context.Context, *ListPayloadsRequest) (*ListPayloadsResponse, error)
+
+	// GetKernelQueues returns the kernel state of the NFQUEUE queues joined with
+	// the rules feeding them.
+	GetKernelQueues(context.Context, *KernelQueuesRequest) (*KernelQueuesResponse, error)
+
+	// GetChangelog returns a page of the changelog of applied configurations,
+	// oldest first.
+	GetChangelog(context.Context, *ChangelogRequest) (*ChangelogResponse, error)
+
+	// GetCapabilities reports what this installation can do: compiled-in
+	// backends, kernel capabilities, privileges, the nfqws binary and which
+	// optional subsystems are enabled.
+	GetCapabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error)
+
+	// RunDiagnostics runs the health, capability, permission and conflict
+	// checks and returns each finding with a suggested fix.
+	RunDiagnostics(context.Context, *DiagnosticsRequest) (*DiagnosticsResponse, error)
+
+	// SuspendProcesses stops all nfqws processes with SIGSTOP until
+	// ResumeProcesses or the suspend timeout. Firewall rules stay in place.
+	SuspendProcesses(context.Context, *SuspendProcessesRequest) (*SuspendProcessesResponse, error)
+
+	// ResumeProcesses continues the nfqws processes with SIGCONT.
+	ResumeProcesses(context.Context, *ResumeProcessesRequest) (*ResumeProcessesResponse, error)
+
+	// Start starts the strategy runner after Stop. Starting a running runner
+	// does nothing.
+	Start(context.Context, *StartRequest) (*StartResponse, error)
+
+	// Stop stops the nfqws processes and removes the firewall rules, leaving
+	// the daemon running. Stopping a stopped runner does nothing.
+	Stop(context.Context, *StopRequest) (*StopResponse, error)
+
+	// ProbeDNS resolves a domain with the system resolver and a DNS-over-HTTPS
+	// server and compares the answers, telling DNS poisoning from DPI blocking.
+	ProbeDNS(context.Context, *ProbeDNSRequest) (*ProbeDNSResponse, error)
 }
 
 // ============================
@@ -47,7 +109,7 @@ type ZapretDaemon interface {
 
 type zapretDaemonProtobufClient struct {
 	client      HTTPClient
-	urls        [2]string
+	urls        [19]string
 	interceptor twirp.Interceptor
 	opts        twirp.ClientOptions
 }
@@ -75,9 +137,26 @@ func NewZapretDaemonProtobufClient(baseURL string, client HTTPClient, opts ...tw
 	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
 	serviceURL := sanitizeBaseURL(baseURL)
 	serviceURL += baseServicePath(pathPrefix, "daemon", "ZapretDaemon")
-	urls := [2]string{
+	urls := [19]string{
 		serviceURL + "Restart",
 		serviceURL + "GetStatus",
+		serviceURL + "ListRules",
+		serviceURL + "ExplainDomain",
+		serviceURL + "CheckPort",
+		serviceURL + "InstallStrategy",
+		serviceURL + "GetSnapshot",
+		serviceURL + "GetHostlistStatus",
+		serviceURL + "ValidateStrategy",
+		serviceURL + "ListPayloads",
+		serviceURL + "GetKernelQueues",
+		serviceURL + "GetChangelog",
+		serviceURL + "GetCapabilities",
+		serviceURL + "RunDiagnostics",
+		serviceURL + "SuspendProcesses",
+		serviceURL + "ResumeProcesses",
+		serviceURL + "Start",
+		serviceURL + "Stop",
+		serviceURL + "ProbeDNS",
 	}
 
 	return &zapretDaemonProtobufClient{
@@ -180,73 +259,210 @@ func (c *zapretDaemonProtobufClient) callGetStatus(ctx context.Context, in *Stat
 	return out, nil
 }
 
-// ========================
-// ZapretDaemon JSON Client
-// ========================
+func (c *zapretDaemonProtobufClient) ListRules(ctx context.Context, in *ListRulesRequest) (*ListRulesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ListRules")
+	caller := c.callListRules
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListRulesRequest) (*ListRulesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListRulesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListRulesRequest) when calling interceptor")
+					}
+					return c.callListRules(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListRulesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListRulesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
 
-type zapretDaemonJSONClient struct {
-	client      HTTPClient
-	urls        [2]string
-	interceptor twirp.Interceptor
-	opts        twirp.ClientOptions
+func (c *zapretDaemonProtobufClient) callListRules(ctx context.Context, in *ListRulesRequest) (*ListRulesResponse, error) {
+	out := new(ListRulesResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[2], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
 }
 
-// NewZapretDaemonJSONClient creates a JSON client that implements the ZapretDaemon interface.
-// It communicates using JSON and can be configured with a custom HTTPClient.
-func NewZapretDaemonJSONClient(baseURL string, client HTTPClient, opts ...twirp.ClientOption) ZapretDaemon {
-	if c, ok := client.(*http.Client); ok {
-		client = withoutRedirects(c)
+func (c *zapretDaemonProtobufClient) ExplainDomain(ctx context.Context, in *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ExplainDomain")
+	caller := c.callExplainDomain
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ExplainDomainRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ExplainDomainRequest) when calling interceptor")
+					}
+					return c.callExplainDomain(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ExplainDomainResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ExplainDomainResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
 	}
+	return caller(ctx, in)
+}
 
-	clientOpts := twirp.ClientOptions{}
-	for _, o := range opts {
-		o(&clientOpts)
+func (c *zapretDaemonProtobufClient) callExplainDomain(ctx context.Context, in *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+	out := new(ExplainDomainResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[3], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
 	}
 
-	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
-	literalURLs := false
-	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
-	var pathPrefix string
-	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
-		pathPrefix = "/twirp" // default prefix
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) CheckPort(ctx context.Context, in *CheckPortRequest) (*CheckPortResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "CheckPort")
+	caller := c.callCheckPort
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *CheckPortRequest) (*CheckPortResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CheckPortRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CheckPortRequest) when calling interceptor")
+					}
+					return c.callCheckPort(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CheckPortResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CheckPortResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
 	}
+	return caller(ctx, in)
+}
 
-	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
-	serviceURL := sanitizeBaseURL(baseURL)
-	serviceURL += baseServicePath(pathPrefix, "daemon", "ZapretDaemon")
-	urls := [2]string{
-		serviceURL + "Restart",
-		serviceURL + "GetStatus",
+func (c *zapretDaemonProtobufClient) callCheckPort(ctx context.Context, in *CheckPortRequest) (*CheckPortResponse, error) {
+	out := new(CheckPortResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[4], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
 	}
 
-	return &zapretDaemonJSONClient{
-		client:      client,
-		urls:        urls,
-		interceptor: twirp.ChainInterceptors(clientOpts.Interceptors...),
-		opts:        clientOpts,
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) InstallStrategy(ctx context.Context, in *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "InstallStrategy")
+	caller := c.callInstallStrategy
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*InstallStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*InstallStrategyRequest) when calling interceptor")
+					}
+					return c.callInstallStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*InstallStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*InstallStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
 	}
+	return caller(ctx, in)
 }
 
-func (c *zapretDaemonJSONClient) Restart(ctx context.Context, in *RestartRequest) (*RestartResponse, error) {
+func (c *zapretDaemonProtobufClient) callInstallStrategy(ctx context.Context, in *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+	out := new(InstallStrategyResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[5], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) GetSnapshot(ctx context.Context, in *SnapshotRequest) (*SnapshotResponse, error) {
 	ctx = ctxsetters.WithPackageName(ctx, "daemon")
 	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
-	ctx = ctxsetters.WithMethodName(ctx, "Restart")
-	caller := c.callRestart
+	ctx = ctxsetters.WithMethodName(ctx, "GetSnapshot")
+	caller := c.callGetSnapshot
 	if c.interceptor != nil {
-		caller = func(ctx context.Context, req *RestartRequest) (*RestartResponse, error) {
+		caller = func(ctx context.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
 			resp, err := c.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*RestartRequest)
+					typedReq, ok := req.(*SnapshotRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*RestartRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*SnapshotRequest) when calling interceptor")
 					}
-					return c.callRestart(ctx, typedReq)
+					return c.callGetSnapshot(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*RestartResponse)
+				typedResp, ok := resp.(*SnapshotResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*RestartResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*SnapshotResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -256,9 +472,101 @@ func (c *zapretDaemonJSONClient) Restart(ctx context.Context, in *RestartRequest
 	return caller(ctx, in)
 }
 
-func (c *zapretDaemonJSONClient) callRestart(ctx context.Context, in *RestartRequest) (*RestartResponse, error) {
-	out := new(RestartResponse)
-	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[0], in, out)
+func (c *zapretDaemonProtobufClient) callGetSnapshot(ctx context.Context, in *SnapshotRequest) (*SnapshotResponse, error) {
+	out := new(SnapshotResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[6], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) GetHostlistStatus(ctx context.Context, in *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetHostlistStatus")
+	caller := c.callGetHostlistStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*HostlistStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*HostlistStatusRequest) when calling interceptor")
+					}
+					return c.callGetHostlistStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*HostlistStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*HostlistStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callGetHostlistStatus(ctx context.Context, in *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+	out := new(HostlistStatusResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[7], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) ValidateStrategy(ctx context.Context, in *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ValidateStrategy")
+	caller := c.callValidateStrategy
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ValidateStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ValidateStrategyRequest) when calling interceptor")
+					}
+					return c.callValidateStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ValidateStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ValidateStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callValidateStrategy(ctx context.Context, in *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+	out := new(ValidateStrategyResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[8], in, out)
 	if err != nil {
 		twerr, ok := err.(twirp.Error)
 		if !ok {
@@ -268,168 +576,4631 @@ func (c *zapretDaemonJSONClient) callRestart(ctx context.Context, in *RestartReq
 		return nil, err
 	}
 
-	callClientResponseReceived(ctx, c.opts.Hooks)
-
-	return out, nil
-}
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) ListPayloads(ctx context.Context, in *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ListPayloads")
+	caller := c.callListPayloads
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListPayloadsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListPayloadsRequest) when calling interceptor")
+					}
+					return c.callListPayloads(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListPayloadsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListPayloadsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callListPayloads(ctx context.Context, in *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+	out := new(ListPayloadsResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[9], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) GetKernelQueues(ctx context.Context, in *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetKernelQueues")
+	caller := c.callGetKernelQueues
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*KernelQueuesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*KernelQueuesRequest) when calling interceptor")
+					}
+					return c.callGetKernelQueues(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*KernelQueuesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*KernelQueuesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callGetKernelQueues(ctx context.Context, in *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+	out := new(KernelQueuesResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[10], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) GetChangelog(ctx context.Context, in *ChangelogRequest) (*ChangelogResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetChangelog")
+	caller := c.callGetChangelog
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ChangelogRequest) (*ChangelogResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ChangelogRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ChangelogRequest) when calling interceptor")
+					}
+					return c.callGetChangelog(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ChangelogResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ChangelogResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callGetChangelog(ctx context.Context, in *ChangelogRequest) (*ChangelogResponse, error) {
+	out := new(ChangelogResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[11], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) GetCapabilities(ctx context.Context, in *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetCapabilities")
+	caller := c.callGetCapabilities
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CapabilitiesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CapabilitiesRequest) when calling interceptor")
+					}
+					return c.callGetCapabilities(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CapabilitiesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CapabilitiesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callGetCapabilities(ctx context.Context, in *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	out := new(CapabilitiesResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[12], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) RunDiagnostics(ctx context.Context, in *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "RunDiagnostics")
+	caller := c.callRunDiagnostics
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiagnosticsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiagnosticsRequest) when calling interceptor")
+					}
+					return c.callRunDiagnostics(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiagnosticsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiagnosticsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callRunDiagnostics(ctx context.Context, in *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+	out := new(DiagnosticsResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[13], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) SuspendProcesses(ctx context.Context, in *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "SuspendProcesses")
+	caller := c.callSuspendProcesses
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SuspendProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SuspendProcessesRequest) when calling interceptor")
+					}
+					return c.callSuspendProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SuspendProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SuspendProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callSuspendProcesses(ctx context.Context, in *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+	out := new(SuspendProcessesResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[14], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) ResumeProcesses(ctx context.Context, in *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ResumeProcesses")
+	caller := c.callResumeProcesses
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ResumeProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ResumeProcessesRequest) when calling interceptor")
+					}
+					return c.callResumeProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ResumeProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ResumeProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callResumeProcesses(ctx context.Context, in *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+	out := new(ResumeProcessesResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[15], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) Start(ctx context.Context, in *StartRequest) (*StartResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "Start")
+	caller := c.callStart
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StartRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StartRequest) when calling interceptor")
+					}
+					return c.callStart(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StartResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StartResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callStart(ctx context.Context, in *StartRequest) (*StartResponse, error) {
+	out := new(StartResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[16], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) Stop(ctx context.Context, in *StopRequest) (*StopResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "Stop")
+	caller := c.callStop
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *StopRequest) (*StopResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StopRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StopRequest) when calling interceptor")
+					}
+					return c.callStop(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StopResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StopResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callStop(ctx context.Context, in *StopRequest) (*StopResponse, error) {
+	out := new(StopResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[17], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonProtobufClient) ProbeDNS(ctx context.Context, in *ProbeDNSRequest) (*ProbeDNSResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ProbeDNS")
+	caller := c.callProbeDNS
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ProbeDNSRequest) (*ProbeDNSResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ProbeDNSRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ProbeDNSRequest) when calling interceptor")
+					}
+					return c.callProbeDNS(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ProbeDNSResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ProbeDNSResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonProtobufClient) callProbeDNS(ctx context.Context, in *ProbeDNSRequest) (*ProbeDNSResponse, error) {
+	out := new(ProbeDNSResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[18], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+// ========================
+// ZapretDaemon JSON Client
+// ========================
+
+type zapretDaemonJSONClient struct {
+	client      HTTPClient
+	urls        [19]string
+	interceptor twirp.Interceptor
+	opts        twirp.ClientOptions
+}
+
+// NewZapretDaemonJSONClient creates a JSON client that implements the ZapretDaemon interface.
+// It communicates using JSON and can be configured with a custom HTTPClient.
+func NewZapretDaemonJSONClient(baseURL string, client HTTPClient, opts ...twirp.ClientOption) ZapretDaemon {
+	if c, ok := client.(*http.Client); ok {
+		client = withoutRedirects(c)
+	}
+
+	clientOpts := twirp.ClientOptions{}
+	for _, o := range opts {
+		o(&clientOpts)
+	}
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	literalURLs := false
+	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
+	var pathPrefix string
+	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
+	serviceURL := sanitizeBaseURL(baseURL)
+	serviceURL += baseServicePath(pathPrefix, "daemon", "ZapretDaemon")
+	urls := [19]string{
+		serviceURL + "Restart",
+		serviceURL + "GetStatus",
+		serviceURL + "ListRules",
+		serviceURL + "ExplainDomain",
+		serviceURL + "CheckPort",
+		serviceURL + "InstallStrategy",
+		serviceURL + "GetSnapshot",
+		serviceURL + "GetHostlistStatus",
+		serviceURL + "ValidateStrategy",
+		serviceURL + "ListPayloads",
+		serviceURL + "GetKernelQueues",
+		serviceURL + "GetChangelog",
+		serviceURL + "GetCapabilities",
+		serviceURL + "RunDiagnostics",
+		serviceURL + "SuspendProcesses",
+		serviceURL + "ResumeProcesses",
+		serviceURL + "Start",
+		serviceURL + "Stop",
+		serviceURL + "ProbeDNS",
+	}
+
+	return &zapretDaemonJSONClient{
+		client:      client,
+		urls:        urls,
+		interceptor: twirp.ChainInterceptors(clientOpts.Interceptors...),
+		opts:        clientOpts,
+	}
+}
+
+func (c *zapretDaemonJSONClient) Restart(ctx context.Context, in *RestartRequest) (*RestartResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "Restart")
+	caller := c.callRestart
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *RestartRequest) (*RestartResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*RestartRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*RestartRequest) when calling interceptor")
+					}
+					return c.callRestart(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*RestartResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*RestartResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callRestart(ctx context.Context, in *RestartRequest) (*RestartResponse, error) {
+	out := new(RestartResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[0], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) GetStatus(ctx context.Context, in *StatusRequest) (*StatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetStatus")
+	caller := c.callGetStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StatusRequest) when calling interceptor")
+					}
+					return c.callGetStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callGetStatus(ctx context.Context, in *StatusRequest) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[1], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) ListRules(ctx context.Context, in *ListRulesRequest) (*ListRulesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ListRules")
+	caller := c.callListRules
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListRulesRequest) (*ListRulesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListRulesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListRulesRequest) when calling interceptor")
+					}
+					return c.callListRules(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListRulesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListRulesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callListRules(ctx context.Context, in *ListRulesRequest) (*ListRulesResponse, error) {
+	out := new(ListRulesResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[2], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) ExplainDomain(ctx context.Context, in *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ExplainDomain")
+	caller := c.callExplainDomain
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ExplainDomainRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ExplainDomainRequest) when calling interceptor")
+					}
+					return c.callExplainDomain(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ExplainDomainResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ExplainDomainResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callExplainDomain(ctx context.Context, in *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+	out := new(ExplainDomainResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[3], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) CheckPort(ctx context.Context, in *CheckPortRequest) (*CheckPortResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "CheckPort")
+	caller := c.callCheckPort
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *CheckPortRequest) (*CheckPortResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CheckPortRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CheckPortRequest) when calling interceptor")
+					}
+					return c.callCheckPort(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CheckPortResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CheckPortResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callCheckPort(ctx context.Context, in *CheckPortRequest) (*CheckPortResponse, error) {
+	out := new(CheckPortResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[4], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) InstallStrategy(ctx context.Context, in *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "InstallStrategy")
+	caller := c.callInstallStrategy
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*InstallStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*InstallStrategyRequest) when calling interceptor")
+					}
+					return c.callInstallStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*InstallStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*InstallStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callInstallStrategy(ctx context.Context, in *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+	out := new(InstallStrategyResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[5], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) GetSnapshot(ctx context.Context, in *SnapshotRequest) (*SnapshotResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetSnapshot")
+	caller := c.callGetSnapshot
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SnapshotRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SnapshotRequest) when calling interceptor")
+					}
+					return c.callGetSnapshot(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SnapshotResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SnapshotResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callGetSnapshot(ctx context.Context, in *SnapshotRequest) (*SnapshotResponse, error) {
+	out := new(SnapshotResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[6], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) GetHostlistStatus(ctx context.Context, in *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetHostlistStatus")
+	caller := c.callGetHostlistStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*HostlistStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*HostlistStatusRequest) when calling interceptor")
+					}
+					return c.callGetHostlistStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*HostlistStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*HostlistStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callGetHostlistStatus(ctx context.Context, in *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+	out := new(HostlistStatusResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[7], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) ValidateStrategy(ctx context.Context, in *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ValidateStrategy")
+	caller := c.callValidateStrategy
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ValidateStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ValidateStrategyRequest) when calling interceptor")
+					}
+					return c.callValidateStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ValidateStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ValidateStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callValidateStrategy(ctx context.Context, in *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+	out := new(ValidateStrategyResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[8], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) ListPayloads(ctx context.Context, in *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ListPayloads")
+	caller := c.callListPayloads
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListPayloadsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListPayloadsRequest) when calling interceptor")
+					}
+					return c.callListPayloads(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListPayloadsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListPayloadsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callListPayloads(ctx context.Context, in *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+	out := new(ListPayloadsResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[9], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) GetKernelQueues(ctx context.Context, in *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetKernelQueues")
+	caller := c.callGetKernelQueues
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*KernelQueuesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*KernelQueuesRequest) when calling interceptor")
+					}
+					return c.callGetKernelQueues(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*KernelQueuesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*KernelQueuesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callGetKernelQueues(ctx context.Context, in *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+	out := new(KernelQueuesResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[10], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) GetChangelog(ctx context.Context, in *ChangelogRequest) (*ChangelogResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetChangelog")
+	caller := c.callGetChangelog
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ChangelogRequest) (*ChangelogResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ChangelogRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ChangelogRequest) when calling interceptor")
+					}
+					return c.callGetChangelog(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ChangelogResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ChangelogResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callGetChangelog(ctx context.Context, in *ChangelogRequest) (*ChangelogResponse, error) {
+	out := new(ChangelogResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[11], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) GetCapabilities(ctx context.Context, in *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "GetCapabilities")
+	caller := c.callGetCapabilities
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CapabilitiesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CapabilitiesRequest) when calling interceptor")
+					}
+					return c.callGetCapabilities(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CapabilitiesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CapabilitiesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callGetCapabilities(ctx context.Context, in *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	out := new(CapabilitiesResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[12], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) RunDiagnostics(ctx context.Context, in *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "RunDiagnostics")
+	caller := c.callRunDiagnostics
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiagnosticsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiagnosticsRequest) when calling interceptor")
+					}
+					return c.callRunDiagnostics(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiagnosticsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiagnosticsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callRunDiagnostics(ctx context.Context, in *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+	out := new(DiagnosticsResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[13], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) SuspendProcesses(ctx context.Context, in *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "SuspendProcesses")
+	caller := c.callSuspendProcesses
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SuspendProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SuspendProcessesRequest) when calling interceptor")
+					}
+					return c.callSuspendProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SuspendProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SuspendProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callSuspendProcesses(ctx context.Context, in *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+	out := new(SuspendProcessesResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[14], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) ResumeProcesses(ctx context.Context, in *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ResumeProcesses")
+	caller := c.callResumeProcesses
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ResumeProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ResumeProcessesRequest) when calling interceptor")
+					}
+					return c.callResumeProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ResumeProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ResumeProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callResumeProcesses(ctx context.Context, in *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+	out := new(ResumeProcessesResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[15], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) Start(ctx context.Context, in *StartRequest) (*StartResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "Start")
+	caller := c.callStart
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StartRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StartRequest) when calling interceptor")
+					}
+					return c.callStart(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StartResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StartResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callStart(ctx context.Context, in *StartRequest) (*StartResponse, error) {
+	out := new(StartResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[16], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) Stop(ctx context.Context, in *StopRequest) (*StopResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "Stop")
+	caller := c.callStop
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *StopRequest) (*StopResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StopRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StopRequest) when calling interceptor")
+					}
+					return c.callStop(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StopResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StopResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callStop(ctx context.Context, in *StopRequest) (*StopResponse, error) {
+	out := new(StopResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[17], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *zapretDaemonJSONClient) ProbeDNS(ctx context.Context, in *ProbeDNSRequest) (*ProbeDNSResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithMethodName(ctx, "ProbeDNS")
+	caller := c.callProbeDNS
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ProbeDNSRequest) (*ProbeDNSResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ProbeDNSRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ProbeDNSRequest) when calling interceptor")
+					}
+					return c.callProbeDNS(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ProbeDNSResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ProbeDNSResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *zapretDaemonJSONClient) callProbeDNS(ctx context.Context, in *ProbeDNSRequest) (*ProbeDNSResponse, error) {
+	out := new(ProbeDNSResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[18], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+// ===========================
+// ZapretDaemon Server Handler
+// ===========================
+
+type zapretDaemonServer struct {
+	ZapretDaemon
+	interceptor      twirp.Interceptor
+	hooks            *twirp.ServerHooks
+	pathPrefix       string // prefix for routing
+	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
+	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
+}
+
+// NewZapretDaemonServer builds a TwirpServer that can be used as an http.Handler to handle
+// HTTP requests that are routed to the right method in the provided svc implementation.
+// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
+func NewZapretDaemonServer(svc ZapretDaemon, opts ...interface{}) TwirpServer {
+	serverOpts := newServerOpts(opts)
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	jsonSkipDefaults := false
+	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
+	jsonCamelCase := false
+	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
+	var pathPrefix string
+	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	return &zapretDaemonServer{
+		ZapretDaemon:     svc,
+		hooks:            serverOpts.Hooks,
+		interceptor:      twirp.ChainInterceptors(serverOpts.Interceptors...),
+		pathPrefix:       pathPrefix,
+		jsonSkipDefaults: jsonSkipDefaults,
+		jsonCamelCase:    jsonCamelCase,
+	}
+}
+
+// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
+// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
+func (s *zapretDaemonServer) writeError(ctx context.Context, resp http.ResponseWriter, err error) {
+	writeError(ctx, resp, err, s.hooks)
+}
+
+// handleRequestBodyError is used to handle error when the twirp server cannot read request
+func (s *zapretDaemonServer) handleRequestBodyError(ctx context.Context, resp http.ResponseWriter, msg string, err error) {
+	if context.Canceled == ctx.Err() {
+		s.writeError(ctx, resp, twirp.NewError(twirp.Canceled, "failed to read request: context canceled"))
+		return
+	}
+	if context.DeadlineExceeded == ctx.Err() {
+		s.writeError(ctx, resp, twirp.NewError(twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+		return
+	}
+	s.writeError(ctx, resp, twirp.WrapError(malformedRequestError(msg), err))
+}
+
+// ZapretDaemonPathPrefix is a convenience constant that may identify URL paths.
+// Should be used with caution, it only matches routes generated by Twirp Go clients,
+// with the default "/twirp" prefix and default CamelCase service and method names.
+// More info: https://twitchtv.github.io/twirp/docs/routing.html
+const ZapretDaemonPathPrefix = "/twirp/daemon.ZapretDaemon/"
+
+func (s *zapretDaemonServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	ctx = ctxsetters.WithPackageName(ctx, "daemon")
+	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
+	ctx = ctxsetters.WithResponseWriter(ctx, resp)
+
+	var err error
+	ctx, err = callRequestReceived(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	if req.Method != "POST" {
+		msg := fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
+	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
+	if pkgService != "daemon.ZapretDaemon" {
+		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+	if prefix != s.pathPrefix {
+		msg := fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	switch method {
+	case "Restart":
+		s.serveRestart(ctx, resp, req)
+		return
+	case "GetStatus":
+		s.serveGetStatus(ctx, resp, req)
+		return
+	case "ListRules":
+		s.serveListRules(ctx, resp, req)
+		return
+	case "ExplainDomain":
+		s.serveExplainDomain(ctx, resp, req)
+		return
+	case "CheckPort":
+		s.serveCheckPort(ctx, resp, req)
+		return
+	case "InstallStrategy":
+		s.serveInstallStrategy(ctx, resp, req)
+		return
+	case "GetSnapshot":
+		s.serveGetSnapshot(ctx, resp, req)
+		return
+	case "GetHostlistStatus":
+		s.serveGetHostlistStatus(ctx, resp, req)
+		return
+	case "ValidateStrategy":
+		s.serveValidateStrategy(ctx, resp, req)
+		return
+	case "ListPayloads":
+		s.serveListPayloads(ctx, resp, req)
+		return
+	case "GetKernelQueues":
+		s.serveGetKernelQueues(ctx, resp, req)
+		return
+	case "GetChangelog":
+		s.serveGetChangelog(ctx, resp, req)
+		return
+	case "GetCapabilities":
+		s.serveGetCapabilities(ctx, resp, req)
+		return
+	case "RunDiagnostics":
+		s.serveRunDiagnostics(ctx, resp, req)
+		return
+	case "SuspendProcesses":
+		s.serveSuspendProcesses(ctx, resp, req)
+		return
+	case "ResumeProcesses":
+		s.serveResumeProcesses(ctx, resp, req)
+		return
+	case "Start":
+		s.serveStart(ctx, resp, req)
+		return
+	case "Stop":
+		s.serveStop(ctx, resp, req)
+		return
+	case "ProbeDNS":
+		s.serveProbeDNS(ctx, resp, req)
+		return
+	default:
+		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+}
+
+func (s *zapretDaemonServer) serveRestart(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveRestartJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveRestartProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveRestartJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "Restart")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(RestartRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.Restart
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *RestartRequest) (*RestartResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*RestartRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*RestartRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.Restart(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*RestartResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*RestartResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *RestartResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *RestartResponse and nil error while calling Restart. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveRestartProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "Restart")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(RestartRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.Restart
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *RestartRequest) (*RestartResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*RestartRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*RestartRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.Restart(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*RestartResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*RestartResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *RestartResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *RestartResponse and nil error while calling Restart. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetStatusJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetStatusProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveGetStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(StatusRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.GetStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StatusRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *StatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *StatusResponse and nil error while calling GetStatus. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(StatusRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.GetStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StatusRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *StatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *StatusResponse and nil error while calling GetStatus. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveListRules(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveListRulesJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveListRulesProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveListRulesJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListRules")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ListRulesRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.ListRules
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListRulesRequest) (*ListRulesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListRulesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListRulesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ListRules(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListRulesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListRulesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListRulesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListRulesResponse and nil error while calling ListRules. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveListRulesProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListRules")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ListRulesRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.ListRules
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListRulesRequest) (*ListRulesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListRulesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListRulesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ListRules(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListRulesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListRulesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListRulesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListRulesResponse and nil error while calling ListRules. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveExplainDomain(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveExplainDomainJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveExplainDomainProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveExplainDomainJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ExplainDomain")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ExplainDomainRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.ExplainDomain
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ExplainDomainRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ExplainDomainRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ExplainDomain(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ExplainDomainResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ExplainDomainResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ExplainDomainResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ExplainDomainResponse and nil error while calling ExplainDomain. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveExplainDomainProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ExplainDomain")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ExplainDomainRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.ExplainDomain
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ExplainDomainRequest) (*ExplainDomainResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ExplainDomainRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ExplainDomainRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ExplainDomain(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ExplainDomainResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ExplainDomainResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ExplainDomainResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ExplainDomainResponse and nil error while calling ExplainDomain. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveCheckPort(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveCheckPortJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveCheckPortProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveCheckPortJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "CheckPort")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(CheckPortRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.CheckPort
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *CheckPortRequest) (*CheckPortResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CheckPortRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CheckPortRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.CheckPort(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CheckPortResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CheckPortResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *CheckPortResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *CheckPortResponse and nil error while calling CheckPort. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveCheckPortProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "CheckPort")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(CheckPortRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.CheckPort
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *CheckPortRequest) (*CheckPortResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CheckPortRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CheckPortRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.CheckPort(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CheckPortResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CheckPortResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *CheckPortResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *CheckPortResponse and nil error while calling CheckPort. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveInstallStrategy(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveInstallStrategyJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveInstallStrategyProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveInstallStrategyJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "InstallStrategy")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(InstallStrategyRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.InstallStrategy
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*InstallStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*InstallStrategyRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.InstallStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*InstallStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*InstallStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *InstallStrategyResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *InstallStrategyResponse and nil error while calling InstallStrategy. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveInstallStrategyProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "InstallStrategy")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(InstallStrategyRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.InstallStrategy
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *InstallStrategyRequest) (*InstallStrategyResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*InstallStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*InstallStrategyRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.InstallStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*InstallStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*InstallStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *InstallStrategyResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *InstallStrategyResponse and nil error while calling InstallStrategy. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetSnapshot(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetSnapshotJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetSnapshotProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveGetSnapshotJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetSnapshot")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(SnapshotRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.GetSnapshot
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SnapshotRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SnapshotRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetSnapshot(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SnapshotResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SnapshotResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *SnapshotResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *SnapshotResponse and nil error while calling GetSnapshot. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetSnapshotProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetSnapshot")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(SnapshotRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.GetSnapshot
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *SnapshotRequest) (*SnapshotResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SnapshotRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SnapshotRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetSnapshot(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SnapshotResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SnapshotResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *SnapshotResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *SnapshotResponse and nil error while calling GetSnapshot. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetHostlistStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetHostlistStatusJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetHostlistStatusProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveGetHostlistStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetHostlistStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(HostlistStatusRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.GetHostlistStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*HostlistStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*HostlistStatusRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetHostlistStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*HostlistStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*HostlistStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *HostlistStatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *HostlistStatusResponse and nil error while calling GetHostlistStatus. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetHostlistStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetHostlistStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(HostlistStatusRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.GetHostlistStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *HostlistStatusRequest) (*HostlistStatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*HostlistStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*HostlistStatusRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetHostlistStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*HostlistStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*HostlistStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *HostlistStatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *HostlistStatusResponse and nil error while calling GetHostlistStatus. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveValidateStrategy(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveValidateStrategyJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveValidateStrategyProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveValidateStrategyJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ValidateStrategy")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ValidateStrategyRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.ValidateStrategy
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ValidateStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ValidateStrategyRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ValidateStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ValidateStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ValidateStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ValidateStrategyResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ValidateStrategyResponse and nil error while calling ValidateStrategy. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveValidateStrategyProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ValidateStrategy")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ValidateStrategyRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.ValidateStrategy
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ValidateStrategyRequest) (*ValidateStrategyResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ValidateStrategyRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ValidateStrategyRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ValidateStrategy(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ValidateStrategyResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ValidateStrategyResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ValidateStrategyResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ValidateStrategyResponse and nil error while calling ValidateStrategy. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveListPayloads(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveListPayloadsJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveListPayloadsProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveListPayloadsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListPayloads")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ListPayloadsRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.ListPayloads
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListPayloadsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListPayloadsRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ListPayloads(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListPayloadsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListPayloadsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListPayloadsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListPayloadsResponse and nil error while calling ListPayloads. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveListPayloadsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListPayloads")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ListPayloadsRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.ListPayloads
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListPayloadsRequest) (*ListPayloadsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListPayloadsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListPayloadsRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ListPayloads(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListPayloadsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListPayloadsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListPayloadsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListPayloadsResponse and nil error while calling ListPayloads. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetKernelQueues(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetKernelQueuesJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetKernelQueuesProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveGetKernelQueuesJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetKernelQueues")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(KernelQueuesRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.GetKernelQueues
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*KernelQueuesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*KernelQueuesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetKernelQueues(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*KernelQueuesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*KernelQueuesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *KernelQueuesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *KernelQueuesResponse and nil error while calling GetKernelQueues. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetKernelQueuesProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetKernelQueues")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(KernelQueuesRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.GetKernelQueues
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *KernelQueuesRequest) (*KernelQueuesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*KernelQueuesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*KernelQueuesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetKernelQueues(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*KernelQueuesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*KernelQueuesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *KernelQueuesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *KernelQueuesResponse and nil error while calling GetKernelQueues. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetChangelog(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetChangelogJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetChangelogProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveGetChangelogJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetChangelog")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ChangelogRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.GetChangelog
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ChangelogRequest) (*ChangelogResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ChangelogRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ChangelogRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetChangelog(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ChangelogResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ChangelogResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ChangelogResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ChangelogResponse and nil error while calling GetChangelog. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetChangelogProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetChangelog")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ChangelogRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.GetChangelog
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ChangelogRequest) (*ChangelogResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ChangelogRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ChangelogRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetChangelog(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ChangelogResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ChangelogResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ChangelogResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ChangelogResponse and nil error while calling GetChangelog. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetCapabilities(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetCapabilitiesJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetCapabilitiesProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveGetCapabilitiesJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetCapabilities")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(CapabilitiesRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.GetCapabilities
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CapabilitiesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CapabilitiesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetCapabilities(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CapabilitiesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CapabilitiesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *CapabilitiesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *CapabilitiesResponse and nil error while calling GetCapabilities. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveGetCapabilitiesProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetCapabilities")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(CapabilitiesRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.GetCapabilities
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*CapabilitiesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*CapabilitiesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.GetCapabilities(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*CapabilitiesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*CapabilitiesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *CapabilitiesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *CapabilitiesResponse and nil error while calling GetCapabilities. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveRunDiagnostics(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveRunDiagnosticsJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveRunDiagnosticsProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveRunDiagnosticsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "RunDiagnostics")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(DiagnosticsRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.RunDiagnostics
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiagnosticsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiagnosticsRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.RunDiagnostics(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiagnosticsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiagnosticsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *DiagnosticsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *DiagnosticsResponse and nil error while calling RunDiagnostics. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveRunDiagnosticsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "RunDiagnostics")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(DiagnosticsRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.RunDiagnostics
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *DiagnosticsRequest) (*DiagnosticsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*DiagnosticsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*DiagnosticsRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.RunDiagnostics(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*DiagnosticsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*DiagnosticsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *DiagnosticsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *DiagnosticsResponse and nil error while calling RunDiagnostics. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveSuspendProcesses(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveSuspendProcessesJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveSuspendProcessesProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveSuspendProcessesJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "SuspendProcesses")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(SuspendProcessesRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.SuspendProcesses
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SuspendProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SuspendProcessesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.SuspendProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SuspendProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SuspendProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *SuspendProcessesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *SuspendProcessesResponse and nil error while calling SuspendProcesses. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveSuspendProcessesProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "SuspendProcesses")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(SuspendProcessesRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.SuspendProcesses
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *SuspendProcessesRequest) (*SuspendProcessesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*SuspendProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*SuspendProcessesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.SuspendProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*SuspendProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*SuspendProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *SuspendProcessesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *SuspendProcessesResponse and nil error while calling SuspendProcesses. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveResumeProcesses(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveResumeProcessesJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveResumeProcessesProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveResumeProcessesJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ResumeProcesses")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ResumeProcessesRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.ZapretDaemon.ResumeProcesses
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ResumeProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ResumeProcessesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ResumeProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ResumeProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ResumeProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ResumeProcessesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ResumeProcessesResponse and nil error while calling ResumeProcesses. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveResumeProcessesProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ResumeProcesses")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ResumeProcessesRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.ZapretDaemon.ResumeProcesses
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ResumeProcessesRequest) (*ResumeProcessesResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ResumeProcessesRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ResumeProcessesRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.ResumeProcesses(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ResumeProcessesResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ResumeProcessesResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ResumeProcessesResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ResumeProcessesResponse and nil error while calling ResumeProcesses. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *zapretDaemonServer) serveStart(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveStartJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveStartProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *zapretDaemonServer) serveStartJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "Start")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(StartRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
 
-func (c *zapretDaemonJSONClient) GetStatus(ctx context.Context, in *StatusRequest) (*StatusResponse, error) {
-	ctx = ctxsetters.WithPackageName(ctx, "daemon")
-	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
-	ctx = ctxsetters.WithMethodName(ctx, "GetStatus")
-	caller := c.callGetStatus
-	if c.interceptor != nil {
-		caller = func(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
-			resp, err := c.interceptor(
+	handler := s.ZapretDaemon.Start
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*StatusRequest)
+					typedReq, ok := req.(*StartRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*StatusRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*StartRequest) when calling interceptor")
 					}
-					return c.callGetStatus(ctx, typedReq)
+					return s.ZapretDaemon.Start(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*StatusResponse)
+				typedResp, ok := resp.(*StartResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*StatusResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*StartResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
 			return nil, err
 		}
 	}
-	return caller(ctx, in)
-}
 
-func (c *zapretDaemonJSONClient) callGetStatus(ctx context.Context, in *StatusRequest) (*StatusResponse, error) {
-	out := new(StatusResponse)
-	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[1], in, out)
+	// Call service method
+	var respContent *StartResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
 	if err != nil {
-		twerr, ok := err.(twirp.Error)
-		if !ok {
-			twerr = twirp.InternalErrorWith(err)
-		}
-		callClientError(ctx, c.opts.Hooks, twerr)
-		return nil, err
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *StartResponse and nil error while calling Start. nil responses are not supported"))
+		return
 	}
 
-	callClientResponseReceived(ctx, c.opts.Hooks)
-
-	return out, nil
-}
-
-// ===========================
-// ZapretDaemon Server Handler
-// ===========================
-
-type zapretDaemonServer struct {
-	ZapretDaemon
-	interceptor      twirp.Interceptor
-	hooks            *twirp.ServerHooks
-	pathPrefix       string // prefix for routing
-	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
-	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
-}
-
-// NewZapretDaemonServer builds a TwirpServer that can be used as an http.Handler to handle
-// HTTP requests that are routed to the right method in the provided svc implementation.
-// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
-func NewZapretDaemonServer(svc ZapretDaemon, opts ...interface{}) TwirpServer {
-	serverOpts := newServerOpts(opts)
+	ctx = callResponsePrepared(ctx, s.hooks)
 
-	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
-	jsonSkipDefaults := false
-	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
-	jsonCamelCase := false
-	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
-	var pathPrefix string
-	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
-		pathPrefix = "/twirp" // default prefix
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
 	}
 
-	return &zapretDaemonServer{
-		ZapretDaemon:     svc,
-		hooks:            serverOpts.Hooks,
-		interceptor:      twirp.ChainInterceptors(serverOpts.Interceptors...),
-		pathPrefix:       pathPrefix,
-		jsonSkipDefaults: jsonSkipDefaults,
-		jsonCamelCase:    jsonCamelCase,
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
 	}
+	callResponseSent(ctx, s.hooks)
 }
 
-// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
-// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
-func (s *zapretDaemonServer) writeError(ctx context.Context, resp http.ResponseWriter, err error) {
-	writeError(ctx, resp, err, s.hooks)
-}
+func (s *zapretDaemonServer) serveStartProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "Start")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
 
-// handleRequestBodyError is used to handle error when the twirp server cannot read request
-func (s *zapretDaemonServer) handleRequestBodyError(ctx context.Context, resp http.ResponseWriter, msg string, err error) {
-	if context.Canceled == ctx.Err() {
-		s.writeError(ctx, resp, twirp.NewError(twirp.Canceled, "failed to read request: context canceled"))
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	if context.DeadlineExceeded == ctx.Err() {
-		s.writeError(ctx, resp, twirp.NewError(twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+	reqContent := new(StartRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
-	s.writeError(ctx, resp, twirp.WrapError(malformedRequestError(msg), err))
-}
 
-// ZapretDaemonPathPrefix is a convenience constant that may identify URL paths.
-// Should be used with caution, it only matches routes generated by Twirp Go clients,
-// with the default "/twirp" prefix and default CamelCase service and method names.
-// More info: https://twitchtv.github.io/twirp/docs/routing.html
-const ZapretDaemonPathPrefix = "/twirp/daemon.ZapretDaemon/"
+	handler := s.ZapretDaemon.Start
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*StartRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*StartRequest) when calling interceptor")
+					}
+					return s.ZapretDaemon.Start(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*StartResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*StartResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
 
-func (s *zapretDaemonServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
-	ctx := req.Context()
-	ctx = ctxsetters.WithPackageName(ctx, "daemon")
-	ctx = ctxsetters.WithServiceName(ctx, "ZapretDaemon")
-	ctx = ctxsetters.WithResponseWriter(ctx, resp)
+	// Call service method
+	var respContent *StartResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
 
-	var err error
-	ctx, err = callRequestReceived(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
 		return
 	}
-
-	if req.Method != "POST" {
-		msg := fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *StartResponse and nil error while calling Start. nil responses are not supported"))
 		return
 	}
 
-	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
-	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
-	if pkgService != "daemon.ZapretDaemon" {
-		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
-		return
-	}
-	if prefix != s.pathPrefix {
-		msg := fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
 		return
 	}
 
-	switch method {
-	case "Restart":
-		s.serveRestart(ctx, resp, req)
-		return
-	case "GetStatus":
-		s.serveGetStatus(ctx, resp, req)
-		return
-	default:
-		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
-		return
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
 	}
+	callResponseSent(ctx, s.hooks)
 }
 
-func (s *zapretDaemonServer) serveRestart(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *zapretDaemonServer) serveStop(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	header := req.Header.Get("Content-Type")
 	i := strings.Index(header, ";")
 	if i == -1 {
@@ -437,9 +5208,9 @@ func (s *zapretDaemonServer) serveRestart(ctx context.Context, resp http.Respons
 	}
 	switch strings.TrimSpace(strings.ToLower(header[:i])) {
 	case "application/json":
-		s.serveRestartJSON(ctx, resp, req)
+		s.serveStopJSON(ctx, resp, req)
 	case "application/protobuf":
-		s.serveRestartProtobuf(ctx, resp, req)
+		s.serveStopProtobuf(ctx, resp, req)
 	default:
 		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
 		twerr := badRouteError(msg, req.Method, req.URL.Path)
@@ -447,9 +5218,9 @@ func (s *zapretDaemonServer) serveRestart(ctx context.Context, resp http.Respons
 	}
 }
 
-func (s *zapretDaemonServer) serveRestartJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *zapretDaemonServer) serveStopJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "Restart")
+	ctx = ctxsetters.WithMethodName(ctx, "Stop")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -462,29 +5233,29 @@ func (s *zapretDaemonServer) serveRestartJSON(ctx context.Context, resp http.Res
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
-	reqContent := new(RestartRequest)
+	reqContent := new(StopRequest)
 	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
 	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
 
-	handler := s.ZapretDaemon.Restart
+	handler := s.ZapretDaemon.Stop
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *RestartRequest) (*RestartResponse, error) {
+		handler = func(ctx context.Context, req *StopRequest) (*StopResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*RestartRequest)
+					typedReq, ok := req.(*StopRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*RestartRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*StopRequest) when calling interceptor")
 					}
-					return s.ZapretDaemon.Restart(ctx, typedReq)
+					return s.ZapretDaemon.Stop(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*RestartResponse)
+				typedResp, ok := resp.(*StopResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*RestartResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*StopResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -493,7 +5264,7 @@ func (s *zapretDaemonServer) serveRestartJSON(ctx context.Context, resp http.Res
 	}
 
 	// Call service method
-	var respContent *RestartResponse
+	var respContent *StopResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -504,7 +5275,7 @@ func (s *zapretDaemonServer) serveRestartJSON(ctx context.Context, resp http.Res
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *RestartResponse and nil error while calling Restart. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *StopResponse and nil error while calling Stop. nil responses are not supported"))
 		return
 	}
 
@@ -530,9 +5301,9 @@ func (s *zapretDaemonServer) serveRestartJSON(ctx context.Context, resp http.Res
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *zapretDaemonServer) serveRestartProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *zapretDaemonServer) serveStopProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "Restart")
+	ctx = ctxsetters.WithMethodName(ctx, "Stop")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -544,28 +5315,28 @@ func (s *zapretDaemonServer) serveRestartProtobuf(ctx context.Context, resp http
 		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	reqContent := new(RestartRequest)
+	reqContent := new(StopRequest)
 	if err = proto.Unmarshal(buf, reqContent); err != nil {
 		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
 
-	handler := s.ZapretDaemon.Restart
+	handler := s.ZapretDaemon.Stop
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *RestartRequest) (*RestartResponse, error) {
+		handler = func(ctx context.Context, req *StopRequest) (*StopResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*RestartRequest)
+					typedReq, ok := req.(*StopRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*RestartRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*StopRequest) when calling interceptor")
 					}
-					return s.ZapretDaemon.Restart(ctx, typedReq)
+					return s.ZapretDaemon.Stop(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*RestartResponse)
+				typedResp, ok := resp.(*StopResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*RestartResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*StopResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -574,7 +5345,7 @@ func (s *zapretDaemonServer) serveRestartProtobuf(ctx context.Context, resp http
 	}
 
 	// Call service method
-	var respContent *RestartResponse
+	var respContent *StopResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -585,7 +5356,7 @@ func (s *zapretDaemonServer) serveRestartProtobuf(ctx context.Context, resp http
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *RestartResponse and nil error while calling Restart. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *StopResponse and nil error while calling Stop. nil responses are not supported"))
 		return
 	}
 
@@ -609,7 +5380,7 @@ func (s *zapretDaemonServer) serveRestartProtobuf(ctx context.Context, resp http
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *zapretDaemonServer) serveGetStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *zapretDaemonServer) serveProbeDNS(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	header := req.Header.Get("Content-Type")
 	i := strings.Index(header, ";")
 	if i == -1 {
@@ -617,9 +5388,9 @@ func (s *zapretDaemonServer) serveGetStatus(ctx context.Context, resp http.Respo
 	}
 	switch strings.TrimSpace(strings.ToLower(header[:i])) {
 	case "application/json":
-		s.serveGetStatusJSON(ctx, resp, req)
+		s.serveProbeDNSJSON(ctx, resp, req)
 	case "application/protobuf":
-		s.serveGetStatusProtobuf(ctx, resp, req)
+		s.serveProbeDNSProtobuf(ctx, resp, req)
 	default:
 		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
 		twerr := badRouteError(msg, req.Method, req.URL.Path)
@@ -627,9 +5398,9 @@ func (s *zapretDaemonServer) serveGetStatus(ctx context.Context, resp http.Respo
 	}
 }
 
-func (s *zapretDaemonServer) serveGetStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *zapretDaemonServer) serveProbeDNSJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "GetStatus")
+	ctx = ctxsetters.WithMethodName(ctx, "ProbeDNS")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -642,29 +5413,29 @@ func (s *zapretDaemonServer) serveGetStatusJSON(ctx context.Context, resp http.R
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
-	reqContent := new(StatusRequest)
+	reqContent := new(ProbeDNSRequest)
 	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
 	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
 
-	handler := s.ZapretDaemon.GetStatus
+	handler := s.ZapretDaemon.ProbeDNS
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+		handler = func(ctx context.Context, req *ProbeDNSRequest) (*ProbeDNSResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*StatusRequest)
+					typedReq, ok := req.(*ProbeDNSRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*StatusRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*ProbeDNSRequest) when calling interceptor")
 					}
-					return s.ZapretDaemon.GetStatus(ctx, typedReq)
+					return s.ZapretDaemon.ProbeDNS(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*StatusResponse)
+				typedResp, ok := resp.(*ProbeDNSResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*StatusResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*ProbeDNSResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -673,7 +5444,7 @@ func (s *zapretDaemonServer) serveGetStatusJSON(ctx context.Context, resp http.R
 	}
 
 	// Call service method
-	var respContent *StatusResponse
+	var respContent *ProbeDNSResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -684,7 +5455,7 @@ func (s *zapretDaemonServer) serveGetStatusJSON(ctx context.Context, resp http.R
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *StatusResponse and nil error while calling GetStatus. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ProbeDNSResponse and nil error while calling ProbeDNS. nil responses are not supported"))
 		return
 	}
 
@@ -710,9 +5481,9 @@ func (s *zapretDaemonServer) serveGetStatusJSON(ctx context.Context, resp http.R
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *zapretDaemonServer) serveGetStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *zapretDaemonServer) serveProbeDNSProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "GetStatus")
+	ctx = ctxsetters.WithMethodName(ctx, "ProbeDNS")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -724,28 +5495,28 @@ func (s *zapretDaemonServer) serveGetStatusProtobuf(ctx context.Context, resp ht
 		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	reqContent := new(StatusRequest)
+	reqContent := new(ProbeDNSRequest)
 	if err = proto.Unmarshal(buf, reqContent); err != nil {
 		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
 
-	handler := s.ZapretDaemon.GetStatus
+	handler := s.ZapretDaemon.ProbeDNS
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+		handler = func(ctx context.Context, req *ProbeDNSRequest) (*ProbeDNSResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*StatusRequest)
+					typedReq, ok := req.(*ProbeDNSRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*StatusRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*ProbeDNSRequest) when calling interceptor")
 					}
-					return s.ZapretDaemon.GetStatus(ctx, typedReq)
+					return s.ZapretDaemon.ProbeDNS(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*StatusResponse)
+				typedResp, ok := resp.(*ProbeDNSResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*StatusResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*ProbeDNSResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -754,7 +5525,7 @@ func (s *zapretDaemonServer) serveGetStatusProtobuf(ctx context.Context, resp ht
 	}
 
 	// Call service method
-	var respContent *StatusResponse
+	var respContent *ProbeDNSResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -765,7 +5536,7 @@ func (s *zapretDaemonServer) serveGetStatusProtobuf(ctx context.Context, resp ht
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *StatusResponse and nil error while calling GetStatus. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ProbeDNSResponse and nil error while calling ProbeDNS. nil responses are not supported"))
 		return
 	}
 
@@ -1370,29 +6141,266 @@ func callClientError(ctx context.Context, h *twirp.ClientHooks, err twirp.Error)
 }
 
 var twirpFileDescriptor0 = []byte{
-	// 380 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x92, 0x51, 0xab, 0xd3, 0x30,
-	0x14, 0xc7, 0xa9, 0xba, 0x5d, 0x17, 0x7b, 0x6f, 0x25, 0xe8, 0x35, 0x5c, 0x10, 0x66, 0x05, 0x99,
-	0x0f, 0x6b, 0xc1, 0xbd, 0x4d, 0x7c, 0x70, 0x88, 0xbe, 0x89, 0x76, 0x3e, 0xed, 0xa5, 0xa4, 0xe9,
-	0x59, 0x0d, 0xb6, 0x4d, 0x97, 0x9c, 0x4c, 0xe6, 0x07, 0xf0, 0xbb, 0xfa, 0x2d, 0x64, 0x49, 0xbb,
-	0x31, 0x7d, 0x3c, 0xbf, 0xfe, 0xca, 0x3f, 0xf9, 0x9f, 0x10, 0xa6, 0x3b, 0x91, 0x96, 0x1c, 0x1a,
-	0xd5, 0xa6, 0x06, 0xf4, 0x5e, 0x0a, 0x48, 0x3a, 0xad, 0x50, 0xd1, 0xb1, 0xa7, 0xf1, 0x2b, 0x72,
-	0x93, 0x81, 0x41, 0xae, 0x31, 0x83, 0x9d, 0x05, 0x83, 0xf4, 0x09, 0x19, 0x6d, 0x95, 0x16, 0xc0,
-	0x82, 0x69, 0x30, 0x7b, 0x98, 0xf9, 0x21, 0xfe, 0x4c, 0xa2, 0x93, 0x67, 0x3a, 0xd5, 0x1a, 0xa0,
-	0x8c, 0x5c, 0x35, 0x60, 0x0c, 0xaf, 0xbc, 0x3a, 0xc9, 0x86, 0x91, 0xbe, 0x20, 0xa1, 0xf6, 0x32,
-	0x94, 0x39, 0x47, 0x76, 0xcf, 0x7d, 0x7e, 0x74, 0x62, 0xef, 0x31, 0x8e, 0xc8, 0xf5, 0x1a, 0x39,
-	0x5a, 0xd3, 0xc7, 0xc6, 0x7f, 0x02, 0x72, 0x33, 0x90, 0x73, 0x80, 0xb6, 0x6d, 0x2b, 0xdb, 0xaa,
-	0x3f, 0xcb, 0x30, 0xd2, 0x97, 0xe4, 0xda, 0xa0, 0xe6, 0x08, 0xd5, 0x21, 0xdf, 0xca, 0x1a, 0xfa,
-	0x84, 0x70, 0x80, 0x1f, 0x65, 0x0d, 0x47, 0x89, 0x0b, 0x94, 0x7b, 0xc8, 0x77, 0x16, 0x2c, 0x18,
-	0x76, 0x7f, 0x1a, 0xcc, 0x46, 0x59, 0xe8, 0xe1, 0x57, 0xc7, 0xe8, 0x6b, 0xf2, 0xb8, 0x97, 0x3a,
-	0xad, 0x04, 0x18, 0x03, 0x86, 0x3d, 0x70, 0x5e, 0xe4, 0xf9, 0x97, 0x01, 0x1f, 0xd5, 0xad, 0xd4,
-	0xf0, 0x93, 0xd7, 0x75, 0x5e, 0x70, 0xf1, 0x03, 0xda, 0x92, 0x8d, 0x5c, 0x6e, 0x34, 0xf0, 0x95,
-	0xc7, 0xf4, 0x39, 0x21, 0xee, 0xaa, 0x39, 0xca, 0x06, 0xd8, 0xd8, 0x49, 0x13, 0x47, 0xbe, 0xc9,
-	0x06, 0xde, 0xfc, 0x0e, 0x48, 0xb8, 0xe1, 0x9d, 0x06, 0xfc, 0xe0, 0xb6, 0x40, 0x97, 0xe4, 0xaa,
-	0x6f, 0x97, 0xde, 0x26, 0x7e, 0x33, 0xc9, 0xe5, 0x5a, 0xee, 0x9e, 0xfd, 0xc7, 0xfb, 0x96, 0x96,
-	0x64, 0xf2, 0x09, 0xd0, 0x57, 0x47, 0x9f, 0x0e, 0xd6, 0x45, 0xb9, 0x77, 0xb7, 0xff, 0x62, 0xff,
-	0xef, 0xea, 0xdd, 0xe6, 0x6d, 0x25, 0xf1, 0xbb, 0x2d, 0x12, 0xa1, 0x9a, 0x74, 0x0d, 0xba, 0x82,
-	0x43, 0x29, 0xab, 0x7a, 0x91, 0xfe, 0x72, 0xc7, 0x9b, 0x97, 0xd2, 0x08, 0xa5, 0xcb, 0xf9, 0x41,
-	0x59, 0xb4, 0x05, 0xcc, 0xdb, 0x2a, 0x3d, 0x3f, 0xa9, 0x62, 0xec, 0xde, 0xd2, 0xe2, 0x6f, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x4c, 0xef, 0xfc, 0x11, 0x67, 0x02, 0x00, 0x00,
+	// 4173 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x5a, 0x4b, 0x73, 0x1c, 0x47,
+	0x72, 0x8e, 0xc1, 0xcc, 0x00, 0x33, 0x35, 0x33, 0x00, 0xd8, 0x04, 0xc1, 0xe6, 0x90, 0x12, 0xa1,
+	0x96, 0x56, 0x24, 0x57, 0x4b, 0x52, 0x0f, 0xef, 0xae, 0x42, 0x1b, 0x1b, 0x36, 0x09, 0x3e, 0x25,
+	0x52, 0x84, 0x1a, 0xd2, 0x1e, 0xd6, 0x8e, 0x68, 0x17, 0xba, 0x6b, 0x66, 0x3a, 0xd0, 0x2f, 0x76,
+	0x55, 0x83, 0x80, 0x2e, 0x0e, 0xff, 0x08, 0x87, 0x7d, 0xf5, 0xd1, 0x07, 0xfb, 0x60, 0x5f, 0x7c,
+	0xf1, 0x75, 0x4f, 0xfe, 0x0f, 0x3e, 0x38, 0x7c, 0xd7, 0x4f, 0x70, 0x64, 0x66, 0x55, 0x75, 0xf7,
+	0x60, 0x40, 0x49, 0xf6, 0x6d, 0xf2, 0xcb, 0xec, 0x7a, 0x64, 0x65, 0xe5, 0xab, 0x86, 0xb9, 0x65,
+	0x11, 0xde, 0x8f, 0xb8, 0x48, 0xf3, 0xec, 0xbe, 0x14, 0xe5, 0x49, 0x1c, 0x8a, 0x7b, 0x45, 0x99,
+	0xab, 0xdc, 0x59, 0x27, 0xd4, 0xfb, 0xf7, 0x0e, 0xdb, 0xf4, 0x85, 0x54, 0xbc, 0x54, 0xbe, 0x78,
+	0x5d, 0x09, 0xa9, 0x9c, 0x1d, 0xd6, 0x9f, 0xe5, 0x65, 0x28, 0xdc, 0xce, 0x5e, 0xe7, 0xf6, 0xc0,
+	0x27, 0xc2, 0x79, 0x87, 0xb1, 0x3c, 0x4b, 0xce, 0x82, 0x84, 0x1f, 0x89, 0xc4, 0x5d, 0xdb, 0xeb,
+	0xdc, 0x1e, 0xfa, 0x43, 0x40, 0x5e, 0x00, 0x60, 0xd9, 0x38, 0xba, 0xdb, 0xad, 0xd9, 0x07, 0x38,
+	0xdd, 0x75, 0x36, 0x24, 0x76, 0x5e, 0x2a, 0xb7, 0x87, 0xdc, 0x01, 0x72, 0xf3, 0x52, 0xd9, 0x6f,
+	0xcb, 0x2a, 0x11, 0xd2, 0xed, 0xef, 0x75, 0x6f, 0xf7, 0xe9, 0x5b, 0x1f, 0x00, 0x67, 0x97, 0xad,
+	0x87, 0x3c, 0xe3, 0xe5, 0x99, 0xbb, 0x8e, 0x0b, 0xd2, 0x94, 0xf7, 0x35, 0xdb, 0xb2, 0x2b, 0x97,
+	0x45, 0x9e, 0x49, 0xe1, 0xb8, 0x6c, 0x23, 0x15, 0x52, 0xf2, 0x39, 0x2d, 0x7e, 0xe8, 0x1b, 0xd2,
+	0x79, 0x8f, 0x8d, 0x4b, 0x12, 0x16, 0x51, 0xc0, 0x95, 0xde, 0xc0, 0xc8, 0x62, 0x0f, 0x94, 0xb7,
+	0xc5, 0x26, 0x87, 0x8a, 0xab, 0x4a, 0x6a, 0x45, 0x78, 0x7f, 0x37, 0x61, 0x9b, 0x06, 0xa9, 0x27,
+	0x28, 0xab, 0x2c, 0x8b, 0xb3, 0xb9, 0xd6, 0x8e, 0x21, 0x9d, 0xf7, 0xd9, 0x44, 0xaa, 0x92, 0x2b,
+	0x31, 0x3f, 0x0b, 0x66, 0x71, 0x22, 0xf4, 0x0c, 0x63, 0x03, 0x3e, 0x89, 0x13, 0x01, 0x42, 0x3c,
+	0x54, 0xf1, 0x89, 0x08, 0x5e, 0x57, 0xa2, 0x12, 0x12, 0x15, 0xd5, 0xf7, 0xc7, 0x04, 0x7e, 0x83,
+	0x98, 0x73, 0x87, 0x6d, 0x6b, 0xa1, 0xa2, 0xcc, 0x43, 0x21, 0xa5, 0x90, 0xa8, 0xb2, 0xbe, 0xbf,
+	0x45, 0xf8, 0x81, 0x81, 0x41, 0x74, 0x16, 0x97, 0xe2, 0x0d, 0x4f, 0x92, 0xe0, 0x88, 0x87, 0xc7,
+	0x22, 0x8b, 0xdc, 0x3e, 0xce, 0xbb, 0x65, 0xf0, 0x87, 0x04, 0x83, 0x92, 0x71, 0xab, 0x81, 0x8a,
+	0x53, 0x81, 0x9a, 0x1c, 0xfa, 0x43, 0x44, 0xbe, 0x8d, 0x53, 0xe1, 0xdc, 0x65, 0x97, 0xed, 0x48,
+	0x09, 0x97, 0x2a, 0xc8, 0x8b, 0x20, 0x95, 0xee, 0xc6, 0x5e, 0xe7, 0x76, 0xc7, 0xb7, 0x93, 0xbc,
+	0xe0, 0x52, 0xbd, 0x2a, 0x5e, 0x4a, 0xe7, 0x23, 0xe6, 0x58, 0xf1, 0x94, 0x9f, 0x6a, 0xe9, 0x01,
+	0x4a, 0xdb, 0xa9, 0x5f, 0xf2, 0x53, 0x14, 0xfe, 0x98, 0xed, 0x2c, 0x72, 0xa9, 0x92, 0x58, 0xaa,
+	0x20, 0xce, 0x22, 0x71, 0x1a, 0x1c, 0x9d, 0x29, 0x21, 0xdd, 0xe1, 0x5e, 0xe7, 0x76, 0xd7, 0x77,
+	0x0c, 0xef, 0x39, 0xb0, 0x1e, 0x02, 0x07, 0xf4, 0x54, 0x88, 0x2c, 0x8a, 0xb3, 0xb9, 0x36, 0x0a,
+	0x46, 0x7a, 0xd2, 0x20, 0xd9, 0xc5, 0xc7, 0x6c, 0x23, 0x9f, 0xcd, 0x92, 0x9c, 0x47, 0xee, 0x68,
+	0xaf, 0x7b, 0x7b, 0xf4, 0xe9, 0xee, 0x3d, 0x32, 0xea, 0x7b, 0xaf, 0x08, 0x7e, 0x12, 0x93, 0xb4,
+	0x11, 0x73, 0xee, 0x32, 0x47, 0xab, 0x34, 0x48, 0x79, 0xc6, 0xe7, 0x22, 0x15, 0x99, 0x72, 0xc7,
+	0xa8, 0x8b, 0x4b, 0x9a, 0xf3, 0xd2, 0x32, 0x9c, 0xfb, 0x0d, 0x9d, 0x34, 0xe4, 0x27, 0x28, 0xef,
+	0xd4, 0xbb, 0xb4, 0x1f, 0xfc, 0x82, 0x6d, 0x56, 0xd9, 0x51, 0x5e, 0x65, 0x91, 0x39, 0xdf, 0x4d,
+	0x34, 0xe6, 0x89, 0x46, 0xf5, 0x01, 0x7f, 0xc0, 0x36, 0x91, 0x1d, 0xa4, 0xbc, 0x20, 0x5b, 0xd9,
+	0x22, 0x5b, 0x41, 0xf4, 0x25, 0x2f, 0xd0, 0x56, 0x6e, 0xb2, 0x11, 0xec, 0x1d, 0x04, 0x94, 0x28,
+	0xdd, 0x6d, 0x14, 0x61, 0x00, 0x3d, 0x41, 0x04, 0x66, 0x23, 0x9e, 0x88, 0xb4, 0x96, 0x2e, 0xa1,
+	0x96, 0x26, 0x06, 0x25, 0x35, 0xdd, 0x62, 0x5b, 0xd6, 0x30, 0x65, 0x5e, 0xc1, 0xc5, 0x76, 0x70,
+	0xac, 0x4d, 0x03, 0x1f, 0x22, 0x0a, 0xf7, 0xbe, 0x58, 0x70, 0x29, 0xdc, 0xcb, 0xc8, 0x26, 0xc2,
+	0xb9, 0xc7, 0x2e, 0xcb, 0x70, 0x21, 0xa2, 0x2a, 0x11, 0x51, 0x90, 0xcf, 0x66, 0x7a, 0xaa, 0x1d,
+	0x9c, 0xea, 0x92, 0x65, 0xbd, 0x9a, 0xcd, 0xcc, 0xa9, 0xec, 0x84, 0x79, 0x36, 0x8b, 0xe7, 0x41,
+	0x91, 0x27, 0x49, 0x10, 0x67, 0x4a, 0x94, 0x27, 0x3c, 0x71, 0xaf, 0x90, 0xd6, 0x88, 0x77, 0x90,
+	0x27, 0xc9, 0x73, 0xcd, 0x81, 0x7d, 0xbc, 0xe1, 0x2a, 0x5c, 0x04, 0x33, 0x9e, 0x24, 0x60, 0xc5,
+	0xee, 0x2e, 0x5e, 0xad, 0x09, 0xa2, 0x4f, 0x34, 0x08, 0x36, 0x21, 0xd2, 0x42, 0x69, 0x37, 0x21,
+	0x94, 0x7b, 0x15, 0xa5, 0xc6, 0x08, 0xfa, 0x84, 0x39, 0xf7, 0xd8, 0x10, 0x66, 0x48, 0xe2, 0x50,
+	0x49, 0xd7, 0x45, 0xab, 0xd8, 0x36, 0x56, 0xb1, 0xaf, 0x19, 0x7e, 0x2d, 0xe2, 0x3c, 0x67, 0x97,
+	0x8f, 0x45, 0x99, 0x89, 0x24, 0x08, 0x79, 0xc1, 0x8f, 0xe2, 0x24, 0x56, 0xb1, 0x90, 0xee, 0x35,
+	0xfc, 0xd2, 0x35, 0x5f, 0x7e, 0x85, 0x22, 0xfb, 0x46, 0xe2, 0xcc, 0x77, 0x8e, 0xdb, 0x48, 0x2c,
+	0x24, 0x18, 0x57, 0x26, 0x54, 0x12, 0x67, 0xc7, 0x41, 0x29, 0xc2, 0x3c, 0xcb, 0x04, 0xac, 0x61,
+	0xba, 0xd7, 0xb9, 0xdd, 0xf3, 0x2f, 0x69, 0x8e, 0x6f, 0x19, 0x78, 0x2c, 0x95, 0x04, 0x83, 0x16,
+	0x51, 0x50, 0x65, 0x2a, 0x4e, 0xdc, 0xeb, 0xfa, 0x58, 0x0c, 0xfc, 0x1d, 0xa0, 0x70, 0xc7, 0x6b,
+	0x41, 0x6d, 0x56, 0x37, 0xd0, 0xac, 0xea, 0x01, 0xb4, 0x61, 0xdd, 0x62, 0x5b, 0x91, 0x98, 0x97,
+	0xbc, 0x21, 0xf9, 0x0e, 0x4a, 0x6e, 0x1a, 0x58, 0x0b, 0x7e, 0xc1, 0xae, 0xe9, 0x6d, 0x93, 0x58,
+	0x50, 0x65, 0xfc, 0x84, 0xc7, 0x09, 0x3f, 0x4a, 0x84, 0xfb, 0x2e, 0xea, 0xf5, 0x2a, 0x09, 0xd0,
+	0x07, 0xdf, 0xd5, 0x6c, 0x38, 0x2e, 0x7d, 0xc0, 0x27, 0xa2, 0x94, 0x71, 0x9e, 0xb9, 0x37, 0x71,
+	0xdd, 0x13, 0x42, 0xff, 0x40, 0x20, 0xba, 0xba, 0xa2, 0x00, 0x97, 0x4f, 0x77, 0xd6, 0xdd, 0xa3,
+	0xe3, 0x42, 0xf0, 0x80, 0x30, 0xe7, 0x13, 0x36, 0xac, 0x7d, 0xdc, 0x7b, 0xa8, 0xf4, 0xcb, 0x46,
+	0xe9, 0xda, 0xcb, 0x3d, 0xcf, 0x66, 0xb9, 0x5f, 0x4b, 0xc1, 0xf4, 0xdf, 0xe7, 0xe9, 0x51, 0x2c,
+	0x64, 0x50, 0x0a, 0x5e, 0x88, 0xc8, 0xf5, 0x50, 0xc5, 0x13, 0x8d, 0xfa, 0x08, 0xd2, 0xe5, 0xd0,
+	0x77, 0x97, 0x2c, 0xf6, 0x7d, 0x73, 0x39, 0x08, 0x25, 0x6b, 0xbd, 0xc3, 0xb6, 0x8d, 0x47, 0xd0,
+	0xa1, 0x40, 0xba, 0x1f, 0xe0, 0x78, 0x5b, 0x1a, 0xd7, 0x21, 0x46, 0x36, 0xf6, 0x5d, 0x0a, 0xf0,
+	0x26, 0xd2, 0xfd, 0x05, 0x4d, 0x4c, 0xa8, 0x4f, 0x20, 0x04, 0x9a, 0x82, 0x97, 0x52, 0x04, 0xa2,
+	0x2c, 0xf3, 0x52, 0xba, 0x1f, 0xa2, 0xd0, 0x08, 0xb1, 0xc7, 0x08, 0x81, 0x2b, 0xc6, 0xbb, 0x15,
+	0x44, 0x79, 0x26, 0xdc, 0x5b, 0xb8, 0xae, 0x21, 0x22, 0x8f, 0xf2, 0x0c, 0x2f, 0x3e, 0xb1, 0x55,
+	0xae, 0x78, 0xe2, 0xde, 0x46, 0x3e, 0x7d, 0xf1, 0x2d, 0x20, 0x5e, 0xc2, 0xb6, 0x97, 0x2d, 0xd2,
+	0x71, 0x58, 0x2f, 0xe3, 0xa9, 0x09, 0x7b, 0xf8, 0x1b, 0x2e, 0xb4, 0x54, 0x5c, 0x99, 0x50, 0x44,
+	0x04, 0x84, 0xd3, 0x34, 0x87, 0x3b, 0xab, 0xa3, 0xb4, 0xa6, 0x00, 0x8f, 0x84, 0xe2, 0x71, 0xa2,
+	0xe3, 0xb3, 0xa6, 0xbc, 0x2f, 0xd9, 0xc0, 0xdc, 0x1c, 0x98, 0xe5, 0x38, 0xce, 0x22, 0x33, 0x0b,
+	0xfc, 0xb6, 0x33, 0xaf, 0x35, 0x66, 0xde, 0x65, 0xeb, 0xa5, 0x48, 0x45, 0x74, 0x66, 0xe6, 0x20,
+	0xca, 0xfb, 0xfb, 0x0e, 0xdb, 0x6c, 0x3b, 0x67, 0xe7, 0x06, 0x1b, 0xa2, 0x8f, 0x98, 0xf1, 0xd0,
+	0xac, 0xbe, 0x06, 0x9c, 0x29, 0x1b, 0xcc, 0x04, 0x57, 0x55, 0x29, 0xa4, 0xbb, 0xb6, 0xd7, 0x85,
+	0xb4, 0xc1, 0xd0, 0xa0, 0xa7, 0x59, 0x7c, 0x1a, 0x84, 0x79, 0x9a, 0xf2, 0x2c, 0xd2, 0x33, 0xb1,
+	0x59, 0x7c, 0xba, 0x4f, 0x08, 0x26, 0x32, 0xf1, 0xa9, 0x88, 0xdc, 0x9e, 0x4e, 0x64, 0x80, 0x00,
+	0x14, 0x8f, 0x46, 0x07, 0x4a, 0x22, 0xbc, 0xff, 0xea, 0xb0, 0xdd, 0xe7, 0x99, 0x54, 0x3c, 0x49,
+	0x0e, 0xb5, 0x5b, 0x34, 0xf9, 0xd0, 0x2a, 0xd5, 0x4e, 0xd9, 0xc0, 0x78, 0x4f, 0xdc, 0xf8, 0xd8,
+	0xb7, 0xb4, 0xf3, 0xe7, 0xac, 0x0f, 0xe1, 0x0c, 0x82, 0x3b, 0x18, 0xf4, 0x1d, 0x63, 0xd0, 0xab,
+	0x87, 0xbf, 0xf7, 0x02, 0x64, 0x1f, 0x67, 0xaa, 0x3c, 0xf3, 0xe9, 0x3b, 0x18, 0x1c, 0x03, 0x3d,
+	0x1c, 0x1d, 0x2d, 0xdd, 0xd2, 0xd3, 0xcf, 0x19, 0xab, 0x3f, 0x70, 0xb6, 0x59, 0xf7, 0x58, 0x9c,
+	0xe9, 0x95, 0xc1, 0x4f, 0xd8, 0xdd, 0x09, 0x4f, 0x2a, 0xa1, 0x57, 0x45, 0xc4, 0x17, 0x6b, 0x9f,
+	0x77, 0xbc, 0xbf, 0x62, 0x57, 0xcf, 0xad, 0xe0, 0x47, 0xd3, 0xa6, 0x5b, 0x6c, 0x2b, 0xa6, 0x8f,
+	0x44, 0x14, 0x14, 0x5c, 0x2d, 0xcc, 0x31, 0x6c, 0x5a, 0xf8, 0x00, 0x50, 0xef, 0x97, 0x6c, 0x1b,
+	0xd6, 0x85, 0xb7, 0xca, 0x28, 0x0e, 0xad, 0x20, 0x8b, 0x44, 0xa9, 0x73, 0x25, 0x4d, 0x79, 0xbf,
+	0x63, 0x97, 0x1a, 0xb2, 0x7a, 0x0d, 0x1f, 0xb2, 0x3e, 0xdd, 0xd3, 0x4e, 0xdb, 0x6b, 0x83, 0x14,
+	0xfa, 0x00, 0x62, 0x7b, 0xff, 0xd2, 0x67, 0x03, 0x83, 0x41, 0x5a, 0x49, 0x91, 0x34, 0xab, 0x52,
+	0x9c, 0xa4, 0xef, 0x0f, 0x10, 0xf8, 0xba, 0x4a, 0x41, 0x8d, 0x98, 0x8d, 0x86, 0xb9, 0xc9, 0x57,
+	0x2d, 0x8d, 0xb1, 0x2e, 0x2f, 0x95, 0xd4, 0x56, 0x43, 0x04, 0x9c, 0x34, 0x2f, 0xe7, 0x52, 0x5f,
+	0x00, 0xfc, 0x0d, 0x56, 0x46, 0x51, 0x33, 0x48, 0xe2, 0x4c, 0xa0, 0xd1, 0xf4, 0x7d, 0x46, 0xd0,
+	0x8b, 0x38, 0xc3, 0xc4, 0x18, 0xd4, 0x19, 0x24, 0x71, 0x1a, 0x2b, 0x4c, 0xac, 0xfa, 0xfe, 0x10,
+	0x90, 0x17, 0x00, 0x80, 0x6e, 0x0b, 0x48, 0xc1, 0x14, 0x25, 0x53, 0x3d, 0xdf, 0x90, 0xb0, 0x06,
+	0xca, 0x83, 0x06, 0x88, 0x13, 0x01, 0x7e, 0x33, 0x8d, 0xa5, 0x84, 0xd4, 0x07, 0x52, 0x03, 0xc8,
+	0x92, 0x40, 0xdf, 0x63, 0x0d, 0x42, 0x6a, 0x80, 0x8e, 0x9e, 0xf6, 0x5d, 0x94, 0x02, 0xf2, 0x7a,
+	0x11, 0x61, 0x86, 0x34, 0xf0, 0x29, 0xb1, 0x38, 0x30, 0xa8, 0xf3, 0x11, 0xbb, 0x64, 0xdd, 0xa0,
+	0xbe, 0x28, 0x12, 0xb3, 0xa5, 0x61, 0x9d, 0xd4, 0xe9, 0xeb, 0x22, 0x75, 0x0a, 0x1b, 0x17, 0x05,
+	0xa4, 0xc8, 0xa0, 0x87, 0x31, 0x4d, 0x6d, 0xc0, 0x07, 0xa0, 0x8f, 0xf7, 0xd8, 0x38, 0xcc, 0xd3,
+	0x82, 0x2b, 0x72, 0x70, 0x3a, 0x1b, 0x1a, 0x11, 0x86, 0x0e, 0x0e, 0x36, 0x46, 0x55, 0xc2, 0x26,
+	0x29, 0x17, 0x09, 0xf8, 0xd0, 0xa6, 0x2b, 0x79, 0xa5, 0x30, 0xe7, 0x19, 0xf8, 0x23, 0x83, 0xbd,
+	0xaa, 0x50, 0x57, 0x59, 0xae, 0x4a, 0x48, 0x01, 0xb6, 0x91, 0x6b, 0x48, 0x70, 0xbe, 0x05, 0x3f,
+	0x03, 0xbf, 0x11, 0xc4, 0x52, 0x56, 0x98, 0xeb, 0xc0, 0xda, 0x26, 0x1a, 0x7d, 0x8e, 0x20, 0xcc,
+	0xa1, 0x53, 0xe7, 0x45, 0x5e, 0x95, 0xd2, 0x75, 0x50, 0x68, 0x44, 0xd8, 0x33, 0x80, 0x70, 0x93,
+	0xcd, 0x7c, 0x06, 0xb3, 0x9d, 0x81, 0x3f, 0x6e, 0x66, 0x32, 0xa0, 0xdf, 0x4c, 0x9c, 0xaa, 0x40,
+	0x95, 0x3c, 0x93, 0xb1, 0x82, 0x20, 0xb7, 0x43, 0xc1, 0x19, 0xe0, 0x6f, 0x2d, 0x8a, 0xde, 0x3e,
+	0x2f, 0x55, 0xc0, 0x93, 0x98, 0x43, 0x0c, 0xbb, 0x42, 0x13, 0x02, 0xf6, 0x80, 0x20, 0xef, 0x1e,
+	0xdb, 0x79, 0x7c, 0x5a, 0x24, 0x3c, 0xce, 0x1e, 0xe5, 0x29, 0x8f, 0xb3, 0xc6, 0xed, 0x88, 0x10,
+	0xd0, 0x77, 0x4e, 0x53, 0xde, 0x97, 0xec, 0xca, 0x92, 0xbc, 0xbe, 0x21, 0x9f, 0xb0, 0x8d, 0x14,
+	0x32, 0x22, 0x7b, 0x47, 0xae, 0x9a, 0x3b, 0xa2, 0x05, 0xab, 0x44, 0xbc, 0x04, 0x01, 0xdf, 0xc8,
+	0x79, 0x31, 0xdb, 0x5a, 0xe2, 0x39, 0x1f, 0xb0, 0x1e, 0x5c, 0x24, 0x9c, 0x74, 0xd5, 0x35, 0x43,
+	0x2e, 0x7a, 0x04, 0x1c, 0x23, 0xc2, 0xab, 0x33, 0x30, 0x43, 0x46, 0x74, 0xa9, 0xb9, 0xcc, 0xb3,
+	0xda, 0xb5, 0x03, 0xe5, 0x3d, 0x64, 0xdb, 0xfb, 0x0b, 0x11, 0x1e, 0x43, 0x45, 0x67, 0xb6, 0xd8,
+	0xbc, 0x81, 0x9d, 0xa5, 0x1b, 0xe8, 0xb0, 0x1e, 0x16, 0x83, 0x6b, 0x78, 0x61, 0xf0, 0xb7, 0xf7,
+	0x8f, 0x1d, 0x76, 0xa9, 0x31, 0x88, 0xde, 0xf7, 0x2e, 0x5b, 0x47, 0xab, 0x8e, 0x8c, 0x1b, 0x21,
+	0xaa, 0x7d, 0xf9, 0xd7, 0x96, 0x2e, 0xff, 0x2f, 0x8d, 0x3b, 0x21, 0x27, 0xbc, 0x63, 0xb3, 0x8a,
+	0xbc, 0x54, 0xfb, 0xf9, 0x89, 0x28, 0xf9, 0x5c, 0x68, 0x97, 0x02, 0x97, 0x44, 0x9c, 0x2a, 0x51,
+	0x66, 0x3c, 0x09, 0xcc, 0xa5, 0xd0, 0x8e, 0x77, 0xdb, 0x30, 0x9e, 0x68, 0xdc, 0xfb, 0xa7, 0x0e,
+	0x1b, 0x37, 0x07, 0xf9, 0x89, 0x0a, 0xbd, 0xc3, 0xd6, 0xa5, 0xe2, 0x73, 0x1d, 0xc6, 0x46, 0x9f,
+	0x5e, 0x6a, 0x2e, 0xe8, 0x10, 0x38, 0xbe, 0x16, 0x00, 0xdd, 0x87, 0x30, 0xb8, 0xa0, 0x98, 0x36,
+	0xf0, 0x0d, 0xd9, 0xd0, 0x44, 0xaf, 0xa5, 0x89, 0xfa, 0x4c, 0xfa, 0xad, 0x33, 0xf9, 0x1b, 0x36,
+	0xb4, 0xc3, 0xaf, 0x0c, 0x63, 0x0e, 0xeb, 0xc9, 0x42, 0x84, 0x26, 0x76, 0xc3, 0x6f, 0x38, 0xb4,
+	0x52, 0xc8, 0x3c, 0x39, 0xb1, 0xf3, 0x5b, 0xba, 0xb9, 0xb4, 0x5e, 0x7b, 0x69, 0x3b, 0xac, 0x5f,
+	0xf2, 0x6c, 0x2e, 0x4c, 0x54, 0x45, 0xc2, 0x7b, 0xc1, 0xb6, 0x0e, 0x33, 0x5e, 0xc8, 0x45, 0xae,
+	0x1a, 0x66, 0x3f, 0x8b, 0x45, 0x12, 0x91, 0x11, 0x0f, 0x7d, 0x4d, 0xc1, 0x4d, 0x12, 0x27, 0x22,
+	0x53, 0x32, 0x90, 0x71, 0x16, 0x52, 0xfc, 0xea, 0xf9, 0x23, 0xc2, 0x0e, 0x01, 0xf2, 0x7e, 0xe8,
+	0xb2, 0xed, 0x7a, 0x38, 0x6d, 0x1d, 0xd7, 0xd8, 0x40, 0xf1, 0x63, 0x91, 0x41, 0x51, 0xaf, 0x83,
+	0x17, 0xd2, 0x0f, 0xa0, 0x18, 0x00, 0x95, 0xaa, 0x4a, 0xe2, 0x60, 0x8d, 0xfa, 0xb0, 0x5d, 0xd4,
+	0xfb, 0x5a, 0xca, 0xf9, 0xb0, 0x6d, 0x33, 0x17, 0x85, 0xa0, 0x76, 0xd6, 0xda, 0xfb, 0x49, 0x59,
+	0xeb, 0x2e, 0x5b, 0x5f, 0x08, 0x9e, 0xa8, 0x85, 0x39, 0x21, 0xa2, 0x9c, 0x5f, 0xb1, 0x0d, 0x93,
+	0x4d, 0xae, 0xe3, 0x40, 0x8e, 0x9d, 0x14, 0x61, 0x1c, 0xc7, 0x88, 0x80, 0x11, 0x91, 0x3e, 0xdc,
+	0x8d, 0xb6, 0x11, 0x3d, 0x06, 0x14, 0x65, 0xb5, 0x80, 0x55, 0x67, 0x10, 0x56, 0xa5, 0xcc, 0x4b,
+	0x77, 0xd0, 0x50, 0xe7, 0x3e, 0x42, 0x94, 0xd0, 0x56, 0x90, 0x6a, 0x49, 0xed, 0xcb, 0x87, 0x26,
+	0x91, 0x27, 0x94, 0xbc, 0xf9, 0xfb, 0x6c, 0x42, 0x8b, 0x0d, 0xb4, 0x8d, 0x51, 0xbd, 0x3c, 0x26,
+	0xd0, 0x47, 0xcc, 0xf9, 0x2d, 0x1b, 0x95, 0x45, 0x18, 0xa4, 0x42, 0x2d, 0xf2, 0x08, 0xca, 0xf5,
+	0x56, 0x3d, 0xee, 0x17, 0xe1, 0x4b, 0xe4, 0x80, 0xe2, 0xa5, 0xcf, 0x4a, 0x43, 0x63, 0x2e, 0x0c,
+	0x1f, 0x16, 0x3c, 0x8b, 0x43, 0x88, 0x4c, 0xb0, 0xca, 0x61, 0x59, 0x84, 0x07, 0x08, 0x78, 0x3f,
+	0x40, 0x7b, 0xaa, 0xf5, 0x35, 0xe6, 0xaf, 0x48, 0x1a, 0xbf, 0x49, 0x14, 0xd9, 0x2d, 0xda, 0x98,
+	0xd4, 0xc6, 0x63, 0x69, 0xf8, 0x46, 0xa7, 0xe3, 0x5d, 0xe4, 0x68, 0x0a, 0x70, 0x3d, 0x73, 0x8f,
+	0x70, 0xa2, 0x60, 0xcf, 0x47, 0x15, 0x44, 0xe9, 0x00, 0xeb, 0x76, 0x6a, 0x4a, 0x75, 0xfc, 0x31,
+	0x81, 0x0f, 0x11, 0x6b, 0x08, 0xa1, 0xc2, 0xe8, 0x04, 0x7b, 0x46, 0x68, 0x1f, 0x31, 0x28, 0x87,
+	0xa3, 0xaa, 0xe4, 0x10, 0x2c, 0x02, 0x59, 0xa5, 0x81, 0x84, 0x0a, 0x30, 0x32, 0x8d, 0x15, 0xc7,
+	0xf0, 0x0e, 0xab, 0xf4, 0x90, 0x38, 0xde, 0x3f, 0x74, 0xd9, 0xa8, 0x61, 0x45, 0x90, 0xe3, 0x15,
+	0x71, 0xa4, 0xb3, 0x1b, 0xf8, 0xf9, 0x76, 0xc7, 0x67, 0xfa, 0x3c, 0xd4, 0xe6, 0xea, 0x36, 0xfa,
+	0x3c, 0xd0, 0xe4, 0x72, 0x6e, 0x53, 0x4d, 0x40, 0x1b, 0x6e, 0x98, 0x1b, 0xd6, 0x79, 0x74, 0x3c,
+	0x24, 0x00, 0x89, 0xb9, 0xad, 0x2f, 0xd1, 0x6a, 0x07, 0x7e, 0x0d, 0x68, 0x2f, 0x41, 0x05, 0x13,
+	0xe5, 0x3c, 0x96, 0x06, 0x9e, 0xa9, 0x37, 0x71, 0x9f, 0x03, 0xdf, 0xd2, 0xcd, 0x06, 0xda, 0xa0,
+	0xdd, 0x40, 0x83, 0xe6, 0x49, 0x01, 0xcd, 0x29, 0xab, 0x23, 0xea, 0x0f, 0x4d, 0x08, 0xd5, 0xea,
+	0xb1, 0x39, 0x1a, 0x6b, 0xe4, 0x68, 0xd7, 0xd9, 0x10, 0x7b, 0x56, 0xe2, 0x34, 0x56, 0x68, 0x43,
+	0x43, 0x7f, 0x00, 0xc0, 0xe3, 0xd3, 0x58, 0x01, 0x33, 0xcf, 0xd3, 0xe0, 0x38, 0x4e, 0x12, 0x89,
+	0xb6, 0xdb, 0xf7, 0x07, 0x79, 0x9e, 0x7e, 0x05, 0xb4, 0xf3, 0x2b, 0xe6, 0xa4, 0x22, 0xcd, 0xcb,
+	0x33, 0x4a, 0xdf, 0x74, 0x63, 0x6a, 0x82, 0x13, 0x6f, 0x13, 0x07, 0xd3, 0x38, 0x6c, 0x4b, 0x79,
+	0xff, 0xda, 0x61, 0xac, 0x56, 0x14, 0xac, 0x38, 0x12, 0xf2, 0x2c, 0x0b, 0x03, 0x28, 0x6a, 0x63,
+	0x1d, 0xa0, 0x7a, 0xfe, 0x84, 0xd0, 0x07, 0x04, 0xe2, 0x05, 0x32, 0xed, 0xaf, 0x45, 0x6c, 0xad,
+	0x73, 0x6c, 0xc0, 0x67, 0xb1, 0x92, 0xce, 0xaf, 0xd9, 0x2e, 0xaf, 0x54, 0x6e, 0x05, 0x79, 0x14,
+	0x61, 0x86, 0x61, 0x2c, 0xf6, 0x4a, 0x93, 0xfb, 0xc0, 0x30, 0xcf, 0x55, 0x9b, 0xbd, 0x73, 0xd5,
+	0xa6, 0x27, 0x19, 0xab, 0x7d, 0x09, 0xa8, 0x0f, 0x1b, 0x80, 0x3a, 0x0a, 0xc0, 0x6f, 0x38, 0x2f,
+	0x55, 0xc6, 0xf3, 0xb9, 0x28, 0x6d, 0x91, 0x65, 0x68, 0x48, 0x7f, 0xad, 0xfd, 0xa6, 0xb4, 0x98,
+	0x8e, 0xcf, 0x0c, 0xf4, 0x52, 0xd6, 0xe5, 0x54, 0xaf, 0x59, 0x4e, 0x05, 0x6c, 0x68, 0x7d, 0x12,
+	0x58, 0xb0, 0x14, 0xaf, 0xb5, 0x72, 0xe0, 0xa7, 0x5d, 0xc5, 0x5a, 0x63, 0x15, 0xa6, 0xb6, 0xec,
+	0x36, 0x6a, 0xcb, 0x46, 0x61, 0xd2, 0x6b, 0x15, 0x26, 0xde, 0x55, 0x76, 0xe5, 0x99, 0xd6, 0x46,
+	0xbb, 0x69, 0xfb, 0x25, 0xdb, 0x5d, 0x66, 0xe8, 0x48, 0xf1, 0x31, 0xdb, 0xa0, 0xb4, 0xdd, 0xe4,
+	0x4f, 0xd6, 0x3f, 0xd9, 0x0f, 0x90, 0xed, 0x1b, 0x31, 0xef, 0x7f, 0x3a, 0x6c, 0xb3, 0xcd, 0x83,
+	0xbd, 0x54, 0xa5, 0xc9, 0x66, 0xe0, 0x27, 0x26, 0x32, 0x5c, 0x2d, 0xcc, 0x5e, 0xe0, 0x37, 0x1c,
+	0x0b, 0x1a, 0xa4, 0xac, 0x42, 0xb8, 0xc7, 0x7a, 0x4f, 0x23, 0xc0, 0x0e, 0x09, 0x82, 0x7b, 0x8a,
+	0x22, 0x4d, 0xe5, 0xa1, 0x15, 0x93, 0xd7, 0x85, 0xc8, 0x1c, 0x7f, 0x4f, 0xe1, 0xb4, 0xeb, 0xe3,
+	0x6f, 0xd0, 0x86, 0xc8, 0x54, 0x19, 0x0b, 0x73, 0xe5, 0x0c, 0x89, 0x65, 0x32, 0x8f, 0x13, 0x2c,
+	0x93, 0x37, 0xc8, 0xc4, 0x0d, 0x0d, 0x6b, 0xc1, 0x5c, 0x96, 0x2b, 0x05, 0xad, 0x32, 0xbc, 0x76,
+	0x43, 0x7f, 0x04, 0xd8, 0x03, 0x82, 0xbc, 0xbf, 0x66, 0x57, 0xff, 0xc0, 0x93, 0x38, 0xe2, 0x4a,
+	0x2c, 0x17, 0xbf, 0xcd, 0x42, 0xb7, 0xb3, 0x54, 0xe8, 0x42, 0xa3, 0x1a, 0x5b, 0x3c, 0x32, 0x4e,
+	0xab, 0x04, 0x0d, 0x42, 0x67, 0x8b, 0x5b, 0x88, 0x1f, 0x5a, 0xd8, 0xfb, 0x53, 0x87, 0xb9, 0xe7,
+	0xa7, 0xd0, 0x07, 0x43, 0x35, 0x6b, 0x6c, 0xf2, 0x3b, 0x22, 0x1a, 0x3e, 0x9b, 0x6c, 0x52, 0x53,
+	0xb0, 0xa2, 0x37, 0xbc, 0x04, 0x97, 0x41, 0x81, 0x7a, 0xe8, 0x5b, 0xba, 0x8e, 0xe0, 0xbd, 0xb7,
+	0x47, 0xf0, 0xcf, 0x19, 0xcb, 0x0b, 0x41, 0x36, 0x4c, 0xce, 0xbd, 0xd1, 0xed, 0x3b, 0x48, 0x78,
+	0x96, 0x89, 0xe8, 0x95, 0x11, 0xf0, 0x1b, 0xb2, 0xde, 0x33, 0xb6, 0xbd, 0xcc, 0x5f, 0xd9, 0x15,
+	0xd9, 0x63, 0xa3, 0x48, 0xc8, 0xb0, 0x8c, 0x0b, 0xab, 0x96, 0xa1, 0xdf, 0x84, 0xbc, 0x2b, 0xec,
+	0x32, 0x54, 0xc1, 0x07, 0x54, 0xc0, 0x58, 0xfb, 0xdd, 0x67, 0x3b, 0x6d, 0x58, 0x2b, 0xe9, 0x23,
+	0x36, 0xd0, 0xb5, 0x8e, 0x31, 0xdf, 0x2d, 0xbb, 0x60, 0xc2, 0x7d, 0x2b, 0x00, 0x8e, 0x6a, 0x43,
+	0xa3, 0xd6, 0x3e, 0x3b, 0x0d, 0xfb, 0x34, 0x2b, 0x5e, 0x6b, 0xf7, 0x71, 0xd0, 0xe2, 0xba, 0x0d,
+	0x8b, 0xdb, 0x65, 0xeb, 0x72, 0xc1, 0x3f, 0xfd, 0xf5, 0x6f, 0x4c, 0x4f, 0x88, 0x28, 0xb0, 0xa9,
+	0x46, 0x51, 0x6c, 0xde, 0x6c, 0x46, 0x75, 0x55, 0x2c, 0x6d, 0xae, 0x4a, 0x61, 0xb1, 0xaf, 0x73,
+	0x55, 0xcc, 0x6e, 0x8b, 0x32, 0x3f, 0x4a, 0x44, 0x8a, 0x96, 0x3a, 0xf4, 0x0d, 0x09, 0x0a, 0xf9,
+	0xaa, 0xd1, 0x73, 0x6c, 0x28, 0xa4, 0x0d, 0x5b, 0x85, 0x98, 0x09, 0x3a, 0xed, 0x14, 0xac, 0x21,
+	0x6d, 0x66, 0xf5, 0xfe, 0xb9, 0xcb, 0x46, 0x0d, 0x1c, 0x4c, 0x0e, 0x39, 0x3a, 0xac, 0xf6, 0x5f,
+	0x1b, 0xb4, 0xf9, 0xbc, 0x45, 0xc4, 0x72, 0x07, 0xa0, 0x7b, 0xae, 0x03, 0x80, 0x2d, 0x2c, 0xdd,
+	0x0d, 0xd1, 0x79, 0x71, 0x0d, 0x60, 0x99, 0x0f, 0x09, 0x83, 0x8e, 0xa1, 0x44, 0xc0, 0xa0, 0x85,
+	0x10, 0x25, 0x3e, 0x88, 0xc5, 0x11, 0xde, 0xe7, 0x89, 0xcf, 0x00, 0x3a, 0x40, 0xc4, 0x84, 0xfd,
+	0x8d, 0x3a, 0xec, 0xef, 0xb2, 0xf5, 0x44, 0x64, 0x73, 0xb5, 0xc0, 0x2b, 0xdc, 0xf7, 0x35, 0x05,
+	0x01, 0x2e, 0xcc, 0x8b, 0xb3, 0x20, 0xcd, 0x23, 0xa1, 0x53, 0xb8, 0x01, 0x00, 0x2f, 0xf3, 0x08,
+	0xbb, 0x13, 0xc8, 0xa4, 0xe4, 0x9c, 0x9e, 0x51, 0x50, 0xdc, 0x07, 0x00, 0x4e, 0x23, 0x2a, 0x73,
+	0x28, 0xee, 0x75, 0xee, 0x65, 0x48, 0x38, 0xe2, 0x4a, 0x8a, 0x32, 0x30, 0xec, 0x31, 0x45, 0x16,
+	0xc0, 0x1e, 0x69, 0x91, 0xf7, 0xd9, 0x04, 0xb8, 0x32, 0x98, 0x97, 0xf9, 0x1b, 0x88, 0xe8, 0x13,
+	0x2a, 0xa5, 0x11, 0x7c, 0x4a, 0x98, 0xae, 0x01, 0xe1, 0x80, 0xe9, 0x35, 0x64, 0xe8, 0x5b, 0x1a,
+	0x66, 0xaf, 0xb2, 0xe3, 0x2c, 0x7f, 0x93, 0xe9, 0x6e, 0x80, 0x21, 0xbd, 0xbf, 0x84, 0x6a, 0x12,
+	0x56, 0x98, 0xe4, 0xf3, 0xc6, 0xbb, 0x24, 0x95, 0x06, 0x64, 0xc9, 0x44, 0xc0, 0xee, 0xf9, 0x4c,
+	0x89, 0x32, 0x80, 0x10, 0xa3, 0xf3, 0x3e, 0x04, 0x0e, 0xc5, 0x6b, 0x3c, 0x50, 0x6c, 0xcb, 0xd0,
+	0xa1, 0x11, 0xe1, 0xfd, 0x2d, 0x96, 0x99, 0x76, 0xf4, 0x3a, 0x3c, 0x18, 0xef, 0xba, 0x14, 0x1e,
+	0xac, 0x2c, 0x75, 0xe9, 0x8c, 0x18, 0x94, 0x1e, 0xe8, 0x59, 0xeb, 0x99, 0x37, 0x80, 0x86, 0x89,
+	0x6f, 0xb2, 0x51, 0xb8, 0xe0, 0x71, 0xa6, 0xdd, 0xbb, 0xee, 0x4d, 0x22, 0x84, 0xfe, 0xdd, 0xfb,
+	0x8f, 0x2e, 0xdb, 0x6c, 0x8f, 0xfb, 0x13, 0xc3, 0xe4, 0xb9, 0x77, 0xc6, 0xee, 0xea, 0x77, 0x46,
+	0x2b, 0xb4, 0xe0, 0x72, 0xe1, 0xf6, 0xda, 0x42, 0xcf, 0xb8, 0x5c, 0xfc, 0x9c, 0xc7, 0xc3, 0x8f,
+	0x8c, 0x5f, 0xa5, 0x22, 0xe5, 0xca, 0x39, 0xcd, 0x80, 0x83, 0xad, 0xcb, 0xe9, 0x3e, 0x8f, 0x28,
+	0xf7, 0x7b, 0x9b, 0x30, 0xca, 0x38, 0xf7, 0xa1, 0x00, 0x4a, 0x73, 0x28, 0x36, 0x07, 0x6f, 0x13,
+	0x37, 0x52, 0xb0, 0x6a, 0xbb, 0xb5, 0x10, 0x45, 0x22, 0x34, 0xfa, 0x81, 0x6f, 0x9f, 0xb9, 0xe8,
+	0xcb, 0x88, 0x9a, 0xfb, 0xe2, 0x24, 0xce, 0x2b, 0x69, 0x37, 0x48, 0x69, 0xe3, 0x96, 0xc1, 0xcd,
+	0x06, 0xaf, 0x43, 0x49, 0x27, 0x4e, 0x48, 0x59, 0x23, 0xd3, 0xaa, 0x10, 0x27, 0xa8, 0x28, 0x87,
+	0xf5, 0x10, 0xa7, 0xc2, 0x07, 0x7f, 0x7b, 0x8a, 0x4d, 0x5a, 0x0b, 0x7c, 0x6b, 0xaf, 0xc3, 0x76,
+	0x1b, 0xd7, 0x9a, 0xdd, 0x46, 0xeb, 0x83, 0xba, 0x4d, 0x1f, 0x04, 0xf6, 0x5c, 0xce, 0x65, 0xf3,
+	0xd8, 0x06, 0x00, 0xc0, 0x4a, 0xc0, 0x45, 0x36, 0xdf, 0x9c, 0x8c, 0x8b, 0xfc, 0xd3, 0x1a, 0xdb,
+	0x69, 0xe3, 0xda, 0xa6, 0x61, 0x51, 0x09, 0x57, 0xb3, 0xbc, 0x4c, 0xed, 0xa2, 0x34, 0xed, 0x7c,
+	0xb6, 0xd4, 0x5a, 0x6f, 0xf4, 0x93, 0xf6, 0xf3, 0xb4, 0x88, 0x13, 0x11, 0x3d, 0x21, 0x7e, 0xa3,
+	0xe7, 0x7e, 0xc1, 0x7b, 0x59, 0xf7, 0xff, 0xf0, 0x5e, 0xf6, 0x1b, 0xc6, 0x8a, 0x32, 0x3e, 0x89,
+	0x13, 0x31, 0xb7, 0x01, 0x7b, 0xb7, 0x2e, 0xa3, 0x35, 0x07, 0xdb, 0x41, 0x7e, 0x43, 0xd2, 0xb9,
+	0xc5, 0xfa, 0xd9, 0xec, 0xf5, 0x1b, 0x89, 0xb6, 0xda, 0xa8, 0x81, 0xbf, 0x06, 0x90, 0x82, 0x3c,
+	0xf2, 0x9d, 0x4f, 0x18, 0x93, 0xd5, 0x91, 0x3c, 0x93, 0x4a, 0xa4, 0xc6, 0x72, 0xad, 0xf4, 0xa1,
+	0xe1, 0xf8, 0x0d, 0x21, 0xef, 0x1b, 0xb6, 0xb5, 0xb4, 0xf7, 0x9f, 0xf3, 0xe4, 0xa1, 0x9f, 0x4f,
+	0xba, 0xad, 0xe7, 0x93, 0x17, 0x6c, 0xb3, 0xbd, 0x99, 0x95, 0x8d, 0x98, 0x4d, 0xb6, 0x96, 0x1f,
+	0xeb, 0xe4, 0x69, 0x2d, 0x3f, 0xbe, 0x70, 0xb4, 0xff, 0xec, 0xb0, 0xa1, 0xdd, 0x28, 0x48, 0x1d,
+	0xc5, 0xf8, 0xcf, 0x08, 0x5d, 0x0a, 0x13, 0xb5, 0x32, 0xbc, 0xbf, 0xc7, 0xc6, 0x39, 0x26, 0x1e,
+	0x54, 0xad, 0x6a, 0xa3, 0x1b, 0x11, 0x86, 0xc5, 0x2a, 0x55, 0x7c, 0x05, 0x18, 0x27, 0xc6, 0xb1,
+	0x2e, 0x56, 0x8e, 0x06, 0x80, 0x8c, 0xa6, 0xca, 0x6a, 0x7e, 0x1f, 0xf9, 0x4d, 0xa8, 0x2e, 0x05,
+	0xd6, 0x1b, 0xa5, 0x00, 0xd8, 0xa0, 0x69, 0xa2, 0x99, 0x6a, 0xd0, 0xd0, 0xde, 0x37, 0x6c, 0x68,
+	0x0f, 0x62, 0xa5, 0x5e, 0x30, 0xe5, 0x85, 0x67, 0x47, 0xdb, 0x87, 0xd4, 0xe4, 0x85, 0x1a, 0xda,
+	0x61, 0xce, 0xa3, 0x98, 0xcf, 0xb3, 0x5c, 0xaa, 0x38, 0xb4, 0x37, 0xe4, 0x09, 0xbb, 0xdc, 0x42,
+	0xf5, 0xfd, 0xb8, 0xcf, 0xd6, 0x43, 0x38, 0x93, 0xf3, 0x1d, 0x55, 0x2b, 0x4c, 0x06, 0xa8, 0xc5,
+	0xbc, 0x8a, 0x6d, 0x2d, 0xb1, 0x7e, 0xc6, 0xcb, 0x5b, 0xa3, 0x9a, 0xe9, 0xb6, 0x9f, 0x59, 0xde,
+	0x05, 0x53, 0x9d, 0xcf, 0x85, 0xc4, 0x64, 0x91, 0x6e, 0x7d, 0x03, 0xf1, 0x3e, 0x63, 0x57, 0x0f,
+	0xa9, 0xf4, 0xb6, 0xff, 0xfd, 0x30, 0x51, 0xd1, 0x65, 0x1b, 0x10, 0x17, 0xa0, 0xa3, 0x6e, 0xda,
+	0x5f, 0x44, 0x7a, 0xdf, 0x31, 0xf7, 0xfc, 0x47, 0x7a, 0xe3, 0x37, 0x9a, 0x2d, 0xac, 0x8e, 0x7e,
+	0x81, 0x34, 0x00, 0xf8, 0xa0, 0x52, 0xc8, 0x2a, 0x15, 0xf5, 0x3f, 0x65, 0x06, 0x04, 0x3c, 0x50,
+	0x9e, 0xcb, 0x76, 0x7d, 0xfc, 0xbd, 0xbc, 0x14, 0xef, 0xb7, 0xec, 0xea, 0x39, 0xce, 0x4f, 0x99,
+	0xcf, 0xdb, 0x64, 0xe3, 0xc3, 0xc6, 0x3f, 0x90, 0xbc, 0x7d, 0x36, 0xd1, 0xf4, 0x8f, 0x3e, 0x50,
+	0x35, 0xfa, 0x09, 0x6b, 0xad, 0x7e, 0x82, 0x37, 0x61, 0xa3, 0x43, 0x95, 0x17, 0x66, 0xcc, 0x87,
+	0x6c, 0x4c, 0xe4, 0xff, 0x63, 0xc8, 0x3b, 0x6c, 0xeb, 0xa0, 0xcc, 0x8f, 0xc4, 0xa3, 0xaf, 0x0f,
+	0x7f, 0xac, 0x8b, 0xff, 0x6f, 0x6b, 0x6c, 0xbb, 0x96, 0xad, 0x3b, 0xd9, 0xab, 0x84, 0x61, 0xc6,
+	0x13, 0x51, 0x46, 0x71, 0x68, 0xb4, 0x6d, 0xc8, 0x8b, 0xac, 0x1c, 0x13, 0x70, 0xbc, 0x35, 0xd0,
+	0x28, 0x28, 0xa5, 0xbe, 0xad, 0x23, 0xc2, 0x1e, 0x00, 0xd4, 0x10, 0x69, 0x3e, 0x77, 0x6a, 0x11,
+	0x2a, 0x32, 0xaf, 0xb3, 0x61, 0x94, 0x2f, 0xf4, 0x10, 0xeb, 0x94, 0x9c, 0x45, 0xf9, 0x82, 0xbe,
+	0xd7, 0x4c, 0xfa, 0x98, 0x52, 0x75, 0x60, 0xd2, 0x97, 0x37, 0xd9, 0xe8, 0x28, 0x9f, 0x57, 0x52,
+	0x7f, 0x3b, 0xc0, 0x6f, 0x19, 0x42, 0xf4, 0xb5, 0xbd, 0x01, 0xc3, 0xe6, 0x0d, 0x68, 0xdb, 0x39,
+	0x5b, 0xb6, 0xf3, 0x4f, 0xff, 0x9b, 0xb1, 0xf1, 0x1f, 0x79, 0x51, 0x0a, 0xf5, 0x08, 0xef, 0xa1,
+	0xf3, 0x05, 0xdb, 0xd0, 0x0f, 0xf0, 0x4e, 0xdd, 0x4d, 0x6c, 0xfd, 0x5d, 0x6d, 0x7a, 0xf5, 0x1c,
+	0xae, 0xb5, 0xfd, 0x05, 0x1b, 0x3e, 0x15, 0xba, 0x09, 0xe0, 0x5c, 0x59, 0xee, 0xfd, 0xd2, 0xc7,
+	0x17, 0xb4, 0x84, 0x9d, 0xbf, 0x60, 0x43, 0xfb, 0x44, 0xe9, 0xd8, 0xb8, 0xb6, 0xfc, 0xc2, 0x39,
+	0xbd, 0xb6, 0x82, 0xa3, 0x47, 0x78, 0xc1, 0x26, 0xad, 0x67, 0x1c, 0xe7, 0x86, 0x6d, 0xd6, 0xae,
+	0x78, 0x0d, 0x9a, 0xbe, 0x73, 0x01, 0xb7, 0x5e, 0x8f, 0x7d, 0x18, 0xa9, 0xd7, 0xb3, 0xfc, 0xe0,
+	0x32, 0xbd, 0xb6, 0x82, 0xa3, 0x47, 0xf0, 0xd9, 0xd6, 0xd2, 0xf3, 0xaf, 0xf3, 0xee, 0xdb, 0x5f,
+	0xa6, 0xa7, 0x37, 0x2f, 0xe4, 0xdb, 0x55, 0x8d, 0x40, 0xc3, 0xba, 0x25, 0xef, 0xd8, 0x93, 0x58,
+	0xea, 0xf9, 0x4f, 0xdd, 0xf3, 0x0c, 0xbb, 0xaa, 0x4b, 0x4f, 0x85, 0x6a, 0x37, 0x6c, 0x9c, 0x77,
+	0xce, 0xf5, 0x65, 0x5a, 0x67, 0xf6, 0xee, 0x45, 0x6c, 0x3d, 0xe6, 0x77, 0x6c, 0x7b, 0xb9, 0xd5,
+	0xe0, 0xd8, 0xad, 0x5c, 0xd0, 0xe7, 0x98, 0xee, 0x5d, 0x2c, 0xa0, 0x87, 0x7d, 0xce, 0xc6, 0xcd,
+	0xc2, 0xdc, 0xb9, 0xde, 0x3c, 0xfb, 0xa5, 0x2a, 0x7e, 0x7a, 0x63, 0x35, 0xd3, 0xda, 0xc6, 0xd6,
+	0x53, 0xa1, 0x9a, 0x55, 0x6d, 0x3d, 0xda, 0x8a, 0x12, 0x78, 0x7a, 0x63, 0x35, 0x53, 0x8f, 0xb6,
+	0xcf, 0xc6, 0x4f, 0x85, 0xb2, 0xd9, 0x68, 0xd3, 0x3c, 0xda, 0x15, 0xd4, 0xf4, 0xda, 0x0a, 0x4e,
+	0x6b, 0x49, 0xad, 0x04, 0xcd, 0x2e, 0x69, 0x45, 0xca, 0x39, 0xbd, 0xb1, 0x9a, 0x69, 0x75, 0xb5,
+	0xe9, 0x57, 0x59, 0x23, 0xe2, 0x3a, 0xd3, 0xf3, 0x91, 0xd5, 0x8e, 0x75, 0x7d, 0x25, 0xaf, 0x3e,
+	0xcd, 0xe5, 0x28, 0x56, 0x9f, 0xe6, 0x05, 0x41, 0x71, 0xba, 0x77, 0xb1, 0x40, 0x7d, 0x1d, 0x96,
+	0x62, 0x55, 0x7d, 0x1d, 0x56, 0x87, 0xb7, 0xe9, 0xcd, 0x0b, 0xf9, 0x7a, 0xcc, 0x3f, 0x63, 0x7d,
+	0x0c, 0x5b, 0xce, 0x4e, 0xc3, 0xab, 0xd4, 0x97, 0xf3, 0xca, 0x12, 0x6a, 0x9f, 0x75, 0x7b, 0x10,
+	0x98, 0x9c, 0xcb, 0x35, 0xdb, 0x46, 0xad, 0xe9, 0x4e, 0x1b, 0xd4, 0x9f, 0xfc, 0x9e, 0x0d, 0x4c,
+	0x6c, 0xa9, 0x2f, 0xdd, 0x52, 0x64, 0x9a, 0xba, 0xe7, 0x19, 0xf4, 0xf9, 0xc3, 0xdf, 0xff, 0xf1,
+	0x77, 0xf3, 0x58, 0x2d, 0xaa, 0xa3, 0x7b, 0x61, 0x9e, 0xde, 0x3f, 0x14, 0xe5, 0x5c, 0x9c, 0x45,
+	0xf1, 0x3c, 0xf9, 0xec, 0xfe, 0xf7, 0xe8, 0x7b, 0xef, 0x46, 0xb1, 0x0c, 0xf3, 0x32, 0xba, 0x7b,
+	0x96, 0x57, 0xaa, 0x3a, 0x12, 0x77, 0xb3, 0xf9, 0xfd, 0xfa, 0x8f, 0xc4, 0x47, 0xeb, 0x58, 0xd7,
+	0x7c, 0xf6, 0xbf, 0x03, 0x00, 0x65, 0x7f, 0xb1, 0x1f, 0x5d, 0x2c, 0x00, 0x00,
 }